@@ -0,0 +1,96 @@
+package accounting
+
+import "math"
+
+// epsilon absorbs the float error of summed sizes
+const epsilon = 1e-9
+
+// Method is how the cost of a position is tracked
+type Method string
+
+const (
+	// MethodFIFO closes the oldest lots first
+	MethodFIFO Method = "fifo"
+	// MethodAverage keeps one lot at the average entry price, like the exchange
+	MethodAverage Method = "average"
+)
+
+// Lot is an open part of a position, with a signed size
+type Lot struct {
+	Time int64
+	Px   float64
+	Sz   float64
+}
+
+// book is the position and realized profit of a coin
+type book struct {
+	method   Method
+	lots     []Lot
+	realized float64
+}
+
+// position returns the signed size of the open lots
+func (b *book) position() float64 {
+	var szi float64
+	for _, l := range b.lots {
+		szi += l.Sz
+	}
+	return szi
+}
+
+// entryPx returns the average price of the open lots, 0 when flat
+func (b *book) entryPx() float64 {
+	var szi, cost float64
+	for _, l := range b.lots {
+		szi += l.Sz
+		cost += l.Sz * l.Px
+	}
+	if math.Abs(szi) < epsilon {
+		return 0
+	}
+	return cost / szi
+}
+
+// unrealized returns the profit of the open lots at mark
+func (b *book) unrealized(mark float64) float64 {
+	var pnl float64
+	for _, l := range b.lots {
+		pnl += l.Sz * (mark - l.Px)
+	}
+	return pnl
+}
+
+// trade applies a trade of the signed size sz at px, returning the profit it realizes
+func (b *book) trade(time int64, px, sz float64) float64 {
+	var realized float64
+	for len(b.lots) > 0 && math.Abs(sz) > epsilon && b.lots[0].Sz*sz < 0 {
+		lot := &b.lots[0]
+		closed := math.Min(math.Abs(sz), math.Abs(lot.Sz))
+		if lot.Sz < 0 {
+			closed = -closed
+		}
+		realized += closed * (px - lot.Px)
+		lot.Sz -= closed
+		sz += closed
+		if math.Abs(lot.Sz) < epsilon {
+			b.lots = b.lots[1:]
+		}
+	}
+	if math.Abs(sz) > epsilon {
+		b.open(Lot{Time: time, Px: px, Sz: sz})
+	}
+	b.realized += realized
+	return realized
+}
+
+// open adds a lot in the direction of the position
+func (b *book) open(lot Lot) {
+	if b.method != MethodAverage || len(b.lots) == 0 {
+		b.lots = append(b.lots, lot)
+		return
+	}
+	avg := &b.lots[0]
+	szi := avg.Sz + lot.Sz
+	avg.Px = (avg.Sz*avg.Px + lot.Sz*lot.Px) / szi
+	avg.Sz = szi
+}
@@ -0,0 +1,53 @@
+package accounting
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBookMethods(t *testing.T) {
+	type trade struct{ px, sz float64 }
+	tests := []struct {
+		name       string
+		method     Method
+		trades     []trade
+		realized   float64
+		lots       []Lot
+		unrealized float64
+	}{
+		{"fifo closes the oldest lot", MethodFIFO, []trade{{100, 1}, {110, 1}, {120, -1}}, 20, []Lot{{Time: 1, Px: 110, Sz: 1}}, 20},
+		{"average closes at the entry price", MethodAverage, []trade{{100, 1}, {110, 1}, {120, -1}}, 15, []Lot{{Time: 0, Px: 105, Sz: 1}}, 25},
+		{"fifo flips", MethodFIFO, []trade{{100, 1}, {110, 1}, {120, -1}, {100, -2}}, 10, []Lot{{Time: 3, Px: 100, Sz: -1}}, -30},
+		{"average flips", MethodAverage, []trade{{100, 1}, {110, 1}, {120, -1}, {100, -2}}, 10, []Lot{{Time: 3, Px: 100, Sz: -1}}, -30},
+		{"short", MethodFIFO, []trade{{100, -1}, {90, 0.5}}, 5, []Lot{{Time: 0, Px: 100, Sz: -0.5}}, -15},
+		{"flat", MethodAverage, []trade{{100, 0.3}, {101, -0.1}, {102, -0.2}}, 0.5, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &book{method: tt.method}
+			for i, tr := range tt.trades {
+				b.trade(int64(i), tr.px, tr.sz)
+			}
+			if math.Abs(b.realized-tt.realized) > 1e-9 {
+				t.Errorf("b.realized = %v, want %v", b.realized, tt.realized)
+			}
+			if len(b.lots) != len(tt.lots) {
+				t.Errorf("len(b.lots) = %d, want %d", len(b.lots), len(tt.lots))
+			}
+			for i, lot := range tt.lots {
+				if b.lots[i].Time != lot.Time {
+					t.Errorf("b.lots[i].Time = %v, want %v", b.lots[i].Time, lot.Time)
+				}
+				if math.Abs(b.lots[i].Px-lot.Px) > 1e-9 {
+					t.Errorf("b.lots[i].Px = %v, want %v", b.lots[i].Px, lot.Px)
+				}
+				if math.Abs(b.lots[i].Sz-lot.Sz) > 1e-9 {
+					t.Errorf("b.lots[i].Sz = %v, want %v", b.lots[i].Sz, lot.Sz)
+				}
+			}
+			if got := b.unrealized(130); math.Abs(got-tt.unrealized) > 1e-9 {
+				t.Errorf("unrealized(130) = %v, want %v", got, tt.unrealized)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package accounting
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// UnknownBuilder groups the builder fees of orders whose builder was not set with
+// SetBuilder
+const UnknownBuilder = "unknown"
+
+// FeeTotals are the fees of a set of fills, in USDC
+type FeeTotals struct {
+	Fills int
+	// Volume is the notional traded
+	Volume float64
+	// Fees are the fees paid and Rebates the rebates earned, both positive
+	Fees    float64
+	Rebates float64
+	// BuilderFees is the part of Fees paid to builders
+	BuilderFees float64
+	// Net is Fees less Rebates, negative when the rebates earned exceed the fees paid
+	Net float64
+}
+
+// Bps returns the net fee rate in basis points of the volume
+func (t FeeTotals) Bps() float64 {
+	if t.Volume == 0 {
+		return 0
+	}
+	return t.Net / t.Volume * 1e4
+}
+
+// FeeReport is the fees of an account, in total and broken down by coin, by maker
+// and taker fills, and by builder. Builders only counts the fills paying a builder fee.
+type FeeReport struct {
+	Total    FeeTotals
+	Maker    FeeTotals
+	Taker    FeeTotals
+	Coins    map[string]FeeTotals
+	Builders map[string]FeeTotals
+}
+
+// FeeTracker aggregates the fees paid and rebates earned by fills. A fill added twice
+// is ignored. Safe for concurrent use.
+type FeeTracker struct {
+	mu       sync.Mutex
+	fills    map[fillKey]bool
+	records  []feeRecord
+	builders map[int64]string
+}
+
+// feeRecord is what a fill adds to the fees
+type feeRecord struct {
+	coin       string
+	oid        int64
+	taker      bool
+	volume     float64
+	fee        float64
+	builderFee float64
+}
+
+// NewFeeTracker creates a tracker without fills
+func NewFeeTracker() *FeeTracker {
+	return &FeeTracker{
+		fills:    make(map[fillKey]bool),
+		builders: make(map[int64]string),
+	}
+}
+
+// SetBuilder attributes the builder fees of the order oid to builder, e.g. the address
+// of the BuilderInfo the order was placed with. It applies to fills added before too.
+func (t *FeeTracker) SetBuilder(oid int64, builder string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.builders[oid] = builder
+}
+
+// AddFills adds fills, see AddFill
+func (t *FeeTracker) AddFills(fills []types.Fill) error {
+	for _, fill := range fills {
+		if _, err := t.AddFill(fill); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddFill adds a fill, returning false if it was already added. Crossed fills are
+// taker fills, the others maker fills. Fees paid in another token than USDC are
+// valued at the fill price.
+func (t *FeeTracker) AddFill(fill types.Fill) (bool, error) {
+	px, err := fill.PxFloat()
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill price: %w", err)
+	}
+	sz, err := fill.SzFloat()
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill size: %w", err)
+	}
+	fee, err := parseOptional(fill.Fee)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill fee: %w", err)
+	}
+	var builderFee float64
+	if fill.BuilderFee != nil {
+		if builderFee, err = parseOptional(*fill.BuilderFee); err != nil {
+			return false, fmt.Errorf("failed to parse fill builder fee: %w", err)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := fillKey{tid: fill.Tid, oid: fill.Oid}
+	if t.fills[key] {
+		return false, nil
+	}
+	t.fills[key] = true
+	t.records = append(t.records, feeRecord{
+		coin:       fill.Coin,
+		oid:        fill.Oid,
+		taker:      fill.Crossed,
+		volume:     sz * px,
+		fee:        quoteFee(fee, px, fill.FeeToken),
+		builderFee: quoteFee(builderFee, px, fill.FeeToken),
+	})
+	return true, nil
+}
+
+// Report returns the fees of the fills added
+func (t *FeeTracker) Report() FeeReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	report := FeeReport{
+		Coins:    make(map[string]FeeTotals),
+		Builders: make(map[string]FeeTotals),
+	}
+	for _, r := range t.records {
+		report.Total.add(r)
+		if r.taker {
+			report.Taker.add(r)
+		} else {
+			report.Maker.add(r)
+		}
+		coin := report.Coins[r.coin]
+		coin.add(r)
+		report.Coins[r.coin] = coin
+		if r.builderFee != 0 {
+			name, ok := t.builders[r.oid]
+			if !ok {
+				name = UnknownBuilder
+			}
+			builder := report.Builders[name]
+			builder.add(r)
+			report.Builders[name] = builder
+		}
+	}
+	return report
+}
+
+// add adds the fees of a fill
+func (t *FeeTotals) add(r feeRecord) {
+	t.Fills++
+	t.Volume += r.volume
+	if r.fee >= 0 {
+		t.Fees += r.fee
+	} else {
+		t.Rebates -= r.fee
+	}
+	t.BuilderFees += r.builderFee
+	t.Net += r.fee
+}
@@ -0,0 +1,131 @@
+package accounting
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+func TestFeeTracker(t *testing.T) {
+	builderFee := "0.2"
+	fills := []types.Fill{
+		// Taker fills, one of them through a builder
+		{Coin: "ETH", Tid: 1, Oid: 10, Px: "2000", Sz: "1", Fee: "0.9", FeeToken: "USDC", Crossed: true},
+		{Coin: "ETH", Tid: 2, Oid: 11, Px: "2000", Sz: "1", Fee: "1.1", FeeToken: "USDC", Crossed: true, BuilderFee: &builderFee},
+		// A maker rebate
+		{Coin: "BTC", Tid: 3, Oid: 12, Px: "50000", Sz: "0.1", Fee: "-0.05", FeeToken: "USDC"},
+		// A spot buy paying its fee in the base token
+		{Coin: "@1", Tid: 4, Oid: 13, Px: "25", Sz: "10", Fee: "0.01", FeeToken: "PURR", Crossed: true, BuilderFee: &builderFee},
+	}
+	tracker := NewFeeTracker()
+	tracker.SetBuilder(11, "0xbuilder")
+	if err := tracker.AddFills(fills); err != nil {
+		t.Fatalf("AddFills(fills) error = %v", err)
+	}
+	added, err := tracker.AddFill(fills[0])
+	if err != nil {
+		t.Fatalf("AddFill(fills[0]) error = %v", err)
+	}
+	if added {
+		t.Error("added = true")
+	}
+
+	report := tracker.Report()
+	if report.Total.Fills != 4 {
+		t.Errorf("report.Total.Fills = %v, want 4", report.Total.Fills)
+	}
+	if math.Abs(report.Total.Volume-9250) > 1e-9 {
+		t.Errorf("report.Total.Volume = %v, want %v", report.Total.Volume, 9250)
+	}
+	if math.Abs(report.Total.Fees-2.25) > 1e-9 {
+		t.Errorf("report.Total.Fees = %v, want %v", report.Total.Fees, 2.25)
+	}
+	if math.Abs(report.Total.Rebates-0.05) > 1e-9 {
+		t.Errorf("report.Total.Rebates = %v, want %v", report.Total.Rebates, 0.05)
+	}
+	if math.Abs(report.Total.Net-2.2) > 1e-9 {
+		t.Errorf("report.Total.Net = %v, want %v", report.Total.Net, 2.2)
+	}
+	if math.Abs(report.Total.BuilderFees-5.2) > 1e-9 {
+		t.Errorf("report.Total.BuilderFees = %v, want %v", report.Total.BuilderFees, 5.2)
+	}
+
+	if report.Taker.Fills != 3 {
+		t.Errorf("report.Taker.Fills = %v, want 3", report.Taker.Fills)
+	}
+	if math.Abs(report.Taker.Net-2.25) > 1e-9 {
+		t.Errorf("report.Taker.Net = %v, want %v", report.Taker.Net, 2.25)
+	}
+	if report.Maker.Fills != 1 {
+		t.Errorf("report.Maker.Fills = %v, want 1", report.Maker.Fills)
+	}
+	if math.Abs(report.Maker.Net-(-0.05)) > 1e-9 {
+		t.Errorf("report.Maker.Net = %v, want %v", report.Maker.Net, -0.05)
+	}
+	if got := report.Maker.Bps(); math.Abs(got-(-0.1)) > 1e-9 {
+		t.Errorf("Bps() = %v, want %v", got, -0.1)
+	}
+
+	if len(report.Coins) != 3 {
+		t.Fatalf("len(report.Coins) = %d, want 3", len(report.Coins))
+	}
+	if math.Abs(report.Coins["ETH"].Net-2) > 1e-9 {
+		t.Errorf("report.Coins[ETH].Net = %v, want %v", report.Coins["ETH"].Net, 2)
+	}
+	if got := report.Coins["ETH"].Bps(); math.Abs(got-5) > 1e-9 {
+		t.Errorf("Bps() = %v, want %v", got, 5)
+	}
+	if math.Abs(report.Coins["@1"].Fees-0.25) > 1e-9 {
+		t.Errorf("report.Coins[@1].Fees = %v, want %v", report.Coins["@1"].Fees, 0.25)
+	}
+
+	if len(report.Builders) != 2 {
+		t.Fatalf("len(report.Builders) = %d, want 2", len(report.Builders))
+	}
+	if report.Builders["0xbuilder"].Fills != 1 {
+		t.Errorf("report.Builders[0xbuilder].Fills = %v, want 1", report.Builders["0xbuilder"].Fills)
+	}
+	if math.Abs(report.Builders["0xbuilder"].BuilderFees-0.2) > 1e-9 {
+		t.Errorf("report.Builders[0xbuilder].BuilderFees = %v, want %v", report.Builders["0xbuilder"].BuilderFees, 0.2)
+	}
+	if math.Abs(report.Builders[UnknownBuilder].BuilderFees-5) > 1e-9 {
+		t.Errorf("report.Builders[UnknownBuilder].BuilderFees = %v, want %v", report.Builders[UnknownBuilder].BuilderFees, 5)
+	}
+
+	// Builders set after the fills apply to them too
+	tracker.SetBuilder(13, "0xother")
+	report = tracker.Report()
+	if _, ok := report.Builders[UnknownBuilder]; ok {
+		t.Errorf("report.Builders = %+v, want no unknown builder", report.Builders)
+	}
+	if math.Abs(report.Builders["0xother"].BuilderFees-5) > 1e-9 {
+		t.Errorf("report.Builders[0xother].BuilderFees = %v, want %v", report.Builders["0xother"].BuilderFees, 5)
+	}
+}
+
+func TestFeeTrackerErrors(t *testing.T) {
+	tracker := NewFeeTracker()
+	bad := "x"
+	for _, f := range []types.Fill{
+		{Px: "x", Sz: "1"},
+		{Px: "1", Sz: "x"},
+		{Px: "1", Sz: "1", Fee: "x"},
+		{Px: "1", Sz: "1", BuilderFee: &bad},
+	} {
+		_, err := tracker.AddFill(f)
+		if err == nil {
+			t.Error("AddFill(f) error = nil, want error")
+		}
+	}
+	if err := tracker.AddFills([]types.Fill{{Px: "x"}}); err == nil {
+		t.Error("AddFills() error = nil, want error")
+	}
+	if got := tracker.Report().Total; !reflect.ValueOf(got).IsZero() {
+		t.Errorf("tracker.Report().Total = %+v, want zero", got)
+	}
+	if got := (FeeTotals{}.Bps()); got != 0 {
+		t.Errorf("Bps() = %v, want 0", got)
+	}
+}
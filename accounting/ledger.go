@@ -0,0 +1,277 @@
+// Package accounting keeps the books of an account from its fills and funding
+// payments: realized and unrealized profit per coin with FIFO or average cost lots,
+// fees and funding apart, so they can be reconciled with the closedPnl of the
+// exchange:
+//
+//	ledger := accounting.NewLedger(accounting.MethodFIFO)
+//	fills, _ := info.UserFills(address)
+//	ledger.AddFills(fills)
+//	for _, fill := range dedupe.Filter(msg) { // fills of the userFills feed
+//	    ledger.AddFill(fill)
+//	}
+//	summary := ledger.Summary(marks)
+//	fmt.Println(summary.Realized, summary.Unrealized, summary.Fees, summary.Funding)
+//
+// FeeTracker breaks the fees of fills down by coin, maker and taker, and builder, and
+// ProjectFeeTier projects the fee tier of an account from its UserFees.
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// QuoteToken is the token in which profits, fees and funding are counted
+const QuoteToken = "USDC"
+
+// CoinPnl is the books of a coin
+type CoinPnl struct {
+	Coin string
+	// Position is the signed size of the open lots, and EntryPx their average price
+	Position float64
+	EntryPx  float64
+	Lots     []Lot
+	// Realized is the profit of the closed lots, before fees and funding
+	Realized float64
+	// Unrealized is the profit of the open lots at the mark price
+	Unrealized float64
+	// Fees are the fees paid, negative for rebates. Fees paid in another token than
+	// USDC, e.g. the base token of spot buys, are valued at the fill price.
+	Fees float64
+	// Funding is the funding received, negative when paid
+	Funding float64
+	// Net is the profit after fees and funding
+	Net float64
+	// ClosedPnl is the sum of the closedPnl of the fills, as computed by the exchange
+	ClosedPnl float64
+	// ExchangePosition is the position after the last fill according to the exchange.
+	// It differs from Position when fills are missing.
+	ExchangePosition float64
+	Fills            int
+}
+
+// Summary is the books of an account
+type Summary struct {
+	Coins      []CoinPnl
+	Realized   float64
+	Unrealized float64
+	Fees       float64
+	Funding    float64
+	Net        float64
+}
+
+// Ledger keeps the books of an account. Fills must be added in the order they
+// happened; a fill or funding payment added twice is ignored. Safe for concurrent use.
+type Ledger struct {
+	method Method
+
+	mu      sync.Mutex
+	books   map[string]*book
+	coins   map[string]*CoinPnl
+	fills   map[fillKey]bool
+	funding map[fundingKey]bool
+}
+
+type fillKey struct {
+	tid int64
+	oid int64
+}
+
+type fundingKey struct {
+	time int64
+	coin string
+}
+
+// NewLedger creates empty books tracking costs with method, MethodFIFO if empty
+func NewLedger(method Method) *Ledger {
+	if method == "" {
+		method = MethodFIFO
+	}
+	return &Ledger{
+		method:  method,
+		books:   make(map[string]*book),
+		coins:   make(map[string]*CoinPnl),
+		fills:   make(map[fillKey]bool),
+		funding: make(map[fundingKey]bool),
+	}
+}
+
+// SetPosition opens the books of coin with a position held before the first fill,
+// e.g. from UserState
+func (l *Ledger) SetPosition(coin string, szi, entryPx float64, time int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.book(coin)
+	b.lots = nil
+	if szi != 0 {
+		b.lots = []Lot{{Time: time, Px: entryPx, Sz: szi}}
+	}
+	l.coin(coin).ExchangePosition = szi
+}
+
+// AddFills adds fills of the REST API, which may be newest first, in the order of their
+// time and trade id
+func (l *Ledger) AddFills(fills []types.Fill) error {
+	sorted := append([]types.Fill(nil), fills...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Time != sorted[j].Time {
+			return sorted[i].Time < sorted[j].Time
+		}
+		return sorted[i].Tid < sorted[j].Tid
+	})
+	for _, fill := range sorted {
+		if _, err := l.AddFill(fill); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddFill adds a fill, returning false if it was already added
+func (l *Ledger) AddFill(fill types.Fill) (bool, error) {
+	px, err := fill.PxFloat()
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill price: %w", err)
+	}
+	sz, err := fill.SzFloat()
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill size: %w", err)
+	}
+	fee, err := parseOptional(fill.Fee)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill fee: %w", err)
+	}
+	closedPnl, err := parseOptional(fill.ClosedPnl)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill closed pnl: %w", err)
+	}
+	start, err := parseOptional(fill.StartPosition)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse fill start position: %w", err)
+	}
+	fee = quoteFee(fee, px, fill.FeeToken)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := fillKey{tid: fill.Tid, oid: fill.Oid}
+	if l.fills[key] {
+		return false, nil
+	}
+	l.fills[key] = true
+
+	signed := sz * float64(fill.Side.Sign())
+	l.book(fill.Coin).trade(fill.Time, px, signed)
+	c := l.coin(fill.Coin)
+	c.Fees += fee
+	c.ClosedPnl += closedPnl
+	c.ExchangePosition = start + signed
+	c.Fills++
+	return true, nil
+}
+
+// AddFunding adds a funding payment of usdc received, negative when paid, returning
+// false if it was already added
+func (l *Ledger) AddFunding(coin string, time int64, usdc float64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := fundingKey{time: time, coin: coin}
+	if l.funding[key] {
+		return false
+	}
+	l.funding[key] = true
+	l.book(coin)
+	l.coin(coin).Funding += usdc
+	return true
+}
+
+// AddWsFunding adds a payment of the userFundings feed, see AddFunding
+func (l *Ledger) AddWsFunding(funding ws.WsUserFunding) (bool, error) {
+	usdc, err := strconv.ParseFloat(funding.Usdc, 64)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse funding payment: %w", err)
+	}
+	return l.AddFunding(funding.Coin, funding.Time, usdc), nil
+}
+
+// Coin returns the books of coin, with open lots marked at mark
+func (l *Ledger) Coin(coin string, mark float64) CoinPnl {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.report(coin, mark)
+}
+
+// Summary returns the books of every coin, sorted by name, with open lots marked at
+// marks. Coins without a mark have no unrealized profit.
+func (l *Ledger) Summary(marks map[string]float64) Summary {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var s Summary
+	for coin := range l.coins {
+		c := l.report(coin, marks[coin])
+		s.Coins = append(s.Coins, c)
+		s.Realized += c.Realized
+		s.Unrealized += c.Unrealized
+		s.Fees += c.Fees
+		s.Funding += c.Funding
+		s.Net += c.Net
+	}
+	sort.Slice(s.Coins, func(i, j int) bool { return s.Coins[i].Coin < s.Coins[j].Coin })
+	return s
+}
+
+// report returns the books of coin. Must be called with mu held.
+func (l *Ledger) report(coin string, mark float64) CoinPnl {
+	c := *l.coin(coin)
+	b := l.book(coin)
+	c.Position = b.position()
+	c.EntryPx = b.entryPx()
+	c.Lots = append([]Lot(nil), b.lots...)
+	c.Realized = b.realized
+	if mark > 0 {
+		c.Unrealized = b.unrealized(mark)
+	}
+	c.Net = c.Realized + c.Unrealized - c.Fees + c.Funding
+	return c
+}
+
+// book returns the book of coin, creating it. Must be called with mu held.
+func (l *Ledger) book(coin string) *book {
+	b, ok := l.books[coin]
+	if !ok {
+		b = &book{method: l.method}
+		l.books[coin] = b
+	}
+	return b
+}
+
+// coin returns the totals of coin, creating them. Must be called with mu held.
+func (l *Ledger) coin(coin string) *CoinPnl {
+	c, ok := l.coins[coin]
+	if !ok {
+		c = &CoinPnl{Coin: coin}
+		l.coins[coin] = c
+	}
+	return c
+}
+
+// quoteFee values a fee paid in token, e.g. the base token of a spot buy, in USDC at
+// the fill price px
+func quoteFee(fee, px float64, token string) float64 {
+	if token != "" && token != QuoteToken {
+		return fee * px
+	}
+	return fee
+}
+
+// parseOptional parses a wire number that may be empty
+func parseOptional(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,186 @@
+package accounting
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+func fill(tid int64, time int64, side types.Side, px, sz, start, fee, closedPnl string) types.Fill {
+	return types.Fill{Coin: "ETH", Tid: tid, Oid: tid, Time: time, Side: side, Px: px, Sz: sz, StartPosition: start, Fee: fee, FeeToken: "USDC", ClosedPnl: closedPnl}
+}
+
+func TestLedger(t *testing.T) {
+	// Fills of the REST API, newest first
+	fills := []types.Fill{
+		fill(3, 3000, types.SideAsk, "2100", "1", "2", "0.9", "150"),
+		fill(2, 2000, types.SideBid, "2000", "1", "1", "0.8", "0"),
+		fill(1, 1000, types.SideBid, "1900", "1", "0", "0.7", "0"),
+	}
+	for _, tt := range []struct {
+		method   Method
+		realized float64
+		entryPx  float64
+	}{
+		{MethodFIFO, 200, 2000},
+		{MethodAverage, 150, 1950},
+	} {
+		t.Run(string(tt.method), func(t *testing.T) {
+			l := NewLedger(tt.method)
+			if err := l.AddFills(fills); err != nil {
+				t.Fatalf("AddFills(fills) error = %v", err)
+			}
+
+			// The ws feed resends fills already added
+			added, err := l.AddFill(fills[0])
+			if err != nil {
+				t.Fatalf("AddFill(fills[0]) error = %v", err)
+			}
+			if added {
+				t.Error("added = true")
+			}
+
+			c := l.Coin("ETH", 2050)
+			if c.Position != 1.0 {
+				t.Errorf("c.Position = %v, want 1.0", c.Position)
+			}
+			if c.EntryPx != tt.entryPx {
+				t.Errorf("c.EntryPx = %v, want %v", c.EntryPx, tt.entryPx)
+			}
+			if math.Abs(c.Realized-tt.realized) > 1e-9 {
+				t.Errorf("c.Realized = %v, want %v", c.Realized, tt.realized)
+			}
+			if math.Abs(c.Unrealized-(2050-tt.entryPx)) > 1e-9 {
+				t.Errorf("c.Unrealized = %v, want %v", c.Unrealized, 2050-tt.entryPx)
+			}
+			if math.Abs(c.Fees-2.4) > 1e-9 {
+				t.Errorf("c.Fees = %v, want %v", c.Fees, 2.4)
+			}
+			if c.ClosedPnl != 150.0 {
+				t.Errorf("c.ClosedPnl = %v, want 150.0", c.ClosedPnl)
+			}
+			if c.ExchangePosition != 1.0 {
+				t.Errorf("c.ExchangePosition = %v, want 1.0", c.ExchangePosition)
+			}
+			if c.Fills != 3 {
+				t.Errorf("c.Fills = %v, want 3", c.Fills)
+			}
+			if math.Abs(c.Net-(250-2.4)) > 1e-9 {
+				t.Errorf("c.Net = %v, want %v", c.Net, 250-2.4)
+			}
+		})
+	}
+}
+
+func TestLedgerFundingAndSummary(t *testing.T) {
+	l := NewLedger("")
+	l.SetPosition("BTC", -0.5, 60000, 0)
+	_, err := l.AddFill(types.Fill{Coin: "BTC", Tid: 1, Oid: 1, Side: types.SideBid, Px: "59000", Sz: "0.2", StartPosition: "-0.5", Fee: "-0.1", FeeToken: "USDC"})
+	if err != nil {
+		t.Fatalf("AddFill() error = %v", err)
+	}
+	// A spot buy pays its fee in the base token
+	_, err = l.AddFill(types.Fill{Coin: "@1", Tid: 2, Oid: 2, Side: types.SideBid, Px: "25", Sz: "10", Fee: "0.01", FeeToken: "PURR"})
+	if err != nil {
+		t.Fatalf("AddFill() error = %v", err)
+	}
+
+	added, err := l.AddWsFunding(ws.WsUserFunding{Time: 3600000, Coin: "BTC", Usdc: "1.5", Szi: "-0.3"})
+	if err != nil {
+		t.Fatalf("AddWsFunding() error = %v", err)
+	}
+	if !added {
+		t.Error("added = false")
+	}
+	added, err = l.AddWsFunding(ws.WsUserFunding{Time: 3600000, Coin: "BTC", Usdc: "1.5", Szi: "-0.3"})
+	if err != nil {
+		t.Fatalf("AddWsFunding() error = %v", err)
+	}
+	if added {
+		t.Error("added = true")
+	}
+	if !l.AddFunding("BTC", 7200000, -0.5) {
+		t.Error("AddFunding(BTC, 7200000, -0.5) = false")
+	}
+	_, err = l.AddWsFunding(ws.WsUserFunding{Coin: "BTC", Usdc: "x"})
+	if err == nil {
+		t.Error("AddWsFunding() error = nil, want error")
+	}
+
+	s := l.Summary(map[string]float64{"BTC": 61000})
+	if len(s.Coins) != 2 {
+		t.Fatalf("len(s.Coins) = %d, want 2", len(s.Coins))
+	}
+	if s.Coins[0].Coin != "@1" {
+		t.Errorf("s.Coins[0].Coin = %q, want %q", s.Coins[0].Coin, "@1")
+	}
+	if s.Coins[0].Position != 10.0 {
+		t.Errorf("s.Coins[0].Position = %v, want 10.0", s.Coins[0].Position)
+	}
+	if math.Abs(s.Coins[0].Fees-0.25) > 1e-9 {
+		t.Errorf("s.Coins[0].Fees = %v, want %v", s.Coins[0].Fees, 0.25)
+	}
+	if s.Coins[0].Unrealized != 0 {
+		t.Errorf("s.Coins[0].Unrealized = %v, want 0", s.Coins[0].Unrealized)
+	}
+
+	btc := s.Coins[1]
+	if math.Abs(btc.Position-(-0.3)) > 1e-9 {
+		t.Errorf("btc.Position = %v, want %v", btc.Position, -0.3)
+	}
+	if math.Abs(btc.ExchangePosition-(-0.3)) > 1e-9 {
+		t.Errorf("btc.ExchangePosition = %v, want %v", btc.ExchangePosition, -0.3)
+	}
+	if math.Abs(btc.Realized-200) > 1e-9 {
+		t.Errorf("btc.Realized = %v, want %v", btc.Realized, 200)
+	}
+	if math.Abs(btc.Unrealized-(-300)) > 1e-9 {
+		t.Errorf("btc.Unrealized = %v, want %v", btc.Unrealized, -300)
+	}
+	if math.Abs(btc.Funding-1) > 1e-9 {
+		t.Errorf("btc.Funding = %v, want %v", btc.Funding, 1)
+	}
+	if math.Abs(btc.Net-(200-300+0.1+1)) > 1e-9 {
+		t.Errorf("btc.Net = %v, want %v", btc.Net, 200-300+0.1+1)
+	}
+
+	if math.Abs(s.Realized-200) > 1e-9 {
+		t.Errorf("s.Realized = %v, want %v", s.Realized, 200)
+	}
+	if math.Abs(s.Unrealized-(-300)) > 1e-9 {
+		t.Errorf("s.Unrealized = %v, want %v", s.Unrealized, -300)
+	}
+	if math.Abs(s.Fees-0.15) > 1e-9 {
+		t.Errorf("s.Fees = %v, want %v", s.Fees, 0.15)
+	}
+	if math.Abs(s.Funding-1) > 1e-9 {
+		t.Errorf("s.Funding = %v, want %v", s.Funding, 1)
+	}
+	if math.Abs(s.Net-(-99.15)) > 1e-9 {
+		t.Errorf("s.Net = %v, want %v", s.Net, -99.15)
+	}
+}
+
+func TestLedgerErrors(t *testing.T) {
+	l := NewLedger(MethodFIFO)
+	for _, f := range []types.Fill{
+		{Coin: "ETH", Px: "x", Sz: "1"},
+		{Coin: "ETH", Px: "1", Sz: "x"},
+		{Coin: "ETH", Px: "1", Sz: "1", Fee: "x"},
+		{Coin: "ETH", Px: "1", Sz: "1", ClosedPnl: "x"},
+		{Coin: "ETH", Px: "1", Sz: "1", StartPosition: "x"},
+	} {
+		_, err := l.AddFill(f)
+		if err == nil {
+			t.Error("AddFill(f) error = nil, want error")
+		}
+	}
+	if err := l.AddFills([]types.Fill{{Coin: "ETH", Px: "x"}}); err == nil {
+		t.Error("AddFills() error = nil, want error")
+	}
+	if got := l.Summary(nil).Coins; len(got) != 0 {
+		t.Errorf("l.Summary(nil).Coins = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,137 @@
+package accounting
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// FeeTierDays is the number of days of volume setting the fee tier of an account
+const FeeTierDays = 14
+
+// TierProjection is the fee tier of an account and the tier it reaches at its recent
+// pace. Tiers index the VIP or MM tiers of the fee schedule, -1 below the first tier.
+// Rates are fractions of the notional, negative for rebates.
+type TierProjection struct {
+	// Volume is the taker and maker volume of the last FeeTierDays days
+	Volume float64
+	// Tier is the VIP tier of Volume, with its taker and maker rates
+	Tier      int
+	CrossRate float64
+	AddRate   float64
+	// NextTier is the tier above Tier, -1 at the top, and VolumeToNext the volume it
+	// still needs
+	NextTier     int
+	NextCutoff   float64
+	VolumeToNext float64
+	// ProjectedVolume is the volume of FeeTierDays days at the average daily volume of
+	// the pace days, and ProjectedTier its VIP tier
+	ProjectedVolume float64
+	ProjectedTier   int
+	// MakerShare is the share of the exchange volume made by the account over the last
+	// FeeTierDays days, and MakerTier its MM tier with its maker rate MakerAddRate
+	MakerShare   float64
+	MakerTier    int
+	MakerAddRate float64
+}
+
+// ProjectFeeTier returns the fee tier of the account of fees, projected at the pace of
+// its last paceDays days, FeeTierDays if not positive
+func ProjectFeeTier(fees *types.UserFees, paceDays int) (*TierProjection, error) {
+	if paceDays <= 0 {
+		paceDays = FeeTierDays
+	}
+	volumes, err := fees.DailyVolumes()
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := fees.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	cutoffs := make([]float64, len(schedule.Tiers.VIP))
+	for i, tier := range schedule.Tiers.VIP {
+		if cutoffs[i], err = strconv.ParseFloat(tier.NtlCutoff, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse VIP tier cutoff: %w", err)
+		}
+	}
+	makerCutoffs := make([]float64, len(schedule.Tiers.MM))
+	for i, tier := range schedule.Tiers.MM {
+		if makerCutoffs[i], err = strconv.ParseFloat(tier.MakerFractionCutoff, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse MM tier cutoff: %w", err)
+		}
+	}
+
+	p := &TierProjection{NextTier: -1}
+	var made, exchange, paced float64
+	for i := range volumes {
+		// Newest first
+		v := volumes[len(volumes)-1-i]
+		if i >= FeeTierDays && i >= paceDays {
+			break
+		}
+		cross, err := strconv.ParseFloat(v.UserCross, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse daily taker volume: %w", err)
+		}
+		add, err := strconv.ParseFloat(v.UserAdd, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse daily maker volume: %w", err)
+		}
+		total, err := parseOptional(v.Exchange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse daily exchange volume: %w", err)
+		}
+		if i < FeeTierDays {
+			p.Volume += cross + add
+			made += add
+			exchange += total
+		}
+		if i < paceDays {
+			paced += cross + add
+		}
+	}
+	p.ProjectedVolume = paced / float64(paceDays) * FeeTierDays
+	if exchange > 0 {
+		p.MakerShare = made / exchange
+	}
+
+	p.Tier = tierOf(cutoffs, p.Volume)
+	p.ProjectedTier = tierOf(cutoffs, p.ProjectedVolume)
+	if p.Tier+1 < len(cutoffs) {
+		p.NextTier = p.Tier + 1
+		p.NextCutoff = cutoffs[p.NextTier]
+		p.VolumeToNext = p.NextCutoff - p.Volume
+	}
+	cross, add := schedule.Cross, schedule.Add
+	if p.Tier >= 0 {
+		cross, add = schedule.Tiers.VIP[p.Tier].Cross, schedule.Tiers.VIP[p.Tier].Add
+	}
+	if p.CrossRate, err = strconv.ParseFloat(cross, 64); err != nil {
+		return nil, fmt.Errorf("failed to parse taker rate: %w", err)
+	}
+	if p.AddRate, err = strconv.ParseFloat(add, 64); err != nil {
+		return nil, fmt.Errorf("failed to parse maker rate: %w", err)
+	}
+
+	p.MakerTier = tierOf(makerCutoffs, p.MakerShare)
+	p.MakerAddRate = p.AddRate
+	if p.MakerTier >= 0 {
+		if p.MakerAddRate, err = strconv.ParseFloat(schedule.Tiers.MM[p.MakerTier].Add, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse MM tier rate: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// tierOf returns the highest tier whose cutoff is at most value, -1 if none
+func tierOf(cutoffs []float64, value float64) int {
+	tier := -1
+	for i, cutoff := range cutoffs {
+		if cutoff <= value {
+			tier = i
+		}
+	}
+	return tier
+}
@@ -0,0 +1,155 @@
+package accounting
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+const testSchedule = `{
+	"cross": "0.00045", "add": "0.00015", "spotCross": "0.0007", "spotAdd": "0.0004",
+	"tiers": {
+		"vip": [
+			{"ntlCutoff": "5000000.0", "cross": "0.0004", "add": "0.00012", "spotCross": "0.0006", "spotAdd": "0.0003"},
+			{"ntlCutoff": "25000000.0", "cross": "0.00035", "add": "0.00008", "spotCross": "0.0005", "spotAdd": "0.0002"}
+		],
+		"mm": [
+			{"makerFractionCutoff": "0.005", "add": "-0.00001"},
+			{"makerFractionCutoff": "0.015", "add": "-0.00002"}
+		]
+	},
+	"referralDiscount": "0.04"
+}`
+
+// userFees returns the fees of an account trading cross and add each of days days
+func userFees(days int, cross, add, exchange float64) *types.UserFees {
+	fees := &types.UserFees{FeeSchedule: types.RawJSON(testSchedule)}
+	for i := 0; i < days; i++ {
+		fees.DailyUserVlm = append(fees.DailyUserVlm, types.RawJSON(fmt.Sprintf(
+			`{"date": "2026-03-%02d", "userCross": "%v", "userAdd": "%v", "exchange": "%v"}`, i+1, cross, add, exchange)))
+	}
+	return fees
+}
+
+func TestProjectFeeTier(t *testing.T) {
+	// 20 days of 500k a day, the last 14 of which count
+	p, err := ProjectFeeTier(userFees(20, 300000, 200000, 10000000), 0)
+	if err != nil {
+		t.Fatalf("ProjectFeeTier() error = %v", err)
+	}
+	if p.Volume != 7000000.0 {
+		t.Errorf("p.Volume = %v, want 7000000.0", p.Volume)
+	}
+	if p.Tier != 0 {
+		t.Errorf("p.Tier = %v, want 0", p.Tier)
+	}
+	if p.CrossRate != 0.0004 {
+		t.Errorf("p.CrossRate = %v, want 0.0004", p.CrossRate)
+	}
+	if p.AddRate != 0.00012 {
+		t.Errorf("p.AddRate = %v, want 0.00012", p.AddRate)
+	}
+	if p.NextTier != 1 {
+		t.Errorf("p.NextTier = %v, want 1", p.NextTier)
+	}
+	if p.NextCutoff != 25000000.0 {
+		t.Errorf("p.NextCutoff = %v, want 25000000.0", p.NextCutoff)
+	}
+	if p.VolumeToNext != 18000000.0 {
+		t.Errorf("p.VolumeToNext = %v, want 18000000.0", p.VolumeToNext)
+	}
+	if p.ProjectedVolume != 7000000.0 {
+		t.Errorf("p.ProjectedVolume = %v, want 7000000.0", p.ProjectedVolume)
+	}
+	if p.ProjectedTier != 0 {
+		t.Errorf("p.ProjectedTier = %v, want 0", p.ProjectedTier)
+	}
+	if math.Abs(p.MakerShare-0.02) > 1e-12 {
+		t.Errorf("p.MakerShare = %v, want %v", p.MakerShare, 0.02)
+	}
+	if p.MakerTier != 1 {
+		t.Errorf("p.MakerTier = %v, want 1", p.MakerTier)
+	}
+	if p.MakerAddRate != -0.00002 {
+		t.Errorf("p.MakerAddRate = %v, want -0.00002", p.MakerAddRate)
+	}
+}
+
+func TestProjectFeeTierPace(t *testing.T) {
+	fees := userFees(13, 1000, 0, 10000000)
+	fees.DailyUserVlm = append(fees.DailyUserVlm, types.RawJSON(`{"date": "2026-03-14", "userCross": "3000000", "userAdd": "0", "exchange": "10000000"}`))
+
+	p, err := ProjectFeeTier(fees, 1)
+	if err != nil {
+		t.Fatalf("ProjectFeeTier(fees, 1) error = %v", err)
+	}
+	if p.Volume != 3013000.0 {
+		t.Errorf("p.Volume = %v, want 3013000.0", p.Volume)
+	}
+	if p.Tier != -1 {
+		t.Errorf("p.Tier = %v, want -1", p.Tier)
+	}
+	if p.CrossRate != 0.00045 {
+		t.Errorf("p.CrossRate = %v, want 0.00045", p.CrossRate)
+	}
+	if p.NextTier != 0 {
+		t.Errorf("p.NextTier = %v, want 0", p.NextTier)
+	}
+	if p.VolumeToNext != 1987000.0 {
+		t.Errorf("p.VolumeToNext = %v, want 1987000.0", p.VolumeToNext)
+	}
+	// Another 3M a day reaches the top tier
+	if p.ProjectedVolume != 42000000.0 {
+		t.Errorf("p.ProjectedVolume = %v, want 42000000.0", p.ProjectedVolume)
+	}
+	if p.ProjectedTier != 1 {
+		t.Errorf("p.ProjectedTier = %v, want 1", p.ProjectedTier)
+	}
+	if p.MakerShare != 0 {
+		t.Errorf("p.MakerShare = %v, want 0", p.MakerShare)
+	}
+	if p.MakerTier != -1 {
+		t.Errorf("p.MakerTier = %v, want -1", p.MakerTier)
+	}
+	if p.MakerAddRate != 0.00015 {
+		t.Errorf("p.MakerAddRate = %v, want 0.00015", p.MakerAddRate)
+	}
+
+	// At the top there is no next tier
+	p, err = ProjectFeeTier(userFees(14, 2000000, 0, 0), 7)
+	if err != nil {
+		t.Fatalf("ProjectFeeTier() error = %v", err)
+	}
+	if p.Tier != 1 {
+		t.Errorf("p.Tier = %v, want 1", p.Tier)
+	}
+	if p.NextTier != -1 {
+		t.Errorf("p.NextTier = %v, want -1", p.NextTier)
+	}
+	if p.VolumeToNext != 0 {
+		t.Errorf("p.VolumeToNext = %v, want 0", p.VolumeToNext)
+	}
+}
+
+func TestProjectFeeTierErrors(t *testing.T) {
+	_, err := ProjectFeeTier(&types.UserFees{}, 0)
+	if err == nil {
+		t.Error("ProjectFeeTier() error = nil, want error")
+	}
+
+	fees := userFees(0, 0, 0, 0)
+	fees.DailyUserVlm = []types.RawJSON{types.RawJSON(`{"userCross": "x", "userAdd": "0"}`)}
+	_, err = ProjectFeeTier(fees, 0)
+	if err == nil {
+		t.Error("ProjectFeeTier(fees, 0) error = nil, want error")
+	}
+
+	fees = userFees(1, 0, 0, 0)
+	fees.FeeSchedule = types.RawJSON(`{"cross": "x", "add": "0"}`)
+	_, err = ProjectFeeTier(fees, 0)
+	if err == nil {
+		t.Error("ProjectFeeTier(fees, 0) error = nil, want error")
+	}
+}
@@ -0,0 +1,571 @@
+// Package accounts runs several accounts from one master account. A Manager holds the
+// Exchange of the master and the Exchanges of its sub-accounts and agents by label,
+// routes orders to them, aggregates their positions and balances, and moves USDC from
+// and to the master to keep a target buying power on each:
+//
+//	manager := accounts.NewManager(master, info, accounts.Config{MasterReserve: 1000})
+//	manager.AddAccount(accounts.Account{
+//	    Label:             "mm",
+//	    Kind:              accounts.SubAccount,
+//	    Address:           subAccountAddress,
+//	    Exchange:          subExchange, // the master key with the sub-account as vault
+//	    TargetBuyingPower: 50_000,
+//	})
+//	manager.Order("mm", "ETH", true, 1, 3000, types.NewLimit(types.TifGtc), false, nil, nil)
+//	go manager.Run(ctx)
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Defaults of a Manager
+const (
+	DefaultMasterLabel       = "master"
+	DefaultRebalanceInterval = time.Minute
+	// DefaultTolerance is the fraction of its target by which the buying power of an
+	// account may differ before it is rebalanced
+	DefaultTolerance = 0.1
+	// DefaultMinTransfer is the smallest transfer in USDC
+	DefaultMinTransfer = 1.0
+)
+
+// Kind is how an account is funded from the master
+type Kind int
+
+const (
+	// SubAccount is a sub-account of the master, funded and drained by the master with
+	// SubAccountTransfer
+	SubAccount Kind = iota
+	// Agent is an agent wallet trading for Address. An agent of the master shares its
+	// funds and is not rebalanced; an agent of another account is funded by the master
+	// with SendAsset, but cannot send the excess back.
+	Agent
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case SubAccount:
+		return "sub-account"
+	case Agent:
+		return "agent"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Account is an account run by a Manager
+type Account struct {
+	// Label names the account in the methods of the Manager
+	Label string
+	Kind  Kind
+	// Address is the account traded, Exchange.GetAccountAddress if empty. A sub-account
+	// traded by the master key with a vault address must be given.
+	Address  string
+	Exchange client.Exchanger
+	// TargetBuyingPower is the withdrawable USDC kept on the account, not rebalanced if
+	// zero
+	TargetBuyingPower float64
+}
+
+// Config configures a Manager
+type Config struct {
+	// MasterLabel is the label of the master account, DefaultMasterLabel if empty
+	MasterLabel string
+	// MasterReserve is the withdrawable USDC the master keeps for itself when funding
+	// accounts
+	MasterReserve float64
+	// Tolerance is the fraction of its target by which the buying power of an account
+	// may differ before it is rebalanced, DefaultTolerance if zero
+	Tolerance float64
+	// MinTransfer is the smallest transfer in USDC, DefaultMinTransfer if zero
+	MinTransfer float64
+	// RebalanceInterval is how often Run rebalances, DefaultRebalanceInterval if zero
+	RebalanceInterval time.Duration
+}
+
+// Transfer is a transfer of USDC between the master and an account
+type Transfer struct {
+	Time   time.Time
+	From   string
+	To     string
+	Amount float64
+	// Short is the USDC an account still lacks after the transfer, when the master
+	// cannot fund its whole target
+	Short float64
+	// Err is the error of a transfer of Run that failed
+	Err error
+}
+
+func (t Transfer) String() string {
+	s := fmt.Sprintf("%s -> %s: %.2f USDC", t.From, t.To, t.Amount)
+	if t.Short > 0 {
+		s += fmt.Sprintf(" (%.2f short)", t.Short)
+	}
+	if t.Err != nil {
+		s += ": " + t.Err.Error()
+	}
+	return s
+}
+
+// AccountState is the state of an account of a Portfolio
+type AccountState struct {
+	Label        string
+	Address      string
+	AccountValue float64
+	Withdrawable float64
+	MarginUsed   float64
+	Positions    []types.Position
+	Balances     []types.SpotBalance
+}
+
+// NetPosition is the position in a coin summed across accounts
+type NetPosition struct {
+	Coin          string
+	Szi           float64
+	PositionValue float64
+	UnrealizedPnl float64
+}
+
+// Portfolio is the state of the accounts of a Manager. Accounts trading the same
+// address, such as the agents of the master, are counted once in the totals.
+type Portfolio struct {
+	Time         time.Time
+	Accounts     []AccountState
+	AccountValue float64
+	Withdrawable float64
+	MarginUsed   float64
+	// Positions are the net positions by coin
+	Positions map[string]*NetPosition
+	// Balances are the total spot balances by coin
+	Balances map[string]float64
+}
+
+// Manager runs accounts from a master account. Safe for concurrent use.
+type Manager struct {
+	info       client.Infoer
+	config     Config
+	master     string
+	onTransfer func(Transfer)
+
+	mu       sync.Mutex
+	clock    utils.Clock
+	accounts []*Account
+	byLabel  map[string]*Account
+	usdc     string
+
+	// rebalancing serializes the rebalances
+	rebalancing sync.Mutex
+}
+
+// NewManager creates a manager of the accounts funded by master, reading their state
+// with info. The master is the first account, under Config.MasterLabel.
+func NewManager(master client.Exchanger, info client.Infoer, config Config) *Manager {
+	if config.MasterLabel == "" {
+		config.MasterLabel = DefaultMasterLabel
+	}
+	if config.Tolerance <= 0 {
+		config.Tolerance = DefaultTolerance
+	}
+	if config.MinTransfer <= 0 {
+		config.MinTransfer = DefaultMinTransfer
+	}
+	if config.RebalanceInterval <= 0 {
+		config.RebalanceInterval = DefaultRebalanceInterval
+	}
+	m := &Manager{
+		info:    info,
+		config:  config,
+		master:  master.GetAccountAddress(),
+		clock:   utils.SystemClock,
+		byLabel: make(map[string]*Account),
+	}
+	account := &Account{Label: config.MasterLabel, Kind: Agent, Address: m.master, Exchange: master}
+	m.accounts = append(m.accounts, account)
+	m.byLabel[account.Label] = account
+	return m
+}
+
+// SetClock sets the clock of the portfolios and transfers, e.g. a utils.FakeClock in
+// tests
+func (m *Manager) SetClock(clock utils.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
+
+// OnTransfer sets a callback invoked for every transfer of Run, including failed
+// ones. Must be called before Run.
+func (m *Manager) OnTransfer(fn func(Transfer)) {
+	m.onTransfer = fn
+}
+
+// AddAccount adds an account under its label
+func (m *Manager) AddAccount(account Account) error {
+	if account.Label == "" {
+		return fmt.Errorf("account has no label")
+	}
+	if account.Exchange == nil {
+		return fmt.Errorf("account %s has no exchange", account.Label)
+	}
+	if account.Address == "" {
+		account.Address = account.Exchange.GetAccountAddress()
+	}
+	if err := utils.ValidateAddress(account.Address); err != nil {
+		return fmt.Errorf("invalid address of account %s: %w", account.Label, err)
+	}
+	if account.TargetBuyingPower < 0 {
+		return fmt.Errorf("invalid target buying power of account %s: %v", account.Label, account.TargetBuyingPower)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.byLabel[account.Label]; ok {
+		return fmt.Errorf("account %s already added", account.Label)
+	}
+	if account.Kind == SubAccount && m.isMaster(account.Address) {
+		return fmt.Errorf("account %s is the master, not a sub-account", account.Label)
+	}
+	m.accounts = append(m.accounts, &account)
+	m.byLabel[account.Label] = &account
+	return nil
+}
+
+// SetTarget sets the target buying power of the account labelled label
+func (m *Manager) SetTarget(label string, usdc float64) error {
+	if usdc < 0 {
+		return fmt.Errorf("invalid target buying power: %v", usdc)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	account, ok := m.byLabel[label]
+	if !ok {
+		return fmt.Errorf("unknown account: %s", label)
+	}
+	account.TargetBuyingPower = usdc
+	return nil
+}
+
+// Labels returns the labels of the accounts, the master first
+func (m *Manager) Labels() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	labels := make([]string, len(m.accounts))
+	for i, account := range m.accounts {
+		labels[i] = account.Label
+	}
+	return labels
+}
+
+// Exchange returns the exchange of the account labelled label
+func (m *Manager) Exchange(label string) (client.Exchanger, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	account, ok := m.byLabel[label]
+	if !ok {
+		return nil, fmt.Errorf("unknown account: %s", label)
+	}
+	return account.Exchange, nil
+}
+
+// Order places an order on the account labelled label, see Exchange.Order
+func (m *Manager) Order(label string, name string, isBuy bool, sz float64, limitPx float64, orderType types.OrderType, reduceOnly bool, cloid *types.Cloid, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	exchange, err := m.Exchange(label)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.Order(name, isBuy, sz, limitPx, orderType, reduceOnly, cloid, builder)
+}
+
+// BulkOrders places orders on the account labelled label, see Exchange.BulkOrders
+func (m *Manager) BulkOrders(label string, orders []types.OrderRequest, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	exchange, err := m.Exchange(label)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.BulkOrders(orders, builder)
+}
+
+// Cancel cancels an order of the account labelled label, see Exchange.Cancel
+func (m *Manager) Cancel(label string, name string, oid int) (*types.CancelResponse, error) {
+	exchange, err := m.Exchange(label)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.Cancel(name, oid)
+}
+
+// Portfolio reads the perp and spot state of every account and aggregates them
+func (m *Manager) Portfolio() (*Portfolio, error) {
+	accounts := m.snapshot()
+	perps, err := m.userStates(accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &Portfolio{
+		Time:      m.now(),
+		Positions: make(map[string]*NetPosition),
+		Balances:  make(map[string]float64),
+	}
+	spots := make(map[string]*types.SpotUserState)
+	for _, account := range accounts {
+		address := strings.ToLower(account.Address)
+		spot, ok := spots[address]
+		counted := ok
+		if !ok {
+			if spot, err = m.info.SpotUserState(account.Address); err != nil {
+				return nil, fmt.Errorf("failed to get spot state of %s: %w", account.Label, err)
+			}
+			spots[address] = spot
+		}
+
+		perp := perps[address]
+		state := AccountState{Label: account.Label, Address: account.Address, Balances: spot.Balances}
+		for _, position := range perp.state.AssetPositions {
+			state.Positions = append(state.Positions, position.Position)
+		}
+		state.Withdrawable = perp.withdrawable
+		if state.AccountValue, err = perp.state.MarginSummary.AccountValueFloat(); err != nil {
+			return nil, fmt.Errorf("failed to parse account value of %s: %w", account.Label, err)
+		}
+		if state.MarginUsed, err = perp.state.MarginSummary.TotalMarginUsedFloat(); err != nil {
+			return nil, fmt.Errorf("failed to parse margin of %s: %w", account.Label, err)
+		}
+		portfolio.Accounts = append(portfolio.Accounts, state)
+		if counted {
+			continue
+		}
+
+		portfolio.AccountValue += state.AccountValue
+		portfolio.Withdrawable += state.Withdrawable
+		portfolio.MarginUsed += state.MarginUsed
+		for _, position := range state.Positions {
+			if err := portfolio.addPosition(position); err != nil {
+				return nil, fmt.Errorf("failed to parse position of %s: %w", account.Label, err)
+			}
+		}
+		for _, balance := range state.Balances {
+			total, err := balance.TotalFloat()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse balance of %s: %w", account.Label, err)
+			}
+			portfolio.Balances[balance.Coin] += total
+		}
+	}
+	return portfolio, nil
+}
+
+func (p *Portfolio) addPosition(position types.Position) error {
+	szi, err := position.SziFloat()
+	if err != nil {
+		return err
+	}
+	value, err := position.PositionValueFloat()
+	if err != nil {
+		return err
+	}
+	pnl, err := position.UnrealizedPnlFloat()
+	if err != nil {
+		return err
+	}
+	net, ok := p.Positions[position.Coin]
+	if !ok {
+		net = &NetPosition{Coin: position.Coin}
+		p.Positions[position.Coin] = net
+	}
+	net.Szi += szi
+	// Position values are unsigned
+	if szi < 0 {
+		value = -value
+	}
+	net.PositionValue += value
+	net.UnrealizedPnl += pnl
+	return nil
+}
+
+// Rebalance moves USDC between the master and the accounts with a target buying
+// power. Sub-accounts above their target are drained to the master first, then
+// accounts below it are funded, in the order they were added, from the withdrawable
+// USDC of the master above Config.MasterReserve. It returns the transfers made.
+func (m *Manager) Rebalance() ([]Transfer, error) {
+	m.rebalancing.Lock()
+	defer m.rebalancing.Unlock()
+
+	accounts := m.snapshot()
+	perps, err := m.userStates(accounts)
+	if err != nil {
+		return nil, err
+	}
+	master := accounts[0]
+	available := perps[strings.ToLower(master.Address)].withdrawable - m.config.MasterReserve
+
+	var (
+		transfers []Transfer
+		short     []*Account
+	)
+	for _, account := range accounts[1:] {
+		target := account.TargetBuyingPower
+		if target == 0 || m.isMaster(account.Address) {
+			continue
+		}
+		withdrawable := perps[strings.ToLower(account.Address)].withdrawable
+		band := target * m.config.Tolerance
+		switch {
+		case withdrawable > target+band && account.Kind == SubAccount:
+			excess := floorUsd(withdrawable - target)
+			if excess < m.config.MinTransfer {
+				continue
+			}
+			if _, err := master.Exchange.SubAccountTransfer(account.Address, false, int(math.Round(excess*1e6))); err != nil {
+				return transfers, fmt.Errorf("failed to drain %s: %w", account.Label, err)
+			}
+			available += excess
+			transfers = append(transfers, Transfer{Time: m.now(), From: account.Label, To: master.Label, Amount: excess})
+		case withdrawable < target-band:
+			short = append(short, account)
+		}
+	}
+
+	for _, account := range short {
+		need := account.TargetBuyingPower - perps[strings.ToLower(account.Address)].withdrawable
+		amount := floorUsd(math.Min(need, available))
+		if amount < m.config.MinTransfer {
+			transfers = append(transfers, Transfer{Time: m.now(), From: master.Label, To: account.Label, Short: need})
+			continue
+		}
+		if err := m.fund(master, account, amount); err != nil {
+			return transfers, fmt.Errorf("failed to fund %s: %w", account.Label, err)
+		}
+		available -= amount
+		transfers = append(transfers, Transfer{Time: m.now(), From: master.Label, To: account.Label, Amount: amount, Short: math.Max(need-amount, 0)})
+	}
+	return transfers, nil
+}
+
+// fund transfers amount USDC from the master to account
+func (m *Manager) fund(master, account *Account, amount float64) error {
+	if account.Kind == SubAccount {
+		_, err := master.Exchange.SubAccountTransfer(account.Address, true, int(math.Round(amount*1e6)))
+		return err
+	}
+	token, err := m.usdcToken()
+	if err != nil {
+		return err
+	}
+	_, err = master.Exchange.SendAsset(account.Address, "", "", token, amount)
+	return err
+}
+
+// usdcToken returns the name:tokenId of USDC of SendAsset
+func (m *Manager) usdcToken() (string, error) {
+	m.mu.Lock()
+	token := m.usdc
+	m.mu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+
+	spotMeta, err := m.info.SpotMeta()
+	if err != nil {
+		return "", fmt.Errorf("failed to get spot meta: %w", err)
+	}
+	for _, t := range spotMeta.Tokens {
+		if t.Name == "USDC" {
+			token = t.Name + ":" + t.TokenID
+			m.mu.Lock()
+			m.usdc = token
+			m.mu.Unlock()
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("USDC not found in spot meta")
+}
+
+// Run rebalances every interval until ctx is done. Transfers, and the errors of
+// failed rebalances, are passed to the OnTransfer callback.
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.RebalanceInterval)
+	defer ticker.Stop()
+	for {
+		transfers, err := m.Rebalance()
+		if err != nil {
+			transfers = append(transfers, Transfer{Time: m.now(), Err: err})
+		}
+		if m.onTransfer != nil {
+			for _, transfer := range transfers {
+				m.onTransfer(transfer)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// perpState is the perp state of an address with its parsed withdrawable
+type perpState struct {
+	state        *types.UserState
+	withdrawable float64
+}
+
+// userStates reads the perp state of every address of accounts, by lowercase address
+func (m *Manager) userStates(accounts []*Account) (map[string]perpState, error) {
+	states := make(map[string]perpState)
+	for _, account := range accounts {
+		address := strings.ToLower(account.Address)
+		if _, ok := states[address]; ok {
+			continue
+		}
+		state, err := m.info.UserState(account.Address, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get state of %s: %w", account.Label, err)
+		}
+		withdrawable, err := state.WithdrawableFloat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse withdrawable of %s: %w", account.Label, err)
+		}
+		states[address] = perpState{state: state, withdrawable: withdrawable}
+	}
+	return states, nil
+}
+
+// snapshot returns copies of the accounts, the master first
+func (m *Manager) snapshot() []*Account {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	accounts := make([]*Account, len(m.accounts))
+	for i, account := range m.accounts {
+		a := *account
+		accounts[i] = &a
+	}
+	return accounts
+}
+
+// isMaster returns whether address is the master's
+func (m *Manager) isMaster(address string) bool {
+	return strings.EqualFold(address, m.master)
+}
+
+func (m *Manager) now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clock.Now()
+}
+
+// floorUsd floors usd to the micro USD of transfers
+func floorUsd(usd float64) float64 {
+	return math.Floor(usd*1e6) / 1e6
+}
@@ -0,0 +1,379 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+)
+
+const subAddress = "0x00000000000000000000000000000000000000aa"
+
+// fakeAccounts serves the withdrawable USDC and positions of accounts, moving USDC on
+// the transfers of the master
+type fakeAccounts struct {
+	mu           sync.Mutex
+	withdrawable map[string]float64
+	positions    map[string][]types.AssetPosition
+	balances     map[string][]types.SpotBalance
+	transfers    []string
+}
+
+func (f *fakeAccounts) set(address string, withdrawable float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.withdrawable[strings.ToLower(address)] = withdrawable
+}
+
+func (f *fakeAccounts) get(address string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.withdrawable[strings.ToLower(address)]
+}
+
+func (f *fakeAccounts) move(from, to string, amount float64) {
+	f.withdrawable[strings.ToLower(from)] -= amount
+	f.withdrawable[strings.ToLower(to)] += amount
+}
+
+type fixture struct {
+	manager *Manager
+	fake    *fakeAccounts
+	srv     *hltest.Server
+	master  string
+	other   string
+}
+
+func newFixture(t *testing.T, config Config) *fixture {
+	t.Helper()
+	srv := hltest.NewTestServer(t)
+	srv.SetSpotMeta(types.SpotMeta{Tokens: []types.SpotTokenInfo{
+		{Name: "USDC", TokenID: "0x6d1e7cde53ba9467b783cb7c530ce054"},
+	}})
+	info := srv.NewTestInfo(t)
+
+	// The key of the master also signs for the sub-account
+	masterKey := hltest.NewTestKey(t)
+	master, err := srv.NewExchange(masterKey)
+	if err != nil {
+		t.Fatalf("NewExchange(masterKey) error = %v", err)
+	}
+	other := srv.NewTestExchange(t)
+	masterAddress := master.GetAccountAddress()
+
+	f := &fakeAccounts{
+		withdrawable: make(map[string]float64),
+		positions:    make(map[string][]types.AssetPosition),
+		balances:     make(map[string][]types.SpotBalance),
+	}
+	srv.HandleInfo("clearinghouseState", func(req map[string]any) (any, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		user := strings.ToLower(req["user"].(string))
+		w := strconv.FormatFloat(f.withdrawable[user], 'f', -1, 64)
+		return types.UserState{
+			AssetPositions: f.positions[user],
+			MarginSummary:  types.MarginSummary{AccountValue: w, TotalMarginUsed: "0"},
+			Withdrawable:   w,
+		}, nil
+	})
+	srv.HandleInfo("spotClearinghouseState", func(req map[string]any) (any, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return types.SpotUserState{Balances: f.balances[strings.ToLower(req["user"].(string))]}, nil
+	})
+	srv.HandleExchange("subAccountTransfer", func(req *hltest.ExchangeRequest) (any, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		usd, err := strconv.ParseFloat(fmt.Sprint(req.Action["usd"]), 64)
+		if err != nil {
+			return nil, err
+		}
+		sub := req.Action["subAccountUser"].(string)
+		if req.Action["isDeposit"].(bool) {
+			f.move(masterAddress, sub, usd/1e6)
+		} else {
+			f.move(sub, masterAddress, usd/1e6)
+		}
+		f.transfers = append(f.transfers, fmt.Sprintf("subAccountTransfer %v %v", req.Action["isDeposit"], req.Action["usd"]))
+		return map[string]any{"type": "default"}, nil
+	})
+	srv.HandleExchange("sendAsset", func(req *hltest.ExchangeRequest) (any, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		amount, err := strconv.ParseFloat(req.Action["amount"].(string), 64)
+		if err != nil {
+			return nil, err
+		}
+		f.move(masterAddress, req.Action["destination"].(string), amount)
+		f.transfers = append(f.transfers, fmt.Sprintf("sendAsset %s %s", req.Action["token"], req.Action["amount"]))
+		return map[string]any{"type": "default"}, nil
+	})
+
+	options := srv.ExchangeOptions(masterKey)
+	vault := subAddress
+	options.VaultAddress = &vault
+	sub, err := client.NewExchange(options)
+	if err != nil {
+		t.Fatalf("NewExchange(options) error = %v", err)
+	}
+
+	manager := NewManager(master, info, config)
+	if err := manager.AddAccount(Account{Label: "sub", Kind: SubAccount, Address: subAddress, Exchange: sub}); err != nil {
+		t.Fatalf("AddAccount() error = %v", err)
+	}
+	if err := manager.AddAccount(Account{Label: "other", Kind: Agent, Exchange: other}); err != nil {
+		t.Fatalf("AddAccount() error = %v", err)
+	}
+	if err := manager.AddAccount(Account{Label: "agent", Kind: Agent, Address: masterAddress, Exchange: master}); err != nil {
+		t.Fatalf("AddAccount() error = %v", err)
+	}
+	return &fixture{manager: manager, fake: f, srv: srv, master: masterAddress, other: other.GetAccountAddress()}
+}
+
+func TestManagerAccounts(t *testing.T) {
+	fx := newFixture(t, Config{})
+	if got, want := fx.manager.Labels(), []string{"master", "sub", "other", "agent"}; !slices.Equal(got, want) {
+		t.Errorf("Labels() = %+v, want %+v", got, want)
+	}
+
+	err := fx.manager.AddAccount(Account{Label: "sub", Exchange: fx.manager.accounts[1].Exchange, Address: subAddress})
+	if err == nil || !strings.Contains(err.Error(), "already added") {
+		t.Errorf("AddAccount() error = %v, want %q", err, "already added")
+	}
+	master, err := fx.manager.Exchange("master")
+	if err != nil {
+		t.Fatalf("Exchange(master) error = %v", err)
+	}
+	err = fx.manager.AddAccount(Account{Label: "self", Kind: SubAccount, Exchange: master})
+	if err == nil || !strings.Contains(err.Error(), "is the master") {
+		t.Errorf("AddAccount() error = %v, want %q", err, "is the master")
+	}
+	if err := fx.manager.AddAccount(Account{Label: "none"}); err == nil || !strings.Contains(err.Error(), "no exchange") {
+		t.Errorf("AddAccount() error = %v, want %q", err, "no exchange")
+	}
+
+	_, err = fx.manager.Order("missing", "ETH", true, 1, 2000, types.NewLimit(types.TifGtc), false, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown account: missing") {
+		t.Errorf("Order() error = %v, want %q", err, "unknown account: missing")
+	}
+	if err := fx.manager.SetTarget("missing", 1); err == nil {
+		t.Error("SetTarget(missing, 1) error = nil, want error")
+	}
+}
+
+func TestManagerRoutesOrders(t *testing.T) {
+	fx := newFixture(t, Config{})
+	_, err := fx.manager.Order("sub", "ETH", true, 1, 2000, types.NewLimit(types.TifGtc), false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	_, err = fx.manager.BulkOrders("other", []types.OrderRequest{
+		{Coin: "ETH", IsBuy: false, Sz: 2, LimitPx: 2100, OrderType: types.NewLimit(types.TifGtc)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("BulkOrders() error = %v", err)
+	}
+
+	// The order of the sub-account is placed for its vault address
+	if got := fx.srv.OpenOrders(subAddress); len(got) != 1 {
+		t.Errorf("len(OpenOrders(subAddress)) = %d, want 1", len(got))
+	}
+	if got := fx.srv.OpenOrders(fx.master); len(got) != 0 {
+		t.Errorf("OpenOrders(fx.master) = %+v, want empty", got)
+	}
+	orders := fx.srv.OpenOrders(fx.other)
+	if len(orders) != 1 {
+		t.Fatalf("len(orders) = %d, want 1", len(orders))
+	}
+	_, err = fx.manager.Cancel("other", "ETH", orders[0].Oid)
+	if err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if got := fx.srv.OpenOrders(fx.other); len(got) != 0 {
+		t.Errorf("OpenOrders(fx.other) = %+v, want empty", got)
+	}
+}
+
+func TestManagerPortfolio(t *testing.T) {
+	fx := newFixture(t, Config{})
+	fx.fake.set(fx.master, 1000)
+	fx.fake.set(subAddress, 300)
+	fx.fake.set(fx.other, 200)
+	position := func(coin, szi, value, pnl string) types.AssetPosition {
+		return types.AssetPosition{Type: "oneWay", Position: types.Position{Coin: coin, Szi: szi, PositionValue: value, UnrealizedPnl: pnl}}
+	}
+	fx.fake.positions[strings.ToLower(fx.master)] = []types.AssetPosition{position("ETH", "2", "4000", "100")}
+	fx.fake.positions[subAddress] = []types.AssetPosition{position("ETH", "-0.5", "1000", "-20"), position("BTC", "0.1", "6000", "50")}
+	fx.fake.balances[strings.ToLower(fx.other)] = []types.SpotBalance{{Coin: "USDC", Total: "25.5"}}
+	fx.fake.balances[strings.ToLower(fx.master)] = []types.SpotBalance{{Coin: "USDC", Total: "10"}}
+
+	portfolio, err := fx.manager.Portfolio()
+	if err != nil {
+		t.Fatalf("Portfolio() error = %v", err)
+	}
+	if len(portfolio.Accounts) != 4 {
+		t.Fatalf("len(portfolio.Accounts) = %d, want 4", len(portfolio.Accounts))
+	}
+	if portfolio.Accounts[3].Label != "agent" {
+		t.Errorf("portfolio.Accounts[3].Label = %q, want %q", portfolio.Accounts[3].Label, "agent")
+	}
+	// The agent of the master sees its account
+	if portfolio.Accounts[3].Withdrawable != 1000.0 {
+		t.Errorf("portfolio.Accounts[3].Withdrawable = %v, want 1000.0", portfolio.Accounts[3].Withdrawable)
+	}
+
+	// The agent of the master is counted once
+	if portfolio.Withdrawable != 1500.0 {
+		t.Errorf("portfolio.Withdrawable = %v, want 1500.0", portfolio.Withdrawable)
+	}
+	if portfolio.AccountValue != 1500.0 {
+		t.Errorf("portfolio.AccountValue = %v, want 1500.0", portfolio.AccountValue)
+	}
+	eth := portfolio.Positions["ETH"]
+	if eth.Szi != 1.5 {
+		t.Errorf("eth.Szi = %v, want 1.5", eth.Szi)
+	}
+	if eth.PositionValue != 3000.0 {
+		t.Errorf("eth.PositionValue = %v, want 3000.0", eth.PositionValue)
+	}
+	if eth.UnrealizedPnl != 80.0 {
+		t.Errorf("eth.UnrealizedPnl = %v, want 80.0", eth.UnrealizedPnl)
+	}
+	if portfolio.Positions["BTC"].Szi != 0.1 {
+		t.Errorf("portfolio.Positions[BTC].Szi = %v, want 0.1", portfolio.Positions["BTC"].Szi)
+	}
+	if portfolio.Balances["USDC"] != 35.5 {
+		t.Errorf("portfolio.Balances[USDC] = %v, want 35.5", portfolio.Balances["USDC"])
+	}
+}
+
+func TestManagerRebalance(t *testing.T) {
+	fx := newFixture(t, Config{MasterReserve: 100})
+	if err := fx.manager.SetTarget("sub", 500); err != nil {
+		t.Fatalf("SetTarget(sub, 500) error = %v", err)
+	}
+	if err := fx.manager.SetTarget("other", 400); err != nil {
+		t.Fatalf("SetTarget(other, 400) error = %v", err)
+	}
+	if err := fx.manager.SetTarget("agent", 1000); err != nil {
+		t.Fatalf("SetTarget(agent, 1000) error = %v", err)
+	}
+
+	fx.fake.set(fx.master, 600)
+	fx.fake.set(subAddress, 900.5)
+	fx.fake.set(fx.other, 100)
+
+	// The sub-account is drained to its target, then the other account funded
+	transfers, err := fx.manager.Rebalance()
+	if err != nil {
+		t.Fatalf("Rebalance() error = %v", err)
+	}
+	if len(transfers) != 2 {
+		t.Fatalf("len(transfers) = %d, want 2", len(transfers))
+	}
+	if want := (Transfer{Time: transfers[0].Time, From: "sub", To: "master", Amount: 400.5}); !reflect.DeepEqual(transfers[0], want) {
+		t.Errorf("transfers[0] = %+v, want %+v", transfers[0], want)
+	}
+	if want := (Transfer{Time: transfers[1].Time, From: "master", To: "other", Amount: 300}); !reflect.DeepEqual(transfers[1], want) {
+		t.Errorf("transfers[1] = %+v, want %+v", transfers[1], want)
+	}
+	if want := []string{
+		"subAccountTransfer false 400500000",
+		"sendAsset USDC:0x6d1e7cde53ba9467b783cb7c530ce054 300.000000",
+	}; !slices.Equal(fx.fake.transfers, want) {
+		t.Errorf("fx.fake.transfers = %+v, want %+v", fx.fake.transfers, want)
+	}
+	if got := fx.fake.get(fx.master); got != 700.5 {
+		t.Errorf("get(fx.master) = %v, want 700.5", got)
+	}
+	if got := fx.fake.get(subAddress); got != 500.0 {
+		t.Errorf("get(subAddress) = %v, want 500.0", got)
+	}
+	if got := fx.fake.get(fx.other); got != 400.0 {
+		t.Errorf("get(fx.other) = %v, want 400.0", got)
+	}
+
+	// Within the tolerance nothing moves
+	fx.fake.set(subAddress, 460)
+	transfers, err = fx.manager.Rebalance()
+	if err != nil {
+		t.Fatalf("Rebalance() error = %v", err)
+	}
+	if len(transfers) != 0 {
+		t.Errorf("transfers = %+v, want empty", transfers)
+	}
+
+	// The master funds what it can above its reserve
+	fx.fake.set(fx.master, 250)
+	fx.fake.set(subAddress, 200)
+	fx.fake.set(fx.other, 0)
+	transfers, err = fx.manager.Rebalance()
+	if err != nil {
+		t.Fatalf("Rebalance() error = %v", err)
+	}
+	if len(transfers) != 2 {
+		t.Fatalf("len(transfers) = %d, want 2", len(transfers))
+	}
+	if transfers[0].Amount != 150.0 {
+		t.Errorf("transfers[0].Amount = %v, want 150.0", transfers[0].Amount)
+	}
+	if transfers[0].Short != 150.0 {
+		t.Errorf("transfers[0].Short = %v, want 150.0", transfers[0].Short)
+	}
+	if transfers[1].To != "other" {
+		t.Errorf("transfers[1].To = %q, want %q", transfers[1].To, "other")
+	}
+	if transfers[1].Amount != 0 {
+		t.Errorf("transfers[1].Amount = %v, want 0", transfers[1].Amount)
+	}
+	if transfers[1].Short != 400.0 {
+		t.Errorf("transfers[1].Short = %v, want 400.0", transfers[1].Short)
+	}
+	if fx.fake.transfers[2] != "subAccountTransfer true 150000000" {
+		t.Errorf("fx.fake.transfers[2] = %q, want %q", fx.fake.transfers[2], "subAccountTransfer true 150000000")
+	}
+	if got := fx.fake.get(fx.master); got != 100.0 {
+		t.Errorf("get(fx.master) = %v, want 100.0", got)
+	}
+}
+
+func TestManagerRun(t *testing.T) {
+	fx := newFixture(t, Config{RebalanceInterval: time.Millisecond})
+	if err := fx.manager.SetTarget("sub", 100); err != nil {
+		t.Fatalf("SetTarget(sub, 100) error = %v", err)
+	}
+	fx.fake.set(fx.master, 1000)
+
+	transfers := make(chan Transfer, 16)
+	fx.manager.OnTransfer(func(t Transfer) { transfers <- t })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- fx.manager.Run(ctx) }()
+
+	select {
+	case transfer := <-transfers:
+		if got := transfer.String(); got != "master -> sub: 100.00 USDC" {
+			t.Errorf("String() = %q, want %q", got, "master -> sub: 100.00 USDC")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no transfer")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if got := fx.fake.get(fx.master); got != 900.0 {
+		t.Errorf("get(fx.master) = %v, want 900.0", got)
+	}
+}
@@ -0,0 +1,329 @@
+package algo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// State is the state of an execution
+type State string
+
+const (
+	// StatePending waits for the first mid price, the arrival price
+	StatePending State = "pending"
+	StateRunning State = "running"
+	// StatePaused sends no children. The time of the schedule goes on, so the first
+	// child after a resume catches up with it.
+	StatePaused   State = "paused"
+	StateCanceled State = "canceled"
+	// StateDone has sent the last slice or filled the parent order
+	StateDone State = "done"
+)
+
+// Config describes a parent order and its schedule
+type Config struct {
+	Coin  string
+	IsBuy bool
+	Sz    float64
+	// Duration is the time over which the slices are sent, one every Duration/Slices
+	Duration time.Duration
+	Slices   int
+	// Weights are the relative sizes of the slices, e.g. from VolumeProfile. Nil slices
+	// the order evenly.
+	Weights []float64
+	// SlippageBps is how far beyond the best price of the other side a child may fill
+	SlippageBps float64
+	// LimitPx is the worst price of a child, 0 for none. Children wait while the book
+	// is beyond it.
+	LimitPx    float64
+	ReduceOnly bool
+	// SzDecimals and IsSpot round children to the tick and lot of the coin
+	SzDecimals int
+	IsSpot     bool
+}
+
+// Child is a child order of an execution
+type Child struct {
+	Slice  int
+	Time   time.Time
+	Px     float64
+	Sz     float64
+	Filled float64
+	AvgPx  float64
+	// Error is why the child did not fill entirely, e.g. the Ioc cancel of the exchange
+	Error string
+}
+
+// Report is the progress of an execution
+type Report struct {
+	State State
+	// ArrivalPx is the mid price when the execution started
+	ArrivalPx float64
+	Filled    float64
+	Remaining float64
+	AvgPx     float64
+	// SlippageBps is the slippage of AvgPx against ArrivalPx, positive when worse
+	SlippageBps float64
+	Children    []Child
+}
+
+// Executor executes a parent order in Ioc children following a schedule. Each slice
+// sends the quantity the schedule is behind by, so what a child misses is retried by
+// the next one. Safe for concurrent use.
+type Executor struct {
+	trader client.Trader
+	config Config
+	// targets are the cumulative sizes due by the end of each slice
+	targets []float64
+
+	mu       sync.Mutex
+	clock    utils.Clock
+	state    State
+	start    time.Time
+	arrival  float64
+	next     int
+	filled   float64
+	notional float64
+	children []Child
+}
+
+// NewExecutor creates the execution of config with trader, e.g. a client.Exchange or a
+// paper.Exchange
+func NewExecutor(trader client.Trader, config Config) (*Executor, error) {
+	switch {
+	case config.Coin == "":
+		return nil, errors.New("execution has no coin")
+	case !(utils.RoundToLot(config.Sz, config.SzDecimals) > 0):
+		return nil, fmt.Errorf("invalid execution size: %v", config.Sz)
+	case config.Duration <= 0 || config.Slices <= 0:
+		return nil, fmt.Errorf("invalid execution schedule: %d slices over %v", config.Slices, config.Duration)
+	case config.SlippageBps < 0:
+		return nil, fmt.Errorf("invalid execution slippage: %v", config.SlippageBps)
+	}
+	weights := config.Weights
+	if weights == nil {
+		weights = Even(config.Slices)
+	}
+	if len(weights) != config.Slices {
+		return nil, fmt.Errorf("execution has %d weights for %d slices", len(weights), config.Slices)
+	}
+	var total float64
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("invalid execution weight: %v", w)
+		}
+		total += w
+	}
+	if !(total > 0) {
+		return nil, errors.New("execution weights are all zero")
+	}
+
+	sz := utils.RoundToLot(config.Sz, config.SzDecimals)
+	targets := make([]float64, config.Slices)
+	var sum float64
+	for i, w := range weights {
+		sum += w
+		targets[i] = utils.RoundToLot(sz*sum/total, config.SzDecimals)
+	}
+	targets[len(targets)-1] = sz
+	config.Sz = sz
+	return &Executor{trader: trader, config: config, targets: targets, clock: utils.SystemClock, state: StatePending}, nil
+}
+
+// SetClock sets the clock of the schedule, e.g. a utils.FakeClock in tests
+func (e *Executor) SetClock(clock utils.Clock) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = clock
+}
+
+// Run steps the execution on the updates of data, and every second without updates,
+// until it is done or canceled or ctx is done. Errors of children are in the report.
+func (e *Executor) Run(ctx context.Context, data *ws.MarketData) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	view := data.View()
+	for {
+		e.Step(view)
+		if e.finished() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case view = <-data.Updates():
+		case <-ticker.C:
+			view = data.View()
+		}
+	}
+}
+
+// Step sends the child due at the time of the clock, priced off view, and returns it.
+// It returns nil when no child is due, or none can be priced yet.
+func (e *Executor) Step(view ws.MarketView) (*Child, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if view.Coin != e.config.Coin {
+		return nil, nil
+	}
+	now := e.clock.Now()
+	switch e.state {
+	case StatePending:
+		if !(view.Mid > 0) {
+			return nil, nil
+		}
+		e.arrival = view.Mid
+		e.start = now
+		e.state = StateRunning
+	case StateRunning:
+	default:
+		return nil, nil
+	}
+
+	interval := e.config.Duration / time.Duration(e.config.Slices)
+	slice := min(int(now.Sub(e.start)/interval), e.config.Slices-1)
+	if slice < e.next {
+		return nil, nil
+	}
+	due := utils.RoundToLot(e.targets[slice]-e.filled, e.config.SzDecimals)
+	if !(due > 0) {
+		e.advance(slice)
+		return nil, nil
+	}
+	px, ok := e.price(view)
+	if !ok {
+		return nil, nil
+	}
+
+	child := Child{Slice: slice, Time: now, Px: px, Sz: due}
+	e.advance(slice)
+	result, err := e.trader.Order(e.config.Coin, e.config.IsBuy, due, px, types.NewLimit(types.TifIoc), e.config.ReduceOnly, nil, nil)
+	if err != nil {
+		child.Error = err.Error()
+		e.children = append(e.children, child)
+		return &child, fmt.Errorf("failed to place child order: %w", err)
+	}
+	if len(result.Data.Statuses) > 0 {
+		status := result.Data.Statuses[0]
+		if status.Filled != nil {
+			child.Filled, _ = strconv.ParseFloat(status.Filled.TotalSz, 64)
+			child.AvgPx, _ = strconv.ParseFloat(status.Filled.AvgPx, 64)
+			e.filled = utils.RoundToLot(e.filled+child.Filled, e.config.SzDecimals)
+			e.notional += child.Filled * child.AvgPx
+		}
+		child.Error = status.Error
+	}
+	if child.Filled < child.Sz && child.Error == "" {
+		child.Error = "child order partially filled"
+	}
+	if e.filled >= e.config.Sz {
+		e.state = StateDone
+	}
+	e.children = append(e.children, child)
+	return &child, nil
+}
+
+// price returns the limit price of a child: the best price of the other side moved by
+// the slippage and bounded by the limit price. It is false when that side is empty or
+// beyond the limit.
+func (e *Executor) price(view ws.MarketView) (float64, bool) {
+	touch := view.Bid.Px
+	if e.config.IsBuy {
+		touch = view.Ask.Px
+	}
+	if !(touch > 0) {
+		return 0, false
+	}
+	px := utils.SlippagePx(touch, e.config.SlippageBps, e.config.IsBuy)
+	if limit := e.config.LimitPx; limit > 0 {
+		if (e.config.IsBuy && touch > limit) || (!e.config.IsBuy && touch < limit) {
+			return 0, false
+		}
+		if e.config.IsBuy {
+			px = math.Min(px, limit)
+		} else {
+			px = math.Max(px, limit)
+		}
+	}
+	return utils.RoundToTick(px, e.config.SzDecimals, e.config.IsSpot), true
+}
+
+// advance moves the schedule past slice, finishing it after the last one
+func (e *Executor) advance(slice int) {
+	e.next = slice + 1
+	if e.next >= e.config.Slices {
+		e.state = StateDone
+	}
+}
+
+// Pause stops sending children until Resume
+func (e *Executor) Pause() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state != StateRunning && e.state != StatePending {
+		return fmt.Errorf("cannot pause a %s execution", e.state)
+	}
+	e.state = StatePaused
+	return nil
+}
+
+// Resume resumes a paused execution. One paused before it started starts on the next
+// step.
+func (e *Executor) Resume() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state != StatePaused {
+		return fmt.Errorf("cannot resume a %s execution", e.state)
+	}
+	e.state = StateRunning
+	if e.start.IsZero() {
+		e.state = StatePending
+	}
+	return nil
+}
+
+// Cancel stops the execution for good. Children are Ioc, so no order is left on the
+// book.
+func (e *Executor) Cancel() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state == StateDone || e.state == StateCanceled {
+		return fmt.Errorf("cannot cancel a %s execution", e.state)
+	}
+	e.state = StateCanceled
+	return nil
+}
+
+// Report returns the progress of the execution
+func (e *Executor) Report() Report {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	r := Report{
+		State:     e.state,
+		ArrivalPx: e.arrival,
+		Filled:    e.filled,
+		Remaining: utils.RoundToLot(e.config.Sz-e.filled, e.config.SzDecimals),
+		Children:  append([]Child(nil), e.children...),
+	}
+	if e.filled > 0 {
+		r.AvgPx = e.notional / e.filled
+		r.SlippageBps = utils.SlippageBps(e.arrival, r.AvgPx, e.config.IsBuy)
+	}
+	return r
+}
+
+func (e *Executor) finished() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.state == StateDone || e.state == StateCanceled
+}
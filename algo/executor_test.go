@@ -0,0 +1,320 @@
+package algo
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/backtest"
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/paper"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// view is a book with a spread of 1 around mid
+func view(mid float64) ws.MarketView {
+	return ws.MarketView{Coin: "ETH", Bid: ws.WsLevel{Px: mid - 0.5}, Ask: ws.WsLevel{Px: mid + 0.5}, Mid: mid}
+}
+
+func newExecutor(t *testing.T, config Config) (*Executor, *paper.Exchange, *utils.FakeClock) {
+	t.Helper()
+	exchange := paper.New(backtest.Config{InitialBalance: 100000})
+	exchange.Update(view(2000))
+	config.Coin = "ETH"
+	config.SzDecimals = 2
+	e, err := NewExecutor(exchange, config)
+	if err != nil {
+		t.Fatalf("NewExecutor(exchange, config) error = %v", err)
+	}
+	clock := utils.NewFakeClock(time.Unix(1000, 0))
+	e.SetClock(clock)
+	return e, exchange, clock
+}
+
+// step moves the market of exchange to mid and steps e on it
+func step(t *testing.T, e *Executor, exchange *paper.Exchange, mid float64) *Child {
+	t.Helper()
+	exchange.Update(view(mid))
+	child, err := e.Step(view(mid))
+	if err != nil {
+		t.Fatalf("Step(view(mid)) error = %v", err)
+	}
+	return child
+}
+
+func TestExecutorTWAP(t *testing.T) {
+	e, exchange, clock := newExecutor(t, Config{IsBuy: true, Sz: 1, Duration: 4 * time.Minute, Slices: 4, SlippageBps: 5})
+
+	child := step(t, e, exchange, 2000)
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if want := (Child{Slice: 0, Time: time.Unix(1000, 0), Px: 2001.5, Sz: 0.25, Filled: 0.25, AvgPx: 2000.5}); !reflect.DeepEqual(*child, want) {
+		t.Errorf("*child = %+v, want %+v", *child, want)
+	}
+	if got := step(t, e, exchange, 2000); got != nil {
+		t.Errorf("step(t, e, exchange, 2000) = %v, want nil", got)
+	}
+
+	clock.Advance(time.Minute)
+	child = step(t, e, exchange, 2010)
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if child.AvgPx != 2010.5 {
+		t.Errorf("child.AvgPx = %v, want 2010.5", child.AvgPx)
+	}
+
+	// The slice missed while paused is caught up after the resume
+	if err := e.Pause(); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	clock.Advance(time.Minute)
+	if got := step(t, e, exchange, 2010); got != nil {
+		t.Errorf("step(t, e, exchange, 2010) = %v, want nil", got)
+	}
+	if err := e.Resume(); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	clock.Advance(time.Minute)
+	child = step(t, e, exchange, 2020)
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if child.Slice != 3 {
+		t.Errorf("child.Slice = %v, want 3", child.Slice)
+	}
+	if child.Filled != 0.5 {
+		t.Errorf("child.Filled = %v, want 0.5", child.Filled)
+	}
+
+	report := e.Report()
+	if report.State != StateDone {
+		t.Errorf("report.State = %q, want %q", report.State, StateDone)
+	}
+	if report.ArrivalPx != 2000.0 {
+		t.Errorf("report.ArrivalPx = %v, want 2000.0", report.ArrivalPx)
+	}
+	if report.Filled != 1.0 {
+		t.Errorf("report.Filled = %v, want 1.0", report.Filled)
+	}
+	if report.Remaining != 0 {
+		t.Errorf("report.Remaining = %v, want 0", report.Remaining)
+	}
+	if math.Abs(report.AvgPx-2013.0) > 1e-9 {
+		t.Errorf("report.AvgPx = %v, want %v", report.AvgPx, 2013.0)
+	}
+	if math.Abs(report.SlippageBps-65.0) > 1e-9 {
+		t.Errorf("report.SlippageBps = %v, want %v", report.SlippageBps, 65.0)
+	}
+	if len(report.Children) != 3 {
+		t.Errorf("len(report.Children) = %d, want 3", len(report.Children))
+	}
+	if got := exchange.Position("ETH").Szi; got != 1.0 {
+		t.Errorf("exchange.Position(ETH).Szi = %v, want 1.0", got)
+	}
+
+	clock.Advance(time.Minute)
+	if got := step(t, e, exchange, 2020); got != nil {
+		t.Errorf("step(t, e, exchange, 2020) = %v, want nil", got)
+	}
+	if err := e.Pause(); err == nil {
+		t.Error("Pause() error = nil, want error")
+	}
+	if err := e.Cancel(); err == nil {
+		t.Error("Cancel() error = nil, want error")
+	}
+}
+
+func TestExecutorVWAP(t *testing.T) {
+	e, exchange, clock := newExecutor(t, Config{IsBuy: false, Sz: 2, Duration: time.Hour, Slices: 2, Weights: []float64{1, 3}})
+
+	if got := step(t, e, exchange, 2000).Sz; got != 0.5 {
+		t.Errorf("step(t, e, exchange, 2000).Sz = %v, want 0.5", got)
+	}
+	clock.Advance(time.Hour)
+	if got := step(t, e, exchange, 2000).Sz; got != 1.5 {
+		t.Errorf("step(t, e, exchange, 2000).Sz = %v, want 1.5", got)
+	}
+	if got := exchange.Position("ETH").Szi; got != -2.0 {
+		t.Errorf("exchange.Position(ETH).Szi = %v, want -2.0", got)
+	}
+	if got := e.Report().State; got != StateDone {
+		t.Errorf("e.Report().State = %q, want %q", got, StateDone)
+	}
+}
+
+func TestExecutorLimitPx(t *testing.T) {
+	e, exchange, clock := newExecutor(t, Config{IsBuy: false, Sz: 1, Duration: time.Minute, Slices: 2, SlippageBps: 50, LimitPx: 1990})
+
+	// The arrival price is taken even while the book is beyond the limit
+	if got := step(t, e, exchange, 1980); got != nil {
+		t.Errorf("step(t, e, exchange, 1980) = %v, want nil", got)
+	}
+	if got := e.Report().State; got != StateRunning {
+		t.Errorf("e.Report().State = %q, want %q", got, StateRunning)
+	}
+	if got := e.Report().ArrivalPx; got != 1980.0 {
+		t.Errorf("e.Report().ArrivalPx = %v, want 1980.0", got)
+	}
+
+	child := step(t, e, exchange, 1995.5)
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if child.Px != 1990.0 {
+		t.Errorf("child.Px = %v, want 1990.0", child.Px)
+	}
+	if child.Filled != 0.5 {
+		t.Errorf("child.Filled = %v, want 0.5", child.Filled)
+	}
+
+	clock.Advance(time.Minute)
+	child = step(t, e, exchange, 2000.5)
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if child.Px != 1990.0 {
+		t.Errorf("child.Px = %v, want 1990.0", child.Px)
+	}
+	report := e.Report()
+	if math.Abs(report.AvgPx-1997.5) > 1e-9 {
+		t.Errorf("report.AvgPx = %v, want %v", report.AvgPx, 1997.5)
+	}
+	if report.SlippageBps >= 0.0 {
+		t.Errorf("report.SlippageBps = %v, want < %v", report.SlippageBps, 0.0)
+	}
+}
+
+func TestExecutorMisses(t *testing.T) {
+	e, exchange, clock := newExecutor(t, Config{IsBuy: false, Sz: 1, Duration: time.Minute, Slices: 2, ReduceOnly: true})
+
+	// Without a position the reduce only children are refused, and retried by the next
+	// slice once there is one
+	child := step(t, e, exchange, 2000)
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if child.Filled != 0 {
+		t.Errorf("child.Filled = %v, want 0", child.Filled)
+	}
+	if got := types.ParseOrderError(child.Error).Status; got != types.OrderStatusReduceOnlyRejected {
+		t.Errorf("types.ParseOrderError(child.Error).Status = %q, want %q", got, types.OrderStatusReduceOnlyRejected)
+	}
+
+	_, err := exchange.MarketOpen("ETH", true, 2, nil, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("MarketOpen() error = %v", err)
+	}
+	clock.Advance(30 * time.Second)
+	child = step(t, e, exchange, 2000)
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if child.Filled != 1.0 {
+		t.Errorf("child.Filled = %v, want 1.0", child.Filled)
+	}
+	if got := e.Report().State; got != StateDone {
+		t.Errorf("e.Report().State = %q, want %q", got, StateDone)
+	}
+}
+
+type failingTrader struct {
+	client.Trader
+}
+
+func (failingTrader) Order(string, bool, float64, float64, types.OrderType, bool, *types.Cloid, *types.BuilderInfo) (*types.OrderResponse, error) {
+	return nil, errors.New("timeout")
+}
+
+func TestExecutorCancel(t *testing.T) {
+	e, err := NewExecutor(failingTrader{}, Config{Coin: "ETH", IsBuy: true, Sz: 1, Duration: time.Minute, Slices: 2, SzDecimals: 2})
+	if err != nil {
+		t.Fatalf("NewExecutor() error = %v", err)
+	}
+
+	// Steps on other coins or books without a mid are ignored
+	child, err := e.Step(ws.MarketView{Coin: "BTC", Mid: 50000})
+	if child != nil {
+		t.Errorf("child = %v, want nil", child)
+	}
+	if err != nil {
+		t.Errorf("Step() error = %v", err)
+	}
+	child, err = e.Step(ws.MarketView{Coin: "ETH"})
+	if child != nil {
+		t.Errorf("child = %v, want nil", child)
+	}
+	if err != nil {
+		t.Errorf("Step() error = %v", err)
+	}
+	if got := e.Report().State; got != StatePending {
+		t.Errorf("e.Report().State = %q, want %q", got, StatePending)
+	}
+
+	child, err = e.Step(view(2000))
+	if err == nil {
+		t.Error("Step(view(2000)) error = nil, want error")
+	}
+	if child == nil {
+		t.Fatal("child = nil")
+	}
+	if child.Error != "timeout" {
+		t.Errorf("child.Error = %q, want %q", child.Error, "timeout")
+	}
+
+	if err := e.Cancel(); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if err := e.Resume(); err == nil {
+		t.Error("Resume() error = nil, want error")
+	}
+	child, err = e.Step(view(2000))
+	if child != nil {
+		t.Errorf("child = %v, want nil", child)
+	}
+	if err != nil {
+		t.Errorf("Step(view(2000)) error = %v", err)
+	}
+	report := e.Report()
+	if report.State != StateCanceled {
+		t.Errorf("report.State = %q, want %q", report.State, StateCanceled)
+	}
+	if report.Remaining != 1.0 {
+		t.Errorf("report.Remaining = %v, want 1.0", report.Remaining)
+	}
+	if report.AvgPx != 0 {
+		t.Errorf("report.AvgPx = %v, want 0", report.AvgPx)
+	}
+}
+
+func TestNewExecutorErrors(t *testing.T) {
+	valid := Config{Coin: "ETH", Sz: 1, Duration: time.Minute, Slices: 2, SzDecimals: 2}
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+	}{
+		{"no coin", func(c *Config) { c.Coin = "" }},
+		{"size below lot", func(c *Config) { c.Sz = 0.001 }},
+		{"no duration", func(c *Config) { c.Duration = 0 }},
+		{"no slices", func(c *Config) { c.Slices = 0 }},
+		{"negative slippage", func(c *Config) { c.SlippageBps = -1 }},
+		{"weights of other slices", func(c *Config) { c.Weights = []float64{1} }},
+		{"negative weight", func(c *Config) { c.Weights = []float64{2, -1} }},
+		{"zero weights", func(c *Config) { c.Weights = []float64{0, 0} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			_, err := NewExecutor(failingTrader{}, c)
+			if err == nil {
+				t.Error("NewExecutor() error = nil, want error")
+			}
+		})
+	}
+}
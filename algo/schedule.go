@@ -0,0 +1,62 @@
+// Package algo executes large orders on the client side: an Executor slices a parent
+// order into Ioc child orders over time, evenly (TWAP) or along a volume profile
+// (VWAP), prices each child off the live best bid and offer, and measures its
+// execution against the arrival price. Unlike the exchange TWAP, it can be paused,
+// resumed and canceled, and bounds the price of every child:
+//
+//	e, err := algo.NewExecutor(exchange, algo.Config{
+//	    Coin:        "ETH",
+//	    IsBuy:       true,
+//	    Sz:          10,
+//	    Duration:    time.Hour,
+//	    Slices:      60,
+//	    SlippageBps: 5,
+//	    SzDecimals:  4,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	go e.Run(ctx, eth)
+//	...
+//	report := e.Report()
+//	fmt.Println(report.Filled, report.AvgPx, report.SlippageBps)
+package algo
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// Even returns the weights of slices of the same size, for a TWAP
+func Even(slices int) []float64 {
+	weights := make([]float64, slices)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return weights
+}
+
+// VolumeProfile returns the weights of slices following the volume of candles, for a
+// VWAP, e.g. the candles of the same hours of the previous day. The candles, oldest
+// first, are split into slices consecutive groups of about the same number.
+func VolumeProfile(candles []types.Candle, slices int) ([]float64, error) {
+	if slices <= 0 || len(candles) < slices {
+		return nil, fmt.Errorf("%d candles cannot be split into %d slices", len(candles), slices)
+	}
+	weights := make([]float64, slices)
+	var total float64
+	for i, c := range candles {
+		v, err := strconv.ParseFloat(c.V, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse candle volume: %w", err)
+		}
+		weights[i*slices/len(candles)] += v
+		total += v
+	}
+	if !(total > 0) {
+		return nil, fmt.Errorf("candles have no volume")
+	}
+	return weights, nil
+}
@@ -0,0 +1,45 @@
+package algo
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+func TestVolumeProfile(t *testing.T) {
+	candles := []types.Candle{{V: "1"}, {V: "2"}, {V: "3"}, {V: "4"}, {V: "5"}}
+
+	weights, err := VolumeProfile(candles, 2)
+	if err != nil {
+		t.Fatalf("VolumeProfile(candles, 2) error = %v", err)
+	}
+	if want := []float64{6, 9}; !slices.Equal(weights, want) {
+		t.Errorf("weights = %+v, want %+v", weights, want)
+	}
+
+	weights, err = VolumeProfile(candles, 5)
+	if err != nil {
+		t.Fatalf("VolumeProfile(candles, 5) error = %v", err)
+	}
+	if want := []float64{1, 2, 3, 4, 5}; !slices.Equal(weights, want) {
+		t.Errorf("weights = %+v, want %+v", weights, want)
+	}
+
+	_, err = VolumeProfile(candles, 6)
+	if err == nil {
+		t.Error("VolumeProfile(candles, 6) error = nil, want error")
+	}
+	_, err = VolumeProfile([]types.Candle{{V: "0"}}, 1)
+	if err == nil {
+		t.Error("VolumeProfile() error = nil, want error")
+	}
+	_, err = VolumeProfile([]types.Candle{{V: "x"}}, 1)
+	if err == nil {
+		t.Error("VolumeProfile() error = nil, want error")
+	}
+
+	if got, want := Even(3), []float64{1, 1, 1}; !slices.Equal(got, want) {
+		t.Errorf("Even(3) = %+v, want %+v", got, want)
+	}
+}
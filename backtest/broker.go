@@ -0,0 +1,481 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Default fee rates of the base tier of the exchange
+const (
+	DefaultMakerFee = 0.00015
+	DefaultTakerFee = 0.00045
+)
+
+// Config is the account simulated by a Broker
+type Config struct {
+	// InitialBalance is the USD balance the account starts with
+	InitialBalance float64
+	// MakerFee and TakerFee are the fee rates of resting and crossing fills,
+	// DefaultMakerFee and DefaultTakerFee if both are zero
+	MakerFee float64
+	TakerFee float64
+	// Slippage is the slippage of MarketOrder, constants.DefaultSlippage if zero
+	Slippage float64
+}
+
+// Fill is an execution of an order
+type Fill struct {
+	Time  int64
+	Coin  string
+	Oid   int
+	Cloid *types.Cloid
+	IsBuy bool
+	Px    float64
+	Sz    float64
+	// Crossed is true for taker fills, false for resting orders
+	Crossed bool
+	Fee     float64
+	// ClosedPnl is the PnL realized by the part of the fill reducing the position, fees
+	// excluded
+	ClosedPnl float64
+}
+
+// FundingPayment is the funding paid or received for a position, positive if received
+type FundingPayment struct {
+	Time    int64
+	Coin    string
+	Szi     float64
+	Px      float64
+	Rate    float64
+	Payment float64
+}
+
+// Position is the position of the account in a coin
+type Position struct {
+	Coin string
+	// Szi is the signed size, positive for longs
+	Szi         float64
+	EntryPx     float64
+	RealizedPnl float64
+}
+
+// UnrealizedPnl returns the PnL of the position at px
+func (p Position) UnrealizedPnl(px float64) float64 {
+	return p.Szi * (px - p.EntryPx)
+}
+
+// Order is an open order of a Broker
+type Order struct {
+	Oid     int
+	Time    int64
+	Request types.OrderRequest
+	// Triggered is true for trigger orders whose limit order rests on the book
+	Triggered bool
+	// seen is true once the order was matched against a price event: it then rests as a
+	// maker order instead of crossing as a taker
+	seen bool
+}
+
+// Broker simulates an account trading against replayed prices. Orders are matched
+// from the next price event after they are placed, so that they never fill at prices
+// the strategy has already seen:
+//
+//   - orders crossing the first price of the event fill there as takers. Ioc orders
+//     that do not are canceled, and so are crossing Alo orders.
+//   - resting orders fill at their limit price as makers once the price reaches it.
+//   - trigger orders trigger when the price reaches their trigger price, or at the
+//     first price if it is already beyond, and then execute as market or limit orders.
+//
+// Each order is matched independently and against unlimited liquidity. A Broker is
+// designed for single-threaded use.
+type Broker struct {
+	config    Config
+	cash      float64
+	time      int64
+	nextOid   int
+	prices    map[string]float64
+	orders    []*Order
+	positions map[string]*Position
+	fills     []Fill
+	fundings  []FundingPayment
+	onFill    func(Fill)
+}
+
+// NewBroker creates a broker of an account with config
+func NewBroker(config Config) *Broker {
+	if config.MakerFee == 0 && config.TakerFee == 0 {
+		config.MakerFee, config.TakerFee = DefaultMakerFee, DefaultTakerFee
+	}
+	if config.Slippage == 0 {
+		config.Slippage = constants.DefaultSlippage
+	}
+	return &Broker{
+		config:    config,
+		cash:      config.InitialBalance,
+		nextOid:   1,
+		prices:    make(map[string]float64),
+		positions: make(map[string]*Position),
+	}
+}
+
+// OnFill sets a callback invoked with every fill
+func (b *Broker) OnFill(fn func(Fill)) {
+	b.onFill = fn
+}
+
+// Time returns the time of the last price event, in milliseconds
+func (b *Broker) Time() int64 {
+	return b.time
+}
+
+// Price returns the last price of coin
+func (b *Broker) Price(coin string) (float64, bool) {
+	px, ok := b.prices[coin]
+	return px, ok
+}
+
+// Order places an order, matched from the next price event. The status is resting
+// with the oid of the order, or an error if the order is invalid.
+func (b *Broker) Order(req types.OrderRequest) types.OrderStatus {
+	if msg := checkOrder(req); msg != "" {
+		return types.OrderStatus{Error: msg}
+	}
+	order := &Order{Oid: b.nextOid, Time: b.time, Request: req}
+	b.nextOid++
+	b.orders = append(b.orders, order)
+	return types.OrderStatus{Resting: &types.RestingOrder{Oid: order.Oid}}
+}
+
+// MarketOrder places an Ioc order priced the slippage of the config away from the last
+// price of coin
+func (b *Broker) MarketOrder(coin string, isBuy bool, sz float64, reduceOnly bool) types.OrderStatus {
+	px, ok := b.prices[coin]
+	if !ok {
+		return types.OrderStatus{Error: fmt.Sprintf("No price for %s yet.", coin)}
+	}
+	return b.Order(types.OrderRequest{
+		Coin:       coin,
+		IsBuy:      isBuy,
+		Sz:         sz,
+		LimitPx:    utils.SlippagePx(px, utils.FractionToBps(b.config.Slippage), isBuy),
+		OrderType:  types.NewLimit(types.TifIoc),
+		ReduceOnly: reduceOnly,
+	})
+}
+
+// Cancel cancels an open order
+func (b *Broker) Cancel(oid int) error {
+	for i, order := range b.orders {
+		if order.Oid == oid {
+			b.orders = append(b.orders[:i], b.orders[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("order %d is not open", oid)
+}
+
+// OpenOrders returns the open orders, oldest first
+func (b *Broker) OpenOrders() []Order {
+	orders := make([]Order, len(b.orders))
+	for i, order := range b.orders {
+		orders[i] = *order
+	}
+	return orders
+}
+
+// Position returns the position in coin, zero if there is none
+func (b *Broker) Position(coin string) Position {
+	if p, ok := b.positions[coin]; ok {
+		return *p
+	}
+	return Position{Coin: coin}
+}
+
+// Positions returns the open positions, by coin
+func (b *Broker) Positions() []Position {
+	var positions []Position
+	for _, p := range b.positions {
+		if p.Szi != 0 {
+			positions = append(positions, *p)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Coin < positions[j].Coin })
+	return positions
+}
+
+// Cash returns the balance, with realized PnL, fees and funding
+func (b *Broker) Cash() float64 {
+	return b.cash
+}
+
+// Equity returns the balance with the unrealized PnL of the positions at the last prices
+func (b *Broker) Equity() float64 {
+	equity := b.cash
+	for coin, p := range b.positions {
+		if px, ok := b.prices[coin]; ok {
+			equity += p.UnrealizedPnl(px)
+		}
+	}
+	return equity
+}
+
+// Fills returns the fills so far, in order
+func (b *Broker) Fills() []Fill {
+	return append([]Fill(nil), b.fills...)
+}
+
+// Fundings returns the funding payments so far, in order
+func (b *Broker) Fundings() []FundingPayment {
+	return append([]FundingPayment(nil), b.fundings...)
+}
+
+// MatchCandle matches the open orders of the coin of candle against its prices, then
+// sets the last price to its close. The path within the candle is assumed to reach the
+// nearer extreme first: the low before the high for an up candle.
+func (b *Broker) MatchCandle(candle types.OHLCV) {
+	path := []float64{candle.Open, candle.High, candle.Low, candle.Close}
+	if candle.Close >= candle.Open {
+		path[1], path[2] = candle.Low, candle.High
+	}
+	b.match(candle.Coin, candle.End, path, path, candle.Close)
+}
+
+// MatchTrade matches the open orders of coin against a trade at px
+func (b *Broker) MatchTrade(coin string, px float64, time int64) {
+	path := []float64{px}
+	b.match(coin, time, path, path, px)
+}
+
+// MatchQuote matches the open buy orders of coin against the best ask and the sell
+// orders against the best bid, and sets the last price to their middle. A zero bid or
+// ask is a side without orders, which leaves the orders matching it open.
+func (b *Broker) MatchQuote(coin string, bid, ask float64, time int64) {
+	var buyPath, sellPath []float64
+	if ask > 0 {
+		buyPath = []float64{ask}
+	}
+	if bid > 0 {
+		sellPath = []float64{bid}
+	}
+	last := (bid + ask) / 2
+	if bid == 0 || ask == 0 {
+		last = bid + ask
+	}
+	b.match(coin, time, buyPath, sellPath, last)
+}
+
+// ApplyFunding pays the funding of the position in coin at rate and the last price.
+// Longs pay shorts when rate is positive.
+func (b *Broker) ApplyFunding(coin string, rate float64, time int64) {
+	p, ok := b.positions[coin]
+	px, hasPx := b.prices[coin]
+	if !ok || !hasPx || p.Szi == 0 {
+		return
+	}
+	payment := -p.Szi * px * rate
+	b.cash += payment
+	b.fundings = append(b.fundings, FundingPayment{Time: time, Coin: coin, Szi: p.Szi, Px: px, Rate: rate, Payment: payment})
+}
+
+// match matches the open orders of coin against the path of prices of their side at
+// time, then sets the last price of coin
+func (b *Broker) match(coin string, time int64, buyPath, sellPath []float64, last float64) {
+	if time > b.time {
+		b.time = time
+	}
+	open := b.orders[:0]
+	for _, order := range b.orders {
+		path := sellPath
+		if order.Request.IsBuy {
+			path = buyPath
+		}
+		if order.Request.Coin != coin || len(path) == 0 || b.matchOrder(order, path) {
+			open = append(open, order)
+		}
+	}
+	// Clear the tail so removed orders can be collected
+	for i := len(open); i < len(b.orders); i++ {
+		b.orders[i] = nil
+	}
+	b.orders = open
+	if last > 0 {
+		b.prices[coin] = last
+	}
+}
+
+// matchOrder matches order against path, returning whether it stays open
+func (b *Broker) matchOrder(order *Order, path []float64) bool {
+	req := order.Request
+	if trigger := req.OrderType.Trigger; trigger != nil && !order.Triggered {
+		i, px, ok := triggerPoint(req.IsBuy, trigger, path)
+		if !ok {
+			return true
+		}
+		if trigger.IsMarket {
+			b.fill(order, px, true)
+			return false
+		}
+		// The limit order of the trigger crosses at the trigger price, else rests
+		order.Triggered = true
+		order.seen = true
+		if crosses(req.IsBuy, req.LimitPx, px) {
+			b.fill(order, px, true)
+			return false
+		}
+		return b.rest(order, path[i:])
+	}
+
+	if !order.seen {
+		order.seen = true
+		tif := types.TifGtc
+		if req.OrderType.Limit != nil {
+			tif = req.OrderType.Limit.Tif
+		}
+		crossing := crosses(req.IsBuy, req.LimitPx, path[0])
+		switch {
+		case crossing && tif == types.TifAlo:
+			return false
+		case crossing:
+			b.fill(order, path[0], true)
+			return false
+		case tif == types.TifIoc:
+			return false
+		}
+	}
+	return b.rest(order, path)
+}
+
+// rest fills a resting order at its limit price if path reaches it, returning whether
+// it stays open
+func (b *Broker) rest(order *Order, path []float64) bool {
+	for _, px := range path {
+		if crosses(order.Request.IsBuy, order.Request.LimitPx, px) {
+			b.fill(order, order.Request.LimitPx, false)
+			return false
+		}
+	}
+	return true
+}
+
+// fill executes order at px, clamping reduce-only orders to the position
+func (b *Broker) fill(order *Order, px float64, crossed bool) {
+	req := order.Request
+	p := b.positions[req.Coin]
+	if p == nil {
+		p = &Position{Coin: req.Coin}
+		b.positions[req.Coin] = p
+	}
+	sz := req.Sz
+	if req.ReduceOnly {
+		if (req.IsBuy && p.Szi >= 0) || (!req.IsBuy && p.Szi <= 0) {
+			return
+		}
+		sz = math.Min(sz, math.Abs(p.Szi))
+	}
+
+	rate := b.config.MakerFee
+	if crossed {
+		rate = b.config.TakerFee
+	}
+	f := Fill{
+		Time:    b.time,
+		Coin:    req.Coin,
+		Oid:     order.Oid,
+		Cloid:   req.Cloid,
+		IsBuy:   req.IsBuy,
+		Px:      px,
+		Sz:      sz,
+		Crossed: crossed,
+		Fee:     px * sz * rate,
+	}
+	f.ClosedPnl = p.apply(req.IsBuy, sz, px)
+	b.cash += f.ClosedPnl - f.Fee
+	b.fills = append(b.fills, f)
+	if b.onFill != nil {
+		b.onFill(f)
+	}
+}
+
+// apply adds a fill to the position, returning the PnL it realizes
+func (p *Position) apply(isBuy bool, sz, px float64) float64 {
+	delta := sz
+	if !isBuy {
+		delta = -sz
+	}
+	var closedPnl float64
+	if p.Szi != 0 && (p.Szi > 0) != (delta > 0) {
+		closed := math.Min(math.Abs(delta), math.Abs(p.Szi))
+		if p.Szi > 0 {
+			closedPnl = closed * (px - p.EntryPx)
+		} else {
+			closedPnl = closed * (p.EntryPx - px)
+		}
+	}
+
+	szi := p.Szi + delta
+	switch {
+	case math.Abs(szi) < 1e-12:
+		szi, p.EntryPx = 0, 0
+	case p.Szi == 0 || (p.Szi > 0) != (szi > 0):
+		// Opened, or flipped: the remainder is entered at px
+		p.EntryPx = px
+	case math.Abs(szi) > math.Abs(p.Szi):
+		p.EntryPx = (p.EntryPx*math.Abs(p.Szi) + px*sz) / math.Abs(szi)
+	}
+	p.Szi = szi
+	p.RealizedPnl += closedPnl
+	return closedPnl
+}
+
+// crosses reports whether a limit order at limitPx trades against px
+func crosses(isBuy bool, limitPx, px float64) bool {
+	if isBuy {
+		return px <= limitPx
+	}
+	return px >= limitPx
+}
+
+// triggerPoint returns the index of the segment of path where a trigger order triggers
+// and the price it executes at: the trigger price, or the first price if it is beyond
+func triggerPoint(isBuy bool, trigger *types.TriggerOrderType, path []float64) (int, float64, bool) {
+	// Stop losses buy above and sell below the trigger, take profits the other way
+	above := isBuy == (trigger.Tpsl == types.TpslSl)
+	reached := func(px float64) bool {
+		if above {
+			return px >= trigger.TriggerPx
+		}
+		return px <= trigger.TriggerPx
+	}
+	if reached(path[0]) {
+		return 0, path[0], true
+	}
+	for i := 1; i < len(path); i++ {
+		if reached(path[i]) {
+			return i, trigger.TriggerPx, true
+		}
+	}
+	return 0, 0, false
+}
+
+// checkOrder returns the error message of orders that could never be matched, in the
+// words of the exchange
+func checkOrder(req types.OrderRequest) string {
+	switch {
+	case req.Coin == "":
+		return "Order has invalid asset."
+	case !(req.Sz > 0):
+		return "Order has invalid size."
+	case !(req.LimitPx > 0):
+		return "Order has invalid price."
+	case req.OrderType.Check() != nil:
+		return types.ErrInvalidOrderType.Error()
+	case req.OrderType.Trigger != nil && !(req.OrderType.Trigger.TriggerPx > 0):
+		return "Order has invalid TP/SL price."
+	}
+	return ""
+}
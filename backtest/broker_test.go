@@ -0,0 +1,303 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+func candle(end int64, o, h, l, c float64) types.OHLCV {
+	return types.OHLCV{Coin: "BTC", Start: end - 60000, End: end, Open: o, High: h, Low: l, Close: c}
+}
+
+func TestBrokerMatching(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     types.OrderRequest
+		candle  types.OHLCV
+		px      float64
+		crossed bool
+		filled  bool
+		open    bool
+	}{
+		{
+			name:   "resting buy fills at its price",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: 95, OrderType: types.NewLimit(types.TifGtc)},
+			candle: candle(1, 100, 101, 94, 99),
+			px:     95, filled: true,
+		},
+		{
+			name:   "resting buy not reached",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: 90, OrderType: types.NewLimit(types.TifGtc)},
+			candle: candle(1, 100, 101, 94, 99),
+			open:   true,
+		},
+		{
+			name:   "crossing buy takes the open",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: 105, OrderType: types.NewLimit(types.TifGtc)},
+			candle: candle(1, 100, 101, 94, 99),
+			px:     100, crossed: true, filled: true,
+		},
+		{
+			name:   "crossing alo is canceled",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: false, Sz: 1, LimitPx: 95, OrderType: types.NewLimit(types.TifAlo)},
+			candle: candle(1, 100, 101, 94, 99),
+		},
+		{
+			name:   "ioc not crossing is canceled",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: 95, OrderType: types.NewLimit(types.TifIoc)},
+			candle: candle(1, 100, 101, 94, 99),
+		},
+		{
+			name:   "stop loss triggers at its price",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: false, Sz: 1, LimitPx: 90, OrderType: types.NewStopLoss(96, true)},
+			candle: candle(1, 100, 101, 94, 99),
+			px:     96, crossed: true, filled: true,
+		},
+		{
+			name:   "stop loss gapped through fills at the open",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: false, Sz: 1, LimitPx: 80, OrderType: types.NewStopLoss(96, true)},
+			candle: candle(1, 93, 95, 90, 94),
+			px:     93, crossed: true, filled: true,
+		},
+		{
+			name:   "take profit limit rests after triggering",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: false, Sz: 1, LimitPx: 103, OrderType: types.NewTakeProfit(101, false)},
+			candle: candle(1, 100, 102, 99, 101),
+			open:   true,
+		},
+		{
+			name:   "take profit limit crossing at the trigger",
+			req:    types.OrderRequest{Coin: "BTC", IsBuy: false, Sz: 1, LimitPx: 100, OrderType: types.NewTakeProfit(101, false)},
+			candle: candle(1, 100, 102, 99, 101),
+			px:     101, crossed: true, filled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroker(Config{InitialBalance: 1000})
+			status := b.Order(tt.req)
+			if status.Resting == nil {
+				t.Fatalf("Order() = %+v, want resting", status)
+			}
+			b.MatchCandle(tt.candle)
+
+			fills := b.Fills()
+			if !tt.filled {
+				if len(fills) != 0 {
+					t.Errorf("fills = %+v, want empty", fills)
+				}
+			} else {
+				if len(fills) != 1 {
+					t.Fatalf("len(fills) = %d, want 1", len(fills))
+				}
+				if fills[0].Px != tt.px {
+					t.Errorf("fills[0].Px = %v, want %v", fills[0].Px, tt.px)
+				}
+				if fills[0].Crossed != tt.crossed {
+					t.Errorf("fills[0].Crossed = %v, want %v", fills[0].Crossed, tt.crossed)
+				}
+				if fills[0].Oid != status.Resting.Oid {
+					t.Errorf("fills[0].Oid = %v, want %v", fills[0].Oid, status.Resting.Oid)
+				}
+			}
+			if got := len(b.OpenOrders()) == 1; got != tt.open {
+				t.Errorf("len(b.OpenOrders()) == 1 = %v, want %v", got, tt.open)
+			}
+		})
+	}
+}
+
+func TestBrokerOrdersMatchFromNextEvent(t *testing.T) {
+	b := NewBroker(Config{InitialBalance: 1000})
+	b.MatchCandle(candle(1, 100, 101, 94, 99))
+
+	status := b.MarketOrder("BTC", true, 1, false)
+	if status.Resting == nil {
+		t.Fatal("status.Resting = nil")
+	}
+	if got := b.Fills(); len(got) != 0 {
+		t.Errorf("Fills() = %+v, want empty", got)
+	}
+
+	b.MatchCandle(candle(2, 98, 99, 97, 98))
+	fills := b.Fills()
+	if len(fills) != 1 {
+		t.Fatalf("len(fills) = %d, want 1", len(fills))
+	}
+	if fills[0].Px != 98.0 {
+		t.Errorf("fills[0].Px = %v, want 98.0", fills[0].Px)
+	}
+	if fills[0].Time != int64(2) {
+		t.Errorf("fills[0].Time = %v, want int64(2)", fills[0].Time)
+	}
+	if got := b.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+
+	if got := b.MarketOrder("ETH", true, 1, false).Error; got == "" {
+		t.Errorf("b.MarketOrder(ETH, true, 1, false).Error = %q, want not empty", got)
+	}
+}
+
+func TestBrokerAccounting(t *testing.T) {
+	b := NewBroker(Config{InitialBalance: 1000, MakerFee: 0.001, TakerFee: 0.002})
+	b.MatchTrade("BTC", 100, 1)
+
+	b.Order(types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 2, LimitPx: 100, OrderType: types.NewLimit(types.TifIoc)})
+	b.MatchTrade("BTC", 100, 2)
+	if got, want := b.Position("BTC"), (Position{Coin: "BTC", Szi: 2, EntryPx: 100}); got != want {
+		t.Errorf("Position(BTC) = %+v, want %+v", got, want)
+	}
+	if got := b.Cash(); math.Abs(got-(1000-0.4)) > 1e-9 {
+		t.Errorf("Cash() = %v, want %v", got, 1000-0.4)
+	}
+
+	b.MatchTrade("BTC", 110, 3)
+	if got := b.Equity(); math.Abs(got-(1000-0.4+20)) > 1e-9 {
+		t.Errorf("Equity() = %v, want %v", got, 1000-0.4+20)
+	}
+
+	// Longs pay positive funding
+	b.ApplyFunding("BTC", 0.001, 4)
+	if got := b.Fundings(); len(got) != 1 {
+		t.Fatalf("len(Fundings()) = %d, want 1", len(got))
+	}
+	if got := b.Fundings()[0].Payment; math.Abs(got-(-0.22)) > 1e-9 {
+		t.Errorf("b.Fundings()[0].Payment = %v, want %v", got, -0.22)
+	}
+
+	// A reduce only order larger than the position only closes it
+	b.Order(types.OrderRequest{Coin: "BTC", IsBuy: false, Sz: 5, LimitPx: 120, OrderType: types.NewLimit(types.TifGtc), ReduceOnly: true})
+	b.MatchTrade("BTC", 115, 5)
+	b.MatchTrade("BTC", 121, 6)
+	fills := b.Fills()
+	if len(fills) != 2 {
+		t.Fatalf("len(fills) = %d, want 2", len(fills))
+	}
+	if fills[1].Sz != 2.0 {
+		t.Errorf("fills[1].Sz = %v, want 2.0", fills[1].Sz)
+	}
+	if math.Abs(fills[1].ClosedPnl-40) > 1e-9 {
+		t.Errorf("fills[1].ClosedPnl = %v, want %v", fills[1].ClosedPnl, 40)
+	}
+	if math.Abs(fills[1].Fee-0.24) > 1e-9 {
+		t.Errorf("fills[1].Fee = %v, want %v", fills[1].Fee, 0.24)
+	}
+	if got := b.Position("BTC").Szi; got != 0.0 {
+		t.Errorf("b.Position(BTC).Szi = %v, want 0.0", got)
+	}
+	if got := b.Positions(); len(got) != 0 {
+		t.Errorf("Positions() = %+v, want empty", got)
+	}
+	if got := b.Cash(); math.Abs(got-(1000-0.4-0.22+40-0.24)) > 1e-9 {
+		t.Errorf("Cash() = %v, want %v", got, 1000-0.4-0.22+40-0.24)
+	}
+	if got := b.Equity(); math.Abs(got-b.Cash()) > 1e-9 {
+		t.Errorf("Equity() = %v, want %v", got, b.Cash())
+	}
+}
+
+func TestPositionApply(t *testing.T) {
+	p := &Position{Coin: "BTC"}
+	if got := p.apply(true, 1, 100); got != 0.0 {
+		t.Errorf("apply(true, 1, 100) = %v, want 0.0", got)
+	}
+	if got := p.apply(true, 1, 110); got != 0.0 {
+		t.Errorf("apply(true, 1, 110) = %v, want 0.0", got)
+	}
+	if p.EntryPx != 105.0 {
+		t.Errorf("p.EntryPx = %v, want 105.0", p.EntryPx)
+	}
+
+	// Flipping realizes the long and enters the short at the price
+	if got := p.apply(false, 3, 120); math.Abs(got-(2*(120-105.0))) > 1e-9 {
+		t.Errorf("apply(false, 3, 120) = %v, want %v", got, 2*(120-105.0))
+	}
+	if p.Szi != -1.0 {
+		t.Errorf("p.Szi = %v, want -1.0", p.Szi)
+	}
+	if p.EntryPx != 120.0 {
+		t.Errorf("p.EntryPx = %v, want 120.0", p.EntryPx)
+	}
+	if got := p.apply(true, 1, 115); math.Abs(got-5) > 1e-9 {
+		t.Errorf("apply(true, 1, 115) = %v, want %v", got, 5)
+	}
+	if want := (Position{Coin: "BTC", RealizedPnl: 35}); *p != want {
+		t.Errorf("*p = %+v, want %+v", *p, want)
+	}
+}
+
+func TestBrokerRejectsAndCancels(t *testing.T) {
+	b := NewBroker(Config{})
+	status := b.Order(types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 0, LimitPx: 100, OrderType: types.NewLimit(types.TifGtc)})
+	if status.Error != "Order has invalid size." {
+		t.Errorf("status.Error = %q, want %q", status.Error, "Order has invalid size.")
+	}
+	status = b.Order(types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: math.NaN(), OrderType: types.NewLimit(types.TifGtc)})
+	if got := types.ParseOrderError(status.Error).Status; got != types.OrderStatusTickRejected {
+		t.Errorf("types.ParseOrderError(status.Error).Status = %q, want %q", got, types.OrderStatusTickRejected)
+	}
+
+	status = b.Order(types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: 100, OrderType: types.NewLimit(types.TifGtc)})
+	if status.Resting == nil {
+		t.Fatal("status.Resting = nil")
+	}
+	if err := b.Cancel(status.Resting.Oid); err != nil {
+		t.Fatalf("Cancel(status.Resting.Oid) error = %v", err)
+	}
+	if err := b.Cancel(status.Resting.Oid); err == nil {
+		t.Error("Cancel(status.Resting.Oid) error = nil, want error")
+	}
+	if got := b.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+}
+
+func TestBrokerMatchQuote(t *testing.T) {
+	b := NewBroker(Config{InitialBalance: 1000})
+	buy := b.Order(types.OrderRequest{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: 101, OrderType: types.NewLimit(types.TifGtc)})
+	sell := b.Order(types.OrderRequest{Coin: "BTC", IsBuy: false, Sz: 1, LimitPx: 103, OrderType: types.NewLimit(types.TifGtc)})
+
+	// Without asks the buy stays open
+	b.MatchQuote("BTC", 99, 0, 1)
+	if got := b.OpenOrders(); len(got) != 2 {
+		t.Errorf("len(OpenOrders()) = %d, want 2", len(got))
+	}
+	px, _ := b.Price("BTC")
+	if px != 99.0 {
+		t.Errorf("px = %v, want 99.0", px)
+	}
+
+	// Buys take the ask and sells rest until the bid reaches them
+	b.MatchQuote("BTC", 100, 100.5, 2)
+	if got := b.Fills(); len(got) != 1 {
+		t.Fatalf("len(Fills()) = %d, want 1", len(got))
+	}
+	if got := b.Fills()[0].Oid; got != buy.Resting.Oid {
+		t.Errorf("b.Fills()[0].Oid = %v, want %v", got, buy.Resting.Oid)
+	}
+	if got := b.Fills()[0].Px; got != 100.5 {
+		t.Errorf("b.Fills()[0].Px = %v, want 100.5", got)
+	}
+	px, _ = b.Price("BTC")
+	if px != 100.25 {
+		t.Errorf("px = %v, want 100.25", px)
+	}
+
+	b.MatchQuote("BTC", 104, 105, 3)
+	if got := b.Fills(); len(got) != 2 {
+		t.Fatalf("len(Fills()) = %d, want 2", len(got))
+	}
+	if got := b.Fills()[1].Oid; got != sell.Resting.Oid {
+		t.Errorf("b.Fills()[1].Oid = %v, want %v", got, sell.Resting.Oid)
+	}
+	if got := b.Fills()[1].Px; got != 103.0 {
+		t.Errorf("b.Fills()[1].Px = %v, want 103.0", got)
+	}
+	if b.Fills()[1].Crossed {
+		t.Error("b.Fills()[1].Crossed = true")
+	}
+}
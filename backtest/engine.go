@@ -0,0 +1,209 @@
+// Package backtest replays historical candles, trades and funding through a Strategy
+// trading against a simulated Broker, producing the fills, funding payments and equity
+// curve of the strategy:
+//
+//	snapshot, _ := info.CandlesSnapshot("BTC", string(types.Interval1h), start, end)
+//	candles, _ := types.CandlesToOHLCV(snapshot)
+//	history, _ := info.FundingHistory("BTC", start, &end)
+//	funding, _ := backtest.FundingRates("BTC", history)
+//	result, err := backtest.Run(backtest.Config{InitialBalance: 10000}, strategy,
+//	    backtest.Data{Candles: candles, Funding: funding})
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// Strategy is called with each candle, after the open orders were matched against it.
+// Orders it places on the broker are matched from the next candle or trade of their
+// coin.
+type Strategy interface {
+	OnCandle(b *Broker, candle types.OHLCV)
+}
+
+// TradeStrategy is a Strategy also called with each trade
+type TradeStrategy interface {
+	Strategy
+	OnTrade(b *Broker, trade Trade)
+}
+
+// FillStrategy is a Strategy also called with each of its fills
+type FillStrategy interface {
+	Strategy
+	OnFill(b *Broker, fill Fill)
+}
+
+// StrategyFunc is a Strategy only using candles
+type StrategyFunc func(b *Broker, candle types.OHLCV)
+
+// OnCandle calls f
+func (f StrategyFunc) OnCandle(b *Broker, candle types.OHLCV) {
+	f(b, candle)
+}
+
+// Trade is a historical trade
+type Trade struct {
+	Coin string
+	Px   float64
+	Sz   float64
+	// Time is in milliseconds
+	Time int64
+}
+
+// FundingRate is the funding rate of a coin at a time, in milliseconds
+type FundingRate struct {
+	Coin string
+	Rate float64
+	Time int64
+}
+
+// Data is the history replayed by Run. Events are replayed by time: candles at their
+// end, after the trades and funding of the same time.
+type Data struct {
+	Candles []types.OHLCV
+	Trades  []Trade
+	Funding []FundingRate
+}
+
+// FundingRates converts the funding history of coin
+func FundingRates(coin string, records []types.FundingRecord) ([]FundingRate, error) {
+	rates := make([]FundingRate, len(records))
+	for i, r := range records {
+		rate, err := strconv.ParseFloat(r.Rate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse funding rate %q: %w", r.Rate, err)
+		}
+		rates[i] = FundingRate{Coin: coin, Rate: rate, Time: r.Time}
+	}
+	return rates, nil
+}
+
+// EquityPoint is the equity of the account after a candle
+type EquityPoint struct {
+	Time   int64
+	Equity float64
+}
+
+// Result is the outcome of a backtest
+type Result struct {
+	Fills    []Fill
+	Fundings []FundingPayment
+	// Equity is the equity curve, a point per candle time
+	Equity []EquityPoint
+	// Positions are the positions left open at the end
+	Positions   []Position
+	FinalEquity float64
+	TotalFees   float64
+	// TotalFunding is the funding received, negative if paid
+	TotalFunding float64
+	// MaxDrawdown is the largest fall of the equity from a peak, as a fraction of the peak
+	MaxDrawdown float64
+}
+
+// Return returns the return of the backtest, as a fraction of the initial balance
+func (r *Result) Return(initialBalance float64) float64 {
+	if initialBalance == 0 {
+		return 0
+	}
+	return r.FinalEquity/initialBalance - 1
+}
+
+// event kinds, in their order at the same time
+const (
+	fundingEvent = iota
+	tradeEvent
+	candleEvent
+)
+
+type event struct {
+	time  int64
+	kind  int
+	index int
+}
+
+// Run replays data through strategy and the broker of config
+func Run(config Config, strategy Strategy, data Data) (*Result, error) {
+	if strategy == nil {
+		return nil, errors.New("strategy is required")
+	}
+	if len(data.Candles) == 0 && len(data.Trades) == 0 {
+		return nil, errors.New("no candles or trades to replay")
+	}
+
+	events := make([]event, 0, len(data.Candles)+len(data.Trades)+len(data.Funding))
+	for i, c := range data.Candles {
+		events = append(events, event{time: c.End, kind: candleEvent, index: i})
+	}
+	for i, t := range data.Trades {
+		events = append(events, event{time: t.Time, kind: tradeEvent, index: i})
+	}
+	for i, f := range data.Funding {
+		events = append(events, event{time: f.Time, kind: fundingEvent, index: i})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].time != events[j].time {
+			return events[i].time < events[j].time
+		}
+		return events[i].kind < events[j].kind
+	})
+
+	b := NewBroker(config)
+	if s, ok := strategy.(FillStrategy); ok {
+		b.OnFill(func(f Fill) { s.OnFill(b, f) })
+	}
+	tradeStrategy, _ := strategy.(TradeStrategy)
+
+	result := &Result{}
+	for i, e := range events {
+		switch e.kind {
+		case fundingEvent:
+			f := data.Funding[e.index]
+			b.ApplyFunding(f.Coin, f.Rate, f.Time)
+		case tradeEvent:
+			t := data.Trades[e.index]
+			b.MatchTrade(t.Coin, t.Px, t.Time)
+			if tradeStrategy != nil {
+				tradeStrategy.OnTrade(b, t)
+			}
+		case candleEvent:
+			c := data.Candles[e.index]
+			b.MatchCandle(c)
+			strategy.OnCandle(b, c)
+			// A point per time, after its last candle
+			if i+1 == len(events) || events[i+1].time != e.time {
+				result.Equity = append(result.Equity, EquityPoint{Time: e.time, Equity: b.Equity()})
+			}
+		}
+	}
+
+	result.Fills = b.Fills()
+	result.Fundings = b.Fundings()
+	result.Positions = b.Positions()
+	result.FinalEquity = b.Equity()
+	for _, f := range result.Fills {
+		result.TotalFees += f.Fee
+	}
+	for _, f := range result.Fundings {
+		result.TotalFunding += f.Payment
+	}
+	result.MaxDrawdown = maxDrawdown(result.Equity)
+	return result, nil
+}
+
+// maxDrawdown returns the largest fall of the curve from a previous peak, as a fraction
+func maxDrawdown(curve []EquityPoint) float64 {
+	var peak, drawdown float64
+	for _, p := range curve {
+		peak = math.Max(peak, p.Equity)
+		if peak > 0 {
+			drawdown = math.Max(drawdown, (peak-p.Equity)/peak)
+		}
+	}
+	return drawdown
+}
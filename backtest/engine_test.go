@@ -0,0 +1,160 @@
+package backtest
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// breakout buys once when the close is above level and sells at target
+type breakout struct {
+	level, target float64
+	entered       bool
+	fills         []Fill
+	trades        int
+}
+
+func (s *breakout) OnCandle(b *Broker, c types.OHLCV) {
+	if !s.entered && c.Close > s.level {
+		s.entered = true
+		b.MarketOrder(c.Coin, true, 1, false)
+		b.Order(types.OrderRequest{Coin: c.Coin, IsBuy: false, Sz: 1, LimitPx: s.target, OrderType: types.NewLimit(types.TifGtc), ReduceOnly: true})
+	}
+}
+
+func (s *breakout) OnTrade(b *Broker, trade Trade) {
+	s.trades++
+}
+
+func (s *breakout) OnFill(b *Broker, fill Fill) {
+	s.fills = append(s.fills, fill)
+}
+
+func TestRun(t *testing.T) {
+	data := Data{
+		Candles: []types.OHLCV{
+			candle(60000, 100, 102, 99, 101),
+			candle(120000, 102, 106, 101, 105),
+			candle(180000, 104, 108, 103, 107),
+			candle(240000, 107, 112, 106, 111),
+			candle(300000, 111, 111, 100, 101),
+		},
+		Trades:  []Trade{{Coin: "BTC", Px: 103, Sz: 1, Time: 150000}},
+		Funding: []FundingRate{{Coin: "BTC", Rate: 0.001, Time: 180000}},
+	}
+	s := &breakout{level: 104, target: 110}
+	result, err := Run(Config{InitialBalance: 1000, MakerFee: 0.001, TakerFee: 0.002}, s, data)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// The breakout of the second candle buys the open of the trade after it, and the
+	// target fills as a maker in the fourth candle
+	if len(result.Fills) != 2 {
+		t.Fatalf("len(result.Fills) = %d, want 2", len(result.Fills))
+	}
+	if math.Abs(result.Fills[0].Fee-0.206) > 1e-9 {
+		t.Errorf("result.Fills[0].Fee = %v, want %v", result.Fills[0].Fee, 0.206)
+	}
+	result.Fills[0].Fee = 0
+	if want := (Fill{Time: 150000, Coin: "BTC", Oid: 1, IsBuy: true, Px: 103, Sz: 1, Crossed: true}); !reflect.DeepEqual(result.Fills[0], want) {
+		t.Errorf("result.Fills[0] = %+v, want %+v", result.Fills[0], want)
+	}
+	if result.Fills[1].Px != 110.0 {
+		t.Errorf("result.Fills[1].Px = %v, want 110.0", result.Fills[1].Px)
+	}
+	if result.Fills[1].Crossed {
+		t.Error("result.Fills[1].Crossed = true")
+	}
+	if result.Fills[1].Time != int64(240000) {
+		t.Errorf("result.Fills[1].Time = %v, want int64(240000)", result.Fills[1].Time)
+	}
+	if math.Abs(result.Fills[1].ClosedPnl-7) > 1e-9 {
+		t.Errorf("result.Fills[1].ClosedPnl = %v, want %v", result.Fills[1].ClosedPnl, 7)
+	}
+	if len(s.fills) != 2 {
+		t.Fatalf("len(s.fills) = %d, want 2", len(s.fills))
+	}
+	if !reflect.DeepEqual(s.fills[1], result.Fills[1]) {
+		t.Errorf("s.fills[1] = %+v, want %+v", s.fills[1], result.Fills[1])
+	}
+	if s.trades != 1 {
+		t.Errorf("s.trades = %v, want 1", s.trades)
+	}
+
+	// Funding is paid on the last price, the trade, before the candle of its time
+	if len(result.Fundings) != 1 {
+		t.Fatalf("len(result.Fundings) = %d, want 1", len(result.Fundings))
+	}
+	if math.Abs(result.Fundings[0].Payment-(-0.103)) > 1e-9 {
+		t.Errorf("result.Fundings[0].Payment = %v, want %v", result.Fundings[0].Payment, -0.103)
+	}
+	if math.Abs(result.TotalFunding-(-0.103)) > 1e-9 {
+		t.Errorf("result.TotalFunding = %v, want %v", result.TotalFunding, -0.103)
+	}
+	if math.Abs(result.TotalFees-(0.206+0.11)) > 1e-9 {
+		t.Errorf("result.TotalFees = %v, want %v", result.TotalFees, 0.206+0.11)
+	}
+
+	if len(result.Equity) != 5 {
+		t.Fatalf("len(result.Equity) = %d, want 5", len(result.Equity))
+	}
+	if want := (EquityPoint{Time: 60000, Equity: 1000}); result.Equity[0] != want {
+		t.Errorf("result.Equity[0] = %+v, want %+v", result.Equity[0], want)
+	}
+	if math.Abs(result.Equity[2].Equity-(1000-0.206-0.103+4)) > 1e-9 {
+		t.Errorf("result.Equity[2].Equity = %v, want %v", result.Equity[2].Equity, 1000-0.206-0.103+4)
+	}
+	final := 1000 + 7 - 0.206 - 0.11 - 0.103
+	if math.Abs(result.FinalEquity-final) > 1e-9 {
+		t.Errorf("result.FinalEquity = %v, want %v", result.FinalEquity, final)
+	}
+	if got := result.Return(1000); math.Abs(got-(final/1000-1)) > 1e-9 {
+		t.Errorf("Return(1000) = %v, want %v", got, final/1000-1)
+	}
+	if len(result.Positions) != 0 {
+		t.Errorf("result.Positions = %+v, want empty", result.Positions)
+	}
+	if result.MaxDrawdown != 0.0 {
+		t.Errorf("result.MaxDrawdown = %v, want 0.0", result.MaxDrawdown)
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	curve := []EquityPoint{{Equity: 100}, {Equity: 120}, {Equity: 90}, {Equity: 130}, {Equity: 117}}
+	if got := maxDrawdown(curve); math.Abs(got-0.25) > 1e-9 {
+		t.Errorf("maxDrawdown(curve) = %v, want %v", got, 0.25)
+	}
+	if got := maxDrawdown(nil); got != 0.0 {
+		t.Errorf("maxDrawdown(nil) = %v, want 0.0", got)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	_, err := Run(Config{}, nil, Data{Candles: []types.OHLCV{candle(1, 1, 1, 1, 1)}})
+	if err == nil {
+		t.Error("Run() error = nil, want error")
+	}
+	_, err = Run(Config{}, StrategyFunc(func(*Broker, types.OHLCV) {}), Data{})
+	if err == nil {
+		t.Error("Run() error = nil, want error")
+	}
+}
+
+func TestFundingRates(t *testing.T) {
+	rates, err := FundingRates("BTC", []types.FundingRecord{{Time: 1, Rate: "0.0000125", Px: "100"}})
+	if err != nil {
+		t.Fatalf("FundingRates() error = %v", err)
+	}
+	if want := []FundingRate{{Coin: "BTC", Rate: 0.0000125, Time: 1}}; !slices.Equal(rates, want) {
+		t.Errorf("rates = %+v, want %+v", rates, want)
+	}
+
+	_, err = FundingRates("BTC", []types.FundingRecord{{Time: 1, Rate: "x"}})
+	if err == nil {
+		t.Error("FundingRates() error = nil, want error")
+	}
+}
@@ -3,14 +3,23 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/tracing"
+	"github.com/dwdwow/hl-go/types"
 	"github.com/dwdwow/hl-go/ws"
 )
 
@@ -36,6 +45,36 @@ type API struct {
 	HTTPClient *http.Client
 	WsClient   *ws.PostOnlyClient
 	timeout    time.Duration
+	network    *constants.Network
+	// wsActions sends exchange actions over WsClient even though HTTPClient is set
+	wsActions bool
+	// requestHook observes every request, see SetRequestHook
+	requestHook func(RequestEvent)
+	// tracer traces every request, as a child of ctx if set, see SetTracerProvider
+	tracer trace.Tracer
+	ctx    context.Context
+	// assetCoin names the assets of traced actions
+	assetCoin func(asset int) (string, bool)
+}
+
+// RequestEvent describes a request to the API once it completed, see SetRequestHook
+type RequestEvent struct {
+	// Path is "/info" or "/exchange"
+	Path string
+	// Type is the type of the info request or of the action, e.g. "l2Book" or "order"
+	Type string
+	// Payload is the request as sent, signature and nonce included for actions
+	Payload any
+	// Result is the decoded response, e.g. a *types.OrderResponse, nil on error or when
+	// the response is not decoded
+	Result any
+	// Ws is true if the request was sent over the WebSocket post channel
+	Ws bool
+	// Start is when the request started and Duration how long it took
+	Start    time.Time
+	Duration time.Duration
+	// Err is the error returned to the caller, an *APIError for HTTP error statuses
+	Err error
 }
 
 // // NewAPI creates a new API client
@@ -71,6 +110,7 @@ func newAPIUsingWs(baseURL string, timeout time.Duration) (*API, error) {
 		timeout = constants.DefaultTimeout * time.Second
 	}
 	w := ws.NewPostOnlyClient()
+	w.SetURL(wsURLFor(baseURL))
 	if err := w.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start WebSocket client: %w", err)
 	}
@@ -81,22 +121,213 @@ func newAPIUsingWs(baseURL string, timeout time.Duration) (*API, error) {
 	}, nil
 }
 
-type ExchangeResponse struct {
-	Status   string          `json:"status"`
-	Response json.RawMessage `json:"response,omitempty"`
+// wsURLFor returns the WebSocket endpoint for baseURL, which may be either the WebSocket
+// URL itself or an HTTP API URL such as constants.TestnetAPIURL
+func wsURLFor(baseURL string) string {
+	if strings.HasPrefix(baseURL, "http://") || strings.HasPrefix(baseURL, "https://") {
+		return "ws" + strings.TrimPrefix(strings.TrimSuffix(baseURL, "/"), "http") + "/ws"
+	}
+	return baseURL
+}
+
+// ExchangeResponse is the envelope of exchange responses, decoded by decodeExchangeResponse
+type ExchangeResponse = types.ApiResponse[json.RawMessage]
+
+// errWsNotSent marks WebSocket requests that never reached the server, which are safe to retry over HTTP
+var errWsNotSent = errors.New("websocket request not sent")
+
+// SetWsActionClient submits exchange actions over the WebSocket post channel of w
+// instead of HTTP, for lower latency. Info requests keep using HTTP.
+//
+// Actions fall back to HTTP when they cannot be sent over the WebSocket, e.g. because
+// the connection is down. Actions that were sent but got no response in time are not
+// retried, since they may have been executed.
+func (a *API) SetWsActionClient(w *ws.PostOnlyClient) {
+	a.WsClient = w
+	a.wsActions = w != nil
+}
+
+// SetRequestHook makes the API call fn after every info request and action, e.g. to
+// export metrics. fn runs on the goroutine of the request and must not block; set it
+// before the API is used.
+func (a *API) SetRequestHook(fn func(RequestEvent)) {
+	a.requestHook = fn
+}
+
+// observe calls the request hook, if any, with the outcome of a request
+func (a *API) observe(urlPath string, payload any, result any, start time.Time, usedWs bool, err error) {
+	if a.requestHook == nil {
+		return
+	}
+	event := RequestEvent{
+		Path:     urlPath,
+		Type:     requestType(payload),
+		Payload:  payload,
+		Ws:       usedWs,
+		Start:    start,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if err == nil {
+		event.Result = result
+	}
+	a.requestHook(event)
+}
+
+// requestType returns the type of an info request, or of the action of an exchange payload
+func requestType(payload any) string {
+	m, ok := payload.(map[string]any)
+	if !ok {
+		return ""
+	}
+	if action, ok := m["action"].(map[string]any); ok {
+		m = action
+	}
+	t, _ := m["type"].(string)
+	return t
+}
+
+// SetTracerProvider sets the provider of the span traced for every info request and
+// action, see package tracing. Without it the global provider of otel is used. Set it
+// before the API is used.
+func (a *API) SetTracerProvider(tp trace.TracerProvider) {
+	a.tracer = tracing.Tracer(tp)
+}
+
+// context returns the context of the requests, the parent of their spans
+func (a *API) context() context.Context {
+	if a.ctx != nil {
+		return a.ctx
+	}
+	return context.Background()
+}
+
+// startSpan starts the client span of a request to service, named after its rpc.method
+func (a *API) startSpan(service string, payload any) (context.Context, trace.Span) {
+	tracer := a.tracer
+	if tracer == nil {
+		tracer = tracing.Tracer(nil)
+	}
+	typ := requestType(payload)
+	method := tracing.RPCMethod(service, typ)
+	ctx, span := tracer.Start(a.context(), method, trace.WithSpanKind(trace.SpanKindClient))
+	if span.IsRecording() {
+		span.SetAttributes(tracing.RPCSystem, semconv.RPCMethod(method))
+		span.SetAttributes(a.spanAttributes(typ, payload)...)
+	}
+	return ctx, span
+}
+
+// spanAttributes returns the attributes of the span of a request: the type, nonce,
+// vault and coins of an action, or the type, coin and user of an info request
+func (a *API) spanAttributes(typ string, payload any) []attribute.KeyValue {
+	m, _ := payload.(map[string]any)
+	action, isAction := m["action"].(map[string]any)
+	if !isAction {
+		attrs := []attribute.KeyValue{tracing.InfoTypeKey.String(typ)}
+		if coin, ok := m["coin"].(string); ok {
+			attrs = append(attrs, tracing.CoinKey.String(coin))
+		}
+		if user, ok := m["user"].(string); ok {
+			attrs = append(attrs, tracing.UserKey.String(user))
+		}
+		return attrs
+	}
+
+	attrs := []attribute.KeyValue{tracing.ActionTypeKey.String(typ)}
+	if nonce, ok := m["nonce"].(int64); ok {
+		attrs = append(attrs, tracing.NonceKey.Int64(nonce))
+	}
+	if vault, ok := m["vaultAddress"].(*string); ok && vault != nil {
+		attrs = append(attrs, tracing.VaultAddressKey.String(*vault))
+	}
+	if coins := a.actionCoins(action); len(coins) > 0 {
+		attrs = append(attrs, tracing.CoinsKey.StringSlice(coins))
+	}
+	return attrs
+}
+
+// actionCoins returns the coins of the assets an action trades or cancels
+func (a *API) actionCoins(action map[string]any) []string {
+	var assets []int
+	if orders, ok := action["orders"].([]types.OrderWire); ok {
+		for _, order := range orders {
+			assets = append(assets, order.Asset)
+		}
+	}
+	if modifies, ok := action["modifies"].([]types.ModifyWire); ok {
+		for _, modify := range modifies {
+			assets = append(assets, modify.Order.Asset)
+		}
+	}
+	if cancels, ok := action["cancels"].([]map[string]any); ok {
+		for _, cancel := range cancels {
+			for _, key := range []string{"a", "asset"} {
+				if asset, ok := cancel[key].(int); ok {
+					assets = append(assets, asset)
+				}
+			}
+		}
+	}
+	if asset, ok := action["asset"].(int); ok {
+		assets = append(assets, asset)
+	}
+
+	var coins []string
+	seen := make(map[string]bool)
+	for _, asset := range assets {
+		coin := strconv.Itoa(asset)
+		if a.assetCoin != nil {
+			if name, ok := a.assetCoin(asset); ok {
+				coin = name
+			}
+		}
+		if !seen[coin] {
+			seen[coin] = true
+			coins = append(coins, coin)
+		}
+	}
+	return coins
+}
+
+// endSpan ends the span of a request with its protocol and error
+func endSpan(span trace.Span, usedWs bool, err error) {
+	protocol := "http"
+	if usedWs {
+		protocol = "websocket"
+	}
+	span.SetAttributes(semconv.NetworkProtocolName(protocol))
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(apiErr.StatusCode)))
+	}
+	tracing.End(span, err)
 }
 
 func (a *API) exchangePost(urlPath string, payload any, result any) error {
-	if a.HTTPClient != nil {
-		return a.exchangePostUsingHTTP(urlPath, payload, result)
+	start := time.Now()
+	ctx, span := a.startSpan(tracing.ServiceExchange, payload)
+	usedWs, err := a.sendExchangePost(ctx, urlPath, payload, result)
+	endSpan(span, usedWs, err)
+	a.observe(urlPath, payload, result, start, usedWs, err)
+	return err
+}
+
+// sendExchangePost posts an action, reporting whether it went over the WebSocket
+func (a *API) sendExchangePost(ctx context.Context, urlPath string, payload any, result any) (bool, error) {
+	if a.WsClient != nil && (a.HTTPClient == nil || a.wsActions) {
+		err := a.exchangePostUsingWs(ctx, payload, result)
+		if a.HTTPClient == nil || !errors.Is(err, errWsNotSent) {
+			return true, err
+		}
 	}
-	if a.WsClient != nil {
-		return a.exchangePostUsingWs(payload, result)
+	if a.HTTPClient != nil {
+		return false, a.exchangePostUsingHTTP(ctx, urlPath, payload, result)
 	}
-	return fmt.Errorf("no HTTP or WebSocket client available")
+	return false, fmt.Errorf("no HTTP or WebSocket client available")
 }
 
-func (a *API) exchangePostUsingHTTP(urlPath string, payload any, result any) error {
+func (a *API) exchangePostUsingHTTP(ctx context.Context, urlPath string, payload any, result any) error {
 	// Marshal payload
 	var body []byte
 	var err error
@@ -112,7 +343,7 @@ func (a *API) exchangePostUsingHTTP(urlPath string, payload any, result any) err
 
 	// Create request
 	url := a.BaseURL + urlPath
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -120,11 +351,14 @@ func (a *API) exchangePostUsingHTTP(urlPath string, payload any, result any) err
 	req.Header.Set("Content-Type", "application/json")
 
 	// Make request
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(tracing.HTTPAttributes(url)...)
 	resp, err := a.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
@@ -143,41 +377,23 @@ func (a *API) exchangePostUsingHTTP(urlPath string, payload any, result any) err
 		return a.handleError(resp.StatusCode, respData.Response)
 	}
 
-	// Check API status
-	if respData.Status != "ok" {
-		// Response is an error message string
-		var errMsg string
-		if err := json.Unmarshal(respData.Response, &errMsg); err != nil {
-			return fmt.Errorf("API error (status: %s): failed to parse error message", respData.Status)
-		}
-		return fmt.Errorf("API error: %s", errMsg)
-	}
-
-	// Parse response (status is "ok", response is data object)
-	if result != nil {
-		if err := json.Unmarshal(respData.Response, result); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
-		}
-	}
-
-	return nil
+	return decodeExchangeResponse(respData, result)
 }
 
-func (a *API) exchangePostUsingWs(payload any, result any) error {
-	waiter, err := a.WsClient.Request(ws.PostRequestTypeAction, payload)
+func (a *API) exchangePostUsingWs(ctx context.Context, payload any, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	waiter, err := a.WsClient.RequestContext(ctx, ws.PostRequestTypeAction, payload)
 	if err != nil {
-		return fmt.Errorf("failed to request: %w", err)
+		return fmt.Errorf("failed to request: %w: %w", errWsNotSent, err)
 	}
 
-	var resp *ws.PostResponse
-
-	select {
-	case resp = <-waiter.Chan():
-		if resp.Err != nil {
-			return fmt.Errorf("failed to get response: %w", resp.Err)
-		}
-	case <-time.After(a.timeout):
-		return fmt.Errorf("request timed out")
+	resp := <-waiter.Chan()
+	if errors.Is(resp.Err, context.DeadlineExceeded) {
+		return fmt.Errorf("request timed out: %w", resp.Err)
+	}
+	if resp.Err != nil {
+		return fmt.Errorf("failed to get response: %w", resp.Err)
 	}
 
 	if resp.Data.Response.Type == ws.PostResponseError {
@@ -192,11 +408,17 @@ func (a *API) exchangePostUsingWs(payload any, result any) error {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	return decodeExchangeResponse(respData, result)
+}
+
+// decodeExchangeResponse decodes the data of an "ok" response into result, or returns
+// the error message of the exchange
+func decodeExchangeResponse(respData *ExchangeResponse, result any) error {
 	// Check API status
 	if respData.Status != "ok" {
 		// Response is an error message string
-		var errMsg string
-		if err := json.Unmarshal(respData.Response, &errMsg); err != nil {
+		errMsg, err := respData.GetError()
+		if err != nil {
 			return fmt.Errorf("API error (status: %s): failed to parse error message", respData.Status)
 		}
 		return fmt.Errorf("API error: %s", errMsg)
@@ -210,20 +432,27 @@ func (a *API) exchangePostUsingWs(payload any, result any) error {
 	}
 
 	return nil
-
 }
 
 func (a *API) infoPost(urlPath string, payload any, result any) error {
-	if a.HTTPClient != nil {
-		return a.infoPostUsingHTTP(urlPath, payload, result)
-	}
-	if a.WsClient != nil {
-		return a.infoPostUsingWs(payload, result)
-	}
-	return fmt.Errorf("no HTTP or WebSocket client available")
+	start := time.Now()
+	ctx, span := a.startSpan(tracing.ServiceInfo, payload)
+	var err error
+	switch {
+	case a.HTTPClient != nil:
+		err = a.infoPostUsingHTTP(ctx, urlPath, payload, result)
+	case a.WsClient != nil:
+		err = a.infoPostUsingWs(ctx, payload, result)
+	default:
+		err = fmt.Errorf("no HTTP or WebSocket client available")
+	}
+	usedWs := a.HTTPClient == nil && a.WsClient != nil
+	endSpan(span, usedWs, err)
+	a.observe(urlPath, payload, result, start, usedWs, err)
+	return err
 }
 
-func (a *API) infoPostUsingHTTP(urlPath string, payload any, result any) error {
+func (a *API) infoPostUsingHTTP(ctx context.Context, urlPath string, payload any, result any) error {
 	// Marshal payload
 	var body []byte
 	var err error
@@ -239,7 +468,7 @@ func (a *API) infoPostUsingHTTP(urlPath string, payload any, result any) error {
 
 	// Create request
 	url := a.BaseURL + urlPath
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -247,11 +476,14 @@ func (a *API) infoPostUsingHTTP(urlPath string, payload any, result any) error {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Make request
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(tracing.HTTPAttributes(url)...)
 	resp, err := a.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
@@ -279,21 +511,20 @@ type InfoRespPayload struct {
 	Data json.RawMessage `json:"data"`
 }
 
-func (a *API) infoPostUsingWs(payload any, result any) error {
-	waiter, err := a.WsClient.Request(ws.PostRequestTypeInfo, payload)
+func (a *API) infoPostUsingWs(ctx context.Context, payload any, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	waiter, err := a.WsClient.RequestContext(ctx, ws.PostRequestTypeInfo, payload)
 	if err != nil {
 		return fmt.Errorf("failed to request: %w", err)
 	}
 
-	var resp *ws.PostResponse
-
-	select {
-	case resp = <-waiter.Chan():
-		if resp.Err != nil {
-			return fmt.Errorf("failed to get response: %w", resp.Err)
-		}
-	case <-time.After(a.timeout):
-		return fmt.Errorf("request timed out")
+	resp := <-waiter.Chan()
+	if errors.Is(resp.Err, context.DeadlineExceeded) {
+		return fmt.Errorf("request timed out: %w", resp.Err)
+	}
+	if resp.Err != nil {
+		return fmt.Errorf("failed to get response: %w", resp.Err)
 	}
 
 	if resp.Data.Response.Type == ws.PostResponseError {
@@ -348,7 +579,22 @@ func (a *API) handleError(statusCode int, body []byte) error {
 
 // IsMainnet returns true if the client is configured for mainnet
 func (a *API) IsMainnet() bool {
-	return a.BaseURL == constants.MainnetAPIURL || a.BaseURL == ws.MainnetWsURL
+	return a.Network().IsMainnet()
+}
+
+// Network returns the network used for signing.
+// Unless set with SetNetwork, it is derived from BaseURL.
+func (a *API) Network() constants.Network {
+	if a.network != nil {
+		return *a.network
+	}
+	return constants.NetworkForURL(a.BaseURL)
+}
+
+// SetNetwork sets the network used for signing, e.g. for a custom environment
+// whose URL is not one of the presets
+func (a *API) SetNetwork(network constants.Network) {
+	a.network = &network
 }
 
 // SetHTTPTimeout updates the HTTP client timeout
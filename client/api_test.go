@@ -0,0 +1,807 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/signing"
+	"github.com/dwdwow/hl-go/tracing"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/wallet"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// newHybridTestAPI returns an HTTP API whose /exchange endpoint counts requests,
+// with actions routed to a WebSocket post server that answers if respond is true
+func newHybridTestAPI(t *testing.T, respond bool) (*API, *atomic.Int32) {
+	t.Helper()
+
+	var httpRequests atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpRequests.Add(1)
+		w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	}))
+	t.Cleanup(httpServer.Close)
+
+	upgrader := websocket.Upgrader{}
+	wsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg struct {
+				Method  string `json:"method"`
+				ID      int64  `json:"id"`
+				Request struct {
+					Type string `json:"type"`
+				} `json:"request"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Method != "post" || msg.Request.Type != "action" || !respond {
+				continue
+			}
+			conn.WriteJSON(map[string]any{
+				"channel": "post",
+				"data": map[string]any{
+					"id": msg.ID,
+					"response": map[string]any{
+						"type":    "action",
+						"payload": map[string]any{"status": "ok", "response": map[string]any{"type": "ws"}},
+					},
+				},
+			})
+		}
+	}))
+	t.Cleanup(wsServer.Close)
+
+	api := NewAPIUsingHTTP(httpServer.URL, 200*time.Millisecond)
+	w := ws.NewPostOnlyClient()
+	w.SetURL("ws" + strings.TrimPrefix(wsServer.URL, "http"))
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	api.SetWsActionClient(w)
+
+	return api, &httpRequests
+}
+
+func TestExchangePostUsesWsActions(t *testing.T) {
+	api, httpRequests := newHybridTestAPI(t, true)
+
+	var result types.DefaultResponse
+	if err := api.exchangePost("/exchange", map[string]any{"action": "test"}, &result); err != nil {
+		t.Fatalf("exchangePost() error = %v", err)
+	}
+	if result.Type != "ws" {
+		t.Errorf("result type = %q, want ws", result.Type)
+	}
+	if httpRequests.Load() != 0 {
+		t.Errorf("HTTP requests = %d, want 0", httpRequests.Load())
+	}
+}
+
+func TestExchangePostFallsBackToHTTP(t *testing.T) {
+	api, httpRequests := newHybridTestAPI(t, true)
+	// A client that was never started cannot send anything
+	api.SetWsActionClient(ws.NewPostOnlyClient())
+
+	var result types.DefaultResponse
+	if err := api.exchangePost("/exchange", map[string]any{"action": "test"}, &result); err != nil {
+		t.Fatalf("exchangePost() error = %v", err)
+	}
+	if result.Type != "default" || httpRequests.Load() != 1 {
+		t.Errorf("result type = %q, HTTP requests = %d", result.Type, httpRequests.Load())
+	}
+}
+
+func TestExchangePostDoesNotRetrySentActions(t *testing.T) {
+	api, httpRequests := newHybridTestAPI(t, false)
+
+	if err := api.exchangePost("/exchange", map[string]any{"action": "test"}, nil); err == nil {
+		t.Fatal("exchangePost() expected timeout error")
+	}
+	if httpRequests.Load() != 0 {
+		t.Errorf("HTTP requests = %d, want 0: a sent action must not be retried", httpRequests.Load())
+	}
+}
+
+func TestRequestHook(t *testing.T) {
+	api, _ := newHybridTestAPI(t, true)
+	var events []RequestEvent
+	api.SetRequestHook(func(event RequestEvent) { events = append(events, event) })
+
+	var result types.DefaultResponse
+	action := map[string]any{"action": map[string]any{"type": "order"}}
+	if err := api.exchangePost("/exchange", action, &result); err != nil {
+		t.Fatalf("exchangePost() error = %v", err)
+	}
+	api.HTTPClient = nil
+	if err := api.infoPost("/info", map[string]any{"type": "allMids"}, nil); err == nil {
+		t.Fatal("infoPost() expected error, the WebSocket server answers actions only")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if e := events[0]; e.Path != "/exchange" || e.Type != "order" || !e.Ws || e.Err != nil || e.Result != &result {
+		t.Errorf("action event = %+v", e)
+	}
+	if e := events[1]; e.Path != "/info" || e.Type != "allMids" || !e.Ws || e.Err == nil || e.Result != nil {
+		t.Errorf("info event = %+v", e)
+	}
+}
+
+func TestTracerProvider(t *testing.T) {
+	api, _ := newHybridTestAPI(t, true)
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	api.SetTracerProvider(tp)
+	api.assetCoin = func(asset int) (string, bool) { return "ETH", asset == 1 }
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "handler")
+	exchange := (&Exchange{API: api}).WithContext(ctx)
+	action := map[string]any{
+		"action": map[string]any{"type": "order", "orders": []types.OrderWire{{Asset: 1}, {Asset: 2}, {Asset: 1}}},
+		"nonce":  int64(1700000000000),
+	}
+	if err := exchange.exchangePost("/exchange", action, nil); err != nil {
+		t.Fatalf("exchangePost() error = %v", err)
+	}
+	if err := api.infoPost("/info", map[string]any{"type": "l2Book", "coin": "BTC"}, nil); err != nil {
+		t.Fatalf("infoPost() error = %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	host, portText, _ := net.SplitHostPort(strings.TrimPrefix(api.BaseURL, "http://"))
+	port, _ := strconv.Atoi(portText)
+	tests := []struct {
+		span   sdktrace.ReadOnlySpan
+		name   string
+		parent trace.SpanID
+		attrs  []attribute.KeyValue
+	}{
+		{spans[0], "exchange/order", spans[2].SpanContext().SpanID(), []attribute.KeyValue{
+			attribute.String("rpc.system.name", "hyperliquid"),
+			attribute.String("rpc.method", "exchange/order"),
+			attribute.String("network.protocol.name", "websocket"),
+			tracing.ActionTypeKey.String("order"),
+			tracing.NonceKey.Int64(1700000000000),
+			tracing.CoinsKey.StringSlice([]string{"ETH", "2"}),
+		}},
+		{spans[1], "info/l2Book", trace.SpanID{}, []attribute.KeyValue{
+			attribute.String("rpc.system.name", "hyperliquid"),
+			attribute.String("rpc.method", "info/l2Book"),
+			attribute.String("network.protocol.name", "http"),
+			attribute.String("http.request.method", "POST"),
+			attribute.String("url.full", api.BaseURL+"/info"),
+			attribute.String("server.address", host),
+			attribute.Int("server.port", port),
+			attribute.Int("http.response.status_code", 200),
+			tracing.InfoTypeKey.String("l2Book"),
+			tracing.CoinKey.String("BTC"),
+		}},
+	}
+	for _, tt := range tests {
+		if tt.span.Name() != tt.name || tt.span.SpanKind() != trace.SpanKindClient || tt.span.Parent().SpanID() != tt.parent ||
+			tt.span.Status().Code != codes.Unset {
+			t.Errorf("span = %s %v %+v, want %s with parent %v", tt.span.Name(), tt.span.SpanKind(), tt.span.Status(), tt.name, tt.parent)
+		}
+		attrs := attribute.NewSet(tt.span.Attributes()...)
+		for _, kv := range tt.attrs {
+			if got, _ := attrs.Value(kv.Key); got.Emit() != kv.Value.Emit() {
+				t.Errorf("%s attribute %s = %v, want %v", tt.name, kv.Key, got.Emit(), kv.Value.Emit())
+			}
+		}
+	}
+}
+
+func TestWsURLFor(t *testing.T) {
+	tests := map[string]string{
+		"https://api.hyperliquid.xyz":          "wss://api.hyperliquid.xyz/ws",
+		"https://api.hyperliquid-testnet.xyz/": "wss://api.hyperliquid-testnet.xyz/ws",
+		"http://localhost:3001":                "ws://localhost:3001/ws",
+		"wss://api.hyperliquid.xyz/ws":         "wss://api.hyperliquid.xyz/ws",
+	}
+	for in, want := range tests {
+		if got := wsURLFor(in); got != want {
+			t.Errorf("wsURLFor(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSendL1Action(t *testing.T) {
+	responses := []string{
+		`{"status":"ok","response":{"type":"default"}}`,
+		`{"status":"err","response":"Insufficient margin to place order."}`,
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Action    map[string]any   `json:"action"`
+			Nonce     int64            `json:"nonce"`
+			Signature *types.Signature `json:"signature"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Action["type"] != "noop" ||
+			payload.Nonce == 0 || payload.Signature == nil || payload.Signature.R == "" {
+			t.Errorf("payload = %+v, %v", payload, err)
+		}
+		w.Write([]byte(responses[requests.Add(1)-1]))
+	}))
+	defer server.Close()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key)}
+
+	result, err := SendL1Action[types.DefaultResponse](exchange, map[string]any{"type": "noop"})
+	if err != nil || result.Type != "default" {
+		t.Fatalf("SendL1Action() = %+v, %v", result, err)
+	}
+	if _, err := SendL1Action[types.DefaultResponse](exchange, map[string]any{"type": "noop"}); err == nil ||
+		err.Error() != "API error: Insufficient margin to place order." {
+		t.Errorf("SendL1Action() error = %v", err)
+	}
+}
+
+func TestInfoCoinRefs(t *testing.T) {
+	var metaRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		switch {
+		case payload["type"] == "spotMeta":
+			w.Write([]byte(`{"tokens":[{"name":"USDC","szDecimals":8,"index":0},{"name":"PURR","szDecimals":0,"index":1}],
+				"universe":[{"name":"PURR/USDC","tokens":[1,0],"index":0,"isCanonical":true}]}`))
+		case payload["type"] == "meta" && payload["dex"] == "":
+			w.Write([]byte(`{"universe":[{"name":"BTC","szDecimals":5,"maxLeverage":40}]}`))
+		case payload["type"] == "meta" && payload["dex"] == "xyz":
+			metaRequests.Add(1)
+			w.Write([]byte(`{"universe":[{"name":"xyz:XYZ100","szDecimals":4,"maxLeverage":20},{"name":"xyz:TSLA","szDecimals":3,"maxLeverage":10}]}`))
+		case payload["type"] == "perpDexs":
+			w.Write([]byte(`[null,{"name":"abc"},{"name":"xyz"}]`))
+		default:
+			t.Errorf("unexpected request %v", payload)
+		}
+	}))
+	defer server.Close()
+
+	info, err := NewInfoUsingHTTP(server.URL, time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoUsingHTTP() error = %v", err)
+	}
+	tests := []struct {
+		name  string
+		coin  string
+		asset int
+	}{
+		{"BTC", "BTC", 0},
+		{"PURR/USDC", "PURR/USDC", 10000},
+		{"@0", "PURR/USDC", 10000},
+		{"xyz:XYZ100", "xyz:XYZ100", 120000},
+		{types.CoinRef{Dex: "xyz", Coin: "TSLA"}.String(), "xyz:TSLA", 120001},
+	}
+	for _, tt := range tests {
+		coin, err := info.NameToCoin(tt.name)
+		if err != nil || coin != tt.coin {
+			t.Errorf("NameToCoin(%q) = %q, %v, want %q", tt.name, coin, err, tt.coin)
+		}
+		asset, err := info.NameToAsset(tt.name)
+		if err != nil || asset != tt.asset {
+			t.Errorf("NameToAsset(%q) = %d, %v, want %d", tt.name, asset, err, tt.asset)
+		}
+	}
+	if metaRequests.Load() != 1 {
+		t.Errorf("meta of dex xyz fetched %d times, want 1", metaRequests.Load())
+	}
+	if szDecimals, ok := info.szDecimals(120001); !ok || szDecimals != 3 {
+		t.Errorf("szDecimals(120001) = %d, %v", szDecimals, ok)
+	}
+	for _, tt := range tests {
+		if coin, ok := info.assetCoin(tt.asset); !ok || coin != tt.coin {
+			t.Errorf("assetCoin(%d) = %q, %v, want %q", tt.asset, coin, ok, tt.coin)
+		}
+	}
+	if coin, ok := info.assetCoin(7); ok {
+		t.Errorf("assetCoin(7) = %q, want unknown", coin)
+	}
+
+	for _, name := range []string{"ETH", "xyz:ETH", "nope:BTC"} {
+		if _, err := info.NameToAsset(name); err == nil {
+			t.Errorf("NameToAsset(%q) should fail", name)
+		}
+	}
+}
+
+func TestInfoCoinRefLoad(t *testing.T) {
+	loading, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		switch {
+		case payload["type"] == "spotMeta":
+			w.Write([]byte(`{"tokens":[],"universe":[]}`))
+		case payload["type"] == "meta" && payload["dex"] == "":
+			w.Write([]byte(`{"universe":[{"name":"BTC","szDecimals":5,"maxLeverage":40}]}`))
+		case payload["type"] == "meta" && payload["dex"] == "slow":
+			close(loading)
+			<-release
+			w.Write([]byte(`{"universe":[{"name":"slow:ABC","szDecimals":2,"maxLeverage":10}]}`))
+		case payload["type"] == "meta":
+			w.WriteHeader(http.StatusInternalServerError)
+		case payload["type"] == "perpDexs":
+			w.Write([]byte(`[null,{"name":"bad"},{"name":"slow"}]`))
+		}
+	}))
+	defer server.Close()
+
+	info, err := NewInfoUsingHTTP(server.URL, 5*time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoUsingHTTP() error = %v", err)
+	}
+
+	// The error of the load is returned rather than an unknown coin
+	if _, err := info.NameToAsset("bad:ABC"); err == nil || !strings.Contains(err.Error(), "failed to get perp meta of dex bad") {
+		t.Errorf("NameToAsset(bad:ABC) error = %v, want the meta error", err)
+	}
+
+	// Other coins resolve while a dex loads
+	done := make(chan error, 1)
+	go func() {
+		_, err := info.NameToAsset("slow:ABC")
+		done <- err
+	}()
+	<-loading
+	resolved := make(chan error, 1)
+	go func() {
+		_, err := info.NameToAsset("BTC")
+		resolved <- err
+	}()
+	select {
+	case err := <-resolved:
+		if err != nil {
+			t.Errorf("NameToAsset(BTC) error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("NameToAsset(BTC) blocked by the load of dex slow")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("NameToAsset(slow:ABC) error = %v", err)
+	}
+}
+
+func TestNewInfoForNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload["type"] == "spotMeta" {
+			w.Write([]byte(`{"tokens":[],"universe":[]}`))
+			return
+		}
+		w.Write([]byte(`{"universe":[{"name":"BTC","szDecimals":5,"maxLeverage":40}]}`))
+	}))
+	defer server.Close()
+
+	// A private node of mainnet signs for mainnet although its URL is not the preset
+	info, err := NewInfoForNetwork(constants.Mainnet.WithURLs(server.URL, ""), time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoForNetwork() error = %v", err)
+	}
+	if !info.IsMainnet() || info.BaseURL != server.URL {
+		t.Errorf("Network() = %+v, BaseURL = %s", info.Network(), info.BaseURL)
+	}
+	if asset, err := info.NameToAsset("BTC"); err != nil || asset != 0 {
+		t.Errorf("NameToAsset(BTC) = %d, %v", asset, err)
+	}
+}
+
+func TestMarketOpenUsesBook(t *testing.T) {
+	var limitPx string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		if r.URL.Path == "/exchange" {
+			order := payload["action"].(map[string]any)["orders"].([]any)[0].(map[string]any)
+			limitPx, _ = order["p"].(string)
+			w.Write([]byte(`{"status":"ok","response":{"type":"order","data":{"statuses":[{"filled":{"totalSz":"1.5","avgPx":"100.4","oid":1}}]}}}`))
+			return
+		}
+		switch payload["type"] {
+		case "spotMeta":
+			w.Write([]byte(`{"tokens":[],"universe":[]}`))
+		case "meta":
+			w.Write([]byte(`{"universe":[{"name":"ETH","szDecimals":2,"maxLeverage":25}]}`))
+		case "l2Book":
+			w.Write([]byte(`{"coin":"ETH","time":1,"levels":[[{"px":"99","sz":"1","n":1}],[{"px":"101","sz":"1","n":1},{"px":"120","sz":"5","n":2}]]}`))
+		default:
+			t.Errorf("unexpected request %v", payload)
+		}
+	}))
+	defer server.Close()
+
+	info, err := NewInfoUsingHTTP(server.URL, time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoUsingHTTP() error = %v", err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key), info: info}
+
+	// 5% above the mid of 100
+	if _, err := exchange.MarketOpen("ETH", true, 1.5, nil, 0.05, nil, nil); err != nil {
+		t.Fatalf("MarketOpen() error = %v", err)
+	}
+	if limitPx != "105" {
+		t.Errorf("limit price = %s, want 105", limitPx)
+	}
+	// Nothing is sent when the book has no liquidity within the slippage
+	limitPx = ""
+	if _, err := exchange.MarketOpen("ETH", false, 1, nil, 0.005, nil, nil); err == nil || limitPx != "" {
+		t.Errorf("MarketOpen() = %v, sent %q", err, limitPx)
+	}
+
+	fill, err := info.EstimateFill("ETH", true, 2)
+	if err != nil || fill.Sz != 2 || fill.AvgPx != 110.5 || fill.WorstPx != 120 {
+		t.Errorf("EstimateFill() = %+v, %v", fill, err)
+	}
+}
+
+func TestExchangeBuilderFee(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		if r.URL.Path == "/exchange" {
+			w.Write([]byte(`{"status":"ok","response":{"type":"order","data":{"statuses":[{"resting":{"oid":1}},{"resting":{"oid":2}}]}}}`))
+			return
+		}
+		switch payload["type"] {
+		case "spotMeta":
+			w.Write([]byte(`{"tokens":[{"name":"USDC","szDecimals":8,"index":0},{"name":"PURR","szDecimals":0,"index":1}],
+				"universe":[{"name":"PURR/USDC","tokens":[1,0],"index":0,"isCanonical":true}]}`))
+		case "meta":
+			w.Write([]byte(`{"universe":[{"name":"ETH","szDecimals":2,"maxLeverage":25}]}`))
+		}
+	}))
+	defer server.Close()
+
+	info, err := NewInfoUsingHTTP(server.URL, time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoUsingHTTP() error = %v", err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key), info: info}
+	spot := types.OrderRequest{Coin: "PURR/USDC", IsBuy: true, Sz: 100, LimitPx: 0.2, OrderType: types.NewLimit(types.TifGtc)}
+	perp := types.OrderRequest{Coin: "ETH", IsBuy: true, Sz: 1, LimitPx: 2000, OrderType: types.NewLimit(types.TifGtc)}
+
+	// A fee above the perp cap is only accepted for batches of spot orders
+	tests := []struct {
+		name    string
+		orders  []types.OrderRequest
+		wantErr bool
+	}{
+		{"spot", []types.OrderRequest{spot}, false},
+		{"perp", []types.OrderRequest{perp}, true},
+		{"mixed", []types.OrderRequest{spot, perp}, true},
+	}
+	for _, tt := range tests {
+		builder := &types.BuilderInfo{B: "0x0000000000000000000000000000000000000001", F: types.MaxPerpBuilderFee + 1}
+		_, err := exchange.BulkOrders(tt.orders, builder)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: BulkOrders() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExchangeInvalidOrderType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		switch {
+		case r.URL.Path == "/exchange":
+			t.Errorf("unexpected action %v", payload["action"])
+		case payload["type"] == "spotMeta":
+			w.Write([]byte(`{"tokens":[],"universe":[]}`))
+		case payload["type"] == "meta":
+			w.Write([]byte(`{"universe":[{"name":"ETH","szDecimals":2,"maxLeverage":25}]}`))
+		}
+	}))
+	defer server.Close()
+
+	info, err := NewInfoUsingHTTP(server.URL, time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoUsingHTTP() error = %v", err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key), info: info}
+
+	// Orders without exactly one order type fail before the coin is resolved or anything is sent
+	for _, order := range []types.OrderRequest{
+		{Coin: "ETH", IsBuy: true, Sz: 1, LimitPx: 2000},
+		{Coin: "UNKNOWN", IsBuy: true, Sz: 1, LimitPx: 2000},
+		{Coin: "ETH", IsBuy: true, Sz: 1, LimitPx: 2000, OrderType: types.OrderType{
+			Limit:   &types.LimitOrderType{Tif: types.TifGtc},
+			Trigger: &types.TriggerOrderType{TriggerPx: 1900, Tpsl: types.TpslSl},
+		}},
+	} {
+		if _, err := exchange.BulkOrders([]types.OrderRequest{order}, nil); !errors.Is(err, types.ErrInvalidOrderType) {
+			t.Errorf("BulkOrders(%s) error = %v, want ErrInvalidOrderType", order.Coin, err)
+		}
+		if _, err := exchange.BulkModifyOrders([]types.ModifyRequest{{Oid: 1, Order: order}}); !errors.Is(err, types.ErrInvalidOrderType) {
+			t.Errorf("BulkModifyOrders(%s) error = %v, want ErrInvalidOrderType", order.Coin, err)
+		}
+	}
+}
+
+func TestExchangeUserSignedActionFloatNonce(t *testing.T) {
+	// The registry is global, so use unique types per run (go test -count)
+	suffix := time.Now().UnixNano()
+	actionType := signing.UserSignedActionType{
+		ActionType:  fmt.Sprintf("floatNonce%d", suffix),
+		PrimaryType: fmt.Sprintf("HyperliquidTransaction:FloatNonce%d", suffix),
+		Types: []apitypes.Type{
+			{Name: "hyperliquidChain", Type: "string"},
+			{Name: "target", Type: "address"},
+			{Name: "nonce", Type: "uint64"},
+		},
+	}
+	if err := signing.RegisterUserSignedActionType(actionType); err != nil {
+		t.Fatalf("RegisterUserSignedActionType() error = %v", err)
+	}
+
+	var posted map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		if r.URL.Path == "/exchange" {
+			posted = payload
+			w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+			return
+		}
+		switch payload["type"] {
+		case "spotMeta":
+			w.Write([]byte(`{"tokens":[],"universe":[]}`))
+		case "meta":
+			w.Write([]byte(`{"universe":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	info, err := NewInfoUsingHTTP(server.URL, time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoUsingHTTP() error = %v", err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key), info: info}
+
+	// Fields decoded from JSON carry numbers as float64
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(`{"target":"0x0000000000000000000000000000000000000001","nonce":1700000000000}`), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exchange.UserSignedAction(actionType.PrimaryType, fields); err != nil {
+		t.Fatalf("UserSignedAction() error = %v", err)
+	}
+	if posted["nonce"] != float64(1700000000000) {
+		t.Errorf("posted nonce = %v, want 1700000000000", posted["nonce"])
+	}
+	action := map[string]any{
+		"type":             actionType.ActionType,
+		"target":           "0x0000000000000000000000000000000000000001",
+		"nonce":            uint64(1700000000000),
+		"hyperliquidChain": posted["action"].(map[string]any)["hyperliquidChain"],
+		"signatureChainId": posted["action"].(map[string]any)["signatureChainId"],
+	}
+	want, err := signing.SignRegisteredUserSignedActionForNetwork(key, action, actionType.PrimaryType, exchange.Network())
+	if err != nil {
+		t.Fatalf("SignRegisteredUserSignedActionForNetwork() error = %v", err)
+	}
+	if got := posted["signature"].(map[string]any)["r"]; got != want.R {
+		t.Errorf("signature r = %v, want %v", got, want.R)
+	}
+
+	for _, nonce := range []float64{1.5, -1} {
+		if _, err := exchange.UserSignedAction(actionType.PrimaryType, map[string]any{"target": "0x0000000000000000000000000000000000000001", "nonce": nonce}); err == nil {
+			t.Errorf("UserSignedAction() with nonce %v expected error", nonce)
+		}
+	}
+}
+
+func TestExchangeZeroKey(t *testing.T) {
+	var orders atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		if r.URL.Path == "/exchange" {
+			orders.Add(1)
+			w.Write([]byte(`{"status":"ok","response":{"type":"order","data":{"statuses":[{"resting":{"oid":1}}]}}}`))
+			return
+		}
+		switch payload["type"] {
+		case "spotMeta":
+			w.Write([]byte(`{"tokens":[],"universe":[]}`))
+		case "meta":
+			w.Write([]byte(`{"universe":[{"name":"ETH","szDecimals":2,"maxLeverage":25}]}`))
+		}
+	}))
+	defer server.Close()
+
+	info, err := NewInfoUsingHTTP(server.URL, time.Second)
+	if err != nil {
+		t.Fatalf("NewInfoUsingHTTP() error = %v", err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key), info: info}
+	limit := types.NewLimit(types.TifGtc)
+	if _, err := exchange.Order("ETH", true, 1, 2000, limit, false, nil, nil); err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if exchange.GetWallet() != key {
+		t.Error("GetWallet() did not return the key")
+	}
+
+	// Orders signing while the key is wiped either complete or fail with ErrKeyZeroed
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := exchange.Order("ETH", true, 1, 2000, limit, false, nil, nil); err != nil && !errors.Is(err, wallet.ErrKeyZeroed) {
+				t.Errorf("Order() error = %v", err)
+			}
+		}()
+	}
+	exchange.ZeroKey()
+	wg.Wait()
+
+	sent := orders.Load()
+	if _, err := exchange.Order("ETH", true, 1, 2000, limit, false, nil, nil); !errors.Is(err, wallet.ErrKeyZeroed) {
+		t.Errorf("Order() after ZeroKey error = %v, want %v", err, wallet.ErrKeyZeroed)
+	}
+	if _, err := exchange.USDTransfer(1, "0x0000000000000000000000000000000000000001"); !errors.Is(err, wallet.ErrKeyZeroed) {
+		t.Errorf("USDTransfer() after ZeroKey error = %v, want %v", err, wallet.ErrKeyZeroed)
+	}
+	if orders.Load() != sent {
+		t.Error("an action was sent after ZeroKey")
+	}
+	if exchange.GetWallet() != nil {
+		t.Error("GetWallet() returned a zeroed key")
+	}
+	if key.D.Sign() != 0 {
+		t.Error("ZeroKey() did not wipe the key")
+	}
+}
+
+func TestApproveNewAgent(t *testing.T) {
+	var actions []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Action map[string]any `json:"action"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		actions = append(actions, payload.Action)
+		w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	}))
+	defer server.Close()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key), walletAddress: address}
+	store := wallet.NewAgentStore(t.TempDir())
+	store.SetScryptParams(keystore.LightScryptN, keystore.LightScryptP)
+
+	validUntil := time.UnixMilli(1735689600000)
+	tests := []struct {
+		name       string
+		validUntil *time.Time
+		want       any
+	}{
+		{"bot", &validUntil, "bot valid_until 1735689600000"},
+		{"", &validUntil, "valid_until 1735689600000"},
+		{"bot2", nil, "bot2"},
+	}
+	for i, tt := range tests {
+		_, agent, err := exchange.ApproveNewAgent(store, tt.name, tt.validUntil, "secret")
+		if err != nil {
+			t.Fatalf("ApproveNewAgent(%q) error = %v", tt.name, err)
+		}
+		action := actions[i]
+		if action["type"] != "approveAgent" || action["agentAddress"] != agent.AgentAddress || action["agentName"] != tt.want {
+			t.Errorf("ApproveNewAgent(%q) action = %v, want agentName %v", tt.name, action, tt.want)
+		}
+		if agent.Name != tt.name || agent.ApprovedAt == nil {
+			t.Errorf("ApproveNewAgent(%q) agent = %+v", tt.name, agent.AgentMetadata)
+		}
+	}
+}
+
+func TestExchangeClock(t *testing.T) {
+	type request struct {
+		Nonce     int64            `json:"nonce"`
+		Signature *types.Signature `json:"signature"`
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload request
+		json.NewDecoder(r.Body).Decode(&payload)
+		requests = append(requests, payload)
+		w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	}))
+	defer server.Close()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := utils.NewFakeClock(time.UnixMilli(1700000000000))
+	exchange := &Exchange{API: NewAPIUsingHTTP(server.URL, time.Second), key: wallet.NewPrivateKey(key)}
+	exchange.SetClock(clock)
+
+	action := map[string]any{"type": "noop"}
+	for range 2 {
+		if _, err := SendL1Action[types.DefaultResponse](exchange, action); err != nil {
+			t.Fatalf("SendL1Action() error = %v", err)
+		}
+	}
+	clock.Advance(time.Millisecond)
+	if _, err := SendL1Action[types.DefaultResponse](exchange, action); err != nil {
+		t.Fatalf("SendL1Action() error = %v", err)
+	}
+
+	if len(requests) != 3 || requests[0].Nonce != 1700000000000 || requests[2].Nonce != 1700000000001 {
+		t.Fatalf("requests = %+v", requests)
+	}
+	if *requests[0].Signature != *requests[1].Signature || *requests[0].Signature == *requests[2].Signature {
+		t.Errorf("signatures = %+v, want equal signatures for equal nonces", requests)
+	}
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Environment variables read by Config.ApplyEnv
+const (
+	EnvNetwork        = "HYPERLIQUID_NETWORK"
+	EnvBaseURL        = "HYPERLIQUID_BASE_URL"
+	EnvWsURL          = "HYPERLIQUID_WS_URL"
+	EnvTimeout        = "HYPERLIQUID_TIMEOUT"
+	EnvSlippage       = "HYPERLIQUID_SLIPPAGE"
+	EnvAccountAddress = "HYPERLIQUID_ACCOUNT_ADDRESS"
+	EnvVaultAddress   = "HYPERLIQUID_VAULT_ADDRESS"
+)
+
+// Config holds the settings of the clients, so binaries can read them from a file or
+// the environment instead of hardcoding them. Zero fields keep the defaults of the SDK.
+//
+//	cfg, err := client.LoadConfig("hyperliquid.json") // then overridden by HYPERLIQUID_* variables
+//	info, err := cfg.NewInfo()
+//	exchange, err := cfg.NewExchange(privateKey)
+type Config struct {
+	// Network is "mainnet", "testnet" or "local", derived from BaseURL if empty and
+	// mainnet without BaseURL
+	Network string `json:"network,omitempty"`
+	// BaseURL and WsURL override the endpoints of the network. WsURL is derived from
+	// BaseURL if empty.
+	BaseURL string `json:"baseUrl,omitempty"`
+	WsURL   string `json:"wsUrl,omitempty"`
+	// Timeout of HTTP requests, e.g. "10s" in files and variables, or a number of seconds
+	Timeout time.Duration `json:"-"`
+	// Slippage is the default slippage of market orders, e.g. 0.01 for 1%
+	Slippage       float64 `json:"slippage,omitempty"`
+	AccountAddress string  `json:"accountAddress,omitempty"`
+	VaultAddress   string  `json:"vaultAddress,omitempty"`
+}
+
+// configJSON is the file form of Config
+type configJSON struct {
+	*configAlias
+	Timeout string `json:"timeout,omitempty"`
+}
+
+type configAlias Config
+
+// LoadConfig reads a JSON config file and applies the environment on top of it
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ConfigFromEnv returns the config set by the HYPERLIQUID_* environment variables
+func ConfigFromEnv() (*Config, error) {
+	var cfg Config
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// UnmarshalJSON decodes a config, with the timeout as a duration string
+func (c *Config) UnmarshalJSON(data []byte) error {
+	decoded := configJSON{configAlias: (*configAlias)(c)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Timeout == "" {
+		return nil
+	}
+	timeout, err := parseTimeout(decoded.Timeout)
+	if err != nil {
+		return err
+	}
+	c.Timeout = timeout
+	return nil
+}
+
+// MarshalJSON encodes a config, with the timeout as a duration string
+func (c Config) MarshalJSON() ([]byte, error) {
+	encoded := configJSON{configAlias: (*configAlias)(&c)}
+	if c.Timeout != 0 {
+		encoded.Timeout = c.Timeout.String()
+	}
+	return json.Marshal(encoded)
+}
+
+// ApplyEnv overrides the fields of c with the HYPERLIQUID_* environment variables that
+// are set
+func (c *Config) ApplyEnv() error {
+	fields := map[string]*string{
+		EnvNetwork:        &c.Network,
+		EnvBaseURL:        &c.BaseURL,
+		EnvWsURL:          &c.WsURL,
+		EnvAccountAddress: &c.AccountAddress,
+		EnvVaultAddress:   &c.VaultAddress,
+	}
+	for name, field := range fields {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+
+	if value, ok := os.LookupEnv(EnvTimeout); ok {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvTimeout, err)
+		}
+		c.Timeout = timeout
+	}
+	if value, ok := os.LookupEnv(EnvSlippage); ok {
+		slippage, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvSlippage, err)
+		}
+		c.Slippage = slippage
+	}
+	return nil
+}
+
+// Validate checks the network name, the addresses and the ranges of the config
+func (c Config) Validate() error {
+	if c.Network != "" {
+		if _, ok := constants.NetworkByName(c.Network); !ok {
+			return fmt.Errorf("unknown network: %q", c.Network)
+		}
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("negative timeout: %v", c.Timeout)
+	}
+	if c.Slippage < 0 || c.Slippage >= 1 {
+		return fmt.Errorf("slippage must be in [0, 1), got %v", c.Slippage)
+	}
+	if c.AccountAddress != "" {
+		if err := utils.ValidateAddress(c.AccountAddress); err != nil {
+			return fmt.Errorf("invalid account address: %w", err)
+		}
+	}
+	if c.VaultAddress != "" {
+		if err := utils.ValidateAddress(c.VaultAddress); err != nil {
+			return fmt.Errorf("invalid vault address: %w", err)
+		}
+	}
+	return nil
+}
+
+// NetworkConfig returns the network of the config with its endpoint overrides
+func (c Config) NetworkConfig() (constants.Network, error) {
+	if err := c.Validate(); err != nil {
+		return constants.Network{}, err
+	}
+
+	network := constants.Mainnet
+	switch {
+	case c.Network != "":
+		network, _ = constants.NetworkByName(c.Network)
+	case c.BaseURL != "":
+		network = constants.NetworkForURL(c.BaseURL)
+	}
+
+	apiURL, wsURL := network.APIURL, network.WsURL
+	if c.BaseURL != "" {
+		apiURL, wsURL = c.BaseURL, wsURLFor(c.BaseURL)
+	}
+	if c.WsURL != "" {
+		wsURL = c.WsURL
+	}
+	return network.WithURLs(apiURL, wsURL), nil
+}
+
+// NewInfo creates an Info client for the config
+func (c Config) NewInfo() (*Info, error) {
+	network, err := c.NetworkConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewInfoForNetwork(network, c.Timeout)
+}
+
+// ExchangeOptions returns the options of an Exchange signing with wallet
+func (c Config) ExchangeOptions(wallet *ecdsa.PrivateKey) (*ExchangeOptions, error) {
+	network, err := c.NetworkConfig()
+	if err != nil {
+		return nil, err
+	}
+	options := &ExchangeOptions{
+		Wallet:   wallet,
+		Timeout:  c.Timeout,
+		Network:  &network,
+		Slippage: c.Slippage,
+	}
+	if c.AccountAddress != "" {
+		options.AccountAddress = &c.AccountAddress
+	}
+	if c.VaultAddress != "" {
+		options.VaultAddress = &c.VaultAddress
+	}
+	return options, nil
+}
+
+// NewExchange creates an Exchange client for the config, signing with wallet
+func (c Config) NewExchange(wallet *ecdsa.PrivateKey) (*Exchange, error) {
+	options, err := c.ExchangeOptions(wallet)
+	if err != nil {
+		return nil, err
+	}
+	return NewExchange(options)
+}
+
+// parseTimeout parses a duration such as "10s", or a number of seconds
+func parseTimeout(s string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout: %q", s)
+	}
+	return timeout, nil
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/constants"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"network":"testnet","timeout":"10s","slippage":0.01,"vaultAddress":"0x8c967e73e7b15087c42a10d344cff4c96d877f1d"}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvSlippage, "0.02")
+	t.Setenv(EnvTimeout, "5")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Network != "testnet" || cfg.Timeout != 5*time.Second || cfg.Slippage != 0.02 || cfg.VaultAddress == "" {
+		t.Errorf("LoadConfig() = %+v", cfg)
+	}
+
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded Config
+	if err := json.Unmarshal(out, &decoded); err != nil || decoded != *cfg {
+		t.Errorf("round trip = %+v, %v (%s)", decoded, err, out)
+	}
+
+	options, err := cfg.ExchangeOptions(nil)
+	if err != nil {
+		t.Fatalf("ExchangeOptions() error = %v", err)
+	}
+	if options.Network.Name != "testnet" || options.Network.APIURL != constants.TestnetAPIURL ||
+		options.VaultAddress == nil || options.AccountAddress != nil || options.Slippage != 0.02 {
+		t.Errorf("ExchangeOptions() = %+v", options)
+	}
+}
+
+func TestConfigNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    constants.Network
+		wantErr bool
+	}{
+		{name: "default", want: constants.Mainnet},
+		{name: "preset", cfg: Config{Network: "Local"}, want: constants.Local},
+		{
+			name: "url of a preset",
+			cfg:  Config{BaseURL: constants.TestnetAPIURL},
+			want: constants.Testnet,
+		},
+		{
+			name: "private node of mainnet",
+			cfg:  Config{Network: "mainnet", BaseURL: "https://node.example.com"},
+			want: constants.Mainnet.WithURLs("https://node.example.com", "wss://node.example.com/ws"),
+		},
+		{
+			name: "custom websocket",
+			cfg:  Config{Network: "testnet", WsURL: "wss://ws.example.com/ws"},
+			want: constants.Testnet.WithURLs(constants.TestnetAPIURL, "wss://ws.example.com/ws"),
+		},
+		{name: "unknown network", cfg: Config{Network: "devnet"}, wantErr: true},
+		{name: "slippage", cfg: Config{Slippage: 1.5}, wantErr: true},
+		{name: "address", cfg: Config{AccountAddress: "0x1234"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.NetworkConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NetworkConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("NetworkConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvNetwork, "testnet")
+	t.Setenv(EnvAccountAddress, "0x8c967e73e7b15087c42a10d344cff4c96d877f1d")
+	t.Setenv(EnvTimeout, "1m30s")
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.Network != "testnet" || cfg.AccountAddress == "" || cfg.Timeout != 90*time.Second {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+
+	t.Setenv(EnvSlippage, "five")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() with an invalid slippage should fail")
+	}
+}
@@ -43,6 +43,12 @@
 //   - Mainnet: https://api.hyperliquid.xyz
 //   - Testnet: https://api.hyperliquid-testnet.xyz
 //
+// # WebSocket Actions
+//
+// Set ExchangeOptions.WsActions to submit orders, cancels and other actions over the
+// WebSocket post channel for lower latency, while info queries keep using HTTP.
+// Actions that cannot be sent over the WebSocket fall back to HTTP.
+//
 // # Error Handling
 //
 // All methods return typed responses with Status field:
@@ -64,11 +70,7 @@
 //	    true,                               // is buy
 //	    0.1,                                // size
 //	    50000.0,                            // limit price
-//	    types.OrderType{                    // order type
-//	        Limit: &types.LimitOrderType{
-//	            Tif: types.TifGtc,
-//	        },
-//	    },
+//	    types.NewLimit(types.TifGtc),       // order type
 //	    false,                              // reduce only
 //	    nil,                                // cloid
 //	    nil,                                // builder
@@ -76,32 +78,40 @@
 package client
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"fmt"
 	"math"
+	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/dwdwow/evmutil-go"
 	"github.com/dwdwow/hl-go/constants"
 	"github.com/dwdwow/hl-go/signing"
 	"github.com/dwdwow/hl-go/types"
 	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/wallet"
+	"github.com/dwdwow/hl-go/ws"
 )
 
 // Exchange provides trading functionality for the Hyperliquid exchange
 type Exchange struct {
 	*API
-	wallet         *ecdsa.PrivateKey
+	key            *wallet.PrivateKey
 	walletAddress  string
 	vaultAddress   *string
 	accountAddress *string
 	info           *Info
 	expiresAfter   *int64
+	slippage       float64
+	clock          utils.Clock
 }
 
 type ExchangeOptions struct {
@@ -111,6 +121,25 @@ type ExchangeOptions struct {
 	VaultAddress   *string
 	AccountAddress *string
 	UseWs          bool
+	// WsActions submits actions over the WebSocket post channel and everything else
+	// over HTTP, falling back to HTTP when the WebSocket is down. Ignored with UseWs.
+	WsActions bool
+	// Network sets the endpoints, unless BaseURL is set, and the signing parameters.
+	// Without it, the network is derived from BaseURL, see constants.NetworkForURL.
+	Network *constants.Network
+	// Slippage is the slippage of market orders placed without one, DefaultSlippage
+	// if 0
+	Slippage float64
+	// Clock gives the nonces and timestamps of actions, utils.SystemClock if nil.
+	// Tests can use a utils.FakeClock to sign deterministically.
+	Clock utils.Clock
+	// HTTPClient sends the HTTP requests, e.g. through a recording transport, instead
+	// of a client with Timeout. Ignored with UseWs.
+	HTTPClient *http.Client
+	// TracerProvider traces the info requests and actions of the exchange, and the
+	// WebSocket post requests of WsActions, the global provider of otel if nil.
+	// Signatures are traced with signing.SetTracerProvider.
+	TracerProvider trace.TracerProvider
 }
 
 // NewExchange creates a new Exchange client
@@ -124,20 +153,55 @@ func NewExchange(
 		options = &ExchangeOptions{}
 	}
 
+	baseURL := options.BaseURL
+	if baseURL == "" && options.Network != nil {
+		baseURL = options.Network.APIURL
+		if options.UseWs {
+			baseURL = options.Network.WsURL
+		}
+	}
+
 	var info *Info
 	var err error
 	// Create info client
 	if options.UseWs {
-		info, err = NewInfoUsingWs(options.BaseURL, options.Timeout)
+		info, err = NewInfoUsingWs(baseURL, options.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create info client: %w", err)
+		}
+	} else if options.HTTPClient != nil {
+		info, err = NewInfoUsingHTTPClient(baseURL, options.HTTPClient)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create info client: %w", err)
 		}
 	} else {
-		info, err = NewInfoUsingHTTP(options.BaseURL, options.Timeout)
+		info, err = NewInfoUsingHTTP(baseURL, options.Timeout)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create info client: %w", err)
 		}
 	}
+	if options.Network != nil {
+		info.API.SetNetwork(*options.Network)
+	}
+	if options.TracerProvider != nil {
+		info.API.SetTracerProvider(options.TracerProvider)
+	}
+
+	if options.WsActions && !options.UseWs {
+		w := ws.NewPostOnlyClient()
+		if options.Network != nil && options.BaseURL == "" {
+			w.SetNetwork(*options.Network)
+		} else {
+			w.SetURL(wsURLFor(info.BaseURL))
+		}
+		if options.TracerProvider != nil {
+			w.SetTracerProvider(options.TracerProvider)
+		}
+		if err := w.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start WebSocket client: %w", err)
+		}
+		info.API.SetWsActionClient(w)
+	}
 
 	// Get wallet address
 	pubKey := options.Wallet.Public()
@@ -149,11 +213,13 @@ func NewExchange(
 
 	return &Exchange{
 		API:            info.API,
-		wallet:         options.Wallet,
+		key:            wallet.NewPrivateKey(options.Wallet),
 		walletAddress:  walletAddress,
 		vaultAddress:   options.VaultAddress,
 		accountAddress: options.AccountAddress,
 		info:           info,
+		slippage:       options.Slippage,
+		clock:          options.Clock,
 	}, nil
 }
 
@@ -171,15 +237,126 @@ func NewExchangeFromTerminal(vaultAddress, accountAddress *string, useWs bool) (
 	return NewExchange(opts)
 }
 
+// NewExchangeFromKeystore creates an exchange client with a key loaded from a go-ethereum keystore directory.
+// If address is empty, the keystore must contain exactly one account.
+// options.Wallet is ignored and replaced by the decrypted key.
+func NewExchangeFromKeystore(dir, address string, passphrase wallet.PassphraseFunc, options *ExchangeOptions) (*Exchange, error) {
+	key, err := wallet.LoadFromKeystore(dir, address, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key from keystore: %w", err)
+	}
+	opts := ExchangeOptions{}
+	if options != nil {
+		opts = *options
+	}
+	opts.Wallet = key
+	return NewExchange(&opts)
+}
+
 // SetExpiresAfter sets the expiration time for actions (in milliseconds)
 // Set to nil to disable expiration
 func (e *Exchange) SetExpiresAfter(expiresAfter *int64) {
 	e.expiresAfter = expiresAfter
 }
 
-// GetWallet returns the private key
+// WithContext returns a copy of the exchange whose requests are made with ctx: they
+// are canceled when ctx is done and their spans are children of the span of ctx, so
+// an order can be traced from the request of a service that placed it:
+//
+//	result, err := exchange.WithContext(r.Context()).Order("ETH", true, 1, 2000, limit, false, nil, nil)
+//
+// The copy shares the connections and metadata of the exchange. The info requests it
+// makes internally, e.g. for the book of MarketOpen, keep the context of the exchange.
+func (e *Exchange) WithContext(ctx context.Context) *Exchange {
+	api := *e.API
+	api.ctx = ctx
+	copied := *e
+	copied.API = &api
+	return &copied
+}
+
+// SetClock sets the clock giving the nonces and timestamps of actions, nil for the
+// real clock
+func (e *Exchange) SetClock(clock utils.Clock) {
+	e.clock = clock
+}
+
+// now returns the time of the clock of the exchange
+func (e *Exchange) now() time.Time {
+	if e.clock == nil {
+		return time.Now()
+	}
+	return e.clock.Now()
+}
+
+// timestampMs returns the time of the clock of the exchange in milliseconds, used as
+// nonce of actions
+func (e *Exchange) timestampMs() int64 {
+	return utils.TimestampMs(e.clock)
+}
+
+// GetWallet returns the private key, or nil once the key is zeroed
+//
+// Deprecated: use Key, which keeps the key out of logs and supports zeroization.
 func (e *Exchange) GetWallet() *ecdsa.PrivateKey {
-	return e.wallet
+	var raw *ecdsa.PrivateKey
+	_ = e.key.Use(func(key *ecdsa.PrivateKey) error {
+		raw = key
+		return nil
+	})
+	return raw
+}
+
+// Key returns the guarded signing key
+func (e *Exchange) Key() *wallet.PrivateKey {
+	return e.key
+}
+
+// ZeroKey wipes the signing key from memory, e.g. on shutdown.
+// It waits for signatures in progress, and all signing methods fail with
+// wallet.ErrKeyZeroed afterwards.
+func (e *Exchange) ZeroKey() {
+	e.key.Zero()
+}
+
+// signL1Action signs an L1 action with the key of the exchange for its network
+func (e *Exchange) signL1Action(action any, vaultAddress *string, nonce int64, expiresAfter *int64) (*types.Signature, error) {
+	var signature *types.Signature
+	err := e.key.Use(func(key *ecdsa.PrivateKey) (err error) {
+		signature, err = signing.SignL1ActionForNetwork(key, action, vaultAddress, nonce, expiresAfter, e.Network())
+		return err
+	})
+	return signature, err
+}
+
+// signUserSignedAction signs a user-signed action with the key of the exchange for its network
+func (e *Exchange) signUserSignedAction(action map[string]any, signatureTypes []apitypes.Type, primaryType string) (*types.Signature, error) {
+	var signature *types.Signature
+	err := e.key.Use(func(key *ecdsa.PrivateKey) (err error) {
+		signature, err = signing.SignUserSignedActionForNetwork(key, action, signatureTypes, primaryType, e.Network())
+		return err
+	})
+	return signature, err
+}
+
+// signRegisteredUserSignedAction signs a registered user-signed action with the key of the exchange
+func (e *Exchange) signRegisteredUserSignedAction(action map[string]any, primaryType string) (*types.Signature, error) {
+	var signature *types.Signature
+	err := e.key.Use(func(key *ecdsa.PrivateKey) (err error) {
+		signature, err = signing.SignRegisteredUserSignedActionForNetwork(key, action, primaryType, e.Network())
+		return err
+	})
+	return signature, err
+}
+
+// signMultiSigAction signs a multi-sig action as its outer signer with the key of the exchange
+func (e *Exchange) signMultiSigAction(action map[string]any, vaultAddress *string, nonce int64, expiresAfter *int64) (*types.Signature, error) {
+	var signature *types.Signature
+	err := e.key.Use(func(key *ecdsa.PrivateKey) (err error) {
+		signature, err = signing.SignMultiSigActionForNetwork(key, action, e.Network(), vaultAddress, nonce, expiresAfter)
+		return err
+	})
+	return signature, err
 }
 
 // GetWalletAddress returns the wallet address
@@ -215,61 +392,107 @@ func (e *Exchange) postAction(action map[string]any, signature *types.Signature,
 	return e.exchangePost("/exchange", payload, result)
 }
 
-// slippagePrice calculates the price with slippage applied
-func (e *Exchange) slippagePrice(name string, isBuy bool, slippage float64, px *float64) (float64, error) {
-	coin, ok := e.info.nameToCoin[name]
-	if !ok {
-		return 0, fmt.Errorf("unknown coin: %s", name)
+// postTypedAction posts a signed action and decodes the response data into a T
+func postTypedAction[T any](e *Exchange, action map[string]any, signature *types.Signature, nonce int64) (*T, error) {
+	var result T
+	if err := e.postAction(action, signature, nonce, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SendL1Action signs action as an L1 action of the exchange, with the vault and
+// expiration of the exchange, posts it and decodes the response data into a T. It is
+// the building block of the trading methods, for actions they do not cover yet:
+//
+//	action := utils.NewOrderedMap("type", "noop")
+//	result, err := client.SendL1Action[types.DefaultResponse](exchange, action)
+func SendL1Action[T any](e *Exchange, action map[string]any) (*T, error) {
+	nonce := e.timestampMs()
+	signature, err := e.signL1Action(
+		action,
+		e.vaultAddress,
+		nonce,
+		e.expiresAfter,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign action: %w", err)
+	}
+	return postTypedAction[T](e, action, signature, nonce)
+}
+
+// slippagePrice calculates the price with slippage applied. Without px, the price is
+// taken from the L2 book of the coin, and an order of sz that would not fill at all
+// within the slippage fails before being sent.
+func (e *Exchange) slippagePrice(name string, isBuy bool, sz, slippage float64, px *float64) (float64, error) {
+	coin, err := e.info.resolveCoin(name)
+	if err != nil {
+		return 0, err
 	}
 
 	// Get mid price if not provided
 	price := float64(0)
+	var book *types.L2BookData
 	if px == nil {
-		mids, err := e.info.AllMids("")
+		var err error
+		book, err = e.info.L2Snapshot(coin)
 		if err != nil {
-			return 0, fmt.Errorf("failed to get mid price: %w", err)
+			return 0, fmt.Errorf("failed to get order book: %w", err)
 		}
-		midStr, ok := mids[coin]
+		mid, ok := book.Mid()
 		if !ok {
-			return 0, fmt.Errorf("no mid price for %s", coin)
+			mid, err = e.midPrice(coin)
+			if err != nil {
+				return 0, err
+			}
 		}
-		price, _ = strconv.ParseFloat(midStr, 64)
+		price = mid
 	} else {
 		price = *px
 	}
 
-	asset, ok := e.info.coinToAsset[coin]
+	asset, ok := e.info.coinAsset(coin)
 	if !ok {
 		return 0, fmt.Errorf("unknown coin: %s", coin)
 	}
 
-	// Check if spot asset
-	isSpot := asset >= constants.SpotAssetOffset
+	isSpot := constants.IsSpotAsset(asset)
 
-	// Apply slippage
-	if isBuy {
-		price *= (1 + slippage)
-	} else {
-		price *= (1 - slippage)
-	}
+	// Apply slippage and round to the tick of the asset
+	szDecimals, _ := e.info.szDecimals(asset)
+	price = utils.RoundToTick(utils.SlippagePx(price, utils.FractionToBps(slippage), isBuy), szDecimals, isSpot)
 
-	// Round to appropriate decimals
-	decimals := 6
-	if isSpot {
-		decimals = 8
+	if book != nil {
+		fill, err := book.EstimateFillWithin(isBuy, sz, price)
+		if err != nil {
+			return 0, err
+		}
+		if fill.Sz == 0 {
+			return 0, fmt.Errorf("no liquidity for %s within %v bps", coin, utils.FractionToBps(slippage))
+		}
 	}
+	return price, nil
+}
 
-	szDecimals, ok := e.info.assetToSzDecimals[asset]
+// midPrice returns the mid price of coin from AllMids
+func (e *Exchange) midPrice(coin string) (float64, error) {
+	var dex string
+	if ref, err := types.ParseCoinRef(coin); err == nil {
+		dex = ref.Dex
+	}
+	mids, err := e.info.AllMids(dex)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get mid price: %w", err)
+	}
+	midStr, ok := mids[coin]
 	if !ok {
-		szDecimals = 0
+		return 0, fmt.Errorf("no mid price for %s", coin)
 	}
-
-	decimals = decimals - szDecimals
-
-	// Round to 5 significant figures and appropriate decimals
-	rounded := utils.RoundPrice(price, 5, decimals)
-
-	return rounded, nil
+	mid, err := strconv.ParseFloat(midStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mid price for %s: %w", coin, err)
+	}
+	return mid, nil
 }
 
 // Order places a single order
@@ -300,49 +523,77 @@ func (e *Exchange) Order(
 func (e *Exchange) BulkOrders(orders []types.OrderRequest, builder *types.BuilderInfo) (*types.OrderResponse, error) {
 	// Convert orders to wire format
 	orderWires := make([]types.OrderWire, len(orders))
+	perp := false
 	for i, order := range orders {
+		if err := order.OrderType.Check(); err != nil {
+			return nil, fmt.Errorf("invalid order %d: %w", i, err)
+		}
 		asset, err := e.info.NameToAsset(order.Coin)
 		if err != nil {
 			return nil, fmt.Errorf("invalid coin for order %d: %w", i, err)
 		}
+		perp = perp || !constants.IsSpotAsset(asset)
+		if err := e.validateOrder(order, asset); err != nil {
+			return nil, fmt.Errorf("invalid order %d: %w", i, err)
+		}
 
-		wire, err := signing.OrderRequestToOrderWire(order, asset)
+		wire, err := order.ToWire(asset)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert order %d to wire format: %w", i, err)
 		}
 		orderWires[i] = wire
 	}
 
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
-	// Prepare builder info
+	// Prepare builder info, whose fee is capped lower when the batch has perp orders
 	if builder != nil {
 		builder.B = strings.ToLower(builder.B)
+		validate := builder.Validate
+		if perp {
+			validate = builder.ValidatePerp
+		}
+		if err := validate(); err != nil {
+			return nil, fmt.Errorf("invalid builder: %w", err)
+		}
 	}
 
 	// Create order action
 	action := signing.OrderWiresToOrderAction(orderWires, builder)
 
 	// Sign action
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign order: %w", err)
 	}
 
-	// Post action with typed response
-	var result types.OrderResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
+	return postTypedAction[types.OrderResponse](e, action, signature, timestamp)
+}
+
+// validateOrder checks order against the precision of asset, when the metadata of the
+// asset is loaded
+func (e *Exchange) validateOrder(order types.OrderRequest, asset int) error {
+	szDecimals, ok := e.info.szDecimals(asset)
+	if !ok {
+		return nil
 	}
+	if constants.IsSpotAsset(asset) {
+		return order.ValidateSpot(szDecimals)
+	}
+	return order.Validate(types.AssetInfo{Name: order.Coin, SzDecimals: szDecimals})
+}
 
-	return &result, nil
+// defaultSlippage returns the slippage of market orders placed without one
+func (e *Exchange) defaultSlippage() float64 {
+	if e.slippage != 0 {
+		return e.slippage
+	}
+	return constants.DefaultSlippage
 }
 
 // MarketOpen opens a position with a market order (aggressive limit order with IOC)
@@ -356,19 +607,17 @@ func (e *Exchange) MarketOpen(
 	builder *types.BuilderInfo,
 ) (*types.OrderResponse, error) {
 	if slippage == 0 {
-		slippage = constants.DefaultSlippage
+		slippage = e.defaultSlippage()
 	}
 
 	// Calculate price with slippage
-	price, err := e.slippagePrice(name, isBuy, slippage, px)
+	price, err := e.slippagePrice(name, isBuy, sz, slippage, px)
 	if err != nil {
 		return nil, err
 	}
 
 	// Market order is an aggressive limit order with IOC
-	orderType := types.OrderType{
-		Limit: &types.LimitOrderType{Tif: types.TifIoc},
-	}
+	orderType := types.NewLimit(types.TifIoc)
 
 	return e.Order(name, isBuy, sz, price, orderType, false, cloid, builder)
 }
@@ -383,7 +632,7 @@ func (e *Exchange) MarketClose(
 	builder *types.BuilderInfo,
 ) (*types.OrderResponse, error) {
 	if slippage == 0 {
-		slippage = constants.DefaultSlippage
+		slippage = e.defaultSlippage()
 	}
 
 	// Get user address
@@ -405,7 +654,7 @@ func (e *Exchange) MarketClose(
 	found := false
 	for _, assetPos := range userState.AssetPositions {
 		if assetPos.Position.Coin == name {
-			szi, _ := strconv.ParseFloat(assetPos.Position.Szi, 64)
+			szi, _ := assetPos.Position.SziFloat()
 			positionSzi = szi
 			found = true
 			break
@@ -426,15 +675,13 @@ func (e *Exchange) MarketClose(
 	isBuy := positionSzi < 0
 
 	// Calculate price with slippage
-	price, err := e.slippagePrice(name, isBuy, slippage, px)
+	price, err := e.slippagePrice(name, isBuy, *size, slippage, px)
 	if err != nil {
 		return nil, err
 	}
 
 	// Market order is an aggressive limit order with IOC
-	orderType := types.OrderType{
-		Limit: &types.LimitOrderType{Tif: types.TifIoc},
-	}
+	orderType := types.NewLimit(types.TifIoc)
 
 	return e.Order(name, isBuy, *size, price, orderType, true, cloid, builder)
 }
@@ -451,7 +698,7 @@ func (e *Exchange) CancelByCloid(name string, cloid types.Cloid) (*types.CancelR
 
 // BulkCancel cancels multiple orders by order ID
 func (e *Exchange) BulkCancel(cancels []types.CancelRequest) (*types.CancelResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Create cancel action
 	cancelWires := make([]map[string]any, len(cancels))
@@ -475,30 +722,22 @@ func (e *Exchange) BulkCancel(cancels []types.CancelRequest) (*types.CancelRespo
 	)
 
 	// Sign action
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign cancel: %w", err)
 	}
 
-	// Post action with typed response
-	var result types.CancelResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.CancelResponse](e, action, signature, timestamp)
 }
 
 // BulkCancelByCloid cancels multiple orders by client order ID
 func (e *Exchange) BulkCancelByCloid(cancels []types.CancelByCloidRequest) (*types.CancelResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Create cancel action
 	cancelWires := make([]map[string]any, len(cancels))
@@ -522,30 +761,22 @@ func (e *Exchange) BulkCancelByCloid(cancels []types.CancelByCloidRequest) (*typ
 	)
 
 	// Sign action
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign cancel: %w", err)
 	}
 
-	// Post action with typed response
-	var result types.CancelResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.CancelResponse](e, action, signature, timestamp)
 }
 
 // UpdateLeverage updates the leverage for a coin
 func (e *Exchange) UpdateLeverage(leverage int, name string, isCross bool) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	asset, err := e.info.NameToAsset(name)
 	if err != nil {
@@ -560,29 +791,26 @@ func (e *Exchange) UpdateLeverage(leverage int, name string, isCross bool) (*typ
 		"leverage", leverage,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign leverage update: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // USDTransfer transfers USD to another address
 func (e *Exchange) USDTransfer(amount float64, destination string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(destination); err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"destination": ..., "amount": ..., "time": ..., "type": "usdSend"}
 	action := utils.NewOrderedMap(
@@ -592,28 +820,21 @@ func (e *Exchange) USDTransfer(amount float64, destination string) (*types.Defau
 		"type", "usdSend",
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.USDSendSignTypes,
 		"HyperliquidTransaction:UsdSend",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign USD transfer: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // USDClassTransfer transfers funds between perpetual and spot wallets
 func (e *Exchange) USDClassTransfer(amount float64, toPerp bool) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	amountStr := fmt.Sprintf("%f", amount)
 	if e.vaultAddress != nil {
@@ -628,28 +849,21 @@ func (e *Exchange) USDClassTransfer(amount float64, toPerp bool) (*types.Default
 		"nonce", timestamp,
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.USDClassTransferSignTypes,
 		"HyperliquidTransaction:UsdClassTransfer",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign USD class transfer: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // CreateSubAccount creates a new sub-account
 func (e *Exchange) CreateSubAccount(name string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "createSubAccount", "name": ...}
 	action := utils.NewOrderedMap(
@@ -657,29 +871,22 @@ func (e *Exchange) CreateSubAccount(name string) (*types.DefaultResponse, error)
 		"name", name,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign sub-account creation: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SetReferrer sets the referral code for the account
 func (e *Exchange) SetReferrer(code string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "setReferrer", "code": ...}
 	action := utils.NewOrderedMap(
@@ -687,24 +894,17 @@ func (e *Exchange) SetReferrer(code string) (*types.DefaultResponse, error) {
 		"code", code,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign referrer update: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // ModifyOrder modifies a single order
@@ -735,32 +935,26 @@ func (e *Exchange) ModifyOrder(
 
 // BulkModifyOrders modifies multiple orders
 func (e *Exchange) BulkModifyOrders(modifies []types.ModifyRequest) (*types.ModifyResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	modifyWires := make([]types.ModifyWire, len(modifies))
 	for i, modify := range modifies {
+		if err := modify.Order.OrderType.Check(); err != nil {
+			return nil, fmt.Errorf("invalid order %d: %w", i, err)
+		}
 		asset, err := e.info.NameToAsset(modify.Order.Coin)
 		if err != nil {
 			return nil, fmt.Errorf("invalid coin for modify %d: %w", i, err)
 		}
+		if err := e.validateOrder(modify.Order, asset); err != nil {
+			return nil, fmt.Errorf("invalid order %d: %w", i, err)
+		}
 
-		orderWire, err := signing.OrderRequestToOrderWire(modify.Order, asset)
+		wire, err := modify.ToWire(asset)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert order %d to wire format: %w", i, err)
 		}
-
-		// Handle oid - can be int or Cloid
-		var oidValue any
-		if cloid, ok := modify.Oid.(*types.Cloid); ok {
-			oidValue = cloid.ToRaw()
-		} else {
-			oidValue = modify.Oid
-		}
-
-		modifyWires[i] = types.ModifyWire{
-			Oid:   oidValue,
-			Order: orderWire,
-		}
+		modifyWires[i] = wire
 	}
 
 	// Python SDK: {"type": "batchModify", "modifies": ...}
@@ -769,29 +963,22 @@ func (e *Exchange) BulkModifyOrders(modifies []types.ModifyRequest) (*types.Modi
 		"modifies", modifyWires,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign modify: %w", err)
 	}
 
-	var result types.ModifyResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.ModifyResponse](e, action, signature, timestamp)
 }
 
 // ScheduleCancel schedules a time to cancel all open orders (dead man's switch)
 func (e *Exchange) ScheduleCancel(time *int64) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "scheduleCancel"} or {"type": "scheduleCancel", "time": ...}
 	action := utils.NewOrderedMap("type", "scheduleCancel")
@@ -799,29 +986,22 @@ func (e *Exchange) ScheduleCancel(time *int64) (*types.DefaultResponse, error) {
 		action["time"] = *time
 	}
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign schedule cancel: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // UpdateIsolatedMargin adds or removes margin from isolated position
 func (e *Exchange) UpdateIsolatedMargin(amount float64, name string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	asset, err := e.info.NameToAsset(name)
 	if err != nil {
@@ -839,29 +1019,26 @@ func (e *Exchange) UpdateIsolatedMargin(amount float64, name string) (*types.Def
 		"ntli", ntli,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign isolated margin update: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotTransfer sends spot assets to another address
 func (e *Exchange) SpotTransfer(amount float64, destination string, token string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(destination); err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"destination": ..., "amount": ..., "token": ..., "time": ..., "type": "spotSend"}
 	action := utils.NewOrderedMap(
@@ -872,28 +1049,25 @@ func (e *Exchange) SpotTransfer(amount float64, destination string, token string
 		"type", "spotSend",
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.SpotSendSignTypes,
 		"HyperliquidTransaction:SpotSend",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot transfer: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // WithdrawFromBridge initiates a withdrawal request
 func (e *Exchange) WithdrawFromBridge(amount float64, destination string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(destination); err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"destination": ..., "amount": ..., "time": ..., "type": "withdraw3"}
 	action := utils.NewOrderedMap(
@@ -903,23 +1077,16 @@ func (e *Exchange) WithdrawFromBridge(amount float64, destination string) (*type
 		"type", "withdraw3",
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.Withdraw3SignTypes,
 		"HyperliquidTransaction:Withdraw",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign withdrawal: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SendAsset transfers tokens between different perp DEXs, spot, users, and/or sub-accounts
@@ -930,7 +1097,11 @@ func (e *Exchange) SendAsset(
 	token string,
 	amount float64,
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(destination); err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	fromSubAccount := ""
 	if e.vaultAddress != nil {
@@ -949,28 +1120,25 @@ func (e *Exchange) SendAsset(
 		"nonce", timestamp,
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.SendAssetSignTypes,
 		"HyperliquidTransaction:SendAsset",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign send asset: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SubAccountTransfer transfers USDC between main account and sub-account
 func (e *Exchange) SubAccountTransfer(subAccountUser string, isDeposit bool, usd int) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(subAccountUser); err != nil {
+		return nil, fmt.Errorf("invalid sub-account: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "subAccountTransfer", "subAccountUser": ..., "isDeposit": ..., "usd": ...}
 	action := utils.NewOrderedMap(
@@ -980,29 +1148,26 @@ func (e *Exchange) SubAccountTransfer(subAccountUser string, isDeposit bool, usd
 		"usd", usd,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign sub-account transfer: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SubAccountSpotTransfer transfers spot assets between main account and sub-account
 func (e *Exchange) SubAccountSpotTransfer(subAccountUser string, isDeposit bool, token string, amount float64) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(subAccountUser); err != nil {
+		return nil, fmt.Errorf("invalid sub-account: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "subAccountSpotTransfer", "subAccountUser": ..., "isDeposit": ..., "token": ..., "amount": ...}
 	action := utils.NewOrderedMap(
@@ -1013,29 +1178,26 @@ func (e *Exchange) SubAccountSpotTransfer(subAccountUser string, isDeposit bool,
 		"amount", fmt.Sprintf("%f", amount),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign sub-account spot transfer: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // VaultTransfer deposits or withdraws from a vault
 func (e *Exchange) VaultTransfer(vaultAddress string, isDeposit bool, usd int) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(vaultAddress); err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "vaultTransfer", "vaultAddress": ..., "isDeposit": ..., "usd": ...}
 	action := utils.NewOrderedMap(
@@ -1045,29 +1207,92 @@ func (e *Exchange) VaultTransfer(vaultAddress string, isDeposit bool, usd int) (
 		"usd", usd,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign vault transfer: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
+}
+
+// VaultDistribute distributes usd of a vault led by the account to its followers, in
+// proportion to their equity. Like VaultTransfer, usd is in millionths of USD.
+func (e *Exchange) VaultDistribute(vaultAddress string, usd int) (*types.DefaultResponse, error) {
+	if err := utils.ValidateAddress(vaultAddress); err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
 	}
 
-	return &result, nil
+	timestamp := e.timestampMs()
+
+	action := utils.NewOrderedMap(
+		"type", "vaultDistribute",
+		"vaultAddress", vaultAddress,
+		"usd", usd,
+	)
+
+	signature, err := e.signL1Action(
+		action,
+		nil,
+		timestamp,
+		e.expiresAfter,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign vault distribute: %w", err)
+	}
+
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
+}
+
+// VaultModify changes the settings of a vault led by the account: whether it accepts
+// deposits and whether withdrawals close positions. Nil settings are left unchanged.
+func (e *Exchange) VaultModify(vaultAddress string, allowDeposits *bool, alwaysCloseOnWithdraw *bool) (*types.DefaultResponse, error) {
+	if err := utils.ValidateAddress(vaultAddress); err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+
+	timestamp := e.timestampMs()
+
+	var allowDepositsValue any
+	if allowDeposits != nil {
+		allowDepositsValue = *allowDeposits
+	}
+	var alwaysCloseOnWithdrawValue any
+	if alwaysCloseOnWithdraw != nil {
+		alwaysCloseOnWithdrawValue = *alwaysCloseOnWithdraw
+	}
+
+	action := utils.NewOrderedMap(
+		"type", "vaultModify",
+		"vaultAddress", vaultAddress,
+		"allowDeposits", allowDepositsValue,
+		"alwaysCloseOnWithdraw", alwaysCloseOnWithdrawValue,
+	)
+
+	signature, err := e.signL1Action(
+		action,
+		nil,
+		timestamp,
+		e.expiresAfter,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign vault modify: %w", err)
+	}
+
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // TokenDelegate delegates or undelegates stake from validator
 func (e *Exchange) TokenDelegate(validator string, wei int64, isUndelegate bool) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(validator); err != nil {
+		return nil, fmt.Errorf("invalid validator: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"validator": ..., "wei": ..., "isUndelegate": ..., "nonce": ..., "type": "tokenDelegate"}
 	action := utils.NewOrderedMap(
@@ -1078,28 +1303,25 @@ func (e *Exchange) TokenDelegate(validator string, wei int64, isUndelegate bool)
 		"type", "tokenDelegate",
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.TokenDelegateSignTypes,
 		"HyperliquidTransaction:TokenDelegate",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign token delegate: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // ApproveAgent approves an API wallet
 func (e *Exchange) ApproveAgent(agentAddress string, agentName *string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(agentAddress); err != nil {
+		return nil, fmt.Errorf("invalid agent address: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "approveAgent", "agentAddress": ..., "agentName": ... (optional), "nonce": ...}
 	action := utils.NewOrderedMap(
@@ -1111,28 +1333,59 @@ func (e *Exchange) ApproveAgent(agentAddress string, agentName *string) (*types.
 		action["agentName"] = *agentName
 	}
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.ApproveAgentSignTypes,
 		"HyperliquidTransaction:ApproveAgent",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign approve agent: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
+}
+
+// ApproveNewAgent generates an agent wallet in store, approves it for this account and
+// records the approval. The agent can then trade for the account through an Exchange
+// created with the agent key as Wallet and this account as AccountAddress.
+// An empty name approves an unnamed agent. With validUntil the agent is approved
+// under its AgentMetadata.ApprovalName, so the exchange enforces the expiry.
+func (e *Exchange) ApproveNewAgent(
+	store *wallet.AgentStore,
+	name string,
+	validUntil *time.Time,
+	passphrase string,
+) (*types.DefaultResponse, *wallet.Agent, error) {
+	agent, err := store.Generate(name, e.GetAccountAddress(), validUntil, passphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate agent: %w", err)
 	}
 
-	return &result, nil
+	var agentName *string
+	if approvalName := agent.ApprovalName(); approvalName != "" {
+		agentName = &approvalName
+	}
+	result, err := e.ApproveAgent(agent.AgentAddress, agentName)
+	if err != nil {
+		return nil, agent, err
+	}
+
+	approvedAt := e.now().UTC()
+	if err := store.MarkApproved(agent.AgentAddress, approvedAt); err != nil {
+		return result, agent, fmt.Errorf("failed to record agent approval: %w", err)
+	}
+	agent.ApprovedAt = &approvedAt
+
+	return result, agent, nil
 }
 
 // ApproveBuilderFee approves a maximum fee rate for a builder
 func (e *Exchange) ApproveBuilderFee(builder string, maxFeeRate string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(builder); err != nil {
+		return nil, fmt.Errorf("invalid builder: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"maxFeeRate": ..., "builder": ..., "nonce": ..., "type": "approveBuilderFee"}
 	action := utils.NewOrderedMap(
@@ -1142,23 +1395,16 @@ func (e *Exchange) ApproveBuilderFee(builder string, maxFeeRate string) (*types.
 		"type", "approveBuilderFee",
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.ApproveBuilderFeeSignTypes,
 		"HyperliquidTransaction:ApproveBuilderFee",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign approve builder fee: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // Noop does nothing but marks the nonce as used (useful for canceling in-flight orders)
@@ -1166,29 +1412,26 @@ func (e *Exchange) Noop(nonce int64) (*types.DefaultResponse, error) {
 	// Python SDK: {"type": "noop"}
 	action := utils.NewOrderedMap("type", "noop")
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		nonce,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign noop: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, nonce, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, nonce)
 }
 
 // UserDexAbstraction enables HIP-3 DEX abstraction
 func (e *Exchange) UserDexAbstraction(user string, enabled bool) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	if err := utils.ValidateAddress(user); err != nil {
+		return nil, fmt.Errorf("invalid user: %w", err)
+	}
+
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "userDexAbstraction", "user": ..., "enabled": ..., "nonce": ...}
 	action := utils.NewOrderedMap(
@@ -1198,50 +1441,36 @@ func (e *Exchange) UserDexAbstraction(user string, enabled bool) (*types.Default
 		"nonce", timestamp,
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.UserDexAbstractionSignTypes,
 		"HyperliquidTransaction:UserDexAbstraction",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign user dex abstraction: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // AgentEnableDexAbstraction enables HIP-3 DEX abstraction (agent version)
 func (e *Exchange) AgentEnableDexAbstraction() (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "agentEnableDexAbstraction"}
 	action := utils.NewOrderedMap("type", "agentEnableDexAbstraction")
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign agent enable dex abstraction: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // TWAPOrder places a TWAP order
@@ -1253,7 +1482,7 @@ func (e *Exchange) TWAPOrder(
 	minutes int,
 	randomize bool,
 ) (*types.TWAPOrderResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	asset, err := e.info.NameToAsset(name)
 	if err != nil {
@@ -1273,29 +1502,22 @@ func (e *Exchange) TWAPOrder(
 		),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign TWAP order: %w", err)
 	}
 
-	var result types.TWAPOrderResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.TWAPOrderResponse](e, action, signature, timestamp)
 }
 
 // TWAPCancel cancels a TWAP order
 func (e *Exchange) TWAPCancel(name string, twapID int) (*types.TWAPCancelResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	asset, err := e.info.NameToAsset(name)
 	if err != nil {
@@ -1309,29 +1531,22 @@ func (e *Exchange) TWAPCancel(name string, twapID int) (*types.TWAPCancelRespons
 		"t", twapID,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		e.vaultAddress,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign TWAP cancel: %w", err)
 	}
 
-	var result types.TWAPCancelResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.TWAPCancelResponse](e, action, signature, timestamp)
 }
 
 // UseBigBlocks enables or disables big blocks for EVM
 func (e *Exchange) UseBigBlocks(enable bool) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "evmUserModify", "usingBigBlocks": ...}
 	action := utils.NewOrderedMap(
@@ -1339,29 +1554,22 @@ func (e *Exchange) UseBigBlocks(enable bool) (*types.DefaultResponse, error) {
 		"usingBigBlocks", enable,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign use big blocks: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // ConvertToMultiSigUser converts an account to multi-sig
 func (e *Exchange) ConvertToMultiSigUser(authorizedUsers []string, threshold int) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Sort authorized users
 	sortedUsers := make([]string, len(authorizedUsers))
@@ -1384,23 +1592,63 @@ func (e *Exchange) ConvertToMultiSigUser(authorizedUsers []string, threshold int
 		"nonce", timestamp,
 	)
 
-	signature, err := signing.SignUserSignedAction(
-		e.wallet,
+	signature, err := e.signUserSignedAction(
 		action,
 		signing.ConvertToMultiSigUserSignTypes,
 		"HyperliquidTransaction:ConvertToMultiSigUser",
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign convert to multi-sig: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
+}
+
+// UserSignedAction signs and posts any user-signed action registered with
+// signing.RegisterUserSignedActionType, for action types the SDK has no dedicated method for yet.
+// The action type is taken from the registry; the nonce or time field is set to the current
+// timestamp unless provided in fields, as an integer or a whole float64 such as decoded
+// from JSON.
+func (e *Exchange) UserSignedAction(primaryType string, fields map[string]any) (*types.DefaultResponse, error) {
+	actionType, ok := signing.LookupUserSignedActionType(primaryType)
+	if !ok {
+		return nil, fmt.Errorf("unknown primary type: %s", primaryType)
+	}
+
+	action := make(map[string]any, len(fields)+3)
+	for k, v := range fields {
+		action[k] = v
+	}
+	action["type"] = actionType.ActionType
+
+	nonceField := actionType.NonceField()
+	var timestamp int64
+	switch v := action[nonceField].(type) {
+	case nil:
+		timestamp = e.timestampMs()
+		action[nonceField] = timestamp
+	case int64:
+		timestamp = v
+	case int:
+		timestamp = int64(v)
+	case uint64:
+		timestamp = int64(v)
+	case float64:
+		if v < 0 || v >= 1<<63 || v != math.Trunc(v) {
+			return nil, fmt.Errorf("%s must be a non-negative whole number, got %v", nonceField, v)
+		}
+		timestamp = int64(v)
+		action[nonceField] = uint64(v)
+	default:
+		return nil, fmt.Errorf("%s must be an integer, got %T", nonceField, v)
 	}
 
-	return &result, nil
+	signature, err := e.signRegisteredUserSignedAction(action, primaryType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %s: %w", actionType.ActionType, err)
+	}
+
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeployRegisterToken registers a new spot token
@@ -1411,7 +1659,7 @@ func (e *Exchange) SpotDeployRegisterToken(
 	maxGas int,
 	fullName string,
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "spotDeploy", "registerToken2": {"spec": {"name": ..., "szDecimals": ..., "weiDecimals": ...}, "maxGas": ..., "fullName": ...}}
 	action := utils.NewOrderedMap(
@@ -1427,24 +1675,17 @@ func (e *Exchange) SpotDeployRegisterToken(
 		),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy register token: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeployUserGenesis sets initial token distribution
@@ -1459,7 +1700,7 @@ func (e *Exchange) SpotDeployUserGenesis(
 		Wei   string
 	},
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	userWeiList := make([][]string, len(userAndWei))
 	for i, uw := range userAndWei {
@@ -1481,24 +1722,17 @@ func (e *Exchange) SpotDeployUserGenesis(
 		),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy user genesis: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeployEnableFreezePrivilege enables freeze privilege for a token
@@ -1508,7 +1742,7 @@ func (e *Exchange) SpotDeployEnableFreezePrivilege(token int) (*types.DefaultRes
 
 // SpotDeployFreezeUser freezes or unfreezes a user for a token
 func (e *Exchange) SpotDeployFreezeUser(token int, user string, freeze bool) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "spotDeploy", "freezeUser": {"token": ..., "user": ..., "freeze": ...}}
 	action := utils.NewOrderedMap(
@@ -1520,24 +1754,17 @@ func (e *Exchange) SpotDeployFreezeUser(token int, user string, freeze bool) (*t
 		),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy freeze user: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeployRevokeFreezePrivilege revokes freeze privilege for a token
@@ -1552,7 +1779,7 @@ func (e *Exchange) SpotDeployEnableQuoteToken(token int) (*types.DefaultResponse
 
 // spotDeployTokenActionInner is a helper for spot deploy token actions
 func (e *Exchange) spotDeployTokenActionInner(variant string, token int) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "spotDeploy", variant: {"token": ...}}
 	action := utils.NewOrderedMap(
@@ -1560,29 +1787,22 @@ func (e *Exchange) spotDeployTokenActionInner(variant string, token int) (*types
 		variant, utils.NewOrderedMap("token", token),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy token action: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeployGenesis performs genesis for a token
 func (e *Exchange) SpotDeployGenesis(token int, maxSupply string, noHyperliquidity bool) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "spotDeploy", "genesis": {"token": ..., "maxSupply": ..., "noHyperliquidity": ... (optional)}}
 	genesis := utils.NewOrderedMap("token", token, "maxSupply", maxSupply)
@@ -1595,29 +1815,22 @@ func (e *Exchange) SpotDeployGenesis(token int, maxSupply string, noHyperliquidi
 		"genesis", genesis,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy genesis: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeployRegisterSpot registers a spot market
 func (e *Exchange) SpotDeployRegisterSpot(baseToken int, quoteToken int) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "spotDeploy", "registerSpot": {"tokens": ...}}
 	action := utils.NewOrderedMap(
@@ -1625,24 +1838,17 @@ func (e *Exchange) SpotDeployRegisterSpot(baseToken int, quoteToken int) (*types
 		"registerSpot", utils.NewOrderedMap("tokens", []int{baseToken, quoteToken}),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy register spot: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeployRegisterHyperliquidity registers hyperliquidity for a spot market
@@ -1653,7 +1859,7 @@ func (e *Exchange) SpotDeployRegisterHyperliquidity(
 	nOrders int,
 	nSeededLevels *int,
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "spotDeploy", "registerHyperliquidity": {"spot": ..., "startPx": ..., "orderSz": ..., "nOrders": ..., "nSeededLevels": ... (optional)}}
 	registerHL := utils.NewOrderedMap(
@@ -1671,29 +1877,22 @@ func (e *Exchange) SpotDeployRegisterHyperliquidity(
 		"registerHyperliquidity", registerHL,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy register hyperliquidity: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // SpotDeploySetDeployerTradingFeeShare sets the deployer trading fee share
 func (e *Exchange) SpotDeploySetDeployerTradingFeeShare(token int, share string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "spotDeploy", "setDeployerTradingFeeShare": {"token": ..., "share": ...}}
 	action := utils.NewOrderedMap(
@@ -1701,24 +1900,17 @@ func (e *Exchange) SpotDeploySetDeployerTradingFeeShare(token int, share string)
 		"setDeployerTradingFeeShare", utils.NewOrderedMap("token", token, "share", share),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign spot deploy set deployer trading fee share: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // PerpDeployRegisterAsset registers a new asset on a perp DEX
@@ -1736,7 +1928,7 @@ func (e *Exchange) PerpDeployRegisterAsset(
 		OracleUpdater   *string
 	},
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	var schemaWire map[string]any
 	if schema != nil {
@@ -1769,24 +1961,17 @@ func (e *Exchange) PerpDeployRegisterAsset(
 		),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign perp deploy register asset: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // PerpDeploySetOracle sets oracle prices for a perp DEX
@@ -1796,7 +1981,7 @@ func (e *Exchange) PerpDeploySetOracle(
 	allMarkPxs []map[string]string,
 	externalPerpPxs map[string]string,
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK sorts all price maps: sorted(list(oracle_pxs.items()))
 	// Sort oracle prices
@@ -1841,24 +2026,17 @@ func (e *Exchange) PerpDeploySetOracle(
 		),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign perp deploy set oracle: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // CSignerUnjailSelf unjails the C-signer
@@ -1873,7 +2051,7 @@ func (e *Exchange) CSignerJailSelf() (*types.DefaultResponse, error) {
 
 // cSignerInner is a helper for C-signer actions
 func (e *Exchange) cSignerInner(variant string) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "CSignerAction", variant: None}
 	action := utils.NewOrderedMap(
@@ -1881,24 +2059,17 @@ func (e *Exchange) cSignerInner(variant string) (*types.DefaultResponse, error)
 		variant, nil,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign C-signer action: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // CValidatorRegister registers a new validator
@@ -1912,7 +2083,7 @@ func (e *Exchange) CValidatorRegister(
 	unjailed bool,
 	initialWei int64,
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "CValidatorAction", "register": {"profile": {...}, "unjailed": ..., "initial_wei": ...}}
 	action := utils.NewOrderedMap(
@@ -1931,24 +2102,17 @@ func (e *Exchange) CValidatorRegister(
 		),
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign C-validator register: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // CValidatorChangeProfile changes validator profile
@@ -1961,7 +2125,7 @@ func (e *Exchange) CValidatorChangeProfile(
 	commissionBps *int,
 	signer *string,
 ) (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "CValidatorAction", "changeProfile": {"node_ip": ..., "name": ..., "description": ..., "unjailed": ..., "disable_delegations": ..., "commission_bps": ..., "signer": ...}}
 	// Build profile with fields in Python SDK order: node_ip, name, description, unjailed, disable_delegations, commission_bps, signer
@@ -2022,29 +2186,22 @@ func (e *Exchange) CValidatorChangeProfile(
 		"changeProfile", profile,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign C-validator change profile: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // CValidatorUnregister unregisters a validator
 func (e *Exchange) CValidatorUnregister() (*types.DefaultResponse, error) {
-	timestamp := utils.GetTimestampMs()
+	timestamp := e.timestampMs()
 
 	// Python SDK: {"type": "CValidatorAction", "unregister": None}
 	action := utils.NewOrderedMap(
@@ -2052,24 +2209,17 @@ func (e *Exchange) CValidatorUnregister() (*types.DefaultResponse, error) {
 		"unregister", nil,
 	)
 
-	signature, err := signing.SignL1Action(
-		e.wallet,
+	signature, err := e.signL1Action(
 		action,
 		nil,
 		timestamp,
 		e.expiresAfter,
-		e.IsMainnet(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign C-validator unregister: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(action, signature, timestamp, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, action, signature, timestamp)
 }
 
 // MultiSig executes a multi-sig action
@@ -2083,7 +2233,7 @@ func (e *Exchange) MultiSig(
 	// Python SDK: {"type": "multiSig", "signatureChainId": ..., "signatures": ..., "payload": {"multiSigUser": ..., "outerSigner": ..., "action": ...}}
 	multiSigAction := utils.NewOrderedMap(
 		"type", "multiSig",
-		"signatureChainId", "0x66eee",
+		"signatureChainId", e.Network().SignatureChainID,
 		"signatures", signatures,
 		"payload", utils.NewOrderedMap(
 			"multiSigUser", strings.ToLower(multiSigUser),
@@ -2092,10 +2242,8 @@ func (e *Exchange) MultiSig(
 		),
 	)
 
-	signature, err := signing.SignMultiSigAction(
-		e.wallet,
+	signature, err := e.signMultiSigAction(
 		multiSigAction,
-		e.IsMainnet(),
 		vaultAddress,
 		nonce,
 		e.expiresAfter,
@@ -2104,12 +2252,7 @@ func (e *Exchange) MultiSig(
 		return nil, fmt.Errorf("failed to sign multi-sig action: %w", err)
 	}
 
-	var result types.DefaultResponse
-	if err := e.postAction(multiSigAction, signature, nonce, &result); err != nil {
-		return nil, err
-	}
-
-	return &result, nil
+	return postTypedAction[types.DefaultResponse](e, multiSigAction, signature, nonce)
 }
 
 // GetAddress returns the wallet address
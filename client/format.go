@@ -0,0 +1,81 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// unknownSzDecimals is the precision of sizes of coins whose metadata is not loaded
+const unknownSzDecimals = 8
+
+// Formatter renders sizes, prices and notionals with the precision of their asset, from
+// the metadata cached by an Info, for logs, alerts and CLIs:
+//
+//	f := info.Formatter()
+//	log.Print(f.Order("ETH", 0.0147, 1670.1)) // 0.0147 ETH @ 1,670.1
+type Formatter struct {
+	info *Info
+}
+
+// Formatter returns a formatter using the metadata of i
+func (i *Info) Formatter() *Formatter {
+	return &Formatter{info: i}
+}
+
+// Size formats a size of coin with the szDecimals of the asset, e.g. "0.0147"
+func (f *Formatter) Size(name string, sz float64) string {
+	szDecimals, _, ok := f.precision(name)
+	if !ok {
+		szDecimals = unknownSzDecimals
+	}
+	return utils.FormatSize(sz, szDecimals)
+}
+
+// Price formats a price of coin with the precision the exchange accepts, e.g. "1,670.1"
+func (f *Formatter) Price(name string, px float64) string {
+	// Unknown coins get the finest precision of perps
+	szDecimals, isSpot, _ := f.precision(name)
+	return utils.FormatPrice(px, szDecimals, isSpot)
+}
+
+// Notional formats an amount of USD, e.g. "$24.55"
+func (f *Formatter) Notional(notional float64) string {
+	return utils.FormatUSD(notional)
+}
+
+// Order formats a size at a price, e.g. "0.0147 ETH @ 1,670.1". Sizes of spot pairs are
+// in the base token, e.g. "12 PURR @ 0.1873" for "PURR/USDC" or "3 HFUN @ 25.1" for "@2".
+func (f *Formatter) Order(name string, sz, px float64) string {
+	return fmt.Sprintf("%s %s @ %s", f.Size(name, sz), f.unit(name), f.Price(name, px))
+}
+
+// precision returns the szDecimals of the asset of coin and whether it is spot, false
+// if the metadata of the coin is not loaded
+func (f *Formatter) precision(name string) (int, bool, bool) {
+	coin, err := f.info.resolveCoin(name)
+	if err != nil {
+		return 0, false, false
+	}
+	asset, ok := f.info.coinAsset(coin)
+	if !ok {
+		return 0, false, false
+	}
+	szDecimals, ok := f.info.szDecimals(asset)
+	return szDecimals, constants.IsSpotAsset(asset), ok
+}
+
+// unit returns the name sizes of coin are expressed in, the base token for spot pairs
+func (f *Formatter) unit(name string) string {
+	coin, err := f.info.resolveCoin(name)
+	if err != nil {
+		base, _, _ := strings.Cut(name, "/")
+		return base
+	}
+	if base, ok := f.info.spotBase(coin); ok {
+		return base
+	}
+	return coin
+}
@@ -0,0 +1,35 @@
+package client
+
+import "testing"
+
+func TestFormatter(t *testing.T) {
+	info := &Info{
+		coinToAsset:       map[string]int{"ETH": 1, "PURR/USDC": 10000, "@2": 10002},
+		nameToCoin:        map[string]string{"ETH": "ETH", "PURR/USDC": "PURR/USDC", "@0": "PURR/USDC", "@2": "@2", "HFUN/USDC": "@2"},
+		assetToSzDecimals: map[int]int{1: 4, 10000: 0, 10002: 2},
+		coinToBase:        map[string]string{"PURR/USDC": "PURR", "@2": "HFUN"},
+		loadedDexs:        map[string]bool{},
+	}
+	f := info.Formatter()
+
+	tests := []struct {
+		name string
+		sz   float64
+		px   float64
+		want string
+	}{
+		{"ETH", 0.014719, 1670.1234, "0.0147 ETH @ 1,670.1"},
+		{"@0", 1200, 0.187345, "1,200 PURR @ 0.18735"},
+		{"@2", 3.14159, 25.1234, "3.14 HFUN @ 25.123"},
+		{"HFUN/USDC", 3, 25.1, "3 HFUN @ 25.1"},
+		{"DOGE", 0.123456789, 0.123456789, "0.12345679 DOGE @ 0.12346"},
+	}
+	for _, tt := range tests {
+		if got := f.Order(tt.name, tt.sz, tt.px); got != tt.want {
+			t.Errorf("Order(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := f.Notional(24.551); got != "$24.55" {
+		t.Errorf("Notional() = %q", got)
+	}
+}
@@ -3,6 +3,9 @@ package client
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dwdwow/hl-go/constants"
@@ -12,27 +15,37 @@ import (
 // Info provides read-only access to Hyperliquid market data and user information
 type Info struct {
 	*API
+
+	mu                sync.Mutex // guards the maps, filled lazily for builder dexs
 	coinToAsset       map[string]int
+	assetToCoin       map[int]string
 	nameToCoin        map[string]string
 	assetToSzDecimals map[int]int
+	// coinToBase is the base token of the spot coins, e.g. "HFUN" for "@2"
+	coinToBase map[string]string
+	loadedDexs map[string]bool
 }
 
 // NewInfo creates a new Info client
 // If skipWS is false, WebSocket connections will be initialized (not yet implemented)
 func NewInfoUsingHTTP(baseURL string, timeout time.Duration) (*Info, error) {
-	info := &Info{
-		API:               NewAPIUsingHTTP(baseURL, timeout),
-		coinToAsset:       make(map[string]int),
-		nameToCoin:        make(map[string]string),
-		assetToSzDecimals: make(map[int]int),
-	}
+	return newInfo(NewAPIUsingHTTP(baseURL, timeout))
+}
 
-	// Initialize metadata
-	if err := info.initializeMetadata(); err != nil {
-		return nil, fmt.Errorf("failed to initialize metadata: %w", err)
-	}
+// NewInfoUsingHTTPClient creates an Info client sending its requests with httpClient,
+// e.g. one whose transport records or replays them
+func NewInfoUsingHTTPClient(baseURL string, httpClient *http.Client) (*Info, error) {
+	api := NewAPIUsingHTTP(baseURL, httpClient.Timeout)
+	api.HTTPClient = httpClient
+	return newInfo(api)
+}
 
-	return info, nil
+// NewInfoForNetwork creates an Info client using the HTTP API of network, which also
+// sets the signing parameters of an Exchange sharing its API
+func NewInfoForNetwork(network constants.Network, timeout time.Duration) (*Info, error) {
+	api := NewAPIUsingHTTP(network.APIURL, timeout)
+	api.SetNetwork(network)
+	return newInfo(api)
 }
 
 func NewInfoUsingWs(baseURL string, timeout time.Duration) (*Info, error) {
@@ -40,12 +53,22 @@ func NewInfoUsingWs(baseURL string, timeout time.Duration) (*Info, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create API: %w", err)
 	}
+	return newInfo(w)
+}
+
+// newInfo creates an Info client using api and loads the metadata of the default dex
+// and spot
+func newInfo(api *API) (*Info, error) {
 	info := &Info{
-		API:               w,
+		API:               api,
 		coinToAsset:       make(map[string]int),
+		assetToCoin:       make(map[int]string),
 		nameToCoin:        make(map[string]string),
 		assetToSzDecimals: make(map[int]int),
+		coinToBase:        make(map[string]string),
+		loadedDexs:        make(map[string]bool),
 	}
+	api.assetCoin = info.assetCoin
 
 	// Initialize metadata
 	if err := info.initializeMetadata(); err != nil {
@@ -63,20 +86,23 @@ func (i *Info) initializeMetadata() error {
 		return fmt.Errorf("failed to get spot meta: %w", err)
 	}
 
-	// Process spot assets (start at 10000)
+	// Process spot assets (start at SpotAssetOffset)
 	for _, spotInfo := range spotMeta.Universe {
-		asset := spotInfo.Index + constants.SpotAssetOffset
+		asset := constants.SpotAsset(spotInfo.Index)
 		i.coinToAsset[spotInfo.Name] = asset
+		i.assetToCoin[asset] = spotInfo.Name
 		i.nameToCoin[spotInfo.Name] = spotInfo.Name
 
 		baseToken := spotMeta.Tokens[spotInfo.Tokens[0]]
 		quoteToken := spotMeta.Tokens[spotInfo.Tokens[1]]
 		i.assetToSzDecimals[asset] = baseToken.SzDecimals
+		i.coinToBase[spotInfo.Name] = baseToken.Name
 
-		// Also map base/quote format
-		name := fmt.Sprintf("%s/%s", baseToken.Name, quoteToken.Name)
-		if _, exists := i.nameToCoin[name]; !exists {
-			i.nameToCoin[name] = spotInfo.Name
+		// Also map base/quote and @index formats
+		for _, name := range []string{fmt.Sprintf("%s/%s", baseToken.Name, quoteToken.Name), types.SpotCoinRef(spotInfo.Index).String()} {
+			if _, exists := i.nameToCoin[name]; !exists {
+				i.nameToCoin[name] = spotInfo.Name
+			}
 		}
 	}
 
@@ -89,6 +115,7 @@ func (i *Info) initializeMetadata() error {
 	// Process perp assets
 	for asset, assetInfo := range perpMeta.Universe {
 		i.coinToAsset[assetInfo.Name] = asset
+		i.assetToCoin[asset] = assetInfo.Name
 		i.nameToCoin[assetInfo.Name] = assetInfo.Name
 		i.assetToSzDecimals[asset] = assetInfo.SzDecimals
 	}
@@ -96,22 +123,131 @@ func (i *Info) initializeMetadata() error {
 	return nil
 }
 
-func (i *Info) NameToCoin(name string) (string, error) {
+// loadDex fetches and caches the metadata of the builder-deployed perp dex, whose
+// asset ids are given by constants.PerpAsset. The metadata is fetched without mu held,
+// and cached once if the dex is loaded concurrently.
+func (i *Info) loadDex(dex string) error {
+	i.mu.Lock()
+	loaded := i.loadedDexs[dex]
+	i.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	dexs, err := i.PerpDexs()
+	if err != nil {
+		return fmt.Errorf("failed to get perp dexs: %w", err)
+	}
+	index := -1
+	for j, d := range dexs {
+		// The default dex is listed first, as null
+		if j > 0 && d.Name == dex {
+			index = j
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("unknown perp dex: %s", dex)
+	}
+
+	meta, err := i.Meta(dex)
+	if err != nil {
+		return fmt.Errorf("failed to get perp meta of dex %s: %w", dex, err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.loadedDexs[dex] {
+		return nil
+	}
+	for j, assetInfo := range meta.Universe {
+		asset := constants.PerpAsset(index, j)
+		coin := assetInfo.Name
+		if !strings.Contains(coin, ":") {
+			coin = types.CoinRef{Dex: dex, Coin: coin}.String()
+		}
+		i.coinToAsset[coin] = asset
+		i.assetToCoin[asset] = coin
+		i.nameToCoin[coin] = coin
+		i.assetToSzDecimals[asset] = assetInfo.SzDecimals
+	}
+	i.loadedDexs[dex] = true
+	return nil
+}
+
+// resolveCoin returns the coin of name, loading the metadata of its dex for
+// dex-qualified names such as "xyz:XYZ100"
+func (i *Info) resolveCoin(name string) (string, error) {
+	i.mu.Lock()
 	coin, ok := i.nameToCoin[name]
+	i.mu.Unlock()
+	if ok {
+		return coin, nil
+	}
+
+	ref, err := types.ParseCoinRef(name)
+	if err != nil || ref.Dex == "" {
+		return "", fmt.Errorf("unknown coin name: %s", name)
+	}
+	if err := i.loadDex(ref.Dex); err != nil {
+		return "", fmt.Errorf("failed to load coin %s: %w", name, err)
+	}
+
+	i.mu.Lock()
+	coin, ok = i.nameToCoin[name]
+	i.mu.Unlock()
 	if !ok {
 		return "", fmt.Errorf("unknown coin name: %s", name)
 	}
 	return coin, nil
 }
 
-// NameToAsset converts a coin name to its asset ID
+// coinAsset returns the asset ID of a resolved coin
+func (i *Info) coinAsset(coin string) (int, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	asset, ok := i.coinToAsset[coin]
+	return asset, ok
+}
+
+// assetCoin returns the coin of an asset ID
+func (i *Info) assetCoin(asset int) (string, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	coin, ok := i.assetToCoin[asset]
+	return coin, ok
+}
+
+// spotBase returns the base token of a resolved spot coin
+func (i *Info) spotBase(coin string) (string, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	base, ok := i.coinToBase[coin]
+	return base, ok
+}
+
+// szDecimals returns the size decimals of an asset
+func (i *Info) szDecimals(asset int) (int, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	szDecimals, ok := i.assetToSzDecimals[asset]
+	return szDecimals, ok
+}
+
+// NameToCoin converts a coin name, e.g. "PURR/USDC", "@107" or "xyz:XYZ100", to the
+// coin used on the wire
+func (i *Info) NameToCoin(name string) (string, error) {
+	return i.resolveCoin(name)
+}
+
+// NameToAsset converts a coin name to its asset ID, see NameToCoin
 func (i *Info) NameToAsset(name string) (int, error) {
-	coin, ok := i.nameToCoin[name]
-	if !ok {
-		return 0, fmt.Errorf("unknown coin name: %s", name)
+	coin, err := i.resolveCoin(name)
+	if err != nil {
+		return 0, err
 	}
 
-	asset, ok := i.coinToAsset[coin]
+	asset, ok := i.coinAsset(coin)
 	if !ok {
 		return 0, fmt.Errorf("unknown coin: %s", coin)
 	}
@@ -307,9 +443,9 @@ func (i *Info) SpotMetaAndAssetCtxs() (*types.SpotMetaAndAssetCtxs, error) {
 
 // FundingHistory retrieves funding history for a given coin
 func (i *Info) FundingHistory(name string, startTime int64, endTime *int64) ([]types.FundingRecord, error) {
-	coin, ok := i.nameToCoin[name]
-	if !ok {
-		return nil, fmt.Errorf("unknown coin: %s", name)
+	coin, err := i.resolveCoin(name)
+	if err != nil {
+		return nil, err
 	}
 
 	payload := map[string]any{
@@ -330,7 +466,8 @@ func (i *Info) FundingHistory(name string, startTime int64, endTime *int64) ([]t
 	return result, nil
 }
 
-// UserFundingHistory retrieves a user's funding history
+// UserFundingHistory retrieves a user's funding history. The records only hold the
+// time of each payment; UserFundingPayments decodes the payments.
 func (i *Info) UserFundingHistory(user string, startTime int64, endTime *int64) ([]types.FundingRecord, error) {
 	payload := map[string]any{
 		"type":      "userFunding",
@@ -350,11 +487,32 @@ func (i *Info) UserFundingHistory(user string, startTime int64, endTime *int64)
 	return result, nil
 }
 
+// UserFundingPayments retrieves the funding payments of a user, at most 500 from
+// startTime
+func (i *Info) UserFundingPayments(user string, startTime int64, endTime *int64) ([]types.UserFundingUpdate, error) {
+	payload := map[string]any{
+		"type":      "userFunding",
+		"user":      user,
+		"startTime": startTime,
+	}
+
+	if endTime != nil {
+		payload["endTime"] = *endTime
+	}
+
+	var result []types.UserFundingUpdate
+	if err := i.infoPost("/info", payload, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // L2Snapshot retrieves L2 order book snapshot for a given coin
 func (i *Info) L2Snapshot(name string) (*types.L2BookData, error) {
-	coin, ok := i.nameToCoin[name]
-	if !ok {
-		return nil, fmt.Errorf("unknown coin: %s", name)
+	coin, err := i.resolveCoin(name)
+	if err != nil {
+		return nil, err
 	}
 
 	payload := map[string]any{
@@ -370,11 +528,26 @@ func (i *Info) L2Snapshot(name string) (*types.L2BookData, error) {
 	return &result, nil
 }
 
+// EstimateFill estimates the fill of a market order of sz from the current L2 book of
+// a coin
+func (i *Info) EstimateFill(name string, isBuy bool, sz float64) (*types.BookFill, error) {
+	book, err := i.L2Snapshot(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fill, err := book.EstimateFill(isBuy, sz)
+	if err != nil {
+		return nil, err
+	}
+	return &fill, nil
+}
+
 // CandlesSnapshot retrieves candles snapshot for a given coin
 func (i *Info) CandlesSnapshot(name string, interval string, startTime int64, endTime int64) ([]types.Candle, error) {
-	coin, ok := i.nameToCoin[name]
-	if !ok {
-		return nil, fmt.Errorf("unknown coin: %s", name)
+	coin, err := i.resolveCoin(name)
+	if err != nil {
+		return nil, err
 	}
 
 	req := map[string]any{
@@ -472,6 +645,21 @@ func (i *Info) DelegatorHistory(user string) ([]types.DelegatorHistoryEntry, err
 	return result, nil
 }
 
+// ValidatorSummaries retrieves every validator with its stake, jail status, commission
+// and uptime
+func (i *Info) ValidatorSummaries() ([]types.ValidatorSummary, error) {
+	payload := map[string]any{
+		"type": "validatorSummaries",
+	}
+
+	var result []types.ValidatorSummary
+	if err := i.infoPost("/info", payload, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // QueryOrderByOid queries order status by order ID
 func (i *Info) QueryOrderByOid(user string, oid int) (*types.OrderQueryResponse, error) {
 	payload := map[string]any{
@@ -549,8 +737,9 @@ func (i *Info) HistoricalOrders(user string) ([]types.OrderQueryInner, error) {
 	return result, nil
 }
 
-// UserNonFundingLedgerUpdates retrieves non-funding ledger updates for a user
-func (i *Info) UserNonFundingLedgerUpdates(user string, startTime int64, endTime *int64) (types.RawJSON, error) {
+// UserNonFundingLedgerUpdates retrieves non-funding ledger updates for a user. See
+// LedgerDelta.Variant for the typed form of each delta.
+func (i *Info) UserNonFundingLedgerUpdates(user string, startTime int64, endTime *int64) ([]types.NonFundingLedgerUpdate, error) {
 	payload := map[string]any{
 		"type":      "userNonFundingLedgerUpdates",
 		"user":      user,
@@ -561,7 +750,7 @@ func (i *Info) UserNonFundingLedgerUpdates(user string, startTime int64, endTime
 		payload["endTime"] = *endTime
 	}
 
-	var result types.RawJSON
+	var result []types.NonFundingLedgerUpdate
 	if err := i.infoPost("/info", payload, &result); err != nil {
 		return nil, err
 	}
@@ -657,6 +846,26 @@ func (i *Info) UserTwapSliceFills(user string) ([]types.TwapSliceFill, error) {
 	return result, nil
 }
 
+// VaultDetails retrieves the details of a vault: its followers, portfolio and the
+// amounts its leader may distribute and withdraw. With user, FollowerState is that of
+// the user.
+func (i *Info) VaultDetails(vaultAddress string, user *string) (*types.VaultDetails, error) {
+	payload := map[string]any{
+		"type":         "vaultDetails",
+		"vaultAddress": vaultAddress,
+	}
+	if user != nil {
+		payload["user"] = *user
+	}
+
+	var result types.VaultDetails
+	if err := i.infoPost("/info", payload, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 // UserVaultEquities retrieves user's equity positions across all vaults
 func (i *Info) UserVaultEquities(user string) ([]types.VaultEquity, error) {
 	payload := map[string]any{
@@ -819,3 +1028,22 @@ func (i *Info) ActiveAssetData(user string, coin string) (*types.ActiveAssetData
 
 	return &result, nil
 }
+
+// CheckMargin checks the margin of an order of sz coin at px on the default perp dex,
+// sz being negative for sells, against the state of the user, see types.CheckMargin
+func (i *Info) CheckMargin(user string, coin string, sz float64, px float64) (*types.MarginCheck, error) {
+	meta, err := i.Meta("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meta: %w", err)
+	}
+	state, err := i.UserState(user, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user state: %w", err)
+	}
+
+	check, err := types.CheckMargin(meta, state, coin, sz, px)
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
@@ -264,6 +264,20 @@ func TestInfo_UserFundingHistory(t *testing.T) {
 	t.Logf("User funding history count: %d", len(history))
 }
 
+func TestInfo_UserFundingPayments(t *testing.T) {
+	info := getTestInfoUsingHTTP(t)
+
+	endTime := time.Now().UnixMilli()
+	startTime := endTime - 24*3600000
+
+	payments, err := info.UserFundingPayments(testAddress, startTime, &endTime)
+	if err != nil {
+		t.Fatalf("UserFundingPayments() error = %v", err)
+	}
+
+	t.Logf("User funding payments count: %d", len(payments))
+}
+
 func TestInfo_UserFees(t *testing.T) {
 	info := getTestInfoUsingHTTP(t)
 
@@ -464,6 +478,29 @@ func TestInfo_UserVaultEquities(t *testing.T) {
 	t.Logf("Vault equities: %v", equities)
 }
 
+func TestInfo_VaultDetails(t *testing.T) {
+	info := getTestInfoUsingHTTP(t)
+
+	// HLP
+	details, err := info.VaultDetails("0xdfc24b077bc1425ad1dea75bcb6f8158e10df303", nil)
+	if err != nil {
+		t.Fatalf("VaultDetails() error = %v", err)
+	}
+
+	t.Logf("Vault %s: %d followers", details.Name, len(details.Followers))
+}
+
+func TestInfo_ValidatorSummaries(t *testing.T) {
+	info := getTestInfoUsingHTTP(t)
+
+	summaries, err := info.ValidatorSummaries()
+	if err != nil {
+		t.Fatalf("ValidatorSummaries() error = %v", err)
+	}
+
+	t.Logf("Validators: %d", len(summaries))
+}
+
 func TestInfo_UserRole(t *testing.T) {
 	info := getTestInfoUsingHTTP(t)
 
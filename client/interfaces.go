@@ -0,0 +1,175 @@
+package client
+
+import (
+	"time"
+
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/wallet"
+)
+
+// Trader is the order management of Exchange, so strategies can run against a
+// simulated exchange such as paper.Exchange and then against the real one unchanged
+type Trader interface {
+	Order(name string, isBuy bool, sz float64, limitPx float64, orderType types.OrderType, reduceOnly bool, cloid *types.Cloid, builder *types.BuilderInfo) (*types.OrderResponse, error)
+	BulkOrders(orders []types.OrderRequest, builder *types.BuilderInfo) (*types.OrderResponse, error)
+	MarketOpen(name string, isBuy bool, sz float64, px *float64, slippage float64, cloid *types.Cloid, builder *types.BuilderInfo) (*types.OrderResponse, error)
+	MarketClose(name string, sz *float64, px *float64, slippage float64, cloid *types.Cloid, builder *types.BuilderInfo) (*types.OrderResponse, error)
+	ModifyOrder(oid any, name string, isBuy bool, sz float64, limitPx float64, orderType types.OrderType, reduceOnly bool, cloid *types.Cloid) (*types.ModifyResponse, error)
+	BulkModifyOrders(modifies []types.ModifyRequest) (*types.ModifyResponse, error)
+	Cancel(name string, oid int) (*types.CancelResponse, error)
+	CancelByCloid(name string, cloid types.Cloid) (*types.CancelResponse, error)
+	BulkCancel(cancels []types.CancelRequest) (*types.CancelResponse, error)
+	BulkCancelByCloid(cancels []types.CancelByCloidRequest) (*types.CancelResponse, error)
+}
+
+// Exchanger is the method set of Exchange, for mocks and for decorators such as retries
+// or metrics wrapping an Exchange. The configuration of the client, its clock and its
+// keys are left to the concrete type.
+type Exchanger interface {
+	Trader
+
+	// Account
+	GetAddress() string
+	GetAccountAddress() string
+	GetWalletAddress() string
+	NameToAsset(name string) (int, error)
+
+	// Positions and orders
+	UpdateLeverage(leverage int, name string, isCross bool) (*types.DefaultResponse, error)
+	UpdateIsolatedMargin(amount float64, name string) (*types.DefaultResponse, error)
+	ScheduleCancel(time *int64) (*types.DefaultResponse, error)
+	TWAPOrder(name string, isBuy bool, sz float64, reduceOnly bool, minutes int, randomize bool) (*types.TWAPOrderResponse, error)
+	TWAPCancel(name string, twapID int) (*types.TWAPCancelResponse, error)
+
+	// Transfers
+	USDTransfer(amount float64, destination string) (*types.DefaultResponse, error)
+	USDClassTransfer(amount float64, toPerp bool) (*types.DefaultResponse, error)
+	SpotTransfer(amount float64, destination string, token string) (*types.DefaultResponse, error)
+	WithdrawFromBridge(amount float64, destination string) (*types.DefaultResponse, error)
+	SendAsset(destination string, sourceDex string, destinationDex string, token string, amount float64) (*types.DefaultResponse, error)
+	SubAccountTransfer(subAccountUser string, isDeposit bool, usd int) (*types.DefaultResponse, error)
+	SubAccountSpotTransfer(subAccountUser string, isDeposit bool, token string, amount float64) (*types.DefaultResponse, error)
+	VaultTransfer(vaultAddress string, isDeposit bool, usd int) (*types.DefaultResponse, error)
+	VaultDistribute(vaultAddress string, usd int) (*types.DefaultResponse, error)
+	TokenDelegate(validator string, wei int64, isUndelegate bool) (*types.DefaultResponse, error)
+
+	// Account management
+	CreateSubAccount(name string) (*types.DefaultResponse, error)
+	SetReferrer(code string) (*types.DefaultResponse, error)
+	ApproveAgent(agentAddress string, agentName *string) (*types.DefaultResponse, error)
+	ApproveNewAgent(store *wallet.AgentStore, name string, validUntil *time.Time, passphrase string) (*types.DefaultResponse, *wallet.Agent, error)
+	ApproveBuilderFee(builder string, maxFeeRate string) (*types.DefaultResponse, error)
+	UserDexAbstraction(user string, enabled bool) (*types.DefaultResponse, error)
+	AgentEnableDexAbstraction() (*types.DefaultResponse, error)
+	UseBigBlocks(enable bool) (*types.DefaultResponse, error)
+	VaultModify(vaultAddress string, allowDeposits *bool, alwaysCloseOnWithdraw *bool) (*types.DefaultResponse, error)
+	Noop(nonce int64) (*types.DefaultResponse, error)
+
+	// Multi-sig and custom actions
+	ConvertToMultiSigUser(authorizedUsers []string, threshold int) (*types.DefaultResponse, error)
+	MultiSig(multiSigUser string, innerAction map[string]any, signatures []map[string]any, nonce int64, vaultAddress *string) (*types.DefaultResponse, error)
+	UserSignedAction(primaryType string, fields map[string]any) (*types.DefaultResponse, error)
+
+	// Spot deployment
+	SpotDeployRegisterToken(tokenName string, szDecimals int, weiDecimals int, maxGas int, fullName string) (*types.DefaultResponse, error)
+	SpotDeployUserGenesis(token int, userAndWei []struct {
+		User string
+		Wei  string
+	}, existingTokenAndWei []struct {
+		Token int
+		Wei   string
+	}) (*types.DefaultResponse, error)
+	SpotDeployEnableFreezePrivilege(token int) (*types.DefaultResponse, error)
+	SpotDeployFreezeUser(token int, user string, freeze bool) (*types.DefaultResponse, error)
+	SpotDeployRevokeFreezePrivilege(token int) (*types.DefaultResponse, error)
+	SpotDeployEnableQuoteToken(token int) (*types.DefaultResponse, error)
+	SpotDeployGenesis(token int, maxSupply string, noHyperliquidity bool) (*types.DefaultResponse, error)
+	SpotDeployRegisterSpot(baseToken int, quoteToken int) (*types.DefaultResponse, error)
+	SpotDeployRegisterHyperliquidity(spot int, startPx float64, orderSz float64, nOrders int, nSeededLevels *int) (*types.DefaultResponse, error)
+	SpotDeploySetDeployerTradingFeeShare(token int, share string) (*types.DefaultResponse, error)
+
+	// Perp deployment
+	PerpDeployRegisterAsset(dex string, maxGas *int, coin string, szDecimals int, oraclePx string, marginTableID int, onlyIsolated bool, schema *struct {
+		FullName        string
+		CollateralToken string
+		OracleUpdater   *string
+	}) (*types.DefaultResponse, error)
+	PerpDeploySetOracle(dex string, oraclePxs map[string]string, allMarkPxs []map[string]string, externalPerpPxs map[string]string) (*types.DefaultResponse, error)
+
+	// Validators
+	CSignerUnjailSelf() (*types.DefaultResponse, error)
+	CSignerJailSelf() (*types.DefaultResponse, error)
+	CValidatorRegister(nodeIP string, name string, description string, delegationsDisabled bool, commissionBps int, signer string, unjailed bool, initialWei int64) (*types.DefaultResponse, error)
+	CValidatorChangeProfile(nodeIP *string, name *string, description *string, unjailed bool, disableDelegations *bool, commissionBps *int, signer *string) (*types.DefaultResponse, error)
+	CValidatorUnregister() (*types.DefaultResponse, error)
+}
+
+// Infoer is the method set of Info, for mocks and for decorators such as caches or
+// metrics wrapping an Info
+type Infoer interface {
+	// Names
+	NameToAsset(name string) (int, error)
+	NameToCoin(name string) (string, error)
+
+	// Market data
+	AllMids(dex string) (map[string]string, error)
+	L2Snapshot(name string) (*types.L2BookData, error)
+	EstimateFill(name string, isBuy bool, sz float64) (*types.BookFill, error)
+	CandlesSnapshot(name string, interval string, startTime int64, endTime int64) ([]types.Candle, error)
+	FundingHistory(name string, startTime int64, endTime *int64) ([]types.FundingRecord, error)
+	PredictedFundings() (types.PredictedFundings, error)
+	PerpsAtOpenInterestCap() ([]string, error)
+	Meta(dex string) (*types.Meta, error)
+	MetaAndAssetCtxs() (*types.MetaAndAssetCtxs, error)
+	SpotMeta() (*types.SpotMeta, error)
+	SpotMetaAndAssetCtxs() (*types.SpotMetaAndAssetCtxs, error)
+	TokenDetails(tokenId string) (*types.TokenDetails, error)
+	PerpDexs() ([]types.PerpDex, error)
+	PerpDexLimits(dex string) (*types.PerpDexLimits, error)
+	PerpDexStatus(dex string) (*types.PerpDexStatus, error)
+
+	// User account data
+	UserState(address string, dex string) (*types.UserState, error)
+	SpotUserState(address string) (*types.SpotUserState, error)
+	ActiveAssetData(user string, coin string) (*types.ActiveAssetData, error)
+	CheckMargin(user string, coin string, sz float64, px float64) (*types.MarginCheck, error)
+	OpenOrders(address string, dex string) ([]types.OpenOrder, error)
+	FrontendOpenOrders(address string, dex string) ([]types.FrontendOpenOrder, error)
+	HistoricalOrders(user string) ([]types.OrderQueryInner, error)
+	QueryOrderByOid(user string, oid int) (*types.OrderQueryResponse, error)
+	QueryOrderByCloid(user string, cloid *types.Cloid) (*types.OrderQueryResponse, error)
+	UserFills(address string) ([]types.Fill, error)
+	UserFillsByTime(address string, startTime int64, endTime *int64, aggregateByTime bool) ([]types.Fill, error)
+	UserTwapSliceFills(user string) ([]types.TwapSliceFill, error)
+	UserFundingHistory(user string, startTime int64, endTime *int64) ([]types.FundingRecord, error)
+	UserFundingPayments(user string, startTime int64, endTime *int64) ([]types.UserFundingUpdate, error)
+	UserNonFundingLedgerUpdates(user string, startTime int64, endTime *int64) ([]types.NonFundingLedgerUpdate, error)
+	UserFees(address string) (*types.UserFees, error)
+	UserRateLimit(user string) (*types.UserRateLimitResponse, error)
+	UserRole(user string) (*types.UserRole, error)
+	UserVaultEquities(user string) ([]types.VaultEquity, error)
+	VaultDetails(vaultAddress string, user *string) (*types.VaultDetails, error)
+	Portfolio(user string) (types.RawJSON, error)
+	ExtraAgents(user string) (types.RawJSON, error)
+	QueryReferralState(user string) (*types.ReferralResponse, error)
+	QuerySubAccounts(user string) ([]types.SubAccount, error)
+	QueryUserDexAbstractionState(user string) (bool, error)
+	QueryUserToMultiSigSigners(multiSigUser string) (types.RawJSON, error)
+
+	// Staking
+	UserStakingSummary(address string) (*types.DelegatorSummary, error)
+	UserStakingDelegations(address string) ([]types.Delegation, error)
+	UserStakingRewards(address string) ([]types.DelegatorReward, error)
+	DelegatorHistory(user string) ([]types.DelegatorHistoryEntry, error)
+	ValidatorSummaries() ([]types.ValidatorSummary, error)
+
+	// Deploy auctions
+	QuerySpotDeployAuctionStatus(user string) (*types.SpotDeployState, error)
+	QuerySpotPairDeployAuctionStatus() (*types.AuctionStatus, error)
+	QueryPerpDeployAuctionStatus() (*types.PerpDeployAuctionStatus, error)
+}
+
+var (
+	_ Exchanger = (*Exchange)(nil)
+	_ Infoer    = (*Info)(nil)
+)
@@ -0,0 +1,85 @@
+package main
+
+import "github.com/spf13/cobra"
+
+func init() {
+	register(newTransferCommand, newWithdrawCommand, newApproveAgentCommand)
+}
+
+func newTransferCommand(a *app) *cobra.Command {
+	var amount float64
+	var to string
+	cmd := &cobra.Command{
+		Use:   "transfer --amount 10 --to 0x...",
+		Short: "send USDC to another account",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			result, err := exchange.USDTransfer(amount, to)
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	cmd.Flags().Float64Var(&amount, "amount", 0, "amount of USDC")
+	cmd.Flags().StringVar(&to, "to", "", "destination address")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func newWithdrawCommand(a *app) *cobra.Command {
+	var amount float64
+	var to string
+	cmd := &cobra.Command{
+		Use:   "withdraw --amount 10 --to 0x...",
+		Short: "withdraw USDC to Arbitrum",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			result, err := exchange.WithdrawFromBridge(amount, to)
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	cmd.Flags().Float64Var(&amount, "amount", 0, "amount of USDC")
+	cmd.Flags().StringVar(&to, "to", "", "destination address")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func newApproveAgentCommand(a *app) *cobra.Command {
+	var agent, name string
+	cmd := &cobra.Command{
+		Use:   "approve-agent --agent 0x... [--name bot]",
+		Short: "approve an agent (API) wallet",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			var agentName *string
+			if name != "" {
+				agentName = &name
+			}
+			result, err := exchange.ApproveAgent(agent, agentName)
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	cmd.Flags().StringVar(&agent, "agent", "", "address of the agent")
+	cmd.Flags().StringVar(&name, "name", "", "name of the agent, unnamed if empty")
+	cmd.MarkFlagRequired("agent")
+	return cmd
+}
@@ -0,0 +1,220 @@
+// Command hl is a command line client of Hyperliquid built on the SDK, for operations
+// and for debugging the behavior of the SDK:
+//
+//	hl [global flags] <command> [flags]
+//
+// Settings come from --config, the HYPERLIQUID_* environment variables of
+// client.Config and the global flags, in increasing priority. Signing keys are read
+// from a keystore directory (--keystore, passphrase in HYPERLIQUID_KEYSTORE_PASSPHRASE
+// or prompted) or from HYPERLIQUID_PRIVATE_KEY. Results are printed as JSON.
+// "hl help <command>" describes a command and "hl completion" generates shell
+// completion scripts.
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/wallet"
+)
+
+// Environment variables of the signing key
+const (
+	envPrivateKey         = "HYPERLIQUID_PRIVATE_KEY"
+	envKeystorePassphrase = "HYPERLIQUID_KEYSTORE_PASSPHRASE"
+)
+
+// errUsage marks errors caused by the command line, printed with the usage
+var errUsage = errors.New("usage")
+
+// commands are the constructors of the subcommands of hl, registered by init
+var commands []func(a *app) *cobra.Command
+
+func register(cmds ...func(a *app) *cobra.Command) {
+	commands = append(commands, cmds...)
+}
+
+// app holds the settings shared by the commands and their lazily created clients
+type app struct {
+	cfg      client.Config
+	keystore string
+	address  string
+	out      io.Writer
+
+	info     *client.Info
+	exchange *client.Exchange
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if err == nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "hl:", strings.TrimPrefix(err.Error(), errUsage.Error()+": "))
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	os.Exit(1)
+}
+
+// newRootCommand creates the hl command with its global flags and subcommands.
+// started is set once the command line is accepted and a command starts running.
+func newRootCommand(a *app, started *bool) *cobra.Command {
+	var configPath, network, baseURL string
+	root := &cobra.Command{
+		Use:   "hl",
+		Short: "Hyperliquid command line client",
+		Long: "hl is a command line client of Hyperliquid built on the SDK.\n\n" +
+			"Settings come from --config, the HYPERLIQUID_* environment variables and the global flags, in increasing priority. " +
+			"Signing keys are read from --keystore (passphrase in " + envKeystorePassphrase + " or prompted) or from " + envPrivateKey + ".",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Cobra checks the required and exclusive flags after this hook
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return fmt.Errorf("%w: %w", errUsage, err)
+			}
+			if err := cmd.ValidateFlagGroups(); err != nil {
+				return fmt.Errorf("%w: %w", errUsage, err)
+			}
+			*started = true
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if network != "" {
+				cfg.Network = network
+			}
+			if baseURL != "" {
+				cfg.BaseURL = baseURL
+			}
+			a.cfg = *cfg
+			return nil
+		},
+	}
+	flags := root.PersistentFlags()
+	flags.StringVar(&configPath, "config", "", "JSON config file, see client.Config")
+	flags.StringVar(&network, "network", "", "network: mainnet, testnet or local")
+	flags.StringVar(&baseURL, "base-url", "", "API URL, overriding the network")
+	flags.StringVar(&a.keystore, "keystore", "", "keystore directory of the signing key")
+	flags.StringVar(&a.address, "address", "", "address of the key in the keystore, or of the account to query")
+	for _, newCommand := range commands {
+		root.AddCommand(newCommand(a))
+	}
+	return root
+}
+
+// run executes the command of args. Errors of the command line, including those
+// returned by commands as errUsage, are printed with the usage of the command.
+func run(args []string, stdout, stderr io.Writer) error {
+	var started bool
+	root := newRootCommand(&app{out: stdout}, &started)
+	root.SetArgs(args)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return nil
+	}
+	if !started && !errors.Is(err, errUsage) {
+		// Cobra rejected the command line, e.g. an unknown command or a missing required flag
+		err = fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(stderr, cmd.UsageString())
+	}
+	return err
+}
+
+// loadConfig reads the config file at path, or the environment alone without it
+func loadConfig(path string) (*client.Config, error) {
+	if path == "" {
+		return client.ConfigFromEnv()
+	}
+	return client.LoadConfig(path)
+}
+
+// Info returns the Info client of the config
+func (a *app) Info() (*client.Info, error) {
+	if a.info == nil {
+		info, err := a.cfg.NewInfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create info client: %w", err)
+		}
+		a.info = info
+	}
+	return a.info, nil
+}
+
+// Exchange returns the Exchange client of the config, signing with the key
+func (a *app) Exchange() (*client.Exchange, error) {
+	if a.exchange == nil {
+		key, err := a.key()
+		if err != nil {
+			return nil, err
+		}
+		exchange, err := a.cfg.NewExchange(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create exchange client: %w", err)
+		}
+		a.exchange = exchange
+	}
+	return a.exchange, nil
+}
+
+// key loads the signing key from the keystore if set, else from the environment
+func (a *app) key() (*ecdsa.PrivateKey, error) {
+	if a.keystore != "" {
+		passphrase := wallet.TerminalPassphrase()
+		if _, ok := os.LookupEnv(envKeystorePassphrase); ok {
+			passphrase = wallet.EnvPassphrase(envKeystorePassphrase)
+		}
+		key, err := wallet.LoadFromKeystore(a.keystore, a.address, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key from keystore: %w", err)
+		}
+		return key, nil
+	}
+
+	hexKey, ok := os.LookupEnv(envPrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("no signing key: set --keystore or %s", envPrivateKey)
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", envPrivateKey, err)
+	}
+	return key, nil
+}
+
+// user returns the account to query: user if set, else --address, the account or vault
+// of the config, or the address of the signing key
+func (a *app) user(user string) (string, error) {
+	for _, address := range []string{user, a.address, a.cfg.AccountAddress, a.cfg.VaultAddress} {
+		if address != "" {
+			return address, nil
+		}
+	}
+	key, err := a.key()
+	if err != nil {
+		return "", fmt.Errorf("no account: set --user or --address, or a signing key: %w", err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), nil
+}
+
+// print writes v as indented JSON
+func (a *app) print(v any) error {
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		switch payload["type"] {
+		case "spotMeta":
+			w.Write([]byte(`{"tokens":[],"universe":[]}`))
+		case "meta":
+			w.Write([]byte(`{"universe":[{"name":"ETH","szDecimals":4,"maxLeverage":25}]}`))
+		case "allMids":
+			w.Write([]byte(`{"ETH":"2000.5","BTC":"97000"}`))
+		default:
+			t.Errorf("unexpected request %v", payload)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunMids(t *testing.T) {
+	server := newTestServer(t)
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"--base-url", server.URL, "mids", "--coin", "ETH"}, &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v, stderr %s", err, stderr.String())
+	}
+	var mids map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &mids); err != nil || len(mids) != 1 || mids["ETH"] != "2000.5" {
+		t.Errorf("output = %s, %v", stdout.String(), err)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown command", []string{"nope"}, `unknown command "nope"`},
+		{"unknown flag", []string{"mids", "--nope"}, "unknown flag: --nope"},
+		{"missing flag", []string{"order", "--side", "buy"}, `required flag(s) "coin" not set`},
+		{"bad side", []string{"market-open", "--coin", "ETH", "--side", "up"}, "invalid side"},
+		{"exclusive ids", []string{"cancel", "--coin", "ETH", "--oid", "1", "--cloid", "0x1"}, "none of the others can be"},
+		{"missing id", []string{"cancel", "--coin", "ETH"}, "at least one of the flags in the group [oid cloid]"},
+		{"unknown feed", []string{"stream", "nope"}, `invalid argument "nope"`},
+		{"missing coins", []string{"stream", "trades"}, "--coin is required for trades"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(tt.args, &stdout, &stderr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("run(%v) error = %v, want %q", tt.args, err, tt.want)
+			}
+			if !errors.Is(err, errUsage) || !strings.Contains(stderr.String(), "Usage:") {
+				t.Errorf("stderr = %q, want a usage", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--help"}, []string{"Available Commands:", "market-open", "stream", "completion", "--keystore"}},
+		{[]string{"help", "order"}, []string{"place a limit order", "--reduce-only", "--tif string", "Global Flags:"}},
+		{[]string{"completion", "bash"}, []string{"bash completion V2 for hl"}},
+		{[]string{"__complete", "stream", ""}, []string{"trades", "candles", ":4"}},
+		{[]string{"__complete", "order", "--side", ""}, []string{"buy", "sell"}},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if err := run(tt.args, &stdout, &stderr); err != nil {
+			t.Fatalf("run(%v) error = %v", tt.args, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("run(%v) output does not contain %q:\n%s", tt.args, want, stdout.String())
+			}
+		}
+	}
+}
+
+func TestAppUser(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envPrivateKey, "0x"+hex.EncodeToString(crypto.FromECDSA(key)))
+
+	a := &app{}
+	address, err := a.user("")
+	if err != nil || address != crypto.PubkeyToAddress(key.PublicKey).Hex() {
+		t.Errorf("user() = %s, %v", address, err)
+	}
+	a.address = "0xabc"
+	if address, _ := a.user(""); address != "0xabc" {
+		t.Errorf("user() with -address = %s", address)
+	}
+	if address, _ := a.user("0xdef"); address != "0xdef" {
+		t.Errorf("user(0xdef) = %s", address)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+func init() {
+	register(newPositionsCommand, newOrdersCommand, newFillsCommand, newFundingCommand, newMidsCommand)
+}
+
+func newPositionsCommand(a *app) *cobra.Command {
+	var user, dex string
+	cmd := &cobra.Command{
+		Use:   "positions [--user 0x...] [--dex xyz]",
+		Short: "show positions and margin",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, err := a.user(user)
+			if err != nil {
+				return err
+			}
+			info, err := a.Info()
+			if err != nil {
+				return err
+			}
+			state, err := info.UserState(address, dex)
+			if err != nil {
+				return err
+			}
+			return a.print(state)
+		},
+	}
+	cmd.Flags().StringVar(&user, "user", "", "account")
+	cmd.Flags().StringVar(&dex, "dex", "", "perp dex, the default dex if empty")
+	return cmd
+}
+
+func newOrdersCommand(a *app) *cobra.Command {
+	var user, dex string
+	cmd := &cobra.Command{
+		Use:   "orders [--user 0x...] [--dex xyz]",
+		Short: "show open orders",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, err := a.user(user)
+			if err != nil {
+				return err
+			}
+			info, err := a.Info()
+			if err != nil {
+				return err
+			}
+			orders, err := info.OpenOrders(address, dex)
+			if err != nil {
+				return err
+			}
+			return a.print(orders)
+		},
+	}
+	cmd.Flags().StringVar(&user, "user", "", "account")
+	cmd.Flags().StringVar(&dex, "dex", "", "perp dex, the default dex if empty")
+	return cmd
+}
+
+func newFillsCommand(a *app) *cobra.Command {
+	var user string
+	var since time.Duration
+	cmd := &cobra.Command{
+		Use:   "fills [--user 0x...] [--since 24h]",
+		Short: "show fills",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, err := a.user(user)
+			if err != nil {
+				return err
+			}
+			info, err := a.Info()
+			if err != nil {
+				return err
+			}
+			var fills []types.Fill
+			if since > 0 {
+				fills, err = info.UserFillsByTime(address, sinceMs(since), nil, false)
+			} else {
+				fills, err = info.UserFills(address)
+			}
+			if err != nil {
+				return err
+			}
+			return a.print(fills)
+		},
+	}
+	cmd.Flags().StringVar(&user, "user", "", "account")
+	cmd.Flags().DurationVar(&since, "since", 0, "only fills of the last duration, e.g. 24h, the most recent fills if 0")
+	return cmd
+}
+
+func newFundingCommand(a *app) *cobra.Command {
+	var coin, user string
+	var since time.Duration
+	cmd := &cobra.Command{
+		Use:   "funding (--coin ETH | [--user 0x...]) [--since 24h]",
+		Short: "show funding rates of a coin, or payments of an account",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := a.Info()
+			if err != nil {
+				return err
+			}
+			var records []types.FundingRecord
+			if coin != "" {
+				records, err = info.FundingHistory(coin, sinceMs(since), nil)
+			} else {
+				address, userErr := a.user(user)
+				if userErr != nil {
+					return userErr
+				}
+				records, err = info.UserFundingHistory(address, sinceMs(since), nil)
+			}
+			if err != nil {
+				return err
+			}
+			return a.print(records)
+		},
+	}
+	cmd.Flags().StringVar(&coin, "coin", "", "coin whose funding rates to show")
+	cmd.Flags().StringVar(&user, "user", "", "account whose funding payments to show")
+	cmd.Flags().DurationVar(&since, "since", 24*time.Hour, "history of the last duration")
+	cmd.MarkFlagsMutuallyExclusive("coin", "user")
+	return cmd
+}
+
+func newMidsCommand(a *app) *cobra.Command {
+	var dex, coin string
+	cmd := &cobra.Command{
+		Use:   "mids [--dex xyz] [--coin ETH]",
+		Short: "show mid prices",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := a.Info()
+			if err != nil {
+				return err
+			}
+			mids, err := info.AllMids(dex)
+			if err != nil {
+				return err
+			}
+			if coin == "" {
+				return a.print(mids)
+			}
+			name, err := info.NameToCoin(coin)
+			if err != nil {
+				return err
+			}
+			mid, ok := mids[name]
+			if !ok {
+				return fmt.Errorf("no mid price for %s", name)
+			}
+			return a.print(map[string]string{name: mid})
+		},
+	}
+	cmd.Flags().StringVar(&dex, "dex", "", "perp dex, the default dex if empty")
+	cmd.Flags().StringVar(&coin, "coin", "", "only the mid of coin")
+	return cmd
+}
+
+// sinceMs returns the timestamp d ago in milliseconds
+func sinceMs(d time.Duration) int64 {
+	return utils.GetTimestampMs() - d.Milliseconds()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// feeds are the WebSocket feeds of the stream command
+var feeds = []string{"trades", "book", "bbo", "candles", "mids", "fills", "orders", "events"}
+
+func init() {
+	register(newStreamCommand)
+}
+
+func newStreamCommand(a *app) *cobra.Command {
+	var coins, interval, user string
+	cmd := &cobra.Command{
+		Use:       "stream <trades|book|bbo|candles|mids|fills|orders|events> [--coin ETH,BTC] [--interval 1m] [--user 0x...]",
+		Short:     "print a WebSocket feed, one JSON message per line, until interrupted",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: feeds,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStream(a, args[0], splitList(coins), interval, user)
+		},
+	}
+	cmd.Flags().StringVar(&coins, "coin", "", "comma-separated coins of market feeds")
+	cmd.Flags().StringVar(&interval, "interval", string(ws.Interval1m), "interval of candles")
+	cmd.Flags().StringVar(&user, "user", "", "account of user feeds")
+	return cmd
+}
+
+func runStream(a *app, feed string, coinList []string, interval, user string) error {
+	network, err := a.cfg.NetworkConfig()
+	if err != nil {
+		return err
+	}
+	needCoins := func() error {
+		if len(coinList) == 0 {
+			return fmt.Errorf("%w: --coin is required for %s", errUsage, feed)
+		}
+		return nil
+	}
+
+	switch feed {
+	case "trades":
+		if err := needCoins(); err != nil {
+			return err
+		}
+		return stream(a, network, ws.NewTradesClient(coinList...))
+	case "book":
+		if err := needCoins(); err != nil {
+			return err
+		}
+		return stream(a, network, ws.NewL2BookClient(coinList...))
+	case "bbo":
+		if err := needCoins(); err != nil {
+			return err
+		}
+		return stream(a, network, ws.NewBboClient(coinList...))
+	case "candles":
+		if err := needCoins(); err != nil {
+			return err
+		}
+		i, err := ws.ParseInterval(interval)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		return stream(a, network, ws.NewCandleClient(i, coinList...))
+	case "mids":
+		return stream(a, network, ws.NewAllMidsClient())
+	}
+
+	address, err := a.user(user)
+	if err != nil {
+		return err
+	}
+	switch feed {
+	case "fills":
+		return stream(a, network, ws.NewUserFillsClient(address))
+	case "orders":
+		return stream(a, network, ws.NewOrderUpdatesClient(address))
+	default:
+		return stream(a, network, ws.NewUserEventsClient(address))
+	}
+}
+
+// stream prints the messages of c on network until an interrupt or an error
+func stream[T any](a *app, network constants.Network, c *ws.Client[T]) error {
+	c.SetNetwork(network)
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	enc := json.NewEncoder(a.out)
+	for {
+		msg, err := c.ReadContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// splitList splits a comma-separated list, ignoring empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
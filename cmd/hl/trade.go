@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+func init() {
+	register(newOrderCommand, newCancelCommand, newModifyCommand, newMarketOpenCommand, newMarketCloseCommand)
+}
+
+// orderFlags are the flags describing a limit order
+type orderFlags struct {
+	coin       string
+	side       string
+	sz         float64
+	px         float64
+	tif        string
+	reduceOnly bool
+	cloid      string
+}
+
+func (f *orderFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&f.coin, "coin", "", "coin, e.g. ETH, PURR/USDC or xyz:XYZ100")
+	flags.StringVar(&f.side, "side", "", "buy or sell")
+	flags.Float64Var(&f.sz, "sz", 0, "size")
+	flags.Float64Var(&f.px, "px", 0, "limit price")
+	flags.StringVar(&f.tif, "tif", string(types.TifGtc), "time in force: Gtc, Ioc or Alo")
+	flags.BoolVar(&f.reduceOnly, "reduce-only", false, "only reduce the position")
+	flags.StringVar(&f.cloid, "cloid", "", "client order id")
+	cmd.MarkFlagRequired("coin")
+	registerSideFlag(cmd)
+	cmd.RegisterFlagCompletionFunc("tif", cobra.FixedCompletions(
+		[]string{string(types.TifGtc), string(types.TifIoc), string(types.TifAlo)}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// registerSideFlag makes the side flag of cmd required and completes it
+func registerSideFlag(cmd *cobra.Command) {
+	cmd.MarkFlagRequired("side")
+	cmd.RegisterFlagCompletionFunc("side", cobra.FixedCompletions([]string{"buy", "sell"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// request returns the order described by the flags
+func (f *orderFlags) request() (types.OrderRequest, error) {
+	side, err := types.ParseSide(f.side)
+	if err != nil {
+		return types.OrderRequest{}, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	order := types.OrderRequest{
+		Coin:       f.coin,
+		IsBuy:      side.IsBuy(),
+		Sz:         f.sz,
+		LimitPx:    f.px,
+		OrderType:  types.NewLimit(types.Tif(f.tif)),
+		ReduceOnly: f.reduceOnly,
+	}
+	if f.cloid != "" {
+		if order.Cloid, err = types.NewCloidFromString(f.cloid); err != nil {
+			return types.OrderRequest{}, fmt.Errorf("%w: %v", errUsage, err)
+		}
+	}
+	return order, nil
+}
+
+func newOrderCommand(a *app) *cobra.Command {
+	var f orderFlags
+	cmd := &cobra.Command{
+		Use:   "order --coin ETH --side buy --sz 0.1 --px 2000 [--tif Gtc] [--reduce-only] [--cloid 0x...]",
+		Short: "place a limit order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			order, err := f.request()
+			if err != nil {
+				return err
+			}
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			result, err := exchange.Order(order.Coin, order.IsBuy, order.Sz, order.LimitPx, order.OrderType, order.ReduceOnly, order.Cloid, nil)
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	f.register(cmd)
+	return cmd
+}
+
+// orderID returns the order id of the oid or cloid flag, an int or a *types.Cloid.
+// The flags are exclusive and one of them is required.
+func orderID(oid int, cloid string) (any, error) {
+	if cloid == "" {
+		return oid, nil
+	}
+	c, err := types.NewCloidFromString(cloid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return c, nil
+}
+
+// registerOrderID adds the exclusive flags identifying the order to act on
+func registerOrderID(cmd *cobra.Command, oid *int, cloid *string, cloidName, usage string) {
+	cmd.Flags().IntVar(oid, "oid", 0, "order id"+usage)
+	cmd.Flags().StringVar(cloid, cloidName, "", "client order id"+usage)
+	cmd.MarkFlagsMutuallyExclusive("oid", cloidName)
+	cmd.MarkFlagsOneRequired("oid", cloidName)
+}
+
+func newCancelCommand(a *app) *cobra.Command {
+	var coin, cloid string
+	var oid int
+	cmd := &cobra.Command{
+		Use:   "cancel --coin ETH (--oid 123 | --cloid 0x...)",
+		Short: "cancel an order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := orderID(oid, cloid)
+			if err != nil {
+				return err
+			}
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			var result *types.CancelResponse
+			if c, ok := id.(*types.Cloid); ok {
+				result, err = exchange.CancelByCloid(coin, *c)
+			} else {
+				result, err = exchange.Cancel(coin, id.(int))
+			}
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	cmd.Flags().StringVar(&coin, "coin", "", "coin of the order")
+	cmd.MarkFlagRequired("coin")
+	registerOrderID(cmd, &oid, &cloid, "cloid", "")
+	return cmd
+}
+
+func newModifyCommand(a *app) *cobra.Command {
+	var f orderFlags
+	var target string
+	var oid int
+	cmd := &cobra.Command{
+		Use:   "modify --coin ETH (--oid 123 | --target-cloid 0x...) --side buy --sz 0.1 --px 2000 [--tif Gtc] [--reduce-only]",
+		Short: "modify an order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := orderID(oid, target)
+			if err != nil {
+				return err
+			}
+			order, err := f.request()
+			if err != nil {
+				return err
+			}
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			result, err := exchange.ModifyOrder(id, order.Coin, order.IsBuy, order.Sz, order.LimitPx, order.OrderType, order.ReduceOnly, order.Cloid)
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	f.register(cmd)
+	registerOrderID(cmd, &oid, &target, "target-cloid", " of the order to modify")
+	return cmd
+}
+
+func newMarketOpenCommand(a *app) *cobra.Command {
+	var coin, sideName string
+	var sz, slippage float64
+	cmd := &cobra.Command{
+		Use:   "market-open --coin ETH --side buy --sz 0.1 [--slippage 0.01]",
+		Short: "open a position with a market order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			side, err := types.ParseSide(sideName)
+			if err != nil {
+				return fmt.Errorf("%w: %v", errUsage, err)
+			}
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			result, err := exchange.MarketOpen(coin, side.IsBuy(), sz, nil, slippage, nil, nil)
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	cmd.Flags().StringVar(&coin, "coin", "", "coin")
+	cmd.Flags().StringVar(&sideName, "side", "", "buy or sell")
+	cmd.Flags().Float64Var(&sz, "sz", 0, "size")
+	cmd.Flags().Float64Var(&slippage, "slippage", 0, "slippage, e.g. 0.01 for 1%, the default of the config if 0")
+	cmd.MarkFlagRequired("coin")
+	registerSideFlag(cmd)
+	return cmd
+}
+
+func newMarketCloseCommand(a *app) *cobra.Command {
+	var coin string
+	var sz, slippage float64
+	cmd := &cobra.Command{
+		Use:   "market-close --coin ETH [--sz 0.1] [--slippage 0.01]",
+		Short: "close a position with a market order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exchange, err := a.Exchange()
+			if err != nil {
+				return err
+			}
+			var size *float64
+			if sz != 0 {
+				size = &sz
+			}
+			result, err := exchange.MarketClose(coin, size, nil, slippage, nil, nil)
+			if err != nil {
+				return err
+			}
+			return a.print(result)
+		},
+	}
+	cmd.Flags().StringVar(&coin, "coin", "", "coin")
+	cmd.Flags().Float64Var(&sz, "sz", 0, "size to close, the whole position if 0")
+	cmd.Flags().Float64Var(&slippage, "slippage", 0, "slippage, e.g. 0.01 for 1%, the default of the config if 0")
+	cmd.MarkFlagRequired("coin")
+	return cmd
+}
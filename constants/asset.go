@@ -0,0 +1,58 @@
+package constants
+
+// BuilderPerpDexSize is the number of asset ids reserved for each builder-deployed
+// perp dex
+const BuilderPerpDexSize = 10000
+
+// IsSpotAsset returns true if id is the asset id of a spot pair
+func IsSpotAsset(id int) bool {
+	return id >= SpotAssetOffset && id < BuilderPerpDexOffset
+}
+
+// IsBuilderPerpAsset returns true if id is the asset id of a perp of a builder-deployed
+// dex
+func IsBuilderPerpAsset(id int) bool {
+	return id >= BuilderPerpDexOffset
+}
+
+// IsPerpAsset returns true if id is the asset id of a perp, of the default dex or of a
+// builder-deployed one
+func IsPerpAsset(id int) bool {
+	return (id >= 0 && id < SpotAssetOffset) || IsBuilderPerpAsset(id)
+}
+
+// DexIndexFromAsset returns the index of the perp dex of id, as listed by perpDexs: 0
+// for the default dex, 1 and up for builder-deployed dexs. False for spot assets.
+func DexIndexFromAsset(id int) (int, bool) {
+	switch {
+	case IsBuilderPerpAsset(id):
+		return (id-BuilderPerpDexOffset)/BuilderPerpDexSize + 1, true
+	case IsPerpAsset(id):
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// SpotIndexFromAsset returns the index of the spot pair of id, as in "@index" coin
+// names, false if id is not a spot asset
+func SpotIndexFromAsset(id int) (int, bool) {
+	if !IsSpotAsset(id) {
+		return 0, false
+	}
+	return id - SpotAssetOffset, true
+}
+
+// SpotAsset returns the asset id of the spot pair at index
+func SpotAsset(index int) int {
+	return SpotAssetOffset + index
+}
+
+// PerpAsset returns the asset id of the perp at index in the universe of the perp dex
+// at dexIndex, 0 being the default dex
+func PerpAsset(dexIndex, index int) int {
+	if dexIndex == 0 {
+		return index
+	}
+	return BuilderPerpDexOffset + (dexIndex-1)*BuilderPerpDexSize + index
+}
@@ -0,0 +1,50 @@
+package constants
+
+import "testing"
+
+func TestAssetClassification(t *testing.T) {
+	tests := []struct {
+		id      int
+		spot    bool
+		builder bool
+		dex     int
+		dexOK   bool
+	}{
+		{0, false, false, 0, true},
+		{9999, false, false, 0, true},
+		{10000, true, false, 0, false},
+		{10107, true, false, 0, false},
+		{110000, false, true, 1, true},
+		{120001, false, true, 2, true},
+		{-1, false, false, 0, false},
+	}
+	for _, tt := range tests {
+		if got := IsSpotAsset(tt.id); got != tt.spot {
+			t.Errorf("IsSpotAsset(%d) = %v", tt.id, got)
+		}
+		if got := IsBuilderPerpAsset(tt.id); got != tt.builder {
+			t.Errorf("IsBuilderPerpAsset(%d) = %v", tt.id, got)
+		}
+		if dex, ok := DexIndexFromAsset(tt.id); dex != tt.dex || ok != tt.dexOK {
+			t.Errorf("DexIndexFromAsset(%d) = %d, %v", tt.id, dex, ok)
+		}
+	}
+}
+
+func TestAssetConstruction(t *testing.T) {
+	if got := SpotAsset(107); got != 10107 {
+		t.Errorf("SpotAsset(107) = %d", got)
+	}
+	if index, ok := SpotIndexFromAsset(10107); !ok || index != 107 {
+		t.Errorf("SpotIndexFromAsset(10107) = %d, %v", index, ok)
+	}
+	if _, ok := SpotIndexFromAsset(3); ok {
+		t.Error("SpotIndexFromAsset(3) should fail")
+	}
+	if got := PerpAsset(0, 3); got != 3 {
+		t.Errorf("PerpAsset(0, 3) = %d", got)
+	}
+	if got := PerpAsset(2, 1); got != 120001 {
+		t.Errorf("PerpAsset(2, 1) = %d", got)
+	}
+}
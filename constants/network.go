@@ -0,0 +1,119 @@
+package constants
+
+import "strings"
+
+const (
+	// MainnetWsURL is the WebSocket URL for Hyperliquid mainnet
+	MainnetWsURL = "wss://api.hyperliquid.xyz/ws"
+
+	// TestnetWsURL is the WebSocket URL for Hyperliquid testnet
+	TestnetWsURL = "wss://api.hyperliquid-testnet.xyz/ws"
+
+	// LocalWsURL is the WebSocket URL for local development
+	LocalWsURL = "ws://localhost:3001/ws"
+)
+
+// Network bundles the endpoints and signing parameters of a Hyperliquid environment
+type Network struct {
+	// Name identifies the network, e.g. "mainnet"
+	Name string
+
+	// APIURL is the base URL of the HTTP API
+	APIURL string
+
+	// WsURL is the WebSocket URL
+	WsURL string
+
+	// L1ChainID is the chainId of the EIP-712 domain used for L1 actions (phantom agent)
+	L1ChainID int64
+
+	// SignatureChainID is the hex chainId of the EIP-712 domain used for user-signed actions
+	SignatureChainID string
+
+	// HyperliquidChain is the hyperliquidChain value of user-signed actions ("Mainnet" or "Testnet")
+	HyperliquidChain string
+
+	// AgentSource is the phantom agent source ("a" for mainnet, "b" otherwise)
+	AgentSource string
+}
+
+var (
+	// Mainnet is the Hyperliquid mainnet
+	Mainnet = Network{
+		Name:             "mainnet",
+		APIURL:           MainnetAPIURL,
+		WsURL:            MainnetWsURL,
+		L1ChainID:        1337,
+		SignatureChainID: "0x66eee",
+		HyperliquidChain: "Mainnet",
+		AgentSource:      "a",
+	}
+
+	// Testnet is the Hyperliquid testnet
+	Testnet = Network{
+		Name:             "testnet",
+		APIURL:           TestnetAPIURL,
+		WsURL:            TestnetWsURL,
+		L1ChainID:        1337,
+		SignatureChainID: "0x66eee",
+		HyperliquidChain: "Testnet",
+		AgentSource:      "b",
+	}
+
+	// Local is a local node, which expects testnet signatures
+	Local = Network{
+		Name:             "local",
+		APIURL:           LocalAPIURL,
+		WsURL:            LocalWsURL,
+		L1ChainID:        1337,
+		SignatureChainID: "0x66eee",
+		HyperliquidChain: "Testnet",
+		AgentSource:      "b",
+	}
+)
+
+// WithURLs returns the network with other endpoints, e.g. a private node of mainnet:
+//
+//	constants.Mainnet.WithURLs("https://node.example.com", "wss://node.example.com/ws")
+func (n Network) WithURLs(apiURL, wsURL string) Network {
+	n.APIURL = apiURL
+	n.WsURL = wsURL
+	return n
+}
+
+// IsMainnet returns true if actions signed for the network are valid on mainnet
+func (n Network) IsMainnet() bool {
+	return n.HyperliquidChain == Mainnet.HyperliquidChain && n.AgentSource == Mainnet.AgentSource
+}
+
+// NetworkFor returns Mainnet or Testnet
+func NetworkFor(isMainnet bool) Network {
+	if isMainnet {
+		return Mainnet
+	}
+	return Testnet
+}
+
+// NetworkForURL returns the preset whose API or WebSocket URL is url, ignoring case
+// and a trailing slash, and Testnet otherwise, since the exchange expects testnet
+// signatures for any non-mainnet environment. Custom endpoints of mainnet must set
+// their network explicitly, see Network.WithURLs.
+func NetworkForURL(url string) Network {
+	url = strings.ToLower(strings.TrimSuffix(url, "/"))
+	for _, n := range []Network{Mainnet, Testnet, Local} {
+		if url == n.APIURL || url == n.WsURL {
+			return n
+		}
+	}
+	return Testnet
+}
+
+// NetworkByName returns the preset named name, e.g. "mainnet", ignoring case
+func NetworkByName(name string) (Network, bool) {
+	for _, n := range []Network{Mainnet, Testnet, Local} {
+		if strings.EqualFold(name, n.Name) {
+			return n, true
+		}
+	}
+	return Network{}, false
+}
@@ -0,0 +1,49 @@
+package constants
+
+import "testing"
+
+func TestNetworkForURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.hyperliquid.xyz", "mainnet"},
+		{"https://api.hyperliquid.xyz/", "mainnet"},
+		{"HTTPS://API.HYPERLIQUID.XYZ", "mainnet"},
+		{"wss://api.hyperliquid.xyz/ws", "mainnet"},
+		{"https://api.hyperliquid-testnet.xyz", "testnet"},
+		{"http://localhost:3001", "local"},
+		{"ws://localhost:3001/ws", "local"},
+		{"https://node.example.com", "testnet"},
+	}
+	for _, tt := range tests {
+		if got := NetworkForURL(tt.url); got.Name != tt.want {
+			t.Errorf("NetworkForURL(%q) = %s, want %s", tt.url, got.Name, tt.want)
+		}
+	}
+	if Local.IsMainnet() || !Mainnet.IsMainnet() {
+		t.Error("only Mainnet should sign for mainnet")
+	}
+}
+
+func TestNetworkWithURLs(t *testing.T) {
+	node := Mainnet.WithURLs("https://node.example.com", "wss://node.example.com/ws")
+	if !node.IsMainnet() || node.APIURL != "https://node.example.com" || node.WsURL != "wss://node.example.com/ws" {
+		t.Errorf("WithURLs() = %+v", node)
+	}
+	if Mainnet.APIURL != MainnetAPIURL {
+		t.Errorf("WithURLs() modified Mainnet: %+v", Mainnet)
+	}
+}
+
+func TestNetworkByName(t *testing.T) {
+	if n, ok := NetworkByName("Mainnet"); !ok || n != Mainnet {
+		t.Errorf("NetworkByName(Mainnet) = %+v, %v", n, ok)
+	}
+	if n, ok := NetworkByName("local"); !ok || n != Local {
+		t.Errorf("NetworkByName(local) = %+v, %v", n, ok)
+	}
+	if _, ok := NetworkByName("devnet"); ok {
+		t.Error("NetworkByName(devnet) should fail")
+	}
+}
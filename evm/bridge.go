@@ -0,0 +1,99 @@
+// Package evm deposits USDC from Arbitrum to Hyperliquid through the bridge contract.
+// A deposit is a USDC transfer to the bridge, which credits the perp balance of the
+// sender; the bridge needs no allowance. Deposits below MinDeposit are lost.
+//
+//	backend, err := ethclient.DialContext(ctx, "https://arb1.arbitrum.io/rpc")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer backend.Close()
+//	depositor := evm.NewDepositor(backend, info, key, evm.Config{Bridge: evm.BridgeFor(constants.Mainnet)})
+//	deposit, err := depositor.Deposit(ctx, 100)
+//
+// Withdrawals go the other way with Exchange.WithdrawFromBridge.
+package evm
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dwdwow/hl-go/constants"
+)
+
+const (
+	// USDCDecimals is the number of decimals of USDC on Arbitrum
+	USDCDecimals = 6
+	// MinDeposit is the smallest deposit in USDC; the bridge keeps smaller deposits
+	// without crediting them
+	MinDeposit = 5
+)
+
+// Bridge is the bridge contract of a network and the USDC token it accepts, on the
+// Arbitrum chain ChainID
+type Bridge struct {
+	Address common.Address
+	USDC    common.Address
+	ChainID int64
+}
+
+var (
+	// MainnetBridge is the bridge of mainnet, on Arbitrum One
+	MainnetBridge = Bridge{
+		Address: common.HexToAddress("0x2Df1c51E09aECF9cacB7bc98cB1742757f163dF7"),
+		USDC:    common.HexToAddress("0xaf88d065e77c8cC2239327C5EDb3A432268e5831"),
+		ChainID: 42161,
+	}
+
+	// TestnetBridge is the bridge of testnet, on Arbitrum Sepolia
+	TestnetBridge = Bridge{
+		Address: common.HexToAddress("0x08cfc1B6b2dCF36A1480b99353A354AA8AC56f89"),
+		USDC:    common.HexToAddress("0x1baAbB04529D43a73232B713C0FE471f7c7334d5"),
+		ChainID: 421614,
+	}
+)
+
+// BridgeFor returns the bridge of network, MainnetBridge or TestnetBridge
+func BridgeFor(network constants.Network) Bridge {
+	if network.IsMainnet() {
+		return MainnetBridge
+	}
+	return TestnetBridge
+}
+
+// erc20ABI is the part of the ERC-20 interface used by deposits
+const erc20ABI = `[
+	{"type": "function", "name": "balanceOf", "stateMutability": "view",
+	 "inputs": [{"name": "account", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "transfer", "stateMutability": "nonpayable",
+	 "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}], "outputs": [{"name": "", "type": "bool"}]}
+]`
+
+var erc20 = mustParseABI(erc20ABI)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid abi: %v", err))
+	}
+	return parsed
+}
+
+// ToUnits converts an amount of USDC to the smallest units of the token
+func ToUnits(amount float64) (*big.Int, error) {
+	units := math.Round(amount * math.Pow10(USDCDecimals))
+	if math.IsNaN(units) || math.IsInf(units, 0) || units < 0 || units > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid USDC amount: %v", amount)
+	}
+	return big.NewInt(int64(units)), nil
+}
+
+// FromUnits converts smallest units of USDC to an amount
+func FromUnits(units *big.Int) float64 {
+	amount, _ := new(big.Float).Quo(new(big.Float).SetInt(units), big.NewFloat(math.Pow10(USDCDecimals))).Float64()
+	return amount
+}
@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/dwdwow/hl-go/constants"
+)
+
+func TestUnits(t *testing.T) {
+	for _, tt := range []struct {
+		amount float64
+		units  int64
+	}{
+		{0, 0},
+		{5, 5_000_000},
+		{100.123456, 100_123_456},
+		{0.1 + 0.2, 300_000},
+	} {
+		units, err := ToUnits(tt.amount)
+		if err != nil {
+			t.Fatalf("ToUnits(tt.amount) error = %v", err)
+		}
+		if want := big.NewInt(tt.units); units.Cmp(want) != 0 {
+			t.Errorf("units = %+v, want %+v", units, want)
+		}
+		if got := FromUnits(units); math.Abs(got-tt.amount) > 1e-9 {
+			t.Errorf("FromUnits(units) = %v, want %v", got, tt.amount)
+		}
+	}
+	for _, amount := range []float64{-1, math.NaN(), math.Inf(1)} {
+		_, err := ToUnits(amount)
+		if err == nil {
+			t.Error("ToUnits(amount) error = nil, want error")
+		}
+	}
+}
+
+func TestBridgeFor(t *testing.T) {
+	if got := BridgeFor(constants.Mainnet); got != MainnetBridge {
+		t.Errorf("BridgeFor(constants.Mainnet) = %+v, want %+v", got, MainnetBridge)
+	}
+	if got := BridgeFor(constants.Testnet); got != TestnetBridge {
+		t.Errorf("BridgeFor(constants.Testnet) = %+v, want %+v", got, TestnetBridge)
+	}
+	if got := BridgeFor(constants.Local); got != TestnetBridge {
+		t.Errorf("BridgeFor(constants.Local) = %+v, want %+v", got, TestnetBridge)
+	}
+}
@@ -0,0 +1,288 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// DefaultPollInterval is how often receipts and ledger updates are polled
+const DefaultPollInterval = 2 * time.Second
+
+// Backend is the part of an Arbitrum RPC client used by deposits, implemented by
+// ethclient.Client
+type Backend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	SendTransaction(ctx context.Context, tx *ethtypes.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethtypes.Receipt, error)
+}
+
+var _ Backend = (*ethclient.Client)(nil)
+
+// Config configures a Depositor
+type Config struct {
+	// Bridge is the bridge deposited to, see BridgeFor
+	Bridge Bridge
+	// PollInterval is how often receipts and ledger updates are polled,
+	// DefaultPollInterval if zero
+	PollInterval time.Duration
+}
+
+// Deposit is a deposit sent and credited
+type Deposit struct {
+	Amount float64
+	TxHash common.Hash
+	// Block is the Arbitrum block of the transfer
+	Block uint64
+	// Credit is the ledger update crediting the deposit on Hyperliquid
+	Credit types.NonFundingLedgerUpdate
+}
+
+// Depositor deposits the USDC of the address of a key on Arbitrum to its Hyperliquid
+// account, and follows the deposit until it is credited
+type Depositor struct {
+	backend Backend
+	info    client.Infoer
+	key     *ecdsa.PrivateKey
+	address common.Address
+	config  Config
+	clock   utils.Clock
+}
+
+// NewDepositor creates a depositor sending transactions through backend signed with
+// key, and reading the ledger of the account from info
+func NewDepositor(backend Backend, info client.Infoer, key *ecdsa.PrivateKey, config Config) *Depositor {
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	return &Depositor{
+		backend: backend,
+		info:    info,
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+		config:  config,
+		clock:   utils.SystemClock,
+	}
+}
+
+// SetClock sets the clock giving the start time of the ledger updates searched for
+// a credit, e.g. a utils.FakeClock in tests
+func (d *Depositor) SetClock(clock utils.Clock) {
+	d.clock = clock
+}
+
+// Address returns the address depositing, and credited on Hyperliquid
+func (d *Depositor) Address() common.Address {
+	return d.address
+}
+
+// Balance returns the USDC balance of the address on Arbitrum
+func (d *Depositor) Balance(ctx context.Context) (float64, error) {
+	units, err := d.balance(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return FromUnits(units), nil
+}
+
+// Deposit transfers amount USDC to the bridge, waits for the transfer to be mined and
+// then for the deposit to be credited, until ctx is done
+func (d *Depositor) Deposit(ctx context.Context, amount float64) (*Deposit, error) {
+	since := d.clock.Now()
+	hash, err := d.Transfer(ctx, amount)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := d.WaitMined(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	credit, err := d.WaitCredited(ctx, amount, since)
+	if err != nil {
+		return nil, err
+	}
+	return &Deposit{Amount: amount, TxHash: hash, Block: receipt.BlockNumber.Uint64(), Credit: *credit}, nil
+}
+
+// Transfer sends the transfer of amount USDC to the bridge and returns its hash. It
+// fails without sending for amounts below MinDeposit or above the balance, and when
+// the backend is not on the chain of the bridge.
+func (d *Depositor) Transfer(ctx context.Context, amount float64) (common.Hash, error) {
+	if amount < MinDeposit {
+		return common.Hash{}, fmt.Errorf("deposit of %v USDC is below the minimum of %v", amount, MinDeposit)
+	}
+	units, err := ToUnits(amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	chainID, err := d.backend.ChainID(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get chain id: %w", err)
+	}
+	if chainID.Int64() != d.config.Bridge.ChainID {
+		return common.Hash{}, fmt.Errorf("backend is on chain %v, the bridge on chain %d", chainID, d.config.Bridge.ChainID)
+	}
+	balance, err := d.balance(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if balance.Cmp(units) < 0 {
+		return common.Hash{}, fmt.Errorf("deposit of %v USDC exceeds the balance of %v", amount, FromUnits(balance))
+	}
+
+	data, err := erc20.Pack("transfer", d.config.Bridge.Address, units)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode transfer: %w", err)
+	}
+	tx, err := d.transaction(ctx, chainID, d.config.Bridge.USDC, data)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signed, err := ethtypes.SignTx(tx, ethtypes.LatestSignerForChainID(chainID), d.key)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to sign transfer: %w", err)
+	}
+	if err := d.backend.SendTransaction(ctx, signed); err != nil {
+		return common.Hash{}, fmt.Errorf("failed to send transfer: %w", err)
+	}
+	return signed.Hash(), nil
+}
+
+// WaitMined polls the receipt of the transaction hash until it is mined, failing if
+// the transaction reverted
+func (d *Depositor) WaitMined(ctx context.Context, hash common.Hash) (*ethtypes.Receipt, error) {
+	return poll(ctx, d.config.PollInterval, func() (*ethtypes.Receipt, error) {
+		receipt, err := d.backend.TransactionReceipt(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get receipt of %s: %w", hash, err)
+		}
+		if receipt.Status != ethtypes.ReceiptStatusSuccessful {
+			return nil, fmt.Errorf("transfer %s reverted", hash)
+		}
+		return receipt, nil
+	})
+}
+
+// WaitCredited polls the ledger updates of the account from since until a deposit of
+// amount USDC is credited
+func (d *Depositor) WaitCredited(ctx context.Context, amount float64, since time.Time) (*types.NonFundingLedgerUpdate, error) {
+	user := d.address.Hex()
+	return poll(ctx, d.config.PollInterval, func() (*types.NonFundingLedgerUpdate, error) {
+		updates, err := d.info.UserNonFundingLedgerUpdates(user, since.UnixMilli(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ledger updates: %w", err)
+		}
+		for _, update := range updates {
+			if update.Delta.Type != types.LedgerDeposit {
+				continue
+			}
+			usdc, err := strconv.ParseFloat(update.Delta.Usdc, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse deposit amount: %w", err)
+			}
+			if math.Abs(usdc-amount) < 1e-6 {
+				return &update, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+// balance returns the USDC balance of the address in units
+func (d *Depositor) balance(ctx context.Context) (*big.Int, error) {
+	data, err := erc20.Pack("balanceOf", d.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode balance call: %w", err)
+	}
+	usdc := d.config.Bridge.USDC
+	out, err := d.backend.CallContract(ctx, ethereum.CallMsg{From: d.address, To: &usdc, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get USDC balance: %w", err)
+	}
+	values, err := erc20.Unpack("balanceOf", out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode USDC balance: %w", err)
+	}
+	var balance *big.Int
+	if len(values) == 1 {
+		balance, _ = values[0].(*big.Int)
+	}
+	if balance == nil {
+		return nil, fmt.Errorf("invalid USDC balance %v", values)
+	}
+	return balance, nil
+}
+
+// transaction returns a dynamic fee transaction calling to with data, with a fee cap
+// of twice the base fee plus the tip and the estimated gas with a 20% margin
+func (d *Depositor) transaction(ctx context.Context, chainID *big.Int, to common.Address, data []byte) (*ethtypes.Transaction, error) {
+	nonce, err := d.backend.PendingNonceAt(ctx, d.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	tip, err := d.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas tip: %w", err)
+	}
+	head, err := d.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, fmt.Errorf("chain %v has no base fee", chainID)
+	}
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	gas, err := d.backend.EstimateGas(ctx, ethereum.CallMsg{From: d.address, To: &to, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	return ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas * 6 / 5,
+		To:        &to,
+		Data:      data,
+	}), nil
+}
+
+// poll calls check every interval until it returns a result or an error, or ctx is done
+func poll[T any](ctx context.Context, interval time.Duration, check func() (*T, error)) (*T, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, err := check()
+		if err != nil || result != nil {
+			return result, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
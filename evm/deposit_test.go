@@ -0,0 +1,240 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+)
+
+// chain is a Backend holding USDC balances, mining each transaction after a number of
+// receipt polls
+type chain struct {
+	mu       sync.Mutex
+	chainID  int64
+	balances map[common.Address]*big.Int
+	sent     []*ethtypes.Transaction
+	pending  int
+	revert   bool
+	mined    bool
+}
+
+func newChain(owner common.Address, balance float64) *chain {
+	units, _ := ToUnits(balance)
+	return &chain{chainID: TestnetBridge.ChainID, balances: map[common.Address]*big.Int{owner: units}, pending: 2}
+}
+
+func (c *chain) ChainID(context.Context) (*big.Int, error) {
+	return big.NewInt(c.chainID), nil
+}
+
+func (c *chain) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return uint64(len(c.sent)), nil
+}
+
+func (c *chain) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(1_000_000), nil
+}
+
+func (c *chain) HeaderByNumber(context.Context, *big.Int) (*ethtypes.Header, error) {
+	return &ethtypes.Header{Number: big.NewInt(100), BaseFee: big.NewInt(10_000_000)}, nil
+}
+
+func (c *chain) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 50_000, nil
+}
+
+func (c *chain) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if *msg.To != TestnetBridge.USDC {
+		return nil, errors.New("not a contract")
+	}
+	balance := c.balances[msg.From]
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	return erc20.Methods["balanceOf"].Outputs.Pack(balance)
+}
+
+func (c *chain) SendTransaction(_ context.Context, tx *ethtypes.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	from, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+	args, err := erc20.Methods["transfer"].Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return err
+	}
+	to, value := args[0].(common.Address), args[1].(*big.Int)
+	c.balances[from] = new(big.Int).Sub(c.balances[from], value)
+	if c.balances[to] == nil {
+		c.balances[to] = new(big.Int)
+	}
+	c.balances[to].Add(c.balances[to], value)
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *chain) TransactionReceipt(_ context.Context, hash common.Hash) (*ethtypes.Receipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.sent) == 0 || c.sent[len(c.sent)-1].Hash() != hash {
+		return nil, ethereum.NotFound
+	}
+	if c.pending > 0 {
+		c.pending--
+		return nil, ethereum.NotFound
+	}
+	c.mined = true
+	status := ethtypes.ReceiptStatusSuccessful
+	if c.revert {
+		status = ethtypes.ReceiptStatusFailed
+	}
+	return &ethtypes.Receipt{Status: status, TxHash: hash, BlockNumber: big.NewInt(101)}, nil
+}
+
+func (c *chain) isMined() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mined
+}
+
+func newDepositor(t *testing.T, balance float64) (*Depositor, *chain, *hltest.Server) {
+	t.Helper()
+	key := hltest.NewTestKey(t)
+	c := newChain(crypto.PubkeyToAddress(key.PublicKey), balance)
+	srv := hltest.NewTestServer(t)
+	return NewDepositor(c, srv.NewTestInfo(t), key, Config{Bridge: TestnetBridge, PollInterval: time.Millisecond}), c, srv
+}
+
+func TestDeposit(t *testing.T) {
+	d, c, srv := newDepositor(t, 250)
+	var requests int
+	srv.HandleInfo("userNonFundingLedgerUpdates", func(req map[string]any) (any, error) {
+		requests++
+		if got, want := strings.ToLower(req["user"].(string)), strings.ToLower(d.Address().Hex()); got != want {
+			t.Errorf("ToLower(req[user].(string)) = %q, want %q", got, want)
+		}
+		updates := []types.NonFundingLedgerUpdate{
+			{Time: 1, Hash: "0x1", Delta: types.LedgerDelta{Type: types.LedgerDeposit, Usdc: "50.0"}},
+			{Time: 2, Hash: "0x2", Delta: types.LedgerDelta{Type: types.LedgerWithdraw, Usdc: "100.0"}},
+		}
+		// Credited once mined, and a poll later
+		if c.isMined() && requests > 1 {
+			updates = append(updates, types.NonFundingLedgerUpdate{Time: 3, Hash: "0x3", Delta: types.LedgerDelta{Type: types.LedgerDeposit, Usdc: "100.0"}})
+		}
+		return updates, nil
+	})
+
+	balance, err := d.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance(context.Background()) error = %v", err)
+	}
+	if balance != 250.0 {
+		t.Errorf("balance = %v, want 250.0", balance)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	deposit, err := d.Deposit(ctx, 100)
+	if err != nil {
+		t.Fatalf("Deposit(ctx, 100) error = %v", err)
+	}
+	if deposit.Amount != 100.0 {
+		t.Errorf("deposit.Amount = %v, want 100.0", deposit.Amount)
+	}
+	if deposit.Block != uint64(101) {
+		t.Errorf("deposit.Block = %v, want uint64(101)", deposit.Block)
+	}
+	if deposit.Credit.Hash != "0x3" {
+		t.Errorf("deposit.Credit.Hash = %q, want %q", deposit.Credit.Hash, "0x3")
+	}
+
+	if len(c.sent) != 1 {
+		t.Fatalf("len(c.sent) = %d, want 1", len(c.sent))
+	}
+	tx := c.sent[0]
+	if got := tx.Hash(); got != deposit.TxHash {
+		t.Errorf("Hash() = %+v, want %+v", got, deposit.TxHash)
+	}
+	if got := *tx.To(); got != TestnetBridge.USDC {
+		t.Errorf("*tx.To() = %+v, want %+v", got, TestnetBridge.USDC)
+	}
+	if got := tx.Gas(); got != uint64(60_000) {
+		t.Errorf("Gas() = %v, want uint64(60_000)", got)
+	}
+	if got, want := tx.GasFeeCap(), big.NewInt(21_000_000); got.Cmp(want) != 0 {
+		t.Errorf("GasFeeCap() = %+v, want %+v", got, want)
+	}
+	if want := big.NewInt(100_000_000); c.balances[TestnetBridge.Address].Cmp(want) != 0 {
+		t.Errorf("c.balances[TestnetBridge.Address] = %+v, want %+v", c.balances[TestnetBridge.Address], want)
+	}
+
+	balance, err = d.Balance(context.Background())
+	if err != nil {
+		t.Fatalf("Balance(context.Background()) error = %v", err)
+	}
+	if balance != 150.0 {
+		t.Errorf("balance = %v, want 150.0", balance)
+	}
+}
+
+func TestTransferChecks(t *testing.T) {
+	ctx := context.Background()
+	d, c, _ := newDepositor(t, 20)
+	_, err := d.Transfer(ctx, 4.99)
+	if err == nil || !strings.Contains(err.Error(), "below the minimum") {
+		t.Errorf("Transfer(ctx, 4.99) error = %v, want %q", err, "below the minimum")
+	}
+	_, err = d.Transfer(ctx, 21)
+	if err == nil || !strings.Contains(err.Error(), "exceeds the balance") {
+		t.Errorf("Transfer(ctx, 21) error = %v, want %q", err, "exceeds the balance")
+	}
+	c.chainID = MainnetBridge.ChainID
+	_, err = d.Transfer(ctx, 10)
+	if err == nil || !strings.Contains(err.Error(), "chain") {
+		t.Errorf("Transfer(ctx, 10) error = %v, want %q", err, "chain")
+	}
+	if len(c.sent) != 0 {
+		t.Errorf("c.sent = %+v, want empty", c.sent)
+	}
+}
+
+func TestWaitFailures(t *testing.T) {
+	d, c, srv := newDepositor(t, 20)
+	c.revert = true
+	hash, err := d.Transfer(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Transfer(context.Background(), 10) error = %v", err)
+	}
+	_, err = d.WaitMined(context.Background(), hash)
+	if err == nil || !strings.Contains(err.Error(), "reverted") {
+		t.Errorf("WaitMined() error = %v, want %q", err, "reverted")
+	}
+
+	srv.HandleInfo("userNonFundingLedgerUpdates", func(map[string]any) (any, error) {
+		return []types.NonFundingLedgerUpdate{}, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = d.WaitCredited(ctx, 10, time.Now())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitCredited(ctx, 10, time.Now()) error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
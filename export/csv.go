@@ -0,0 +1,60 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvTimeFormat is RFC 3339 with milliseconds
+const csvTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// csvWriter writes rows as CSV records
+type csvWriter struct {
+	schema []Column
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, schema []Column) (*csvWriter, error) {
+	c := &csvWriter{schema: schema, w: csv.NewWriter(w), record: make([]string, len(schema))}
+	for i, column := range schema {
+		c.record[i] = column.Name
+	}
+	if err := c.w.Write(c.record); err != nil {
+		return nil, fmt.Errorf("failed to write csv header: %w", err)
+	}
+	return c, nil
+}
+
+func (c *csvWriter) Write(row []any) error {
+	if err := checkRow(c.schema, row); err != nil {
+		return err
+	}
+	for i, column := range c.schema {
+		switch column.Type {
+		case TypeString, TypeDecimal:
+			c.record[i] = row[i].(string)
+		case TypeInt64:
+			c.record[i] = strconv.FormatInt(row[i].(int64), 10)
+		case TypeBool:
+			c.record[i] = strconv.FormatBool(row[i].(bool))
+		case TypeTimestamp:
+			c.record[i] = time.UnixMilli(row[i].(int64)).UTC().Format(csvTimeFormat)
+		}
+	}
+	if err := c.w.Write(c.record); err != nil {
+		return fmt.Errorf("failed to write csv record: %w", err)
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return fmt.Errorf("failed to flush csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Package export dumps the history of an account, its fills, funding payments and
+// ledger updates, and candles to CSV or Parquet files with stable schemas, e.g. for
+// tax reporting or offline analysis. The history is fetched page by page over a time
+// range:
+//
+//	exporter := export.NewExporter(info, export.FormatCSV)
+//	f, _ := os.Create("fills.csv")
+//	defer f.Close()
+//	n, err := exporter.Fills(f, address, start, end)
+//
+// Decimal numbers are written as the strings of the API to CSV files, so no precision
+// is lost, and as doubles to Parquet files.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// Format is the file format of an export
+type Format string
+
+const (
+	// FormatCSV writes a header row followed by a row per record
+	FormatCSV Format = "csv"
+	// FormatParquet writes an uncompressed Parquet file
+	FormatParquet Format = "parquet"
+)
+
+// ColumnType is the type of the values of a column
+type ColumnType int
+
+const (
+	// TypeString is a UTF-8 string
+	TypeString ColumnType = iota
+	// TypeInt64 is a 64 bit integer
+	TypeInt64
+	// TypeBool is a boolean
+	TypeBool
+	// TypeTimestamp is a time in milliseconds since the epoch, written as RFC 3339 in
+	// UTC to CSV files
+	TypeTimestamp
+	// TypeDecimal is a decimal number as a string of the API, written as is to CSV
+	// files and as a double to Parquet files, null if empty
+	TypeDecimal
+)
+
+// Column is a column of a schema
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Writer writes rows of a schema. The values of a row are in the order of the columns:
+// a string for TypeString and TypeDecimal, an int64 for TypeInt64 and TypeTimestamp, a
+// bool for TypeBool.
+type Writer interface {
+	Write(row []any) error
+	// Close flushes the rows written, without closing the underlying writer
+	Close() error
+}
+
+// NewWriter returns a writer of rows of schema to w in format
+func NewWriter(w io.Writer, format Format, schema []Column) (Writer, error) {
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("schema has no columns")
+	}
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, schema)
+	case FormatParquet:
+		return newParquetWriter(w, schema), nil
+	default:
+		return nil, fmt.Errorf("unknown export format: %q", format)
+	}
+}
+
+// checkRow checks that row has a value of the type of each column of schema
+func checkRow(schema []Column, row []any) error {
+	if len(row) != len(schema) {
+		return fmt.Errorf("row has %d values, want %d", len(row), len(schema))
+	}
+	for i, column := range schema {
+		var ok bool
+		switch column.Type {
+		case TypeString, TypeDecimal:
+			_, ok = row[i].(string)
+		case TypeInt64, TypeTimestamp:
+			_, ok = row[i].(int64)
+		case TypeBool:
+			_, ok = row[i].(bool)
+		}
+		if !ok {
+			return fmt.Errorf("invalid value %v of type %T for column %s", row[i], row[i], column.Name)
+		}
+	}
+	return nil
+}
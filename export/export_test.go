@@ -0,0 +1,53 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV, []Column{{"time", TypeTimestamp}, {"coin", TypeString}, {"crossed", TypeBool}, {"tid", TypeInt64}})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := w.Write([]any{int64(1772366400123), "ETH", true, int64(42)}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Write([]any{int64(0), "a,b", false, int64(-1)}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := buf.String(); got != "time,coin,crossed,tid\n"+
+		"2026-03-01T12:00:00.123Z,ETH,true,42\n"+
+		"1970-01-01T00:00:00.000Z,\"a,b\",false,-1\n" {
+		t.Errorf("String() = %q, want %q", got, "time,coin,crossed,tid\n"+
+			"2026-03-01T12:00:00.123Z,ETH,true,42\n"+
+			"1970-01-01T00:00:00.000Z,\"a,b\",false,-1\n")
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, "xlsx", []Column{{"coin", TypeString}})
+	if err == nil {
+		t.Error("NewWriter() error = nil, want error")
+	}
+	_, err = NewWriter(&bytes.Buffer{}, FormatCSV, nil)
+	if err == nil {
+		t.Error("NewWriter() error = nil, want error")
+	}
+
+	for _, format := range []Format{FormatCSV, FormatParquet} {
+		w, err := NewWriter(&bytes.Buffer{}, format, []Column{{"coin", TypeString}, {"tid", TypeInt64}})
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		for _, row := range [][]any{{"ETH"}, {"ETH", 1}, {1, int64(1)}} {
+			if err := w.Write(row); err == nil {
+				t.Errorf("%s Write(%v) error = nil, want error", format, row)
+			}
+		}
+	}
+}
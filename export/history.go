@@ -0,0 +1,209 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+)
+
+// Schemas of the exports. Columns are only ever appended, so files written by older
+// versions stay readable with the same column names.
+var (
+	FillSchema = []Column{
+		{"time", TypeTimestamp},
+		{"coin", TypeString},
+		{"side", TypeString},
+		{"dir", TypeString},
+		{"px", TypeDecimal},
+		{"sz", TypeDecimal},
+		{"start_position", TypeDecimal},
+		{"closed_pnl", TypeDecimal},
+		{"fee", TypeDecimal},
+		{"fee_token", TypeString},
+		{"builder_fee", TypeDecimal},
+		{"crossed", TypeBool},
+		{"liquidation", TypeBool},
+		{"oid", TypeInt64},
+		{"tid", TypeInt64},
+		{"hash", TypeString},
+	}
+	FundingSchema = []Column{
+		{"time", TypeTimestamp},
+		{"coin", TypeString},
+		{"usdc", TypeDecimal},
+		{"szi", TypeDecimal},
+		{"funding_rate", TypeDecimal},
+		{"hash", TypeString},
+	}
+	LedgerSchema = []Column{
+		{"time", TypeTimestamp},
+		{"type", TypeString},
+		{"usdc", TypeDecimal},
+		{"token", TypeString},
+		{"amount", TypeDecimal},
+		{"usdc_value", TypeDecimal},
+		{"fee", TypeDecimal},
+		{"native_token_fee", TypeDecimal},
+		{"user", TypeString},
+		{"destination", TypeString},
+		{"vault", TypeString},
+		{"nonce", TypeInt64},
+		{"hash", TypeString},
+	}
+	CandleSchema = []Column{
+		{"open_time", TypeTimestamp},
+		{"close_time", TypeTimestamp},
+		{"coin", TypeString},
+		{"interval", TypeString},
+		{"open", TypeDecimal},
+		{"high", TypeDecimal},
+		{"low", TypeDecimal},
+		{"close", TypeDecimal},
+		{"volume", TypeDecimal},
+		{"trades", TypeInt64},
+	}
+)
+
+// Exporter writes the history of accounts and markets fetched from info. The info
+// requests return a page of records from a start time; the pages are fetched until
+// the end of the range, so an export may take many requests.
+type Exporter struct {
+	info   client.Infoer
+	format Format
+}
+
+// NewExporter creates an exporter writing files in format
+func NewExporter(info client.Infoer, format Format) *Exporter {
+	return &Exporter{info: info, format: format}
+}
+
+// Fills writes the fills of user from start to end, in ms, oldest first, and returns
+// the number written. The exchange only serves the 10000 most recent fills.
+func (e *Exporter) Fills(w io.Writer, user string, start, end int64) (int, error) {
+	return export(e, w, FillSchema, start, end,
+		func(start int64) ([]types.Fill, error) {
+			return e.info.UserFillsByTime(user, start, &end, false)
+		},
+		func(f types.Fill) int64 { return f.Time },
+		func(f types.Fill) string { return strconv.FormatInt(f.Tid, 10) },
+		func(f types.Fill) []any {
+			var builderFee string
+			if f.BuilderFee != nil {
+				builderFee = *f.BuilderFee
+			}
+			return []any{f.Time, f.Coin, string(f.Side), string(f.Dir), f.Px, f.Sz, f.StartPosition, f.ClosedPnl,
+				f.Fee, f.FeeToken, builderFee, f.Crossed, f.Liquidation != nil, f.Oid, f.Tid, f.Hash}
+		})
+}
+
+// Funding writes the funding payments of user from start to end, see Fills
+func (e *Exporter) Funding(w io.Writer, user string, start, end int64) (int, error) {
+	return export(e, w, FundingSchema, start, end,
+		func(start int64) ([]types.UserFundingUpdate, error) {
+			return e.info.UserFundingPayments(user, start, &end)
+		},
+		func(u types.UserFundingUpdate) int64 { return u.Time },
+		func(u types.UserFundingUpdate) string { return u.Delta.Coin },
+		func(u types.UserFundingUpdate) []any {
+			return []any{u.Time, u.Delta.Coin, u.Delta.Usdc, u.Delta.Szi, u.Delta.FundingRate, u.Hash}
+		})
+}
+
+// Ledger writes the non-funding ledger updates of user from start to end, see Fills.
+// The columns not used by the type of an update are empty.
+func (e *Exporter) Ledger(w io.Writer, user string, start, end int64) (int, error) {
+	return export(e, w, LedgerSchema, start, end,
+		func(start int64) ([]types.NonFundingLedgerUpdate, error) {
+			return e.info.UserNonFundingLedgerUpdates(user, start, &end)
+		},
+		func(u types.NonFundingLedgerUpdate) int64 { return u.Time },
+		func(u types.NonFundingLedgerUpdate) string { return u.Hash + "/" + string(u.Delta.Type) },
+		func(u types.NonFundingLedgerUpdate) []any {
+			d := u.Delta
+			return []any{u.Time, string(d.Type), d.Usdc, d.Token, d.Amount, d.UsdcValue, d.Fee, d.NativeTokenFee,
+				d.User, d.Destination, d.Vault, d.Nonce, u.Hash}
+		})
+}
+
+// Candles writes the candles of coin at interval, e.g. "1h", opened from start to
+// end, see Fills
+func (e *Exporter) Candles(w io.Writer, coin, interval string, start, end int64) (int, error) {
+	return export(e, w, CandleSchema, start, end,
+		func(start int64) ([]types.Candle, error) {
+			return e.info.CandlesSnapshot(coin, interval, start, end)
+		},
+		func(c types.Candle) int64 { return c.T0 },
+		func(c types.Candle) string { return "" },
+		func(c types.Candle) []any {
+			return []any{c.T0, c.T, c.S, c.I, c.O, c.H, c.L, c.C, c.V, int64(c.N)}
+		})
+}
+
+// export writes the records of the pages of fetch from start to end as rows of schema
+func export[T any](e *Exporter, w io.Writer, schema []Column, start, end int64, fetch func(start int64) ([]T, error),
+	time func(T) int64, key func(T) string, row func(T) []any) (int, error) {
+	writer, err := NewWriter(w, e.format, schema)
+	if err != nil {
+		return 0, err
+	}
+	n, err := paginate(start, end, fetch, time, key, func(record T) error {
+		return writer.Write(row(record))
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, writer.Close()
+}
+
+// paginate emits the records from start to end of the pages of fetch, each holding
+// the records from a start time. The next page starts at the time of the last record,
+// as the page may have cut records of the same time; the records of that time seen
+// already, by time and key, are skipped. A page of only seen records skips to the
+// next time, and paging stops at an empty page or past end.
+func paginate[T any](start, end int64, fetch func(start int64) ([]T, error), time func(T) int64,
+	key func(T) string, emit func(T) error) (int, error) {
+	var n int
+	seen := make(map[string]bool)
+	for start <= end {
+		page, err := fetch(start)
+		if err != nil {
+			return n, fmt.Errorf("failed to fetch page from %d: %w", start, err)
+		}
+		if len(page) == 0 {
+			return n, nil
+		}
+		sort.SliceStable(page, func(i, j int) bool { return time(page[i]) < time(page[j]) })
+
+		last, fresh := start, 0
+		for _, record := range page {
+			t, k := time(record), key(record)
+			if t < start || seen[k] && t == last {
+				continue
+			}
+			if t > end {
+				return n, nil
+			}
+			if t > last {
+				last = t
+				seen = make(map[string]bool)
+			}
+			seen[k] = true
+			if err := emit(record); err != nil {
+				return n, err
+			}
+			n++
+			fresh++
+		}
+		if fresh == 0 {
+			start = last + 1
+			seen = make(map[string]bool)
+			continue
+		}
+		start = last
+	}
+	return n, nil
+}
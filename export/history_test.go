@@ -0,0 +1,210 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+)
+
+const user = "0x0000000000000000000000000000000000000001"
+
+// pages serves records from the startTime of the request, at most limit of them
+func pages[T any](records []T, time func(T) int64, limit int) hltest.InfoHandler {
+	return func(req map[string]any) (any, error) {
+		start, err := req["startTime"].(json.Number).Int64()
+		if err != nil {
+			return nil, err
+		}
+		page := []T{}
+		for _, r := range records {
+			if time(r) >= start && len(page) < limit {
+				page = append(page, r)
+			}
+		}
+		return page, nil
+	}
+}
+
+func newExporter(t *testing.T, format Format) (*Exporter, *hltest.Server) {
+	t.Helper()
+	srv := hltest.NewTestServer(t)
+	return NewExporter(srv.NewTestInfo(t), format), srv
+}
+
+func TestExportFills(t *testing.T) {
+	e, srv := newExporter(t, FormatCSV)
+	builderFee := "0.01"
+	var fills []types.Fill
+	// Three fills share each time, so pages of four cut them
+	for i := 0; i < 9; i++ {
+		fills = append(fills, types.Fill{Coin: "ETH", Time: int64(1000 + i/3), Tid: int64(i), Oid: 7, Side: types.SideBid,
+			Px: "2000.5", Sz: "0.1", Fee: "0.1", FeeToken: "USDC", Crossed: i%2 == 0, Hash: fmt.Sprintf("0x%d", i)})
+	}
+	fills[4].BuilderFee = &builderFee
+	srv.HandleInfo("userFillsByTime", pages(fills, func(f types.Fill) int64 { return f.Time }, 4))
+
+	var buf bytes.Buffer
+	n, err := e.Fills(&buf, user, 1000, 1001)
+	if err != nil {
+		t.Fatalf("Fills(&buf, user, 1000, 1001) error = %v", err)
+	}
+	if n != 6 {
+		t.Errorf("n = %v, want 6", n)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("len(lines) = %d, want 7", len(lines))
+	}
+	if lines[0] != "time,coin,side,dir,px,sz,start_position,closed_pnl,fee,fee_token,builder_fee,crossed,liquidation,oid,tid,hash" {
+		t.Errorf("lines[0] = %q, want %q", lines[0], "time,coin,side,dir,px,sz,start_position,closed_pnl,fee,fee_token,builder_fee,crossed,liquidation,oid,tid,hash")
+	}
+	if lines[1] != "1970-01-01T00:00:01.000Z,ETH,B,,2000.5,0.1,,,0.1,USDC,,true,false,7,0,0x0" {
+		t.Errorf("lines[1] = %q, want %q", lines[1], "1970-01-01T00:00:01.000Z,ETH,B,,2000.5,0.1,,,0.1,USDC,,true,false,7,0,0x0")
+	}
+	if lines[5] != "1970-01-01T00:00:01.001Z,ETH,B,,2000.5,0.1,,,0.1,USDC,0.01,true,false,7,4,0x4" {
+		t.Errorf("lines[5] = %q, want %q", lines[5], "1970-01-01T00:00:01.001Z,ETH,B,,2000.5,0.1,,,0.1,USDC,0.01,true,false,7,4,0x4")
+	}
+	for i, line := range lines[1:] {
+		if !strings.Contains(line, fmt.Sprintf(",7,%d,", i)) {
+			t.Errorf("line = %q, want containing %q", line, fmt.Sprintf(",7,%d,", i))
+		}
+	}
+}
+
+func TestExportFundingParquet(t *testing.T) {
+	e, srv := newExporter(t, FormatParquet)
+	var payments []types.UserFundingUpdate
+	for i := 0; i < 5; i++ {
+		for _, coin := range []string{"BTC", "ETH"} {
+			payments = append(payments, types.UserFundingUpdate{Time: int64(i) * 3600000, Hash: "0x0",
+				Delta: types.FundingDelta{Type: "funding", Coin: coin, Usdc: "-0.5", Szi: "1.5", FundingRate: "0.0000125"}})
+		}
+	}
+	srv.HandleInfo("userFunding", pages(payments, func(u types.UserFundingUpdate) int64 { return u.Time }, 3))
+
+	var buf bytes.Buffer
+	n, err := e.Funding(&buf, user, 0, 10*3600000)
+	if err != nil {
+		t.Fatalf("Funding() error = %v", err)
+	}
+	if n != 10 {
+		t.Errorf("n = %v, want 10", n)
+	}
+	f, columns, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readParquet() error = %v", err)
+	}
+	if got := f.NumRows(); got != 10 {
+		t.Errorf("NumRows() = %d, want 10", got)
+	}
+	if len(columns) != len(FundingSchema) {
+		t.Fatalf("len(columns) = %d, want %d", len(columns), len(FundingSchema))
+	}
+	if want := []any{"BTC", "ETH", "BTC", "ETH", "BTC", "ETH", "BTC", "ETH", "BTC", "ETH"}; !reflect.DeepEqual(columns[1], want) {
+		t.Errorf("columns[1] = %+v, want %+v", columns[1], want)
+	}
+	if columns[0][9] != int64(4*3600000) {
+		t.Errorf("columns[0][9] = %v, want int64(4*3600000)", columns[0][9])
+	}
+	if columns[4][0] != 0.0000125 {
+		t.Errorf("columns[4][0] = %v, want 0.0000125", columns[4][0])
+	}
+}
+
+func TestExportLedgerAndCandles(t *testing.T) {
+	e, srv := newExporter(t, FormatCSV)
+	srv.HandleInfo("userNonFundingLedgerUpdates", pages([]types.NonFundingLedgerUpdate{
+		{Time: 1000, Hash: "0xa", Delta: types.LedgerDelta{Type: types.LedgerDeposit, Usdc: "100.0"}},
+		{Time: 2000, Hash: "0xb", Delta: types.LedgerDelta{Type: types.LedgerWithdraw, Usdc: "50.0", Fee: "1.0", Nonce: 9}},
+	}, func(u types.NonFundingLedgerUpdate) int64 { return u.Time }, 500))
+	var buf bytes.Buffer
+	n, err := e.Ledger(&buf, user, 0, 5000)
+	if err != nil {
+		t.Fatalf("Ledger(&buf, user, 0, 5000) error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("n = %v, want 2", n)
+	}
+	if got := buf.String(); got != "time,type,usdc,token,amount,usdc_value,fee,native_token_fee,user,destination,vault,nonce,hash\n"+
+		"1970-01-01T00:00:01.000Z,deposit,100.0,,,,,,,,,0,0xa\n"+
+		"1970-01-01T00:00:02.000Z,withdraw,50.0,,,,1.0,,,,,9,0xb\n" {
+		t.Errorf("String() = %q, want %q", got, "time,type,usdc,token,amount,usdc_value,fee,native_token_fee,user,destination,vault,nonce,hash\n"+
+			"1970-01-01T00:00:01.000Z,deposit,100.0,,,,,,,,,0,0xa\n"+
+			"1970-01-01T00:00:02.000Z,withdraw,50.0,,,,1.0,,,,,9,0xb\n")
+	}
+
+	var candles []types.Candle
+	for i := int64(0); i < 5; i++ {
+		candles = append(candles, types.Candle{T0: i * 60000, T: i*60000 + 59999, S: "BTC", I: "1m", O: "1", H: "2", L: "0.5", C: "1.5", V: "10", N: 3})
+	}
+	srv.HandleInfo("candleSnapshot", func(req map[string]any) (any, error) {
+		r := req["req"].(map[string]any)
+		return pages(candles, func(c types.Candle) int64 { return c.T0 }, 2)(map[string]any{"startTime": r["startTime"]})
+	})
+	buf.Reset()
+	n, err = e.Candles(&buf, "BTC", "1m", 60000, 180000)
+	if err != nil {
+		t.Fatalf("Candles() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("n = %v, want 3", n)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("len(lines) = %d, want 4", len(lines))
+	}
+	if lines[0] != "open_time,close_time,coin,interval,open,high,low,close,volume,trades" {
+		t.Errorf("lines[0] = %q, want %q", lines[0], "open_time,close_time,coin,interval,open,high,low,close,volume,trades")
+	}
+	if lines[1] != "1970-01-01T00:01:00.000Z,1970-01-01T00:01:59.999Z,BTC,1m,1,2,0.5,1.5,10,3" {
+		t.Errorf("lines[1] = %q, want %q", lines[1], "1970-01-01T00:01:00.000Z,1970-01-01T00:01:59.999Z,BTC,1m,1,2,0.5,1.5,10,3")
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	e, srv := newExporter(t, FormatCSV)
+	srv.HandleInfo("userFillsByTime", func(map[string]any) (any, error) { return nil, fmt.Errorf("boom") })
+	_, err := e.Fills(&bytes.Buffer{}, user, 0, 1000)
+	if err == nil {
+		t.Error("Fills() error = nil, want error")
+	}
+
+	_, err = NewExporter(nil, "xlsx").Fills(&bytes.Buffer{}, user, 0, 1000)
+	if err == nil {
+		t.Error("Fills() error = nil, want error")
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	// Pages of two records, with a time of three records filling a whole page
+	times := []int64{1, 2, 2, 2, 3, 4}
+	var got []int64
+	n, err := paginate(0, 3, func(start int64) ([]int64, error) {
+		page := []int64{}
+		for _, tm := range times {
+			if tm >= start && len(page) < 2 {
+				page = append(page, tm)
+			}
+		}
+		return page, nil
+	}, func(tm int64) int64 { return tm }, func(int64) string { return "" }, func(tm int64) error {
+		got = append(got, tm)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("paginate() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("n = %v, want 3", n)
+	}
+	if want := []int64{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("got = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetGroupRows is the number of rows buffered in memory before a row group is
+// written
+const parquetGroupRows = 100_000
+
+// parquetWriter writes rows as an uncompressed Parquet file with parquet-go. Decimal
+// columns are optional DOUBLE columns, null for the empty strings of the API; the
+// other columns are required.
+type parquetWriter struct {
+	schema []Column
+	w      *parquet.Writer
+	row    parquet.Row
+}
+
+func newParquetWriter(w io.Writer, schema []Column) *parquetWriter {
+	return &parquetWriter{
+		schema: schema,
+		w:      parquet.NewWriter(w, parquetSchema(schema), parquet.MaxRowsPerRowGroup(parquetGroupRows)),
+		row:    make(parquet.Row, len(schema)),
+	}
+}
+
+// parquetSchema returns the Parquet schema of the columns of schema, in their order.
+// It is derived from a struct type, as the fields of a parquet.Group are sorted by
+// name.
+func parquetSchema(schema []Column) *parquet.Schema {
+	fields := make([]reflect.StructField, len(schema))
+	for i, column := range schema {
+		var typ reflect.Type
+		tag := column.Name
+		switch column.Type {
+		case TypeString:
+			typ = reflect.TypeFor[string]()
+		case TypeInt64:
+			typ = reflect.TypeFor[int64]()
+		case TypeBool:
+			typ = reflect.TypeFor[bool]()
+		case TypeTimestamp:
+			typ, tag = reflect.TypeFor[int64](), tag+",timestamp(millisecond)"
+		case TypeDecimal:
+			typ, tag = reflect.TypeFor[*float64](), tag+",optional"
+		}
+		fields[i] = reflect.StructField{
+			Name: "F" + strconv.Itoa(i),
+			Type: typ,
+			Tag:  reflect.StructTag(`parquet:"` + tag + `"`),
+		}
+	}
+	return parquet.NewSchema("schema", parquet.SchemaOf(reflect.New(reflect.StructOf(fields)).Interface()))
+}
+
+func (p *parquetWriter) Write(row []any) error {
+	if err := checkRow(p.schema, row); err != nil {
+		return err
+	}
+	for i, column := range p.schema {
+		switch s, _ := row[i].(string); {
+		case column.Type != TypeDecimal:
+			p.row[i] = parquet.ValueOf(row[i]).Level(0, 0, i)
+		case s == "":
+			p.row[i] = parquet.NullValue().Level(0, 0, i)
+		default:
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return fmt.Errorf("invalid decimal %q for column %s: %w", s, column.Name, err)
+			}
+			p.row[i] = parquet.ValueOf(f).Level(0, 1, i)
+		}
+	}
+	if _, err := p.w.WriteRows([]parquet.Row{p.row}); err != nil {
+		return fmt.Errorf("failed to write parquet row: %w", err)
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.w.Close(); err != nil {
+		return fmt.Errorf("failed to write parquet: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// readParquet opens a Parquet file with parquet-go and returns the values of each
+// column: int64, string, bool or float64, nil for nulls
+func readParquet(data []byte) (*parquet.File, [][]any, error) {
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make([][]any, len(f.Schema().Fields()))
+	for _, group := range f.RowGroups() {
+		rows := group.Rows()
+		buf := make([]parquet.Row, 16)
+		for {
+			n, err := rows.ReadRows(buf)
+			for _, row := range buf[:n] {
+				for _, value := range row {
+					v, err := columnValue(value)
+					if err != nil {
+						rows.Close()
+						return nil, nil, err
+					}
+					columns[value.Column()] = append(columns[value.Column()], v)
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return f, columns, nil
+}
+
+func columnValue(value parquet.Value) (any, error) {
+	if value.IsNull() {
+		return nil, nil
+	}
+	switch value.Kind() {
+	case parquet.Boolean:
+		return value.Boolean(), nil
+	case parquet.Int64:
+		return value.Int64(), nil
+	case parquet.Double:
+		return value.Double(), nil
+	case parquet.ByteArray:
+		return string(value.ByteArray()), nil
+	}
+	return nil, fmt.Errorf("unexpected kind %s of column %d", value.Kind(), value.Column())
+}
+
+func TestParquetWriter(t *testing.T) {
+	schema := []Column{{"time", TypeTimestamp}, {"coin", TypeString}, {"crossed", TypeBool}, {"tid", TypeInt64}, {"px", TypeDecimal}}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatParquet, schema)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	var rows [][]any
+	for i := 0; i < 20; i++ {
+		px := fmt.Sprintf("%d.5", 2000+i)
+		if i%4 == 0 {
+			px = ""
+		}
+		rows = append(rows, []any{int64(1700000000000 + i), fmt.Sprintf("coin-%d", i), i%3 == 0, int64(-i), px})
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write(row) error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, columns, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readParquet() error = %v", err)
+	}
+	if got := f.NumRows(); got != 20 {
+		t.Errorf("NumRows() = %d, want 20", got)
+	}
+	tests := []struct {
+		name     string
+		kind     parquet.Kind
+		logical  string
+		optional bool
+	}{
+		{"time", parquet.Int64, "TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS)", false},
+		{"coin", parquet.ByteArray, "STRING", false},
+		{"crossed", parquet.Boolean, "", false},
+		{"tid", parquet.Int64, "INT(64,true)", false},
+		{"px", parquet.Double, "", true},
+	}
+	fields := f.Schema().Fields()
+	if len(fields) != len(tests) {
+		t.Fatalf("len(fields) = %d, want %d", len(fields), len(tests))
+	}
+	for i, tt := range tests {
+		field := fields[i]
+		var logical string
+		if lt := field.Type().LogicalType(); lt != nil {
+			logical = lt.String()
+		}
+		if field.Name() != tt.name || field.Type().Kind() != tt.kind || logical != tt.logical || field.Optional() != tt.optional {
+			t.Errorf("fields[%d] = %s %s %s optional=%v, want %s %s %s optional=%v", i,
+				field.Name(), field.Type().Kind(), logical, field.Optional(), tt.name, tt.kind, tt.logical, tt.optional)
+		}
+	}
+
+	for i, row := range rows {
+		want := append([]any(nil), row...)
+		if px := row[4].(string); px == "" {
+			want[4] = nil
+		} else {
+			want[4] = 2000 + float64(i) + 0.5
+		}
+		for j := range schema {
+			if !reflect.DeepEqual(columns[j][i], want[j]) {
+				t.Errorf("columns[%d][%d] = %v, want %v", j, i, columns[j][i], want[j])
+			}
+		}
+	}
+}
+
+func TestParquetWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatParquet, []Column{{"coin", TypeString}})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	f, columns, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readParquet() error = %v", err)
+	}
+	if got := f.NumRows(); got != 0 {
+		t.Errorf("NumRows() = %d, want 0", got)
+	}
+	if len(columns) != 1 || len(columns[0]) != 0 {
+		t.Errorf("columns = %+v, want one empty column", columns)
+	}
+}
+
+func TestParquetWriterInvalidDecimal(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, FormatParquet, []Column{{"px", TypeDecimal}})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := w.Write([]any{"1.2.3"}); err == nil {
+		t.Error("Write(1.2.3) error = nil, want error")
+	}
+}
@@ -0,0 +1,85 @@
+package funding
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Entry is a delta-neutral position opened by Enter
+type Entry struct {
+	Coin string
+	Spot string
+	// PerpSz and SpotSz are the filled sizes of the legs, signed
+	PerpSz float64
+	SpotSz float64
+	PerpPx float64
+	SpotPx float64
+}
+
+// Enter opens the position of o with market orders of sz on both legs: the perp first,
+// then the spot for the size the perp filled. If the spot leg does not fill, the perp
+// leg is closed again. slippage is that of MarketOpen, the default if zero.
+func Enter(trader client.Trader, o Opportunity, sz float64, slippage float64) (*Entry, error) {
+	sz = utils.RoundToLot(sz, o.SzDecimals)
+	if !(sz > 0) {
+		return nil, fmt.Errorf("invalid entry size: %v", sz)
+	}
+	entry := &Entry{Coin: o.Coin, Spot: o.Spot}
+
+	result, err := trader.MarketOpen(o.Coin, !o.ShortPerp, sz, nil, slippage, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open perp leg: %w", err)
+	}
+	perpSz, perpPx, err := filled(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open perp leg: %w", err)
+	}
+	entry.PerpSz, entry.PerpPx = signed(perpSz, !o.ShortPerp), perpPx
+
+	spotSz := utils.RoundToLot(perpSz, o.SzDecimals)
+	result, err = trader.MarketOpen(o.Spot, o.ShortPerp, spotSz, nil, slippage, nil, nil)
+	if err == nil {
+		spotSz, entry.SpotPx, err = filled(result)
+	}
+	if err != nil {
+		// Without the hedge, take the perp leg off
+		if _, closeErr := trader.MarketClose(o.Coin, &perpSz, nil, slippage, nil, nil); closeErr != nil {
+			return entry, fmt.Errorf("failed to open spot leg: %w, and to close perp leg: %v", err, closeErr)
+		}
+		entry.PerpSz = 0
+		return entry, fmt.Errorf("failed to open spot leg: %w", err)
+	}
+	entry.SpotSz = signed(spotSz, o.ShortPerp)
+	return entry, nil
+}
+
+// filled returns the size and price filled by the first order of result
+func filled(result *types.OrderResponse) (float64, float64, error) {
+	if len(result.Data.Statuses) == 0 {
+		return 0, 0, fmt.Errorf("no order status")
+	}
+	status := result.Data.Statuses[0]
+	if status.Filled == nil {
+		return 0, 0, fmt.Errorf("order not filled: %s", status.Error)
+	}
+	sz, err := strconv.ParseFloat(status.Filled.TotalSz, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse filled size: %w", err)
+	}
+	px, err := strconv.ParseFloat(status.Filled.AvgPx, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse fill price: %w", err)
+	}
+	return sz, px, nil
+}
+
+func signed(sz float64, isBuy bool) float64 {
+	if isBuy {
+		return sz
+	}
+	return -sz
+}
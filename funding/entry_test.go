@@ -0,0 +1,72 @@
+package funding
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dwdwow/hl-go/backtest"
+	"github.com/dwdwow/hl-go/paper"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+func newExchange(views ...ws.MarketView) *paper.Exchange {
+	exchange := paper.New(backtest.Config{InitialBalance: 100000})
+	for _, v := range views {
+		exchange.Update(v)
+	}
+	return exchange
+}
+
+func TestEnter(t *testing.T) {
+	exchange := newExchange(
+		ws.MarketView{Coin: "BTC", Bid: ws.WsLevel{Px: 60050}, Ask: ws.WsLevel{Px: 60070}, Mid: 60060},
+		ws.MarketView{Coin: "@1", Bid: ws.WsLevel{Px: 59990}, Ask: ws.WsLevel{Px: 60010}, Mid: 60000},
+	)
+	o := Opportunity{Coin: "BTC", Spot: "@1", SzDecimals: 5, ShortPerp: true}
+
+	entry, err := Enter(exchange, o, 0.100004, 0)
+	if err != nil {
+		t.Fatalf("Enter(exchange, o, 0.100004, 0) error = %v", err)
+	}
+	if want := (&Entry{Coin: "BTC", Spot: "@1", PerpSz: -0.1, SpotSz: 0.1, PerpPx: 60050, SpotPx: 60010}); !reflect.DeepEqual(entry, want) {
+		t.Errorf("entry = %+v, want %+v", entry, want)
+	}
+	if got := exchange.Position("BTC").Szi; got != -0.1 {
+		t.Errorf("exchange.Position(BTC).Szi = %v, want -0.1", got)
+	}
+	if got := exchange.Position("@1").Szi; got != 0.1 {
+		t.Errorf("exchange.Position(@1).Szi = %v, want 0.1", got)
+	}
+
+	_, err = Enter(exchange, o, 0.000001, 0)
+	if err == nil {
+		t.Error("Enter(exchange, o, 0.000001, 0) error = nil, want error")
+	}
+}
+
+func TestEnterUnwinds(t *testing.T) {
+	exchange := newExchange(ws.MarketView{Coin: "ETH", Bid: ws.WsLevel{Px: 1999}, Ask: ws.WsLevel{Px: 2001}, Mid: 2000})
+
+	// Without a spot market the perp leg is closed again
+	entry, err := Enter(exchange, Opportunity{Coin: "ETH", Spot: "@2", SzDecimals: 3}, 1, 0)
+	if err == nil {
+		t.Error("Enter() error = nil, want error")
+	}
+	if entry == nil {
+		t.Fatal("entry = nil")
+	}
+	if entry.PerpSz != 0 {
+		t.Errorf("entry.PerpSz = %v, want 0", entry.PerpSz)
+	}
+	if entry.PerpPx != 2001.0 {
+		t.Errorf("entry.PerpPx = %v, want 2001.0", entry.PerpPx)
+	}
+	if got := exchange.Position("ETH").Szi; got != 0 {
+		t.Errorf("exchange.Position(ETH).Szi = %v, want 0", got)
+	}
+
+	_, err = Enter(exchange, Opportunity{Coin: "BTC", Spot: "@1", SzDecimals: 5}, 1, 0)
+	if err == nil {
+		t.Error("Enter() error = nil, want error")
+	}
+}
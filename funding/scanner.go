@@ -0,0 +1,248 @@
+// Package funding finds funding carry trades: a Scanner ranks the perps by the carry
+// of a delta-neutral position against their spot pair, from the predicted funding
+// rates, the funding history and the spot and perp mids, and Enter opens both legs:
+//
+//	s := funding.NewScanner(info, funding.Config{Notional: 10000, CostBps: 25})
+//	opportunities, err := s.Scan()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, o := range opportunities {
+//	    fmt.Printf("%s: %.1f%% a year, $%.2f a day\n", o.Coin, o.AnnualizedRate*100, o.ExpectedCarry)
+//	}
+//	entry, err := funding.Enter(exchange, opportunities[0], 1, 0)
+package funding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// HlVenue is the venue of the predicted Hyperliquid funding rates
+const HlVenue = "HlPerp"
+
+// HoursPerYear annualizes hourly rates
+const HoursPerYear = 24 * 365
+
+// VenueIntervalHours are the hours between the fundings of the venues of
+// PredictedFundings, to compare their rates per hour. Hyperliquid pays funding every
+// hour.
+var VenueIntervalHours = map[string]float64{
+	HlVenue:     1,
+	"BinPerp":   8,
+	"BybitPerp": 8,
+}
+
+// Config configures a Scanner
+type Config struct {
+	// Notional is the USD value of each leg, for ExpectedCarry
+	Notional float64
+	// Horizon is how long the position is held, for ExpectedCarry; a day if zero
+	Horizon time.Duration
+	// CostBps is the cost of opening and closing both legs, in basis points of the
+	// notional, e.g. fees and half spreads
+	CostBps float64
+	// Lookback is the funding history averaged into HistoricalRate; a week if zero.
+	// Negative skips the history, which is one request per coin.
+	Lookback time.Duration
+	// MinAnnualizedRate drops the opportunities earning less, e.g. 0.1 for 10% a year
+	MinAnnualizedRate float64
+	// AllowSpotShort includes negative fundings, earned long the perp and short the spot,
+	// for accounts holding the spot tokens to sell
+	AllowSpotShort bool
+	// SpotTokens are the spot tokens hedging perps of another name, e.g. "UBTC" for
+	// "BTC". Other perps are hedged with the token of their name.
+	SpotTokens map[string]string
+	// Quote is the quote token of the spot pairs, USDC if empty
+	Quote string
+}
+
+// Opportunity is the carry of a perp hedged with its spot pair
+type Opportunity struct {
+	Coin string
+	// Spot is the name of the spot pair, e.g. "@107", for orders and mids
+	Spot string
+	// SzDecimals is the lot of both legs, the coarser of the perp and the spot token
+	SzDecimals int
+	// Rate is the predicted funding rate of the next hour, positive when longs pay
+	Rate            float64
+	NextFundingTime int64
+	// HistoricalRate is the mean hourly funding rate over the lookback, and
+	// PositiveShare the share of the positive ones
+	HistoricalRate float64
+	PositiveShare  float64
+	// VenueRates are the predicted rates of the other venues, per hour
+	VenueRates map[string]float64
+	PerpPx     float64
+	SpotPx     float64
+	// BasisBps is the premium of the perp over the spot
+	BasisBps float64
+	// ShortPerp is true when the carry is earned short the perp and long the spot
+	ShortPerp bool
+	// AnnualizedRate is the rate earned a year
+	AnnualizedRate float64
+	// ExpectedCarry is the USD earned on the notional over the horizon, net of the
+	// costs, at the predicted rate
+	ExpectedCarry float64
+}
+
+// Scanner ranks funding carry opportunities from the data of an Info
+type Scanner struct {
+	info   client.Infoer
+	config Config
+	clock  utils.Clock
+}
+
+// NewScanner creates a scanner reading the market with info
+func NewScanner(info client.Infoer, config Config) *Scanner {
+	if config.Horizon == 0 {
+		config.Horizon = 24 * time.Hour
+	}
+	if config.Lookback == 0 {
+		config.Lookback = 7 * 24 * time.Hour
+	}
+	if config.Quote == "" {
+		config.Quote = "USDC"
+	}
+	return &Scanner{info: info, config: config, clock: utils.SystemClock}
+}
+
+// SetClock sets the clock of the funding history, e.g. a utils.FakeClock in tests
+func (s *Scanner) SetClock(clock utils.Clock) {
+	s.clock = clock
+}
+
+// Scan returns the opportunities of the perps with a spot pair, best expected carry
+// first
+func (s *Scanner) Scan() ([]Opportunity, error) {
+	predicted, err := s.info.PredictedFundings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predicted fundings: %w", err)
+	}
+	meta, err := s.info.Meta("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meta: %w", err)
+	}
+	spotMeta, err := s.info.SpotMeta()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spot meta: %w", err)
+	}
+	mids, err := s.info.AllMids("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mids: %w", err)
+	}
+	szDecimals := make(map[string]int, len(meta.Universe))
+	for _, asset := range meta.Universe {
+		szDecimals[asset.Name] = asset.SzDecimals
+	}
+
+	var opportunities []Opportunity
+	for _, entry := range predicted {
+		o, ok := s.opportunity(entry, spotMeta, mids, szDecimals)
+		if !ok {
+			continue
+		}
+		if s.config.Lookback > 0 {
+			if err := s.history(&o); err != nil {
+				return nil, err
+			}
+		}
+		opportunities = append(opportunities, o)
+	}
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		return opportunities[i].ExpectedCarry > opportunities[j].ExpectedCarry
+	})
+	return opportunities, nil
+}
+
+// opportunity returns the opportunity of a perp, false if it has no spot pair, mids
+// or Hyperliquid rate, or earns too little
+func (s *Scanner) opportunity(entry types.PredictedFundingEntry, spotMeta *types.SpotMeta, mids map[string]string, szDecimals map[string]int) (Opportunity, bool) {
+	perpDecimals, ok := szDecimals[entry.Coin]
+	if !ok {
+		return Opportunity{}, false
+	}
+	token := entry.Coin
+	if t, ok := s.config.SpotTokens[entry.Coin]; ok {
+		token = t
+	}
+	base, ok := spotMeta.FindToken(token)
+	if !ok {
+		return Opportunity{}, false
+	}
+	pair, ok := spotMeta.FindPair(token, s.config.Quote)
+	if !ok {
+		return Opportunity{}, false
+	}
+	perpPx, _ := strconv.ParseFloat(mids[entry.Coin], 64)
+	spotPx, _ := strconv.ParseFloat(mids[pair.Name], 64)
+	if !(perpPx > 0) || !(spotPx > 0) {
+		return Opportunity{}, false
+	}
+
+	o := Opportunity{
+		Coin:       entry.Coin,
+		Spot:       pair.Name,
+		SzDecimals: min(perpDecimals, base.SzDecimals),
+		VenueRates: make(map[string]float64),
+		PerpPx:     perpPx,
+		SpotPx:     spotPx,
+		BasisBps:   utils.FractionToBps((perpPx - spotPx) / spotPx),
+	}
+	found := false
+	for _, venue := range entry.Venues {
+		rate, err := strconv.ParseFloat(venue.Info.FundingRate, 64)
+		if err != nil {
+			continue
+		}
+		if venue.Venue == HlVenue {
+			o.Rate, o.NextFundingTime, found = rate, venue.Info.NextFundingTime, true
+		} else if hours, ok := VenueIntervalHours[venue.Venue]; ok {
+			o.VenueRates[venue.Venue] = rate / hours
+		}
+	}
+	if !found || o.Rate == 0 || (o.Rate < 0 && !s.config.AllowSpotShort) {
+		return Opportunity{}, false
+	}
+
+	o.ShortPerp = o.Rate > 0
+	o.AnnualizedRate = math.Abs(o.Rate) * HoursPerYear
+	o.ExpectedCarry = s.config.Notional * (math.Abs(o.Rate)*s.config.Horizon.Hours() - utils.BpsToFraction(s.config.CostBps))
+	if o.AnnualizedRate < s.config.MinAnnualizedRate {
+		return Opportunity{}, false
+	}
+	return o, true
+}
+
+// history sets the historical rate of o from its funding history over the lookback
+func (s *Scanner) history(o *Opportunity) error {
+	start := s.clock.Now().Add(-s.config.Lookback).UnixMilli()
+	records, err := s.info.FundingHistory(o.Coin, start, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get funding history of %s: %w", o.Coin, err)
+	}
+	var sum float64
+	var positive int
+	for _, r := range records {
+		rate, err := strconv.ParseFloat(r.Rate, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse funding rate of %s: %w", o.Coin, err)
+		}
+		sum += rate
+		if rate > 0 {
+			positive++
+		}
+	}
+	if len(records) > 0 {
+		o.HistoricalRate = sum / float64(len(records))
+		o.PositiveShare = float64(positive) / float64(len(records))
+	}
+	return nil
+}
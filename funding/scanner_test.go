@@ -0,0 +1,170 @@
+package funding
+
+import (
+	"maps"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newScanner returns a scanner of a server listing BTC, hedged with UBTC, and ETH,
+// with a positive funding for BTC and a negative one for ETH
+func newScanner(t *testing.T, config Config) (*Scanner, *hltest.Server) {
+	t.Helper()
+	srv := hltest.NewTestServer(t)
+	srv.SetSpotMeta(types.SpotMeta{
+		Universe: []types.SpotAssetInfo{
+			{Name: "@1", Tokens: [2]int{1, 0}, Index: 1},
+			{Name: "@2", Tokens: [2]int{2, 0}, Index: 2},
+		},
+		Tokens: []types.SpotTokenInfo{
+			{Name: "USDC", SzDecimals: 8, Index: 0},
+			{Name: "UBTC", SzDecimals: 5, Index: 1},
+			{Name: "ETH", SzDecimals: 3, Index: 2},
+		},
+	})
+	srv.SetInfo("allMids", map[string]string{"BTC": "60060", "@1": "60000", "ETH": "2000", "@2": "2002"})
+	srv.SetInfo("predictedFundings", []any{
+		[]any{"BTC", []any{
+			[]any{"BinPerp", map[string]any{"fundingRate": "0.0004", "nextFundingTime": 1772380800000}},
+			[]any{"HlPerp", map[string]any{"fundingRate": "0.0001", "nextFundingTime": 1772370000000}},
+		}},
+		[]any{"ETH", []any{
+			[]any{"HlPerp", map[string]any{"fundingRate": "-0.00005", "nextFundingTime": 1772370000000}},
+		}},
+		[]any{"SOL", []any{
+			[]any{"HlPerp", map[string]any{"fundingRate": "0.001", "nextFundingTime": 1772370000000}},
+		}},
+	})
+	srv.HandleInfo("fundingHistory", func(req map[string]any) (any, error) {
+		if req["coin"] == "BTC" {
+			return []types.FundingRecord{{Rate: "0.0001"}, {Rate: "0.0002"}, {Rate: "-0.00005"}, {Rate: "0.00015"}}, nil
+		}
+		return []types.FundingRecord{{Rate: "-0.0001"}}, nil
+	})
+	info := srv.NewTestInfo(t)
+
+	if config.SpotTokens == nil {
+		config.SpotTokens = map[string]string{"BTC": "UBTC"}
+	}
+	s := NewScanner(info, config)
+	s.SetClock(utils.NewFakeClock(now))
+	return s, srv
+}
+
+func TestScan(t *testing.T) {
+	s, _ := newScanner(t, Config{Notional: 10000, CostBps: 10})
+	opportunities, err := s.Scan()
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// The negative funding of ETH needs a spot short, and SOL has no spot pair
+	if len(opportunities) != 1 {
+		t.Fatalf("len(opportunities) = %d, want 1", len(opportunities))
+	}
+	o := opportunities[0]
+	if o.Coin != "BTC" {
+		t.Errorf("o.Coin = %q, want %q", o.Coin, "BTC")
+	}
+	if o.Spot != "@1" {
+		t.Errorf("o.Spot = %q, want %q", o.Spot, "@1")
+	}
+	if o.SzDecimals != 5 {
+		t.Errorf("o.SzDecimals = %v, want 5", o.SzDecimals)
+	}
+	if o.Rate != 0.0001 {
+		t.Errorf("o.Rate = %v, want 0.0001", o.Rate)
+	}
+	if o.NextFundingTime != int64(1772370000000) {
+		t.Errorf("o.NextFundingTime = %v, want int64(1772370000000)", o.NextFundingTime)
+	}
+	if want := map[string]float64{"BinPerp": 0.00005}; !maps.Equal(o.VenueRates, want) {
+		t.Errorf("o.VenueRates = %+v, want %+v", o.VenueRates, want)
+	}
+	if math.Abs(o.BasisBps-10) > 1e-9 {
+		t.Errorf("o.BasisBps = %v, want %v", o.BasisBps, 10)
+	}
+	if !o.ShortPerp {
+		t.Error("o.ShortPerp = false")
+	}
+	if math.Abs(o.AnnualizedRate-0.876) > 1e-9 {
+		t.Errorf("o.AnnualizedRate = %v, want %v", o.AnnualizedRate, 0.876)
+	}
+	if math.Abs(o.ExpectedCarry-14) > 1e-9 {
+		t.Errorf("o.ExpectedCarry = %v, want %v", o.ExpectedCarry, 14)
+	}
+	if math.Abs(o.HistoricalRate-0.0001) > 1e-12 {
+		t.Errorf("o.HistoricalRate = %v, want %v", o.HistoricalRate, 0.0001)
+	}
+	if o.PositiveShare != 0.75 {
+		t.Errorf("o.PositiveShare = %v, want 0.75", o.PositiveShare)
+	}
+}
+
+func TestScanOptions(t *testing.T) {
+	s, srv := newScanner(t, Config{Notional: 10000, CostBps: 10, AllowSpotShort: true, Lookback: -1})
+	opportunities, err := s.Scan()
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(opportunities) != 2 {
+		t.Fatalf("len(opportunities) = %d, want 2", len(opportunities))
+	}
+	if opportunities[0].Coin != "BTC" {
+		t.Errorf("opportunities[0].Coin = %q, want %q", opportunities[0].Coin, "BTC")
+	}
+	eth := opportunities[1]
+	if eth.Coin != "ETH" {
+		t.Errorf("eth.Coin = %q, want %q", eth.Coin, "ETH")
+	}
+	if eth.ShortPerp {
+		t.Error("eth.ShortPerp = true")
+	}
+	if eth.SzDecimals != 3 {
+		t.Errorf("eth.SzDecimals = %v, want 3", eth.SzDecimals)
+	}
+	if math.Abs(eth.ExpectedCarry-2) > 1e-9 {
+		t.Errorf("eth.ExpectedCarry = %v, want %v", eth.ExpectedCarry, 2)
+	}
+	if math.Abs(eth.BasisBps-(-9.99)) > 1e-3 {
+		t.Errorf("eth.BasisBps = %v, want %v", eth.BasisBps, -9.99)
+	}
+	if eth.HistoricalRate != 0 {
+		t.Errorf("eth.HistoricalRate = %v, want 0", eth.HistoricalRate)
+	}
+	for _, r := range srv.Requests() {
+		if strings.Contains(string(r.Body), "fundingHistory") {
+			t.Errorf("request %s fetched the funding history", r.Body)
+		}
+	}
+
+	s, _ = newScanner(t, Config{AllowSpotShort: true, MinAnnualizedRate: 0.5, Lookback: -1})
+	opportunities, err = s.Scan()
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(opportunities) != 1 {
+		t.Fatalf("len(opportunities) = %d, want 1", len(opportunities))
+	}
+	if opportunities[0].Coin != "BTC" {
+		t.Errorf("opportunities[0].Coin = %q, want %q", opportunities[0].Coin, "BTC")
+	}
+
+	// Without the alias, BTC has no spot pair
+	s, _ = newScanner(t, Config{SpotTokens: map[string]string{}})
+	opportunities, err = s.Scan()
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(opportunities) != 0 {
+		t.Errorf("opportunities = %+v, want empty", opportunities)
+	}
+}
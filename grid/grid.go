@@ -0,0 +1,431 @@
+// Package grid implements grid trading: resting buy orders on the price levels of a
+// range below the price and sell orders above it, each fill replaced by an order on
+// the opposite side one level away, so every oscillation of the price between two
+// levels earns their difference:
+//
+//	g, err := grid.New(exchange, grid.Config{
+//	    Coin: "ETH", Lower: 1800, Upper: 2200, Levels: 21, Size: 0.1, SzDecimals: 4,
+//	    Rebalance: grid.RebalanceRecenter,
+//	}, grid.NewFileStore("eth-grid.json"))
+//	err = g.Start(mid)
+//	for {
+//	    update, err := userFills.Read()
+//	    ...
+//	    if update.IsSnapshot == nil || !*update.IsSnapshot {
+//	        for _, fill := range update.Fills {
+//	            err = g.OnFill(fill)
+//	        }
+//	    }
+//	}
+//
+// The state of the grid is saved to its Store after every change. After a restart, New
+// resumes the saved grid and Sync reconciles it with the open orders of the account.
+// The sell orders above the price open shorts on perps, and need a balance of the
+// base token on spot.
+package grid
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Spacing is how the levels of a grid are spread over its range
+type Spacing string
+
+const (
+	// SpacingArithmetic spaces the levels by the same price difference (the default)
+	SpacingArithmetic Spacing = "arithmetic"
+	// SpacingGeometric spaces the levels by the same price ratio
+	SpacingGeometric Spacing = "geometric"
+)
+
+// Rebalance is what a grid does when the price leaves its range
+type Rebalance string
+
+const (
+	// RebalanceNone keeps the grid, to wait for the price to return (the default)
+	RebalanceNone Rebalance = "none"
+	// RebalanceRecenter replaces the grid with one of the same width centered on the price
+	RebalanceRecenter Rebalance = "recenter"
+	// RebalanceStop cancels the orders of the grid and stops it
+	RebalanceStop Rebalance = "stop"
+)
+
+// Config describes a grid
+type Config struct {
+	Coin  string  `json:"coin"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	// Levels is the number of prices from Lower to Upper, at least 2
+	Levels int `json:"levels"`
+	// Size is the size of the order of each level
+	Size      float64   `json:"size"`
+	Spacing   Spacing   `json:"spacing,omitempty"`
+	Rebalance Rebalance `json:"rebalance,omitempty"`
+	// SzDecimals and IsSpot round the prices and size to the tick and lot of the coin
+	SzDecimals int  `json:"szDecimals"`
+	IsSpot     bool `json:"isSpot,omitempty"`
+}
+
+// Validate checks that the config describes a grid of distinct prices
+func (c Config) Validate() error {
+	switch {
+	case c.Coin == "":
+		return errors.New("grid has no coin")
+	case !(c.Lower > 0) || !(c.Upper > c.Lower):
+		return fmt.Errorf("invalid grid range: %v to %v", c.Lower, c.Upper)
+	case c.Levels < 2:
+		return fmt.Errorf("grid needs at least 2 levels, got %d", c.Levels)
+	case !(utils.RoundToLot(c.Size, c.SzDecimals) > 0):
+		return fmt.Errorf("invalid grid size: %v", c.Size)
+	}
+	switch c.Spacing {
+	case "", SpacingArithmetic, SpacingGeometric:
+	default:
+		return fmt.Errorf("unknown grid spacing: %s", c.Spacing)
+	}
+	switch c.Rebalance {
+	case "", RebalanceNone, RebalanceRecenter, RebalanceStop:
+	default:
+		return fmt.Errorf("unknown grid rebalance: %s", c.Rebalance)
+	}
+	prices := c.Prices()
+	for i := 1; i < len(prices); i++ {
+		if prices[i] <= prices[i-1] {
+			return fmt.Errorf("grid levels %v and %v round to the same price", i-1, i)
+		}
+	}
+	return nil
+}
+
+// Prices returns the prices of the levels, rounded to the tick of the coin, lowest first
+func (c Config) Prices() []float64 {
+	prices := make([]float64, c.Levels)
+	for i := range prices {
+		f := float64(i) / float64(c.Levels-1)
+		px := c.Lower + f*(c.Upper-c.Lower)
+		if c.Spacing == SpacingGeometric {
+			px = c.Lower * math.Pow(c.Upper/c.Lower, f)
+		}
+		prices[i] = utils.RoundToTick(px, c.SzDecimals, c.IsSpot)
+	}
+	return prices
+}
+
+// LevelOrder is an order of a grid
+type LevelOrder struct {
+	Level int     `json:"level"`
+	Oid   int     `json:"oid"`
+	IsBuy bool    `json:"isBuy"`
+	Px    float64 `json:"px"`
+	Sz    float64 `json:"sz"`
+	// Filled is the size filled so far
+	Filled float64 `json:"filled,omitempty"`
+	// Exit is true for the orders placed on the fill of an entry, one level away, whose
+	// fill completes a round trip
+	Exit bool `json:"exit,omitempty"`
+}
+
+// State is the persisted state of a grid
+type State struct {
+	// Config is the config of the grid, with the range it was recentered to
+	Config Config       `json:"config"`
+	Orders []LevelOrder `json:"orders"`
+	// RoundTrips is the number of filled exits, and Profit their gross profit
+	RoundTrips int     `json:"roundTrips"`
+	Profit     float64 `json:"profit"`
+	Stopped    bool    `json:"stopped,omitempty"`
+}
+
+// Grid runs a grid of orders with a trader. Safe for concurrent use.
+type Grid struct {
+	trader client.Trader
+	store  Store
+
+	mu     sync.Mutex
+	state  State
+	prices []float64
+}
+
+// New creates the grid of config, or resumes the grid of the same coin saved in store.
+// A resumed grid keeps its saved config, since it may have been recentered. store may
+// be nil to keep the state in memory only.
+func New(trader client.Trader, config Config, store Store) (*Grid, error) {
+	g := &Grid{trader: trader, store: store, state: State{Config: config}}
+	if store != nil {
+		saved, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil && saved.Config.Coin == config.Coin {
+			g.state = *saved
+		}
+	}
+	if err := g.state.Config.Validate(); err != nil {
+		return nil, err
+	}
+	g.prices = g.state.Config.Prices()
+	return g, nil
+}
+
+// State returns a copy of the state of the grid
+func (g *Grid) State() State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	state := g.state
+	state.Orders = append([]LevelOrder(nil), g.state.Orders...)
+	return state
+}
+
+// Start places the orders of the grid around mid: buys on the levels below the level
+// nearest to mid and sells above it, leaving that level empty. A resumed grid that has
+// orders is left as it is.
+func (g *Grid) Start(mid float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.state.Stopped {
+		return errors.New("grid is stopped")
+	}
+	if len(g.state.Orders) > 0 {
+		return nil
+	}
+	return errors.Join(g.start(mid), g.save())
+}
+
+func (g *Grid) start(mid float64) error {
+	center := 0
+	for i, px := range g.prices {
+		if math.Abs(px-mid) < math.Abs(g.prices[center]-mid) {
+			center = i
+		}
+	}
+	var orders []LevelOrder
+	for i, px := range g.prices {
+		if i != center {
+			orders = append(orders, LevelOrder{Level: i, IsBuy: i < center, Px: px, Sz: g.size()})
+		}
+	}
+	return g.place(orders)
+}
+
+// Filled records a fill of sz of an order of the grid, e.g. from the userFills feed.
+// Once the order is filled, the opposite order is placed one level away. Fills of
+// other orders are ignored.
+func (g *Grid) Filled(oid int, sz float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i := g.find(oid)
+	if i < 0 {
+		return nil
+	}
+	order := &g.state.Orders[i]
+	order.Filled = utils.RoundToLot(order.Filled+sz, g.state.Config.SzDecimals)
+	var err error
+	if order.Filled >= order.Sz {
+		err = g.fill(i)
+	}
+	return errors.Join(err, g.save())
+}
+
+// OnFill records a fill of the userFills feed or of Info.UserFills, see Filled
+func (g *Grid) OnFill(fill types.Fill) error {
+	if fill.Coin != g.State().Config.Coin {
+		return nil
+	}
+	sz, err := fill.SzFloat()
+	if err != nil {
+		return fmt.Errorf("invalid size of fill %d: %w", fill.Tid, err)
+	}
+	return g.Filled(int(fill.Oid), sz)
+}
+
+// Sync reconciles the grid with the open orders of the account, e.g. after a restart:
+// orders of the grid that are no longer open are taken as filled, and open orders with
+// a smaller size as partially filled
+func (g *Grid) Sync(open []types.OpenOrder) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	remaining := make(map[int]float64)
+	for _, o := range open {
+		if o.Coin != g.state.Config.Coin {
+			continue
+		}
+		sz, err := strconv.ParseFloat(o.Sz, 64)
+		if err != nil {
+			return fmt.Errorf("invalid size of order %d: %w", o.Oid, err)
+		}
+		remaining[o.Oid] = sz
+	}
+
+	var errs []error
+	for _, order := range append([]LevelOrder(nil), g.state.Orders...) {
+		i := g.find(order.Oid)
+		if i < 0 {
+			continue
+		}
+		sz, ok := remaining[order.Oid]
+		if !ok {
+			errs = append(errs, g.fill(i))
+			continue
+		}
+		g.state.Orders[i].Filled = utils.RoundToLot(order.Sz-sz, g.state.Config.SzDecimals)
+	}
+	return errors.Join(append(errs, g.save())...)
+}
+
+// Update applies the rebalance rule of the grid when mid is outside of its range
+func (g *Grid) Update(mid float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	config := g.state.Config
+	if g.state.Stopped || (mid >= config.Lower && mid <= config.Upper) {
+		return nil
+	}
+
+	switch config.Rebalance {
+	case RebalanceStop:
+		g.state.Stopped = true
+		return errors.Join(g.cancelAll(), g.save())
+	case RebalanceRecenter:
+		if err := g.cancelAll(); err != nil {
+			return errors.Join(err, g.save())
+		}
+		if config.Spacing == SpacingGeometric {
+			ratio := math.Sqrt(config.Upper / config.Lower)
+			config.Lower, config.Upper = mid/ratio, mid*ratio
+		} else {
+			half := (config.Upper - config.Lower) / 2
+			config.Lower, config.Upper = mid-half, mid+half
+		}
+		if err := config.Validate(); err != nil {
+			g.state.Stopped = true
+			return errors.Join(fmt.Errorf("failed to recenter grid: %w", err), g.save())
+		}
+		g.state.Config = config
+		g.prices = config.Prices()
+		return errors.Join(g.start(mid), g.save())
+	}
+	return nil
+}
+
+// Stop cancels the orders of the grid and stops it
+func (g *Grid) Stop() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.state.Stopped = true
+	return errors.Join(g.cancelAll(), g.save())
+}
+
+// fill removes the filled order i and places its opposite one level away
+func (g *Grid) fill(i int) error {
+	order := g.state.Orders[i]
+	g.state.Orders = append(g.state.Orders[:i], g.state.Orders[i+1:]...)
+
+	level := order.Level + 1
+	if !order.IsBuy {
+		level = order.Level - 1
+	}
+	if order.Exit {
+		g.state.RoundTrips++
+		g.state.Profit += order.Sz * math.Abs(order.Px-g.prices[level])
+	}
+	if g.state.Stopped || level < 0 || level >= len(g.prices) || g.atLevel(level) {
+		return nil
+	}
+	return g.place([]LevelOrder{{Level: level, IsBuy: !order.IsBuy, Px: g.prices[level], Sz: order.Sz, Exit: !order.Exit}})
+}
+
+// place places orders in one action, recording those that rest. Orders filled at once
+// are replaced like any fill.
+func (g *Grid) place(orders []LevelOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	requests := make([]types.OrderRequest, len(orders))
+	for i, o := range orders {
+		requests[i] = types.OrderRequest{
+			Coin:      g.state.Config.Coin,
+			IsBuy:     o.IsBuy,
+			Sz:        o.Sz,
+			LimitPx:   o.Px,
+			OrderType: types.NewLimit(types.TifGtc),
+		}
+	}
+	result, err := g.trader.BulkOrders(requests, nil)
+	if err != nil {
+		return fmt.Errorf("failed to place grid orders: %w", err)
+	}
+
+	var errs []error
+	for i, status := range result.Data.Statuses {
+		if i >= len(orders) {
+			break
+		}
+		o := orders[i]
+		switch {
+		case status.Resting != nil:
+			o.Oid = status.Resting.Oid
+			g.state.Orders = append(g.state.Orders, o)
+		case status.Filled != nil:
+			o.Oid = status.Filled.Oid
+			o.Filled = o.Sz
+			g.state.Orders = append(g.state.Orders, o)
+			errs = append(errs, g.fill(len(g.state.Orders)-1))
+		default:
+			errs = append(errs, fmt.Errorf("grid order at %v rejected: %s", o.Px, status.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cancelAll cancels the orders of the grid
+func (g *Grid) cancelAll() error {
+	if len(g.state.Orders) == 0 {
+		return nil
+	}
+	cancels := make([]types.CancelRequest, len(g.state.Orders))
+	for i, o := range g.state.Orders {
+		cancels[i] = types.CancelRequest{Coin: g.state.Config.Coin, Oid: o.Oid}
+	}
+	if _, err := g.trader.BulkCancel(cancels); err != nil {
+		return fmt.Errorf("failed to cancel grid orders: %w", err)
+	}
+	g.state.Orders = nil
+	return nil
+}
+
+func (g *Grid) find(oid int) int {
+	for i, o := range g.state.Orders {
+		if o.Oid == oid {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Grid) atLevel(level int) bool {
+	for _, o := range g.state.Orders {
+		if o.Level == level {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Grid) size() float64 {
+	return utils.RoundToLot(g.state.Config.Size, g.state.Config.SzDecimals)
+}
+
+func (g *Grid) save() error {
+	if g.store == nil {
+		return nil
+	}
+	return g.store.Save(&g.state)
+}
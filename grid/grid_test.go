@@ -0,0 +1,291 @@
+package grid
+
+import (
+	"maps"
+	"math"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/dwdwow/hl-go/backtest"
+	"github.com/dwdwow/hl-go/paper"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+var testConfig = Config{Coin: "ETH", Lower: 1900, Upper: 2100, Levels: 5, Size: 0.1, SzDecimals: 2}
+
+// market runs a paper exchange whose fills are applied to a grid after each move
+type market struct {
+	t        *testing.T
+	exchange *paper.Exchange
+	fills    []backtest.Fill
+}
+
+func newMarket(t *testing.T, mid float64) *market {
+	m := &market{t: t, exchange: paper.New(backtest.Config{InitialBalance: 10000})}
+	m.exchange.SetFillHook(func(f backtest.Fill) { m.fills = append(m.fills, f) })
+	m.move(nil, mid)
+	return m
+}
+
+// move moves the book to a spread of 1 around mid and applies the fills to g
+func (m *market) move(g *Grid, mid float64) {
+	m.exchange.Update(ws.MarketView{Coin: "ETH", Bid: ws.WsLevel{Px: mid - 0.5}, Ask: ws.WsLevel{Px: mid + 0.5}, Mid: mid})
+	fills := m.fills
+	m.fills = nil
+	for _, f := range fills {
+		if err := g.Filled(f.Oid, f.Sz); err != nil {
+			m.t.Fatalf("Filled(f.Oid, f.Sz) error = %v", err)
+		}
+	}
+}
+
+func levelPrices(orders []LevelOrder) map[float64]bool {
+	prices := make(map[float64]bool)
+	for _, o := range orders {
+		prices[o.Px] = o.IsBuy
+	}
+	return prices
+}
+
+func TestGridRoundTrip(t *testing.T) {
+	m := newMarket(t, 2000)
+	g, err := New(m.exchange, testConfig, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := g.Start(2000); err != nil {
+		t.Fatalf("Start(2000) error = %v", err)
+	}
+	if got, want := levelPrices(g.State().Orders), map[float64]bool{1900: true, 1950: true, 2050: false, 2100: false}; !maps.Equal(got, want) {
+		t.Errorf("levelPrices(g.State().Orders) = %+v, want %+v", got, want)
+	}
+	if got := m.exchange.OpenOrders(); len(got) != 4 {
+		t.Errorf("len(OpenOrders()) = %d, want 4", len(got))
+	}
+
+	// The buy at 1950 fills and is replaced by a sell at 2000, whose fill completes a
+	// round trip and puts the buy back
+	m.move(g, 1949)
+	if got, want := levelPrices(g.State().Orders), map[float64]bool{1900: true, 2000: false, 2050: false, 2100: false}; !maps.Equal(got, want) {
+		t.Errorf("levelPrices(g.State().Orders) = %+v, want %+v", got, want)
+	}
+	m.move(g, 2001)
+	state := g.State()
+	if got, want := levelPrices(state.Orders), map[float64]bool{1900: true, 1950: true, 2050: false, 2100: false}; !maps.Equal(got, want) {
+		t.Errorf("levelPrices(state.Orders) = %+v, want %+v", got, want)
+	}
+	if state.RoundTrips != 1 {
+		t.Errorf("state.RoundTrips = %v, want 1", state.RoundTrips)
+	}
+	if math.Abs(state.Profit-5) > 1e-9 {
+		t.Errorf("state.Profit = %v, want %v", state.Profit, 5)
+	}
+	if got := m.exchange.OpenOrders(); len(got) != 4 {
+		t.Errorf("len(OpenOrders()) = %d, want 4", len(got))
+	}
+
+	if err := g.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if got := m.exchange.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+	if err := g.Start(2000); err == nil {
+		t.Error("Start(2000) error = nil, want error")
+	}
+}
+
+func TestGridPartialFills(t *testing.T) {
+	m := newMarket(t, 2000)
+	g, err := New(m.exchange, testConfig, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := g.Start(2000); err != nil {
+		t.Fatalf("Start(2000) error = %v", err)
+	}
+	oid := g.State().Orders[1].Oid
+
+	if err := g.Filled(oid, 0.04); err != nil {
+		t.Fatalf("Filled(oid, 0.04) error = %v", err)
+	}
+	if got := g.State().Orders; len(got) != 4 {
+		t.Errorf("len(g.State().Orders) = %d, want 4", len(got))
+	}
+	if got := g.State().Orders[1].Filled; got != 0.04 {
+		t.Errorf("g.State().Orders[1].Filled = %v, want 0.04", got)
+	}
+	if err := g.Filled(oid, 0.06); err != nil {
+		t.Fatalf("Filled(oid, 0.06) error = %v", err)
+	}
+	if got, want := levelPrices(g.State().Orders), map[float64]bool{1900: true, 2000: false, 2050: false, 2100: false}; !maps.Equal(got, want) {
+		t.Errorf("levelPrices(g.State().Orders) = %+v, want %+v", got, want)
+	}
+
+	// Fills of other orders are ignored
+	if err := g.OnFill(types.Fill{Coin: "ETH", Oid: 999, Sz: "1"}); err != nil {
+		t.Fatalf("OnFill() error = %v", err)
+	}
+	if err := g.OnFill(types.Fill{Coin: "BTC", Oid: int64(g.State().Orders[0].Oid), Sz: "1"}); err != nil {
+		t.Fatalf("OnFill() error = %v", err)
+	}
+	if got := g.State().Orders; len(got) != 4 {
+		t.Errorf("len(g.State().Orders) = %d, want 4", len(got))
+	}
+}
+
+func TestGridRebalance(t *testing.T) {
+	recenter := testConfig
+	recenter.Rebalance = RebalanceRecenter
+	m := newMarket(t, 2000)
+	g, err := New(m.exchange, recenter, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := g.Start(2000); err != nil {
+		t.Fatalf("Start(2000) error = %v", err)
+	}
+
+	if err := g.Update(2050); err != nil {
+		t.Fatalf("Update(2050) error = %v", err)
+	}
+	if got := g.State().Orders; len(got) != 4 {
+		t.Errorf("len(g.State().Orders) = %d, want 4", len(got))
+	}
+	m.move(g, 2300)
+	if err := g.Update(2300); err != nil {
+		t.Fatalf("Update(2300) error = %v", err)
+	}
+	state := g.State()
+	if state.Config.Lower != 2200.0 {
+		t.Errorf("state.Config.Lower = %v, want 2200.0", state.Config.Lower)
+	}
+	if state.Config.Upper != 2400.0 {
+		t.Errorf("state.Config.Upper = %v, want 2400.0", state.Config.Upper)
+	}
+	if got, want := levelPrices(state.Orders), map[float64]bool{2200: true, 2250: true, 2350: false, 2400: false}; !maps.Equal(got, want) {
+		t.Errorf("levelPrices(state.Orders) = %+v, want %+v", got, want)
+	}
+	if got := m.exchange.OpenOrders(); len(got) != 4 {
+		t.Errorf("len(OpenOrders()) = %d, want 4", len(got))
+	}
+
+	stop := testConfig
+	stop.Rebalance = RebalanceStop
+	m = newMarket(t, 2000)
+	g, err = New(m.exchange, stop, nil)
+	if err != nil {
+		t.Fatalf("New(m.exchange, stop, nil) error = %v", err)
+	}
+	if err := g.Start(2000); err != nil {
+		t.Fatalf("Start(2000) error = %v", err)
+	}
+	if err := g.Update(1800); err != nil {
+		t.Fatalf("Update(1800) error = %v", err)
+	}
+	if !g.State().Stopped {
+		t.Error("g.State().Stopped = false")
+	}
+	if got := m.exchange.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+}
+
+func TestGridResume(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "grid", "eth.json"))
+	m := newMarket(t, 2000)
+	g, err := New(m.exchange, testConfig, store)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := g.Start(2000); err != nil {
+		t.Fatalf("Start(2000) error = %v", err)
+	}
+	saved := g.State()
+
+	// A restart resumes the saved grid instead of placing a new one
+	changed := testConfig
+	changed.Levels = 9
+	g, err = New(m.exchange, changed, store)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := g.State(); !reflect.DeepEqual(got, saved) {
+		t.Errorf("State() = %+v, want %+v", got, saved)
+	}
+	if err := g.Start(2000); err != nil {
+		t.Fatalf("Start(2000) error = %v", err)
+	}
+	if got := m.exchange.OpenOrders(); len(got) != 4 {
+		t.Errorf("len(OpenOrders()) = %d, want 4", len(got))
+	}
+
+	// The buy at 1950 filled while the program was down
+	var open []types.OpenOrder
+	for _, o := range m.exchange.OpenOrders() {
+		if o.Request.LimitPx == 1950 {
+			continue
+		}
+		open = append(open, types.OpenOrder{Coin: "ETH", Oid: o.Oid, Sz: strconv.FormatFloat(o.Request.Sz, 'f', -1, 64)})
+	}
+	open[0].Sz = "0.07"
+	if err := g.Sync(open); err != nil {
+		t.Fatalf("Sync(open) error = %v", err)
+	}
+	state := g.State()
+	if got, want := levelPrices(state.Orders), map[float64]bool{1900: true, 2000: false, 2050: false, 2100: false}; !maps.Equal(got, want) {
+		t.Errorf("levelPrices(state.Orders) = %+v, want %+v", got, want)
+	}
+	if state.Orders[0].Filled != 0.03 {
+		t.Errorf("state.Orders[0].Filled = %v, want 0.03", state.Orders[0].Filled)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(*loaded, state) {
+		t.Errorf("*loaded = %+v, want %+v", *loaded, state)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		ok     bool
+	}{
+		{"valid", func(c *Config) {}, true},
+		{"geometric", func(c *Config) { c.Spacing = SpacingGeometric }, true},
+		{"no coin", func(c *Config) { c.Coin = "" }, false},
+		{"inverted range", func(c *Config) { c.Lower, c.Upper = 2100, 1900 }, false},
+		{"one level", func(c *Config) { c.Levels = 1 }, false},
+		{"size below lot", func(c *Config) { c.Size = 0.001 }, false},
+		{"levels below tick", func(c *Config) { c.Levels = 100000 }, false},
+		{"unknown rebalance", func(c *Config) { c.Rebalance = "sometimes" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testConfig
+			tt.modify(&c)
+			if err := c.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestConfigPrices(t *testing.T) {
+	if got, want := testConfig.Prices(), []float64{1900, 1950, 2000, 2050, 2100}; !slices.Equal(got, want) {
+		t.Errorf("Prices() = %+v, want %+v", got, want)
+	}
+
+	geometric := Config{Coin: "ETH", Lower: 1000, Upper: 4000, Levels: 3, Spacing: SpacingGeometric, SzDecimals: 2}
+	if got, want := geometric.Prices(), []float64{1000, 2000, 4000}; !slices.Equal(got, want) {
+		t.Errorf("Prices() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package grid
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Store persists the state of a grid across restarts
+type Store interface {
+	// Load returns the saved state, nil if there is none
+	Load() (*State, error)
+	Save(state *State) error
+}
+
+// FileStore keeps the state of a grid in a JSON file
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store of the state in the file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the state, nil if the file does not exist
+func (s *FileStore) Load() (*State, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grid state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse grid state: %w", err)
+	}
+	return &state, nil
+}
+
+// Save writes the state to a temporary file renamed over the file, so a crash never
+// leaves a partial state
+func (s *FileStore) Save(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal grid state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create grid state directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write grid state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write grid state: %w", err)
+	}
+	return nil
+}
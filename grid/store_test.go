@@ -0,0 +1,58 @@
+package grid
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "grid.json")
+	store := NewFileStore(path)
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if state != nil {
+		t.Errorf("state = %v, want nil", state)
+	}
+
+	saved := &State{
+		Config:     testConfig,
+		Orders:     []LevelOrder{{Level: 1, Oid: 7, IsBuy: true, Px: 1950, Sz: 0.1, Filled: 0.02}},
+		RoundTrips: 3,
+		Profit:     15,
+	}
+	if err := store.Save(saved); err != nil {
+		t.Fatalf("Save(saved) error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat(path) error = %v", err)
+	}
+	if got := info.Mode().Perm(); got != os.FileMode(0600) {
+		t.Errorf("Perm() = %v, want os.FileMode(0600)", got)
+	}
+	_, err = os.Stat(path + ".tmp")
+	if !os.IsNotExist(err) {
+		t.Error("IsNotExist(err) = false")
+	}
+
+	state, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(state, saved) {
+		t.Errorf("state = %+v, want %+v", state, saved)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	_, err = store.Load()
+	if err == nil {
+		t.Error("Load() error = nil, want error")
+	}
+}
@@ -0,0 +1,139 @@
+package hltest
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// restingOrder is an order on the book of the server
+type restingOrder struct {
+	oid        int
+	user       string
+	coin       string
+	isBuy      bool
+	px         float64
+	sz         float64
+	origSz     float64
+	reduceOnly bool
+	cloid      string
+	timestamp  int64
+}
+
+// book is the price-time priority order book of a coin
+type book struct {
+	bids []*restingOrder // best (highest) first
+	asks []*restingOrder // best (lowest) first
+}
+
+// side returns the resting orders on the side of isBuy
+func (b *book) side(isBuy bool) *[]*restingOrder {
+	if isBuy {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// crosses reports whether an order at px on the side of isBuy would match a resting order
+func (b *book) crosses(isBuy bool, px float64) bool {
+	opposite := *b.side(!isBuy)
+	if len(opposite) == 0 {
+		return false
+	}
+	if isBuy {
+		return opposite[0].px <= px
+	}
+	return opposite[0].px >= px
+}
+
+// insert rests order behind the orders at the same or a better price
+func (b *book) insert(order *restingOrder) {
+	orders := b.side(order.isBuy)
+	i := sort.Search(len(*orders), func(i int) bool {
+		if order.isBuy {
+			return (*orders)[i].px < order.px
+		}
+		return (*orders)[i].px > order.px
+	})
+	*orders = append(*orders, nil)
+	copy((*orders)[i+1:], (*orders)[i:])
+	(*orders)[i] = order
+}
+
+// remove takes the order with oid off the book
+func (b *book) remove(oid int) (*restingOrder, bool) {
+	for _, orders := range []*[]*restingOrder{&b.bids, &b.asks} {
+		for i, order := range *orders {
+			if order.oid == oid {
+				*orders = append((*orders)[:i], (*orders)[i+1:]...)
+				return order, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// match fills up to sz at px or better against the resting orders, best first,
+// calling fill for each match at the price of the resting order
+func (b *book) match(isBuy bool, px, sz float64, fill func(maker *restingOrder, sz float64)) float64 {
+	opposite := b.side(!isBuy)
+	for sz > epsilon && b.crosses(isBuy, px) {
+		maker := (*opposite)[0]
+		matched := math.Min(sz, maker.sz)
+		fill(maker, matched)
+		sz -= matched
+		maker.sz -= matched
+		if maker.sz <= epsilon {
+			*opposite = (*opposite)[1:]
+		}
+	}
+	return math.Max(sz, 0)
+}
+
+// snapshot returns the book aggregated by price, at most depth levels per side
+func (b *book) snapshot(coin string, depth int, time int64) types.L2BookData {
+	data := types.L2BookData{Coin: coin, Time: time}
+	for i, orders := range [][]*restingOrder{b.bids, b.asks} {
+		levels := []types.L2Level{}
+		for _, order := range orders {
+			if n := len(levels); n > 0 && levels[n-1].Px == formatFloat(order.px) {
+				sz, _ := strconv.ParseFloat(levels[n-1].Sz, 64)
+				levels[n-1].Sz = formatFloat(sz + order.sz)
+				levels[n-1].N++
+				continue
+			}
+			if len(levels) == depth {
+				break
+			}
+			levels = append(levels, types.L2Level{Px: formatFloat(order.px), Sz: formatFloat(order.sz), N: 1})
+		}
+		data.Levels[i] = levels
+	}
+	return data
+}
+
+// mid returns the mid price of the book, if it has orders on both sides
+func (b *book) mid() (float64, bool) {
+	if len(b.bids) == 0 || len(b.asks) == 0 {
+		return 0, false
+	}
+	return (b.bids[0].px + b.asks[0].px) / 2, true
+}
+
+// epsilon absorbs the float error of summed sizes
+const epsilon = 1e-12
+
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+// parsePositive parses a wire price or size, which must be positive
+func parsePositive(s string) (float64, bool) {
+	x, err := strconv.ParseFloat(s, 64)
+	if err != nil || x <= 0 || math.IsInf(x, 0) {
+		return 0, false
+	}
+	return x, true
+}
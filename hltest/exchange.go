@@ -0,0 +1,486 @@
+package hltest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Nonces must be within this window around the server time, like on the exchange
+const (
+	maxNonceAge  = 2 * 24 * time.Hour
+	maxNonceLead = 24 * time.Hour
+)
+
+// zeroAddress is the user of actions when signatures are not verified
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// errUnknownSigner is returned when no known account signed an action
+var errUnknownSigner = errors.New("unknown signer")
+
+// exchangePayload is the body of /exchange requests
+type exchangePayload struct {
+	Action       json.RawMessage `json:"action"`
+	Nonce        int64           `json:"nonce"`
+	Signature    types.Signature `json:"signature"`
+	VaultAddress *string         `json:"vaultAddress"`
+	ExpiresAfter *int64          `json:"expiresAfter"`
+}
+
+// exchangeResponse is the body of /exchange responses, whose response is an error
+// message with the err status
+type exchangeResponse struct {
+	Status   string `json:"status"`
+	Response any    `json:"response"`
+}
+
+func (s *Server) serveExchange(w http.ResponseWriter, body []byte) {
+	var payload exchangePayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "Failed to deserialize the JSON body into the target type", http.StatusUnprocessableEntity)
+		return
+	}
+	req := &ExchangeRequest{
+		Nonce:        payload.Nonce,
+		Signature:    payload.Signature,
+		VaultAddress: payload.VaultAddress,
+		ExpiresAfter: payload.ExpiresAfter,
+	}
+	if err := decodeJSON(payload.Action, &req.Action); err != nil || req.Action == nil {
+		http.Error(w, "Failed to deserialize the JSON body into the target type", http.StatusUnprocessableEntity)
+		return
+	}
+	req.ActionType, _ = req.Action["type"].(string)
+
+	if err := s.authenticate(req); err != nil {
+		writeJSON(w, exchangeResponse{Status: "err", Response: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	h, ok := s.actions[req.ActionType]
+	s.mu.Unlock()
+	if !ok {
+		h = s.execute
+	}
+	response, err := h(req)
+	if err != nil {
+		writeJSON(w, exchangeResponse{Status: "err", Response: err.Error()})
+		return
+	}
+	writeJSON(w, exchangeResponse{Status: "ok", Response: response})
+}
+
+// authenticate checks the expiry, signature and nonce of req and sets its signer and
+// user
+func (s *Server) authenticate(req *ExchangeRequest) error {
+	s.mu.Lock()
+	now := utils.TimestampMs(s.clock)
+	verify := s.verify
+	s.mu.Unlock()
+
+	if req.ExpiresAfter != nil && *req.ExpiresAfter < now {
+		return fmt.Errorf("Action expired at %d, server time is %d", *req.ExpiresAfter, now)
+	}
+	if req.Nonce < now-maxNonceAge.Milliseconds() || req.Nonce > now+maxNonceLead.Milliseconds() {
+		return fmt.Errorf("Invalid nonce: %d is too far from the server time %d", req.Nonce, now)
+	}
+
+	signer := ""
+	if verify {
+		var err error
+		if signer, err = s.recoverSigner(req); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := signer
+	if key == "" {
+		key = zeroAddress
+	}
+	if s.nonces[key] == nil {
+		s.nonces[key] = make(map[int64]bool)
+	}
+	if s.nonces[key][req.Nonce] {
+		return fmt.Errorf("Invalid nonce: duplicate nonce %d", req.Nonce)
+	}
+	s.nonces[key][req.Nonce] = true
+
+	req.Signer = signer
+	switch {
+	case req.VaultAddress != nil:
+		req.User = strings.ToLower(*req.VaultAddress)
+	case signer == "":
+		req.User = zeroAddress
+	case s.agents[signer] != "":
+		req.User = s.agents[signer]
+	default:
+		req.User = signer
+	}
+	return nil
+}
+
+// recoverSigner returns the lower-case address of the known account or agent that
+// signed req
+func (s *Server) recoverSigner(req *ExchangeRequest) (string, error) {
+	if !signatureValid(req.Signature) {
+		return "", fmt.Errorf("Invalid signature")
+	}
+	if req.ActionType == "multiSig" {
+		return "", fmt.Errorf("multiSig actions are not supported, see SetVerifySignatures")
+	}
+
+	known := func(address common.Address) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		a := strings.ToLower(address.Hex())
+		return s.users[a] || s.agents[a] != ""
+	}
+
+	var (
+		signer common.Address
+		err    error
+	)
+	if _, ok := req.Action["signatureChainId"]; ok {
+		signer, _, err = recoverUserSigner(req)
+		if err == nil && !known(signer) {
+			err = errUnknownSigner
+		}
+	} else {
+		signer, err = recoverL1Signer(req, s.Network(), known)
+	}
+	if errors.Is(err, errUnknownSigner) {
+		return "", fmt.Errorf("User or API Wallet %s does not exist.", strings.ToLower(signer.Hex()))
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(signer.Hex()), nil
+}
+
+// execute is the built-in behavior of actions
+func (s *Server) execute(req *ExchangeRequest) (any, error) {
+	switch req.ActionType {
+	case "order":
+		var action struct {
+			Orders []types.OrderWire `json:"orders"`
+		}
+		if err := remarshal(req.Action, &action); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		statuses := make([]types.OrderStatus, len(action.Orders))
+		for i, wire := range action.Orders {
+			statuses[i] = s.placeOrder(req.User, wire)
+		}
+		return map[string]any{"type": "order", "data": map[string]any{"statuses": statuses}}, nil
+
+	case "cancel", "cancelByCloid":
+		var action struct {
+			Cancels []struct {
+				A     *int   `json:"a"`
+				O     int    `json:"o"`
+				Asset *int   `json:"asset"`
+				Cloid string `json:"cloid"`
+			} `json:"cancels"`
+		}
+		if err := remarshal(req.Action, &action); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		statuses := make([]any, len(action.Cancels))
+		for i, c := range action.Cancels {
+			var order *restingOrder
+			asset := 0
+			if req.ActionType == "cancel" && c.A != nil {
+				asset, order = *c.A, s.orders[c.O]
+			} else if c.Asset != nil {
+				asset, order = *c.Asset, s.orderByCloid(req.User, c.Cloid)
+			}
+			if coin, ok := s.assetCoin(asset); !ok || order == nil || order.user != req.User || order.coin != coin {
+				statuses[i] = map[string]string{"error": fmt.Sprintf("Order was never placed, already canceled, or filled. asset=%d", asset)}
+				continue
+			}
+			s.cancelOrder(order)
+			statuses[i] = "success"
+		}
+		return map[string]any{"type": "cancel", "data": map[string]any{"statuses": statuses}}, nil
+
+	case "modify", "batchModify":
+		var action struct {
+			types.ModifyWire
+			Modifies []types.ModifyWire `json:"modifies"`
+		}
+		if err := remarshal(req.Action, &action); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if req.ActionType == "modify" {
+			if status := s.modifyOrder(req.User, action.ModifyWire); status.Error != "" {
+				return nil, errors.New(status.Error)
+			}
+			return map[string]any{"type": "default"}, nil
+		}
+		statuses := make([]types.OrderStatus, len(action.Modifies))
+		for i, modify := range action.Modifies {
+			statuses[i] = s.modifyOrder(req.User, modify)
+		}
+		return map[string]any{"type": "batchModify", "data": map[string]any{"statuses": statuses}}, nil
+
+	case "approveAgent":
+		agent, _ := req.Action["agentAddress"].(string)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if agent != "" {
+			s.agents[strings.ToLower(agent)] = req.User
+		}
+	}
+	return map[string]any{"type": "default"}, nil
+}
+
+// placeOrder executes a wire order of user. Must be called with mu held.
+func (s *Server) placeOrder(user string, wire types.OrderWire) types.OrderStatus {
+	coin, ok := s.assetCoin(wire.Asset)
+	if !ok {
+		return types.OrderStatus{Error: fmt.Sprintf("Unknown asset %d.", wire.Asset)}
+	}
+	if wire.OrderType.Limit == nil {
+		return types.OrderStatus{Error: "Trigger orders are not supported by the mock server."}
+	}
+	px, ok := parsePositive(wire.LimitPx)
+	if !ok {
+		return types.OrderStatus{Error: "Order has invalid price."}
+	}
+	sz, ok := parsePositive(wire.Sz)
+	if !ok {
+		return types.OrderStatus{Error: "Order has invalid size."}
+	}
+
+	spot := constants.IsSpotAsset(wire.Asset)
+	if wire.ReduceOnly {
+		current := 0.0
+		if p := s.positions[user][coin]; p != nil && !spot {
+			current = p.szi
+		}
+		if current == 0 || (current > 0) == wire.IsBuy {
+			return types.OrderStatus{Error: "Reduce only order would increase position."}
+		}
+		sz = min(sz, abs(current))
+	}
+
+	b := s.books[coin]
+	if b == nil {
+		b = &book{}
+		s.books[coin] = b
+	}
+	tif := wire.OrderType.Limit.Tif
+	if tif == types.TifAlo && b.crosses(wire.IsBuy, px) {
+		return types.OrderStatus{Error: fmt.Sprintf("Post only order would have immediately matched. asset=%d", wire.Asset)}
+	}
+
+	now := utils.TimestampMs(s.clock)
+	oid := s.nextOid
+	s.nextOid++
+
+	var filled, notional float64
+	remaining := b.match(wire.IsBuy, px, sz, func(maker *restingOrder, matched float64) {
+		tid := s.nextTid
+		s.nextTid++
+		s.recordFill(maker.user, coin, spot, !wire.IsBuy, maker.px, matched, maker.oid, tid, false, now)
+		s.recordFill(user, coin, spot, wire.IsBuy, maker.px, matched, oid, tid, true, now)
+		if maker.sz-matched <= epsilon {
+			delete(s.orders, maker.oid)
+		}
+		filled += matched
+		notional += matched * maker.px
+	})
+
+	if remaining > epsilon && tif != types.TifIoc {
+		order := &restingOrder{
+			oid:        oid,
+			user:       user,
+			coin:       coin,
+			isBuy:      wire.IsBuy,
+			px:         px,
+			sz:         remaining,
+			origSz:     sz,
+			reduceOnly: wire.ReduceOnly,
+			timestamp:  now,
+		}
+		if wire.Cloid != nil {
+			order.cloid = strings.ToLower(*wire.Cloid)
+		}
+		b.insert(order)
+		s.orders[oid] = order
+		return types.OrderStatus{Resting: &types.RestingOrder{Oid: oid}}
+	}
+	if filled == 0 {
+		return types.OrderStatus{Error: fmt.Sprintf("Order could not immediately match against any resting orders. asset=%d", wire.Asset)}
+	}
+	return types.OrderStatus{Filled: &types.FilledOrder{TotalSz: formatFloat(filled), AvgPx: formatFloat(notional / filled), Oid: oid}}
+}
+
+// modifyOrder replaces an open order of user by another. Must be called with mu held.
+func (s *Server) modifyOrder(user string, modify types.ModifyWire) types.OrderStatus {
+	var order *restingOrder
+	switch oid := modify.Oid.(type) {
+	case float64:
+		order = s.orders[int(oid)]
+	case string:
+		order = s.orderByCloid(user, oid)
+	}
+	if order == nil || order.user != user {
+		return types.OrderStatus{Error: "Cannot modify canceled or filled order"}
+	}
+	s.cancelOrder(order)
+	return s.placeOrder(user, modify.Order)
+}
+
+// orderByCloid returns the open order of user with cloid. Must be called with mu held.
+func (s *Server) orderByCloid(user, cloid string) *restingOrder {
+	cloid = strings.ToLower(cloid)
+	for _, order := range s.orders {
+		if order.user == user && order.cloid != "" && order.cloid == cloid {
+			return order
+		}
+	}
+	return nil
+}
+
+// cancelOrder takes an open order off its book. Must be called with mu held.
+func (s *Server) cancelOrder(order *restingOrder) {
+	s.books[order.coin].remove(order.oid)
+	delete(s.orders, order.oid)
+}
+
+// recordFill adds a fill of user and updates the position. Must be called with mu held.
+func (s *Server) recordFill(user, coin string, spot, isBuy bool, px, sz float64, oid, tid int, crossed bool, now int64) {
+	dir := types.DirSell
+	if isBuy {
+		dir = types.DirBuy
+	}
+	start := 0.0
+	if !spot {
+		if s.positions[user] == nil {
+			s.positions[user] = make(map[string]*position)
+		}
+		p := s.positions[user][coin]
+		if p == nil {
+			p = &position{}
+			s.positions[user][coin] = p
+		}
+		start = p.szi
+		dir = p.apply(isBuy, px, sz)
+	}
+
+	s.fills[user] = append(s.fills[user], types.Fill{
+		Coin:          coin,
+		Px:            formatFloat(px),
+		Sz:            formatFloat(sz),
+		Side:          types.SideFromIsBuy(isBuy),
+		Time:          now,
+		StartPosition: formatFloat(start),
+		Dir:           dir,
+		ClosedPnl:     "0",
+		Hash:          fmt.Sprintf("0x%064x", tid),
+		Oid:           int64(oid),
+		Crossed:       crossed,
+		Fee:           "0",
+		Tid:           int64(tid),
+		FeeToken:      "USDC",
+	})
+}
+
+// apply adds a fill to the position and returns its direction
+func (p *position) apply(isBuy bool, px, sz float64) types.Direction {
+	delta := sz
+	if !isBuy {
+		delta = -sz
+	}
+	start := p.szi
+	p.szi += delta
+	if abs(p.szi) <= epsilon {
+		p.szi = 0
+	}
+
+	switch {
+	case start == 0 || (start > 0) == isBuy:
+		p.entryPx = (abs(start)*p.entryPx + sz*px) / abs(p.szi)
+		if isBuy {
+			return types.DirOpenLong
+		}
+		return types.DirOpenShort
+	case p.szi == 0 || (p.szi > 0) == (start > 0):
+		if p.szi == 0 {
+			p.entryPx = 0
+		}
+		if start > 0 {
+			return types.DirCloseLong
+		}
+		return types.DirCloseShort
+	default:
+		p.entryPx = px
+		if start > 0 {
+			return types.DirLongToShort
+		}
+		return types.DirShortToLong
+	}
+}
+
+// assetCoin returns the coin of an asset id. Must be called with mu held.
+func (s *Server) assetCoin(asset int) (string, bool) {
+	if index, ok := constants.SpotIndexFromAsset(asset); ok {
+		for _, pair := range s.spotMeta.Universe {
+			if pair.Index == index {
+				return pair.Name, true
+			}
+		}
+		return "", false
+	}
+	if constants.IsPerpAsset(asset) && asset < len(s.meta.Universe) {
+		return s.meta.Universe[asset].Name, true
+	}
+	return "", false
+}
+
+// coinAsset returns the asset id of a coin. Must be called with mu held.
+func (s *Server) coinAsset(coin string) (int, bool) {
+	for asset, info := range s.meta.Universe {
+		if info.Name == coin {
+			return asset, true
+		}
+	}
+	for _, pair := range s.spotMeta.Universe {
+		if pair.Name == coin {
+			return constants.SpotAsset(pair.Index), true
+		}
+	}
+	return 0, false
+}
+
+// remarshal decodes the generic JSON value v into out
+func remarshal(v any, out any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("Failed to deserialize the action: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package hltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// EnvRecord selects RecordMode in ModeFromEnv when set to a non-empty value
+const EnvRecord = "HLTEST_RECORD"
+
+// Mode is whether a Recorder records interactions with the API or replays them
+type Mode int
+
+const (
+	// ReplayMode answers requests from the fixture without touching the network
+	ReplayMode Mode = iota
+	// RecordMode sends requests to the API and writes the interactions to the fixture
+	RecordMode
+)
+
+// ModeFromEnv returns RecordMode if EnvRecord is set, else ReplayMode, so fixtures are
+// refreshed with HLTEST_RECORD=1 go test and replayed in CI
+func ModeFromEnv() Mode {
+	if os.Getenv(EnvRecord) != "" {
+		return RecordMode
+	}
+	return ReplayMode
+}
+
+// redacted replaces the values of redacted fields in fixtures
+const redacted = "REDACTED"
+
+// Default fields of recorded requests that are redacted or not matched on replay
+var (
+	// DefaultRedactedFields are signatures, from which nothing secret can be derived
+	// but which identify the signing key, and credentials a custom action may carry
+	DefaultRedactedFields = []string{"signature", "privateKey", "secret", "passphrase"}
+	// DefaultIgnoredFields change on every run unless the clock is fixed
+	DefaultIgnoredFields = []string{"nonce", "time", "expiresAfter"}
+)
+
+// Interaction is a request and its response, as stored in a fixture
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request of a fixture. Body is the JSON body with the redacted
+// fields replaced.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response of a fixture. JSON bodies are kept in Body, others
+// in Text.
+type RecordedResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Text       string          `json:"text,omitempty"`
+}
+
+// fixture is the file format of recordings
+type fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper recording the interactions of a client with the API
+// to a fixture file, or replaying them from it. Pass Client() to
+// client.NewInfoUsingHTTPClient or client.ExchangeOptions.HTTPClient:
+//
+//	rec, err := hltest.NewRecorder("testdata/market_open.json", hltest.ModeFromEnv())
+//	defer rec.Close()
+//	exchange, err := client.NewExchange(&client.ExchangeOptions{Wallet: key, HTTPClient: rec.Client()})
+//
+// Replayed requests are matched in order against the recorded requests with the same
+// method, path and body, ignoring the redacted and ignored fields. Safe for concurrent
+// use.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	redact       map[string]bool
+	ignore       map[string]bool
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder creates a recorder of the fixture at path. In ReplayMode the fixture is
+// read at once; in RecordMode it is written by Close.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+	}
+	r.SetRedactedFields(DefaultRedactedFields...)
+	r.SetIgnoredFields(DefaultIgnoredFields...)
+
+	if mode == ReplayMode {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+		}
+		r.interactions = f.Interactions
+		r.used = make([]bool, len(f.Interactions))
+	}
+	return r, nil
+}
+
+// SetTransport sets the transport of recorded requests, http.DefaultTransport by default
+func (r *Recorder) SetTransport(transport http.RoundTripper) {
+	r.transport = transport
+}
+
+// SetRedactedFields sets the JSON fields of request bodies replaced in fixtures, at any
+// depth, DefaultRedactedFields by default
+func (r *Recorder) SetRedactedFields(fields ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redact = fieldSet(fields)
+}
+
+// SetIgnoredFields sets the JSON fields of request bodies not matched on replay, at any
+// depth, DefaultIgnoredFields by default
+func (r *Recorder) SetIgnoredFields(fields ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ignore = fieldSet(fields)
+}
+
+// Client returns an HTTP client using the recorder as transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the interactions recorded, or loaded for replay
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Close writes the fixture in RecordMode. In ReplayMode it returns an error if some
+// recorded interactions were not replayed.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ReplayMode {
+		for i, used := range r.used {
+			if !used {
+				req := r.interactions[i].Request
+				return fmt.Errorf("interaction %d (%s %s) of %s was not replayed", i, req.Method, req.Path, r.path)
+			}
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(fixture{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// RoundTrip records or replays req
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request: %w", err)
+		}
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	recorded := RecordedRequest{Method: req.Method, Path: req.URL.Path, Body: r.redactBody(body)}
+	r.mu.Unlock()
+
+	if r.mode == ReplayMode {
+		return r.replay(req, recorded)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	response := RecordedResponse{StatusCode: resp.StatusCode}
+	if compacted, ok := compactJSON(respBody); ok {
+		response.Body = compacted
+	} else {
+		response.Text = string(respBody)
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	return resp, nil
+}
+
+// replay answers req with the first unused interaction matching it
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.matchKey(recorded)
+	for i, interaction := range r.interactions {
+		if r.used[i] || r.matchKey(interaction.Request) != key {
+			continue
+		}
+		r.used[i] = true
+
+		body := []byte(interaction.Response.Text)
+		contentType := "text/plain; charset=utf-8"
+		if len(interaction.Response.Body) > 0 {
+			body, contentType = interaction.Response.Body, "application/json"
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {contentType}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction in %s for %s %s %s", r.path, recorded.Method, recorded.Path, recorded.Body)
+}
+
+// redactBody returns the JSON body with the redacted fields replaced, or the body as a
+// JSON string if it is not JSON. Must be called with mu held.
+func (r *Recorder) redactBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if err := decodeJSON(body, &v); err != nil {
+		data, _ := json.Marshal(string(body))
+		return data
+	}
+	data, _ := json.Marshal(replaceFields(v, r.redact, redacted))
+	return data
+}
+
+// matchKey returns the canonical form of a request compared on replay. Must be called
+// with mu held.
+func (r *Recorder) matchKey(req RecordedRequest) string {
+	body := string(req.Body)
+	var v any
+	if len(req.Body) > 0 && decodeJSON(req.Body, &v) == nil {
+		v = replaceFields(replaceFields(v, r.redact, nil), r.ignore, nil)
+		data, _ := json.Marshal(v)
+		body = string(data)
+	}
+	return req.Method + " " + req.Path + " " + body
+}
+
+// replaceFields returns a copy of the decoded JSON value v whose fields in set are
+// replaced by value at any depth, or dropped if value is nil
+func replaceFields(v any, set map[string]bool, value any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			switch {
+			case !set[k]:
+				result[k] = replaceFields(item, set, value)
+			case value != nil:
+				result[k] = value
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = replaceFields(item, set, value)
+		}
+		return result
+	}
+	return v
+}
+
+func compactJSON(data []byte) (json.RawMessage, bool) {
+	var buf bytes.Buffer
+	if len(bytes.TrimSpace(data)) == 0 || json.Compact(&buf, data) != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+func fieldSet(fields []string) map[string]bool {
+	set := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		set[field] = true
+	}
+	return set
+}
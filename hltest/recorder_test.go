@@ -0,0 +1,106 @@
+package hltest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+)
+
+func TestRecorderRecordsAndReplays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures", "order.json")
+	key, user := newKey(t)
+
+	// Record against a mock server standing in for the API
+	srv := NewServer()
+	srv.AddUser(user)
+	srv.PlaceOrder(maker, "ETH", false, 1, 2001)
+
+	rec, err := NewRecorder(path, RecordMode)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	options := srv.ExchangeOptions(key)
+	options.HTTPClient = rec.Client()
+	exchange, err := client.NewExchange(options)
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+	want, err := exchange.Order("ETH", true, 1, 2001, types.NewLimit(types.TifGtc), false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	srv.Close()
+
+	// Signatures are redacted from the fixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var posted struct {
+		Signature types.Signature `json:"signature"`
+	}
+	requests := srv.Requests()
+	json.Unmarshal(requests[len(requests)-1].Body, &posted)
+	if posted.Signature.R == "" || strings.Contains(string(data), posted.Signature.R) || !strings.Contains(string(data), redacted) {
+		t.Errorf("fixture not redacted:\n%s", data)
+	}
+	if n := len(rec.Interactions()); n != 3 {
+		t.Errorf("recorded %d interactions, want spotMeta, meta and order", n)
+	}
+
+	// Replay with other nonces and signatures, the server being gone
+	rec, err = NewRecorder(path, ReplayMode)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	otherKey, _ := newKey(t)
+	options = srv.ExchangeOptions(otherKey)
+	options.HTTPClient = rec.Client()
+	exchange, err = client.NewExchange(options)
+	if err != nil {
+		t.Fatalf("NewExchange() replayed error = %v", err)
+	}
+	got, err := exchange.Order("ETH", true, 1, 2001, types.NewLimit(types.TifGtc), false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() replayed error = %v", err)
+	}
+	if *got.Data.Statuses[0].Filled != *want.Data.Statuses[0].Filled {
+		t.Errorf("replayed status = %+v, want %+v", got.Data.Statuses[0].Filled, want.Data.Statuses[0].Filled)
+	}
+	if err := rec.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	// Requests that were not recorded fail
+	if _, err := exchange.Order("ETH", true, 2, 2001, types.NewLimit(types.TifGtc), false, nil, nil); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("Order() not recorded error = %v", err)
+	}
+}
+
+func TestRecorderReportsUnusedInteractions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	fixture := `{"interactions":[{"request":{"method":"POST","path":"/info","body":{"type":"allMids"}},"response":{"statusCode":200,"body":{"ETH":"2000"}}}]}`
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := NewRecorder(path, ReplayMode)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	if err := rec.Close(); err == nil {
+		t.Error("Close() with an interaction not replayed expected error")
+	}
+
+	if _, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ReplayMode); err == nil {
+		t.Error("NewRecorder() of a missing fixture expected error")
+	}
+}
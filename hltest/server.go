@@ -0,0 +1,477 @@
+// Package hltest provides an in-process Hyperliquid API for integration tests of
+// programs built on the SDK, in the manner of net/http/httptest:
+//
+//	srv := hltest.NewServer()
+//	defer srv.Close()
+//
+//	srv.PlaceOrder(maker, "ETH", false, 10, 2000) // liquidity to trade against
+//	exchange, err := srv.NewExchange(key)         // an Exchange of a known account
+//
+// The server answers the common /info requests from its state and canned responses,
+// and executes orders, cancels and modifies posted to /exchange against a simple
+// price-time priority book per coin, after checking their nonces and signatures like
+// the exchange does. Other actions succeed without effect unless a handler is set.
+package hltest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// bookDepth is the number of levels per side of l2Book responses
+const bookDepth = 20
+
+// InfoHandler answers an /info request, given as its decoded JSON body. Returned
+// errors are sent with status 400.
+type InfoHandler func(req map[string]any) (any, error)
+
+// ExchangeHandler answers an /exchange request whose nonce and signature were
+// accepted. The result is sent as the response of an ok status, an error as the
+// message of an err status.
+type ExchangeHandler func(req *ExchangeRequest) (any, error)
+
+// Request is a request received by the server
+type Request struct {
+	Path string
+	Body json.RawMessage
+}
+
+// ExchangeRequest is a decoded /exchange request
+type ExchangeRequest struct {
+	// Action is the action as posted, with numbers as json.Number
+	Action       map[string]any
+	ActionType   string
+	Nonce        int64
+	Signature    types.Signature
+	VaultAddress *string
+	ExpiresAfter *int64
+	// Signer is the lower-case address that signed the action, empty when signatures
+	// are not verified
+	Signer string
+	// User is the lower-case address of the account acting: the vault, the account of
+	// the signing agent or the signer
+	User string
+}
+
+// Server is a mock Hyperliquid API listening on a local port
+type Server struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	clock     utils.Clock
+	verify    bool
+	meta      types.Meta
+	spotMeta  types.SpotMeta
+	infos     map[string]InfoHandler
+	actions   map[string]ExchangeHandler
+	users     map[string]bool
+	agents    map[string]string         // agent -> user
+	nonces    map[string]map[int64]bool // signer -> used nonces
+	books     map[string]*book
+	orders    map[int]*restingOrder
+	positions map[string]map[string]*position // user -> coin
+	fills     map[string][]types.Fill
+	nextOid   int
+	nextTid   int
+	requests  []Request
+}
+
+// position is the perp position of a user in a coin
+type position struct {
+	szi     float64
+	entryPx float64
+}
+
+// NewServer starts a server listing BTC and ETH perps and no spot pairs, which
+// verifies signatures and takes the time from the system clock
+func NewServer() *Server {
+	s := &Server{
+		clock:  utils.SystemClock,
+		verify: true,
+		meta: types.Meta{Universe: []types.AssetInfo{
+			{Name: "BTC", SzDecimals: 5, MaxLeverage: 40},
+			{Name: "ETH", SzDecimals: 4, MaxLeverage: 25},
+		}},
+		spotMeta:  types.SpotMeta{Universe: []types.SpotAssetInfo{}, Tokens: []types.SpotTokenInfo{}},
+		infos:     make(map[string]InfoHandler),
+		actions:   make(map[string]ExchangeHandler),
+		users:     make(map[string]bool),
+		agents:    make(map[string]string),
+		nonces:    make(map[string]map[int64]bool),
+		books:     make(map[string]*book),
+		orders:    make(map[int]*restingOrder),
+		positions: make(map[string]map[string]*position),
+		fills:     make(map[string][]types.Fill),
+		nextOid:   1,
+		nextTid:   1,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL returns the base URL of the API, for client.ExchangeOptions.BaseURL
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Network returns the network of the server: testnet signing parameters at the URL
+// of the server, which is what the SDK derives from the URL
+func (s *Server) Network() constants.Network {
+	return constants.NetworkForURL(s.URL()).WithURLs(s.URL(), "")
+}
+
+// NewInfo returns an Info client of the server
+func (s *Server) NewInfo() (*client.Info, error) {
+	return client.NewInfoUsingHTTP(s.URL(), 5*time.Second)
+}
+
+// ExchangeOptions returns the options of an Exchange client of the server signing
+// with key, e.g. to add an AccountAddress. Its clock gives unique nonces to actions sent
+// within the same millisecond, which the server rejects like the exchange.
+func (s *Server) ExchangeOptions(key *ecdsa.PrivateKey) *client.ExchangeOptions {
+	return &client.ExchangeOptions{Wallet: key, BaseURL: s.URL(), Timeout: 5 * time.Second, Clock: &uniqueClock{}}
+}
+
+// NewExchange adds the account of key and returns an Exchange client of it
+func (s *Server) NewExchange(key *ecdsa.PrivateKey) (*client.Exchange, error) {
+	s.AddUser(crypto.PubkeyToAddress(key.PublicKey).Hex())
+	return client.NewExchange(s.ExchangeOptions(key))
+}
+
+// uniqueClock is the system clock in milliseconds, moving at least a millisecond
+// between calls
+type uniqueClock struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (c *uniqueClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now().Truncate(time.Millisecond)
+	if !now.After(c.last) {
+		now = c.last.Add(time.Millisecond)
+	}
+	c.last = now
+	return now
+}
+
+// AddUser adds an account whose signed actions are accepted. Agents approved by the
+// account with an approveAgent action are accepted too.
+func (s *Server) AddUser(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[strings.ToLower(address)] = true
+}
+
+// SetClock sets the clock giving the server time, which bounds the nonces and
+// timestamps orders and fills
+func (s *Server) SetClock(clock utils.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// SetVerifySignatures turns the signature check of actions on or off. Without it,
+// actions are attributed to their vault, or to the zero address.
+func (s *Server) SetVerifySignatures(verify bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verify = verify
+}
+
+// SetMeta sets the perps listed by the server, the response to meta requests
+func (s *Server) SetMeta(meta types.Meta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meta = meta
+}
+
+// SetSpotMeta sets the spot pairs listed by the server, the response to spotMeta
+// requests
+func (s *Server) SetSpotMeta(spotMeta types.SpotMeta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spotMeta = spotMeta
+}
+
+// SetInfo answers /info requests of type typ with response, replacing the built-in
+// answer if any
+func (s *Server) SetInfo(typ string, response any) {
+	s.HandleInfo(typ, func(map[string]any) (any, error) { return response, nil })
+}
+
+// HandleInfo answers /info requests of type typ with h, replacing the built-in answer
+// if any
+func (s *Server) HandleInfo(typ string, h InfoHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.infos[typ] = h
+}
+
+// HandleExchange executes actions of type actionType with h instead of the built-in
+// behavior, after their nonce and signature are checked
+func (s *Server) HandleExchange(actionType string, h ExchangeHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions[actionType] = h
+}
+
+// Requests returns the requests received so far, oldest first
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// OpenOrders returns the orders of user resting on the books, newest first
+func (s *Server) OpenOrders(user string) []types.OpenOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.openOrders(strings.ToLower(user))
+}
+
+// Fills returns the fills of user, newest first
+func (s *Server) Fills(user string) []types.Fill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.userFills(strings.ToLower(user))
+}
+
+// Position returns the signed perp position of user in coin
+func (s *Server) Position(user, coin string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := s.positions[strings.ToLower(user)][coin]; p != nil {
+		return p.szi
+	}
+	return 0
+}
+
+// PlaceOrder places a Gtc limit order of user, e.g. liquidity for the orders under
+// test, and returns the status of the order
+func (s *Server) PlaceOrder(user, coin string, isBuy bool, sz, px float64) (types.OrderStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	asset, ok := s.coinAsset(coin)
+	if !ok {
+		return types.OrderStatus{}, fmt.Errorf("unknown coin: %s", coin)
+	}
+	wire := types.OrderWire{
+		Asset:     asset,
+		IsBuy:     isBuy,
+		LimitPx:   formatFloat(px),
+		Sz:        formatFloat(sz),
+		OrderType: types.OrderTypeWire{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+	}
+	status := s.placeOrder(strings.ToLower(user), wire)
+	if status.Error != "" {
+		return status, fmt.Errorf("failed to place order: %s", status.Error)
+	}
+	return status, nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Path: r.URL.Path, Body: body})
+	s.mu.Unlock()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.URL.Path {
+	case "/info":
+		s.serveInfo(w, body)
+	case "/exchange":
+		s.serveExchange(w, body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveInfo(w http.ResponseWriter, body []byte) {
+	var req map[string]any
+	if err := decodeJSON(body, &req); err != nil {
+		http.Error(w, "Failed to deserialize the JSON body into the target type", http.StatusUnprocessableEntity)
+		return
+	}
+	typ, _ := req["type"].(string)
+
+	s.mu.Lock()
+	h, ok := s.infos[typ]
+	s.mu.Unlock()
+	if !ok {
+		h, ok = s.builtinInfo(typ)
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown info type: %s", typ), http.StatusUnprocessableEntity)
+		return
+	}
+
+	response, err := h(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, response)
+}
+
+// builtinInfo returns the answer of the server state to /info requests of type typ
+func (s *Server) builtinInfo(typ string) (InfoHandler, bool) {
+	locked := func(f func(req map[string]any) any) InfoHandler {
+		return func(req map[string]any) (any, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return f(req), nil
+		}
+	}
+	user := func(req map[string]any) string {
+		address, _ := req["user"].(string)
+		return strings.ToLower(address)
+	}
+
+	switch typ {
+	case "meta":
+		return locked(func(map[string]any) any { return s.meta }), true
+	case "spotMeta":
+		return locked(func(map[string]any) any { return s.spotMeta }), true
+	case "allMids":
+		return locked(func(map[string]any) any { return s.allMids() }), true
+	case "l2Book":
+		return locked(func(req map[string]any) any {
+			coin, _ := req["coin"].(string)
+			b := s.books[coin]
+			if b == nil {
+				b = &book{}
+			}
+			return b.snapshot(coin, bookDepth, utils.TimestampMs(s.clock))
+		}), true
+	case "openOrders":
+		return locked(func(req map[string]any) any { return s.openOrders(user(req)) }), true
+	case "userFills":
+		return locked(func(req map[string]any) any { return s.userFills(user(req)) }), true
+	case "clearinghouseState":
+		return locked(func(req map[string]any) any { return s.userState(user(req)) }), true
+	}
+	return nil, false
+}
+
+func (s *Server) allMids() map[string]string {
+	mids := make(map[string]string)
+	for coin, b := range s.books {
+		if mid, ok := b.mid(); ok {
+			mids[coin] = formatFloat(mid)
+		}
+	}
+	return mids
+}
+
+func (s *Server) openOrders(user string) []types.OpenOrder {
+	orders := []types.OpenOrder{}
+	for _, order := range s.orders {
+		if order.user != user {
+			continue
+		}
+		orders = append(orders, types.OpenOrder{
+			Coin:      order.coin,
+			LimitPx:   formatFloat(order.px),
+			Oid:       order.oid,
+			Side:      types.SideFromIsBuy(order.isBuy),
+			Sz:        formatFloat(order.sz),
+			Timestamp: order.timestamp,
+		})
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].Oid > orders[j].Oid })
+	return orders
+}
+
+func (s *Server) userFills(user string) []types.Fill {
+	fills := s.fills[user]
+	result := make([]types.Fill, len(fills))
+	for i, fill := range fills {
+		result[len(fills)-1-i] = fill
+	}
+	return result
+}
+
+func (s *Server) userState(user string) types.UserState {
+	zero := types.MarginSummary{AccountValue: "0", TotalMarginUsed: "0", TotalNtlPos: "0", TotalRawUsd: "0"}
+	state := types.UserState{AssetPositions: []types.AssetPosition{}, CrossMarginSummary: zero, MarginSummary: zero, Withdrawable: "0"}
+
+	coins := make([]string, 0, len(s.positions[user]))
+	for coin, p := range s.positions[user] {
+		if p.szi != 0 {
+			coins = append(coins, coin)
+		}
+	}
+	sort.Strings(coins)
+	for _, coin := range coins {
+		p := s.positions[user][coin]
+		entryPx := formatFloat(p.entryPx)
+		state.AssetPositions = append(state.AssetPositions, types.AssetPosition{
+			Type: "oneWay",
+			Position: types.Position{
+				Coin:           coin,
+				EntryPx:        &entryPx,
+				Leverage:       types.Leverage{Type: "cross", Value: 1},
+				MarginUsed:     "0",
+				PositionValue:  formatFloat(abs(p.szi) * p.entryPx),
+				ReturnOnEquity: "0",
+				Szi:            formatFloat(p.szi),
+				UnrealizedPnl:  "0",
+			},
+		})
+	}
+	return state
+}
+
+// decodeJSON decodes data keeping numbers as json.Number
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
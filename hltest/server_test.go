@@ -0,0 +1,316 @@
+package hltest
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/signing"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+const maker = "0x00000000000000000000000000000000000000aa"
+
+func newKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+func TestServerInfo(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	for _, order := range []struct {
+		isBuy   bool
+		sz, px  float64
+		wantOid int
+	}{
+		{true, 1, 1999, 1},
+		{true, 2, 1999, 2},
+		{false, 1.5, 2001, 3},
+	} {
+		status, err := srv.PlaceOrder(maker, "ETH", order.isBuy, order.sz, order.px)
+		if err != nil || status.Resting == nil || status.Resting.Oid != order.wantOid {
+			t.Fatalf("PlaceOrder() = %+v, %v", status, err)
+		}
+	}
+
+	info, err := srv.NewInfo()
+	if err != nil {
+		t.Fatalf("NewInfo() error = %v", err)
+	}
+	if asset, err := info.NameToAsset("ETH"); err != nil || asset != 1 {
+		t.Errorf("NameToAsset(ETH) = %d, %v", asset, err)
+	}
+
+	mids, err := info.AllMids("")
+	if err != nil || len(mids) != 1 || mids["ETH"] != "2000" {
+		t.Errorf("AllMids() = %v, %v", mids, err)
+	}
+	book, err := info.L2Snapshot("ETH")
+	if err != nil {
+		t.Fatalf("L2Snapshot() error = %v", err)
+	}
+	if bids := book.Levels[0]; len(bids) != 1 || bids[0] != (types.L2Level{Px: "1999", Sz: "3", N: 2}) {
+		t.Errorf("bids = %+v", bids)
+	}
+	if asks := book.Levels[1]; len(asks) != 1 || asks[0] != (types.L2Level{Px: "2001", Sz: "1.5", N: 1}) {
+		t.Errorf("asks = %+v", asks)
+	}
+	orders, err := info.OpenOrders(maker, "")
+	if err != nil || len(orders) != 3 || orders[0].Oid != 3 || orders[0].Side != types.SideAsk {
+		t.Errorf("OpenOrders() = %+v, %v", orders, err)
+	}
+
+	// Canned responses replace the built-in answers
+	srv.SetInfo("allMids", map[string]string{"BTC": "60000"})
+	if mids, err := info.AllMids(""); err != nil || mids["BTC"] != "60000" {
+		t.Errorf("AllMids() after SetInfo = %v, %v", mids, err)
+	}
+	if _, err := info.UserVaultEquities(maker); err == nil {
+		t.Error("unknown info type expected error")
+	}
+
+	if len(srv.Requests()) == 0 || srv.Requests()[0].Path != "/info" {
+		t.Errorf("Requests() = %+v", srv.Requests())
+	}
+}
+
+func TestServerMatching(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.PlaceOrder(maker, "ETH", false, 1, 2001)
+	srv.PlaceOrder(maker, "ETH", false, 2, 2002)
+
+	key, user := newKey(t)
+	exchange, err := srv.NewExchange(key)
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+
+	// Crossing buy: fills the best ask, then part of the next, and rests the rest
+	result, err := exchange.Order("ETH", true, 2, 2002, types.NewLimit(types.TifGtc), false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if status := result.Data.Statuses[0]; status.Filled == nil || status.Filled.TotalSz != "2" || status.Filled.AvgPx != "2001.5" {
+		t.Errorf("status = %+v", status)
+	}
+	if got := srv.Position(user, "ETH"); got != 2 {
+		t.Errorf("Position() = %v, want 2", got)
+	}
+	fills := srv.Fills(user)
+	if len(fills) != 2 || fills[1].Px != "2001" || fills[0].Px != "2002" || !fills[0].Crossed || fills[0].Dir != types.DirOpenLong {
+		t.Errorf("Fills() = %+v", fills)
+	}
+	if makerFills := srv.Fills(maker); len(makerFills) != 2 || makerFills[0].Crossed || makerFills[0].Dir != types.DirOpenShort {
+		t.Errorf("maker fills = %+v", makerFills)
+	}
+
+	// Resting order, cancelled
+	cloid := types.NewCloidFromInt(7)
+	result, err = exchange.Order("ETH", true, 1, 1990, types.NewLimit(types.TifGtc), false, cloid, nil)
+	if err != nil || result.Data.Statuses[0].Resting == nil {
+		t.Fatalf("Order() = %+v, %v", result, err)
+	}
+	if orders := srv.OpenOrders(user); len(orders) != 1 || orders[0].LimitPx != "1990" {
+		t.Errorf("OpenOrders() = %+v", orders)
+	}
+	cancel, err := exchange.CancelByCloid("ETH", *cloid)
+	if err != nil || len(cancel.Data.Statuses) != 1 || cancel.Data.Statuses[0] != "success" {
+		t.Errorf("CancelByCloid() = %+v, %v", cancel, err)
+	}
+	if orders := srv.OpenOrders(user); len(orders) != 0 {
+		t.Errorf("OpenOrders() after cancel = %+v", orders)
+	}
+
+	// Post-only orders must not cross, Ioc orders must fill
+	result, err = exchange.Order("ETH", true, 1, 2002, types.NewLimit(types.TifAlo), false, nil, nil)
+	if err != nil || !strings.Contains(result.Data.Statuses[0].Error, "Post only") {
+		t.Errorf("Alo Order() = %+v, %v", result, err)
+	}
+	result, err = exchange.Order("ETH", false, 1, 1900, types.NewLimit(types.TifIoc), false, nil, nil)
+	if err != nil || !strings.Contains(result.Data.Statuses[0].Error, "could not immediately match") {
+		t.Errorf("Ioc Order() = %+v, %v", result, err)
+	}
+
+	// Reduce-only sells close the long
+	result, err = exchange.Order("ETH", false, 5, 2002, types.NewLimit(types.TifGtc), true, nil, nil)
+	if err != nil || result.Data.Statuses[0].Resting == nil {
+		t.Fatalf("reduce-only Order() = %+v, %v", result, err)
+	}
+	if orders := srv.OpenOrders(user); len(orders) != 1 || orders[0].Sz != "2" {
+		t.Errorf("reduce-only order = %+v, want size 2", orders)
+	}
+
+	oid := result.Data.Statuses[0].Resting.Oid
+	modified, err := exchange.ModifyOrder(oid, "ETH", false, 1, 2100, types.NewLimit(types.TifGtc), false, nil)
+	if err != nil || modified.Data.Statuses[0].Resting == nil {
+		t.Fatalf("ModifyOrder() = %+v, %v", modified, err)
+	}
+	if orders := srv.OpenOrders(user); len(orders) != 1 || orders[0].LimitPx != "2100" || orders[0].Sz != "1" {
+		t.Errorf("modified order = %+v", orders)
+	}
+	modified, err = exchange.ModifyOrder(oid, "ETH", false, 1, 2100, types.NewLimit(types.TifGtc), false, nil)
+	if err != nil || modified.Data.Statuses[0].Error == "" {
+		t.Errorf("ModifyOrder() of a replaced order = %+v, %v", modified, err)
+	}
+}
+
+func TestServerRejectsActions(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	// Accounts must be known
+	key, _ := newKey(t)
+	stranger, err := client.NewExchange(&client.ExchangeOptions{Wallet: key, BaseURL: srv.URL(), Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+	if _, err := stranger.ScheduleCancel(nil); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("action of unknown account error = %v", err)
+	}
+
+	// Nonces must be unique and close to the server time
+	clock := utils.NewFakeClock(time.Now())
+	clock.SetStep(0)
+	srv.AddUser(crypto.PubkeyToAddress(key.PublicKey).Hex())
+	exchange, err := client.NewExchange(&client.ExchangeOptions{Wallet: key, BaseURL: srv.URL(), Timeout: time.Second, Clock: clock})
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+	if _, err := exchange.ScheduleCancel(nil); err != nil {
+		t.Fatalf("ScheduleCancel() error = %v", err)
+	}
+	if _, err := exchange.ScheduleCancel(nil); err == nil || !strings.Contains(err.Error(), "duplicate nonce") {
+		t.Errorf("reused nonce error = %v", err)
+	}
+	clock.Advance(-72 * time.Hour)
+	if _, err := exchange.ScheduleCancel(nil); err == nil || !strings.Contains(err.Error(), "too far") {
+		t.Errorf("stale nonce error = %v", err)
+	}
+
+	// Handlers replace the built-in behavior of an action
+	clock.Set(time.Now().Add(time.Second))
+	srv.HandleExchange("scheduleCancel", func(req *ExchangeRequest) (any, error) {
+		return nil, errors.New("Cannot set scheduled cancel time until enough volume traded")
+	})
+	if _, err := exchange.ScheduleCancel(nil); err == nil || !strings.Contains(err.Error(), "enough volume") {
+		t.Errorf("handled action error = %v", err)
+	}
+}
+
+func TestServerAgents(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.PlaceOrder(maker, "BTC", true, 1, 59990)
+	srv.PlaceOrder(maker, "BTC", false, 1, 60010)
+
+	masterKey, master := newKey(t)
+	exchange, err := srv.NewExchange(masterKey)
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+	agentKey, agentAddress := newKey(t)
+	name := "bot"
+	if _, err := exchange.ApproveAgent(agentAddress, &name); err != nil {
+		t.Fatalf("ApproveAgent() error = %v", err)
+	}
+
+	options := srv.ExchangeOptions(agentKey)
+	options.AccountAddress = &master
+	agent, err := client.NewExchange(options)
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+	result, err := agent.MarketOpen("BTC", true, 0.5, nil, 0.01, nil, nil)
+	if err != nil || result.Data.Statuses[0].Filled == nil {
+		t.Fatalf("MarketOpen() = %+v, %v", result, err)
+	}
+	if got := srv.Position(master, "BTC"); got != 0.5 {
+		t.Errorf("Position() of the master = %v, want 0.5", got)
+	}
+	if _, err := agent.MarketClose("BTC", nil, nil, 0.01, nil, nil); err != nil {
+		t.Fatalf("MarketClose() error = %v", err)
+	}
+	if got := srv.Position(master, "BTC"); got != 0 {
+		t.Errorf("Position() after MarketClose() = %v, want 0", got)
+	}
+	if fills := srv.Fills(master); len(fills) != 2 || fills[0].Dir != types.DirCloseLong || fills[0].StartPosition != "0.5" {
+		t.Errorf("Fills() = %+v", fills)
+	}
+}
+
+func TestTestHelpers(t *testing.T) {
+	srv := NewTestServer(t)
+	srv.PlaceOrder(maker, "ETH", false, 1, 2001)
+
+	exchange, other := srv.NewTestExchange(t), srv.NewTestExchange(t)
+	if exchange.GetAccountAddress() == other.GetAccountAddress() {
+		t.Errorf("NewTestExchange() returned the account %s twice", exchange.GetAccountAddress())
+	}
+	if _, err := exchange.Order("ETH", true, 1, 2001, types.NewLimit(types.TifIoc), false, nil, nil); err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if got := srv.Position(exchange.GetAccountAddress(), "ETH"); got != 1 {
+		t.Errorf("Position() = %v, want 1", got)
+	}
+	if asset, err := srv.NewTestInfo(t).NameToAsset("ETH"); err != nil || asset != 1 {
+		t.Errorf("NameToAsset(ETH) = %d, %v", asset, err)
+	}
+	if a, b := NewTestKey(t), NewTestKey(t); a.Equal(b) {
+		t.Error("NewTestKey() returned the same key twice")
+	}
+	if got := NewFakeClock().Now(); !got.Equal(Epoch) {
+		t.Errorf("NewFakeClock().Now() = %v, want %v", got, Epoch)
+	}
+}
+
+func TestRecoverL1SignerTriesEncodings(t *testing.T) {
+	key, address := newKey(t)
+	known := func(a common.Address) bool { return a.Hex() == address }
+	network := constants.Testnet
+	wire := types.OrderWire{Asset: 3, IsBuy: true, LimitPx: "10", Sz: "1", OrderType: types.OrderTypeWire{Limit: &types.LimitOrderType{Tif: types.TifGtc}}}
+
+	// Signed with the keys in another order than the wire order, and an int64 field
+	signed := orderedMap{
+		keys:   []string{"grouping", "orders", "type", "expires"},
+		values: []any{"na", []any{wire}, "order", int64(5)},
+	}
+	sig, err := signing.SignL1ActionForNetwork(key, signed, nil, 1700000000000, nil, network)
+	if err != nil {
+		t.Fatalf("SignL1ActionForNetwork() error = %v", err)
+	}
+
+	posted, err := json.Marshal(map[string]any{"type": "order", "orders": []types.OrderWire{wire}, "grouping": "na", "expires": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &ExchangeRequest{ActionType: "order", Nonce: 1700000000000, Signature: *sig}
+	if err := decodeJSON(posted, &req.Action); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := recoverL1Signer(req, network, known); err != nil || got.Hex() != address {
+		t.Errorf("recoverL1Signer() = %s, %v, want %s", got.Hex(), err, address)
+	}
+
+	// Another nonce was not signed by the key
+	req.Nonce++
+	if _, err := recoverL1Signer(req, network, known); !errors.Is(err, errUnknownSigner) {
+		t.Errorf("recoverL1Signer() of another nonce error = %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package hltest
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Epoch is the start time of the clocks of NewFakeClock, so tests see the same dates
+var Epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewTestServer starts a server like NewServer, closed when the test ends
+func NewTestServer(t testing.TB) *Server {
+	t.Helper()
+	s := NewServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewTestKey generates a private key, failing the test on error
+func NewTestKey(t testing.TB) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return key
+}
+
+// NewTestExchange adds an account with a new key and returns an Exchange client of
+// it, failing the test on error
+func (s *Server) NewTestExchange(t testing.TB) *client.Exchange {
+	t.Helper()
+	exchange, err := s.NewExchange(NewTestKey(t))
+	if err != nil {
+		t.Fatalf("NewExchange(key) error = %v", err)
+	}
+	return exchange
+}
+
+// NewTestInfo returns an Info client of the server, failing the test on error
+func (s *Server) NewTestInfo(t testing.TB) *client.Info {
+	t.Helper()
+	info, err := s.NewInfo()
+	if err != nil {
+		t.Fatalf("NewInfo() error = %v", err)
+	}
+	return info
+}
+
+// NewFakeClock returns a fake clock at Epoch
+func NewFakeClock() *utils.FakeClock {
+	return utils.NewFakeClock(Epoch)
+}
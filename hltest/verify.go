@@ -0,0 +1,320 @@
+package hltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/signing"
+	"github.com/dwdwow/hl-go/types"
+)
+
+// The SDK builds actions from Go maps, whose keys it msgpack-encodes in no fixed
+// order, and encodes Go ints and int64s differently. The signed bytes therefore
+// cannot be rebuilt from the posted JSON alone: the server searches the key orders and
+// integer encodings of the action for one signed by a known account.
+const (
+	// maxPermutedKeys is the size of the largest map whose key orders are all tried.
+	// Larger maps are tried in their wire order only.
+	maxPermutedKeys = 6
+	// maxCandidates bounds the encodings tried for one signature
+	maxCandidates = 1 << 14
+)
+
+// wireKeyOrders are the msgpack field orders of the SDK's wire structs. Structs are
+// encoded in field order only, with their ints compact.
+var wireKeyOrders = [][]string{
+	{"a", "b", "p", "s", "r", "t", "c"}, // types.OrderWire
+	{"triggerPx", "isMarket", "tpsl"},   // types.TriggerOrderTypeWire
+	{"oid", "order"},                    // types.ModifyWire
+}
+
+// recoverL1Signer returns the known signer of an L1 action, as decided by known, or
+// the zero address and an error when no encoding of the action was signed by one
+func recoverL1Signer(req *ExchangeRequest, network constants.Network, known func(common.Address) bool) (common.Address, error) {
+	root := newNode(req.Action, false)
+	var choices []*choice
+	root.collect(&choices)
+
+	var first common.Address
+	for n := 0; n < maxCandidates; n++ {
+		typedData, err := signing.L1ActionTypedDataForNetwork(root.value(), req.VaultAddress, req.Nonce, req.ExpiresAfter, network)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("failed to hash action: %w", err)
+		}
+		signer, err := signing.RecoverSigner(typedData, req.Signature)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if known(signer) {
+			return signer, nil
+		}
+		if n == 0 {
+			first = signer
+		}
+		if !next(choices) {
+			return first, errUnknownSigner
+		}
+	}
+	return first, fmt.Errorf("could not verify the signature of the %s action within %d encodings", req.ActionType, maxCandidates)
+}
+
+// recoverUserSigner returns the signer of a user-signed action, whose EIP-712 fields
+// have a fixed order, and the schema of the action
+func recoverUserSigner(req *ExchangeRequest) (common.Address, signing.UserSignedActionType, error) {
+	t, ok := signing.LookupUserSignedActionTypeByAction(req.ActionType)
+	if !ok {
+		return common.Address{}, t, fmt.Errorf("unknown user-signed action: %s", req.ActionType)
+	}
+	action := integers(req.Action)
+	// Optional string fields, e.g. the name of an unnamed agent, are signed empty
+	for _, typ := range t.Types {
+		if _, ok := action[typ.Name]; !ok && typ.Type == "string" {
+			action[typ.Name] = ""
+		}
+	}
+	if err := t.Validate(action); err != nil {
+		return common.Address{}, t, err
+	}
+	typedData := signing.UserSignedPayload(action, t.Types, t.PrimaryType)
+	signer, err := signing.RecoverSigner(typedData, req.Signature)
+	return signer, t, err
+}
+
+// integers returns a copy of action whose integral json.Numbers are int64s
+func integers(action map[string]any) map[string]any {
+	result := make(map[string]any, len(action))
+	for k, v := range action {
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = i
+			}
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// choice is one of the alternatives at a point of the action, e.g. a key order
+type choice struct {
+	n, selected int
+}
+
+// next advances choices like an odometer, returning false after the last combination
+func next(choices []*choice) bool {
+	for _, c := range choices {
+		c.selected++
+		if c.selected < c.n {
+			return true
+		}
+		c.selected = 0
+	}
+	return false
+}
+
+// node is a decoded JSON value whose signed encoding is ambiguous
+type node interface {
+	collect(choices *[]*choice)
+	value() any
+}
+
+// newNode returns the node of a decoded JSON value; the ints of wire structs are
+// always compact
+func newNode(v any, inStruct bool) node {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		order, isStruct := structOrder(keys)
+		m := &mapNode{values: make(map[string]node, len(v)), orders: [][]string{order}}
+		if !isStruct {
+			m.orders = keyOrders(keys)
+		}
+		for k, item := range v {
+			m.values[k] = newNode(item, isStruct)
+		}
+		m.choice = &choice{n: len(m.orders)}
+		return m
+	case []any:
+		s := make(sliceNode, len(v))
+		for i, item := range v {
+			s[i] = newNode(item, false)
+		}
+		return s
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			n := &intNode{v: i, choice: &choice{n: 2}}
+			if inStruct {
+				n.choice.n = 1
+			}
+			return n
+		}
+		f, _ := v.Float64()
+		return leaf{f}
+	}
+	return leaf{v}
+}
+
+// mapNode is a JSON object, encoded in one of its key orders
+type mapNode struct {
+	values map[string]node
+	orders [][]string
+	choice *choice
+}
+
+func (m *mapNode) collect(choices *[]*choice) {
+	if m.choice.n > 1 {
+		*choices = append(*choices, m.choice)
+	}
+	for _, k := range m.orders[0] {
+		m.values[k].collect(choices)
+	}
+}
+
+func (m *mapNode) value() any {
+	keys := m.orders[m.choice.selected]
+	o := orderedMap{keys: keys, values: make([]any, len(keys))}
+	for i, k := range keys {
+		o.values[i] = m.values[k].value()
+	}
+	return o
+}
+
+// sliceNode is a JSON array
+type sliceNode []node
+
+func (s sliceNode) collect(choices *[]*choice) {
+	for _, item := range s {
+		item.collect(choices)
+	}
+}
+
+func (s sliceNode) value() any {
+	values := make([]any, len(s))
+	for i, item := range s {
+		values[i] = item.value()
+	}
+	return values
+}
+
+// intNode is an integer, encoded as a Go int (compact) or int64 (fixed width)
+type intNode struct {
+	v      int64
+	choice *choice
+}
+
+func (n *intNode) collect(choices *[]*choice) {
+	if n.choice.n > 1 {
+		*choices = append(*choices, n.choice)
+	}
+}
+
+func (n *intNode) value() any {
+	if n.choice.selected == 0 && n.v >= math.MinInt && n.v <= math.MaxInt {
+		return int(n.v)
+	}
+	return n.v
+}
+
+// leaf is a string, bool, float or null
+type leaf struct {
+	v any
+}
+
+func (leaf) collect(*[]*choice) {}
+
+func (l leaf) value() any {
+	return l.v
+}
+
+// orderedMap encodes as a msgpack map with keys in order
+type orderedMap struct {
+	keys   []string
+	values []any
+}
+
+func (o orderedMap) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if err := enc.EncodeMapLen(len(o.keys)); err != nil {
+		return err
+	}
+	for i, k := range o.keys {
+		if err := enc.EncodeString(k); err != nil {
+			return err
+		}
+		if err := enc.Encode(o.values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyOrders returns the orders in which to try the keys of a map: "type" first, then
+// every other permutation of small maps
+func keyOrders(keys []string) [][]string {
+	sort.Strings(keys)
+	preferred := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k == "type" {
+			preferred = append([]string{k}, preferred...)
+		} else {
+			preferred = append(preferred, k)
+		}
+	}
+	orders := [][]string{preferred}
+	if len(keys) > maxPermutedKeys {
+		return orders
+	}
+	preferredKey := strings.Join(preferred, ",")
+	permute(keys, 0, func(p []string) {
+		if strings.Join(p, ",") != preferredKey {
+			orders = append(orders, append([]string(nil), p...))
+		}
+	})
+	return orders
+}
+
+// structOrder returns keys in the field order of the wire struct with these keys
+func structOrder(keys []string) ([]string, bool) {
+	present := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		present[k] = true
+	}
+	for _, order := range wireKeyOrders {
+		var matched []string
+		for _, k := range order {
+			if present[k] {
+				matched = append(matched, k)
+			}
+		}
+		if len(matched) == len(keys) && len(matched) >= 2 {
+			return matched, true
+		}
+	}
+	return nil, false
+}
+
+// permute calls f with every permutation of keys[i:], reordering keys in place
+func permute(keys []string, i int, f func([]string)) {
+	if i == len(keys) {
+		f(keys)
+		return
+	}
+	for j := i; j < len(keys); j++ {
+		keys[i], keys[j] = keys[j], keys[i]
+		permute(keys, i+1, f)
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+}
+
+// signatureValid reports whether sig is well formed before any recovery is tried
+func signatureValid(sig types.Signature) bool {
+	return (sig.V == 27 || sig.V == 28) && len(sig.R) > 2 && len(sig.S) > 2
+}
@@ -0,0 +1,155 @@
+// Package hyperevm is a client of the HyperEVM JSON-RPC, the EVM of Hyperliquid. It
+// wraps an ethclient.Client with the methods specific to HyperEVM: the gas price of
+// big blocks and whether an address sends its transactions in big blocks, which
+// Exchange.UseBigBlocks switches. Token helpers link the spot tokens of HyperCore to
+// their EVM contracts for transfers between Core and EVM. The read precompiles give the
+// state of Core, positions, spot balances and prices, to EVM, and CoreWriter sends
+// actions from EVM to Core.
+//
+//	evm, err := hyperevm.DialNetwork(ctx, constants.Mainnet)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer evm.Close()
+//	prices, _ := evm.GasPrices(ctx)
+//	using, _ := evm.UsingBigBlocks(ctx, address)
+package hyperevm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/constants"
+)
+
+const (
+	// MainnetRPCURL is the JSON-RPC URL of HyperEVM mainnet
+	MainnetRPCURL = "https://rpc.hyperliquid.xyz/evm"
+	// TestnetRPCURL is the JSON-RPC URL of HyperEVM testnet
+	TestnetRPCURL = "https://rpc.hyperliquid-testnet.xyz/evm"
+
+	// MainnetChainID is the chain id of HyperEVM mainnet
+	MainnetChainID = 999
+	// TestnetChainID is the chain id of HyperEVM testnet
+	TestnetChainID = 998
+)
+
+// RPCURLFor returns the JSON-RPC URL of the HyperEVM of network
+func RPCURLFor(network constants.Network) string {
+	if network.IsMainnet() {
+		return MainnetRPCURL
+	}
+	return TestnetRPCURL
+}
+
+// ChainIDFor returns the chain id of the HyperEVM of network
+func ChainIDFor(network constants.Network) int64 {
+	if network.IsMainnet() {
+		return MainnetChainID
+	}
+	return TestnetChainID
+}
+
+// Client is a HyperEVM client. The methods of the embedded ethclient.Client cover the
+// standard JSON-RPC.
+type Client struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+// NewClient creates a client using c
+func NewClient(c *rpc.Client) *Client {
+	return &Client{Client: ethclient.NewClient(c), rpc: c}
+}
+
+// Dial connects a client to the JSON-RPC at url
+func Dial(ctx context.Context, url string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", url, err)
+	}
+	return NewClient(c), nil
+}
+
+// DialNetwork connects a client to the HyperEVM of network
+func DialNetwork(ctx context.Context, network constants.Network) (*Client, error) {
+	return Dial(ctx, RPCURLFor(network))
+}
+
+// GasPrices are the gas prices of the small, fast blocks and of the big blocks
+type GasPrices struct {
+	Small *big.Int
+	Big   *big.Int
+}
+
+// GasPrices returns the gas prices of small and big blocks
+func (c *Client) GasPrices(ctx context.Context) (*GasPrices, error) {
+	small, err := c.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	bigPrice, err := c.BigBlockGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GasPrices{Small: small, Big: bigPrice}, nil
+}
+
+// BigBlockGasPrice returns the gas price of big blocks
+func (c *Client) BigBlockGasPrice(ctx context.Context) (*big.Int, error) {
+	var price hexutil.Big
+	if err := c.rpc.CallContext(ctx, &price, "eth_bigBlockGasPrice"); err != nil {
+		return nil, fmt.Errorf("failed to get big block gas price: %w", err)
+	}
+	return price.ToInt(), nil
+}
+
+// UsingBigBlocks returns whether the transactions of address go in big blocks
+func (c *Client) UsingBigBlocks(ctx context.Context, address common.Address) (bool, error) {
+	var using bool
+	if err := c.rpc.CallContext(ctx, &using, "eth_usingBigBlocks", address); err != nil {
+		return false, fmt.Errorf("failed to get big blocks status: %w", err)
+	}
+	return using, nil
+}
+
+// SetBigBlocks switches the account of exchange to big blocks or back with
+// UseBigBlocks, unless it already is, and polls every interval until HyperEVM
+// reports the switch or ctx is done. It returns whether the account was switched.
+func (c *Client) SetBigBlocks(ctx context.Context, exchange client.Exchanger, enable bool, interval time.Duration) (bool, error) {
+	address := common.HexToAddress(exchange.GetAccountAddress())
+	using, err := c.UsingBigBlocks(ctx, address)
+	if err != nil {
+		return false, err
+	}
+	if using == enable {
+		return false, nil
+	}
+	if _, err := exchange.UseBigBlocks(enable); err != nil {
+		return false, fmt.Errorf("failed to use big blocks: %w", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-ticker.C:
+		}
+		using, err := c.UsingBigBlocks(ctx, address)
+		if err != nil {
+			return true, err
+		}
+		if using == enable {
+			return true, nil
+		}
+	}
+}
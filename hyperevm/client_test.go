@@ -0,0 +1,224 @@
+package hyperevm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/hltest"
+)
+
+// ethService serves the eth namespace of a HyperEVM node
+type ethService struct {
+	mu        sync.Mutex
+	bigBlocks map[common.Address]bool
+	native    map[common.Address]*big.Int
+	tokens    map[common.Address]map[common.Address]*big.Int
+	// precompiles answer calls to their address with the output for the input
+	precompiles map[common.Address]func(input []byte) ([]byte, error)
+	sent        []*ethtypes.Transaction
+}
+
+func (s *ethService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(TestnetChainID))
+}
+
+func (s *ethService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(100_000_000))
+}
+
+func (s *ethService) BigBlockGasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(200_000_000))
+}
+
+func (s *ethService) UsingBigBlocks(address common.Address) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bigBlocks[address]
+}
+
+func (s *ethService) GetBalance(address common.Address, _ string) *hexutil.Big {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if balance := s.native[address]; balance != nil {
+		return (*hexutil.Big)(balance)
+	}
+	return (*hexutil.Big)(new(big.Int))
+}
+
+type callArgs struct {
+	To    *common.Address `json:"to"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+func (s *ethService) Call(args callArgs, _ string) (hexutil.Bytes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if precompile, ok := s.precompiles[*args.To]; ok {
+		return precompile(args.Input)
+	}
+	holders, ok := s.tokens[*args.To]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	values, err := erc20.Methods["balanceOf"].Inputs.Unpack(args.Input[4:])
+	if err != nil {
+		return nil, err
+	}
+	balance := holders[values[0].(common.Address)]
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	return erc20.Methods["balanceOf"].Outputs.Pack(balance)
+}
+
+func (s *ethService) GetTransactionCount(common.Address, string) hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(len(s.sent))
+}
+
+func (s *ethService) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1))
+}
+
+func (s *ethService) GetBlockByNumber(string, bool) *ethtypes.Header {
+	return &ethtypes.Header{Number: big.NewInt(1), Difficulty: new(big.Int), BaseFee: big.NewInt(100_000_000)}
+}
+
+func (s *ethService) EstimateGas(callArgs, *string) hexutil.Uint64 {
+	return 50_000
+}
+
+func (s *ethService) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	tx := new(ethtypes.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, tx)
+	return tx.Hash(), nil
+}
+
+func newClient(t *testing.T) (*Client, *ethService) {
+	t.Helper()
+	service := &ethService{
+		bigBlocks:   make(map[common.Address]bool),
+		native:      make(map[common.Address]*big.Int),
+		tokens:      make(map[common.Address]map[common.Address]*big.Int),
+		precompiles: make(map[common.Address]func([]byte) ([]byte, error)),
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatalf("RegisterName(eth, service) error = %v", err)
+	}
+	t.Cleanup(server.Stop)
+	c := NewClient(rpc.DialInProc(server))
+	t.Cleanup(c.Close)
+	return c, service
+}
+
+func TestGasPrices(t *testing.T) {
+	c, _ := newClient(t)
+	prices, err := c.GasPrices(context.Background())
+	if err != nil {
+		t.Fatalf("GasPrices(context.Background()) error = %v", err)
+	}
+	if want := big.NewInt(100_000_000); prices.Small.Cmp(want) != 0 {
+		t.Errorf("prices.Small = %+v, want %+v", prices.Small, want)
+	}
+	if want := big.NewInt(200_000_000); prices.Big.Cmp(want) != 0 {
+		t.Errorf("prices.Big = %+v, want %+v", prices.Big, want)
+	}
+
+	chainID, err := c.ChainID(context.Background())
+	if err != nil {
+		t.Fatalf("ChainID(context.Background()) error = %v", err)
+	}
+	if got, want := chainID.Int64(), int64(ChainIDFor(constants.Testnet)); got != want {
+		t.Errorf("Int64() = %v, want %v", got, want)
+	}
+}
+
+func TestSetBigBlocks(t *testing.T) {
+	c, service := newClient(t)
+	srv := hltest.NewTestServer(t)
+	exchange := srv.NewTestExchange(t)
+	address := common.HexToAddress(exchange.GetAccountAddress())
+
+	var actions int
+	srv.HandleExchange("evmUserModify", func(req *hltest.ExchangeRequest) (any, error) {
+		actions++
+		enable := req.Action["usingBigBlocks"].(bool)
+		// The switch shows on EVM a moment later
+		time.AfterFunc(5*time.Millisecond, func() {
+			service.mu.Lock()
+			defer service.mu.Unlock()
+			service.bigBlocks[address] = enable
+		})
+		return map[string]any{"type": "default"}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	switched, err := c.SetBigBlocks(ctx, exchange, true, time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetBigBlocks() error = %v", err)
+	}
+	if !switched {
+		t.Error("switched = false")
+	}
+	using, err := c.UsingBigBlocks(ctx, address)
+	if err != nil {
+		t.Fatalf("UsingBigBlocks(ctx, address) error = %v", err)
+	}
+	if !using {
+		t.Error("using = false")
+	}
+
+	// Already using big blocks
+	switched, err = c.SetBigBlocks(ctx, exchange, true, time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetBigBlocks() error = %v", err)
+	}
+	if switched {
+		t.Error("switched = true")
+	}
+	if actions != 1 {
+		t.Errorf("actions = %v, want 1", actions)
+	}
+
+	srv.HandleExchange("evmUserModify", func(*hltest.ExchangeRequest) (any, error) {
+		return nil, errors.New("user has no EVM activity")
+	})
+	_, err = c.SetBigBlocks(ctx, exchange, false, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "no EVM activity") {
+		t.Errorf("SetBigBlocks() error = %v, want %q", err, "no EVM activity")
+	}
+}
+
+func TestNetworks(t *testing.T) {
+	if got := RPCURLFor(constants.Mainnet); got != MainnetRPCURL {
+		t.Errorf("RPCURLFor(constants.Mainnet) = %q, want %q", got, MainnetRPCURL)
+	}
+	if got := RPCURLFor(constants.Testnet); got != TestnetRPCURL {
+		t.Errorf("RPCURLFor(constants.Testnet) = %q, want %q", got, TestnetRPCURL)
+	}
+	if got := ChainIDFor(constants.Mainnet); got != int64(MainnetChainID) {
+		t.Errorf("ChainIDFor(constants.Mainnet) = %v, want int64(MainnetChainID)", got)
+	}
+	if got := ChainIDFor(constants.Local); got != int64(TestnetChainID) {
+		t.Errorf("ChainIDFor(constants.Local) = %v, want int64(TestnetChainID)", got)
+	}
+}
@@ -0,0 +1,319 @@
+package hyperevm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// CoreWriterAddress is the address of the CoreWriter contract, sending actions from
+// HyperEVM to HyperCore
+var CoreWriterAddress = common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+// CoreActionVersion is the version of the encoding of CoreWriter actions
+const CoreActionVersion = 1
+
+// Ids of the CoreWriter actions
+const (
+	LimitOrderActionID       = 1
+	VaultTransferActionID    = 2
+	TokenDelegateActionID    = 3
+	StakingDepositActionID   = 4
+	StakingWithdrawActionID  = 5
+	SpotSendActionID         = 6
+	UsdClassTransferActionID = 7
+	AddAPIWalletActionID     = 9
+	CancelByOidActionID      = 10
+	CancelByCloidActionID    = 11
+)
+
+// orderDecimals are the decimals of the prices and sizes of CoreWriter orders
+const orderDecimals = 8
+
+// CoreAction is an action sent to HyperCore through CoreWriter. Actions sent from
+// HyperEVM are applied on Core with a delay and their results are not returned; read
+// the state of Core afterwards to follow them.
+type CoreAction interface {
+	// coreAction returns the id of the action and its arguments
+	coreAction() (uint32, []any, error)
+}
+
+// LimitOrderAction places a limit order. Asset is the asset id of orders, the index
+// of a perp or 10000 plus the index of a spot pair. LimitPx and Sz are rounded to 8
+// decimals.
+type LimitOrderAction struct {
+	Asset      uint32
+	IsBuy      bool
+	LimitPx    float64
+	Sz         float64
+	ReduceOnly bool
+	Tif        types.Tif
+	// Cloid is the client order id of the order, none if nil
+	Cloid *types.Cloid
+}
+
+func (a LimitOrderAction) coreAction() (uint32, []any, error) {
+	px, err := toUint64("price", a.LimitPx, orderDecimals)
+	if err != nil {
+		return 0, nil, err
+	}
+	sz, err := toUint64("size", a.Sz, orderDecimals)
+	if err != nil {
+		return 0, nil, err
+	}
+	var tif uint8
+	switch a.Tif {
+	case types.TifAlo:
+		tif = 1
+	case types.TifGtc:
+		tif = 2
+	case types.TifIoc:
+		tif = 3
+	default:
+		return 0, nil, fmt.Errorf("invalid tif %q", a.Tif)
+	}
+	return LimitOrderActionID, []any{a.Asset, a.IsBuy, px, sz, a.ReduceOnly, tif, cloidUint(a.Cloid)}, nil
+}
+
+// VaultTransferAction deposits Usd micro USD to a vault or withdraws them
+type VaultTransferAction struct {
+	Vault     common.Address
+	IsDeposit bool
+	Usd       uint64
+}
+
+func (a VaultTransferAction) coreAction() (uint32, []any, error) {
+	return VaultTransferActionID, []any{a.Vault, a.IsDeposit, a.Usd}, nil
+}
+
+// TokenDelegateAction delegates Wei HYPE wei of the staking balance to a validator or
+// undelegates them
+type TokenDelegateAction struct {
+	Validator    common.Address
+	Wei          uint64
+	IsUndelegate bool
+}
+
+func (a TokenDelegateAction) coreAction() (uint32, []any, error) {
+	return TokenDelegateActionID, []any{a.Validator, a.Wei, a.IsUndelegate}, nil
+}
+
+// StakingDepositAction moves Wei HYPE wei from the spot balance to the staking balance
+type StakingDepositAction struct {
+	Wei uint64
+}
+
+func (a StakingDepositAction) coreAction() (uint32, []any, error) {
+	return StakingDepositActionID, []any{a.Wei}, nil
+}
+
+// StakingWithdrawAction moves Wei HYPE wei from the staking balance back to the spot
+// balance, after the unstaking queue
+type StakingWithdrawAction struct {
+	Wei uint64
+}
+
+func (a StakingWithdrawAction) coreAction() (uint32, []any, error) {
+	return StakingWithdrawActionID, []any{a.Wei}, nil
+}
+
+// SpotSendAction sends Wei units of the spot token of index Token, in its wei
+// decimals, to Destination on Core
+type SpotSendAction struct {
+	Destination common.Address
+	Token       uint64
+	Wei         uint64
+}
+
+func (a SpotSendAction) coreAction() (uint32, []any, error) {
+	return SpotSendActionID, []any{a.Destination, a.Token, a.Wei}, nil
+}
+
+// UsdClassTransferAction moves Ntl micro USD between the spot and perp balances
+type UsdClassTransferAction struct {
+	Ntl    uint64
+	ToPerp bool
+}
+
+func (a UsdClassTransferAction) coreAction() (uint32, []any, error) {
+	return UsdClassTransferActionID, []any{a.Ntl, a.ToPerp}, nil
+}
+
+// AddAPIWalletAction approves Wallet as an API wallet named Name
+type AddAPIWalletAction struct {
+	Wallet common.Address
+	Name   string
+}
+
+func (a AddAPIWalletAction) coreAction() (uint32, []any, error) {
+	return AddAPIWalletActionID, []any{a.Wallet, a.Name}, nil
+}
+
+// CancelByOidAction cancels the order Oid of Asset
+type CancelByOidAction struct {
+	Asset uint32
+	Oid   uint64
+}
+
+func (a CancelByOidAction) coreAction() (uint32, []any, error) {
+	return CancelByOidActionID, []any{a.Asset, a.Oid}, nil
+}
+
+// CancelByCloidAction cancels the order of Asset with the client order id Cloid
+type CancelByCloidAction struct {
+	Asset uint32
+	Cloid *types.Cloid
+}
+
+func (a CancelByCloidAction) coreAction() (uint32, []any, error) {
+	if a.Cloid == nil {
+		return 0, nil, fmt.Errorf("cancel by cloid requires a cloid")
+	}
+	return CancelByCloidActionID, []any{a.Asset, cloidUint(a.Cloid)}, nil
+}
+
+// coreActionArgs are the ABI types of the arguments of each action
+var coreActionArgs = map[uint32]abi.Arguments{
+	LimitOrderActionID:       arguments("uint32", "bool", "uint64", "uint64", "bool", "uint8", "uint128"),
+	VaultTransferActionID:    arguments("address", "bool", "uint64"),
+	TokenDelegateActionID:    arguments("address", "uint64", "bool"),
+	StakingDepositActionID:   arguments("uint64"),
+	StakingWithdrawActionID:  arguments("uint64"),
+	SpotSendActionID:         arguments("address", "uint64", "uint64"),
+	UsdClassTransferActionID: arguments("uint64", "bool"),
+	AddAPIWalletActionID:     arguments("address", "string"),
+	CancelByOidActionID:      arguments("uint32", "uint64"),
+	CancelByCloidActionID:    arguments("uint32", "uint128"),
+}
+
+func arguments(typeNames ...string) abi.Arguments {
+	args := make(abi.Arguments, len(typeNames))
+	for i, name := range typeNames {
+		typ, err := abi.NewType(name, "", nil)
+		if err != nil {
+			panic(fmt.Sprintf("invalid abi type %s: %v", name, err))
+		}
+		args[i] = abi.Argument{Type: typ}
+	}
+	return args
+}
+
+// EncodeAction encodes action as the raw action of CoreWriter: the version, the id of
+// the action in 3 bytes big-endian, then its ABI encoded arguments
+func EncodeAction(action CoreAction) ([]byte, error) {
+	id, values, err := action.coreAction()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := coreActionArgs[id].Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode action %d: %w", id, err)
+	}
+	return append([]byte{CoreActionVersion, byte(id >> 16), byte(id >> 8), byte(id)}, encoded...), nil
+}
+
+// coreWriterABI is the sendRawAction function of CoreWriter
+const coreWriterABI = `[{"type": "function", "name": "sendRawAction", "stateMutability": "nonpayable",
+	"inputs": [{"name": "data", "type": "bytes"}], "outputs": []}]`
+
+var coreWriter = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(coreWriterABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid abi: %v", err))
+	}
+	return parsed
+}()
+
+// ActionCalldata returns the calldata of the CoreWriter call sending action, e.g. to
+// send it from a contract or a multisig
+func ActionCalldata(action CoreAction) ([]byte, error) {
+	raw, err := EncodeAction(action)
+	if err != nil {
+		return nil, err
+	}
+	data, err := coreWriter.Pack("sendRawAction", raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sendRawAction: %w", err)
+	}
+	return data, nil
+}
+
+// SendAction sends action to Core from the address of key, in a transaction calling
+// CoreWriter, and returns its hash. The action is applied on Core once the
+// transaction is mined, and fails there silently.
+func (c *Client) SendAction(ctx context.Context, key *ecdsa.PrivateKey, action CoreAction) (common.Hash, error) {
+	data, err := ActionCalldata(action)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get chain id: %w", err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := c.PendingNonceAt(ctx, from)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	tip, err := c.SuggestGasTipCap(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get gas tip: %w", err)
+	}
+	head, err := c.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return common.Hash{}, fmt.Errorf("chain %v has no base fee", chainID)
+	}
+	gas, err := c.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &CoreWriterAddress, Data: data})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	tx := ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip),
+		Gas:       gas * 6 / 5,
+		To:        &CoreWriterAddress,
+		Data:      data,
+	})
+	signed, err := ethtypes.SignTx(tx, ethtypes.LatestSignerForChainID(chainID), key)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to sign action: %w", err)
+	}
+	if err := c.SendTransaction(ctx, signed); err != nil {
+		return common.Hash{}, fmt.Errorf("failed to send action: %w", err)
+	}
+	return signed.Hash(), nil
+}
+
+// toUint64 converts value to units with decimals, failing for values that do not fit
+func toUint64(name string, value float64, decimals int) (uint64, error) {
+	units := math.Round(value * math.Pow10(decimals))
+	if math.IsNaN(units) || units < 0 || units >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid %s: %v", name, value)
+	}
+	return uint64(units), nil
+}
+
+// cloidUint returns cloid as the uint128 of CoreWriter orders, zero for none
+func cloidUint(cloid *types.Cloid) *big.Int {
+	if cloid == nil {
+		return new(big.Int)
+	}
+	b := cloid.Bytes()
+	return new(big.Int).SetBytes(b[:])
+}
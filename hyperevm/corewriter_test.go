@@ -0,0 +1,175 @@
+package hyperevm
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+)
+
+func TestEncodeLimitOrder(t *testing.T) {
+	raw, err := EncodeAction(LimitOrderAction{
+		Asset:   1,
+		IsBuy:   true,
+		LimitPx: 3000.5,
+		Sz:      0.1,
+		Tif:     types.TifIoc,
+		Cloid:   types.NewCloidFromInt(5),
+	})
+	if err != nil {
+		t.Fatalf("EncodeAction() error = %v", err)
+	}
+	if got, want := raw[:4], []byte{1, 0, 0, 1}; !slices.Equal(got, want) {
+		t.Errorf("raw[:4] = %+v, want %+v", got, want)
+	}
+
+	values, err := coreActionArgs[LimitOrderActionID].Unpack(raw[4:])
+	if err != nil {
+		t.Fatalf("Unpack(raw[4:]) error = %v", err)
+	}
+	if want := []any{
+		uint32(1), true, uint64(300_050_000_000), uint64(10_000_000), false, uint8(3), big.NewInt(5),
+	}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %+v, want %+v", values, want)
+	}
+
+	noCloid, err := EncodeAction(LimitOrderAction{Asset: 10_001, LimitPx: 1, Sz: 1, Tif: types.TifAlo})
+	if err != nil {
+		t.Fatalf("EncodeAction() error = %v", err)
+	}
+	values, err = coreActionArgs[LimitOrderActionID].Unpack(noCloid[4:])
+	if err != nil {
+		t.Fatalf("Unpack(noCloid[4:]) error = %v", err)
+	}
+	if values[0] != uint32(10_001) {
+		t.Errorf("values[0] = %v, want uint32(10_001)", values[0])
+	}
+	if values[5] != uint8(1) {
+		t.Errorf("values[5] = %v, want uint8(1)", values[5])
+	}
+	if got := values[6].(*big.Int).Sign(); got != 0 {
+		t.Errorf("Sign() = %v, want 0", got)
+	}
+
+	_, err = EncodeAction(LimitOrderAction{LimitPx: 1, Sz: 1, Tif: "Fok"})
+	if err == nil || !strings.Contains(err.Error(), "invalid tif") {
+		t.Errorf("EncodeAction() error = %v, want %q", err, "invalid tif")
+	}
+	_, err = EncodeAction(LimitOrderAction{LimitPx: 1, Sz: -1, Tif: types.TifGtc})
+	if err == nil || !strings.Contains(err.Error(), "invalid size") {
+		t.Errorf("EncodeAction() error = %v, want %q", err, "invalid size")
+	}
+}
+
+func TestEncodeActions(t *testing.T) {
+	validator := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	word := func(hex string) string {
+		return strings.Repeat("0", 64-len(hex)) + hex
+	}
+	for _, tt := range []struct {
+		action CoreAction
+		want   string
+	}{
+		{UsdClassTransferAction{Ntl: 1_000_000, ToPerp: true}, "0x01000007" + word("0f4240") + word("01")},
+		{StakingDepositAction{Wei: 100}, "0x01000004" + word("64")},
+		{StakingWithdrawAction{Wei: 100}, "0x01000005" + word("64")},
+		{TokenDelegateAction{Validator: validator, Wei: 1}, "0x01000003" + word("dd") + word("01") + word("00")},
+		{VaultTransferAction{Vault: validator, IsDeposit: true, Usd: 2}, "0x01000002" + word("dd") + word("01") + word("02")},
+		{SpotSendAction{Destination: validator, Token: 1, Wei: 3}, "0x01000006" + word("dd") + word("01") + word("03")},
+		{CancelByOidAction{Asset: 4, Oid: 5}, "0x0100000a" + word("04") + word("05")},
+		{CancelByCloidAction{Asset: 4, Cloid: types.NewCloidFromInt(6)}, "0x0100000b" + word("04") + word("06")},
+		{AddAPIWalletAction{Wallet: validator, Name: "bot"}, "0x01000009" + word("dd") + word("40") + word("03") +
+			"626f740000000000000000000000000000000000000000000000000000000000"},
+	} {
+		raw, err := EncodeAction(tt.action)
+		if err != nil {
+			t.Fatalf("%T", tt.action)
+		}
+		if got := hexutil.Encode(raw); got != tt.want {
+			t.Errorf("%T", tt.action)
+		}
+	}
+
+	_, err := EncodeAction(CancelByCloidAction{Asset: 4})
+	if err == nil || !strings.Contains(err.Error(), "requires a cloid") {
+		t.Errorf("EncodeAction() error = %v, want %q", err, "requires a cloid")
+	}
+}
+
+func TestSendAction(t *testing.T) {
+	c, service := newClient(t)
+	key := hltest.NewTestKey(t)
+	action := UsdClassTransferAction{Ntl: 1_000_000, ToPerp: true}
+
+	hash, err := c.SendAction(context.Background(), key, action)
+	if err != nil {
+		t.Fatalf("SendAction() error = %v", err)
+	}
+
+	if len(service.sent) != 1 {
+		t.Fatalf("len(service.sent) = %d, want 1", len(service.sent))
+	}
+	tx := service.sent[0]
+	if got := tx.Hash(); got != hash {
+		t.Errorf("Hash() = %+v, want %+v", got, hash)
+	}
+	if got := *tx.To(); got != CoreWriterAddress {
+		t.Errorf("*tx.To() = %+v, want %+v", got, CoreWriterAddress)
+	}
+	if got, want := tx.ChainId(), big.NewInt(TestnetChainID); got.Cmp(want) != 0 {
+		t.Errorf("ChainId() = %+v, want %+v", got, want)
+	}
+	if got := tx.Gas(); got != uint64(60_000) {
+		t.Errorf("Gas() = %v, want uint64(60_000)", got)
+	}
+	if got, want := tx.GasFeeCap(), big.NewInt(200_000_001); got.Cmp(want) != 0 {
+		t.Errorf("GasFeeCap() = %+v, want %+v", got, want)
+	}
+	if got, want := tx.GasTipCap(), big.NewInt(1); got.Cmp(want) != 0 {
+		t.Errorf("GasTipCap() = %+v, want %+v", got, want)
+	}
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		t.Fatalf("Sender() error = %v", err)
+	}
+	if want := crypto.PubkeyToAddress(key.PublicKey); sender != want {
+		t.Errorf("sender = %+v, want %+v", sender, want)
+	}
+
+	data, err := ActionCalldata(action)
+	if err != nil {
+		t.Fatalf("ActionCalldata(action) error = %v", err)
+	}
+	if got := tx.Data(); !slices.Equal(got, data) {
+		t.Errorf("Data() = %+v, want %+v", got, data)
+	}
+	values, err := coreWriter.Methods["sendRawAction"].Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		t.Fatalf("Unpack(tx.Data()[4:]) error = %v", err)
+	}
+	raw, err := EncodeAction(action)
+	if err != nil {
+		t.Fatalf("EncodeAction(action) error = %v", err)
+	}
+	if want := []any{raw}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %+v, want %+v", values, want)
+	}
+
+	_, err = c.SendAction(context.Background(), key, CancelByCloidAction{})
+	if err == nil {
+		t.Error("SendAction() error = nil, want error")
+	}
+	if len(service.sent) != 1 {
+		t.Errorf("len(service.sent) = %d, want 1", len(service.sent))
+	}
+}
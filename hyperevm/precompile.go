@@ -0,0 +1,439 @@
+package hyperevm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// Addresses of the read precompiles exposing HyperCore state to HyperEVM
+var (
+	PositionPrecompile         = common.HexToAddress("0x0000000000000000000000000000000000000800")
+	SpotBalancePrecompile      = common.HexToAddress("0x0000000000000000000000000000000000000801")
+	VaultEquityPrecompile      = common.HexToAddress("0x0000000000000000000000000000000000000802")
+	WithdrawablePrecompile     = common.HexToAddress("0x0000000000000000000000000000000000000803")
+	DelegationsPrecompile      = common.HexToAddress("0x0000000000000000000000000000000000000804")
+	DelegatorSummaryPrecompile = common.HexToAddress("0x0000000000000000000000000000000000000805")
+	MarkPxPrecompile           = common.HexToAddress("0x0000000000000000000000000000000000000806")
+	OraclePxPrecompile         = common.HexToAddress("0x0000000000000000000000000000000000000807")
+	SpotPxPrecompile           = common.HexToAddress("0x0000000000000000000000000000000000000808")
+	L1BlockNumberPrecompile    = common.HexToAddress("0x0000000000000000000000000000000000000809")
+	PerpAssetInfoPrecompile    = common.HexToAddress("0x000000000000000000000000000000000000080a")
+	SpotInfoPrecompile         = common.HexToAddress("0x000000000000000000000000000000000000080b")
+)
+
+const (
+	// usdDecimals are the decimals of USD amounts read from and sent to Core
+	usdDecimals = 6
+	// hypeWeiDecimals are the decimals of staked HYPE amounts
+	hypeWeiDecimals = 8
+	// perpPxDecimals and spotPxDecimals are the decimals of prices read from Core,
+	// less the size decimals of the asset
+	perpPxDecimals = 6
+	spotPxDecimals = 8
+)
+
+// CorePosition is the perp position of a user read from Core. Szi is in units of the
+// size decimals of the asset, EntryNtl and IsolatedRawUsd in micro USD.
+type CorePosition struct {
+	Szi            int64
+	EntryNtl       uint64
+	IsolatedRawUsd int64
+	Leverage       uint32
+	IsIsolated     bool
+}
+
+// Position converts the position of coin to the position of the clearinghouse state.
+// The values depending on the mark price, the margin used, position value, return on
+// equity, unrealized pnl and liquidation price, are not read from Core and are left
+// empty.
+func (p CorePosition) Position(coin string, szDecimals int) types.Position {
+	position := types.Position{
+		Coin:     coin,
+		Szi:      formatUnits(big.NewInt(p.Szi), szDecimals),
+		Leverage: types.Leverage{Type: "cross", Value: int(p.Leverage)},
+	}
+	if p.Szi != 0 {
+		size := new(big.Int).Abs(big.NewInt(p.Szi))
+		ntl := new(big.Int).Mul(new(big.Int).SetUint64(p.EntryNtl), pow10Int(szDecimals))
+		px, _ := new(big.Rat).SetFrac(ntl, size.Mul(size, pow10Int(usdDecimals))).Float64()
+		entryPx := strconv.FormatFloat(px, 'f', -1, 64)
+		position.EntryPx = &entryPx
+	}
+	if p.IsIsolated {
+		rawUsd := formatUnits(big.NewInt(p.IsolatedRawUsd), usdDecimals)
+		position.Leverage.Type = "isolated"
+		position.Leverage.RawUsd = &rawUsd
+	}
+	return position
+}
+
+// CoreSpotBalance is the spot balance of a user read from Core. Total and Hold are in
+// units of the wei decimals of the token, EntryNtl in micro USD.
+type CoreSpotBalance struct {
+	Total    uint64
+	Hold     uint64
+	EntryNtl uint64
+}
+
+// Balance converts the balance of token to the balance of the spot clearinghouse
+// state
+func (b CoreSpotBalance) Balance(token types.SpotTokenInfo) types.SpotBalance {
+	return types.SpotBalance{
+		Coin:     token.Name,
+		Token:    token.Index,
+		Total:    formatUnits(new(big.Int).SetUint64(b.Total), token.WeiDecimals),
+		Hold:     formatUnits(new(big.Int).SetUint64(b.Hold), token.WeiDecimals),
+		EntryNtl: formatUnits(new(big.Int).SetUint64(b.EntryNtl), usdDecimals),
+	}
+}
+
+// CoreVaultEquity is the equity of a user in a vault read from Core, in micro USD, and
+// the time in milliseconds until which it is locked
+type CoreVaultEquity struct {
+	Equity               uint64
+	LockedUntilTimestamp uint64
+}
+
+// VaultEquity converts the equity in vault to the vault equity of a user
+func (e CoreVaultEquity) VaultEquity(vault common.Address) types.VaultEquity {
+	return types.VaultEquity{
+		VaultAddress: strings.ToLower(vault.Hex()),
+		Equity:       formatUnits(new(big.Int).SetUint64(e.Equity), usdDecimals),
+	}
+}
+
+// CoreDelegation is a delegation of a user read from Core, Amount in HYPE wei
+type CoreDelegation struct {
+	Validator            common.Address
+	Amount               uint64
+	LockedUntilTimestamp uint64
+}
+
+// Delegation converts the delegation to the staking delegation of a user
+func (d CoreDelegation) Delegation() types.Delegation {
+	return types.Delegation{
+		Validator:            strings.ToLower(d.Validator.Hex()),
+		Amount:               formatUnits(new(big.Int).SetUint64(d.Amount), hypeWeiDecimals),
+		LockedUntilTimestamp: int64(d.LockedUntilTimestamp),
+	}
+}
+
+// CoreDelegatorSummary is the staking summary of a user read from Core, amounts in
+// HYPE wei
+type CoreDelegatorSummary struct {
+	Delegated              uint64
+	Undelegated            uint64
+	TotalPendingWithdrawal uint64
+	NPendingWithdrawals    uint64
+}
+
+// Summary converts the summary to the delegator summary of a user
+func (s CoreDelegatorSummary) Summary() types.DelegatorSummary {
+	return types.DelegatorSummary{
+		Delegated:              formatUnits(new(big.Int).SetUint64(s.Delegated), hypeWeiDecimals),
+		Undelegated:            formatUnits(new(big.Int).SetUint64(s.Undelegated), hypeWeiDecimals),
+		TotalPendingWithdrawal: formatUnits(new(big.Int).SetUint64(s.TotalPendingWithdrawal), hypeWeiDecimals),
+		NPendingWithdrawals:    int(s.NPendingWithdrawals),
+	}
+}
+
+// CorePerpAssetInfo is the metadata of a perp read from Core
+type CorePerpAssetInfo struct {
+	Coin          string
+	MarginTableID uint32 `abi:"marginTableId"`
+	SzDecimals    uint8
+	MaxLeverage   uint8
+	OnlyIsolated  bool
+}
+
+// AssetInfo converts the metadata to the asset of the perp universe
+func (i CorePerpAssetInfo) AssetInfo() types.AssetInfo {
+	return types.AssetInfo{
+		Name:          i.Coin,
+		SzDecimals:    int(i.SzDecimals),
+		MaxLeverage:   int(i.MaxLeverage),
+		MarginTableID: int(i.MarginTableID),
+	}
+}
+
+// CoreSpotInfo is the metadata of a spot pair read from Core, Tokens the indexes of
+// its base and quote tokens
+type CoreSpotInfo struct {
+	Name   string
+	Tokens [2]uint64
+}
+
+// PerpPx converts a mark or oracle price of a perp read from Core to a price
+func PerpPx(px uint64, szDecimals int) float64 {
+	return scale(px, perpPxDecimals-szDecimals)
+}
+
+// SpotPx converts a spot price read from Core to a price, using the size decimals of
+// the base token
+func SpotPx(px uint64, szDecimals int) float64 {
+	return scale(px, spotPxDecimals-szDecimals)
+}
+
+// l1ReadABI declares the precompiles as functions. Precompiles take the ABI encoded
+// arguments without a selector.
+const l1ReadABI = `[
+	{"type": "function", "name": "position", "stateMutability": "view",
+		"inputs": [{"name": "user", "type": "address"}, {"name": "perp", "type": "uint16"}],
+		"outputs": [{"name": "", "type": "tuple", "components": [
+			{"name": "szi", "type": "int64"}, {"name": "entryNtl", "type": "uint64"},
+			{"name": "isolatedRawUsd", "type": "int64"}, {"name": "leverage", "type": "uint32"},
+			{"name": "isIsolated", "type": "bool"}]}]},
+	{"type": "function", "name": "spotBalance", "stateMutability": "view",
+		"inputs": [{"name": "user", "type": "address"}, {"name": "token", "type": "uint64"}],
+		"outputs": [{"name": "", "type": "tuple", "components": [
+			{"name": "total", "type": "uint64"}, {"name": "hold", "type": "uint64"},
+			{"name": "entryNtl", "type": "uint64"}]}]},
+	{"type": "function", "name": "vaultEquity", "stateMutability": "view",
+		"inputs": [{"name": "user", "type": "address"}, {"name": "vault", "type": "address"}],
+		"outputs": [{"name": "", "type": "tuple", "components": [
+			{"name": "equity", "type": "uint64"}, {"name": "lockedUntilTimestamp", "type": "uint64"}]}]},
+	{"type": "function", "name": "withdrawable", "stateMutability": "view",
+		"inputs": [{"name": "user", "type": "address"}],
+		"outputs": [{"name": "withdrawable", "type": "uint64"}]},
+	{"type": "function", "name": "delegations", "stateMutability": "view",
+		"inputs": [{"name": "user", "type": "address"}],
+		"outputs": [{"name": "", "type": "tuple[]", "components": [
+			{"name": "validator", "type": "address"}, {"name": "amount", "type": "uint64"},
+			{"name": "lockedUntilTimestamp", "type": "uint64"}]}]},
+	{"type": "function", "name": "delegatorSummary", "stateMutability": "view",
+		"inputs": [{"name": "user", "type": "address"}],
+		"outputs": [{"name": "", "type": "tuple", "components": [
+			{"name": "delegated", "type": "uint64"}, {"name": "undelegated", "type": "uint64"},
+			{"name": "totalPendingWithdrawal", "type": "uint64"}, {"name": "nPendingWithdrawals", "type": "uint64"}]}]},
+	{"type": "function", "name": "markPx", "stateMutability": "view",
+		"inputs": [{"name": "index", "type": "uint32"}], "outputs": [{"name": "", "type": "uint64"}]},
+	{"type": "function", "name": "oraclePx", "stateMutability": "view",
+		"inputs": [{"name": "index", "type": "uint32"}], "outputs": [{"name": "", "type": "uint64"}]},
+	{"type": "function", "name": "spotPx", "stateMutability": "view",
+		"inputs": [{"name": "index", "type": "uint32"}], "outputs": [{"name": "", "type": "uint64"}]},
+	{"type": "function", "name": "l1BlockNumber", "stateMutability": "view",
+		"inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+	{"type": "function", "name": "perpAssetInfo", "stateMutability": "view",
+		"inputs": [{"name": "perp", "type": "uint32"}],
+		"outputs": [{"name": "", "type": "tuple", "components": [
+			{"name": "coin", "type": "string"}, {"name": "marginTableId", "type": "uint32"},
+			{"name": "szDecimals", "type": "uint8"}, {"name": "maxLeverage", "type": "uint8"},
+			{"name": "onlyIsolated", "type": "bool"}]}]},
+	{"type": "function", "name": "spotInfo", "stateMutability": "view",
+		"inputs": [{"name": "spot", "type": "uint32"}],
+		"outputs": [{"name": "", "type": "tuple", "components": [
+			{"name": "name", "type": "string"}, {"name": "tokens", "type": "uint64[2]"}]}]}
+]`
+
+var l1Read = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(l1ReadABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid abi: %v", err))
+	}
+	return parsed
+}()
+
+// Position returns the position of user in the perp of index
+func (c *Client) Position(ctx context.Context, user common.Address, perp uint16) (*CorePosition, error) {
+	var position CorePosition
+	if err := c.read(ctx, PositionPrecompile, "position", &position, user, perp); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// SpotBalance returns the balance of user in the spot token of index
+func (c *Client) SpotBalance(ctx context.Context, user common.Address, token uint64) (*CoreSpotBalance, error) {
+	var balance CoreSpotBalance
+	if err := c.read(ctx, SpotBalancePrecompile, "spotBalance", &balance, user, token); err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// VaultEquity returns the equity of user in vault
+func (c *Client) VaultEquity(ctx context.Context, user, vault common.Address) (*CoreVaultEquity, error) {
+	var equity CoreVaultEquity
+	if err := c.read(ctx, VaultEquityPrecompile, "vaultEquity", &equity, user, vault); err != nil {
+		return nil, err
+	}
+	return &equity, nil
+}
+
+// Withdrawable returns the USD withdrawable by user from its perp account
+func (c *Client) Withdrawable(ctx context.Context, user common.Address) (float64, error) {
+	var withdrawable uint64
+	if err := c.read(ctx, WithdrawablePrecompile, "withdrawable", &withdrawable, user); err != nil {
+		return 0, err
+	}
+	return scale(withdrawable, usdDecimals), nil
+}
+
+// Delegations returns the staking delegations of user
+func (c *Client) Delegations(ctx context.Context, user common.Address) ([]CoreDelegation, error) {
+	var delegations []CoreDelegation
+	if err := c.read(ctx, DelegationsPrecompile, "delegations", &delegations, user); err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// DelegatorSummary returns the staking summary of user
+func (c *Client) DelegatorSummary(ctx context.Context, user common.Address) (*CoreDelegatorSummary, error) {
+	var summary CoreDelegatorSummary
+	if err := c.read(ctx, DelegatorSummaryPrecompile, "delegatorSummary", &summary, user); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// MarkPx returns the mark price of the perp of index, see PerpPx
+func (c *Client) MarkPx(ctx context.Context, perp uint32) (uint64, error) {
+	var px uint64
+	err := c.read(ctx, MarkPxPrecompile, "markPx", &px, perp)
+	return px, err
+}
+
+// OraclePx returns the oracle price of the perp of index, see PerpPx
+func (c *Client) OraclePx(ctx context.Context, perp uint32) (uint64, error) {
+	var px uint64
+	err := c.read(ctx, OraclePxPrecompile, "oraclePx", &px, perp)
+	return px, err
+}
+
+// SpotPx returns the price of the spot pair of index, see SpotPx
+func (c *Client) SpotPx(ctx context.Context, spot uint32) (uint64, error) {
+	var px uint64
+	err := c.read(ctx, SpotPxPrecompile, "spotPx", &px, spot)
+	return px, err
+}
+
+// L1BlockNumber returns the number of the latest HyperCore block
+func (c *Client) L1BlockNumber(ctx context.Context) (uint64, error) {
+	var number uint64
+	err := c.read(ctx, L1BlockNumberPrecompile, "l1BlockNumber", &number)
+	return number, err
+}
+
+// PerpAssetInfo returns the metadata of the perp of index
+func (c *Client) PerpAssetInfo(ctx context.Context, perp uint32) (*CorePerpAssetInfo, error) {
+	var info CorePerpAssetInfo
+	if err := c.read(ctx, PerpAssetInfoPrecompile, "perpAssetInfo", &info, perp); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// SpotInfo returns the metadata of the spot pair of index
+func (c *Client) SpotInfo(ctx context.Context, spot uint32) (*CoreSpotInfo, error) {
+	var info CoreSpotInfo
+	if err := c.read(ctx, SpotInfoPrecompile, "spotInfo", &info, spot); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// MarkPrice returns the mark price of the perp coin of meta
+func (c *Client) MarkPrice(ctx context.Context, meta *types.Meta, coin string) (float64, error) {
+	asset, index, ok := meta.FindAsset(coin)
+	if !ok {
+		return 0, fmt.Errorf("unknown perp %s", coin)
+	}
+	px, err := c.MarkPx(ctx, uint32(index))
+	if err != nil {
+		return 0, err
+	}
+	return PerpPx(px, asset.SzDecimals), nil
+}
+
+// OraclePrice returns the oracle price of the perp coin of meta
+func (c *Client) OraclePrice(ctx context.Context, meta *types.Meta, coin string) (float64, error) {
+	asset, index, ok := meta.FindAsset(coin)
+	if !ok {
+		return 0, fmt.Errorf("unknown perp %s", coin)
+	}
+	px, err := c.OraclePx(ctx, uint32(index))
+	if err != nil {
+		return 0, err
+	}
+	return PerpPx(px, asset.SzDecimals), nil
+}
+
+// UserPosition returns the position of user in the perp coin of meta
+func (c *Client) UserPosition(ctx context.Context, meta *types.Meta, user common.Address, coin string) (*types.Position, error) {
+	asset, index, ok := meta.FindAsset(coin)
+	if !ok {
+		return nil, fmt.Errorf("unknown perp %s", coin)
+	}
+	position, err := c.Position(ctx, user, uint16(index))
+	if err != nil {
+		return nil, err
+	}
+	converted := position.Position(asset.Name, asset.SzDecimals)
+	return &converted, nil
+}
+
+// UserSpotBalance returns the balance of user in the spot token of meta named
+// nameOrID or with that token id
+func (c *Client) UserSpotBalance(ctx context.Context, meta *types.SpotMeta, user common.Address, nameOrID string) (*types.SpotBalance, error) {
+	token, ok := meta.FindToken(nameOrID)
+	if !ok {
+		return nil, fmt.Errorf("unknown token %s", nameOrID)
+	}
+	balance, err := c.SpotBalance(ctx, user, uint64(token.Index))
+	if err != nil {
+		return nil, err
+	}
+	converted := balance.Balance(token)
+	return &converted, nil
+}
+
+// read calls the precompile at address declared as method with args, and decodes its
+// result into result
+func (c *Client) read(ctx context.Context, address common.Address, method string, result any, args ...any) error {
+	m := l1Read.Methods[method]
+	data, err := m.Inputs.Pack(args...)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s call: %w", method, err)
+	}
+	out, err := c.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", method, err)
+	}
+	values, err := m.Outputs.Unpack(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", method, err)
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("invalid %s %v", method, values)
+	}
+	abi.ConvertType(values[0], result)
+	return nil
+}
+
+// formatUnits formats units with decimals as a decimal string without trailing zeros
+func formatUnits(units *big.Int, decimals int) string {
+	if decimals <= 0 {
+		return new(big.Int).Mul(units, pow10Int(-decimals)).String()
+	}
+	s := new(big.Rat).SetFrac(units, pow10Int(decimals)).FloatString(decimals)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+func scale(units uint64, decimals int) float64 {
+	value, _ := strconv.ParseFloat(formatUnits(new(big.Int).SetUint64(units), decimals), 64)
+	return value
+}
+
+func pow10Int(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,285 @@
+package hyperevm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+var user = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+// answer makes the precompile at address answer calls declared as method with output,
+// after checking the call arguments are args
+func answer(t *testing.T, service *ethService, address common.Address, method string, output any, args ...any) {
+	t.Helper()
+	m := l1Read.Methods[method]
+	if args == nil {
+		args = []any{}
+	}
+	service.precompiles[address] = func(input []byte) ([]byte, error) {
+		values, err := m.Inputs.Unpack(input)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(values, args) {
+			t.Errorf("%s arguments = %v, want %v", method, values, args)
+			return nil, errors.New("execution reverted")
+		}
+		return m.Outputs.Pack(output)
+	}
+}
+
+func testMeta() *types.Meta {
+	return &types.Meta{Universe: []types.AssetInfo{
+		{Name: "BTC", SzDecimals: 5, MaxLeverage: 40},
+		{Name: "ETH", SzDecimals: 4, MaxLeverage: 25},
+	}}
+}
+
+func TestPositionPrecompile(t *testing.T) {
+	c, service := newClient(t)
+	ctx := context.Background()
+	answer(t, service, PositionPrecompile, "position",
+		CorePosition{Szi: -15_000, EntryNtl: 4_500_000_000, Leverage: 10}, user, uint16(1))
+
+	raw, err := c.Position(ctx, user, 1)
+	if err != nil {
+		t.Fatalf("Position(ctx, user, 1) error = %v", err)
+	}
+	if want := (CorePosition{Szi: -15_000, EntryNtl: 4_500_000_000, Leverage: 10}); *raw != want {
+		t.Errorf("*raw = %+v, want %+v", *raw, want)
+	}
+
+	position, err := c.UserPosition(ctx, testMeta(), user, "ETH")
+	if err != nil {
+		t.Fatalf("UserPosition() error = %v", err)
+	}
+	if position.Coin != "ETH" {
+		t.Errorf("position.Coin = %q, want %q", position.Coin, "ETH")
+	}
+	if position.Szi != "-1.5" {
+		t.Errorf("position.Szi = %q, want %q", position.Szi, "-1.5")
+	}
+	if position.EntryPx == nil {
+		t.Fatal("position.EntryPx = nil")
+	}
+	if *position.EntryPx != "3000" {
+		t.Errorf("*position.EntryPx = %q, want %q", *position.EntryPx, "3000")
+	}
+	if want := (types.Leverage{Type: "cross", Value: 10}); !reflect.DeepEqual(position.Leverage, want) {
+		t.Errorf("position.Leverage = %+v, want %+v", position.Leverage, want)
+	}
+
+	_, err = c.UserPosition(ctx, testMeta(), user, "DOGE")
+	if err == nil || !strings.Contains(err.Error(), "unknown perp DOGE") {
+		t.Errorf("UserPosition() error = %v, want %q", err, "unknown perp DOGE")
+	}
+	_, err = c.Withdrawable(ctx, user)
+	if err == nil || !strings.Contains(err.Error(), "failed to read withdrawable") {
+		t.Errorf("Withdrawable(ctx, user) error = %v, want %q", err, "failed to read withdrawable")
+	}
+}
+
+func TestCorePositionConversion(t *testing.T) {
+	position := CorePosition{Szi: 25, EntryNtl: 1_500_000, IsolatedRawUsd: -1_250_000, Leverage: 3, IsIsolated: true}.Position("BTC", 5)
+	if position.Szi != "0.00025" {
+		t.Errorf("position.Szi = %q, want %q", position.Szi, "0.00025")
+	}
+	if *position.EntryPx != "6000" {
+		t.Errorf("*position.EntryPx = %q, want %q", *position.EntryPx, "6000")
+	}
+	if position.Leverage.Type != "isolated" {
+		t.Errorf("position.Leverage.Type = %q, want %q", position.Leverage.Type, "isolated")
+	}
+	if *position.Leverage.RawUsd != "-1.25" {
+		t.Errorf("*position.Leverage.RawUsd = %q, want %q", *position.Leverage.RawUsd, "-1.25")
+	}
+
+	flat := CorePosition{Leverage: 20}.Position("BTC", 5)
+	if flat.Szi != "0" {
+		t.Errorf("flat.Szi = %q, want %q", flat.Szi, "0")
+	}
+	if flat.EntryPx != nil {
+		t.Errorf("flat.EntryPx = %v, want nil", flat.EntryPx)
+	}
+}
+
+func TestSpotBalancePrecompile(t *testing.T) {
+	c, service := newClient(t)
+	ctx := context.Background()
+	answer(t, service, SpotBalancePrecompile, "spotBalance",
+		CoreSpotBalance{Total: 1_250_000, EntryNtl: 2_000_000}, user, uint64(1))
+
+	balance, err := c.UserSpotBalance(ctx, testSpotMeta(), user, "PURR")
+	if err != nil {
+		t.Fatalf("UserSpotBalance() error = %v", err)
+	}
+	if want := (types.SpotBalance{Coin: "PURR", Token: 1, Total: "12.5", Hold: "0", EntryNtl: "2"}); *balance != want {
+		t.Errorf("*balance = %+v, want %+v", *balance, want)
+	}
+
+	_, err = c.UserSpotBalance(ctx, testSpotMeta(), user, "NOPE")
+	if err == nil || !strings.Contains(err.Error(), "unknown token NOPE") {
+		t.Errorf("UserSpotBalance() error = %v, want %q", err, "unknown token NOPE")
+	}
+}
+
+func TestPricePrecompiles(t *testing.T) {
+	c, service := newClient(t)
+	ctx := context.Background()
+	answer(t, service, MarkPxPrecompile, "markPx", uint64(300_012), uint32(1))
+	answer(t, service, OraclePxPrecompile, "oraclePx", uint64(300_000), uint32(1))
+	answer(t, service, SpotPxPrecompile, "spotPx", uint64(1_234_567), uint32(0))
+	answer(t, service, L1BlockNumberPrecompile, "l1BlockNumber", uint64(123_456))
+
+	mark, err := c.MarkPrice(ctx, testMeta(), "ETH")
+	if err != nil {
+		t.Fatalf("MarkPrice(ctx, testMeta(), ETH) error = %v", err)
+	}
+	if mark != 3000.12 {
+		t.Errorf("mark = %v, want 3000.12", mark)
+	}
+	oracle, err := c.OraclePrice(ctx, testMeta(), "ETH")
+	if err != nil {
+		t.Fatalf("OraclePrice(ctx, testMeta(), ETH) error = %v", err)
+	}
+	if oracle != 3000.0 {
+		t.Errorf("oracle = %v, want 3000.0", oracle)
+	}
+
+	px, err := c.SpotPx(ctx, 0)
+	if err != nil {
+		t.Fatalf("SpotPx(ctx, 0) error = %v", err)
+	}
+	if got := SpotPx(px, 0); got != 0.01234567 {
+		t.Errorf("SpotPx(px, 0) = %v, want 0.01234567", got)
+	}
+
+	number, err := c.L1BlockNumber(ctx)
+	if err != nil {
+		t.Fatalf("L1BlockNumber(ctx) error = %v", err)
+	}
+	if number != uint64(123_456) {
+		t.Errorf("number = %v, want uint64(123_456)", number)
+	}
+}
+
+func TestAccountPrecompiles(t *testing.T) {
+	c, service := newClient(t)
+	ctx := context.Background()
+	vault := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	validator := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	answer(t, service, WithdrawablePrecompile, "withdrawable", uint64(12_345_678), user)
+	answer(t, service, VaultEquityPrecompile, "vaultEquity",
+		CoreVaultEquity{Equity: 100_500_000, LockedUntilTimestamp: 1_700_000_000_000}, user, vault)
+	answer(t, service, DelegationsPrecompile, "delegations", []CoreDelegation{
+		{Validator: validator, Amount: 150_000_000, LockedUntilTimestamp: 1_700_000_000_000},
+	}, user)
+	answer(t, service, DelegatorSummaryPrecompile, "delegatorSummary",
+		CoreDelegatorSummary{Delegated: 150_000_000, Undelegated: 5_000_000, NPendingWithdrawals: 1}, user)
+
+	withdrawable, err := c.Withdrawable(ctx, user)
+	if err != nil {
+		t.Fatalf("Withdrawable(ctx, user) error = %v", err)
+	}
+	if withdrawable != 12.345678 {
+		t.Errorf("withdrawable = %v, want 12.345678", withdrawable)
+	}
+
+	equity, err := c.VaultEquity(ctx, user, vault)
+	if err != nil {
+		t.Fatalf("VaultEquity(ctx, user, vault) error = %v", err)
+	}
+	if equity.LockedUntilTimestamp != uint64(1_700_000_000_000) {
+		t.Errorf("equity.LockedUntilTimestamp = %v, want uint64(1_700_000_000_000)", equity.LockedUntilTimestamp)
+	}
+	if got, want := equity.VaultEquity(vault), (types.VaultEquity{VaultAddress: "0x00000000000000000000000000000000000000cc", Equity: "100.5"}); got != want {
+		t.Errorf("VaultEquity(vault) = %+v, want %+v", got, want)
+	}
+
+	delegations, err := c.Delegations(ctx, user)
+	if err != nil {
+		t.Fatalf("Delegations(ctx, user) error = %v", err)
+	}
+	if len(delegations) != 1 {
+		t.Fatalf("len(delegations) = %d, want 1", len(delegations))
+	}
+	if got, want := delegations[0].Delegation(), (types.Delegation{
+		Validator:            "0x00000000000000000000000000000000000000dd",
+		Amount:               "1.5",
+		LockedUntilTimestamp: 1_700_000_000_000,
+	}); got != want {
+		t.Errorf("Delegation() = %+v, want %+v", got, want)
+	}
+
+	summary, err := c.DelegatorSummary(ctx, user)
+	if err != nil {
+		t.Fatalf("DelegatorSummary(ctx, user) error = %v", err)
+	}
+	if got, want := summary.Summary(), (types.DelegatorSummary{
+		Delegated:              "1.5",
+		Undelegated:            "0.05",
+		TotalPendingWithdrawal: "0",
+		NPendingWithdrawals:    1,
+	}); got != want {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestInfoPrecompiles(t *testing.T) {
+	c, service := newClient(t)
+	ctx := context.Background()
+	answer(t, service, PerpAssetInfoPrecompile, "perpAssetInfo",
+		CorePerpAssetInfo{Coin: "ETH", MarginTableID: 25, SzDecimals: 4, MaxLeverage: 25}, uint32(1))
+	answer(t, service, SpotInfoPrecompile, "spotInfo", CoreSpotInfo{Name: "@1", Tokens: [2]uint64{1, 0}}, uint32(1))
+
+	info, err := c.PerpAssetInfo(ctx, 1)
+	if err != nil {
+		t.Fatalf("PerpAssetInfo(ctx, 1) error = %v", err)
+	}
+	if got, want := info.AssetInfo(), (types.AssetInfo{Name: "ETH", SzDecimals: 4, MaxLeverage: 25, MarginTableID: 25}); got != want {
+		t.Errorf("AssetInfo() = %+v, want %+v", got, want)
+	}
+	if info.OnlyIsolated {
+		t.Error("info.OnlyIsolated = true")
+	}
+
+	spot, err := c.SpotInfo(ctx, 1)
+	if err != nil {
+		t.Fatalf("SpotInfo(ctx, 1) error = %v", err)
+	}
+	if want := (CoreSpotInfo{Name: "@1", Tokens: [2]uint64{1, 0}}); *spot != want {
+		t.Errorf("*spot = %+v, want %+v", *spot, want)
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	for _, tt := range []struct {
+		units    int64
+		decimals int
+		want     string
+	}{
+		{0, 6, "0"},
+		{1_500_000, 6, "1.5"},
+		{-25, 5, "-0.00025"},
+		{100, 0, "100"},
+		{12, -2, "1200"},
+	} {
+		if got := formatUnits(big.NewInt(tt.units), tt.decimals); got != tt.want {
+			t.Errorf("formatUnits() = %q, want %q", got, tt.want)
+		}
+	}
+	if got := PerpPx(300_012, 4); got != 3000.12 {
+		t.Errorf("PerpPx(300_012, 4) = %v, want 3000.12", got)
+	}
+	if got := PerpPx(650_000, 5); got != 65000.0 {
+		t.Errorf("PerpPx(650_000, 5) = %v, want 65000.0", got)
+	}
+}
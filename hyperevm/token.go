@@ -0,0 +1,179 @@
+package hyperevm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+)
+
+// HypeSystemAddress is the address receiving HYPE sent from Core to EVM, and to which
+// HYPE is sent back
+var HypeSystemAddress = common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+// HypeToken is the name of HYPE, the native token of HyperEVM
+const HypeToken = "HYPE"
+
+// Token is a spot token of HyperCore linked to a HyperEVM contract
+type Token struct {
+	Name    string
+	Index   int
+	TokenID string
+	// Contract is the ERC-20 contract of the token, the zero address for HYPE
+	Contract common.Address
+	// WeiDecimals are the decimals of the token on Core, and EvmDecimals on EVM, which
+	// adds the extra wei decimals of the contract
+	WeiDecimals int
+	EvmDecimals int
+	// SystemAddress receives the token sent from Core to EVM
+	SystemAddress common.Address
+}
+
+// Wire returns the token of spot sends, "name:tokenId"
+func (t Token) Wire() string {
+	return t.Name + ":" + t.TokenID
+}
+
+// IsNative returns true for HYPE, the native token of HyperEVM
+func (t Token) IsNative() bool {
+	return t.Name == HypeToken
+}
+
+// ToUnits converts an amount of the token to its smallest units on EVM
+func (t Token) ToUnits(amount float64) (*big.Int, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+		return nil, fmt.Errorf("invalid %s amount: %v", t.Name, amount)
+	}
+	// The shortest decimal of amount, so that 0.1 is 10^(decimals-1) units
+	whole, frac, _ := strings.Cut(strconv.FormatFloat(amount, 'f', -1, 64), ".")
+	if len(frac) > t.EvmDecimals {
+		frac = frac[:t.EvmDecimals]
+	}
+	frac += strings.Repeat("0", t.EvmDecimals-len(frac))
+	units, ok := new(big.Int).SetString(whole+frac, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s amount: %v", t.Name, amount)
+	}
+	return units, nil
+}
+
+// FromUnits converts smallest units of the token on EVM to an amount
+func (t Token) FromUnits(units *big.Int) float64 {
+	amount, _ := new(big.Float).Quo(new(big.Float).SetInt(units), pow10(t.EvmDecimals)).Float64()
+	return amount
+}
+
+func pow10(n int) *big.Float {
+	return new(big.Float).SetInt(pow10Int(n))
+}
+
+// SystemAddress returns the address receiving the spot token of index sent from Core
+// to EVM, 0x20 followed by the index, big-endian. HYPE is received by
+// HypeSystemAddress instead.
+func SystemAddress(index int) common.Address {
+	var address common.Address
+	address[0] = 0x20
+	new(big.Int).SetUint64(uint64(index)).FillBytes(address[12:])
+	return address
+}
+
+// LinkedTokens returns the spot tokens of meta linked to an EVM contract, and HYPE
+func LinkedTokens(meta *types.SpotMeta) []Token {
+	var tokens []Token
+	for _, info := range meta.Tokens {
+		if token, ok := linkedToken(info); ok {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// FindToken returns the linked token of meta named nameOrID or with that token id
+func FindToken(meta *types.SpotMeta, nameOrID string) (Token, bool) {
+	info, ok := meta.FindToken(nameOrID)
+	if !ok {
+		return Token{}, false
+	}
+	return linkedToken(info)
+}
+
+func linkedToken(info types.SpotTokenInfo) (Token, bool) {
+	token := Token{
+		Name:          info.Name,
+		Index:         info.Index,
+		TokenID:       info.TokenID,
+		WeiDecimals:   info.WeiDecimals,
+		EvmDecimals:   info.WeiDecimals,
+		SystemAddress: SystemAddress(info.Index),
+	}
+	switch {
+	case info.Name == HypeToken:
+		// HYPE has 18 decimals on EVM, as an ether-like native token
+		token.EvmDecimals = 18
+		token.SystemAddress = HypeSystemAddress
+	case info.EvmContract != nil:
+		token.Contract = common.HexToAddress(info.EvmContract.Address)
+		token.EvmDecimals += info.EvmContract.EvmExtraWeiDecimals
+	default:
+		return Token{}, false
+	}
+	return token, true
+}
+
+// erc20ABI is the balanceOf function of ERC-20
+const erc20ABI = `[{"type": "function", "name": "balanceOf", "stateMutability": "view",
+	"inputs": [{"name": "account", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]}]`
+
+var erc20 = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid abi: %v", err))
+	}
+	return parsed
+}()
+
+// TokenBalance returns the balance of token held by holder on EVM
+func (c *Client) TokenBalance(ctx context.Context, token Token, holder common.Address) (float64, error) {
+	if token.IsNative() {
+		units, err := c.BalanceAt(ctx, holder, nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get %s balance: %w", token.Name, err)
+		}
+		return token.FromUnits(units), nil
+	}
+	data, err := erc20.Pack("balanceOf", holder)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode balance call: %w", err)
+	}
+	out, err := c.CallContract(ctx, ethereum.CallMsg{To: &token.Contract, Data: data}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s balance: %w", token.Name, err)
+	}
+	values, err := erc20.Unpack("balanceOf", out)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode %s balance: %w", token.Name, err)
+	}
+	var units *big.Int
+	if len(values) == 1 {
+		units, _ = values[0].(*big.Int)
+	}
+	if units == nil {
+		return 0, fmt.Errorf("invalid %s balance %v", token.Name, values)
+	}
+	return token.FromUnits(units), nil
+}
+
+// SendToEvm sends amount of token from the spot balance of exchange on Core to the
+// same address on EVM, a spot send to the system address of the token
+func SendToEvm(exchange client.Exchanger, token Token, amount float64) (*types.DefaultResponse, error) {
+	return exchange.SpotTransfer(amount, token.SystemAddress.Hex(), token.Wire())
+}
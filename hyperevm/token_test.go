@@ -0,0 +1,174 @@
+package hyperevm
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+)
+
+var (
+	purrContract = common.HexToAddress("0x9b498c3c8a0b8cd8ba1d9851d40d186f1872b44e")
+	holder       = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+)
+
+func testSpotMeta() *types.SpotMeta {
+	return &types.SpotMeta{Tokens: []types.SpotTokenInfo{
+		{Name: "USDC", WeiDecimals: 8, Index: 0, TokenID: "0x6d1e7cde53ba9467b783cb7c530ce054"},
+		{Name: "PURR", WeiDecimals: 5, Index: 1, TokenID: "0xc4bf3f870c0e9465323c0b6ed28096c2",
+			EvmContract: &types.EvmContract{Address: purrContract.Hex(), EvmExtraWeiDecimals: 13}},
+		{Name: "HYPE", WeiDecimals: 8, Index: 150, TokenID: "0x0d01dc56dcaaca66ad901c959b4011ec"},
+	}}
+}
+
+func TestLinkedTokens(t *testing.T) {
+	tokens := LinkedTokens(testSpotMeta())
+	if len(tokens) != 2 {
+		t.Fatalf("len(tokens) = %d, want 2", len(tokens))
+	}
+
+	purr := tokens[0]
+	if purr.Name != "PURR" {
+		t.Errorf("purr.Name = %q, want %q", purr.Name, "PURR")
+	}
+	if purr.Contract != purrContract {
+		t.Errorf("purr.Contract = %+v, want %+v", purr.Contract, purrContract)
+	}
+	if purr.WeiDecimals != 5 {
+		t.Errorf("purr.WeiDecimals = %v, want 5", purr.WeiDecimals)
+	}
+	if purr.EvmDecimals != 18 {
+		t.Errorf("purr.EvmDecimals = %v, want 18", purr.EvmDecimals)
+	}
+	if want := common.HexToAddress("0x2000000000000000000000000000000000000001"); purr.SystemAddress != want {
+		t.Errorf("purr.SystemAddress = %+v, want %+v", purr.SystemAddress, want)
+	}
+	if got := purr.Wire(); got != "PURR:0xc4bf3f870c0e9465323c0b6ed28096c2" {
+		t.Errorf("Wire() = %q, want %q", got, "PURR:0xc4bf3f870c0e9465323c0b6ed28096c2")
+	}
+	if purr.IsNative() {
+		t.Error("IsNative() = true")
+	}
+
+	hype := tokens[1]
+	if !hype.IsNative() {
+		t.Error("IsNative() = false")
+	}
+	if hype.SystemAddress != HypeSystemAddress {
+		t.Errorf("hype.SystemAddress = %+v, want %+v", hype.SystemAddress, HypeSystemAddress)
+	}
+	if want := (common.Address{}); hype.Contract != want {
+		t.Errorf("hype.Contract = %+v, want %+v", hype.Contract, want)
+	}
+	if hype.EvmDecimals != 18 {
+		t.Errorf("hype.EvmDecimals = %v, want 18", hype.EvmDecimals)
+	}
+
+	_, ok := FindToken(testSpotMeta(), "USDC")
+	// USDC is not linked
+	if ok {
+		t.Error("ok = true")
+	}
+	token, ok := FindToken(testSpotMeta(), "0xc4bf3f870c0e9465323c0b6ed28096c2")
+	if !ok {
+		t.Fatal("ok = false")
+	}
+	if token != purr {
+		t.Errorf("token = %+v, want %+v", token, purr)
+	}
+	_, ok = FindToken(testSpotMeta(), "NOPE")
+	if ok {
+		t.Error("ok = true")
+	}
+
+	if got, want := SystemAddress(200), common.HexToAddress("0x20000000000000000000000000000000000000c8"); got != want {
+		t.Errorf("SystemAddress(200) = %+v, want %+v", got, want)
+	}
+}
+
+func TestTokenUnits(t *testing.T) {
+	token := Token{Name: "PURR", EvmDecimals: 18}
+	for _, tt := range []struct {
+		amount float64
+		units  string
+	}{
+		{0, "0"},
+		{0.1, "100000000000000000"},
+		{1234.5, "1234500000000000000000"},
+		{1e-20, "0"},
+	} {
+		units, err := token.ToUnits(tt.amount)
+		if err != nil {
+			t.Fatalf("ToUnits(tt.amount) error = %v", err)
+		}
+		if got := units.String(); got != tt.units {
+			t.Errorf("%v", tt.amount)
+		}
+	}
+	_, err := token.ToUnits(-1)
+	if err == nil {
+		t.Error("ToUnits(-1) error = nil, want error")
+	}
+
+	units, _ := new(big.Int).SetString("1500000000000000000", 10)
+	if got := token.FromUnits(units); got != 1.5 {
+		t.Errorf("FromUnits(units) = %v, want 1.5", got)
+	}
+}
+
+func TestTokenBalance(t *testing.T) {
+	c, service := newClient(t)
+	service.native[holder] = big.NewInt(2_500_000_000_000_000_000)
+	service.tokens[purrContract] = map[common.Address]*big.Int{holder: big.NewInt(3_000_000_000_000_000_000)}
+	tokens := LinkedTokens(testSpotMeta())
+	ctx := context.Background()
+
+	balance, err := c.TokenBalance(ctx, tokens[0], holder)
+	if err != nil {
+		t.Fatalf("TokenBalance(ctx, tokens[0], holder) error = %v", err)
+	}
+	if balance != 3.0 {
+		t.Errorf("balance = %v, want 3.0", balance)
+	}
+	balance, err = c.TokenBalance(ctx, tokens[1], holder)
+	if err != nil {
+		t.Fatalf("TokenBalance(ctx, tokens[1], holder) error = %v", err)
+	}
+	if balance != 2.5 {
+		t.Errorf("balance = %v, want 2.5", balance)
+	}
+
+	_, err = c.TokenBalance(ctx, Token{Name: "NOPE", Contract: common.HexToAddress("0x01")}, holder)
+	if err == nil {
+		t.Error("TokenBalance() error = nil, want error")
+	}
+}
+
+func TestSendToEvm(t *testing.T) {
+	srv := hltest.NewTestServer(t)
+	exchange := srv.NewTestExchange(t)
+	var action map[string]any
+	srv.HandleExchange("spotSend", func(req *hltest.ExchangeRequest) (any, error) {
+		action = req.Action
+		return map[string]any{"type": "default"}, nil
+	})
+
+	_, err := SendToEvm(exchange, LinkedTokens(testSpotMeta())[0], 12.5)
+	if err != nil {
+		t.Fatalf("SendToEvm() error = %v", err)
+	}
+	if action["token"] != "PURR:0xc4bf3f870c0e9465323c0b6ed28096c2" {
+		t.Errorf("action[token] = %v, want %q", action["token"], "PURR:0xc4bf3f870c0e9465323c0b6ed28096c2")
+	}
+	if got := strings.ToLower(action["destination"].(string)); got != "0x2000000000000000000000000000000000000001" {
+		t.Errorf("ToLower() = %q, want %q", got, "0x2000000000000000000000000000000000000001")
+	}
+	if action["amount"] != "12.500000" {
+		t.Errorf("action[amount] = %v, want %q", action["amount"], "12.500000")
+	}
+}
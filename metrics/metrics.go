@@ -0,0 +1,285 @@
+// Package metrics exports the activity of hl-go clients as Prometheus metrics: request
+// latencies and errors, order rejections by reason, WebSocket reconnections and lag,
+// and rate-limit headroom.
+//
+// Metrics are fed by the hook points of the clients. A Metrics is a
+// prometheus.Collector, registered with the registry of the application, or served
+// on its own:
+//
+//	m := metrics.New()
+//	prometheus.MustRegister(m) // or http.Handle("/metrics", m)
+//	exchange.SetRequestHook(m.ObserveRequest)
+//	client.OnReconnect(m.ReconnectHook("trades"))
+//	client.SetMetricsHook(10*time.Second, m.StatsHook("trades"))
+//
+// Rate-limit headroom is updated whenever Info.UserRateLimit is called through an
+// observed API, or with ObserveRateLimit. Validator operators record the health of
+// their validator with ObserveValidator, e.g. as the summary hook of a
+// validator.Monitor.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the latency and lag histograms
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics holds the metrics of one or more clients. Safe for concurrent use.
+type Metrics struct {
+	requestDuration *prometheus.HistogramVec
+	requestErrors   *prometheus.CounterVec
+	orderStatuses   *prometheus.CounterVec
+
+	wsReconnects       *prometheus.CounterVec
+	wsSinceLastMessage *prometheus.GaugeVec
+	wsPingRTT          *prometheus.GaugeVec
+	wsMessagesPerSec   *prometheus.GaugeVec
+	wsDropped          *prometheus.GaugeVec
+	messageLag         *prometheus.HistogramVec
+
+	rateLimitUsed     *prometheus.GaugeVec
+	rateLimitCap      *prometheus.GaugeVec
+	rateLimitHeadroom *prometheus.GaugeVec
+
+	validatorJailed       *prometheus.GaugeVec
+	validatorActive       *prometheus.GaugeVec
+	validatorStake        *prometheus.GaugeVec
+	validatorCommission   *prometheus.GaugeVec
+	validatorRecentBlocks *prometheus.GaugeVec
+	validatorUptime       *prometheus.GaugeVec
+	validatorAPR          *prometheus.GaugeVec
+
+	// handler serves a registry holding only these metrics
+	handler http.Handler
+}
+
+// New creates metrics with the DefaultBuckets
+func New() *Metrics {
+	return NewWithBuckets(DefaultBuckets)
+}
+
+// NewWithBuckets creates metrics whose latency and lag histograms have the given
+// upper bounds in seconds, in increasing order
+func NewWithBuckets(buckets []float64) *Metrics {
+	m := &Metrics{
+		requestDuration: newHistogram("hl_request_duration_seconds",
+			"Duration of info requests and actions", buckets, "path", "type", "transport"),
+		requestErrors: newCounter("hl_request_errors_total",
+			"Info requests and actions that returned an error, by HTTP status or \"error\"", "path", "type", "code"),
+		orderStatuses: newCounter("hl_order_statuses_total",
+			"Statuses of placed and modified orders, rejections by reason", "action", "status"),
+
+		wsReconnects: newCounter("hl_ws_reconnects_total",
+			"WebSocket reconnection attempts, by result", "client", "result"),
+		wsSinceLastMessage: newGauge("hl_ws_since_last_message_seconds",
+			"Time since the last WebSocket message was received", "client"),
+		wsPingRTT: newGauge("hl_ws_ping_rtt_seconds",
+			"Round-trip time of the last WebSocket ping", "client"),
+		wsMessagesPerSec: newGauge("hl_ws_messages_per_second",
+			"Rate of received WebSocket messages", "client"),
+		wsDropped: newGauge("hl_ws_dropped_messages",
+			"WebSocket messages discarded by the overflow policy", "client"),
+		messageLag: newHistogram("hl_ws_message_lag_seconds",
+			"Delay between the exchange timestamp of a subscription message and its receipt", buckets, "channel"),
+
+		rateLimitUsed: newGauge("hl_rate_limit_requests_used",
+			"Requests used of the address-based rate limit", "user"),
+		rateLimitCap: newGauge("hl_rate_limit_requests_cap",
+			"Request cap of the address-based rate limit", "user"),
+		rateLimitHeadroom: newGauge("hl_rate_limit_headroom_requests",
+			"Requests left before the address-based rate limit", "user"),
+
+		validatorJailed: newGauge("hl_validator_jailed",
+			"Whether the validator is jailed", "validator"),
+		validatorActive: newGauge("hl_validator_active",
+			"Whether the validator is in the active set", "validator"),
+		validatorStake: newGauge("hl_validator_stake_hype",
+			"HYPE staked to the validator", "validator"),
+		validatorCommission: newGauge("hl_validator_commission_ratio",
+			"Fraction of the rewards kept by the validator", "validator"),
+		validatorRecentBlocks: newGauge("hl_validator_recent_blocks",
+			"Blocks recently proposed by the validator", "validator"),
+		validatorUptime: newGauge("hl_validator_uptime_ratio",
+			"Uptime fraction of the validator, by period", "validator", "period"),
+		validatorAPR: newGauge("hl_validator_predicted_apr",
+			"Predicted staking APR of the validator, by period", "validator", "period"),
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(m)
+	m.handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return m
+}
+
+func newCounter(name, help string, labels ...string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+}
+
+func newGauge(name, help string, labels ...string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+}
+
+func newHistogram(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.requestDuration, m.requestErrors, m.orderStatuses,
+		m.wsReconnects, m.wsSinceLastMessage, m.wsPingRTT, m.wsMessagesPerSec, m.wsDropped, m.messageLag,
+		m.rateLimitUsed, m.rateLimitCap, m.rateLimitHeadroom,
+		m.validatorJailed, m.validatorActive, m.validatorStake, m.validatorCommission, m.validatorRecentBlocks,
+		m.validatorUptime, m.validatorAPR,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// ObserveRequest records a request to the API, see client.API.SetRequestHook
+func (m *Metrics) ObserveRequest(event client.RequestEvent) {
+	transport := "http"
+	if event.Ws {
+		transport = "ws"
+	}
+	m.requestDuration.WithLabelValues(event.Path, event.Type, transport).Observe(event.Duration.Seconds())
+
+	if event.Err != nil {
+		code := "error"
+		var apiErr *client.APIError
+		if errors.As(event.Err, &apiErr) {
+			code = strconv.Itoa(apiErr.StatusCode)
+		}
+		m.requestErrors.WithLabelValues(event.Path, event.Type, code).Inc()
+		return
+	}
+
+	switch result := event.Result.(type) {
+	case *types.OrderResponse:
+		m.observeStatuses(event.Type, result.Data.Statuses)
+	case *types.ModifyResponse:
+		m.observeStatuses(event.Type, result.Data.Statuses)
+	case *types.UserRateLimitResponse:
+		if payload, ok := event.Payload.(map[string]any); ok {
+			user, _ := payload["user"].(string)
+			m.ObserveRateLimit(user, result)
+		}
+	}
+}
+
+func (m *Metrics) observeStatuses(action string, statuses []types.OrderStatus) {
+	for _, status := range statuses {
+		m.orderStatuses.WithLabelValues(action, string(statusType(status))).Inc()
+	}
+}
+
+// statusType returns the status of an order of a response, the reason if it failed
+func statusType(status types.OrderStatus) types.OrderStatusType {
+	switch {
+	case status.Resting != nil:
+		return types.OrderStatusOpen
+	case status.Filled != nil:
+		return types.OrderStatusFilled
+	}
+	if err, ok := status.ParseError(); ok {
+		return err.Status
+	}
+	return types.OrderStatusOpen
+}
+
+// ObserveRateLimit records the rate-limit usage of user, e.g. as polled with
+// Info.UserRateLimit
+func (m *Metrics) ObserveRateLimit(user string, limit *types.UserRateLimitResponse) {
+	user = strings.ToLower(user)
+	m.rateLimitUsed.WithLabelValues(user).Set(float64(limit.NRequestsUsed))
+	m.rateLimitCap.WithLabelValues(user).Set(float64(limit.NRequestsCap))
+	m.rateLimitHeadroom.WithLabelValues(user).Set(float64(limit.NRequestsCap - limit.NRequestsUsed))
+}
+
+// ObserveValidator records the health of a validator of Info.ValidatorSummaries
+func (m *Metrics) ObserveValidator(summary types.ValidatorSummary) {
+	validator := strings.ToLower(summary.Validator)
+	m.validatorJailed.WithLabelValues(validator).Set(boolValue(summary.IsJailed))
+	m.validatorActive.WithLabelValues(validator).Set(boolValue(summary.IsActive))
+	m.validatorStake.WithLabelValues(validator).Set(float64(summary.Stake) / 1e8)
+	m.validatorRecentBlocks.WithLabelValues(validator).Set(float64(summary.NRecentBlocks))
+	if commission, err := strconv.ParseFloat(summary.Commission, 64); err == nil {
+		m.validatorCommission.WithLabelValues(validator).Set(commission)
+	}
+	for _, stats := range summary.Stats {
+		if uptime, err := strconv.ParseFloat(stats.UptimeFraction, 64); err == nil {
+			m.validatorUptime.WithLabelValues(validator, stats.Period).Set(uptime)
+		}
+		if apr, err := strconv.ParseFloat(stats.PredictedApr, 64); err == nil {
+			m.validatorAPR.WithLabelValues(validator, stats.Period).Set(apr)
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ReconnectHook returns a callback recording the reconnection attempts of the
+// WebSocket client named name, for OnReconnect
+func (m *Metrics) ReconnectHook(name string) func(ws.ReconnectEvent) {
+	return func(event ws.ReconnectEvent) {
+		result := "ok"
+		if event.Err != nil {
+			result = "error"
+		}
+		m.wsReconnects.WithLabelValues(name, result).Inc()
+	}
+}
+
+// StatsHook returns a callback recording the traffic of the WebSocket client or
+// manager named name, for SetMetricsHook
+func (m *Metrics) StatsHook(name string) func(ws.Stats) {
+	return func(stats ws.Stats) {
+		m.wsSinceLastMessage.WithLabelValues(name).Set(stats.SinceLastMessage.Seconds())
+		m.wsPingRTT.WithLabelValues(name).Set(stats.PingRTT.Seconds())
+		m.wsMessagesPerSec.WithLabelValues(name).Set(stats.MessagesPerSec)
+		m.wsDropped.WithLabelValues(name).Set(float64(stats.Dropped))
+	}
+}
+
+// ObserveMessageTime records the lag of a subscription message of channel stamped at
+// ms by the exchange, e.g. the Time of a WsBook or of the last WsTrade
+func (m *Metrics) ObserveMessageTime(channel string, ms int64) {
+	lag := time.Since(time.UnixMilli(ms))
+	if lag < 0 {
+		lag = 0
+	}
+	m.messageLag.WithLabelValues(channel).Observe(lag.Seconds())
+}
+
+// ServeHTTP serves the metrics to a Prometheus scraper, for applications that do not
+// register them with a registry of their own
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+func TestObserveRequest(t *testing.T) {
+	srv := hltest.NewTestServer(t)
+	srv.SetInfo("userRateLimit", types.UserRateLimitResponse{NRequestsUsed: 300, NRequestsCap: 1200})
+
+	exchange := srv.NewTestExchange(t)
+	info := srv.NewTestInfo(t)
+	m := New()
+	exchange.SetRequestHook(m.ObserveRequest)
+	info.SetRequestHook(m.ObserveRequest)
+
+	limit := types.NewLimit(types.TifGtc)
+	exchange.Order("ETH", true, 1, 2000, limit, false, nil, nil)
+	exchange.Order("ETH", true, 1, 2000, types.NewLimit(types.TifIoc), false, nil, nil)
+	exchange.Order("ETH", true, 1, 2000, limit, true, nil, nil)
+	if _, err := info.UserRateLimit("0xABC"); err != nil {
+		t.Fatalf("UserRateLimit() error = %v", err)
+	}
+	if _, err := info.UserFundingHistory("0xabc", 0, nil); err == nil {
+		t.Fatal("UserFundingHistory() expected error from the mock server")
+	}
+
+	tests := []struct {
+		vec    prometheus.Collector
+		labels []string
+		want   float64
+	}{
+		{m.requestDuration, []string{"/exchange", "order", "http"}, 3},
+		{m.requestDuration, []string{"/info", "userRateLimit", "http"}, 1},
+		{m.orderStatuses, []string{"order", "open"}, 1},
+		{m.orderStatuses, []string{"order", "iocCancelRejected"}, 1},
+		{m.orderStatuses, []string{"order", "reduceOnlyRejected"}, 1},
+		{m.requestErrors, []string{"/info", "userFunding", "422"}, 1},
+		{m.rateLimitHeadroom, []string{"0xabc"}, 900},
+		{m.rateLimitCap, []string{"0xabc"}, 1200},
+	}
+	for _, tt := range tests {
+		if got := value(t, tt.vec, tt.labels...); got != tt.want {
+			t.Errorf("%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestWsHooks(t *testing.T) {
+	m := New()
+	reconnect := m.ReconnectHook("trades")
+	reconnect(ws.ReconnectEvent{Attempt: 1, Err: errors.New("refused")})
+	reconnect(ws.ReconnectEvent{Attempt: 2})
+	m.StatsHook("trades")(ws.Stats{SinceLastMessage: 1500 * time.Millisecond, PingRTT: 20 * time.Millisecond, Dropped: 3})
+	m.ObserveMessageTime("l2Book", time.Now().Add(-200*time.Millisecond).UnixMilli())
+
+	tests := []struct {
+		vec    prometheus.Collector
+		labels []string
+		want   float64
+	}{
+		{m.wsReconnects, []string{"trades", "error"}, 1},
+		{m.wsReconnects, []string{"trades", "ok"}, 1},
+		{m.wsSinceLastMessage, []string{"trades"}, 1.5},
+		{m.wsPingRTT, []string{"trades"}, 0.02},
+		{m.wsDropped, []string{"trades"}, 3},
+		{m.messageLag, []string{"l2Book"}, 1},
+	}
+	for _, tt := range tests {
+		if got := value(t, tt.vec, tt.labels...); got != tt.want {
+			t.Errorf("%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestObserveValidator(t *testing.T) {
+	m := New()
+	m.ObserveValidator(types.ValidatorSummary{
+		Validator:     "0xABC",
+		NRecentBlocks: 120,
+		Stake:         250_000_000_000,
+		IsJailed:      true,
+		Commission:    "0.04",
+		Stats: []types.ValidatorPeriodStats{
+			{Period: "day", ValidatorStats: types.ValidatorStats{UptimeFraction: "0.95", PredictedApr: "0.021"}},
+			{Period: "week", ValidatorStats: types.ValidatorStats{UptimeFraction: "0.99", PredictedApr: "0.022"}},
+		},
+	})
+
+	tests := []struct {
+		vec    prometheus.Collector
+		labels []string
+		want   float64
+	}{
+		{m.validatorJailed, []string{"0xabc"}, 1},
+		{m.validatorActive, []string{"0xabc"}, 0},
+		{m.validatorStake, []string{"0xabc"}, 2500},
+		{m.validatorCommission, []string{"0xabc"}, 0.04},
+		{m.validatorRecentBlocks, []string{"0xabc"}, 120},
+		{m.validatorUptime, []string{"0xabc", "day"}, 0.95},
+		{m.validatorUptime, []string{"0xabc", "week"}, 0.99},
+		{m.validatorAPR, []string{"0xabc", "week"}, 0.022},
+	}
+	for _, tt := range tests {
+		if got := value(t, tt.vec, tt.labels...); got != tt.want {
+			t.Errorf("%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	m := NewWithBuckets([]float64{0.1, 1})
+	m.ObserveRequest(client.RequestEvent{Path: "/info", Type: "l2Book", Duration: 50 * time.Millisecond})
+	m.ObserveRequest(client.RequestEvent{Path: "/info", Type: "l2Book", Duration: 500 * time.Millisecond})
+	m.ReconnectHook(`feed "a"`)(ws.ReconnectEvent{})
+
+	// The pedantic registry checks that the collected metrics match their descriptions
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(m); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register(New()); err == nil {
+		t.Error("Register() of a second Metrics expected a duplicate error")
+	}
+	want := `# HELP hl_request_duration_seconds Duration of info requests and actions
+# TYPE hl_request_duration_seconds histogram
+hl_request_duration_seconds_bucket{path="/info",transport="http",type="l2Book",le="0.1"} 1
+hl_request_duration_seconds_bucket{path="/info",transport="http",type="l2Book",le="1"} 2
+hl_request_duration_seconds_bucket{path="/info",transport="http",type="l2Book",le="+Inf"} 2
+hl_request_duration_seconds_sum{path="/info",transport="http",type="l2Book"} 0.55
+hl_request_duration_seconds_count{path="/info",transport="http",type="l2Book"} 2
+# HELP hl_ws_reconnects_total WebSocket reconnection attempts, by result
+# TYPE hl_ws_reconnects_total counter
+hl_ws_reconnects_total{client="feed \"a\"",result="ok"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	m := New()
+	m.ObserveRateLimit("0xABC", &types.UserRateLimitResponse{NRequestsUsed: 100, NRequestsCap: 1000})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `hl_rate_limit_headroom_requests{user="0xabc"} 900`) {
+		t.Errorf("body =\n%s", body)
+	}
+}
+
+// value returns the value of the counter or gauge of vec with labels, or the sample
+// count of a histogram
+func value(t *testing.T, vec prometheus.Collector, labels ...string) float64 {
+	t.Helper()
+	if h, ok := vec.(*prometheus.HistogramVec); ok {
+		var metric dto.Metric
+		if err := h.WithLabelValues(labels...).(prometheus.Metric).Write(&metric); err != nil {
+			t.Fatal(err)
+		}
+		return float64(metric.GetHistogram().GetSampleCount())
+	}
+	switch v := vec.(type) {
+	case *prometheus.CounterVec:
+		return testutil.ToFloat64(v.WithLabelValues(labels...))
+	case *prometheus.GaugeVec:
+		return testutil.ToFloat64(v.WithLabelValues(labels...))
+	}
+	t.Fatalf("unexpected collector %T", vec)
+	return 0
+}
@@ -0,0 +1,71 @@
+// Package mm is a toolkit for market making: Params computes target quotes around a
+// mid price, and a Quoter keeps the resting orders of a coin on those targets with as
+// few actions as possible:
+//
+//	q := mm.NewQuoter(exchange, mm.Config{Coin: "ETH", SzDecimals: 4, ToleranceBps: 2})
+//	for view := range eth.Updates() {
+//	    bids, asks := mm.Params{
+//	        Mid:       view.Mid,
+//	        SpreadBps: 10,
+//	        Size:      0.5,
+//	        SkewBps:   mm.InventorySkewBps(position, 5, 8),
+//	    }.Quotes()
+//	    if _, err := q.Quote(bids, asks); err != nil {
+//	        log.Println(err)
+//	    }
+//	}
+package mm
+
+import (
+	"math"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Quote is a target order of one side
+type Quote struct {
+	Px float64
+	Sz float64
+}
+
+// Params describes the quotes of a coin around its mid price
+type Params struct {
+	Mid float64
+	// SpreadBps is the distance between the best bid and the best ask
+	SpreadBps float64
+	// Size is the size of each quote
+	Size float64
+	// SkewBps moves the center of the quotes away from the mid: a negative skew lowers
+	// both sides, to sell inventory, and a positive skew raises them
+	SkewBps float64
+	// Levels is the number of quotes per side, 1 if zero
+	Levels int
+	// LevelSpacingBps is the distance between the levels of a side
+	LevelSpacingBps float64
+}
+
+// Quotes returns the bids, best first, and the asks, best first. There are none when
+// the mid is not positive.
+func (p Params) Quotes() (bids, asks []Quote) {
+	if !(p.Mid > 0) || !(p.Size > 0) {
+		return nil, nil
+	}
+	levels := max(p.Levels, 1)
+	center := p.Mid * (1 + utils.BpsToFraction(p.SkewBps))
+	for i := range levels {
+		offset := p.SpreadBps/2 + float64(i)*p.LevelSpacingBps
+		bids = append(bids, Quote{Px: utils.SlippagePx(center, offset, false), Sz: p.Size})
+		asks = append(asks, Quote{Px: utils.SlippagePx(center, offset, true), Sz: p.Size})
+	}
+	return bids, asks
+}
+
+// InventorySkewBps returns the skew leaning against a position: maxSkewBps lower for a
+// long of maxPosition or more, maxSkewBps higher for such a short, linear in between
+func InventorySkewBps(position, maxPosition, maxSkewBps float64) float64 {
+	if maxPosition <= 0 {
+		return 0
+	}
+	ratio := math.Max(-1, math.Min(1, position/maxPosition))
+	return -ratio * maxSkewBps
+}
@@ -0,0 +1,72 @@
+package mm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParamsQuotes(t *testing.T) {
+	bids, asks := Params{Mid: 100, SpreadBps: 20, Size: 1, Levels: 2, LevelSpacingBps: 10}.Quotes()
+	if len(bids) != 2 {
+		t.Fatalf("len(bids) = %d, want 2", len(bids))
+	}
+	if len(asks) != 2 {
+		t.Fatalf("len(asks) = %d, want 2", len(asks))
+	}
+	if math.Abs(bids[0].Px-99.9) > 1e-9 {
+		t.Errorf("bids[0].Px = %v, want %v", bids[0].Px, 99.9)
+	}
+	if math.Abs(bids[1].Px-99.8) > 1e-9 {
+		t.Errorf("bids[1].Px = %v, want %v", bids[1].Px, 99.8)
+	}
+	if math.Abs(asks[0].Px-100.1) > 1e-9 {
+		t.Errorf("asks[0].Px = %v, want %v", asks[0].Px, 100.1)
+	}
+	if math.Abs(asks[1].Px-100.2) > 1e-9 {
+		t.Errorf("asks[1].Px = %v, want %v", asks[1].Px, 100.2)
+	}
+	if bids[1].Sz != 1.0 {
+		t.Errorf("bids[1].Sz = %v, want 1.0", bids[1].Sz)
+	}
+
+	// A negative skew lowers both sides
+	bids, asks = Params{Mid: 100, SpreadBps: 20, Size: 1, SkewBps: -10}.Quotes()
+	if math.Abs(bids[0].Px-(99.9*0.999)) > 1e-9 {
+		t.Errorf("bids[0].Px = %v, want %v", bids[0].Px, 99.9*0.999)
+	}
+	if math.Abs(asks[0].Px-(99.9*1.001)) > 1e-6 {
+		t.Errorf("asks[0].Px = %v, want %v", asks[0].Px, 99.9*1.001)
+	}
+	if len(bids) != 1 {
+		t.Errorf("len(bids) = %d, want 1", len(bids))
+	}
+
+	bids, asks = Params{Size: 1}.Quotes()
+	if len(bids) != 0 {
+		t.Errorf("bids = %+v, want empty", bids)
+	}
+	if len(asks) != 0 {
+		t.Errorf("asks = %+v, want empty", asks)
+	}
+}
+
+func TestInventorySkewBps(t *testing.T) {
+	tests := []struct {
+		position float64
+		want     float64
+	}{
+		{0, 0},
+		{5, -4},
+		{10, -8},
+		{20, -8},
+		{-5, 4},
+	}
+	for _, tt := range tests {
+		if got := InventorySkewBps(tt.position, 10, 8); got != tt.want {
+			t.Errorf("InventorySkewBps(%v, 10, 8) = %v, want %v", tt.position, got, tt.want)
+		}
+	}
+	if got := InventorySkewBps(5, 0, 8); got != 0.0 {
+		t.Errorf("InventorySkewBps(5, 0, 8) = %v, want 0.0", got)
+	}
+}
@@ -0,0 +1,341 @@
+package mm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Config configures a Quoter
+type Config struct {
+	Coin string
+	// SzDecimals and IsSpot round quotes to the tick and lot of the coin
+	SzDecimals int
+	IsSpot     bool
+	// ToleranceBps is how far a resting order may drift from its target before it is
+	// modified, so small moves of the mid do not spend requests
+	ToleranceBps float64
+	// MinInterval is the minimum time between two ticks sending actions. Ticks within it
+	// are throttled.
+	MinInterval time.Duration
+	// ReserveRequests is the part of the address rate limit kept for cancels: a tick
+	// that would leave less only cancels, see SetRateLimit
+	ReserveRequests int
+}
+
+// Order is a resting quote of a Quoter
+type Order struct {
+	Oid   int
+	IsBuy bool
+	Px    float64
+	Sz    float64
+}
+
+// Tick is what a call of Quote did
+type Tick struct {
+	Placed   int
+	Modified int
+	Canceled int
+	Kept     int
+	// Rejected are the errors of the orders refused by the exchange
+	Rejected []string
+	// Throttled is true when nothing was sent because of MinInterval
+	Throttled bool
+	// Limited is true when orders were canceled instead of placed or modified because
+	// of the rate limit
+	Limited bool
+}
+
+// Quoter keeps the resting Alo orders of a coin on target quotes. Each tick diffs the
+// targets against the open quotes, level by level from the best, keeps the quotes
+// within the tolerance, modifies the others and cancels or places the difference, in
+// at most one cancel, one modify and one order action. Safe for concurrent use.
+type Quoter struct {
+	trader client.Trader
+	config Config
+	clock  utils.Clock
+
+	mu     sync.Mutex
+	orders []Order
+	last   time.Time
+	// budget is the number of address requests left, negative if unknown
+	budget int
+}
+
+// NewQuoter creates a quoter trading with trader, e.g. a client.Exchange or a
+// paper.Exchange
+func NewQuoter(trader client.Trader, config Config) *Quoter {
+	return &Quoter{trader: trader, config: config, clock: utils.SystemClock, budget: -1}
+}
+
+// SetClock sets the clock of MinInterval, e.g. a utils.FakeClock in tests
+func (q *Quoter) SetClock(clock utils.Clock) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.clock = clock
+}
+
+// SetRateLimit sets the requests left to the address, from Info.UserRateLimit. Every
+// order and modify sent afterwards is counted against them; cancels are not, as the
+// exchange still accepts them from rate limited addresses.
+func (q *Quoter) SetRateLimit(limit *types.UserRateLimitResponse) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.budget = max(limit.NRequestsCap-limit.NRequestsUsed, 0)
+}
+
+// Orders returns the open quotes, bids then asks, best first
+func (q *Quoter) Orders() []Order {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Order(nil), q.orders...)
+}
+
+// Filled records a fill of sz of a quote, e.g. from the userFills feed. The remainder
+// is modified back to its target by the next tick.
+func (q *Quoter) Filled(oid int, sz float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.orders {
+		if q.orders[i].Oid != oid {
+			continue
+		}
+		q.orders[i].Sz = utils.RoundToLot(q.orders[i].Sz-sz, q.config.SzDecimals)
+		if q.orders[i].Sz <= 0 {
+			q.orders = append(q.orders[:i], q.orders[i+1:]...)
+		}
+		return
+	}
+}
+
+// Quote moves the open quotes to bids and asks, best first
+func (q *Quoter) Quote(bids, asks []Quote) (*Tick, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tick := &Tick{}
+	now := q.clock.Now()
+	if q.config.MinInterval > 0 && !q.last.IsZero() && now.Sub(q.last) < q.config.MinInterval {
+		tick.Throttled = true
+		return tick, nil
+	}
+
+	var p plan
+	q.diff(&p, true, q.round(bids, false))
+	q.diff(&p, false, q.round(asks, true))
+	tick.Kept = len(p.kept)
+	if q.budget >= 0 && q.budget-len(p.modifies)-len(p.places) < q.config.ReserveRequests {
+		// Without requests to move stale quotes, take them off the book
+		tick.Limited = len(p.modifies)+len(p.places) > 0
+		for _, m := range p.modifies {
+			p.cancels = append(p.cancels, m.order)
+		}
+		p.modifies, p.places = nil, nil
+	}
+	if p.empty() {
+		return tick, nil
+	}
+	q.last = now
+	q.orders = p.kept
+
+	if err := q.cancel(p.cancels, tick); err != nil {
+		return tick, err
+	}
+	if err := q.modify(p.modifies, tick); err != nil {
+		return tick, err
+	}
+	if err := q.place(p.places, tick); err != nil {
+		return tick, err
+	}
+	q.sortOrders()
+	return tick, nil
+}
+
+// CancelAll cancels the open quotes
+func (q *Quoter) CancelAll() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	orders := q.orders
+	q.orders = nil
+	return q.cancel(orders, &Tick{})
+}
+
+// plan is the actions of a tick
+type plan struct {
+	kept     []Order
+	cancels  []Order
+	modifies []modify
+	places   []Order
+}
+
+type modify struct {
+	order  Order
+	target Order
+}
+
+func (p *plan) empty() bool {
+	return len(p.cancels) == 0 && len(p.modifies) == 0 && len(p.places) == 0
+}
+
+// round rounds targets to the tick and lot of the coin, dropping empty ones
+func (q *Quoter) round(quotes []Quote, isAsk bool) []Order {
+	orders := make([]Order, 0, len(quotes))
+	for _, quote := range quotes {
+		o := Order{
+			IsBuy: !isAsk,
+			Px:    utils.RoundToTick(quote.Px, q.config.SzDecimals, q.config.IsSpot),
+			Sz:    utils.RoundToLot(quote.Sz, q.config.SzDecimals),
+		}
+		if o.Px > 0 && o.Sz > 0 {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+// diff pairs the open quotes of a side with targets, best first
+func (q *Quoter) diff(p *plan, isBuy bool, targets []Order) {
+	var open []Order
+	for _, o := range q.orders {
+		if o.IsBuy == isBuy {
+			open = append(open, o)
+		}
+	}
+	for i := 0; i < max(len(open), len(targets)); i++ {
+		switch {
+		case i >= len(targets):
+			p.cancels = append(p.cancels, open[i])
+		case i >= len(open):
+			p.places = append(p.places, targets[i])
+		case q.within(open[i], targets[i]):
+			p.kept = append(p.kept, open[i])
+		default:
+			p.modifies = append(p.modifies, modify{order: open[i], target: targets[i]})
+		}
+	}
+}
+
+// within reports whether an open quote can stay for target
+func (q *Quoter) within(o, target Order) bool {
+	return o.Sz == target.Sz && math.Abs(o.Px-target.Px) <= target.Px*utils.BpsToFraction(q.config.ToleranceBps)
+}
+
+func (q *Quoter) request(o Order) types.OrderRequest {
+	return types.OrderRequest{
+		Coin:      q.config.Coin,
+		IsBuy:     o.IsBuy,
+		Sz:        o.Sz,
+		LimitPx:   o.Px,
+		OrderType: types.NewLimit(types.TifAlo),
+	}
+}
+
+// cancel cancels orders, which are gone afterwards whether they were open or not
+func (q *Quoter) cancel(orders []Order, tick *Tick) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	cancels := make([]types.CancelRequest, len(orders))
+	for i, o := range orders {
+		cancels[i] = types.CancelRequest{Coin: q.config.Coin, Oid: o.Oid}
+	}
+	if _, err := q.trader.BulkCancel(cancels); err != nil {
+		// Keep tracking the orders, to cancel them again
+		q.orders = append(q.orders, orders...)
+		return fmt.Errorf("failed to cancel quotes: %w", err)
+	}
+	tick.Canceled += len(orders)
+	return nil
+}
+
+// modify moves orders to their targets. Orders that could not be modified are gone,
+// filled or canceled, and are placed again by the next tick.
+func (q *Quoter) modify(modifies []modify, tick *Tick) error {
+	if len(modifies) == 0 {
+		return nil
+	}
+	requests := make([]types.ModifyRequest, len(modifies))
+	for i, m := range modifies {
+		requests[i] = types.ModifyRequest{Oid: m.order.Oid, Order: q.request(m.target)}
+	}
+	q.spend(len(requests))
+	result, err := q.trader.BulkModifyOrders(requests)
+	if err != nil {
+		for _, m := range modifies {
+			q.orders = append(q.orders, m.order)
+		}
+		return fmt.Errorf("failed to modify quotes: %w", err)
+	}
+	for i, status := range result.Data.Statuses {
+		if i >= len(modifies) {
+			break
+		}
+		if q.track(modifies[i].target, status, tick) {
+			tick.Modified++
+		}
+	}
+	return nil
+}
+
+// place places new orders
+func (q *Quoter) place(orders []Order, tick *Tick) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	requests := make([]types.OrderRequest, len(orders))
+	for i, o := range orders {
+		requests[i] = q.request(o)
+	}
+	q.spend(len(requests))
+	result, err := q.trader.BulkOrders(requests, nil)
+	if err != nil {
+		return fmt.Errorf("failed to place quotes: %w", err)
+	}
+	for i, status := range result.Data.Statuses {
+		if i >= len(orders) {
+			break
+		}
+		if q.track(orders[i], status, tick) {
+			tick.Placed++
+		}
+	}
+	return nil
+}
+
+// track records the order of a status, returning whether it rests
+func (q *Quoter) track(o Order, status types.OrderStatus, tick *Tick) bool {
+	switch {
+	case status.Resting != nil:
+		o.Oid = status.Resting.Oid
+		q.orders = append(q.orders, o)
+		return true
+	case status.Error != "":
+		tick.Rejected = append(tick.Rejected, status.Error)
+	}
+	return false
+}
+
+func (q *Quoter) spend(n int) {
+	if q.budget >= 0 {
+		q.budget = max(q.budget-n, 0)
+	}
+}
+
+// sortOrders sorts bids then asks, best first
+func (q *Quoter) sortOrders() {
+	sort.SliceStable(q.orders, func(i, j int) bool {
+		a, b := q.orders[i], q.orders[j]
+		if a.IsBuy != b.IsBuy {
+			return a.IsBuy
+		}
+		if a.IsBuy {
+			return a.Px > b.Px
+		}
+		return a.Px < b.Px
+	})
+}
@@ -0,0 +1,286 @@
+package mm
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/backtest"
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/paper"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// countingTrader counts the actions sent to a trader
+type countingTrader struct {
+	client.Trader
+	orders, modifies, cancels int
+	err                       error
+}
+
+func (c *countingTrader) BulkOrders(orders []types.OrderRequest, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	c.orders++
+	return c.Trader.BulkOrders(orders, builder)
+}
+
+func (c *countingTrader) BulkModifyOrders(modifies []types.ModifyRequest) (*types.ModifyResponse, error) {
+	c.modifies++
+	return c.Trader.BulkModifyOrders(modifies)
+}
+
+func (c *countingTrader) BulkCancel(cancels []types.CancelRequest) (*types.CancelResponse, error) {
+	c.cancels++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.Trader.BulkCancel(cancels)
+}
+
+// market moves the book of exchange to a spread of 2 around mid
+func market(exchange *paper.Exchange, mid float64) {
+	exchange.Update(ws.MarketView{Coin: "ETH", Bid: ws.WsLevel{Px: mid - 1}, Ask: ws.WsLevel{Px: mid + 1}, Mid: mid})
+}
+
+func newQuoter(t *testing.T, config Config) (*Quoter, *paper.Exchange, *countingTrader) {
+	t.Helper()
+	exchange := paper.New(backtest.Config{InitialBalance: 10000})
+	market(exchange, 2000)
+	trader := &countingTrader{Trader: exchange}
+	config.Coin = "ETH"
+	config.SzDecimals = 2
+	return NewQuoter(trader, config), exchange, trader
+}
+
+func TestQuoterReplacesMinimally(t *testing.T) {
+	q, exchange, trader := newQuoter(t, Config{ToleranceBps: 1})
+	params := Params{Mid: 2000, SpreadBps: 10, Size: 1, Levels: 2, LevelSpacingBps: 5}
+
+	tick, err := q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if want := (&Tick{Placed: 4}); !reflect.DeepEqual(tick, want) {
+		t.Errorf("tick = %+v, want %+v", tick, want)
+	}
+	if trader.orders != 1 {
+		t.Errorf("trader.orders = %v, want 1", trader.orders)
+	}
+	if got := exchange.OpenOrders(); len(got) != 4 {
+		t.Errorf("len(OpenOrders()) = %d, want 4", len(got))
+	}
+	orders := q.Orders()
+	if len(orders) != 4 {
+		t.Fatalf("len(orders) = %d, want 4", len(orders))
+	}
+	if got, want := []float64{orders[0].Px, orders[1].Px, orders[2].Px, orders[3].Px}, []float64{1999, 1998, 2001, 2002}; !slices.Equal(got, want) {
+		t.Errorf("prices = %v, want %v", got, want)
+	}
+
+	// Within the tolerance nothing is sent
+	params.Mid = 2000.1
+	tick, err = q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if want := (&Tick{Kept: 4}); !reflect.DeepEqual(tick, want) {
+		t.Errorf("tick = %+v, want %+v", tick, want)
+	}
+	if got := trader.orders + trader.modifies + trader.cancels; got != 1 {
+		t.Errorf("trader.orders+trader.modifies+trader.cancels = %v, want 1", got)
+	}
+
+	// A move modifies every level in one action, fewer levels cancel the rest
+	params.Mid, params.Levels = 2001, 1
+	tick, err = q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if want := (&Tick{Modified: 2, Canceled: 2}); !reflect.DeepEqual(tick, want) {
+		t.Errorf("tick = %+v, want %+v", tick, want)
+	}
+	if trader.modifies != 1 {
+		t.Errorf("trader.modifies = %v, want 1", trader.modifies)
+	}
+	if trader.cancels != 1 {
+		t.Errorf("trader.cancels = %v, want 1", trader.cancels)
+	}
+	open := exchange.OpenOrders()
+	if len(open) != 2 {
+		t.Fatalf("len(open) = %d, want 2", len(open))
+	}
+	if open[0].Request.LimitPx != 2000.0 {
+		t.Errorf("open[0].Request.LimitPx = %v, want 2000.0", open[0].Request.LimitPx)
+	}
+	if open[0].Request.OrderType.Limit.Tif != types.TifAlo {
+		t.Errorf("open[0].Request.OrderType.Limit.Tif = %q, want %q", open[0].Request.OrderType.Limit.Tif, types.TifAlo)
+	}
+
+	if err := q.CancelAll(); err != nil {
+		t.Fatalf("CancelAll() error = %v", err)
+	}
+	if got := exchange.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+	if got := q.Orders(); len(got) != 0 {
+		t.Errorf("Orders() = %+v, want empty", got)
+	}
+}
+
+func TestQuoterFills(t *testing.T) {
+	q, exchange, _ := newQuoter(t, Config{})
+	var fills []backtest.Fill
+	exchange.SetFillHook(func(f backtest.Fill) { fills = append(fills, f) })
+	params := Params{Mid: 2000, SpreadBps: 10, Size: 1}
+	_, err := q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+
+	// The ask is taken, and placed again by the next tick
+	market(exchange, 2002)
+	if len(fills) != 1 {
+		t.Fatalf("len(fills) = %d, want 1", len(fills))
+	}
+	q.Filled(fills[0].Oid, fills[0].Sz)
+	if got := q.Orders(); len(got) != 1 {
+		t.Errorf("len(Orders()) = %d, want 1", len(got))
+	}
+
+	params.Mid = 2003
+	tick, err := q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if tick.Placed != 1 {
+		t.Errorf("tick.Placed = %v, want 1", tick.Placed)
+	}
+	if tick.Modified != 1 {
+		t.Errorf("tick.Modified = %v, want 1", tick.Modified)
+	}
+	if got := q.Orders(); len(got) != 2 {
+		t.Errorf("len(Orders()) = %d, want 2", len(got))
+	}
+}
+
+func TestQuoterRejections(t *testing.T) {
+	q, _, _ := newQuoter(t, Config{})
+
+	// Quotes crossing the book are refused by Alo
+	tick, err := q.Quote([]Quote{{Px: 2002, Sz: 1}}, []Quote{{Px: 2003, Sz: 1}})
+	if err != nil {
+		t.Fatalf("Quote() error = %v", err)
+	}
+	if tick.Placed != 1 {
+		t.Errorf("tick.Placed = %v, want 1", tick.Placed)
+	}
+	if len(tick.Rejected) != 1 {
+		t.Fatalf("len(tick.Rejected) = %d, want 1", len(tick.Rejected))
+	}
+	if got := types.ParseOrderError(tick.Rejected[0]).Status; got != types.OrderStatusBadAloPxRejected {
+		t.Errorf("types.ParseOrderError(tick.Rejected[0]).Status = %q, want %q", got, types.OrderStatusBadAloPxRejected)
+	}
+	if got := q.Orders(); len(got) != 1 {
+		t.Errorf("len(Orders()) = %d, want 1", len(got))
+	}
+}
+
+func TestQuoterThrottle(t *testing.T) {
+	q, exchange, trader := newQuoter(t, Config{MinInterval: time.Second})
+	clock := utils.NewFakeClock(time.Unix(1000, 0))
+	q.SetClock(clock)
+	params := Params{Mid: 2000, SpreadBps: 10, Size: 1}
+
+	_, err := q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	market(exchange, 2001)
+	params.Mid = 2001
+	tick, err := q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if !tick.Throttled {
+		t.Error("tick.Throttled = false")
+	}
+	if trader.modifies != 0 {
+		t.Errorf("trader.modifies = %v, want 0", trader.modifies)
+	}
+
+	clock.Advance(time.Second)
+	tick, err = q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if tick.Modified != 2 {
+		t.Errorf("tick.Modified = %v, want 2", tick.Modified)
+	}
+}
+
+func TestQuoterRateLimit(t *testing.T) {
+	q, exchange, trader := newQuoter(t, Config{ReserveRequests: 10})
+	q.SetRateLimit(&types.UserRateLimitResponse{NRequestsUsed: 988, NRequestsCap: 1000})
+	params := Params{Mid: 2000, SpreadBps: 10, Size: 1}
+
+	_, err := q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if got := exchange.OpenOrders(); len(got) != 2 {
+		t.Errorf("len(OpenOrders()) = %d, want 2", len(got))
+	}
+
+	// The budget of 10 left is kept for cancels: stale quotes are taken off the book
+	market(exchange, 2001)
+	params.Mid = 2001
+	tick, err := q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if want := (&Tick{Canceled: 2, Limited: true}); !reflect.DeepEqual(tick, want) {
+		t.Errorf("tick = %+v, want %+v", tick, want)
+	}
+	if trader.modifies != 0 {
+		t.Errorf("trader.modifies = %v, want 0", trader.modifies)
+	}
+	if got := exchange.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+
+	q.SetRateLimit(&types.UserRateLimitResponse{NRequestsUsed: 0, NRequestsCap: 1000})
+	tick, err = q.Quote(params.Quotes())
+	if err != nil {
+		t.Fatalf("Quote(params.Quotes()) error = %v", err)
+	}
+	if tick.Placed != 2 {
+		t.Errorf("tick.Placed = %v, want 2", tick.Placed)
+	}
+}
+
+func TestQuoterCancelError(t *testing.T) {
+	q, _, trader := newQuoter(t, Config{})
+	_, err := q.Quote(Params{Mid: 2000, SpreadBps: 10, Size: 1}.Quotes())
+	if err != nil {
+		t.Fatalf("Quote() error = %v", err)
+	}
+
+	trader.err = errors.New("timeout")
+	_, err = q.Quote(nil, nil)
+	if err == nil {
+		t.Error("Quote(nil, nil) error = nil, want error")
+	}
+	if got := q.Orders(); len(got) != 2 {
+		t.Errorf("len(Orders()) = %d, want 2", len(got))
+	}
+	trader.err = nil
+	if err := q.CancelAll(); err != nil {
+		t.Fatalf("CancelAll() error = %v", err)
+	}
+	if got := q.Orders(); len(got) != 0 {
+		t.Errorf("Orders() = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,422 @@
+// Package paper provides a simulated Exchange for paper trading: orders are matched
+// against the live best bid and ask of the WebSocket bbo and l2Book feeds, without
+// being sent to the exchange. It implements client.Trader, so a strategy can be
+// validated against live markets before trading with a real client.Exchange:
+//
+//	btc := ws.NewMarketData("BTC")
+//	go btc.Run(ctx)
+//
+//	var trader client.Trader = paper.New(backtest.Config{InitialBalance: 10000})
+//	go trader.(*paper.Exchange).Follow(ctx, btc)
+//	result, err := trader.MarketOpen("BTC", true, 0.01, nil, 0, nil, nil)
+//
+// Matching follows backtest.Broker: orders crossing the best ask or bid when they are
+// placed fill there as takers, and resting orders fill at their price as makers once
+// the quote reaches it, against unlimited liquidity. Builder fees are ignored.
+package paper
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/dwdwow/hl-go/backtest"
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+var _ client.Trader = (*Exchange)(nil)
+
+// Exchange is a simulated exchange fed with market views, see the package doc.
+// Safe for concurrent use.
+type Exchange struct {
+	mu       sync.Mutex
+	broker   *backtest.Broker
+	clock    utils.Clock
+	slippage float64
+	views    map[string]ws.MarketView
+	fillHook func(backtest.Fill)
+	pending  []backtest.Fill
+}
+
+// New creates a simulated exchange of an account with config
+func New(config backtest.Config) *Exchange {
+	e := &Exchange{
+		clock:    utils.SystemClock,
+		slippage: config.Slippage,
+		views:    make(map[string]ws.MarketView),
+	}
+	if e.slippage == 0 {
+		e.slippage = constants.DefaultSlippage
+	}
+	e.broker = backtest.NewBroker(config)
+	e.broker.OnFill(func(f backtest.Fill) { e.pending = append(e.pending, f) })
+	return e
+}
+
+// SetClock sets the clock of the times of orders, e.g. a utils.FakeClock in tests
+func (e *Exchange) SetClock(clock utils.Clock) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = clock
+}
+
+// SetFillHook sets a function called with every fill, like the userFills feed of a real
+// account. It is called after the exchange is unlocked, so it may place orders.
+func (e *Exchange) SetFillHook(fn func(backtest.Fill)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fillHook = fn
+}
+
+// Follow applies the views of data until ctx is done, returning ctx.Err(). It takes the
+// views of data.Updates, which must not be read elsewhere.
+func (e *Exchange) Follow(ctx context.Context, data *ws.MarketData) error {
+	e.Update(data.View())
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case view := <-data.Updates():
+			e.Update(view)
+		}
+	}
+}
+
+// Update sets the market of the coin of view and matches its open orders against it
+func (e *Exchange) Update(view ws.MarketView) {
+	e.mu.Lock()
+	e.views[view.Coin] = view
+	e.broker.MatchQuote(view.Coin, view.Bid.Px, view.Ask.Px, view.Time)
+	e.unlock()
+}
+
+// unlock unlocks the exchange and then calls the fill hook with the new fills
+func (e *Exchange) unlock() {
+	fills, hook := e.pending, e.fillHook
+	e.pending = nil
+	e.mu.Unlock()
+	if hook == nil {
+		return
+	}
+	for _, f := range fills {
+		hook(f)
+	}
+}
+
+// Position returns the position in coin, zero if there is none
+func (e *Exchange) Position(coin string) backtest.Position {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.broker.Position(coin)
+}
+
+// Positions returns the open positions, by coin
+func (e *Exchange) Positions() []backtest.Position {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.broker.Positions()
+}
+
+// OpenOrders returns the open orders, oldest first
+func (e *Exchange) OpenOrders() []backtest.Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.broker.OpenOrders()
+}
+
+// Fills returns the fills so far, in order
+func (e *Exchange) Fills() []backtest.Fill {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.broker.Fills()
+}
+
+// Cash returns the balance, with realized PnL and fees
+func (e *Exchange) Cash() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.broker.Cash()
+}
+
+// Equity returns the balance with the unrealized PnL of the positions at the mid prices
+func (e *Exchange) Equity() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.broker.Equity()
+}
+
+// Order places a single order
+func (e *Exchange) Order(
+	name string,
+	isBuy bool,
+	sz float64,
+	limitPx float64,
+	orderType types.OrderType,
+	reduceOnly bool,
+	cloid *types.Cloid,
+	builder *types.BuilderInfo,
+) (*types.OrderResponse, error) {
+	order := types.OrderRequest{
+		Coin:       name,
+		IsBuy:      isBuy,
+		Sz:         sz,
+		LimitPx:    limitPx,
+		OrderType:  orderType,
+		ReduceOnly: reduceOnly,
+		Cloid:      cloid,
+	}
+	return e.BulkOrders([]types.OrderRequest{order}, builder)
+}
+
+// BulkOrders places multiple orders, matched in order against the current market
+func (e *Exchange) BulkOrders(orders []types.OrderRequest, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	e.mu.Lock()
+	defer e.unlock()
+	statuses := make([]types.OrderStatus, len(orders))
+	for i, order := range orders {
+		statuses[i] = e.place(order)
+	}
+	return &types.OrderResponse{Type: "order", Data: types.OrderDataBody{Statuses: statuses}}, nil
+}
+
+// place places order on the broker and matches it against the market of its coin
+func (e *Exchange) place(order types.OrderRequest) types.OrderStatus {
+	view, ok := e.views[order.Coin]
+	if !ok {
+		return types.OrderStatus{Error: fmt.Sprintf("No market data for %s.", order.Coin)}
+	}
+	position := e.broker.Position(order.Coin)
+	status := e.broker.Order(order)
+	if status.Resting == nil {
+		return status
+	}
+	oid := status.Resting.Oid
+
+	fillsBefore := len(e.broker.Fills())
+	e.broker.MatchQuote(order.Coin, view.Bid.Px, view.Ask.Px, utils.TimestampMs(e.clock))
+	var sz, notional float64
+	for _, f := range e.broker.Fills()[fillsBefore:] {
+		if f.Oid == oid {
+			sz += f.Sz
+			notional += f.Px * f.Sz
+		}
+	}
+	if sz > 0 {
+		return types.OrderStatus{Filled: &types.FilledOrder{
+			TotalSz: strconv.FormatFloat(sz, 'f', -1, 64),
+			AvgPx:   strconv.FormatFloat(notional/sz, 'f', -1, 64),
+			Oid:     oid,
+		}}
+	}
+	for _, open := range e.broker.OpenOrders() {
+		if open.Oid == oid {
+			return status
+		}
+	}
+	return types.OrderStatus{Error: rejection(order, position, view)}
+}
+
+// rejection returns the error of an order canceled without a fill, in the words of the
+// exchange
+func rejection(order types.OrderRequest, position backtest.Position, view ws.MarketView) string {
+	switch {
+	case order.ReduceOnly && ((order.IsBuy && position.Szi >= 0) || (!order.IsBuy && position.Szi <= 0)):
+		return "Reduce only order would increase position."
+	case order.OrderType.Limit != nil && order.OrderType.Limit.Tif == types.TifAlo:
+		return fmt.Sprintf("Post only order would have immediately matched, bbo was %s@%s.",
+			strconv.FormatFloat(view.Bid.Px, 'f', -1, 64), strconv.FormatFloat(view.Ask.Px, 'f', -1, 64))
+	default:
+		return "Order could not immediately match against any resting orders."
+	}
+}
+
+// MarketOpen opens a position with an Ioc order priced slippage away from px, or from
+// the mid price of the coin
+func (e *Exchange) MarketOpen(
+	name string,
+	isBuy bool,
+	sz float64,
+	px *float64,
+	slippage float64,
+	cloid *types.Cloid,
+	builder *types.BuilderInfo,
+) (*types.OrderResponse, error) {
+	price, err := e.slippagePrice(name, isBuy, slippage, px)
+	if err != nil {
+		return nil, err
+	}
+	return e.Order(name, isBuy, sz, price, types.NewLimit(types.TifIoc), false, cloid, builder)
+}
+
+// MarketClose closes the position in name, or sz of it, with an Ioc order
+func (e *Exchange) MarketClose(
+	name string,
+	sz *float64,
+	px *float64,
+	slippage float64,
+	cloid *types.Cloid,
+	builder *types.BuilderInfo,
+) (*types.OrderResponse, error) {
+	position := e.Position(name)
+	if position.Szi == 0 {
+		return nil, fmt.Errorf("no position found for %s", name)
+	}
+	isBuy := position.Szi < 0
+	size := position.Szi
+	if isBuy {
+		size = -size
+	}
+	if sz != nil {
+		size = *sz
+	}
+
+	price, err := e.slippagePrice(name, isBuy, slippage, px)
+	if err != nil {
+		return nil, err
+	}
+	return e.Order(name, isBuy, size, price, types.NewLimit(types.TifIoc), true, cloid, builder)
+}
+
+// slippagePrice returns the limit price of a market order
+func (e *Exchange) slippagePrice(name string, isBuy bool, slippage float64, px *float64) (float64, error) {
+	if slippage == 0 {
+		slippage = e.slippage
+	}
+	e.mu.Lock()
+	view, ok := e.views[name]
+	e.mu.Unlock()
+	if !ok {
+		return 0, fmt.Errorf("unknown coin: %s", name)
+	}
+
+	price := view.Mid
+	if px != nil {
+		price = *px
+	}
+	if price == 0 {
+		return 0, fmt.Errorf("no mid price for %s", name)
+	}
+	if (isBuy && view.Ask.Px == 0) || (!isBuy && view.Bid.Px == 0) {
+		return 0, fmt.Errorf("no liquidity for %s within %v bps", name, utils.FractionToBps(slippage))
+	}
+	return utils.SlippagePx(price, utils.FractionToBps(slippage), isBuy), nil
+}
+
+// ModifyOrder modifies a single order
+func (e *Exchange) ModifyOrder(
+	oid any, // can be int or *types.Cloid
+	name string,
+	isBuy bool,
+	sz float64,
+	limitPx float64,
+	orderType types.OrderType,
+	reduceOnly bool,
+	cloid *types.Cloid,
+) (*types.ModifyResponse, error) {
+	modify := types.ModifyRequest{
+		Oid: oid,
+		Order: types.OrderRequest{
+			Coin:       name,
+			IsBuy:      isBuy,
+			Sz:         sz,
+			LimitPx:    limitPx,
+			OrderType:  orderType,
+			ReduceOnly: reduceOnly,
+			Cloid:      cloid,
+		},
+	}
+	return e.BulkModifyOrders([]types.ModifyRequest{modify})
+}
+
+// BulkModifyOrders replaces open orders, by oid or cloid, with new orders
+func (e *Exchange) BulkModifyOrders(modifies []types.ModifyRequest) (*types.ModifyResponse, error) {
+	e.mu.Lock()
+	defer e.unlock()
+	statuses := make([]types.OrderStatus, len(modifies))
+	for i, modify := range modifies {
+		oid, ok := e.findOrder(modify.Order.Coin, modify.Oid)
+		if !ok || e.broker.Cancel(oid) != nil {
+			statuses[i] = types.OrderStatus{Error: "Cannot modify canceled or filled order"}
+			continue
+		}
+		statuses[i] = e.place(modify.Order)
+	}
+	return &types.ModifyResponse{Type: "batchModify", Data: types.ModifyDataBody{Statuses: statuses}}, nil
+}
+
+// Cancel cancels a single order by order ID
+func (e *Exchange) Cancel(name string, oid int) (*types.CancelResponse, error) {
+	return e.BulkCancel([]types.CancelRequest{{Coin: name, Oid: oid}})
+}
+
+// CancelByCloid cancels a single order by client order ID
+func (e *Exchange) CancelByCloid(name string, cloid types.Cloid) (*types.CancelResponse, error) {
+	return e.BulkCancelByCloid([]types.CancelByCloidRequest{{Coin: name, Cloid: cloid}})
+}
+
+// BulkCancel cancels multiple orders by order ID. The status of an order that is not
+// open is the error message of the exchange instead of "success".
+func (e *Exchange) BulkCancel(cancels []types.CancelRequest) (*types.CancelResponse, error) {
+	e.mu.Lock()
+	defer e.unlock()
+	statuses := make([]string, len(cancels))
+	for i, cancel := range cancels {
+		statuses[i] = e.cancel(cancel.Coin, cancel.Oid)
+	}
+	return &types.CancelResponse{Type: "cancel", Data: types.CancelDataBody{Statuses: statuses}}, nil
+}
+
+// BulkCancelByCloid cancels multiple orders by client order ID, see BulkCancel
+func (e *Exchange) BulkCancelByCloid(cancels []types.CancelByCloidRequest) (*types.CancelResponse, error) {
+	e.mu.Lock()
+	defer e.unlock()
+	statuses := make([]string, len(cancels))
+	for i, cancel := range cancels {
+		statuses[i] = e.cancel(cancel.Coin, cancel.Cloid)
+	}
+	return &types.CancelResponse{Type: "cancelByCloid", Data: types.CancelDataBody{Statuses: statuses}}, nil
+}
+
+// cancel cancels the open order of coin with id, an oid or a cloid
+func (e *Exchange) cancel(coin string, id any) string {
+	oid, ok := e.findOrder(coin, id)
+	if !ok || e.broker.Cancel(oid) != nil {
+		return "Order was never placed, already canceled, or filled."
+	}
+	return "success"
+}
+
+// findOrder returns the oid of the open order of coin with id: an int oid, or a
+// types.Cloid or *types.Cloid
+func (e *Exchange) findOrder(coin string, id any) (int, bool) {
+	var cloid string
+	switch id := id.(type) {
+	case int:
+		for _, order := range e.broker.OpenOrders() {
+			if order.Oid == id && order.Request.Coin == coin {
+				return id, true
+			}
+		}
+		return 0, false
+	case types.Cloid:
+		cloid = id.ToRaw()
+	case *types.Cloid:
+		if id == nil {
+			return 0, false
+		}
+		cloid = id.ToRaw()
+	default:
+		return 0, false
+	}
+	for _, order := range e.broker.OpenOrders() {
+		if order.Request.Cloid != nil && order.Request.Cloid.ToRaw() == cloid && order.Request.Coin == coin {
+			return order.Oid, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,237 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/backtest"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+func quote(coin string, bid, ask float64, time int64) ws.MarketView {
+	return ws.MarketView{Coin: coin, Bid: ws.WsLevel{Px: bid, Sz: 1}, Ask: ws.WsLevel{Px: ask, Sz: 1}, Mid: (bid + ask) / 2, Time: time}
+}
+
+func newExchange(t *testing.T) *Exchange {
+	t.Helper()
+	e := New(backtest.Config{InitialBalance: 1000, MakerFee: 0.0001, TakerFee: 0.0005})
+	e.SetClock(utils.NewFakeClock(time.UnixMilli(1000)))
+	e.Update(quote("ETH", 2000, 2001, 1000))
+	return e
+}
+
+func TestMarketOrders(t *testing.T) {
+	e := newExchange(t)
+
+	result, err := e.MarketOpen("ETH", true, 2, nil, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("MarketOpen() error = %v", err)
+	}
+	if len(result.Data.Statuses) != 1 {
+		t.Fatalf("len(result.Data.Statuses) = %d, want 1", len(result.Data.Statuses))
+	}
+	if result.Data.Statuses[0].Filled == nil {
+		t.Fatal("result.Data.Statuses[0].Filled = nil")
+	}
+	if want := (types.FilledOrder{TotalSz: "2", AvgPx: "2001", Oid: 1}); *result.Data.Statuses[0].Filled != want {
+		t.Errorf("*result.Data.Statuses[0].Filled = %+v, want %+v", *result.Data.Statuses[0].Filled, want)
+	}
+	if got, want := e.Position("ETH"), (backtest.Position{Coin: "ETH", Szi: 2, EntryPx: 2001}); got != want {
+		t.Errorf("Position(ETH) = %+v, want %+v", got, want)
+	}
+
+	e.Update(quote("ETH", 2010, 2011, 2000))
+	if got := e.Equity(); math.Abs(got-(1000-2*2001*0.0005+2*(2010.5-2001))) > 1e-9 {
+		t.Errorf("Equity() = %v, want %v", got, 1000-2*2001*0.0005+2*(2010.5-2001))
+	}
+
+	result, err = e.MarketClose("ETH", nil, nil, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("MarketClose() error = %v", err)
+	}
+	if result.Data.Statuses[0].Filled == nil {
+		t.Fatal("result.Data.Statuses[0].Filled = nil")
+	}
+	if result.Data.Statuses[0].Filled.AvgPx != "2010" {
+		t.Errorf("result.Data.Statuses[0].Filled.AvgPx = %q, want %q", result.Data.Statuses[0].Filled.AvgPx, "2010")
+	}
+	if got := e.Positions(); len(got) != 0 {
+		t.Errorf("Positions() = %+v, want empty", got)
+	}
+
+	_, err = e.MarketClose("ETH", nil, nil, 0, nil, nil)
+	if err == nil {
+		t.Error("MarketClose() error = nil, want error")
+	}
+	_, err = e.MarketOpen("BTC", true, 1, nil, 0, nil, nil)
+	if err == nil {
+		t.Error("MarketOpen() error = nil, want error")
+	}
+}
+
+func TestRestingOrders(t *testing.T) {
+	e := newExchange(t)
+	var fills []backtest.Fill
+	e.SetFillHook(func(f backtest.Fill) { fills = append(fills, f) })
+
+	cloid := types.NewCloidFromInt(7)
+	result, err := e.Order("ETH", true, 1, 1990, types.NewLimit(types.TifGtc), false, cloid, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if result.Data.Statuses[0].Resting == nil {
+		t.Fatal("result.Data.Statuses[0].Resting = nil")
+	}
+	oid := result.Data.Statuses[0].Resting.Oid
+	if len(fills) != 0 {
+		t.Errorf("fills = %+v, want empty", fills)
+	}
+
+	// The order rests until the ask reaches it, and fills at its price
+	e.Update(quote("ETH", 1995, 1996, 2000))
+	if got := e.OpenOrders(); len(got) != 1 {
+		t.Errorf("len(OpenOrders()) = %d, want 1", len(got))
+	}
+	e.Update(quote("ETH", 1985, 1989, 3000))
+	if len(fills) != 1 {
+		t.Fatalf("len(fills) = %d, want 1", len(fills))
+	}
+	if fills[0].Oid != oid {
+		t.Errorf("fills[0].Oid = %v, want %v", fills[0].Oid, oid)
+	}
+	if fills[0].Px != 1990.0 {
+		t.Errorf("fills[0].Px = %v, want 1990.0", fills[0].Px)
+	}
+	if fills[0].Crossed {
+		t.Error("fills[0].Crossed = true")
+	}
+	if !reflect.DeepEqual(fills[0].Cloid, cloid) {
+		t.Errorf("fills[0].Cloid = %+v, want %+v", fills[0].Cloid, cloid)
+	}
+	if got := e.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+	if got := e.Fills(); !reflect.DeepEqual(got, fills) {
+		t.Errorf("Fills() = %+v, want %+v", got, fills)
+	}
+}
+
+func TestRejections(t *testing.T) {
+	e := newExchange(t)
+	result, err := e.BulkOrders([]types.OrderRequest{
+		{Coin: "ETH", IsBuy: true, Sz: 1, LimitPx: 2005, OrderType: types.NewLimit(types.TifAlo)},
+		{Coin: "ETH", IsBuy: true, Sz: 1, LimitPx: 1990, OrderType: types.NewLimit(types.TifIoc)},
+		{Coin: "ETH", IsBuy: false, Sz: 1, LimitPx: 1990, OrderType: types.NewLimit(types.TifIoc), ReduceOnly: true},
+		{Coin: "ETH", IsBuy: true, Sz: 0, LimitPx: 1990, OrderType: types.NewLimit(types.TifGtc)},
+		{Coin: "BTC", IsBuy: true, Sz: 1, LimitPx: 1990, OrderType: types.NewLimit(types.TifGtc)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("BulkOrders() error = %v", err)
+	}
+
+	want := []types.OrderStatusType{
+		types.OrderStatusBadAloPxRejected,
+		types.OrderStatusIocCancelRejected,
+		types.OrderStatusReduceOnlyRejected,
+		types.OrderStatusRejected,
+		types.OrderStatusRejected,
+	}
+	if len(result.Data.Statuses) != len(want) {
+		t.Fatalf("len(result.Data.Statuses) = %d, want %d", len(result.Data.Statuses), len(want))
+	}
+	for i, status := range result.Data.Statuses {
+		parsed, ok := status.ParseError()
+		if !ok {
+			t.Fatalf("status %d = %+v, want an error", i, status)
+		}
+		if parsed.Status != want[i] {
+			t.Errorf("status %d = %v (%s), want %v", i, parsed.Status, parsed.Message, want[i])
+		}
+	}
+	parsed, _ := result.Data.Statuses[0].ParseError()
+	if parsed.BboBid != 2000.0 {
+		t.Errorf("parsed.BboBid = %v, want 2000.0", parsed.BboBid)
+	}
+	if parsed.BboAsk != 2001.0 {
+		t.Errorf("parsed.BboAsk = %v, want 2001.0", parsed.BboAsk)
+	}
+	if got := e.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+}
+
+func TestCancelAndModify(t *testing.T) {
+	e := newExchange(t)
+	cloid := types.NewCloidFromInt(1)
+	result, err := e.BulkOrders([]types.OrderRequest{
+		{Coin: "ETH", IsBuy: true, Sz: 1, LimitPx: 1990, OrderType: types.NewLimit(types.TifGtc)},
+		{Coin: "ETH", IsBuy: false, Sz: 1, LimitPx: 2020, OrderType: types.NewLimit(types.TifGtc), Cloid: cloid},
+	}, nil)
+	if err != nil {
+		t.Fatalf("BulkOrders() error = %v", err)
+	}
+	oid := result.Data.Statuses[0].Resting.Oid
+
+	modified, err := e.ModifyOrder(oid, "ETH", true, 1, 2001, types.NewLimit(types.TifGtc), false, nil)
+	if err != nil {
+		t.Fatalf("ModifyOrder() error = %v", err)
+	}
+	if modified.Data.Statuses[0].Filled == nil {
+		t.Fatal("modified.Data.Statuses[0].Filled = nil")
+	}
+	modified, err = e.ModifyOrder(oid, "ETH", true, 1, 1980, types.NewLimit(types.TifGtc), false, nil)
+	if err != nil {
+		t.Fatalf("ModifyOrder() error = %v", err)
+	}
+	if modified.Data.Statuses[0].Error == "" {
+		t.Errorf("modified.Data.Statuses[0].Error = %q, want not empty", modified.Data.Statuses[0].Error)
+	}
+
+	canceled, err := e.Cancel("ETH", oid)
+	if err != nil {
+		t.Fatalf("Cancel(ETH, oid) error = %v", err)
+	}
+	if want := []string{"Order was never placed, already canceled, or filled."}; !slices.Equal(canceled.Data.Statuses, want) {
+		t.Errorf("canceled.Data.Statuses = %+v, want %+v", canceled.Data.Statuses, want)
+	}
+	canceled, err = e.CancelByCloid("ETH", *cloid)
+	if err != nil {
+		t.Fatalf("CancelByCloid(ETH, *cloid) error = %v", err)
+	}
+	if want := []string{"success"}; !slices.Equal(canceled.Data.Statuses, want) {
+		t.Errorf("canceled.Data.Statuses = %+v, want %+v", canceled.Data.Statuses, want)
+	}
+	if got := e.OpenOrders(); len(got) != 0 {
+		t.Errorf("OpenOrders() = %+v, want empty", got)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	e := New(backtest.Config{InitialBalance: 1000})
+	data := ws.NewMarketData("ETH")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- e.Follow(ctx, data) }()
+
+	// The initial view of the market data registers its coin
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		result, _ := e.Order("ETH", true, 1, 1990, types.NewLimit(types.TifGtc), false, nil, nil)
+		if result.Data.Statuses[0].Error == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Order() = %+v, want accepted", result.Data.Statuses[0])
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Follow() error = %v, want %v", err, context.Canceled)
+	}
+}
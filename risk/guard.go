@@ -0,0 +1,610 @@
+// Package risk enforces pre-trade limits on an Exchange. A Guard wraps a
+// client.Exchanger, refuses the orders that would break the limits of a coin or of
+// the portfolio or the order rate, and trips a kill switch that cancels every order
+// and flattens every position when the daily loss or the account value goes too far:
+//
+//	guard := risk.NewGuard(exchange, info, risk.Config{
+//	    Default:      risk.Limits{MaxPosition: 10, MaxNotional: 50000},
+//	    MaxDailyLoss: 1000,
+//	    MaxOrders:    60,
+//	})
+//	if err := guard.Refresh(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	// Trade through guard instead of exchange, calling guard.Refresh periodically
+//	_, err := guard.Order("ETH", true, 1, 2000, types.NewLimit(types.TifGtc), false, nil, nil)
+//	var violation *risk.Violation
+//	if errors.As(err, &violation) {
+//	    log.Println("blocked:", violation)
+//	}
+//
+// A LiquidationMonitor watches the distance to liquidation of the positions and can
+// reduce them when they get too close.
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// epsilon is the size below which an open order is filled
+const epsilon = 1e-9
+
+// DefaultOrderWindow is the window of Config.MaxOrders when OrderWindow is zero
+const DefaultOrderWindow = time.Minute
+
+// ErrKilled is returned for orders that could increase a position while the kill
+// switch is tripped
+var ErrKilled = errors.New("risk kill switch is tripped")
+
+// Limits are the limits of a coin. Zero values are no limit.
+type Limits struct {
+	// MaxPosition is the largest absolute size of the position
+	MaxPosition float64
+	// MaxNotional is the largest absolute value of the position, in USD
+	MaxNotional float64
+}
+
+// Config configures a Guard. Zero values are no limit.
+type Config struct {
+	// Address is the account whose state is checked, the GetAccountAddress of the
+	// exchange if empty; set it to the vault when trading for one
+	Address string
+	// Default are the limits of the coins not in Coins
+	Default Limits
+	Coins   map[string]Limits
+	// MaxPortfolioNotional is the largest sum of the absolute values of the positions
+	MaxPortfolioNotional float64
+	// MaxDailyLoss is the largest drop of the account value since the first Refresh of
+	// the UTC day, which trips the kill switch. Transfers count as profits and losses.
+	MaxDailyLoss float64
+	// MinAccountValue is the account value below which the kill switch trips
+	MinAccountValue float64
+	// MaxOrders is the largest number of orders and modifies sent per OrderWindow.
+	// Cancels are not counted.
+	MaxOrders   int
+	OrderWindow time.Duration
+	// ScheduleCancelAfter is how far in the future the kill switch schedules the cancel
+	// of all orders, in case its own cancels fail. The exchange needs at least 5
+	// seconds; 10 seconds if zero.
+	ScheduleCancelAfter time.Duration
+}
+
+// limits returns the limits of coin
+func (c *Config) limits(coin string) Limits {
+	if l, ok := c.Coins[coin]; ok {
+		return l
+	}
+	return c.Default
+}
+
+// Violation is the error of an order refused for breaking a limit
+type Violation struct {
+	// Coin is the coin of the order, empty for the limits of the account
+	Coin string
+	// Limit is the broken limit, e.g. "max position"
+	Limit string
+	// Value is what the order would lead to, Max the limit
+	Value float64
+	Max   float64
+}
+
+func (v *Violation) Error() string {
+	if v.Coin == "" {
+		return fmt.Sprintf("risk limit %s exceeded: %v > %v", v.Limit, v.Value, v.Max)
+	}
+	return fmt.Sprintf("risk limit %s exceeded for %s: %v > %v", v.Limit, v.Coin, v.Value, v.Max)
+}
+
+// Guard is an Exchanger enforcing the limits of its config on the orders sent through
+// it. The positions checked are those of the last Refresh, updated with the fills of
+// orders filled when sent and with OnFill. The open orders of the last Refresh and the
+// orders resting when sent through the guard count as if filled, until they are
+// canceled through the guard or filled, so an order is checked against the worst case
+// of the position and the open orders on its side. Safe for concurrent use.
+type Guard struct {
+	client.Exchanger
+	info   client.Infoer
+	config Config
+
+	mu        sync.Mutex
+	clock     utils.Clock
+	onKill    func(reason string)
+	positions map[string]float64
+	// marks are the prices of the positions, updated by Refresh and by orders
+	marks map[string]float64
+	// open are the orders resting on the book by oid, updated by Refresh, by the orders
+	// and cancels sent and by OnFill
+	open     map[int]*openOrder
+	day      string
+	dayValue float64
+	value    float64
+	sent     []time.Time
+	killed   string
+}
+
+var _ client.Exchanger = (*Guard)(nil)
+
+// NewGuard creates a guard of the orders sent to exchange, reading the state of the
+// account with info
+func NewGuard(exchange client.Exchanger, info client.Infoer, config Config) *Guard {
+	if config.Address == "" {
+		config.Address = exchange.GetAccountAddress()
+	}
+	if config.OrderWindow == 0 {
+		config.OrderWindow = DefaultOrderWindow
+	}
+	if config.ScheduleCancelAfter == 0 {
+		config.ScheduleCancelAfter = 10 * time.Second
+	}
+	return &Guard{
+		Exchanger: exchange,
+		info:      info,
+		config:    config,
+		clock:     utils.SystemClock,
+		positions: make(map[string]float64),
+		marks:     make(map[string]float64),
+		open:      make(map[int]*openOrder),
+	}
+}
+
+// openOrder is an order resting on the book
+type openOrder struct {
+	coin  string
+	isBuy bool
+	sz    float64
+	// px is the limit price, the mark of coins without position
+	px float64
+	// reduceOnly orders cannot increase the position, and are not counted
+	reduceOnly bool
+	cloid      string
+}
+
+// restingOrder returns the open order resting for order
+func restingOrder(order types.OrderRequest) *openOrder {
+	open := &openOrder{coin: order.Coin, isBuy: order.IsBuy, sz: order.Size(), px: order.Price(), reduceOnly: order.ReduceOnly}
+	if order.Cloid != nil {
+		open.cloid = order.Cloid.ToRaw()
+	}
+	return open
+}
+
+// SetClock sets the clock of the order rate and of the daily loss, e.g. a
+// utils.FakeClock in tests
+func (g *Guard) SetClock(clock utils.Clock) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.clock = clock
+}
+
+// SetKillHook sets a function called with the reason when the kill switch trips,
+// before the orders are canceled and the positions closed
+func (g *Guard) SetKillHook(hook func(reason string)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onKill = hook
+}
+
+// Position returns the signed position in coin as known by the guard
+func (g *Guard) Position(coin string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.positions[coin]
+}
+
+// Resting returns the sizes of the open buy and sell orders in coin as known by the
+// guard, without the reduce only orders
+func (g *Guard) Resting(coin string) (buys, sells float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, o := range g.open {
+		switch {
+		case o.coin != coin || o.reduceOnly:
+		case o.isBuy:
+			buys += o.sz
+		default:
+			sells += o.sz
+		}
+	}
+	return buys, sells
+}
+
+// OnFill records a fill of the userFills feed, so orders filled after resting count
+// before the next Refresh
+func (g *Guard) OnFill(fill types.Fill) {
+	start, err := strconv.ParseFloat(fill.StartPosition, 64)
+	if err != nil {
+		return
+	}
+	sz, err := fill.SzFloat()
+	if err != nil {
+		return
+	}
+	px, _ := fill.PxFloat()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.positions[fill.Coin] = start + float64(fill.Side.Sign())*sz
+	if px > 0 {
+		g.marks[fill.Coin] = px
+	}
+	if o := g.open[int(fill.Oid)]; o != nil {
+		if o.sz -= sz; o.sz <= epsilon {
+			delete(g.open, int(fill.Oid))
+		}
+	}
+}
+
+// Order places a single order within the limits
+func (g *Guard) Order(
+	name string,
+	isBuy bool,
+	sz float64,
+	limitPx float64,
+	orderType types.OrderType,
+	reduceOnly bool,
+	cloid *types.Cloid,
+	builder *types.BuilderInfo,
+) (*types.OrderResponse, error) {
+	order := types.OrderRequest{Coin: name, IsBuy: isBuy, Sz: sz, LimitPx: limitPx, OrderType: orderType, ReduceOnly: reduceOnly, Cloid: cloid}
+	return g.BulkOrders([]types.OrderRequest{order}, builder)
+}
+
+// BulkOrders places multiple orders if all of them are within the limits
+func (g *Guard) BulkOrders(orders []types.OrderRequest, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	if err := g.check(orders, nil); err != nil {
+		return nil, err
+	}
+	result, err := g.Exchanger.BulkOrders(orders, builder)
+	if err == nil {
+		g.record(orders, result.Data.Statuses)
+	}
+	return result, err
+}
+
+// MarketOpen opens a position within the limits, checked at the mid price
+func (g *Guard) MarketOpen(name string, isBuy bool, sz float64, px *float64, slippage float64, cloid *types.Cloid, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	price, err := g.marketPrice(name, px)
+	if err != nil {
+		return nil, err
+	}
+	order := types.OrderRequest{Coin: name, IsBuy: isBuy, Sz: sz, LimitPx: price}
+	if err := g.check([]types.OrderRequest{order}, nil); err != nil {
+		return nil, err
+	}
+	result, err := g.Exchanger.MarketOpen(name, isBuy, sz, px, slippage, cloid, builder)
+	if err == nil {
+		g.record([]types.OrderRequest{order}, result.Data.Statuses)
+	}
+	return result, err
+}
+
+// MarketClose closes the position in name, or sz of it. Closing is always allowed,
+// and only counts against the order rate.
+func (g *Guard) MarketClose(name string, sz *float64, px *float64, slippage float64, cloid *types.Cloid, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	g.mu.Lock()
+	g.prune()
+	g.sent = append(g.sent, g.clock.Now())
+	szi := g.positions[name]
+	g.mu.Unlock()
+	result, err := g.Exchanger.MarketClose(name, sz, px, slippage, cloid, builder)
+	if err == nil && szi != 0 {
+		g.record([]types.OrderRequest{{Coin: name, IsBuy: szi < 0}}, result.Data.Statuses)
+	}
+	return result, err
+}
+
+// ModifyOrder modifies a single order within the limits
+func (g *Guard) ModifyOrder(
+	oid any,
+	name string,
+	isBuy bool,
+	sz float64,
+	limitPx float64,
+	orderType types.OrderType,
+	reduceOnly bool,
+	cloid *types.Cloid,
+) (*types.ModifyResponse, error) {
+	modify := types.ModifyRequest{
+		Oid:   oid,
+		Order: types.OrderRequest{Coin: name, IsBuy: isBuy, Sz: sz, LimitPx: limitPx, OrderType: orderType, ReduceOnly: reduceOnly, Cloid: cloid},
+	}
+	return g.BulkModifyOrders([]types.ModifyRequest{modify})
+}
+
+// BulkModifyOrders modifies multiple orders if all the new orders are within the
+// limits, checked as new orders replacing the modified ones
+func (g *Guard) BulkModifyOrders(modifies []types.ModifyRequest) (*types.ModifyResponse, error) {
+	orders := make([]types.OrderRequest, len(modifies))
+	for i, m := range modifies {
+		orders[i] = m.Order
+	}
+	g.mu.Lock()
+	replaced := make([]int, len(modifies))
+	for i, m := range modifies {
+		replaced[i] = g.openOid(m.Oid)
+	}
+	g.mu.Unlock()
+	if err := g.check(orders, replaced); err != nil {
+		return nil, err
+	}
+	result, err := g.Exchanger.BulkModifyOrders(modifies)
+	if err != nil {
+		return result, err
+	}
+	g.mu.Lock()
+	for i, oid := range replaced {
+		o := g.open[oid]
+		if o == nil {
+			continue
+		}
+		// A single modify is answered without status: the order keeps resting under
+		// its old oid until the next Refresh
+		switch statuses := result.Data.Statuses; {
+		case i >= len(statuses):
+			g.open[oid] = restingOrder(orders[i])
+		case statuses[i].Error == "":
+			delete(g.open, oid)
+		}
+	}
+	g.mu.Unlock()
+	g.record(orders, result.Data.Statuses)
+	return result, err
+}
+
+// Cancel cancels an order, which stops counting against the limits
+func (g *Guard) Cancel(name string, oid int) (*types.CancelResponse, error) {
+	return g.BulkCancel([]types.CancelRequest{{Coin: name, Oid: oid}})
+}
+
+// CancelByCloid cancels an order by its client order ID, which stops counting against
+// the limits
+func (g *Guard) CancelByCloid(name string, cloid types.Cloid) (*types.CancelResponse, error) {
+	return g.BulkCancelByCloid([]types.CancelByCloidRequest{{Coin: name, Cloid: cloid}})
+}
+
+// BulkCancel cancels multiple orders, which stop counting against the limits
+func (g *Guard) BulkCancel(cancels []types.CancelRequest) (*types.CancelResponse, error) {
+	result, err := g.Exchanger.BulkCancel(cancels)
+	if err == nil {
+		oids := make([]int, len(cancels))
+		for i, c := range cancels {
+			oids[i] = c.Oid
+		}
+		g.canceled(oids, result.Data.Statuses)
+	}
+	return result, err
+}
+
+// BulkCancelByCloid cancels multiple orders by their client order IDs, which stop
+// counting against the limits
+func (g *Guard) BulkCancelByCloid(cancels []types.CancelByCloidRequest) (*types.CancelResponse, error) {
+	result, err := g.Exchanger.BulkCancelByCloid(cancels)
+	if err == nil {
+		g.mu.Lock()
+		oids := make([]int, len(cancels))
+		for i, c := range cancels {
+			oids[i] = g.openOid(c.Cloid)
+		}
+		g.mu.Unlock()
+		g.canceled(oids, result.Data.Statuses)
+	}
+	return result, err
+}
+
+// TWAPOrder places a TWAP order if its whole size is within the limits, checked at
+// the mid price
+func (g *Guard) TWAPOrder(name string, isBuy bool, sz float64, reduceOnly bool, minutes int, randomize bool) (*types.TWAPOrderResponse, error) {
+	price, err := g.marketPrice(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.check([]types.OrderRequest{{Coin: name, IsBuy: isBuy, Sz: sz, LimitPx: price, ReduceOnly: reduceOnly}}, nil); err != nil {
+		return nil, err
+	}
+	return g.Exchanger.TWAPOrder(name, isBuy, sz, reduceOnly, minutes, randomize)
+}
+
+// marketPrice returns px, or the mid price of name
+func (g *Guard) marketPrice(name string, px *float64) (float64, error) {
+	if px != nil {
+		return *px, nil
+	}
+	mids, err := g.info.AllMids("")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get mid prices: %w", err)
+	}
+	mid, err := strconv.ParseFloat(mids[name], 64)
+	if err != nil || !(mid > 0) {
+		return 0, fmt.Errorf("no mid price for %s", name)
+	}
+	return mid, nil
+}
+
+// check checks orders against the limits, in order as if all of them rested with the
+// open orders but the replaced ones, and counts them against the order rate if they
+// pass. An order is checked at the worst case of its side: the position filled with
+// the open orders and the orders before it on that side.
+func (g *Guard) check(orders []types.OrderRequest, replaced []int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prune()
+	if limit := g.config.MaxOrders; limit > 0 && len(g.sent)+len(orders) > limit {
+		return &Violation{Limit: "order rate", Value: float64(len(g.sent) + len(orders)), Max: float64(limit)}
+	}
+
+	positions := make(map[string]float64, len(g.positions))
+	marks := make(map[string]float64, len(g.marks))
+	for coin, szi := range g.positions {
+		positions[coin] = szi
+	}
+	for coin, px := range g.marks {
+		marks[coin] = px
+	}
+	buys := make(map[string]float64)
+	sells := make(map[string]float64)
+	for oid, o := range g.open {
+		switch {
+		case o.reduceOnly || slices.Contains(replaced, oid):
+			continue
+		case o.isBuy:
+			buys[o.coin] += o.sz
+		default:
+			sells[o.coin] += o.sz
+		}
+		if _, ok := marks[o.coin]; !ok {
+			marks[o.coin] = o.px
+		}
+	}
+	for _, order := range orders {
+		// Reduce only orders cannot increase a position, and pass any limit
+		if order.ReduceOnly {
+			continue
+		}
+		szi := positions[order.Coin] + buys[order.Coin]
+		if !order.IsBuy {
+			szi = positions[order.Coin] - sells[order.Coin]
+		}
+		next := szi + signed(order)
+		if order.IsBuy {
+			buys[order.Coin] += order.Size()
+		} else {
+			sells[order.Coin] += order.Size()
+		}
+		if math.Abs(next) <= math.Abs(szi) {
+			continue
+		}
+		if g.killed != "" {
+			return fmt.Errorf("%w: %s", ErrKilled, g.killed)
+		}
+		limits := g.config.limits(order.Coin)
+		if limits.MaxPosition > 0 && math.Abs(next) > limits.MaxPosition {
+			return &Violation{Coin: order.Coin, Limit: "max position", Value: math.Abs(next), Max: limits.MaxPosition}
+		}
+		if notional := math.Abs(next) * order.Price(); limits.MaxNotional > 0 && notional > limits.MaxNotional {
+			return &Violation{Coin: order.Coin, Limit: "max notional", Value: notional, Max: limits.MaxNotional}
+		}
+		marks[order.Coin] = order.Price()
+		if limit := g.config.MaxPortfolioNotional; limit > 0 {
+			coins := make(map[string]bool, len(positions))
+			for coin := range positions {
+				coins[coin] = true
+			}
+			for coin := range buys {
+				coins[coin] = true
+			}
+			for coin := range sells {
+				coins[coin] = true
+			}
+			var total float64
+			for coin := range coins {
+				szi := positions[coin]
+				total += max(math.Abs(szi+buys[coin]), math.Abs(szi-sells[coin])) * marks[coin]
+			}
+			if total > limit {
+				return &Violation{Limit: "max portfolio notional", Value: total, Max: limit}
+			}
+		}
+	}
+
+	now := g.clock.Now()
+	for range orders {
+		g.sent = append(g.sent, now)
+	}
+	return nil
+}
+
+// record applies the statuses of orders just sent: the fills to the positions, and the
+// orders resting to the open orders
+func (g *Guard) record(orders []types.OrderRequest, statuses []types.OrderStatus) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, status := range statuses {
+		if i >= len(orders) {
+			continue
+		}
+		if status.Resting != nil {
+			g.open[status.Resting.Oid] = restingOrder(orders[i])
+		}
+		if status.Filled == nil {
+			continue
+		}
+		sz, err := strconv.ParseFloat(status.Filled.TotalSz, 64)
+		if err != nil {
+			continue
+		}
+		order := orders[i]
+		if !order.IsBuy {
+			sz = -sz
+		}
+		g.positions[order.Coin] += sz
+		if px, err := strconv.ParseFloat(status.Filled.AvgPx, 64); err == nil && px > 0 {
+			g.marks[order.Coin] = px
+		}
+	}
+}
+
+// canceled forgets the open orders canceled with success
+func (g *Guard) canceled(oids []int, statuses []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, status := range statuses {
+		if i < len(oids) && status == "success" {
+			delete(g.open, oids[i])
+		}
+	}
+}
+
+// openOid returns the oid of the open order identified by id, an oid or a Cloid, or -1
+// if unknown. Must be called with mu held.
+func (g *Guard) openOid(id any) int {
+	switch id := id.(type) {
+	case int:
+		return id
+	case types.Cloid:
+		return g.oidByCloid(id.ToRaw())
+	case *types.Cloid:
+		if id != nil {
+			return g.oidByCloid(id.ToRaw())
+		}
+	}
+	return -1
+}
+
+// oidByCloid returns the oid of the open order with cloid, or -1. Must be called with
+// mu held.
+func (g *Guard) oidByCloid(cloid string) int {
+	for oid, o := range g.open {
+		if o.cloid != "" && strings.EqualFold(o.cloid, cloid) {
+			return oid
+		}
+	}
+	return -1
+}
+
+// prune forgets the orders sent before the order window. Must be called with mu held.
+func (g *Guard) prune() {
+	cutoff := g.clock.Now().Add(-g.config.OrderWindow)
+	i := 0
+	for i < len(g.sent) && !g.sent[i].After(cutoff) {
+		i++
+	}
+	g.sent = g.sent[i:]
+}
+
+// signed returns the signed size of order
+func signed(order types.OrderRequest) float64 {
+	if order.IsBuy {
+		return order.Size()
+	}
+	return -order.Size()
+}
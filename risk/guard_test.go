@@ -0,0 +1,294 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+const maker = "0x00000000000000000000000000000000000000aa"
+
+// newServer starts a server with books around 2000 for ETH and 60000 for BTC, and
+// returns an Exchange and an Info of a new account
+func newServer(t *testing.T) (*hltest.Server, *client.Exchange, *client.Info) {
+	t.Helper()
+	srv := hltest.NewTestServer(t)
+	for _, o := range []struct {
+		coin   string
+		isBuy  bool
+		sz, px float64
+	}{
+		{"ETH", true, 100, 1999}, {"ETH", false, 100, 2001},
+		{"BTC", true, 10, 59990}, {"BTC", false, 10, 60010},
+	} {
+		_, err := srv.PlaceOrder(maker, o.coin, o.isBuy, o.sz, o.px)
+		if err != nil {
+			t.Fatalf("PlaceOrder() error = %v", err)
+		}
+	}
+	return srv, srv.NewTestExchange(t), srv.NewTestInfo(t)
+}
+
+func ioc(g *Guard, coin string, isBuy bool, sz, px float64) error {
+	_, err := g.Order(coin, isBuy, sz, px, types.NewLimit(types.TifIoc), false, nil, nil)
+	return err
+}
+
+func assertViolation(t *testing.T, err error, coin, limit string) {
+	t.Helper()
+	var v *Violation
+	if !errors.As(err, &v) {
+		t.Fatalf("error = %v", err)
+	}
+	if v.Coin != coin {
+		t.Errorf("v.Coin = %q, want %q", v.Coin, coin)
+	}
+	if v.Limit != limit {
+		t.Errorf("v.Limit = %q, want %q", v.Limit, limit)
+	}
+}
+
+func TestGuardLimits(t *testing.T) {
+	srv, exchange, info := newServer(t)
+	g := NewGuard(exchange, info, Config{
+		Default:              Limits{MaxPosition: 2},
+		Coins:                map[string]Limits{"BTC": {MaxNotional: 30000}},
+		MaxPortfolioNotional: 27000,
+	})
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	user := exchange.GetAccountAddress()
+
+	if err := ioc(g, "ETH", true, 1, 2001); err != nil {
+		t.Fatalf("ioc(g, ETH, true, 1, 2001) error = %v", err)
+	}
+	if got := g.Position("ETH"); got != 1.0 {
+		t.Errorf("Position(ETH) = %v, want 1.0", got)
+	}
+	assertViolation(t, ioc(g, "ETH", true, 1.5, 2001), "ETH", "max position")
+	assertViolation(t, ioc(g, "BTC", true, 0.6, 60010), "BTC", "max notional")
+	if err := ioc(g, "BTC", true, 0.4, 60010); err != nil {
+		t.Fatalf("ioc() error = %v", err)
+	}
+	assertViolation(t, ioc(g, "ETH", true, 1, 2001), "", "max portfolio notional")
+
+	// A batch is refused as a whole, on the positions it would lead to
+	_, err := g.BulkOrders([]types.OrderRequest{
+		{Coin: "ETH", IsBuy: false, Sz: 0.5, LimitPx: 1999, OrderType: types.NewLimit(types.TifIoc)},
+		{Coin: "ETH", IsBuy: false, Sz: 3, LimitPx: 1999, OrderType: types.NewLimit(types.TifIoc)},
+	}, nil)
+	assertViolation(t, err, "ETH", "max position")
+	if got := srv.Position(user, "ETH"); got != 1.0 {
+		t.Errorf("Position(user, ETH) = %v, want 1.0", got)
+	}
+
+	// Orders reducing a position pass, including reduce only orders larger than it
+	_, err = g.Order("BTC", false, 1, 59990, types.NewLimit(types.TifIoc), true, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if got := g.Position("BTC"); got != 0 {
+		t.Errorf("Position(BTC) = %v, want 0", got)
+	}
+	if err := ioc(g, "ETH", false, 0.5, 1999); err != nil {
+		t.Fatalf("ioc() error = %v", err)
+	}
+	if got := g.Position("ETH"); got != 0.5 {
+		t.Errorf("Position(ETH) = %v, want 0.5", got)
+	}
+
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got := g.Position("ETH"); got != 0.5 {
+		t.Errorf("Position(ETH) = %v, want 0.5", got)
+	}
+	if got := g.Position("BTC"); got != 0 {
+		t.Errorf("Position(BTC) = %v, want 0", got)
+	}
+}
+
+func TestGuardExactOrders(t *testing.T) {
+	_, exchange, info := newServer(t)
+	g := NewGuard(exchange, info, Config{Default: Limits{MaxPosition: 2}, Coins: map[string]Limits{"BTC": {MaxNotional: 30000}}})
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	// The exact size and price are what is sent, and what is checked
+	sz, px := types.MustParseDecimal("3"), types.MustParseDecimal("2001")
+	_, err := g.BulkOrders([]types.OrderRequest{
+		{Coin: "ETH", IsBuy: true, ExactSz: &sz, ExactLimitPx: &px, OrderType: types.NewLimit(types.TifIoc)},
+	}, nil)
+	assertViolation(t, err, "ETH", "max position")
+	btcSz, btcPx := types.MustParseDecimal("0.6"), types.MustParseDecimal("60010")
+	_, err = g.BulkOrders([]types.OrderRequest{
+		{Coin: "BTC", IsBuy: true, ExactSz: &btcSz, ExactLimitPx: &btcPx, OrderType: types.NewLimit(types.TifIoc)},
+	}, nil)
+	assertViolation(t, err, "BTC", "max notional")
+
+	sz = types.MustParseDecimal("1")
+	_, err = g.BulkOrders([]types.OrderRequest{
+		{Coin: "ETH", IsBuy: true, ExactSz: &sz, ExactLimitPx: &px, OrderType: types.NewLimit(types.TifIoc)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("BulkOrders() error = %v", err)
+	}
+	if got := g.Position("ETH"); got != 1.0 {
+		t.Errorf("Position(ETH) = %v, want 1.0", got)
+	}
+}
+
+func TestGuardMarketAndModify(t *testing.T) {
+	_, exchange, info := newServer(t)
+	g := NewGuard(exchange, info, Config{Default: Limits{MaxNotional: 5000}})
+
+	// Market orders are checked at the mid price
+	_, err := g.MarketOpen("ETH", true, 3, nil, 0, nil, nil)
+	assertViolation(t, err, "ETH", "max notional")
+	_, err = g.TWAPOrder("ETH", true, 3, false, 10, false)
+	assertViolation(t, err, "ETH", "max notional")
+	_, err = g.MarketOpen("ETH", true, 2, nil, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("MarketOpen() error = %v", err)
+	}
+	if got := g.Position("ETH"); got != 2.0 {
+		t.Errorf("Position(ETH) = %v, want 2.0", got)
+	}
+	_, err = g.MarketClose("ETH", nil, nil, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("MarketClose() error = %v", err)
+	}
+	if got := g.Position("ETH"); got != 0 {
+		t.Errorf("Position(ETH) = %v, want 0", got)
+	}
+
+	// Modifies are checked as new orders
+	result, err := g.Order("ETH", true, 1, 1900, types.NewLimit(types.TifGtc), false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	oid := result.Data.Statuses[0].Resting.Oid
+	_, err = g.ModifyOrder(oid, "ETH", true, 3, 1900, types.NewLimit(types.TifGtc), false, nil)
+	assertViolation(t, err, "ETH", "max notional")
+	_, err = g.ModifyOrder(oid, "ETH", true, 2, 1900, types.NewLimit(types.TifGtc), false, nil)
+	if err != nil {
+		t.Fatalf("ModifyOrder() error = %v", err)
+	}
+}
+
+func TestGuardRestingOrders(t *testing.T) {
+	_, exchange, info := newServer(t)
+	g := NewGuard(exchange, info, Config{Default: Limits{MaxPosition: 2}, MaxPortfolioNotional: 5000})
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	// Each order is within the limit, the second is not with the first resting
+	gtc := types.NewLimit(types.TifGtc)
+	cloid := types.NewCloidFromInt(1)
+	if _, err := g.Order("ETH", true, 1.5, 1900, gtc, false, cloid, nil); err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if buys, sells := g.Resting("ETH"); buys != 1.5 || sells != 0 {
+		t.Errorf("Resting(ETH) = %v, %v, want 1.5, 0", buys, sells)
+	}
+	_, err := g.Order("ETH", true, 1, 1900, gtc, false, nil, nil)
+	assertViolation(t, err, "ETH", "max position")
+
+	// The other side is checked on its own, and the portfolio at the worst side
+	sell, err := g.Order("ETH", false, 2, 2100, gtc, false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	_, err = g.Order("BTC", true, 0.03, 59000, gtc, false, nil, nil)
+	assertViolation(t, err, "", "max portfolio notional")
+
+	// Canceled orders stop counting
+	if _, err := g.CancelByCloid("ETH", *cloid); err != nil {
+		t.Fatalf("CancelByCloid() error = %v", err)
+	}
+	if buys, _ := g.Resting("ETH"); buys != 0 {
+		t.Errorf("Resting(ETH) buys = %v, want 0", buys)
+	}
+	if _, err := g.Order("ETH", true, 1, 1900, gtc, false, nil, nil); err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+
+	// Orders placed around the guard count from the next Refresh
+	if _, err := exchange.Order("ETH", true, 1, 1900, gtc, false, nil, nil); err != nil {
+		t.Fatalf("exchange.Order() error = %v", err)
+	}
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if buys, sells := g.Resting("ETH"); buys != 2 || sells != 2 {
+		t.Errorf("Resting(ETH) = %v, %v, want 2, 2", buys, sells)
+	}
+	_, err = g.Order("ETH", true, 0.5, 1900, gtc, false, nil, nil)
+	assertViolation(t, err, "ETH", "max position")
+
+	// Fills move the size from the open orders to the position
+	oid := int64(sell.Data.Statuses[0].Resting.Oid)
+	g.OnFill(types.Fill{Coin: "ETH", Side: types.SideAsk, Px: "2100", Sz: "0.5", StartPosition: "0", Oid: oid})
+	if got := g.Position("ETH"); got != -0.5 {
+		t.Errorf("Position(ETH) = %v, want -0.5", got)
+	}
+	if _, sells := g.Resting("ETH"); sells != 1.5 {
+		t.Errorf("Resting(ETH) sells = %v, want 1.5", sells)
+	}
+}
+
+func TestGuardOrderRate(t *testing.T) {
+	_, exchange, info := newServer(t)
+	g := NewGuard(exchange, info, Config{MaxOrders: 2})
+	clock := utils.NewFakeClock(time.Now())
+	g.SetClock(clock)
+
+	gtc := types.NewLimit(types.TifGtc)
+	result, err := g.Order("ETH", true, 1, 1900, gtc, false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	clock.Advance(30 * time.Second)
+	_, err = g.Order("ETH", true, 1, 1900, gtc, false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	_, err = g.Order("ETH", true, 1, 1900, gtc, false, nil, nil)
+	assertViolation(t, err, "", "order rate")
+
+	// Cancels are not counted, and the window slides
+	_, err = g.Cancel("ETH", result.Data.Statuses[0].Resting.Oid)
+	if err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	clock.Advance(30 * time.Second)
+	_, err = g.Order("ETH", true, 1, 1900, gtc, false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	_, err = g.Order("ETH", true, 1, 1900, gtc, false, nil, nil)
+	assertViolation(t, err, "", "order rate")
+}
+
+func TestGuardOnFill(t *testing.T) {
+	_, exchange, info := newServer(t)
+	g := NewGuard(exchange, info, Config{Default: Limits{MaxPosition: 2}})
+
+	g.OnFill(types.Fill{Coin: "ETH", Side: types.SideBid, Px: "2000", Sz: "1.5", StartPosition: "0"})
+	if got := g.Position("ETH"); got != 1.5 {
+		t.Errorf("Position(ETH) = %v, want 1.5", got)
+	}
+	assertViolation(t, ioc(g, "ETH", true, 1, 2001), "ETH", "max position")
+	g.OnFill(types.Fill{Coin: "ETH", Side: types.SideAsk, Px: "2000", Sz: "1", StartPosition: "1.5"})
+	if got := g.Position("ETH"); got != 0.5 {
+		t.Errorf("Position(ETH) = %v, want 0.5", got)
+	}
+}
@@ -0,0 +1,169 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// Refresh reads the positions, the open orders and the account value from the
+// exchange, and trips the kill switch if the daily loss or the account value is beyond
+// its limit
+func (g *Guard) Refresh() error {
+	state, err := g.info.UserState(g.config.Address, "")
+	if err != nil {
+		return fmt.Errorf("failed to get user state: %w", err)
+	}
+	value, err := state.MarginSummary.AccountValueFloat()
+	if err != nil {
+		return fmt.Errorf("failed to parse account value: %w", err)
+	}
+	positions := make(map[string]float64)
+	marks := make(map[string]float64)
+	for _, ap := range state.AssetPositions {
+		szi, err := ap.Position.SziFloat()
+		if err != nil {
+			return fmt.Errorf("failed to parse position of %s: %w", ap.Position.Coin, err)
+		}
+		positions[ap.Position.Coin] = szi
+		if pv, err := ap.Position.PositionValueFloat(); err == nil && szi != 0 {
+			marks[ap.Position.Coin] = pv / math.Abs(szi)
+		}
+	}
+	orders, err := g.info.OpenOrders(g.config.Address, "")
+	if err != nil {
+		return fmt.Errorf("failed to get open orders: %w", err)
+	}
+	open := make(map[int]*openOrder, len(orders))
+	for _, o := range orders {
+		sz, err := strconv.ParseFloat(o.Sz, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse size of order %d: %w", o.Oid, err)
+		}
+		px, err := strconv.ParseFloat(o.LimitPx, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse price of order %d: %w", o.Oid, err)
+		}
+		open[o.Oid] = &openOrder{coin: o.Coin, isBuy: o.Side == types.SideBid, sz: sz, px: px}
+	}
+
+	g.mu.Lock()
+	g.positions = positions
+	// The open orders do not tell the reduce only orders and the cloids, known for the
+	// orders sent through the guard
+	for oid, o := range open {
+		if known := g.open[oid]; known != nil {
+			o.reduceOnly, o.cloid = known.reduceOnly, known.cloid
+		}
+	}
+	g.open = open
+	for coin, px := range marks {
+		g.marks[coin] = px
+	}
+	g.value = value
+	if day := g.clock.Now().UTC().Format(time.DateOnly); day != g.day {
+		g.day, g.dayValue = day, value
+	}
+	var reason string
+	switch loss := g.dayValue - value; {
+	case g.killed != "":
+	case g.config.MaxDailyLoss > 0 && loss > g.config.MaxDailyLoss:
+		reason = fmt.Sprintf("daily loss %v exceeds %v", loss, g.config.MaxDailyLoss)
+	case g.config.MinAccountValue > 0 && value < g.config.MinAccountValue:
+		reason = fmt.Sprintf("account value %v is below %v", value, g.config.MinAccountValue)
+	}
+	g.mu.Unlock()
+	if reason != "" {
+		return g.Kill(reason)
+	}
+	return nil
+}
+
+// DailyLoss returns the drop of the account value since the first Refresh of the UTC
+// day, negative for a profit
+func (g *Guard) DailyLoss() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.dayValue - g.value
+}
+
+// Kill trips the kill switch: it schedules the cancel of all orders, as a dead man's
+// switch, cancels the open orders and closes every perp position with market orders.
+// Until Reset, only orders that reduce a position are sent. Kill can be called again
+// to retry what failed.
+func (g *Guard) Kill(reason string) error {
+	g.mu.Lock()
+	if g.killed == "" {
+		g.killed = reason
+	}
+	hook := g.onKill
+	at := g.clock.Now().Add(g.config.ScheduleCancelAfter).UnixMilli()
+	g.mu.Unlock()
+	if hook != nil {
+		hook(reason)
+	}
+
+	var errs []error
+	if _, err := g.Exchanger.ScheduleCancel(&at); err != nil {
+		errs = append(errs, fmt.Errorf("failed to schedule cancel: %w", err))
+	}
+	open, err := g.info.OpenOrders(g.config.Address, "")
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to get open orders: %w", err))
+	} else if len(open) > 0 {
+		cancels := make([]types.CancelRequest, len(open))
+		for i, o := range open {
+			cancels[i] = types.CancelRequest{Coin: o.Coin, Oid: o.Oid}
+		}
+		if _, err := g.BulkCancel(cancels); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cancel open orders: %w", err))
+		}
+	}
+
+	state, err := g.info.UserState(g.config.Address, "")
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to get user state: %w", err))
+		return errors.Join(errs...)
+	}
+	for _, ap := range state.AssetPositions {
+		coin := ap.Position.Coin
+		if szi, _ := ap.Position.SziFloat(); szi == 0 {
+			continue
+		}
+		result, err := g.Exchanger.MarketClose(coin, nil, nil, 0, nil, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", coin, err))
+			continue
+		}
+		if statuses := result.Data.Statuses; len(statuses) > 0 && statuses[0].Error != "" {
+			errs = append(errs, fmt.Errorf("failed to close %s: %s", coin, statuses[0].Error))
+			continue
+		}
+		g.mu.Lock()
+		g.positions[coin] = 0
+		g.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Killed returns the reason the kill switch tripped, empty if it has not
+func (g *Guard) Killed() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.killed
+}
+
+// Reset rearms the kill switch, and removes the cancel it scheduled
+func (g *Guard) Reset() error {
+	g.mu.Lock()
+	g.killed = ""
+	g.mu.Unlock()
+	if _, err := g.Exchanger.ScheduleCancel(nil); err != nil {
+		return fmt.Errorf("failed to unschedule cancel: %w", err)
+	}
+	return nil
+}
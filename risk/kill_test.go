@@ -0,0 +1,138 @@
+package risk
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+func TestKillOnDailyLoss(t *testing.T) {
+	srv, exchange, info := newServer(t)
+	user := exchange.GetAccountAddress()
+
+	// The account value is set by the test, the position is the one of the server
+	var mu sync.Mutex
+	value := "10000"
+	srv.HandleInfo("clearinghouseState", func(map[string]any) (any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		summary := types.MarginSummary{AccountValue: value, TotalMarginUsed: "0", TotalNtlPos: "0", TotalRawUsd: "0"}
+		state := types.UserState{AssetPositions: []types.AssetPosition{}, MarginSummary: summary, CrossMarginSummary: summary, Withdrawable: "0"}
+		if szi := srv.Position(user, "ETH"); szi != 0 {
+			state.AssetPositions = append(state.AssetPositions, types.AssetPosition{Type: "oneWay", Position: types.Position{
+				Coin:          "ETH",
+				Szi:           strconv.FormatFloat(szi, 'f', -1, 64),
+				PositionValue: strconv.FormatFloat(szi*2000, 'f', -1, 64),
+			}})
+		}
+		return state, nil
+	})
+	setValue := func(v string) {
+		mu.Lock()
+		defer mu.Unlock()
+		value = v
+	}
+
+	g := NewGuard(exchange, info, Config{MaxDailyLoss: 1000})
+	clock := utils.NewFakeClock(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+	g.SetClock(clock)
+	var reasons []string
+	g.SetKillHook(func(reason string) { reasons = append(reasons, reason) })
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if err := ioc(g, "ETH", true, 1, 2001); err != nil {
+		t.Fatalf("ioc(g, ETH, true, 1, 2001) error = %v", err)
+	}
+	_, err := g.Order("ETH", true, 1, 1900, types.NewLimit(types.TifGtc), false, nil, nil)
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	setValue("9500")
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got := g.DailyLoss(); got != 500.0 {
+		t.Errorf("DailyLoss() = %v, want 500.0", got)
+	}
+	if got := g.Killed(); got != "" {
+		t.Errorf("Killed() = %q, want empty", got)
+	}
+
+	setValue("8900")
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if want := []string{"daily loss 1100 exceeds 1000"}; !slices.Equal(reasons, want) {
+		t.Errorf("reasons = %+v, want %+v", reasons, want)
+	}
+	if got := g.Killed(); got != reasons[0] {
+		t.Errorf("Killed() = %q, want %q", got, reasons[0])
+	}
+	if got := srv.OpenOrders(user); len(got) != 0 {
+		t.Errorf("OpenOrders(user) = %+v, want empty", got)
+	}
+	if got := srv.Position(user, "ETH"); got != 0 {
+		t.Errorf("Position(user, ETH) = %v, want 0", got)
+	}
+	if got := g.Position("ETH"); got != 0 {
+		t.Errorf("Position(ETH) = %v, want 0", got)
+	}
+	var scheduled bool
+	for _, r := range srv.Requests() {
+		scheduled = scheduled || strings.Contains(string(r.Body), `"scheduleCancel"`)
+	}
+	if !scheduled {
+		t.Error("scheduled = false")
+	}
+
+	// Only reducing orders pass until the switch is reset
+	if !errors.Is(ioc(g, "ETH", true, 1, 2001), ErrKilled) {
+		t.Error("Is() = false")
+	}
+	if err := g.Reset(); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if err := ioc(g, "ETH", true, 1, 2001); err != nil {
+		t.Fatalf("ioc(g, ETH, true, 1, 2001) error = %v", err)
+	}
+
+	// The loss is counted from the first refresh of the day
+	clock.Advance(24 * time.Hour)
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got := g.DailyLoss(); got != 0 {
+		t.Errorf("DailyLoss() = %v, want 0", got)
+	}
+	if got := g.Killed(); got != "" {
+		t.Errorf("Killed() = %q, want empty", got)
+	}
+}
+
+func TestKillOnAccountValue(t *testing.T) {
+	_, exchange, info := newServer(t)
+	g := NewGuard(exchange, info, Config{MinAccountValue: 100})
+
+	// The account value of the mock server is 0
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got := g.Killed(); got != "account value 0 is below 100" {
+		t.Errorf("Killed() = %q, want %q", got, "account value 0 is below 100")
+	}
+	if err := g.Kill("manual"); err != nil {
+		t.Fatalf("Kill(manual) error = %v", err)
+	}
+	if got := g.Killed(); got != "account value 0 is below 100" {
+		t.Errorf("Killed() = %q, want %q", got, "account value 0 is below 100")
+	}
+}
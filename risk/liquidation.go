@@ -0,0 +1,577 @@
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+// Defaults of a LiquidationMonitor
+const (
+	// DefaultRecoveryMargin is the fraction by which a position must be back inside the
+	// thresholds of a tier to leave it, when LiquidationConfig.RecoveryMargin is zero
+	DefaultRecoveryMargin = 0.1
+	// DefaultDeleverageCooldown is the time between two reduce-only orders on a coin,
+	// when LiquidationConfig.DeleverageCooldown is zero
+	DefaultDeleverageCooldown = 30 * time.Second
+)
+
+// LiquidationTier is a level of alert of a LiquidationMonitor. A position is in the
+// tier when either of its thresholds is crossed; zero thresholds are not checked.
+type LiquidationTier struct {
+	Name string
+	// Distance is the distance to liquidation at or under which a position is in the tier
+	Distance float64
+	// MarginRatio is the margin ratio at or above which a position is in the tier
+	MarginRatio float64
+	// Deleverage is the fraction of a position in the tier closed with a reduce-only
+	// order, every DeleverageCooldown while it stays in it, with AutoDeleverage
+	Deleverage float64
+}
+
+// crossed returns whether risk is in the tier, with thresholds loosened by widen >= 1
+func (t LiquidationTier) crossed(risk PositionRisk, widen float64) bool {
+	return (t.Distance > 0 && risk.Distance <= t.Distance*widen) ||
+		(t.MarginRatio > 0 && risk.MarginRatio >= t.MarginRatio/widen)
+}
+
+// DefaultLiquidationTiers are the tiers of a LiquidationMonitor without any
+func DefaultLiquidationTiers() []LiquidationTier {
+	return []LiquidationTier{
+		{Name: "warning", Distance: 0.2, MarginRatio: 0.5},
+		{Name: "danger", Distance: 0.1, MarginRatio: 0.7},
+		{Name: "critical", Distance: 0.05, MarginRatio: 0.85, Deleverage: 0.25},
+	}
+}
+
+// LiquidationConfig configures a LiquidationMonitor
+type LiquidationConfig struct {
+	// Address is the account watched, the GetAccountAddress of the exchange if empty
+	Address string
+	// Tiers are the alert levels in increasing severity, DefaultLiquidationTiers if nil
+	Tiers []LiquidationTier
+	// RecoveryMargin is the fraction by which a position must be back inside the
+	// thresholds of its tier to leave it, DefaultRecoveryMargin if zero
+	RecoveryMargin float64
+	// AutoDeleverage is whether the Deleverage fraction of the positions in a tier is
+	// closed. It needs an exchange.
+	AutoDeleverage bool
+	// DeleverageCooldown is the time between two reduce-only orders on a coin,
+	// DefaultDeleverageCooldown if zero
+	DeleverageCooldown time.Duration
+	// Slippage is the slippage of the reduce-only orders, the default of the exchange
+	// if zero
+	Slippage float64
+}
+
+// PositionRisk is the distance to liquidation of a position at the latest mark price
+type PositionRisk struct {
+	Coin     string
+	Szi      float64
+	MarkPx   float64
+	Isolated bool
+	// LiquidationPx is the liquidation price of the last account update, 0 if the
+	// position cannot be liquidated
+	LiquidationPx float64
+	// Distance is the move of the mark price to the liquidation price, relative to the
+	// mark price, +Inf without a liquidation price and negative past it
+	Distance float64
+	// MarginRatio is the maintenance margin over the margin backing the position: the
+	// cross account for cross positions, its isolated margin otherwise. The position
+	// is liquidated at 1.
+	MarginRatio float64
+	// AvailableToTrade are the buy and sell sizes of the latest activeAssetData
+	AvailableToTrade [2]float64
+	// Tier is the index of the tier of the position plus one, 0 in none
+	Tier int
+}
+
+// LiquidationAlert is a position changing tier, or deleveraged
+type LiquidationAlert struct {
+	Time     time.Time
+	Position PositionRisk
+	// Previous is the tier of the position before the alert
+	Previous int
+	// TierName is the name of the tier of the position, empty in none
+	TierName string
+	// Deleveraged is the size of the reduce-only order sent, and Err its error
+	Deleveraged float64
+	Err         error
+}
+
+func (a LiquidationAlert) String() string {
+	p := a.Position
+	name := a.TierName
+	if name == "" {
+		name = "safe"
+	}
+	s := fmt.Sprintf("%s %s: distance %.2f%%, margin ratio %.2f", p.Coin, name, p.Distance*100, p.MarginRatio)
+	if a.Deleveraged > 0 {
+		s += fmt.Sprintf(", reducing %v", a.Deleveraged)
+	}
+	if a.Err != nil {
+		s += ": " + a.Err.Error()
+	}
+	return s
+}
+
+// liquidationPosition is a position of the last account update
+type liquidationPosition struct {
+	position    types.Position
+	szi         float64
+	liqPx       float64
+	isolated    bool
+	margin      float64 // isolated margin at snapshotPx
+	snapshotPx  float64
+	maxLeverage int
+	szDecimals  int
+}
+
+// LiquidationMonitor watches the positions of an account for liquidation risk. It is
+// fed the webData2 updates of the account, which carry the positions, margins and mark
+// prices, and the activeAssetCtx updates of the coins for fresher mark prices, either
+// by Subscribe or by calling OnWebData2 and OnAssetCtx. Positions moving between tiers
+// raise alerts, and with AutoDeleverage the positions of a deleveraging tier are
+// reduced with reduce-only market orders:
+//
+//	monitor := risk.NewLiquidationMonitor(exchange, risk.LiquidationConfig{AutoDeleverage: true})
+//	monitor.OnAlert(func(a risk.LiquidationAlert) { log.Println(a) })
+//	manager := ws.NewManager()
+//	monitor.Subscribe(manager)
+//	manager.Start()
+//
+// Safe for concurrent use.
+type LiquidationMonitor struct {
+	exchange client.Trader
+	config   LiquidationConfig
+	onAlert  func(LiquidationAlert)
+	onError  func(error)
+
+	mu               sync.Mutex
+	clock            utils.Clock
+	positions        map[string]*liquidationPosition
+	marks            map[string]float64
+	available        map[string][2]float64
+	crossValue       float64
+	crossMaintenance float64
+	// crossSnapshotNtl is the notional of the cross positions at their snapshot price
+	crossSnapshotNtl float64
+	tiers            map[string]int
+	deleveraged      map[string]time.Time
+
+	subscribe  func(coin string)
+	subscribed map[string]bool
+}
+
+// NewLiquidationMonitor creates a monitor of the positions of the account of exchange,
+// which sends the reduce-only orders of AutoDeleverage. exchange may be nil without
+// AutoDeleverage if Address is set.
+func NewLiquidationMonitor(exchange client.Trader, config LiquidationConfig) *LiquidationMonitor {
+	if config.Address == "" {
+		if e, ok := exchange.(interface{ GetAccountAddress() string }); ok {
+			config.Address = e.GetAccountAddress()
+		}
+	}
+	if config.Tiers == nil {
+		config.Tiers = DefaultLiquidationTiers()
+	}
+	if config.RecoveryMargin <= 0 {
+		config.RecoveryMargin = DefaultRecoveryMargin
+	}
+	if config.DeleverageCooldown <= 0 {
+		config.DeleverageCooldown = DefaultDeleverageCooldown
+	}
+	return &LiquidationMonitor{
+		exchange:    exchange,
+		config:      config,
+		clock:       utils.SystemClock,
+		positions:   make(map[string]*liquidationPosition),
+		marks:       make(map[string]float64),
+		available:   make(map[string][2]float64),
+		tiers:       make(map[string]int),
+		deleveraged: make(map[string]time.Time),
+		subscribed:  make(map[string]bool),
+	}
+}
+
+// SetClock sets the clock of the alerts and deleverage cooldowns, e.g. a
+// utils.FakeClock in tests
+func (m *LiquidationMonitor) SetClock(clock utils.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
+
+// OnAlert sets a callback invoked for every alert. Must be called before the monitor is
+// fed.
+func (m *LiquidationMonitor) OnAlert(fn func(LiquidationAlert)) {
+	m.onAlert = fn
+}
+
+// OnError sets a callback invoked with the updates that could not be decoded or
+// parsed, and the subscriptions that failed. Must be called before the monitor is fed.
+func (m *LiquidationMonitor) OnError(fn func(error)) {
+	m.onError = fn
+}
+
+// Subscribe feeds the monitor from manager: the webData2 of the account, and the
+// activeAssetCtx and activeAssetData of every coin it holds a position in, subscribed
+// as positions are opened
+func (m *LiquidationMonitor) Subscribe(manager *ws.Manager) error {
+	user := m.config.Address
+	m.mu.Lock()
+	m.subscribe = func(coin string) {
+		if _, err := manager.Subscribe(ws.ActiveAssetCtxSubscription(coin), func(msg ws.Message) {
+			var ctx ws.WsActiveAssetCtx
+			if err := json.Unmarshal(msg.Data, &ctx); err != nil {
+				m.fail(fmt.Errorf("failed to decode asset context of %s: %w", coin, err))
+				return
+			}
+			m.OnAssetCtx(ctx)
+		}); err != nil {
+			m.fail(fmt.Errorf("failed to subscribe to asset context of %s: %w", coin, err))
+		}
+		if _, err := manager.Subscribe(ws.ActiveAssetDataSubscription(user, coin), func(msg ws.Message) {
+			var data ws.WsActiveAssetData
+			if err := json.Unmarshal(msg.Data, &data); err != nil {
+				m.fail(fmt.Errorf("failed to decode asset data of %s: %w", coin, err))
+				return
+			}
+			m.OnActiveAssetData(data)
+		}); err != nil {
+			m.fail(fmt.Errorf("failed to subscribe to asset data of %s: %w", coin, err))
+		}
+	}
+	m.mu.Unlock()
+
+	_, err := manager.Subscribe(ws.WebData2Subscription(user), func(msg ws.Message) {
+		var data ws.WebData2
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			m.fail(fmt.Errorf("failed to decode webData2: %w", err))
+			return
+		}
+		m.OnWebData2(data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to webData2: %w", err)
+	}
+	return nil
+}
+
+// OnWebData2 updates the positions, margins and mark prices of the account
+func (m *LiquidationMonitor) OnWebData2(data ws.WebData2) {
+	if err := m.update(data); err != nil {
+		m.fail(err)
+	}
+}
+
+func (m *LiquidationMonitor) update(data ws.WebData2) error {
+	marks := make(map[string]float64)
+	assets := make(map[string]types.AssetInfo)
+	for i, asset := range data.Meta.Universe {
+		assets[asset.Name] = asset
+		if i < len(data.AssetCtxs) {
+			if px, err := data.AssetCtxs[i].MarkPxFloat(); err == nil && px > 0 {
+				marks[asset.Name] = px
+			}
+		}
+	}
+
+	state := data.ClearinghouseState
+	crossValue, err := state.CrossMarginSummary.AccountValueFloat()
+	if err != nil {
+		return fmt.Errorf("failed to parse cross account value: %w", err)
+	}
+	positions := make(map[string]*liquidationPosition)
+	var crossNtl, crossMaintenance float64
+	for _, ap := range state.AssetPositions {
+		p := ap.Position
+		szi, err := p.SziFloat()
+		if err != nil {
+			return fmt.Errorf("failed to parse position of %s: %w", p.Coin, err)
+		}
+		if szi == 0 {
+			continue
+		}
+		liqPx, err := p.LiquidationPxFloat()
+		if err != nil {
+			return fmt.Errorf("failed to parse liquidation price of %s: %w", p.Coin, err)
+		}
+		value, err := p.PositionValueFloat()
+		if err != nil {
+			return fmt.Errorf("failed to parse position value of %s: %w", p.Coin, err)
+		}
+		margin, err := p.MarginUsedFloat()
+		if err != nil {
+			return fmt.Errorf("failed to parse margin of %s: %w", p.Coin, err)
+		}
+		asset := assets[p.Coin]
+		lp := &liquidationPosition{
+			position:    p,
+			szi:         szi,
+			liqPx:       liqPx,
+			isolated:    p.Leverage.Type == "isolated",
+			margin:      margin,
+			snapshotPx:  value / math.Abs(szi),
+			maxLeverage: asset.MaxLeverage,
+			szDecimals:  asset.SzDecimals,
+		}
+		if !lp.isolated {
+			crossNtl += value
+			crossMaintenance += lp.maintenance(lp.snapshotPx)
+		}
+		positions[p.Coin] = lp
+	}
+	// The maintenance margin of the exchange follows the margin tables, the estimate
+	// from the max leverage is only used without it
+	if state.CrossMaintenanceMarginUsed != "" {
+		if crossMaintenance, err = state.CrossMaintenanceMarginUsedFloat(); err != nil {
+			return fmt.Errorf("failed to parse cross maintenance margin: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	m.positions = positions
+	for coin, px := range marks {
+		m.marks[coin] = px
+	}
+	m.crossValue = crossValue
+	m.crossMaintenance = crossMaintenance
+	m.crossSnapshotNtl = crossNtl
+	var opened []string
+	for coin := range positions {
+		if m.subscribe != nil && !m.subscribed[coin] {
+			m.subscribed[coin] = true
+			opened = append(opened, coin)
+		}
+	}
+	for coin := range m.tiers {
+		if _, ok := positions[coin]; !ok {
+			delete(m.tiers, coin)
+			delete(m.deleveraged, coin)
+		}
+	}
+	subscribe := m.subscribe
+	m.mu.Unlock()
+
+	sort.Strings(opened)
+	for _, coin := range opened {
+		subscribe(coin)
+	}
+	m.evaluate()
+	return nil
+}
+
+// OnAssetCtx updates the mark price of a coin
+func (m *LiquidationMonitor) OnAssetCtx(ctx ws.WsActiveAssetCtx) {
+	if ctx.Ctx.MarkPx <= 0 {
+		return
+	}
+	m.mu.Lock()
+	m.marks[ctx.Coin] = ctx.Ctx.MarkPx
+	_, held := m.positions[ctx.Coin]
+	m.mu.Unlock()
+	if held {
+		m.evaluate()
+	}
+}
+
+// OnActiveAssetData records the sizes available to trade on a coin
+func (m *LiquidationMonitor) OnActiveAssetData(data ws.WsActiveAssetData) {
+	if !strings.EqualFold(data.User, m.config.Address) {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.available[data.Coin] = data.AvailableToTrade
+}
+
+// Positions returns the risk of the positions at the latest mark prices, by coin
+func (m *LiquidationMonitor) Positions() []PositionRisk {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	risks := m.risks()
+	for i := range risks {
+		risks[i].Tier = m.tiers[risks[i].Coin]
+	}
+	return risks
+}
+
+// CrossMarginRatio returns the maintenance margin of the cross positions over the
+// cross account value at the latest mark prices
+func (m *LiquidationMonitor) CrossMarginRatio() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.crossRatio()
+}
+
+// evaluate moves the positions between tiers and deleverages them
+func (m *LiquidationMonitor) evaluate() {
+	type deleverage struct {
+		alert int
+		sz    float64
+	}
+	m.mu.Lock()
+	now := m.clock.Now()
+	var (
+		alerts  []LiquidationAlert
+		reduces []deleverage
+	)
+	for _, risk := range m.risks() {
+		previous := m.tiers[risk.Coin]
+		tier := m.tierOf(risk, previous)
+		risk.Tier = tier
+		m.tiers[risk.Coin] = tier
+
+		var sz float64
+		if tier > 0 && m.config.AutoDeleverage && m.exchange != nil {
+			fraction := m.config.Tiers[tier-1].Deleverage
+			if last, ok := m.deleveraged[risk.Coin]; fraction > 0 && (!ok || now.Sub(last) >= m.config.DeleverageCooldown) {
+				p := m.positions[risk.Coin]
+				sz = math.Min(utils.RoundToLot(math.Abs(risk.Szi)*fraction, p.szDecimals), math.Abs(risk.Szi))
+				if sz > 0 {
+					m.deleveraged[risk.Coin] = now
+				}
+			}
+		}
+		if tier == previous && sz == 0 {
+			continue
+		}
+		alert := LiquidationAlert{Time: now, Position: risk, Previous: previous}
+		if tier > 0 {
+			alert.TierName = m.config.Tiers[tier-1].Name
+		}
+		if sz > 0 {
+			alert.Deleveraged = sz
+			reduces = append(reduces, deleverage{alert: len(alerts), sz: sz})
+		}
+		alerts = append(alerts, alert)
+	}
+	m.mu.Unlock()
+
+	for _, reduce := range reduces {
+		alert := &alerts[reduce.alert]
+		px := alert.Position.MarkPx
+		if _, err := m.exchange.MarketClose(alert.Position.Coin, &reduce.sz, &px, m.config.Slippage, nil, nil); err != nil {
+			alert.Err = fmt.Errorf("failed to deleverage %s: %w", alert.Position.Coin, err)
+		}
+	}
+	if m.onAlert != nil {
+		for _, alert := range alerts {
+			m.onAlert(alert)
+		}
+	}
+}
+
+// tierOf returns the tier of risk, leaving the current tier only once the position is
+// back inside it by the recovery margin. Must be called with the lock held.
+func (m *LiquidationMonitor) tierOf(risk PositionRisk, current int) int {
+	tier := 0
+	for i, t := range m.config.Tiers {
+		if t.crossed(risk, 1) {
+			tier = i + 1
+		}
+	}
+	for l := min(current, len(m.config.Tiers)); l > tier; l-- {
+		if m.config.Tiers[l-1].crossed(risk, 1+m.config.RecoveryMargin) {
+			return l
+		}
+	}
+	return tier
+}
+
+// risks returns the risk of the positions by coin, without tiers. Must be called with
+// the lock held.
+func (m *LiquidationMonitor) risks() []PositionRisk {
+	crossRatio := m.crossRatio()
+	risks := make([]PositionRisk, 0, len(m.positions))
+	for coin, p := range m.positions {
+		mark := m.mark(coin)
+		risk := PositionRisk{
+			Coin:             coin,
+			Szi:              p.szi,
+			MarkPx:           mark,
+			Isolated:         p.isolated,
+			LiquidationPx:    p.liqPx,
+			Distance:         math.Inf(1),
+			MarginRatio:      crossRatio,
+			AvailableToTrade: m.available[coin],
+		}
+		if p.liqPx > 0 && mark > 0 {
+			risk.Distance = (mark - p.liqPx) / mark
+			if p.szi < 0 {
+				risk.Distance = -risk.Distance
+			}
+		}
+		if p.isolated {
+			risk.MarginRatio = ratio(p.maintenance(mark), p.margin+p.szi*(mark-p.snapshotPx))
+		}
+		risks = append(risks, risk)
+	}
+	sort.Slice(risks, func(i, j int) bool { return risks[i].Coin < risks[j].Coin })
+	return risks
+}
+
+// crossRatio returns the cross margin ratio at the latest mark prices, scaling the
+// maintenance margin with the notional and adding the unrealized PnL since the last
+// account update. Must be called with the lock held.
+func (m *LiquidationMonitor) crossRatio() float64 {
+	value, ntl := m.crossValue, 0.0
+	for coin, p := range m.positions {
+		if p.isolated {
+			continue
+		}
+		mark := m.mark(coin)
+		value += p.szi * (mark - p.snapshotPx)
+		ntl += math.Abs(p.szi) * mark
+	}
+	maintenance := m.crossMaintenance
+	if m.crossSnapshotNtl > 0 {
+		maintenance *= ntl / m.crossSnapshotNtl
+	}
+	return ratio(maintenance, value)
+}
+
+// mark returns the latest mark price of coin, the price of the last account update
+// if none. Must be called with the lock held.
+func (m *LiquidationMonitor) mark(coin string) float64 {
+	if px, ok := m.marks[coin]; ok {
+		return px
+	}
+	return m.positions[coin].snapshotPx
+}
+
+// maintenance estimates the maintenance margin of the position at px: half the
+// initial margin at the max leverage
+func (p *liquidationPosition) maintenance(px float64) float64 {
+	if p.maxLeverage <= 0 {
+		return 0
+	}
+	return math.Abs(p.szi) * px / float64(2*p.maxLeverage)
+}
+
+// ratio is a margin ratio, +Inf without margin left
+func ratio(maintenance, margin float64) float64 {
+	if maintenance == 0 {
+		return 0
+	}
+	if margin <= 0 {
+		return math.Inf(1)
+	}
+	return maintenance / margin
+}
+
+func (m *LiquidationMonitor) fail(err error) {
+	if m.onError != nil {
+		m.onError(err)
+	}
+}
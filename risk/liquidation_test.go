@@ -0,0 +1,344 @@
+package risk
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+	"github.com/dwdwow/hl-go/ws"
+)
+
+const liquidationUser = "0x00000000000000000000000000000000000000aa"
+
+// closer records the reduce-only orders of a LiquidationMonitor
+type closer struct {
+	client.Trader
+	mu     sync.Mutex
+	closes []string
+	err    error
+}
+
+func (c *closer) MarketClose(name string, sz *float64, px *float64, slippage float64, cloid *types.Cloid, builder *types.BuilderInfo) (*types.OrderResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closes = append(c.closes, name+" "+utils.FormatFloat(*sz)+" @"+utils.FormatFloat(*px))
+	return &types.OrderResponse{}, c.err
+}
+
+func str(s string) *string { return &s }
+
+// webData2 returns an account with a cross ETH long of 10 at 2000 liquidated at 1600,
+// and an isolated BTC short of 0.1 at 50000 with 500 of margin liquidated at btcLiq
+func webData2(btcLiq string) ws.WebData2 {
+	return ws.WebData2{
+		User: liquidationUser,
+		ClearinghouseState: types.UserState{
+			AssetPositions: []types.AssetPosition{
+				{Type: "oneWay", Position: types.Position{Coin: "ETH", Szi: "10", PositionValue: "20000", MarginUsed: "2000",
+					LiquidationPx: str("1600"), Leverage: types.Leverage{Type: "cross", Value: 10}}},
+				{Type: "oneWay", Position: types.Position{Coin: "BTC", Szi: "-0.1", PositionValue: "5000", MarginUsed: "500",
+					LiquidationPx: str(btcLiq), Leverage: types.Leverage{Type: "isolated", Value: 10}}},
+			},
+			CrossMarginSummary:         types.MarginSummary{AccountValue: "10000"},
+			CrossMaintenanceMarginUsed: "200",
+		},
+		Meta: types.Meta{Universe: []types.AssetInfo{
+			{Name: "BTC", SzDecimals: 5, MaxLeverage: 40},
+			{Name: "ETH", SzDecimals: 4, MaxLeverage: 50},
+		}},
+		AssetCtxs: []types.PerpAssetCtx{{MarkPx: "50000"}, {MarkPx: "2000"}},
+	}
+}
+
+func mark(coin string, px float64) ws.WsActiveAssetCtx {
+	ctx := ws.WsActiveAssetCtx{Coin: coin}
+	ctx.Ctx.MarkPx = px
+	return ctx
+}
+
+func newLiquidationMonitor(t *testing.T, exchange client.Trader, config LiquidationConfig) (*LiquidationMonitor, *[]LiquidationAlert, *utils.FakeClock) {
+	t.Helper()
+	config.Address = liquidationUser
+	monitor := NewLiquidationMonitor(exchange, config)
+	clock := hltest.NewFakeClock()
+	monitor.SetClock(clock)
+	var alerts []LiquidationAlert
+	monitor.OnAlert(func(a LiquidationAlert) { alerts = append(alerts, a) })
+	monitor.OnError(func(err error) { t.Errorf("monitor error: %v", err) })
+	return monitor, &alerts, clock
+}
+
+func TestLiquidationTiers(t *testing.T) {
+	monitor, alerts, _ := newLiquidationMonitor(t, nil, LiquidationConfig{})
+	monitor.OnWebData2(webData2("54000"))
+
+	if len(*alerts) != 2 {
+		t.Fatalf("len(*alerts) = %d, want 2", len(*alerts))
+	}
+	btc, eth := (*alerts)[0], (*alerts)[1]
+	if btc.Position.Coin != "BTC" {
+		t.Errorf("btc.Position.Coin = %q, want %q", btc.Position.Coin, "BTC")
+	}
+	if btc.TierName != "danger" {
+		t.Errorf("btc.TierName = %q, want %q", btc.TierName, "danger")
+	}
+	if math.Abs(btc.Position.Distance-0.08) > 1e-9 {
+		t.Errorf("btc.Position.Distance = %v, want %v", btc.Position.Distance, 0.08)
+	}
+	// The maintenance margin is half the initial margin at 40x
+	if math.Abs(btc.Position.MarginRatio-(62.5/500)) > 1e-9 {
+		t.Errorf("btc.Position.MarginRatio = %v, want %v", btc.Position.MarginRatio, 62.5/500)
+	}
+	if !btc.Position.Isolated {
+		t.Error("btc.Position.Isolated = false")
+	}
+	if eth.Position.Coin != "ETH" {
+		t.Errorf("eth.Position.Coin = %q, want %q", eth.Position.Coin, "ETH")
+	}
+	if eth.Position.Tier != 1 {
+		t.Errorf("eth.Position.Tier = %v, want 1", eth.Position.Tier)
+	}
+	if math.Abs(eth.Position.Distance-0.2) > 1e-9 {
+		t.Errorf("eth.Position.Distance = %v, want %v", eth.Position.Distance, 0.2)
+	}
+	if math.Abs(eth.Position.MarginRatio-0.02) > 1e-9 {
+		t.Errorf("eth.Position.MarginRatio = %v, want %v", eth.Position.MarginRatio, 0.02)
+	}
+
+	// The mark falling brings ETH closer to liquidation and loses on the cross account
+	*alerts = nil
+	monitor.OnAssetCtx(mark("ETH", 1750))
+	if len(*alerts) != 1 {
+		t.Fatalf("len(*alerts) = %d, want 1", len(*alerts))
+	}
+	if (*alerts)[0].TierName != "danger" {
+		t.Errorf("(*alerts)[0].TierName = %q, want %q", (*alerts)[0].TierName, "danger")
+	}
+	if (*alerts)[0].Previous != 1 {
+		t.Errorf("(*alerts)[0].Previous = %v, want 1", (*alerts)[0].Previous)
+	}
+	if math.Abs((*alerts)[0].Position.Distance-(150.0/1750)) > 1e-9 {
+		t.Errorf("(*alerts)[0].Position.Distance = %v, want %v", (*alerts)[0].Position.Distance, 150.0/1750)
+	}
+	if got := monitor.CrossMarginRatio(); math.Abs(got-(175.0/7500)) > 1e-9 {
+		t.Errorf("CrossMarginRatio() = %v, want %v", got, 175.0/7500)
+	}
+
+	// Back to the warning tier, and out of it only past the recovery margin
+	monitor.OnAssetCtx(mark("ETH", 1900))
+	if len(*alerts) != 2 {
+		t.Fatalf("len(*alerts) = %d, want 2", len(*alerts))
+	}
+	if (*alerts)[1].TierName != "warning" {
+		t.Errorf("(*alerts)[1].TierName = %q, want %q", (*alerts)[1].TierName, "warning")
+	}
+	monitor.OnAssetCtx(mark("ETH", 2030))
+	// A distance of 21% is within the recovery margin of the 20% tier
+	if len(*alerts) != 2 {
+		t.Errorf("len(*alerts) = %d, want 2", len(*alerts))
+	}
+	if got := monitor.Positions()[1].Tier; got != 1 {
+		t.Errorf("monitor.Positions()[1].Tier = %v, want 1", got)
+	}
+	monitor.OnAssetCtx(mark("ETH", 2100))
+	if len(*alerts) != 3 {
+		t.Fatalf("len(*alerts) = %d, want 3", len(*alerts))
+	}
+	if (*alerts)[2].TierName != "" {
+		t.Errorf("(*alerts)[2].TierName = %q, want empty", (*alerts)[2].TierName)
+	}
+	if got := (*alerts)[2].String(); got != "ETH safe: distance 23.81%, margin ratio 0.02" {
+		t.Errorf("String() = %q, want %q", got, "ETH safe: distance 23.81%, margin ratio 0.02")
+	}
+
+	// The isolated margin follows the mark: the short loses 100 at 51000
+	monitor.OnAssetCtx(mark("BTC", 51000))
+	positions := monitor.Positions()
+	if len(positions) != 2 {
+		t.Fatalf("len(positions) = %d, want 2", len(positions))
+	}
+	if math.Abs(positions[0].MarginRatio-(63.75/400)) > 1e-9 {
+		t.Errorf("positions[0].MarginRatio = %v, want %v", positions[0].MarginRatio, 63.75/400)
+	}
+	if math.Abs(positions[0].Distance-(3000.0/51000)) > 1e-9 {
+		t.Errorf("positions[0].Distance = %v, want %v", positions[0].Distance, 3000.0/51000)
+	}
+	if positions[0].Tier != 2 {
+		t.Errorf("positions[0].Tier = %v, want 2", positions[0].Tier)
+	}
+
+	// Closed positions are dropped
+	data := webData2("54000")
+	data.ClearinghouseState.AssetPositions = data.ClearinghouseState.AssetPositions[:1]
+	monitor.OnWebData2(data)
+	if got := monitor.Positions(); len(got) != 1 {
+		t.Errorf("len(Positions()) = %d, want 1", len(got))
+	}
+}
+
+func TestLiquidationMarginRatio(t *testing.T) {
+	monitor, alerts, _ := newLiquidationMonitor(t, nil, LiquidationConfig{
+		Tiers: []LiquidationTier{{Name: "margin", MarginRatio: 0.5}},
+	})
+	data := webData2("")
+	data.ClearinghouseState.AssetPositions[1].Position.LiquidationPx = nil
+	data.ClearinghouseState.CrossMaintenanceMarginUsed = "5000"
+	monitor.OnWebData2(data)
+
+	// The isolated BTC has a margin ratio of 0.125
+	if len(*alerts) != 1 {
+		t.Fatalf("len(*alerts) = %d, want 1", len(*alerts))
+	}
+	if (*alerts)[0].Position.Coin != "ETH" {
+		t.Errorf("(*alerts)[0].Position.Coin = %q, want %q", (*alerts)[0].Position.Coin, "ETH")
+	}
+	if math.Abs((*alerts)[0].Position.MarginRatio-0.5) > 1e-9 {
+		t.Errorf("(*alerts)[0].Position.MarginRatio = %v, want %v", (*alerts)[0].Position.MarginRatio, 0.5)
+	}
+	*alerts = nil
+
+	positions := monitor.Positions()
+	// BTC has no liquidation price
+	if !math.IsInf(positions[0].Distance, 1) {
+		t.Error("IsInf(positions[0].Distance, 1) = false")
+	}
+
+	// The whole cross account is lost at 1000
+	monitor.OnAssetCtx(mark("ETH", 1000))
+	if !math.IsInf(monitor.CrossMarginRatio(), 1) {
+		t.Error("IsInf() = false")
+	}
+	// ETH stays in the margin tier
+	if len(*alerts) != 0 {
+		t.Errorf("*alerts = %+v, want empty", *alerts)
+	}
+}
+
+func TestLiquidationDeleverage(t *testing.T) {
+	exchange := &closer{}
+	monitor, alerts, clock := newLiquidationMonitor(t, exchange, LiquidationConfig{AutoDeleverage: true, Slippage: 0.02})
+	monitor.OnWebData2(webData2("52000"))
+
+	// BTC is 4% from liquidation, in the critical tier
+	if len(*alerts) != 2 {
+		t.Fatalf("len(*alerts) = %d, want 2", len(*alerts))
+	}
+	if (*alerts)[0].TierName != "critical" {
+		t.Errorf("(*alerts)[0].TierName = %q, want %q", (*alerts)[0].TierName, "critical")
+	}
+	if (*alerts)[0].Deleveraged != 0.025 {
+		t.Errorf("(*alerts)[0].Deleveraged = %v, want 0.025", (*alerts)[0].Deleveraged)
+	}
+	// The warning tier does not deleverage
+	if (*alerts)[1].Deleveraged != 0 {
+		t.Errorf("(*alerts)[1].Deleveraged = %v, want 0", (*alerts)[1].Deleveraged)
+	}
+	if want := []string{"BTC 0.025 @50000"}; !slices.Equal(exchange.closes, want) {
+		t.Errorf("exchange.closes = %+v, want %+v", exchange.closes, want)
+	}
+
+	// Not again before the cooldown
+	*alerts = nil
+	monitor.OnAssetCtx(mark("BTC", 50500))
+	if len(*alerts) != 0 {
+		t.Errorf("*alerts = %+v, want empty", *alerts)
+	}
+
+	clock.Advance(DefaultDeleverageCooldown)
+	exchange.err = errors.New("insufficient liquidity")
+	monitor.OnAssetCtx(mark("BTC", 50600))
+	if len(*alerts) != 1 {
+		t.Fatalf("len(*alerts) = %d, want 1", len(*alerts))
+	}
+	alert := (*alerts)[0]
+	if alert.Previous != 3 {
+		t.Errorf("alert.Previous = %v, want 3", alert.Previous)
+	}
+	if alert.Position.Tier != 3 {
+		t.Errorf("alert.Position.Tier = %v, want 3", alert.Position.Tier)
+	}
+	if alert.Err == nil || !strings.Contains(alert.Err.Error(), "failed to deleverage BTC: insufficient liquidity") {
+		t.Errorf("alert.Err = %v, want %q", alert.Err, "failed to deleverage BTC: insufficient liquidity")
+	}
+	if want := []string{"BTC 0.025 @50000", "BTC 0.025 @50600"}; !slices.Equal(exchange.closes, want) {
+		t.Errorf("exchange.closes = %+v, want %+v", exchange.closes, want)
+	}
+}
+
+func TestLiquidationSubscribe(t *testing.T) {
+	subscriptions := make(chan map[string]any, 16)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg["method"] != "subscribe" {
+				continue
+			}
+			sub := msg["subscription"].(map[string]any)
+			subscriptions <- sub
+			switch sub["type"] {
+			case "webData2":
+				conn.WriteJSON(map[string]any{"channel": "webData2", "data": webData2("54000")})
+			case "activeAssetCtx":
+				if sub["coin"] == "ETH" {
+					conn.WriteJSON(map[string]any{"channel": "activeAssetCtx",
+						"data": map[string]any{"coin": "ETH", "ctx": map[string]any{"markPx": "1750"}}})
+				}
+			}
+		}
+	}))
+	defer srv.Close()
+
+	monitor, _, _ := newLiquidationMonitor(t, nil, LiquidationConfig{})
+	manager := ws.NewManagerWithURL("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err := monitor.Subscribe(manager); err != nil {
+		t.Fatalf("Subscribe(manager) error = %v", err)
+	}
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer manager.Stop()
+
+	var seen []string
+	for len(seen) < 5 {
+		select {
+		case sub := <-subscriptions:
+			coin, _ := sub["coin"].(string)
+			seen = append(seen, strings.TrimSpace(sub["type"].(string)+" "+coin))
+		case <-time.After(2 * time.Second):
+			t.Fatalf("subscriptions = %v", seen)
+		}
+	}
+	if want := []string{"webData2", "activeAssetCtx BTC", "activeAssetData BTC", "activeAssetCtx ETH", "activeAssetData ETH"}; !slices.Equal(seen, want) {
+		t.Errorf("seen = %+v, want %+v", seen, want)
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		positions := monitor.Positions()
+		if len(positions) == 2 && positions[1].MarkPx == 1750 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Positions() = %+v, want the ETH mark at 1750", positions)
+		}
+	}
+}
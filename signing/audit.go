@@ -0,0 +1,292 @@
+package signing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// AuditRecord describes a single signature produced by this package.
+// Records form a hash chain: each RecordHash covers the record's fields and the
+// previous record's hash, so removing or editing an entry breaks every later hash.
+type AuditRecord struct {
+	Seq         uint64          `json:"seq"`
+	Time        time.Time       `json:"time"`
+	Kind        string          `json:"kind"` // "l1", "userSigned" or "multiSig"
+	ActionType  string          `json:"actionType"`
+	PrimaryType string          `json:"primaryType"`
+	Nonce       int64           `json:"nonce"`
+	Hash        string          `json:"hash"`   // EIP-712 digest that was signed
+	Signer      string          `json:"signer"` // address recovered from the signature
+	Payload     json.RawMessage `json:"payload"`
+	PrevHash    string          `json:"prevHash"`
+	RecordHash  string          `json:"recordHash"`
+}
+
+// AuditSink stores audit records
+type AuditSink interface {
+	WriteAuditRecord(record AuditRecord) error
+}
+
+// AuditSinkFunc adapts a function to the AuditSink interface
+type AuditSinkFunc func(record AuditRecord) error
+
+// WriteAuditRecord calls f(record)
+func (f AuditSinkFunc) WriteAuditRecord(record AuditRecord) error {
+	return f(record)
+}
+
+// AuditRedactor returns the version of an action that is written to the audit log
+type AuditRedactor func(action any) any
+
+// Auditor records every signed action to a sink
+type Auditor struct {
+	sink     AuditSink
+	redactor AuditRedactor
+	now      func() time.Time
+
+	mu       sync.Mutex
+	seq      uint64
+	prevHash string
+}
+
+// NewAuditor creates an auditor writing to sink, using DefaultAuditRedactor
+func NewAuditor(sink AuditSink) *Auditor {
+	return &Auditor{
+		sink:     sink,
+		redactor: DefaultAuditRedactor,
+		now:      time.Now,
+	}
+}
+
+// SetRedactor sets the function applied to actions before they are recorded
+func (a *Auditor) SetRedactor(redactor AuditRedactor) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.redactor = redactor
+}
+
+// Resume continues an existing chain, e.g. after a restart, from its last record
+func (a *Auditor) Resume(last AuditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq = last.Seq
+	a.prevHash = last.RecordHash
+}
+
+var auditor atomic.Pointer[Auditor]
+
+// SetAuditor installs the auditor used for every signature. Pass nil to disable auditing.
+// While an auditor is set, signing fails if the record cannot be written, so no
+// signature leaves the process without an audit entry.
+func SetAuditor(a *Auditor) {
+	auditor.Store(a)
+}
+
+// auditInfo describes the action behind a signature
+type auditInfo struct {
+	kind       string
+	actionType string
+	nonce      int64
+	action     any
+}
+
+func l1AuditInfo(action any, nonce int64) auditInfo {
+	info := auditInfo{kind: "l1", nonce: nonce, action: action}
+	if m, ok := action.(map[string]any); ok {
+		info.actionType, _ = m["type"].(string)
+	}
+	return info
+}
+
+func userSignedAuditInfo(action map[string]any) auditInfo {
+	info := auditInfo{kind: "userSigned", action: action}
+	info.actionType, _ = action["type"].(string)
+	for _, field := range []string{"nonce", "time"} {
+		if nonce, ok := auditNonce(action[field]); ok {
+			info.nonce = nonce
+			break
+		}
+	}
+	return info
+}
+
+func auditNonce(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// record appends a record for a signature over hash
+func (a *Auditor) record(info auditInfo, primaryType string, hash []byte, sig []byte) error {
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("failed to recover signer: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	payload := info.action
+	if a.redactor != nil {
+		payload = a.redactor(payload)
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit payload: %w", err)
+	}
+
+	record := AuditRecord{
+		Seq:         a.seq + 1,
+		Time:        a.now().UTC(),
+		Kind:        info.kind,
+		ActionType:  info.actionType,
+		PrimaryType: primaryType,
+		Nonce:       info.nonce,
+		Hash:        hexutil.Encode(hash),
+		Signer:      crypto.PubkeyToAddress(*pubKey).Hex(),
+		Payload:     payloadJSON,
+		PrevHash:    a.prevHash,
+	}
+	record.RecordHash, err = auditRecordHash(record)
+	if err != nil {
+		return err
+	}
+
+	if err := a.sink.WriteAuditRecord(record); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	a.seq = record.Seq
+	a.prevHash = record.RecordHash
+	return nil
+}
+
+// auditRecordHash hashes the JSON encoding of a record with RecordHash cleared
+func auditRecordHash(record AuditRecord) (string, error) {
+	record.RecordHash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	return hexutil.Encode(crypto.Keccak256(data)), nil
+}
+
+// VerifyAuditChain checks that records form an unbroken hash chain
+func VerifyAuditChain(records []AuditRecord) error {
+	for i, record := range records {
+		want, err := auditRecordHash(record)
+		if err != nil {
+			return err
+		}
+		if record.RecordHash != want {
+			return fmt.Errorf("audit record %d has been modified", record.Seq)
+		}
+		if i > 0 {
+			prev := records[i-1]
+			if record.PrevHash != prev.RecordHash || record.Seq != prev.Seq+1 {
+				return fmt.Errorf("audit chain broken between records %d and %d", prev.Seq, record.Seq)
+			}
+		}
+	}
+	return nil
+}
+
+// JSONLinesAuditSink writes each record as one JSON line
+type JSONLinesAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesAuditSink creates a sink writing JSON lines to w
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+// WriteAuditRecord writes record as a JSON line
+func (s *JSONLinesAuditSink) WriteAuditRecord(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(data)
+	return err
+}
+
+var addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// DefaultAuditRedactor shortens addresses to their first and last four hex digits
+// (0x1234…abcd) and keeps everything else, so records stay useful without
+// exposing full counterparties in log pipelines.
+func DefaultAuditRedactor(action any) any {
+	return redactValue(action, nil)
+}
+
+// RedactFields returns a redactor that replaces the values of the given keys with
+// "[REDACTED]" at any depth, in addition to the default address shortening
+func RedactFields(fields ...string) AuditRedactor {
+	set := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		set[f] = true
+	}
+	return func(action any) any {
+		return redactValue(action, set)
+	}
+}
+
+func redactValue(value any, fields map[string]bool) any {
+	// Normalize through JSON so structs (order wires etc.) are redacted like maps
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+	return redactGeneric(generic, fields)
+}
+
+func redactGeneric(value any, fields map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if fields[k] {
+				v[k] = "[REDACTED]"
+			} else {
+				v[k] = redactGeneric(item, fields)
+			}
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactGeneric(item, fields)
+		}
+		return v
+	case string:
+		if addressPattern.MatchString(v) {
+			return v[:6] + "…" + v[len(v)-4:]
+		}
+		return v
+	default:
+		return v
+	}
+}
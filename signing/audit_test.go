@@ -0,0 +1,83 @@
+package signing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+func TestAuditorRecordsSignedActions(t *testing.T) {
+	privateKey := getTestPrivateKey(t)
+	var buf bytes.Buffer
+	SetAuditor(NewAuditor(NewJSONLinesAuditSink(&buf)))
+	defer SetAuditor(nil)
+
+	if _, err := SignL1Action(privateKey, utils.NewOrderedMap("type", "noop"), nil, 1677777606040, nil, true); err != nil {
+		t.Fatalf("SignL1Action() error = %v", err)
+	}
+	withdraw := utils.NewOrderedMap(
+		"destination", "0x5e9ee1089755c3435139848e47e6635505d5a13a",
+		"amount", "1",
+		"time", int64(1687816341423),
+		"type", "withdraw3",
+	)
+	if _, err := SignUserSignedAction(privateKey, withdraw, Withdraw3SignTypes, "HyperliquidTransaction:Withdraw", true); err != nil {
+		t.Fatalf("SignUserSignedAction() error = %v", err)
+	}
+
+	var records []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d audit records, want 2", len(records))
+	}
+
+	wantSigner := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	if records[0].Kind != "l1" || records[0].ActionType != "noop" || records[0].Nonce != 1677777606040 {
+		t.Errorf("unexpected L1 record: %+v", records[0])
+	}
+	if records[1].Kind != "userSigned" || records[1].ActionType != "withdraw3" || records[1].Nonce != 1687816341423 {
+		t.Errorf("unexpected user-signed record: %+v", records[1])
+	}
+	for _, record := range records {
+		if record.Signer != wantSigner {
+			t.Errorf("record %d signer = %s, want %s", record.Seq, record.Signer, wantSigner)
+		}
+	}
+	if !strings.Contains(string(records[1].Payload), `"0x5e9e…a13a"`) {
+		t.Errorf("destination not redacted: %s", records[1].Payload)
+	}
+
+	if err := VerifyAuditChain(records); err != nil {
+		t.Fatalf("VerifyAuditChain() error = %v", err)
+	}
+	records[0].Nonce++
+	if err := VerifyAuditChain(records); err == nil {
+		t.Error("VerifyAuditChain() on modified record expected error")
+	}
+	if err := VerifyAuditChain(records[1:]); err != nil {
+		t.Errorf("VerifyAuditChain() on suffix error = %v", err)
+	}
+}
+
+func TestAuditorFailureBlocksSignature(t *testing.T) {
+	SetAuditor(NewAuditor(AuditSinkFunc(func(AuditRecord) error {
+		return errors.New("disk full")
+	})))
+	defer SetAuditor(nil)
+
+	if _, err := SignL1Action(getTestPrivateKey(t), utils.NewOrderedMap("type", "noop"), nil, 1, nil, true); err == nil {
+		t.Error("SignL1Action() with failing audit sink expected error")
+	}
+}
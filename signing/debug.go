@@ -61,7 +61,7 @@ func DebugSignL1Action(
 	log.Printf("EIP-712 TypedData:\n%s", string(typedDataJSON))
 
 	// Sign
-	signature, err := signTypedData(privateKey, typedData)
+	signature, err := signTypedData(privateKey, typedData, l1AuditInfo(action, nonce))
 	if err != nil {
 		return nil, err
 	}
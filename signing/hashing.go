@@ -0,0 +1,138 @@
+package signing
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// maxPooledBufferSize keeps unusually large actions (e.g. huge bulk orders) from
+// pinning memory in the buffer pool
+const maxPooledBufferSize = 64 << 10
+
+var (
+	bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+	keccakPool = sync.Pool{New: func() any { return crypto.NewKeccakState() }}
+)
+
+// Cached EIP-712 hashes for the domains and the Agent type, which never change
+var (
+	l1DomainSeparator         = mustHashDomain(L1Payload(nil))
+	userSignedDomainSeparator = mustHashDomain(UserSignedPayload(map[string]any{}, USDSendSignTypes, "HyperliquidTransaction:UsdSend"))
+	agentTypeHash             = hashAgentType()
+)
+
+func hashAgentType() []byte {
+	typedData := L1Payload(nil)
+	return typedData.TypeHash("Agent")
+}
+
+func mustHashDomain(typedData apitypes.TypedData) cachedDomain {
+	separator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		panic(fmt.Sprintf("failed to hash domain %s: %v", typedData.Domain.Name, err))
+	}
+	return cachedDomain{
+		domain:    typedData.Domain,
+		types:     typedData.Types["EIP712Domain"],
+		separator: separator,
+	}
+}
+
+// cachedDomain is a precomputed domain separator with the domain it was computed from
+type cachedDomain struct {
+	domain    apitypes.TypedDataDomain
+	types     []apitypes.Type
+	separator []byte
+}
+
+func (c cachedDomain) matches(typedData apitypes.TypedData) bool {
+	d := typedData.Domain
+	if d.Name != c.domain.Name || d.Version != c.domain.Version ||
+		d.VerifyingContract != c.domain.VerifyingContract || d.Salt != c.domain.Salt ||
+		d.ChainId == nil || (*big.Int)(d.ChainId).Cmp((*big.Int)(c.domain.ChainId)) != 0 {
+		return false
+	}
+	return typesEqual(typedData.Types["EIP712Domain"], c.types)
+}
+
+func typesEqual(a, b []apitypes.Type) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// keccak256 hashes the concatenation of data with a pooled keccak state
+func keccak256(data ...[]byte) []byte {
+	state := keccakPool.Get().(crypto.KeccakState)
+	state.Reset()
+	for _, b := range data {
+		state.Write(b)
+	}
+	hash := make([]byte, 32)
+	state.Read(hash)
+	keccakPool.Put(state)
+	return hash
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buf)
+	}
+}
+
+// domainSeparator returns the EIP-712 domain separator, using the cached value for the
+// built-in domains
+func domainSeparator(typedData apitypes.TypedData) ([]byte, error) {
+	for _, cached := range []*cachedDomain{&l1DomainSeparator, &userSignedDomainSeparator} {
+		if cached.matches(typedData) {
+			return cached.separator, nil
+		}
+	}
+	return typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+}
+
+// messageHash returns the EIP-712 struct hash of the message. Phantom agent messages
+// are hashed directly with the cached Agent type hash.
+func messageHash(typedData apitypes.TypedData) ([]byte, error) {
+	if typedData.PrimaryType == "Agent" && typesEqual(typedData.Types["Agent"], agentTypes) {
+		source, ok1 := typedData.Message["source"].(string)
+		connectionID, ok2 := typedData.Message["connectionId"].(common.Hash)
+		if ok1 && ok2 && len(typedData.Message) == 2 {
+			return keccak256(agentTypeHash, keccak256([]byte(source)), connectionID[:]), nil
+		}
+	}
+	return typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+}
+
+// typedDataHash computes keccak256("\x19\x01" || domainSeparator || hashStruct(message))
+func typedDataHash(typedData apitypes.TypedData) ([]byte, error) {
+	separator, err := domainSeparator(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
+	}
+
+	hash, err := messageHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash message: %w", err)
+	}
+
+	return keccak256([]byte{0x19, 0x01}, separator, hash), nil
+}
@@ -0,0 +1,169 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/types"
+)
+
+// UserSignedActionType describes the EIP-712 schema of a user-signed action
+type UserSignedActionType struct {
+	// ActionType is the value of the action's "type" field, e.g. "usdSend"
+	ActionType string
+	// PrimaryType is the EIP-712 primary type, e.g. "HyperliquidTransaction:UsdSend"
+	PrimaryType string
+	// Types are the fields of the primary type, including hyperliquidChain
+	Types []apitypes.Type
+}
+
+// NonceField returns the name of the field carrying the action nonce ("nonce" or "time")
+func (t UserSignedActionType) NonceField() string {
+	for _, typ := range t.Types {
+		if typ.Name == "nonce" || typ.Name == "time" {
+			return typ.Name
+		}
+	}
+	return ""
+}
+
+var (
+	userSignedRegistryMu sync.RWMutex
+	userSignedRegistry   = map[string]UserSignedActionType{}
+	// userSignedByAction maps action types to the primary types registered for them
+	userSignedByAction = map[string]string{}
+
+	// eip712FieldType matches the EIP-712 atomic and dynamic types, optionally as arrays
+	eip712FieldType = regexp.MustCompile(`^(address|bool|string|bytes([1-9]|[12][0-9]|3[0-2])?|u?int(8|16|24|32|40|48|56|64|72|80|88|96|104|112|120|128|136|144|152|160|168|176|184|192|200|208|216|224|232|240|248|256)?)(\[\d*\])*$`)
+)
+
+func init() {
+	builtins := []UserSignedActionType{
+		{"usdSend", "HyperliquidTransaction:UsdSend", USDSendSignTypes},
+		{"spotSend", "HyperliquidTransaction:SpotSend", SpotSendSignTypes},
+		{"withdraw3", "HyperliquidTransaction:Withdraw", Withdraw3SignTypes},
+		{"usdClassTransfer", "HyperliquidTransaction:UsdClassTransfer", USDClassTransferSignTypes},
+		{"sendAsset", "HyperliquidTransaction:SendAsset", SendAssetSignTypes},
+		{"tokenDelegate", "HyperliquidTransaction:TokenDelegate", TokenDelegateSignTypes},
+		{"approveAgent", "HyperliquidTransaction:ApproveAgent", ApproveAgentSignTypes},
+		{"approveBuilderFee", "HyperliquidTransaction:ApproveBuilderFee", ApproveBuilderFeeSignTypes},
+		{"userDexAbstraction", "HyperliquidTransaction:UserDexAbstraction", UserDexAbstractionSignTypes},
+		{"convertToMultiSigUser", "HyperliquidTransaction:ConvertToMultiSigUser", ConvertToMultiSigUserSignTypes},
+	}
+	for _, t := range builtins {
+		if err := RegisterUserSignedActionType(t); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterUserSignedActionType adds a user-signed action schema to the registry,
+// so actions added by Hyperliquid can be signed without an SDK release.
+// Registering a primary type or action type that already exists is an error.
+func RegisterUserSignedActionType(t UserSignedActionType) error {
+	if t.ActionType == "" {
+		return fmt.Errorf("action type is required")
+	}
+	if t.PrimaryType == "" {
+		return fmt.Errorf("primary type is required")
+	}
+	if len(t.Types) == 0 || t.Types[0].Name != "hyperliquidChain" || t.Types[0].Type != "string" {
+		return fmt.Errorf("first field of %s must be hyperliquidChain of type string", t.PrimaryType)
+	}
+
+	seen := make(map[string]bool, len(t.Types))
+	for _, typ := range t.Types {
+		if typ.Name == "" {
+			return fmt.Errorf("field name is required in %s", t.PrimaryType)
+		}
+		if seen[typ.Name] {
+			return fmt.Errorf("duplicate field %s in %s", typ.Name, t.PrimaryType)
+		}
+		if !eip712FieldType.MatchString(typ.Type) {
+			return fmt.Errorf("unsupported type %s for field %s in %s", typ.Type, typ.Name, t.PrimaryType)
+		}
+		seen[typ.Name] = true
+	}
+	if t.NonceField() == "" {
+		return fmt.Errorf("%s must have a nonce or time field", t.PrimaryType)
+	}
+
+	userSignedRegistryMu.Lock()
+	defer userSignedRegistryMu.Unlock()
+
+	if _, ok := userSignedRegistry[t.PrimaryType]; ok {
+		return fmt.Errorf("primary type %s is already registered", t.PrimaryType)
+	}
+	if existing, ok := userSignedByAction[t.ActionType]; ok {
+		return fmt.Errorf("action type %s is already registered as %s", t.ActionType, existing)
+	}
+	// Copy the fields so later changes by the caller cannot alter the registered schema
+	t.Types = append([]apitypes.Type(nil), t.Types...)
+	userSignedRegistry[t.PrimaryType] = t
+	userSignedByAction[t.ActionType] = t.PrimaryType
+	return nil
+}
+
+// LookupUserSignedActionType returns the registered schema for a primary type
+func LookupUserSignedActionType(primaryType string) (UserSignedActionType, bool) {
+	userSignedRegistryMu.RLock()
+	defer userSignedRegistryMu.RUnlock()
+
+	t, ok := userSignedRegistry[primaryType]
+	return t, ok
+}
+
+// LookupUserSignedActionTypeByAction returns the registered schema whose action type
+// is actionType, e.g. "usdSend"
+func LookupUserSignedActionTypeByAction(actionType string) (UserSignedActionType, bool) {
+	userSignedRegistryMu.RLock()
+	defer userSignedRegistryMu.RUnlock()
+
+	primaryType, ok := userSignedByAction[actionType]
+	if !ok {
+		return UserSignedActionType{}, false
+	}
+	return userSignedRegistry[primaryType], true
+}
+
+// Validate checks that every field of the schema other than hyperliquidChain is present in the action
+func (t UserSignedActionType) Validate(action map[string]any) error {
+	for _, typ := range t.Types[1:] {
+		if _, ok := action[typ.Name]; !ok {
+			return fmt.Errorf("missing field %s for %s", typ.Name, t.PrimaryType)
+		}
+	}
+	return nil
+}
+
+// SignRegisteredUserSignedAction signs a user-signed action using its registered schema
+func SignRegisteredUserSignedAction(
+	privateKey *ecdsa.PrivateKey,
+	action map[string]any,
+	primaryType string,
+	isMainnet bool,
+) (*types.Signature, error) {
+	return SignRegisteredUserSignedActionForNetwork(privateKey, action, primaryType, constants.NetworkFor(isMainnet))
+}
+
+// SignRegisteredUserSignedActionForNetwork signs a registered user-signed action for the given network
+func SignRegisteredUserSignedActionForNetwork(
+	privateKey *ecdsa.PrivateKey,
+	action map[string]any,
+	primaryType string,
+	network constants.Network,
+) (*types.Signature, error) {
+	t, ok := LookupUserSignedActionType(primaryType)
+	if !ok {
+		return nil, fmt.Errorf("unknown primary type: %s", primaryType)
+	}
+	if err := t.Validate(action); err != nil {
+		return nil, err
+	}
+	return SignUserSignedActionForNetwork(privateKey, action, t.Types, t.PrimaryType, network)
+}
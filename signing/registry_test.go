@@ -0,0 +1,102 @@
+package signing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+func TestRegisteredUserSignedActionMatchesBuiltin(t *testing.T) {
+	privateKey := getTestPrivateKey(t)
+	newAction := func() map[string]any {
+		return utils.NewOrderedMap(
+			"destination", "0x5e9ee1089755c3435139848e47e6635505d5a13a",
+			"amount", "1",
+			"time", int64(1687816341423),
+			"type", "usdSend",
+		)
+	}
+
+	want, err := SignUserSignedAction(privateKey, newAction(), USDSendSignTypes, "HyperliquidTransaction:UsdSend", true)
+	if err != nil {
+		t.Fatalf("SignUserSignedAction() error = %v", err)
+	}
+	got, err := SignRegisteredUserSignedAction(privateKey, newAction(), "HyperliquidTransaction:UsdSend", true)
+	if err != nil {
+		t.Fatalf("SignRegisteredUserSignedAction() error = %v", err)
+	}
+	if *got != *want {
+		t.Errorf("registered signature = %+v, want %+v", got, want)
+	}
+
+	missing := newAction()
+	delete(missing, "amount")
+	if _, err := SignRegisteredUserSignedAction(privateKey, missing, "HyperliquidTransaction:UsdSend", true); err == nil {
+		t.Error("SignRegisteredUserSignedAction() with missing field expected error")
+	}
+}
+
+func TestRegisterUserSignedActionType(t *testing.T) {
+	// The registry is global, so use unique types per run (go test -count)
+	suffix := time.Now().UnixNano()
+	custom := UserSignedActionType{
+		ActionType:  fmt.Sprintf("testAction%d", suffix),
+		PrimaryType: fmt.Sprintf("HyperliquidTransaction:TestAction%d", suffix),
+		Types: []apitypes.Type{
+			{Name: "hyperliquidChain", Type: "string"},
+			{Name: "target", Type: "address"},
+			{Name: "nonce", Type: "uint64"},
+		},
+	}
+	if err := RegisterUserSignedActionType(custom); err != nil {
+		t.Fatalf("RegisterUserSignedActionType() error = %v", err)
+	}
+	if err := RegisterUserSignedActionType(custom); err == nil {
+		t.Error("registering a primary type twice expected error")
+	}
+	duplicate := custom
+	duplicate.PrimaryType += "V2"
+	if err := RegisterUserSignedActionType(duplicate); err == nil || !strings.Contains(err.Error(), "already registered as "+custom.PrimaryType) {
+		t.Errorf("registering an action type twice error = %v", err)
+	}
+	if _, ok := LookupUserSignedActionType(duplicate.PrimaryType); ok {
+		t.Error("the rejected schema was registered")
+	}
+
+	got, ok := LookupUserSignedActionType(custom.PrimaryType)
+	if !ok || got.ActionType != custom.ActionType || got.NonceField() != "nonce" {
+		t.Errorf("LookupUserSignedActionType() = %+v, %v", got, ok)
+	}
+	if got, ok := LookupUserSignedActionTypeByAction(custom.ActionType); !ok || got.PrimaryType != custom.PrimaryType {
+		t.Errorf("LookupUserSignedActionTypeByAction() = %+v, %v", got, ok)
+	}
+
+	invalid := []UserSignedActionType{
+		{ActionType: "a", PrimaryType: "HyperliquidTransaction:NoChain", Types: []apitypes.Type{{Name: "nonce", Type: "uint64"}}},
+		{ActionType: "a", PrimaryType: "HyperliquidTransaction:NoNonce", Types: []apitypes.Type{{Name: "hyperliquidChain", Type: "string"}}},
+		{ActionType: "a", PrimaryType: "HyperliquidTransaction:BadType", Types: []apitypes.Type{
+			{Name: "hyperliquidChain", Type: "string"},
+			{Name: "nonce", Type: "float"},
+		}},
+	}
+	for _, typ := range invalid {
+		if err := RegisterUserSignedActionType(typ); err == nil {
+			t.Errorf("RegisterUserSignedActionType(%s) expected error", typ.PrimaryType)
+		}
+	}
+}
+
+func TestLookupUserSignedActionTypeByAction(t *testing.T) {
+	got, ok := LookupUserSignedActionTypeByAction("withdraw3")
+	if !ok || got.PrimaryType != "HyperliquidTransaction:Withdraw" {
+		t.Errorf("LookupUserSignedActionTypeByAction(withdraw3) = %+v, %v", got, ok)
+	}
+	if _, ok := LookupUserSignedActionTypeByAction("order"); ok {
+		t.Error("LookupUserSignedActionTypeByAction(order) found an L1 action")
+	}
+}
@@ -58,6 +58,7 @@
 package signing
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"encoding/binary"
 	"fmt"
@@ -69,57 +70,66 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/vmihailenco/msgpack/v5"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/tracing"
 	"github.com/dwdwow/hl-go/types"
 	"github.com/dwdwow/hl-go/utils"
 )
 
 // ActionHash computes the hash of an action for signing
 func ActionHash(action any, vaultAddress *string, nonce int64, expiresAfter *int64) ([]byte, error) {
-	// Encode action with msgpack
-	data, err := msgpack.Marshal(action)
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	// Encode action with msgpack into a pooled buffer
+	enc := msgpack.GetEncoder()
+	enc.Reset(buf)
+	err := enc.Encode(action)
+	msgpack.PutEncoder(enc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal action: %w", err)
 	}
 
 	// Append nonce (8 bytes, big endian)
-	nonceBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(nonceBytes, uint64(nonce))
-	data = append(data, nonceBytes...)
+	var scratch [8]byte
+	binary.BigEndian.PutUint64(scratch[:], uint64(nonce))
+	buf.Write(scratch[:])
 
 	// Append vault address
 	if vaultAddress == nil {
-		data = append(data, 0x00)
+		buf.WriteByte(0x00)
 	} else {
-		data = append(data, 0x01)
+		buf.WriteByte(0x01)
 		addrBytes, err := utils.AddressToBytes(*vaultAddress)
 		if err != nil {
 			return nil, fmt.Errorf("invalid vault address: %w", err)
 		}
-		data = append(data, addrBytes...)
+		buf.Write(addrBytes)
 	}
 
 	// Append expires after if present
 	if expiresAfter != nil {
-		data = append(data, 0x00)
-		expiresBytes := make([]byte, 8)
-		binary.BigEndian.PutUint64(expiresBytes, uint64(*expiresAfter))
-		data = append(data, expiresBytes...)
+		buf.WriteByte(0x00)
+		binary.BigEndian.PutUint64(scratch[:], uint64(*expiresAfter))
+		buf.Write(scratch[:])
 	}
 
 	// Return keccak256 hash
-	hash := crypto.Keccak256(data)
-	return hash, nil
+	return keccak256(buf.Bytes()), nil
 }
 
 // ConstructPhantomAgent constructs a phantom agent object for L1 signing
 // Must match Python SDK's construct_phantom_agent which creates:
 // {"source": "a" if is_mainnet else "b", "connectionId": hash}
 func ConstructPhantomAgent(hash []byte, isMainnet bool) map[string]any {
-	source := "b"
-	if isMainnet {
-		source = "a"
-	}
+	return ConstructPhantomAgentForNetwork(hash, constants.NetworkFor(isMainnet))
+}
+
+// ConstructPhantomAgentForNetwork constructs a phantom agent using the network's agent source
+func ConstructPhantomAgentForNetwork(hash []byte, network constants.Network) map[string]any {
+	source := network.AgentSource
 
 	// Convert []byte to common.Hash for EIP-712 bytes32 encoding
 	// crypto.Keccak256 always returns 32 bytes, which matches common.Hash size
@@ -132,15 +142,23 @@ func ConstructPhantomAgent(hash []byte, isMainnet bool) map[string]any {
 	)
 }
 
+// agentTypes is the EIP-712 schema of the phantom agent signed for L1 actions
+var agentTypes = []apitypes.Type{
+	{Name: "source", Type: "string"},
+	{Name: "connectionId", Type: "bytes32"},
+}
+
 // L1Payload constructs the EIP-712 payload for L1 actions
 func L1Payload(phantomAgent map[string]any) apitypes.TypedData {
+	return L1PayloadForNetwork(phantomAgent, constants.Mainnet)
+}
+
+// L1PayloadForNetwork constructs the EIP-712 payload for L1 actions using the network's L1 chain id
+func L1PayloadForNetwork(phantomAgent map[string]any, network constants.Network) apitypes.TypedData {
 	// Match Python SDK structure: Agent first, then EIP712Domain
 	return apitypes.TypedData{
 		Types: apitypes.Types{
-			"Agent": []apitypes.Type{
-				{Name: "source", Type: "string"},
-				{Name: "connectionId", Type: "bytes32"},
-			},
+			"Agent": agentTypes,
 			"EIP712Domain": []apitypes.Type{
 				{Name: "name", Type: "string"},
 				{Name: "version", Type: "string"},
@@ -152,7 +170,7 @@ func L1Payload(phantomAgent map[string]any) apitypes.TypedData {
 		Domain: apitypes.TypedDataDomain{
 			Name:              "Exchange",
 			Version:           "1",
-			ChainId:           (*math.HexOrDecimal256)(big.NewInt(1337)),
+			ChainId:           (*math.HexOrDecimal256)(big.NewInt(network.L1ChainID)),
 			VerifyingContract: "0x0000000000000000000000000000000000000000",
 		},
 		Message: apitypes.TypedDataMessage(phantomAgent),
@@ -234,15 +252,24 @@ func SignL1Action(
 	expiresAfter *int64,
 	isMainnet bool,
 ) (*types.Signature, error) {
-	hash, err := ActionHash(action, vaultAddress, nonce, expiresAfter)
+	return SignL1ActionForNetwork(privateKey, action, vaultAddress, nonce, expiresAfter, constants.NetworkFor(isMainnet))
+}
+
+// SignL1ActionForNetwork signs an L1 action for the given network
+func SignL1ActionForNetwork(
+	privateKey *ecdsa.PrivateKey,
+	action any,
+	vaultAddress *string,
+	nonce int64,
+	expiresAfter *int64,
+	network constants.Network,
+) (*types.Signature, error) {
+	typedData, err := L1ActionTypedDataForNetwork(action, vaultAddress, nonce, expiresAfter, network)
 	if err != nil {
 		return nil, err
 	}
 
-	phantomAgent := ConstructPhantomAgent(hash, isMainnet)
-	typedData := L1Payload(phantomAgent)
-
-	return signTypedData(privateKey, typedData)
+	return signTypedData(privateKey, typedData, l1AuditInfo(action, nonce))
 }
 
 // SignUserSignedAction signs a user-signed action (transfers, etc.)
@@ -253,16 +280,20 @@ func SignUserSignedAction(
 	primaryType string,
 	isMainnet bool,
 ) (*types.Signature, error) {
-	// Set chainId and hyperliquidChain
-	action["signatureChainId"] = "0x66eee"
-	if isMainnet {
-		action["hyperliquidChain"] = "Mainnet"
-	} else {
-		action["hyperliquidChain"] = "Testnet"
-	}
+	return SignUserSignedActionForNetwork(privateKey, action, signatureTypes, primaryType, constants.NetworkFor(isMainnet))
+}
 
-	typedData := UserSignedPayload(action, signatureTypes, primaryType)
-	return signTypedData(privateKey, typedData)
+// SignUserSignedActionForNetwork signs a user-signed action for the given network
+func SignUserSignedActionForNetwork(
+	privateKey *ecdsa.PrivateKey,
+	action map[string]any,
+	signatureTypes []apitypes.Type,
+	primaryType string,
+	network constants.Network,
+) (*types.Signature, error) {
+	// Sets chainId and hyperliquidChain on the action
+	typedData := UserSignedActionTypedDataForNetwork(action, signatureTypes, primaryType, network)
+	return signTypedData(privateKey, typedData, userSignedAuditInfo(action))
 }
 
 // SignMultiSigAction signs a multi-sig action
@@ -273,6 +304,18 @@ func SignMultiSigAction(
 	vaultAddress *string,
 	nonce int64,
 	expiresAfter *int64,
+) (*types.Signature, error) {
+	return SignMultiSigActionForNetwork(privateKey, action, constants.NetworkFor(isMainnet), vaultAddress, nonce, expiresAfter)
+}
+
+// SignMultiSigActionForNetwork signs a multi-sig action for the given network
+func SignMultiSigActionForNetwork(
+	privateKey *ecdsa.PrivateKey,
+	action map[string]any,
+	network constants.Network,
+	vaultAddress *string,
+	nonce int64,
+	expiresAfter *int64,
 ) (*types.Signature, error) {
 	// Create a copy without the type field
 	// Python SDK: action_without_tag = action.copy(); del action_without_tag["type"]
@@ -315,40 +358,41 @@ func SignMultiSigAction(
 		"nonce", nonce,
 	)
 
-	return SignUserSignedAction(
-		privateKey,
-		envelope,
-		MultiSigEnvelopeSignTypes,
-		"HyperliquidTransaction:SendMultiSig",
-		isMainnet,
-	)
+	typedData := UserSignedActionTypedDataForNetwork(envelope, MultiSigEnvelopeSignTypes, "HyperliquidTransaction:SendMultiSig", network)
+	info := auditInfo{kind: "multiSig", nonce: nonce, action: action}
+	info.actionType, _ = action["type"].(string)
+	return signTypedData(privateKey, typedData, info)
 }
 
-// signTypedData signs EIP-712 typed data
-func signTypedData(privateKey *ecdsa.PrivateKey, typedData apitypes.TypedData) (*types.Signature, error) {
-	// Compute the typed data hash
-	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash domain: %w", err)
-	}
+// signTypedData signs EIP-712 typed data in a span of the installed tracer
+func signTypedData(privateKey *ecdsa.PrivateKey, typedData apitypes.TypedData, info auditInfo) (*types.Signature, error) {
+	_, span := loadTracer().Start(context.Background(), tracing.SpanSign, trace.WithAttributes(info.attributes()...))
+	sig, err := signTypedDataAudited(privateKey, typedData, info)
+	tracing.End(span, err)
+	return sig, err
+}
 
-	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+// signTypedDataAudited signs EIP-712 typed data and records the signature with the
+// installed auditor
+func signTypedDataAudited(privateKey *ecdsa.PrivateKey, typedData apitypes.TypedData, info auditInfo) (*types.Signature, error) {
+	// Compute keccak256("\x19\x01" + domainSeparator + typedDataHash)
+	hash, err := typedDataHash(typedData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash message: %w", err)
+		return nil, err
 	}
 
-	// Construct the final hash: keccak256("\x19\x01" + domainSeparator + typedDataHash)
-	rawData := []byte{0x19, 0x01}
-	rawData = append(rawData, domainSeparator...)
-	rawData = append(rawData, typedDataHash...)
-	hash := crypto.Keccak256(rawData)
-
 	// Sign the hash
 	sig, err := crypto.Sign(hash, privateKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign: %w", err)
 	}
 
+	if a := auditor.Load(); a != nil {
+		if err := a.record(info, typedData.PrimaryType, hash, sig); err != nil {
+			return nil, fmt.Errorf("failed to audit signature: %w", err)
+		}
+	}
+
 	// Extract r, s, v
 	r := sig[:32]
 	s := sig[32:64]
@@ -359,80 +403,34 @@ func signTypedData(privateKey *ecdsa.PrivateKey, typedData apitypes.TypedData) (
 		v += 27
 	}
 
-	// Format R and S as hex strings, removing leading zeros to match Python SDK
-	// Python SDK uses hex() which doesn't include leading zeros
-	formatHex := func(b []byte) string {
-		hexStr := common.Bytes2Hex(b)
-		// Remove leading zeros but keep at least one digit
-		trimmed := hexStr
-		for len(trimmed) > 1 && trimmed[0] == '0' {
-			trimmed = trimmed[1:]
-		}
-		return "0x" + trimmed
-	}
-
 	return &types.Signature{
-		R: formatHex(r),
-		S: formatHex(s),
+		R: formatSignatureHex(r),
+		S: formatSignatureHex(s),
 		V: v,
 	}, nil
 }
 
-// OrderTypeToWire converts OrderType to wire format
-func OrderTypeToWire(orderType types.OrderType) (types.OrderTypeWire, error) {
-	wire := types.OrderTypeWire{}
-
-	if orderType.Limit != nil {
-		wire.Limit = orderType.Limit
-	} else if orderType.Trigger != nil {
-		triggerPx, err := utils.FloatToWire(orderType.Trigger.TriggerPx)
-		if err != nil {
-			return wire, fmt.Errorf("invalid trigger price: %w", err)
-		}
-		wire.Trigger = &types.TriggerOrderTypeWire{
-			TriggerPx: triggerPx,
-			IsMarket:  orderType.Trigger.IsMarket,
-			Tpsl:      orderType.Trigger.Tpsl,
-		}
-	} else {
-		return wire, fmt.Errorf("invalid order type: must have either limit or trigger")
+// formatSignatureHex formats R and S as hex strings, removing leading zeros to match Python SDK
+// Python SDK uses hex() which doesn't include leading zeros
+func formatSignatureHex(b []byte) string {
+	hexStr := common.Bytes2Hex(b)
+	// Remove leading zeros but keep at least one digit
+	trimmed := hexStr
+	for len(trimmed) > 1 && trimmed[0] == '0' {
+		trimmed = trimmed[1:]
 	}
+	return "0x" + trimmed
+}
 
-	return wire, nil
+// OrderTypeToWire converts OrderType to wire format, see types.OrderType.ToWire
+func OrderTypeToWire(orderType types.OrderType) (types.OrderTypeWire, error) {
+	return orderType.ToWire()
 }
 
-// OrderRequestToOrderWire converts an OrderRequest to wire format
+// OrderRequestToOrderWire converts an OrderRequest to wire format, see
+// types.OrderRequest.ToWire
 func OrderRequestToOrderWire(order types.OrderRequest, asset int) (types.OrderWire, error) {
-	limitPx, err := utils.FloatToWire(order.LimitPx)
-	if err != nil {
-		return types.OrderWire{}, fmt.Errorf("invalid limit price: %w", err)
-	}
-
-	sz, err := utils.FloatToWire(order.Sz)
-	if err != nil {
-		return types.OrderWire{}, fmt.Errorf("invalid size: %w", err)
-	}
-
-	orderTypeWire, err := OrderTypeToWire(order.OrderType)
-	if err != nil {
-		return types.OrderWire{}, err
-	}
-
-	wire := types.OrderWire{
-		Asset:      asset,
-		IsBuy:      order.IsBuy,
-		LimitPx:    limitPx,
-		Sz:         sz,
-		ReduceOnly: order.ReduceOnly,
-		OrderType:  orderTypeWire,
-	}
-
-	if order.Cloid != nil {
-		raw := order.Cloid.ToRaw()
-		wire.Cloid = &raw
-	}
-
-	return wire, nil
+	return order.ToWire(asset)
 }
 
 // OrderWiresToOrderAction creates an order action from order wires
@@ -0,0 +1,113 @@
+package signing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+func benchmarkOrderAction(b *testing.B) map[string]any {
+	b.Helper()
+
+	cloid, err := types.NewCloidFromString("0x00000000000000000000000000000001")
+	if err != nil {
+		b.Fatal(err)
+	}
+	orderWire, err := OrderRequestToOrderWire(types.OrderRequest{
+		Coin:      "ETH",
+		IsBuy:     true,
+		Sz:        0.0147,
+		LimitPx:   1670.1,
+		OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+		Cloid:     cloid,
+	}, 4)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return OrderWiresToOrderAction([]types.OrderWire{orderWire}, nil)
+}
+
+func BenchmarkActionHash(b *testing.B) {
+	action := benchmarkOrderAction(b)
+	vaultAddress := "0x1719884eb866cb12b2287399b15f7db5e7d775ea"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ActionHash(action, &vaultAddress, 1677777606040, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignL1ActionOrder(b *testing.B) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKeyHex)
+	if err != nil {
+		b.Fatal(err)
+	}
+	action := benchmarkOrderAction(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SignL1Action(privateKey, action, nil, 1677777606040, nil, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignUserSignedAction(b *testing.B) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKeyHex)
+	if err != nil {
+		b.Fatal(err)
+	}
+	action := map[string]any{
+		"destination": "0x5e9ee1089755c3435139848e47e6635505d5a13a",
+		"amount":      "1",
+		"time":        int64(1687816341423),
+		"type":        "usdSend",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SignUserSignedAction(privateKey, action, USDSendSignTypes, "HyperliquidTransaction:UsdSend", true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTypedDataHashMatchesGeneric(t *testing.T) {
+	vaultAddress := "0x1719884eb866cb12b2287399b15f7db5e7d775ea"
+	l1, err := L1ActionTypedData(map[string]any{"type": "noop"}, &vaultAddress, 1677777606040, nil, false)
+	if err != nil {
+		t.Fatalf("L1ActionTypedData() error = %v", err)
+	}
+	user := UserSignedActionTypedData(map[string]any{
+		"destination": "0x5e9ee1089755c3435139848e47e6635505d5a13a",
+		"amount":      "1",
+		"time":        int64(1687816341423),
+		"type":        "usdSend",
+	}, USDSendSignTypes, "HyperliquidTransaction:UsdSend", true)
+	// Uncached domain falls back to the generic path
+	custom := L1Payload(l1.Message)
+	custom.Domain.Name = "Other"
+
+	for name, typedData := range map[string]apitypes.TypedData{"l1": l1, "user": user, "custom": custom} {
+		want, _, err := apitypes.TypedDataAndHash(typedData)
+		if err != nil {
+			t.Fatalf("%s: TypedDataAndHash() error = %v", name, err)
+		}
+		got, err := typedDataHash(typedData)
+		if err != nil {
+			t.Fatalf("%s: typedDataHash() error = %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: typedDataHash() = %x, want %x", name, got, want)
+		}
+	}
+}
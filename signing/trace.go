@@ -0,0 +1,44 @@
+package signing
+
+import (
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dwdwow/hl-go/tracing"
+)
+
+var tracer atomic.Pointer[trace.Tracer]
+
+// SetTracerProvider sets the provider of the span recorded per signature, with the kind,
+// type and nonce of the action. Pass nil to trace with the global provider of otel.
+// Signatures are made outside of any request context: their spans are correlated with
+// the spans of the requests posting them by tracing.NonceKey.
+func SetTracerProvider(tp trace.TracerProvider) {
+	if tp == nil {
+		tracer.Store(nil)
+		return
+	}
+	t := tracing.Tracer(tp)
+	tracer.Store(&t)
+}
+
+func loadTracer() trace.Tracer {
+	if t := tracer.Load(); t != nil {
+		return *t
+	}
+	return tracing.Tracer(nil)
+}
+
+// attributes returns the span attributes of the action behind a signature
+func (info auditInfo) attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{tracing.SignatureKindKey.String(info.kind)}
+	if info.actionType != "" {
+		attrs = append(attrs, tracing.ActionTypeKey.String(info.actionType))
+	}
+	if info.nonce != 0 {
+		attrs = append(attrs, tracing.NonceKey.Int64(info.nonce))
+	}
+	return attrs
+}
@@ -0,0 +1,48 @@
+package signing
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/dwdwow/hl-go/tracing"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+func TestSetTracerProvider(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer SetTracerProvider(nil)
+
+	if _, err := SignL1Action(getTestPrivateKey(t), utils.NewOrderedMap("type", "noop"), nil, 1677777606040, nil, true); err != nil {
+		t.Fatalf("SignL1Action() error = %v", err)
+	}
+	SetTracerProvider(noop.NewTracerProvider())
+	if _, err := SignL1Action(getTestPrivateKey(t), utils.NewOrderedMap("type", "noop"), nil, 1677777606041, nil, true); err != nil {
+		t.Fatalf("SignL1Action() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != tracing.SpanSign || s.Status().Code != codes.Unset {
+		t.Errorf("span = %s %+v", s.Name(), s.Status())
+	}
+	attrs := attribute.NewSet(s.Attributes()...)
+	want := []attribute.KeyValue{
+		tracing.SignatureKindKey.String("l1"),
+		tracing.ActionTypeKey.String("noop"),
+		tracing.NonceKey.Int64(1677777606040),
+	}
+	for _, kv := range want {
+		if got, _ := attrs.Value(kv.Key); got != kv.Value {
+			t.Errorf("attribute %s = %v, want %v", kv.Key, got.Emit(), kv.Value.Emit())
+		}
+	}
+}
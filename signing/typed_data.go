@@ -0,0 +1,145 @@
+package signing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/types"
+)
+
+// L1ActionTypedData returns the EIP-712 typed data that SignL1Action signs for an action.
+// The result can be handed to an external wallet (eth_signTypedData_v4) instead of
+// signing with a local private key.
+func L1ActionTypedData(
+	action any,
+	vaultAddress *string,
+	nonce int64,
+	expiresAfter *int64,
+	isMainnet bool,
+) (apitypes.TypedData, error) {
+	return L1ActionTypedDataForNetwork(action, vaultAddress, nonce, expiresAfter, constants.NetworkFor(isMainnet))
+}
+
+// L1ActionTypedDataForNetwork returns the EIP-712 typed data of an L1 action for the given network
+func L1ActionTypedDataForNetwork(
+	action any,
+	vaultAddress *string,
+	nonce int64,
+	expiresAfter *int64,
+	network constants.Network,
+) (apitypes.TypedData, error) {
+	hash, err := ActionHash(action, vaultAddress, nonce, expiresAfter)
+	if err != nil {
+		return apitypes.TypedData{}, err
+	}
+
+	phantomAgent := ConstructPhantomAgentForNetwork(hash, network)
+	return L1PayloadForNetwork(phantomAgent, network), nil
+}
+
+// UserSignedActionTypedData returns the EIP-712 typed data that SignUserSignedAction signs.
+// Like SignUserSignedAction, it sets signatureChainId and hyperliquidChain on the action,
+// so the same action map must be posted to the exchange afterwards.
+func UserSignedActionTypedData(
+	action map[string]any,
+	signatureTypes []apitypes.Type,
+	primaryType string,
+	isMainnet bool,
+) apitypes.TypedData {
+	return UserSignedActionTypedDataForNetwork(action, signatureTypes, primaryType, constants.NetworkFor(isMainnet))
+}
+
+// UserSignedActionTypedDataForNetwork returns the EIP-712 typed data of a user-signed action
+// for the given network, setting signatureChainId and hyperliquidChain on the action
+func UserSignedActionTypedDataForNetwork(
+	action map[string]any,
+	signatureTypes []apitypes.Type,
+	primaryType string,
+	network constants.Network,
+) apitypes.TypedData {
+	action["signatureChainId"] = network.SignatureChainID
+	action["hyperliquidChain"] = network.HyperliquidChain
+
+	return UserSignedPayload(action, signatureTypes, primaryType)
+}
+
+// TypedDataJSON encodes typed data as the JSON document expected by eth_signTypedData_v4.
+// Integer message fields are rendered as decimal strings so that values above 2^53
+// survive JavaScript number parsing in browser wallets.
+func TypedDataJSON(typedData apitypes.TypedData) ([]byte, error) {
+	message := make(apitypes.TypedDataMessage, len(typedData.Message))
+	for k, v := range typedData.Message {
+		switch val := v.(type) {
+		case *big.Int:
+			message[k] = val.String()
+		case common.Hash:
+			message[k] = val.Hex()
+		default:
+			message[k] = v
+		}
+	}
+	typedData.Message = message
+
+	data, err := json.Marshal(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal typed data: %w", err)
+	}
+	return data, nil
+}
+
+// SignatureFromHex converts a 65-byte hex signature (r || s || v), as returned by
+// eth_signTypedData_v4, into the Signature format expected by the exchange.
+func SignatureFromHex(sig string) (*types.Signature, error) {
+	raw := common.FromHex(strings.TrimSpace(sig))
+	if len(raw) != 65 {
+		return nil, fmt.Errorf("signature must be 65 bytes, got %d", len(raw))
+	}
+
+	v := int(raw[64])
+	if v < 27 {
+		v += 27
+	}
+	if v != 27 && v != 28 {
+		return nil, fmt.Errorf("invalid signature recovery id: %d", raw[64])
+	}
+
+	return &types.Signature{
+		R: formatSignatureHex(raw[:32]),
+		S: formatSignatureHex(raw[32:64]),
+		V: v,
+	}, nil
+}
+
+// RecoverSigner returns the address whose key produced sig over typed data, e.g. to
+// check the signature of an action posted to a test server
+func RecoverSigner(typedData apitypes.TypedData, sig types.Signature) (common.Address, error) {
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if sig.V != 27 && sig.V != 28 {
+		return common.Address{}, fmt.Errorf("invalid signature recovery id: %d", sig.V)
+	}
+
+	r, s := common.FromHex(sig.R), common.FromHex(sig.S)
+	if len(r) > 32 || len(s) > 32 {
+		return common.Address{}, fmt.Errorf("invalid signature: r and s must be at most 32 bytes")
+	}
+	raw := make([]byte, 0, 65)
+	raw = append(raw, common.LeftPadBytes(r, 32)...)
+	raw = append(raw, common.LeftPadBytes(s, 32)...)
+	raw = append(raw, byte(sig.V-27))
+
+	pub, err := crypto.SigToPub(hash, raw)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
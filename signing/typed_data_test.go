@@ -0,0 +1,175 @@
+package signing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// signExternally mimics a browser wallet: it decodes the exported JSON, hashes it
+// with the standard EIP-712 implementation and returns a 65-byte hex signature.
+func signExternally(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		t.Fatalf("TypedDataAndHash() error = %v", err)
+	}
+
+	sig, err := crypto.Sign(hash, getTestPrivateKey(t))
+	if err != nil {
+		t.Fatalf("crypto.Sign() error = %v", err)
+	}
+	sig[64] += 27
+	return hexutil.Encode(sig)
+}
+
+func TestL1ActionTypedDataMatchesSignL1Action(t *testing.T) {
+	privateKey := getTestPrivateKey(t)
+	// Single-key action: msgpack map encoding follows Go map iteration order
+	action := utils.NewOrderedMap("type", "noop")
+	vaultAddress := "0x1719884eb866cb12b2287399b15f7db5e7d775ea"
+
+	for _, isMainnet := range []bool{true, false} {
+		want, err := SignL1Action(privateKey, action, &vaultAddress, 1677777606040, nil, isMainnet)
+		if err != nil {
+			t.Fatalf("SignL1Action() error = %v", err)
+		}
+
+		typedData, err := L1ActionTypedData(action, &vaultAddress, 1677777606040, nil, isMainnet)
+		if err != nil {
+			t.Fatalf("L1ActionTypedData() error = %v", err)
+		}
+		data, err := TypedDataJSON(typedData)
+		if err != nil {
+			t.Fatalf("TypedDataJSON() error = %v", err)
+		}
+
+		got, err := SignatureFromHex(signExternally(t, data))
+		if err != nil {
+			t.Fatalf("SignatureFromHex() error = %v", err)
+		}
+		if *got != *want {
+			t.Errorf("isMainnet=%v: external signature = %+v, want %+v", isMainnet, got, want)
+		}
+	}
+}
+
+func TestUserSignedActionTypedDataMatchesSignUserSignedAction(t *testing.T) {
+	privateKey := getTestPrivateKey(t)
+	newAction := func() map[string]any {
+		return utils.NewOrderedMap(
+			"destination", "0x5e9ee1089755c3435139848e47e6635505d5a13a",
+			"amount", "1",
+			"time", int64(1687816341423),
+			"type", "withdraw3",
+		)
+	}
+
+	want, err := SignUserSignedAction(privateKey, newAction(), Withdraw3SignTypes, "HyperliquidTransaction:Withdraw", false)
+	if err != nil {
+		t.Fatalf("SignUserSignedAction() error = %v", err)
+	}
+
+	action := newAction()
+	typedData := UserSignedActionTypedData(action, Withdraw3SignTypes, "HyperliquidTransaction:Withdraw", false)
+	if action["hyperliquidChain"] != "Testnet" || action["signatureChainId"] != "0x66eee" {
+		t.Errorf("action chain fields not set: %+v", action)
+	}
+
+	data, err := TypedDataJSON(typedData)
+	if err != nil {
+		t.Fatalf("TypedDataJSON() error = %v", err)
+	}
+
+	got, err := SignatureFromHex(signExternally(t, data))
+	if err != nil {
+		t.Fatalf("SignatureFromHex() error = %v", err)
+	}
+	if *got != *want {
+		t.Errorf("external signature = %+v, want %+v", got, want)
+	}
+}
+
+func TestSignatureFromHexRejectsMalformedInput(t *testing.T) {
+	for _, sig := range []string{"", "0x1234", "0x" + string(make([]byte, 130))} {
+		if _, err := SignatureFromHex(sig); err == nil {
+			t.Errorf("SignatureFromHex(%q) expected error", sig)
+		}
+	}
+}
+
+func TestNetworkPresetsMatchMainnetFlag(t *testing.T) {
+	privateKey := getTestPrivateKey(t)
+	action := utils.NewOrderedMap("type", "noop")
+
+	for _, isMainnet := range []bool{true, false} {
+		want, err := SignL1Action(privateKey, action, nil, 1677777606040, nil, isMainnet)
+		if err != nil {
+			t.Fatalf("SignL1Action() error = %v", err)
+		}
+		got, err := SignL1ActionForNetwork(privateKey, action, nil, 1677777606040, nil, constants.NetworkFor(isMainnet))
+		if err != nil {
+			t.Fatalf("SignL1ActionForNetwork() error = %v", err)
+		}
+		if *got != *want {
+			t.Errorf("isMainnet=%v: network signature = %+v, want %+v", isMainnet, got, want)
+		}
+	}
+
+	// A network with a different L1 chain id must produce a different signature
+	custom := constants.Testnet
+	custom.L1ChainID = 31337
+	base, _ := SignL1ActionForNetwork(privateKey, action, nil, 1, nil, constants.Testnet)
+	other, err := SignL1ActionForNetwork(privateKey, action, nil, 1, nil, custom)
+	if err != nil {
+		t.Fatalf("SignL1ActionForNetwork() error = %v", err)
+	}
+	if *base == *other {
+		t.Error("custom L1 chain id did not change the signature")
+	}
+}
+
+func TestRecoverSigner(t *testing.T) {
+	privateKey := getTestPrivateKey(t)
+	want := crypto.PubkeyToAddress(privateKey.PublicKey)
+	action := utils.NewOrderedMap("type", "noop")
+
+	sig, err := SignL1Action(privateKey, action, nil, 1677777606040, nil, false)
+	if err != nil {
+		t.Fatalf("SignL1Action() error = %v", err)
+	}
+	typedData, err := L1ActionTypedData(action, nil, 1677777606040, nil, false)
+	if err != nil {
+		t.Fatalf("L1ActionTypedData() error = %v", err)
+	}
+	if got, err := RecoverSigner(typedData, *sig); err != nil || got != want {
+		t.Errorf("RecoverSigner() = %s, %v, want %s", got, err, want)
+	}
+
+	// Another nonce recovers another address
+	other, err := L1ActionTypedData(action, nil, 1677777606041, nil, false)
+	if err != nil {
+		t.Fatalf("L1ActionTypedData() error = %v", err)
+	}
+	if got, err := RecoverSigner(other, *sig); err == nil && got == want {
+		t.Error("RecoverSigner() of other typed data recovered the signer")
+	}
+
+	bad := *sig
+	bad.V = 29
+	if _, err := RecoverSigner(typedData, bad); err == nil {
+		t.Error("RecoverSigner() with invalid v expected error")
+	}
+}
@@ -0,0 +1,116 @@
+// Package tracing instruments hl-go clients with OpenTelemetry, so order lifecycles can be
+// traced end to end through the services using them: info requests and actions,
+// signatures, and WebSocket post requests matched with their responses.
+//
+// The clients trace with the TracerProvider given to API.SetTracerProvider,
+// ExchangeOptions.TracerProvider, signing.SetTracerProvider and
+// PostOnlyClient.SetTracerProvider, or with the global provider of otel if none is given.
+// Info requests and actions are client spans following the RPC and HTTP semantic
+// conventions, named after their rpc.method, e.g. "info/l2Book" or "exchange/order".
+// What is specific to Hyperliquid is recorded under the hyperliquid namespace.
+package tracing
+
+import (
+	"net/url"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracers of hl-go
+const ScopeName = "github.com/dwdwow/hl-go"
+
+// RPC services, the first part of the rpc.method of a request
+const (
+	// ServiceInfo is the info endpoint, e.g. "info/l2Book"
+	ServiceInfo = "info"
+	// ServiceExchange is the exchange endpoint, e.g. "exchange/order"
+	ServiceExchange = "exchange"
+)
+
+// Span names
+const (
+	// SpanSign is the signature of an action
+	SpanSign = "hyperliquid.sign"
+	// SpanWsPost is a WebSocket post request, until its response
+	SpanWsPost = "hyperliquid.ws.post"
+)
+
+// Attribute keys
+const (
+	// ActionTypeKey is the type of an action, e.g. "order"
+	ActionTypeKey = attribute.Key("hyperliquid.action.type")
+	// InfoTypeKey is the type of an info request, e.g. "l2Book"
+	InfoTypeKey = attribute.Key("hyperliquid.info.type")
+	// CoinKey is the coin of an info request
+	CoinKey = attribute.Key("hyperliquid.coin")
+	// CoinsKey is the coins an action trades or cancels
+	CoinsKey = attribute.Key("hyperliquid.coins")
+	// NonceKey is the nonce of an action or signature, which correlates them
+	NonceKey = attribute.Key("hyperliquid.nonce")
+	// UserKey is the user of an info request
+	UserKey = attribute.Key("hyperliquid.user")
+	// VaultAddressKey is the vault an action is made for
+	VaultAddressKey = attribute.Key("hyperliquid.vault_address")
+	// SignatureKindKey is "l1", "userSigned" or "multiSig"
+	SignatureKindKey = attribute.Key("hyperliquid.signature.kind")
+	// WsRequestIDKey is the id of a WebSocket post request, echoed by its response
+	WsRequestIDKey = attribute.Key("hyperliquid.ws.request_id")
+	// WsRequestTypeKey is the type of a WebSocket post request, "info" or "action"
+	WsRequestTypeKey = attribute.Key("hyperliquid.ws.request_type")
+)
+
+// RPCSystem is the rpc.system.name of the requests to Hyperliquid
+var RPCSystem = semconv.RPCSystemNameKey.String("hyperliquid")
+
+// Tracer returns the tracer of hl-go from tp, or from the global provider if tp is nil
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(ScopeName, trace.WithSchemaURL(semconv.SchemaURL))
+}
+
+// RPCMethod returns the rpc.method of a request of type typ to service
+func RPCMethod(service, typ string) string {
+	if typ == "" {
+		return service
+	}
+	return service + "/" + typ
+}
+
+// HTTPAttributes returns the attributes of a POST request to rawURL
+func HTTPAttributes(rawURL string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.HTTPRequestMethodPost, semconv.URLFull(rawURL)}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return attrs
+	}
+	attrs = append(attrs, semconv.ServerAddress(u.Hostname()))
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https", "wss":
+			port = "443"
+		case "http", "ws":
+			port = "80"
+		}
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.ServerPort(p))
+	}
+	return attrs
+}
+
+// End ends span, failed with err if not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, span := Tracer(tp).Start(context.Background(), RPCMethod(ServiceExchange, "order"))
+	End(span, errors.New("timeout"))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(previous)
+	_, span = Tracer(nil).Start(context.Background(), RPCMethod(ServiceInfo, ""))
+	End(span, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if s := spans[0]; s.Name() != "exchange/order" || s.Status().Code != codes.Error || len(s.Events()) != 1 ||
+		s.InstrumentationScope().Name != ScopeName {
+		t.Errorf("failed span = %s %+v %+v", s.Name(), s.Status(), s.InstrumentationScope())
+	}
+	if s := spans[1]; s.Name() != "info" || s.Status().Code != codes.Unset {
+		t.Errorf("span of the global provider = %s %+v", s.Name(), s.Status())
+	}
+}
+
+func TestHTTPAttributes(t *testing.T) {
+	tests := []struct {
+		url     string
+		address string
+		port    int64
+	}{
+		{"https://api.hyperliquid.xyz/info", "api.hyperliquid.xyz", 443},
+		{"http://127.0.0.1:3001/exchange", "127.0.0.1", 3001},
+		{"http://localhost/info", "localhost", 80},
+	}
+	for _, tt := range tests {
+		attrs := attribute.NewSet(HTTPAttributes(tt.url)...)
+		method, _ := attrs.Value("http.request.method")
+		full, _ := attrs.Value("url.full")
+		address, _ := attrs.Value("server.address")
+		port, _ := attrs.Value("server.port")
+		if method.AsString() != "POST" || full.AsString() != tt.url || address.AsString() != tt.address || port.AsInt64() != tt.port {
+			t.Errorf("HTTPAttributes(%q) = %v", tt.url, attrs.ToSlice())
+		}
+	}
+}
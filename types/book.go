@@ -0,0 +1,115 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// ErrEmptyBook is returned when the side of the book an order would take is empty
+var ErrEmptyBook = errors.New("empty order book")
+
+// BookFill is the expected result of taking liquidity from an L2 book
+type BookFill struct {
+	// Sz is the size filled, less than requested when the book is too thin or the
+	// limit price is reached
+	Sz       float64
+	Notional float64
+	AvgPx    float64
+	// WorstPx is the price of the last level taken
+	WorstPx float64
+	// SlippageBps is the slippage of AvgPx against the mid of the book
+	SlippageBps float64
+}
+
+// Bids returns the bids of the book, best first
+func (b L2BookData) Bids() []L2Level {
+	return b.Levels[0]
+}
+
+// Asks returns the asks of the book, best first
+func (b L2BookData) Asks() []L2Level {
+	return b.Levels[1]
+}
+
+// BestBid returns the highest bid price, false if there are no bids
+func (b L2BookData) BestBid() (float64, bool) {
+	return bestPx(b.Bids())
+}
+
+// BestAsk returns the lowest ask price, false if there are no asks
+func (b L2BookData) BestAsk() (float64, bool) {
+	return bestPx(b.Asks())
+}
+
+// Mid returns the mid price of the book, false if either side is empty
+func (b L2BookData) Mid() (float64, bool) {
+	bid, ok := b.BestBid()
+	if !ok {
+		return 0, false
+	}
+	ask, ok := b.BestAsk()
+	if !ok {
+		return 0, false
+	}
+	return (bid + ask) / 2, true
+}
+
+// EstimateFill walks the book to estimate the fill of a market order of sz: the asks
+// for a buy, the bids for a sell
+func (b L2BookData) EstimateFill(isBuy bool, sz float64) (BookFill, error) {
+	return b.EstimateFillWithin(isBuy, sz, 0)
+}
+
+// EstimateFillWithin is like EstimateFill for a limit order at limitPx, taking only the
+// levels at or better than limitPx. A limitPx of 0 takes any level.
+func (b L2BookData) EstimateFillWithin(isBuy bool, sz, limitPx float64) (BookFill, error) {
+	levels, side := b.Bids(), "bids"
+	if isBuy {
+		levels, side = b.Asks(), "asks"
+	}
+	if len(levels) == 0 {
+		return BookFill{}, fmt.Errorf("%w: no %s for %s", ErrEmptyBook, side, b.Coin)
+	}
+
+	var fill BookFill
+	for i, level := range levels {
+		if fill.Sz >= sz {
+			break
+		}
+		px, err := level.PxFloat()
+		if err != nil {
+			return BookFill{}, fmt.Errorf("invalid price of level %d: %w", i, err)
+		}
+		levelSz, err := level.SzFloat()
+		if err != nil {
+			return BookFill{}, fmt.Errorf("invalid size of level %d: %w", i, err)
+		}
+		if limitPx > 0 && ((isBuy && px > limitPx) || (!isBuy && px < limitPx)) {
+			break
+		}
+		taken := math.Min(levelSz, sz-fill.Sz)
+		fill.Sz += taken
+		fill.Notional += taken * px
+		fill.WorstPx = px
+	}
+
+	if fill.Sz > 0 {
+		fill.AvgPx = fill.Notional / fill.Sz
+		if mid, ok := b.Mid(); ok {
+			fill.SlippageBps = utils.SlippageBps(mid, fill.AvgPx, isBuy)
+		}
+	}
+	return fill, nil
+}
+
+// bestPx returns the price of the first level, false if there are none or it is invalid
+func bestPx(levels []L2Level) (float64, bool) {
+	if len(levels) == 0 {
+		return 0, false
+	}
+	px, err := levels[0].PxFloat()
+	return px, err == nil
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func testBook() L2BookData {
+	return L2BookData{
+		Coin: "ETH",
+		Levels: [2][]L2Level{
+			{{Px: "99", Sz: "2", N: 1}, {Px: "98", Sz: "3", N: 2}},
+			{{Px: "101", Sz: "1", N: 1}, {Px: "102", Sz: "1", N: 1}, {Px: "105", Sz: "10", N: 4}},
+		},
+	}
+}
+
+func TestL2BookMid(t *testing.T) {
+	book := testBook()
+	if mid, ok := book.Mid(); !ok || mid != 100 {
+		t.Errorf("Mid() = %v, %v", mid, ok)
+	}
+	book.Levels[0] = nil
+	if _, ok := book.Mid(); ok {
+		t.Error("Mid() without bids should be false")
+	}
+	if ask, ok := book.BestAsk(); !ok || ask != 101 {
+		t.Errorf("BestAsk() = %v, %v", ask, ok)
+	}
+}
+
+func TestL2BookEstimateFill(t *testing.T) {
+	tests := []struct {
+		name    string
+		isBuy   bool
+		sz      float64
+		limitPx float64
+		want    BookFill
+	}{
+		{"within first level", true, 0.5, 0, BookFill{Sz: 0.5, Notional: 50.5, AvgPx: 101, WorstPx: 101, SlippageBps: 100}},
+		{"across levels", true, 3, 0, BookFill{Sz: 3, Notional: 308, AvgPx: 308.0 / 3, WorstPx: 105, SlippageBps: 266.6667}},
+		{"sell", false, 4, 0, BookFill{Sz: 4, Notional: 394, AvgPx: 98.5, WorstPx: 98, SlippageBps: 150}},
+		{"thin book", false, 10, 0, BookFill{Sz: 5, Notional: 492, AvgPx: 98.4, WorstPx: 98, SlippageBps: 160}},
+		{"limit", true, 3, 102, BookFill{Sz: 2, Notional: 203, AvgPx: 101.5, WorstPx: 102, SlippageBps: 150}},
+		{"limit before best", true, 1, 100, BookFill{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testBook().EstimateFillWithin(tt.isBuy, tt.sz, tt.limitPx)
+			if err != nil {
+				t.Fatalf("EstimateFillWithin() error = %v", err)
+			}
+			if !closeTo(got.Sz, tt.want.Sz) || !closeTo(got.Notional, tt.want.Notional) || !closeTo(got.AvgPx, tt.want.AvgPx) ||
+				got.WorstPx != tt.want.WorstPx || math.Abs(got.SlippageBps-tt.want.SlippageBps) > 1e-3 {
+				t.Errorf("EstimateFillWithin() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	book := testBook()
+	book.Levels[1] = nil
+	if _, err := book.EstimateFill(true, 1); !errors.Is(err, ErrEmptyBook) {
+		t.Errorf("EstimateFill() on empty asks error = %v", err)
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
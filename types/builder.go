@@ -0,0 +1,68 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+const (
+	// MaxPerpBuilderFee is the highest builder fee of perp orders, in tenths of basis
+	// points (0.1%)
+	MaxPerpBuilderFee = 100
+	// MaxSpotBuilderFee is the highest builder fee of spot orders, in tenths of basis
+	// points (1%)
+	MaxSpotBuilderFee = 1000
+)
+
+// NewBuilderInfo returns the builder info paying address feeBps basis points of the
+// notional of each order, e.g. 2.5 for 0.025%. The fee must be a whole number of
+// tenths of basis points.
+func NewBuilderInfo(address string, feeBps float64) (*BuilderInfo, error) {
+	tenths := feeBps * 10
+	if math.IsNaN(tenths) || math.Abs(tenths-math.Round(tenths)) > 1e-9 {
+		return nil, fmt.Errorf("builder fee must be a multiple of 0.1 bps, got %v", feeBps)
+	}
+	builder := &BuilderInfo{B: strings.ToLower(address), F: int(math.Round(tenths))}
+	if err := builder.Validate(); err != nil {
+		return nil, err
+	}
+	return builder, nil
+}
+
+// Validate checks the builder address and that the fee is within 0 and
+// MaxSpotBuilderFee, the highest fee of any order
+func (b *BuilderInfo) Validate() error {
+	if err := utils.ValidateAddress(b.B); err != nil {
+		return fmt.Errorf("invalid builder address: %w", err)
+	}
+	if b.F < 0 || b.F > MaxSpotBuilderFee {
+		return fmt.Errorf("builder fee must be within 0 and %d tenths of bps, got %d", MaxSpotBuilderFee, b.F)
+	}
+	return nil
+}
+
+// ValidatePerp is like Validate for perp orders, whose fee is at most MaxPerpBuilderFee
+func (b *BuilderInfo) ValidatePerp() error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if b.F > MaxPerpBuilderFee {
+		return fmt.Errorf("builder fee of perp orders must be at most %d tenths of bps, got %d", MaxPerpBuilderFee, b.F)
+	}
+	return nil
+}
+
+// FeeBps returns the fee in basis points
+func (b *BuilderInfo) FeeBps() float64 {
+	return float64(b.F) / 10
+}
+
+// MaxFeeRate returns the fee as the percentage expected by Exchange.ApproveBuilderFee,
+// e.g. "0.025%"
+func (b *BuilderInfo) MaxFeeRate() string {
+	return strconv.FormatFloat(float64(b.F)/1000, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,39 @@
+package types
+
+import "testing"
+
+func TestNewBuilderInfo(t *testing.T) {
+	const address = "0x8C967E73E7B15087C42A10D344CFF4C96D877F1D"
+	builder, err := NewBuilderInfo(address, 2.5)
+	if err != nil {
+		t.Fatalf("NewBuilderInfo() error = %v", err)
+	}
+	if builder.B != "0x8c967e73e7b15087c42a10d344cff4c96d877f1d" || builder.F != 25 {
+		t.Errorf("NewBuilderInfo() = %+v", builder)
+	}
+	if builder.FeeBps() != 2.5 || builder.MaxFeeRate() != "0.025%" || builder.ValidatePerp() != nil {
+		t.Errorf("FeeBps() = %v, MaxFeeRate() = %s", builder.FeeBps(), builder.MaxFeeRate())
+	}
+
+	invalid := []struct {
+		address string
+		feeBps  float64
+	}{
+		{address, 0.25},
+		{address, -1},
+		{address, 100.1},
+		{"0x8c967e73", 1},
+		{"8c967e73e7b15087c42a10d344cff4c96d877f1d00", 1},
+		{"0xzz967e73e7b15087c42a10d344cff4c96d877f1d", 1},
+	}
+	for _, tt := range invalid {
+		if _, err := NewBuilderInfo(tt.address, tt.feeBps); err == nil {
+			t.Errorf("NewBuilderInfo(%q, %v) should fail", tt.address, tt.feeBps)
+		}
+	}
+
+	spot, err := NewBuilderInfo(address, 50)
+	if err != nil || spot.ValidatePerp() == nil {
+		t.Errorf("a fee of 50 bps is valid for spot only: %v, %v", err, spot.ValidatePerp())
+	}
+}
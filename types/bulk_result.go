@@ -0,0 +1,93 @@
+package types
+
+import "fmt"
+
+// BulkResult is the status of one order of a bulk order or modify, with the request
+// that produced it
+type BulkResult[R OrderRequest | ModifyRequest] struct {
+	// Index is the position of the request in the bulk request
+	Index   int
+	Request R
+	Status  OrderStatus
+}
+
+// OK reports whether the order was accepted, resting or filled
+func (r BulkResult[R]) OK() bool {
+	return r.Status.Error == ""
+}
+
+// Oid returns the id of the accepted order, false if it failed
+func (r BulkResult[R]) Oid() (int, bool) {
+	switch {
+	case r.Status.Resting != nil:
+		return r.Status.Resting.Oid, true
+	case r.Status.Filled != nil:
+		return r.Status.Filled.Oid, true
+	}
+	return 0, false
+}
+
+// BulkResults are the results of a bulk request, in the order of its requests
+type BulkResults[R OrderRequest | ModifyRequest] []BulkResult[R]
+
+// OrderResults are the results of Exchange.BulkOrders
+type OrderResults = BulkResults[OrderRequest]
+
+// ModifyResults are the results of Exchange.BulkModifyOrders
+type ModifyResults = BulkResults[ModifyRequest]
+
+// Succeeded returns the results of the accepted orders
+func (r BulkResults[R]) Succeeded() BulkResults[R] {
+	return r.filter(true)
+}
+
+// Failed returns the results of the rejected orders
+func (r BulkResults[R]) Failed() BulkResults[R] {
+	return r.filter(false)
+}
+
+// Requests returns the requests of the results, e.g. to retry the failed subset:
+//
+//	retry := results.Failed().Requests()
+func (r BulkResults[R]) Requests() []R {
+	requests := make([]R, len(r))
+	for i, result := range r {
+		requests[i] = result.Request
+	}
+	return requests
+}
+
+func (r BulkResults[R]) filter(ok bool) BulkResults[R] {
+	var out BulkResults[R]
+	for _, result := range r {
+		if result.OK() == ok {
+			out = append(out, result)
+		}
+	}
+	return out
+}
+
+// ZipStatuses pairs the statuses of a bulk response with the requests that produced
+// them, which the exchange returns in the same order
+func ZipStatuses[R OrderRequest | ModifyRequest](requests []R, statuses []OrderStatus) (BulkResults[R], error) {
+	if len(requests) != len(statuses) {
+		return nil, fmt.Errorf("got %d statuses for %d requests", len(statuses), len(requests))
+	}
+	results := make(BulkResults[R], len(requests))
+	for i := range requests {
+		results[i] = BulkResult[R]{Index: i, Request: requests[i], Status: statuses[i]}
+	}
+	return results, nil
+}
+
+// Results pairs the statuses of the response with the orders passed to
+// Exchange.BulkOrders
+func (r *OrderResponse) Results(orders []OrderRequest) (OrderResults, error) {
+	return ZipStatuses(orders, r.Data.Statuses)
+}
+
+// Results pairs the statuses of the response with the modifies passed to
+// Exchange.BulkModifyOrders
+func (r *ModifyResponse) Results(modifies []ModifyRequest) (ModifyResults, error) {
+	return ZipStatuses(modifies, r.Data.Statuses)
+}
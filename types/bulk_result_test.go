@@ -0,0 +1,60 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderResponseResults(t *testing.T) {
+	orders := []OrderRequest{
+		{Coin: "BTC", IsBuy: true, Sz: 0.01, LimitPx: 97000, OrderType: NewLimit(TifGtc), Cloid: NewCloidFromInt(1)},
+		{Coin: "ETH", IsBuy: false, Sz: 1, LimitPx: 3000, OrderType: NewLimit(TifIoc), Cloid: NewCloidFromInt(2)},
+		{Coin: "SOL", IsBuy: true, Sz: 0.1, LimitPx: 1, OrderType: NewLimit(TifGtc), Cloid: NewCloidFromInt(3)},
+	}
+	var response OrderResponse
+	data := `{"type":"order","data":{"statuses":[{"resting":{"oid":77738308}},{"filled":{"totalSz":"1","avgPx":"3000.5","oid":77738309}},{"error":"Order must have minimum value of $10. asset=5"}]}}`
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	results, err := response.Results(orders)
+	if err != nil {
+		t.Fatalf("Results() error = %v", err)
+	}
+	if oid, ok := results[0].Oid(); !ok || oid != 77738308 {
+		t.Errorf("Oid() of the resting order = %d, %v", oid, ok)
+	}
+	if oid, ok := results[1].Oid(); !ok || oid != 77738309 {
+		t.Errorf("Oid() of the filled order = %d, %v", oid, ok)
+	}
+
+	succeeded, failed := results.Succeeded(), results.Failed()
+	if len(succeeded) != 2 || succeeded[1].Request.Coin != "ETH" {
+		t.Errorf("Succeeded() = %+v", succeeded)
+	}
+	if len(failed) != 1 || failed[0].Index != 2 || failed[0].Request.Cloid.ToRaw() != orders[2].Cloid.ToRaw() {
+		t.Fatalf("Failed() = %+v", failed)
+	}
+	if _, ok := failed[0].Oid(); ok {
+		t.Error("Oid() of a failed order should be false")
+	}
+	if retry := failed.Requests(); len(retry) != 1 || retry[0].Coin != "SOL" {
+		t.Errorf("Requests() = %+v", retry)
+	}
+
+	if _, err := response.Results(orders[:2]); err == nil {
+		t.Error("Results() with fewer orders than statuses should fail")
+	}
+}
+
+func TestModifyResponseResults(t *testing.T) {
+	modifies := []ModifyRequest{{Oid: 1}, {Oid: 2}}
+	response := ModifyResponse{Data: ModifyDataBody{Statuses: []OrderStatus{{Error: "Cannot modify canceled or filled order"}, {Resting: &RestingOrder{Oid: 3}}}}}
+	results, err := response.Results(modifies)
+	if err != nil {
+		t.Fatalf("Results() error = %v", err)
+	}
+	if failed := results.Failed(); len(failed) != 1 || failed[0].Request.Oid != 1 {
+		t.Errorf("Failed() = %+v", failed)
+	}
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// OHLCV is the canonical form of a candle, with numeric prices and readable names,
+// converted from the Candle of candle snapshots and the ws.Candle of the candle feed
+type OHLCV struct {
+	Coin     string  `json:"coin"`
+	Interval string  `json:"interval"`
+	Start    int64   `json:"start"` // open millis
+	End      int64   `json:"end"`   // close millis
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	Volume   float64 `json:"volume"` // base unit
+	Trades   int     `json:"trades"`
+}
+
+// StartTime returns the open time of the candle
+func (c OHLCV) StartTime() time.Time {
+	return time.UnixMilli(c.Start)
+}
+
+// EndTime returns the close time of the candle
+func (c OHLCV) EndTime() time.Time {
+	return time.UnixMilli(c.End)
+}
+
+// OHLCV returns the candle in canonical form
+func (c Candle) OHLCV() (OHLCV, error) {
+	out := OHLCV{Coin: c.S, Interval: c.I, Start: c.T0, End: c.T, Trades: c.N}
+	fields := []struct {
+		name string
+		raw  string
+		dst  *float64
+	}{
+		{"open", c.O, &out.Open},
+		{"high", c.H, &out.High},
+		{"low", c.L, &out.Low},
+		{"close", c.C, &out.Close},
+		{"volume", c.V, &out.Volume},
+	}
+	for _, f := range fields {
+		v, err := parseFloat(f.raw)
+		if err != nil {
+			return OHLCV{}, fmt.Errorf("failed to parse candle %s: %w", f.name, err)
+		}
+		*f.dst = v
+	}
+	return out, nil
+}
+
+// CandlesToOHLCV converts candles of a snapshot to canonical form
+func CandlesToOHLCV(candles []Candle) ([]OHLCV, error) {
+	out := make([]OHLCV, len(candles))
+	for i, c := range candles {
+		converted, err := c.OHLCV()
+		if err != nil {
+			return nil, fmt.Errorf("candle %d: %w", i, err)
+		}
+		out[i] = converted
+	}
+	return out, nil
+}
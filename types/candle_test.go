@@ -0,0 +1,33 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCandleOHLCV(t *testing.T) {
+	var candles []Candle
+	data := `[{"T":1681924499999,"c":"29258.0","h":"29309.0","i":"15m","l":"29250.0","n":189,"o":"29295.0","s":"BTC","t":1681923600000,"v":"0.98639"}]`
+	if err := json.Unmarshal([]byte(data), &candles); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	got, err := CandlesToOHLCV(candles)
+	if err != nil {
+		t.Fatalf("CandlesToOHLCV() error = %v", err)
+	}
+	want := OHLCV{
+		Coin: "BTC", Interval: "15m", Start: 1681923600000, End: 1681924499999,
+		Open: 29295, High: 29309, Low: 29250, Close: 29258, Volume: 0.98639, Trades: 189,
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("CandlesToOHLCV() = %+v, want %+v", got, want)
+	}
+	if got[0].StartTime().UnixMilli() != want.Start || got[0].EndTime().UnixMilli() != want.End {
+		t.Errorf("StartTime() = %v, EndTime() = %v", got[0].StartTime(), got[0].EndTime())
+	}
+
+	candles[0].H = "bad"
+	if _, err := CandlesToOHLCV(candles); err == nil {
+		t.Error("CandlesToOHLCV() with an invalid high should fail")
+	}
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestNewCloidFromUUID(t *testing.T) {
+	for _, uuid := range []string{"123e4567-e89b-12d3-a456-426614174000", "123E4567E89B12D3A456426614174000", "{123e4567-e89b-12d3-a456-426614174000}"} {
+		cloid, err := NewCloidFromUUID(uuid)
+		if err != nil {
+			t.Fatalf("NewCloidFromUUID(%q) error = %v", uuid, err)
+		}
+		if cloid.ToRaw() != "0x123e4567e89b12d3a456426614174000" {
+			t.Errorf("NewCloidFromUUID(%q) = %s", uuid, cloid)
+		}
+	}
+	for _, uuid := range []string{"", "123e4567-e89b-12d3-a456", "zz3e4567-e89b-12d3-a456-426614174000"} {
+		if _, err := NewCloidFromUUID(uuid); err == nil {
+			t.Errorf("NewCloidFromUUID(%q) should fail", uuid)
+		}
+	}
+}
+
+func TestNewRandomCloid(t *testing.T) {
+	a, b := NewRandomCloid(), NewRandomCloid()
+	if a.ToRaw() == b.ToRaw() {
+		t.Errorf("two random cloids are %s", a)
+	}
+	if _, err := NewCloidFromString(a.ToRaw()); err != nil {
+		t.Errorf("random cloid %s is invalid: %v", a, err)
+	}
+}
+
+func TestCloidGenerator(t *testing.T) {
+	if _, err := NewCloidGenerator(make([]byte, MaxCloidPrefixLen+1)); err == nil {
+		t.Error("NewCloidGenerator() with a long prefix should fail")
+	}
+
+	gen, err := NewCloidGenerator([]byte("mm"))
+	if err != nil {
+		t.Fatalf("NewCloidGenerator() error = %v", err)
+	}
+	other, _ := NewCloidGenerator([]byte("mm"))
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cloid := gen.Next()
+				mu.Lock()
+				seen[cloid.ToRaw()] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 400 {
+		t.Errorf("%d distinct cloids, want 400", len(seen))
+	}
+
+	cloid := gen.Next()
+	if b := cloid.Bytes(); b[15] != 145 || b[14] != 1 {
+		t.Errorf("counter of the 401st cloid = %x", b[8:])
+	}
+	if !cloid.HasPrefix([]byte("mm")) || !gen.Generated(cloid) || other.Generated(cloid) {
+		t.Errorf("cloid %s is not traced to its generator", cloid)
+	}
+	if string(gen.Prefix()) != "mm" {
+		t.Errorf("Prefix() = %q", gen.Prefix())
+	}
+}
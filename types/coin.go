@@ -0,0 +1,100 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CoinRef identifies a coin in any of the forms the API accepts:
+//
+//	"BTC"         perp of the default dex
+//	"xyz:XYZ100"  perp of the builder-deployed dex "xyz"
+//	"PURR/USDC"   spot pair by name
+//	"@107"        spot pair by index
+//
+// String renders it back to that form, which is what orders, info queries and
+// subscriptions send, so a CoinRef can be passed wherever a coin name is expected.
+type CoinRef struct {
+	// Dex is the builder-deployed perp dex of the coin, "" for the default dex and spot
+	Dex string
+	// Coin is the name of the coin on its dex, e.g. "XYZ100" or "@107"
+	Coin string
+}
+
+// ParseCoinRef parses a coin name, see CoinRef
+func ParseCoinRef(s string) (CoinRef, error) {
+	var ref CoinRef
+	if dex, coin, ok := strings.Cut(s, ":"); ok {
+		if dex == "" || coin == "" {
+			return CoinRef{}, fmt.Errorf("invalid coin: %q", s)
+		}
+		ref = CoinRef{Dex: dex, Coin: coin}
+	} else {
+		ref = CoinRef{Coin: s}
+	}
+
+	if ref.Coin == "" || strings.ContainsAny(ref.Coin, ": ") {
+		return CoinRef{}, fmt.Errorf("invalid coin: %q", s)
+	}
+	if ref.Coin[0] == '@' {
+		if ref.Dex != "" {
+			return CoinRef{}, fmt.Errorf("spot coin %q cannot have a dex", s)
+		}
+		if _, err := strconv.ParseUint(ref.Coin[1:], 10, 32); err != nil {
+			return CoinRef{}, fmt.Errorf("invalid spot index in coin %q", s)
+		}
+	}
+	return ref, nil
+}
+
+// MustParseCoinRef is like ParseCoinRef but panics on invalid names
+func MustParseCoinRef(s string) CoinRef {
+	ref, err := ParseCoinRef(s)
+	if err != nil {
+		panic(err)
+	}
+	return ref
+}
+
+// SpotCoinRef returns the reference of the spot pair with index, e.g. "@107"
+func SpotCoinRef(index int) CoinRef {
+	return CoinRef{Coin: "@" + strconv.Itoa(index)}
+}
+
+// String returns the wire form of the coin, e.g. "xyz:XYZ100"
+func (c CoinRef) String() string {
+	if c.Dex == "" {
+		return c.Coin
+	}
+	return c.Dex + ":" + c.Coin
+}
+
+// SpotIndex returns the index of a spot pair referenced as "@index"
+func (c CoinRef) SpotIndex() (int, bool) {
+	if c.Dex != "" || !strings.HasPrefix(c.Coin, "@") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(c.Coin[1:])
+	return index, err == nil
+}
+
+// IsSpot reports whether the coin is a spot pair, referenced as "@index" or "BASE/QUOTE"
+func (c CoinRef) IsSpot() bool {
+	return c.Dex == "" && (strings.HasPrefix(c.Coin, "@") || strings.Contains(c.Coin, "/"))
+}
+
+// MarshalText encodes the coin in wire form
+func (c CoinRef) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText parses the coin, see ParseCoinRef
+func (c *CoinRef) UnmarshalText(text []byte) error {
+	ref, err := ParseCoinRef(string(text))
+	if err != nil {
+		return err
+	}
+	*c = ref
+	return nil
+}
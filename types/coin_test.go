@@ -0,0 +1,73 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseCoinRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    CoinRef
+		spot    bool
+		wantErr bool
+	}{
+		{in: "BTC", want: CoinRef{Coin: "BTC"}},
+		{in: "xyz:XYZ100", want: CoinRef{Dex: "xyz", Coin: "XYZ100"}},
+		{in: "PURR/USDC", want: CoinRef{Coin: "PURR/USDC"}, spot: true},
+		{in: "@107", want: CoinRef{Coin: "@107"}, spot: true},
+		{in: "", wantErr: true},
+		{in: ":BTC", wantErr: true},
+		{in: "xyz:", wantErr: true},
+		{in: "a:b:c", wantErr: true},
+		{in: "xyz:@1", wantErr: true},
+		{in: "@", wantErr: true},
+		{in: "@-1", wantErr: true},
+		{in: "@1x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseCoinRef(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCoinRef(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want || got.String() != tt.in || got.IsSpot() != tt.spot {
+				t.Errorf("ParseCoinRef(%q) = %+v (%s), spot %v", tt.in, got, got, got.IsSpot())
+			}
+		})
+	}
+}
+
+func TestCoinRefSpotIndex(t *testing.T) {
+	ref := SpotCoinRef(107)
+	if index, ok := ref.SpotIndex(); !ok || index != 107 || ref.String() != "@107" {
+		t.Errorf("SpotIndex() = %d, %v", index, ok)
+	}
+	if _, ok := MustParseCoinRef("PURR/USDC").SpotIndex(); ok {
+		t.Error("SpotIndex() of a pair name should be false")
+	}
+}
+
+func TestCoinRefJSON(t *testing.T) {
+	var v struct {
+		Coins []CoinRef          `json:"coins"`
+		ByRef map[CoinRef]string `json:"byRef"`
+	}
+	data := `{"coins":["BTC","xyz:XYZ100","@1"],"byRef":{"xyz:TSLA":"stock"}}`
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(v.Coins) != 3 || v.Coins[1].Dex != "xyz" || v.ByRef[CoinRef{Dex: "xyz", Coin: "TSLA"}] != "stock" {
+		t.Errorf("decoded %+v", v)
+	}
+	out, err := json.Marshal(v)
+	if err != nil || string(out) != data {
+		t.Errorf("Marshal() = %s, %v", out, err)
+	}
+	if err := json.Unmarshal([]byte(`{"coins":["a:b:c"]}`), &v); err == nil {
+		t.Error("Unmarshal() of an invalid coin should fail")
+	}
+}
@@ -0,0 +1,382 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, for the prices, sizes and balances that the API
+// sends as strings and that float64 cannot represent exactly, e.g. 0.1 + 0.2 == 0.3.
+// The zero value is 0. Decimals are immutable: operations return new values.
+type Decimal struct {
+	// coef * 10^-scale is the value, nil coef is 0
+	coef  *big.Int
+	scale int32
+}
+
+var bigTen = big.NewInt(10)
+
+// NewDecimal returns value * 10^exp, e.g. NewDecimal(15, -1) is 1.5
+func NewDecimal(value int64, exp int32) Decimal {
+	d := Decimal{coef: big.NewInt(value)}
+	if exp >= 0 {
+		d.coef.Mul(d.coef, pow10(exp))
+		return d
+	}
+	d.scale = -exp
+	return d
+}
+
+// ParseDecimal parses a decimal number such as "-12.50" or "1e-8"
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+		}
+		mantissa = s[:i]
+	}
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if intPart+fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+	}
+	coef, _ := new(big.Int).SetString(sign+intPart+fracPart, 10)
+	scale := int64(len(fracPart)) - exp
+	if scale < 0 {
+		coef.Mul(coef, pow10(int32(-scale)))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is invalid, for constants
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DecimalFromFloat returns the shortest decimal that parses back to f, e.g. 0.1 for 0.1
+func DecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		// NaN and infinities
+		return Decimal{}
+	}
+	return d
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescale returns the coefficient of d at a larger scale
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+// align returns the coefficients of d and other at their common scale
+func (d Decimal) align(other Decimal) (*big.Int, *big.Int, int32) {
+	scale := max(d.scale, other.scale)
+	return d.rescale(scale), other.rescale(scale), scale
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := d.align(other)
+	return Decimal{coef: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := d.align(other)
+	return Decimal{coef: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul returns d * other
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), other.int()), scale: d.scale + other.scale}
+}
+
+// Div returns d / other rounded half away from zero to places decimal places.
+// It panics if other is 0.
+func (d Decimal) Div(other Decimal, places int32) Decimal {
+	if other.Sign() == 0 {
+		panic("decimal division by zero")
+	}
+	// d / other = (d.coef * 10^(places + other.scale - d.scale)) / other.coef * 10^-places,
+	// computed with one more digit to round
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(other.int())
+	if shift := places + 1 + other.scale - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	quo := new(big.Int).Quo(num, den)
+	return Decimal{coef: quo, scale: places + 1}.Round(places)
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns the absolute value of d
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of d
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp returns -1, 0 or 1 if d is less than, equal to or greater than other
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := d.align(other)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and other have the same value, e.g. 1.5 and 1.50
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Round rounds d half away from zero to places decimal places
+func (d Decimal) Round(places int32) Decimal {
+	if places >= d.scale {
+		return d
+	}
+	unit := pow10(d.scale - places)
+	quo, rem := new(big.Int).QuoRem(d.int(), unit, new(big.Int))
+	// Round up when the remainder is at least half the unit
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(unit) >= 0 {
+		quo.Add(quo, big.NewInt(int64(d.Sign())))
+	}
+	return Decimal{coef: quo, scale: places}
+}
+
+// Truncate rounds d toward zero to places decimal places
+func (d Decimal) Truncate(places int32) Decimal {
+	if places >= d.scale {
+		return d
+	}
+	return Decimal{coef: new(big.Int).Quo(d.int(), pow10(d.scale-places)), scale: places}
+}
+
+// Float64 returns the float64 closest to d
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d without exponent and trailing zeros, as the API expects, e.g. "1.5"
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	frac := strings.TrimRight(digits[point:], "0")
+	if frac == "" {
+		if digits[:point] == "0" {
+			return "0"
+		}
+		return sign + digits[:point]
+	}
+	return sign + digits[:point] + "." + frac
+}
+
+// MarshalJSON encodes d as a string, like the API
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes a string or a number, null as 0
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Decimal accessors of the monetary fields that the API sends as strings
+
+// PxDecimal returns the price of the level
+func (l L2Level) PxDecimal() (Decimal, error) {
+	return ParseDecimal(l.Px)
+}
+
+// SzDecimal returns the size of the level
+func (l L2Level) SzDecimal() (Decimal, error) {
+	return ParseDecimal(l.Sz)
+}
+
+// PxDecimal returns the price of the fill
+func (f Fill) PxDecimal() (Decimal, error) {
+	return ParseDecimal(f.Px)
+}
+
+// SzDecimal returns the size of the fill
+func (f Fill) SzDecimal() (Decimal, error) {
+	return ParseDecimal(f.Sz)
+}
+
+// FeeDecimal returns the fee of the fill, in FeeToken
+func (f Fill) FeeDecimal() (Decimal, error) {
+	return ParseDecimal(f.Fee)
+}
+
+// ClosedPnlDecimal returns the realized PnL of the fill
+func (f Fill) ClosedPnlDecimal() (Decimal, error) {
+	return ParseDecimal(f.ClosedPnl)
+}
+
+// LimitPxDecimal returns the limit price of the order
+func (o OpenOrder) LimitPxDecimal() (Decimal, error) {
+	return ParseDecimal(o.LimitPx)
+}
+
+// SzDecimal returns the remaining size of the order
+func (o OpenOrder) SzDecimal() (Decimal, error) {
+	return ParseDecimal(o.Sz)
+}
+
+// LimitPxDecimal returns the limit price of the order
+func (o FrontendOpenOrder) LimitPxDecimal() (Decimal, error) {
+	return ParseDecimal(o.LimitPx)
+}
+
+// SzDecimal returns the remaining size of the order
+func (o FrontendOpenOrder) SzDecimal() (Decimal, error) {
+	return ParseDecimal(o.Sz)
+}
+
+// OrigSzDecimal returns the original size of the order
+func (o FrontendOpenOrder) OrigSzDecimal() (Decimal, error) {
+	return ParseDecimal(o.OrigSz)
+}
+
+// SziDecimal returns the signed size of the position, negative for shorts
+func (p Position) SziDecimal() (Decimal, error) {
+	return ParseDecimal(p.Szi)
+}
+
+// EntryPxDecimal returns the entry price of the position, 0 if there is none
+func (p Position) EntryPxDecimal() (Decimal, error) {
+	return parseOptionalDecimal(p.EntryPx)
+}
+
+// LiquidationPxDecimal returns the liquidation price of the position, 0 if there is none
+func (p Position) LiquidationPxDecimal() (Decimal, error) {
+	return parseOptionalDecimal(p.LiquidationPx)
+}
+
+// PositionValueDecimal returns the notional value of the position
+func (p Position) PositionValueDecimal() (Decimal, error) {
+	return ParseDecimal(p.PositionValue)
+}
+
+// UnrealizedPnlDecimal returns the unrealized PnL of the position
+func (p Position) UnrealizedPnlDecimal() (Decimal, error) {
+	return ParseDecimal(p.UnrealizedPnl)
+}
+
+// MarginUsedDecimal returns the margin used by the position
+func (p Position) MarginUsedDecimal() (Decimal, error) {
+	return ParseDecimal(p.MarginUsed)
+}
+
+// AccountValueDecimal returns the account value
+func (m MarginSummary) AccountValueDecimal() (Decimal, error) {
+	return ParseDecimal(m.AccountValue)
+}
+
+// TotalMarginUsedDecimal returns the margin used by all positions
+func (m MarginSummary) TotalMarginUsedDecimal() (Decimal, error) {
+	return ParseDecimal(m.TotalMarginUsed)
+}
+
+// TotalNtlPosDecimal returns the notional value of all positions
+func (m MarginSummary) TotalNtlPosDecimal() (Decimal, error) {
+	return ParseDecimal(m.TotalNtlPos)
+}
+
+// WithdrawableDecimal returns the amount that can be withdrawn
+func (s UserState) WithdrawableDecimal() (Decimal, error) {
+	return ParseDecimal(s.Withdrawable)
+}
+
+// TotalDecimal returns the total balance
+func (b SpotBalance) TotalDecimal() (Decimal, error) {
+	return ParseDecimal(b.Total)
+}
+
+// HoldDecimal returns the balance held by open orders
+func (b SpotBalance) HoldDecimal() (Decimal, error) {
+	return ParseDecimal(b.Hold)
+}
+
+// EntryNtlDecimal returns the notional value of the balance at entry
+func (b SpotBalance) EntryNtlDecimal() (Decimal, error) {
+	return ParseDecimal(b.EntryNtl)
+}
+
+func parseOptionalDecimal(s *string) (Decimal, error) {
+	if s == nil {
+		return Decimal{}, nil
+	}
+	return ParseDecimal(*s)
+}
@@ -0,0 +1,128 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"0", "0", false},
+		{"-12.50", "-12.5", false},
+		{"+3", "3", false},
+		{".5", "0.5", false},
+		{"0.00012300", "0.000123", false},
+		{"1e-8", "0.00000001", false},
+		{"1.5E3", "1500", false},
+		{"-0.0", "0", false},
+		{"", "", true},
+		{"-", "", true},
+		{"1.2.3", "", true},
+		{"1-2", "", true},
+		{"abc", "", true},
+		{"1e", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDecimal(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDecimal(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustParseDecimal
+	if got := d("0.1").Add(d("0.2")); !got.Equal(d("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s", got)
+	}
+	if got := d("1.05").Sub(d("2")); got.String() != "-0.95" {
+		t.Errorf("1.05 - 2 = %s", got)
+	}
+	if got := d("1.5").Mul(d("-0.02")); got.String() != "-0.03" {
+		t.Errorf("1.5 * -0.02 = %s", got)
+	}
+	if got := d("10").Div(d("3"), 4); got.String() != "3.3333" {
+		t.Errorf("10 / 3 = %s", got)
+	}
+	if got := d("-2").Div(d("3"), 2); got.String() != "-0.67" {
+		t.Errorf("-2 / 3 = %s", got)
+	}
+	if got := NewDecimal(15, -1); !got.Equal(d("1.50")) || got.Cmp(d("1.49")) != 1 {
+		t.Errorf("NewDecimal(15, -1) = %s", got)
+	}
+	if got := NewDecimal(2, 3); got.String() != "2000" {
+		t.Errorf("NewDecimal(2, 3) = %s", got)
+	}
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" || !zero.Add(d("1")).Equal(d("1")) {
+		t.Errorf("zero value = %s", zero)
+	}
+	if got := DecimalFromFloat(0.1); got.String() != "0.1" {
+		t.Errorf("DecimalFromFloat(0.1) = %s", got)
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in       string
+		places   int32
+		round    string
+		truncate string
+	}{
+		{"1.25", 1, "1.3", "1.2"},
+		{"-1.25", 1, "-1.3", "-1.2"},
+		{"1.249", 2, "1.25", "1.24"},
+		{"-0.05", 1, "-0.1", "0"},
+		{"123.456", 0, "123", "123"},
+		{"7", 2, "7", "7"},
+	}
+	for _, tt := range tests {
+		d := MustParseDecimal(tt.in)
+		if got := d.Round(tt.places); got.String() != tt.round {
+			t.Errorf("%s.Round(%d) = %s, want %s", tt.in, tt.places, got, tt.round)
+		}
+		if got := d.Truncate(tt.places); got.String() != tt.truncate {
+			t.Errorf("%s.Truncate(%d) = %s, want %s", tt.in, tt.places, got, tt.truncate)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		Px  Decimal `json:"px"`
+		Sz  Decimal `json:"sz"`
+		Fee Decimal `json:"fee"`
+	}
+	if err := json.Unmarshal([]byte(`{"px":"97000.5","sz":0.0012,"fee":null}`), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if v.Px.String() != "97000.5" || v.Sz.String() != "0.0012" || !v.Fee.IsZero() {
+		t.Errorf("decoded %s %s %s", v.Px, v.Sz, v.Fee)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"px":"97000.5","sz":"0.0012","fee":"0"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	fill := Fill{Px: "100.1", Sz: "0.3"}
+	px, _ := fill.PxDecimal()
+	sz, _ := fill.SzDecimal()
+	if notional := px.Mul(sz); notional.String() != "30.03" {
+		t.Errorf("notional = %s, want 30.03", notional)
+	}
+	if entry, err := (Position{}).EntryPxDecimal(); err != nil || !entry.IsZero() {
+		t.Errorf("EntryPxDecimal() without entry = %s, %v", entry, err)
+	}
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DailyUserVolume is the volume of a day in UserFees. Rates and volumes are decimal
+// strings; UserCross is the taker volume of the user, UserAdd their maker volume and
+// Exchange the volume of the whole exchange.
+type DailyUserVolume struct {
+	Date      string `json:"date"`
+	UserCross string `json:"userCross"`
+	UserAdd   string `json:"userAdd"`
+	Exchange  string `json:"exchange"`
+}
+
+// FeeSchedule is the fee schedule in UserFees. Rates are fractions of the notional,
+// e.g. "0.00045", negative for rebates.
+type FeeSchedule struct {
+	Cross            string   `json:"cross"`
+	Add              string   `json:"add"`
+	SpotCross        string   `json:"spotCross"`
+	SpotAdd          string   `json:"spotAdd"`
+	Tiers            FeeTiers `json:"tiers"`
+	ReferralDiscount string   `json:"referralDiscount"`
+}
+
+// FeeTiers are the volume tiers and the maker rebate tiers of the fee schedule
+type FeeTiers struct {
+	VIP []VIPFeeTier   `json:"vip"`
+	MM  []MakerFeeTier `json:"mm"`
+}
+
+// VIPFeeTier is a tier of accounts whose 14 day volume is at least NtlCutoff
+type VIPFeeTier struct {
+	NtlCutoff string `json:"ntlCutoff"`
+	Cross     string `json:"cross"`
+	Add       string `json:"add"`
+	SpotCross string `json:"spotCross"`
+	SpotAdd   string `json:"spotAdd"`
+}
+
+// MakerFeeTier is a tier of accounts whose share of the maker volume of the exchange
+// is at least MakerFractionCutoff
+type MakerFeeTier struct {
+	MakerFractionCutoff string `json:"makerFractionCutoff"`
+	Add                 string `json:"add"`
+}
+
+// DailyVolumes decodes DailyUserVlm, oldest first
+func (f *UserFees) DailyVolumes() ([]DailyUserVolume, error) {
+	volumes := make([]DailyUserVolume, 0, len(f.DailyUserVlm))
+	for _, raw := range f.DailyUserVlm {
+		var v DailyUserVolume
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("failed to decode daily user volume: %w", err)
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, nil
+}
+
+// Schedule decodes FeeSchedule
+func (f *UserFees) Schedule() (*FeeSchedule, error) {
+	if len(f.FeeSchedule) == 0 {
+		return nil, fmt.Errorf("user fees have no fee schedule")
+	}
+	var schedule FeeSchedule
+	if err := json.Unmarshal(f.FeeSchedule, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to decode fee schedule: %w", err)
+	}
+	return &schedule, nil
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUserFeesDecode(t *testing.T) {
+	data := `{
+		"dailyUserVlm": [
+			{"date": "2026-03-01", "userCross": "1200.5", "userAdd": "300.0", "exchange": "2000000000.0"},
+			{"date": "2026-03-02", "userCross": "0.0", "userAdd": "50.0", "exchange": "1800000000.0"}
+		],
+		"feeSchedule": {
+			"cross": "0.00045", "add": "0.00015", "spotCross": "0.0007", "spotAdd": "0.0004",
+			"tiers": {
+				"vip": [{"ntlCutoff": "5000000.0", "cross": "0.0004", "add": "0.00012", "spotCross": "0.0006", "spotAdd": "0.0003"}],
+				"mm": [{"makerFractionCutoff": "0.005", "add": "-0.00001"}]
+			},
+			"referralDiscount": "0.04"
+		},
+		"userCrossRate": "0.000315",
+		"userAddRate": "0.000105"
+	}`
+	var fees UserFees
+	if err := json.Unmarshal([]byte(data), &fees); err != nil {
+		t.Fatal(err)
+	}
+
+	volumes, err := fees.DailyVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes) != 2 || volumes[0].Date != "2026-03-01" || volumes[0].UserCross != "1200.5" || volumes[1].UserAdd != "50.0" {
+		t.Errorf("DailyVolumes() = %+v", volumes)
+	}
+
+	schedule, err := fees.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.Cross != "0.00045" || schedule.ReferralDiscount != "0.04" {
+		t.Errorf("Schedule() = %+v", schedule)
+	}
+	if len(schedule.Tiers.VIP) != 1 || schedule.Tiers.VIP[0].NtlCutoff != "5000000.0" || schedule.Tiers.VIP[0].SpotAdd != "0.0003" {
+		t.Errorf("VIP tiers = %+v", schedule.Tiers.VIP)
+	}
+	if len(schedule.Tiers.MM) != 1 || schedule.Tiers.MM[0].Add != "-0.00001" {
+		t.Errorf("MM tiers = %+v", schedule.Tiers.MM)
+	}
+}
+
+func TestUserFeesDecodeErrors(t *testing.T) {
+	if _, err := (&UserFees{}).Schedule(); err == nil {
+		t.Error("Schedule() of empty user fees should fail")
+	}
+	fees := UserFees{DailyUserVlm: []RawJSON{RawJSON(`[1]`)}, FeeSchedule: RawJSON(`"x"`)}
+	if _, err := fees.DailyVolumes(); err == nil {
+		t.Error("DailyVolumes() of a bad entry should fail")
+	}
+	if _, err := fees.Schedule(); err == nil {
+		t.Error("Schedule() of a bad schedule should fail")
+	}
+}
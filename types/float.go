@@ -0,0 +1,178 @@
+package types
+
+import "strconv"
+
+// Float accessors of the numeric fields that the API sends as strings. Prefer the
+// Decimal accessors for accounting and order sizing.
+
+// PxFloat returns the price of the level
+func (l L2Level) PxFloat() (float64, error) {
+	return parseFloat(l.Px)
+}
+
+// SzFloat returns the size of the level
+func (l L2Level) SzFloat() (float64, error) {
+	return parseFloat(l.Sz)
+}
+
+// PxFloat returns the price of the fill
+func (f Fill) PxFloat() (float64, error) {
+	return parseFloat(f.Px)
+}
+
+// SzFloat returns the size of the fill
+func (f Fill) SzFloat() (float64, error) {
+	return parseFloat(f.Sz)
+}
+
+// FeeFloat returns the fee of the fill, in FeeToken
+func (f Fill) FeeFloat() (float64, error) {
+	return parseFloat(f.Fee)
+}
+
+// ClosedPnlFloat returns the realized PnL of the fill
+func (f Fill) ClosedPnlFloat() (float64, error) {
+	return parseFloat(f.ClosedPnl)
+}
+
+// LimitPxFloat returns the limit price of the order
+func (o OpenOrder) LimitPxFloat() (float64, error) {
+	return parseFloat(o.LimitPx)
+}
+
+// SzFloat returns the remaining size of the order
+func (o OpenOrder) SzFloat() (float64, error) {
+	return parseFloat(o.Sz)
+}
+
+// LimitPxFloat returns the limit price of the order
+func (o FrontendOpenOrder) LimitPxFloat() (float64, error) {
+	return parseFloat(o.LimitPx)
+}
+
+// SzFloat returns the remaining size of the order
+func (o FrontendOpenOrder) SzFloat() (float64, error) {
+	return parseFloat(o.Sz)
+}
+
+// OrigSzFloat returns the original size of the order
+func (o FrontendOpenOrder) OrigSzFloat() (float64, error) {
+	return parseFloat(o.OrigSz)
+}
+
+// SziFloat returns the signed size of the position, negative for shorts
+func (p Position) SziFloat() (float64, error) {
+	return parseFloat(p.Szi)
+}
+
+// EntryPxFloat returns the entry price of the position, 0 if there is none
+func (p Position) EntryPxFloat() (float64, error) {
+	return parseOptionalFloat(p.EntryPx)
+}
+
+// LiquidationPxFloat returns the liquidation price of the position, 0 if there is none
+func (p Position) LiquidationPxFloat() (float64, error) {
+	return parseOptionalFloat(p.LiquidationPx)
+}
+
+// PositionValueFloat returns the notional value of the position
+func (p Position) PositionValueFloat() (float64, error) {
+	return parseFloat(p.PositionValue)
+}
+
+// UnrealizedPnlFloat returns the unrealized PnL of the position
+func (p Position) UnrealizedPnlFloat() (float64, error) {
+	return parseFloat(p.UnrealizedPnl)
+}
+
+// MarginUsedFloat returns the margin used by the position
+func (p Position) MarginUsedFloat() (float64, error) {
+	return parseFloat(p.MarginUsed)
+}
+
+// AccountValueFloat returns the account value
+func (m MarginSummary) AccountValueFloat() (float64, error) {
+	return parseFloat(m.AccountValue)
+}
+
+// TotalMarginUsedFloat returns the margin used by all positions
+func (m MarginSummary) TotalMarginUsedFloat() (float64, error) {
+	return parseFloat(m.TotalMarginUsed)
+}
+
+// TotalNtlPosFloat returns the notional value of all positions
+func (m MarginSummary) TotalNtlPosFloat() (float64, error) {
+	return parseFloat(m.TotalNtlPos)
+}
+
+// WithdrawableFloat returns the amount that can be withdrawn
+func (s UserState) WithdrawableFloat() (float64, error) {
+	return parseFloat(s.Withdrawable)
+}
+
+// CrossMaintenanceMarginUsedFloat returns the maintenance margin of the cross
+// positions
+func (s UserState) CrossMaintenanceMarginUsedFloat() (float64, error) {
+	return parseFloat(s.CrossMaintenanceMarginUsed)
+}
+
+// TotalFloat returns the total balance
+func (b SpotBalance) TotalFloat() (float64, error) {
+	return parseFloat(b.Total)
+}
+
+// HoldFloat returns the balance held by open orders
+func (b SpotBalance) HoldFloat() (float64, error) {
+	return parseFloat(b.Hold)
+}
+
+// EntryNtlFloat returns the notional value of the balance at entry
+func (b SpotBalance) EntryNtlFloat() (float64, error) {
+	return parseFloat(b.EntryNtl)
+}
+
+// FundingFloat returns the hourly funding rate of the asset
+func (c PerpAssetCtx) FundingFloat() (float64, error) {
+	return parseFloat(c.Funding)
+}
+
+// MarkPxFloat returns the mark price of the asset
+func (c PerpAssetCtx) MarkPxFloat() (float64, error) {
+	return parseFloat(c.MarkPx)
+}
+
+// MidPxFloat returns the mid price of the asset
+func (c PerpAssetCtx) MidPxFloat() (float64, error) {
+	return parseFloat(c.MidPx)
+}
+
+// OraclePxFloat returns the oracle price of the asset
+func (c PerpAssetCtx) OraclePxFloat() (float64, error) {
+	return parseFloat(c.OraclePx)
+}
+
+// OpenInterestFloat returns the open interest of the asset, in coins
+func (c PerpAssetCtx) OpenInterestFloat() (float64, error) {
+	return parseFloat(c.OpenInterest)
+}
+
+// MarkPxFloat returns the mark price of the token
+func (c SpotAssetCtx) MarkPxFloat() (float64, error) {
+	return parseFloat(c.MarkPx)
+}
+
+// MidPxFloat returns the mid price of the token, 0 if there is none
+func (c SpotAssetCtx) MidPxFloat() (float64, error) {
+	return parseOptionalFloat(c.MidPx)
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseOptionalFloat(s *string) (float64, error) {
+	if s == nil {
+		return 0, nil
+	}
+	return parseFloat(*s)
+}
@@ -0,0 +1,23 @@
+package types
+
+import "testing"
+
+func TestFloatAccessors(t *testing.T) {
+	entry := "97000.5"
+	position := Position{Szi: "-0.25", EntryPx: &entry, UnrealizedPnl: "abc"}
+	if szi, err := position.SziFloat(); err != nil || szi != -0.25 {
+		t.Errorf("SziFloat() = %v, %v", szi, err)
+	}
+	if px, err := position.EntryPxFloat(); err != nil || px != 97000.5 {
+		t.Errorf("EntryPxFloat() = %v, %v", px, err)
+	}
+	if px, err := position.LiquidationPxFloat(); err != nil || px != 0 {
+		t.Errorf("LiquidationPxFloat() without liquidation price = %v, %v", px, err)
+	}
+	if _, err := position.UnrealizedPnlFloat(); err == nil {
+		t.Error("UnrealizedPnlFloat() of an invalid number should fail")
+	}
+	if value, err := (MarginSummary{AccountValue: "1234.5"}).AccountValueFloat(); err != nil || value != 1234.5 {
+		t.Errorf("AccountValueFloat() = %v, %v", value, err)
+	}
+}
@@ -0,0 +1,150 @@
+package types
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// goldenFixture decodes a response recorded from the API in testdata
+type goldenFixture struct {
+	file string
+	test func(t *testing.T, data []byte)
+}
+
+func golden[T any](file string, check func(t *testing.T, v T)) goldenFixture {
+	return goldenFixture{file: file, test: func(t *testing.T, data []byte) {
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		check(t, v)
+	}}
+}
+
+func TestGoldenFixtures(t *testing.T) {
+	fixtures := []goldenFixture{
+		golden("clearinghouse_state.json", func(t *testing.T, v UserState) {
+			if len(v.AssetPositions) != 2 || v.Withdrawable != "11478.062767" || v.MarginSummary.TotalRawUsd != "-2204.713284" ||
+				v.CrossMaintenanceMarginUsed != "460.473187" {
+				t.Errorf("user state = %+v", v)
+			}
+			btc, eth := v.AssetPositions[0].Position, v.AssetPositions[1].Position
+			if btc.LiquidationPx != nil || btc.Leverage.Type != "cross" || btc.Leverage.RawUsd != nil {
+				t.Errorf("cross position = %+v", btc)
+			}
+			if eth.Leverage.RawUsd == nil || *eth.Leverage.RawUsd != "1750.0" || eth.LiquidationPx == nil || eth.Szi != "-0.5" {
+				t.Errorf("isolated position = %+v", eth)
+			}
+		}),
+		golden("spot_clearinghouse_state.json", func(t *testing.T, v SpotUserState) {
+			if len(v.Balances) != 2 || v.Balances[1].Token != 150 || v.Balances[1].EntryNtl != "489.204688" {
+				t.Errorf("spot state = %+v", v)
+			}
+		}),
+		golden("user_fills.json", func(t *testing.T, v []Fill) {
+			if len(v) != 3 {
+				t.Fatalf("%d fills", len(v))
+			}
+			if v[0].Dir != DirOpenLong || v[0].Tid != 118906512037719 || v[0].BuilderFee == nil || v[0].Liquidation != nil {
+				t.Errorf("fill = %+v", v[0])
+			}
+			if v[1].Liquidation == nil || v[1].Liquidation.MarkPx != 2990 || v[1].Liquidation.Method != "market" || v[1].Side != SideAsk {
+				t.Errorf("liquidation fill = %+v", v[1])
+			}
+			if !v[2].Dir.IsSpot() || v[2].FeeToken != "HYPE" {
+				t.Errorf("spot fill = %+v", v[2])
+			}
+		}),
+		golden("frontend_open_orders.json", func(t *testing.T, v []FrontendOpenOrder) {
+			if len(v) != 2 || v[0].OrderType != "Limit" || !v[1].IsTrigger || v[1].TriggerPx != "91000.0" || v[1].Side != SideAsk {
+				t.Errorf("open orders = %+v", v)
+			}
+		}),
+		golden("ledger_updates.json", func(t *testing.T, v []NonFundingLedgerUpdate) {
+			want := []LedgerDeltaType{LedgerDeposit, LedgerAccountClassTransfer, LedgerSpotTransfer, LedgerVaultDeposit, LedgerVaultWithdraw, LedgerWithdraw, LedgerLiquidation}
+			if len(v) != len(want) {
+				t.Fatalf("%d updates, want %d", len(v), len(want))
+			}
+			for i, update := range v {
+				if update.Delta.Variant().LedgerType() != want[i] {
+					t.Errorf("update %d = %+v, want %s", i, update, want[i])
+				}
+			}
+			if withdraw, ok := v[5].Delta.Variant().(WithdrawDelta); !ok || withdraw.Nonce != 1733900499000 {
+				t.Errorf("withdraw = %+v", v[5].Delta)
+			}
+		}),
+		golden("vault_details.json", func(t *testing.T, v VaultDetails) {
+			if v.Leader != "0x677d831aef5328190852e24f13c46cac05f984e7" || len(v.Portfolio) != 2 || len(v.Followers) != 1 ||
+				v.Followers[0].DaysFollowing != 388 || v.MaxWithdrawable != 742958.8915 || !v.AllowDeposits {
+				t.Errorf("vault details = %+v", v)
+			}
+		}),
+		golden("spot_deploy_state.json", func(t *testing.T, v SpotDeployState) {
+			if len(v.States) != 1 || v.States[0].Spec.WeiDecimals != 8 || len(v.States[0].UserGenesisBalances) != 1 {
+				t.Errorf("spot deploy state = %+v", v)
+			}
+			if v.GasAuction.DurationSeconds != 111600 || v.GasAuction.EndGas == nil {
+				t.Errorf("gas auction = %+v", v.GasAuction)
+			}
+		}),
+		golden("perp_deploy_auction_status.json", func(t *testing.T, v PerpDeployAuctionStatus) {
+			if v.StartTimeSeconds != 1747656000 || v.StartGas != "500.0" || v.EndGas != nil {
+				t.Errorf("auction status = %+v", v)
+			}
+		}),
+		golden("meta_and_asset_ctxs.json", func(t *testing.T, v MetaAndAssetCtxs) {
+			if len(v.Meta.Universe) != 2 || len(v.AssetCtxs) != 2 || v.AssetCtxs[1].MarkPx != "3000.0" {
+				t.Errorf("meta and asset ctxs = %+v", v)
+			}
+			if len(v.Meta.MarginTables) != 1 || v.Meta.MarginTables[0].Index != 56 {
+				t.Errorf("margin tables = %+v", v.Meta.MarginTables)
+			}
+		}),
+		golden("spot_meta_and_asset_ctxs.json", func(t *testing.T, v SpotMetaAndAssetCtxs) {
+			if len(v.Meta.Tokens) != 2 || len(v.AssetCtxs) != 1 || v.AssetCtxs[0].MidPx == nil || v.AssetCtxs[0].Coin != "PURR/USDC" {
+				t.Errorf("spot meta and asset ctxs = %+v", v)
+			}
+		}),
+		golden("predicted_fundings.json", func(t *testing.T, v PredictedFundings) {
+			if len(v) != 2 || v[0].Coin != "AVAX" || len(v[0].Venues) != 2 || v[0].Venues[1].Info.FundingRate != "0.0000125" {
+				t.Errorf("predicted fundings = %+v", v)
+			}
+		}),
+		golden("order_status.json", func(t *testing.T, v OrderQueryResponse) {
+			if v.Status != "order" || v.Order.Status != "filled" || v.Order.Order.Coin != "ETH" {
+				t.Errorf("order status = %+v", v)
+			}
+		}),
+		golden("exchange_order.json", func(t *testing.T, v ApiResponse[OrderResponse]) {
+			result, err := v.DecodeResponse()
+			if err != nil {
+				t.Fatalf("DecodeResponse() error = %v", err)
+			}
+			statuses := result.Data.Statuses
+			if len(statuses) != 3 || statuses[0].Resting == nil || statuses[1].Filled == nil || statuses[1].Filled.AvgPx != "1891.4" {
+				t.Fatalf("statuses = %+v", statuses)
+			}
+			if err, ok := statuses[2].ParseError(); !ok || err.Status != OrderStatusMinTradeNtlRejected {
+				t.Errorf("error status = %+v", statuses[2])
+			}
+		}),
+		golden("delegator_summary.json", func(t *testing.T, v DelegatorSummary) {
+			if v.Delegated != "12060.16529862" || v.NPendingWithdrawals != 0 {
+				t.Errorf("delegator summary = %+v", v)
+			}
+		}),
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", fixture.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fixture.test(t, data)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Interval is the interval of candles, e.g. "15m"
+type Interval string
+
+// Intervals offered by the candle snapshot and feed
+const (
+	Interval1m  Interval = "1m"
+	Interval3m  Interval = "3m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval30m Interval = "30m"
+	Interval1h  Interval = "1h"
+	Interval2h  Interval = "2h"
+	Interval4h  Interval = "4h"
+	Interval8h  Interval = "8h"
+	Interval12h Interval = "12h"
+	Interval1d  Interval = "1d"
+	Interval3d  Interval = "3d"
+	Interval1w  Interval = "1w"
+	Interval1M  Interval = "1M"
+)
+
+// MaxCandles is the most candles returned by a candle snapshot
+const MaxCandles = 5000
+
+var intervalDurations = map[Interval]time.Duration{
+	Interval1m:  time.Minute,
+	Interval3m:  3 * time.Minute,
+	Interval5m:  5 * time.Minute,
+	Interval15m: 15 * time.Minute,
+	Interval30m: 30 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval2h:  2 * time.Hour,
+	Interval4h:  4 * time.Hour,
+	Interval8h:  8 * time.Hour,
+	Interval12h: 12 * time.Hour,
+	Interval1d:  24 * time.Hour,
+	Interval3d:  3 * 24 * time.Hour,
+	Interval1w:  7 * 24 * time.Hour,
+	Interval1M:  0,
+}
+
+// weekOffsetMs shifts weekly buckets to start on Monday, the epoch being a Thursday
+const weekOffsetMs = 4 * 24 * int64(time.Hour/time.Millisecond)
+
+// Valid reports whether candles are offered at the interval
+func (i Interval) Valid() bool {
+	_, ok := intervalDurations[i]
+	return ok
+}
+
+// Duration returns the length of the interval, 0 for 1M whose length varies
+func (i Interval) Duration() time.Duration {
+	return intervalDurations[i]
+}
+
+// ParseInterval returns the interval named s, e.g. "15m". Names are case-sensitive,
+// since "1m" is a minute and "1M" a month.
+func ParseInterval(s string) (Interval, error) {
+	interval := Interval(s)
+	if !interval.Valid() {
+		return "", fmt.Errorf("invalid candle interval: %q", s)
+	}
+	return interval, nil
+}
+
+// BucketStart returns the start in milliseconds of the candle containing ms. Buckets
+// are aligned to the epoch in UTC, weeks start on Monday and months on the 1st. ms is
+// returned as is for an invalid interval.
+func (i Interval) BucketStart(ms int64) int64 {
+	switch {
+	case !i.Valid():
+		return ms
+	case i == Interval1M:
+		t := time.UnixMilli(ms).UTC()
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	case i == Interval1w:
+		return ms - floorMod(ms-weekOffsetMs, i.Duration().Milliseconds())
+	default:
+		return ms - floorMod(ms, i.Duration().Milliseconds())
+	}
+}
+
+// NextBucket returns the start in milliseconds of the candle after the one containing ms
+func (i Interval) NextBucket(ms int64) int64 {
+	start := i.BucketStart(ms)
+	if i == Interval1M {
+		return time.UnixMilli(start).UTC().AddDate(0, 1, 0).UnixMilli()
+	}
+	return start + i.Duration().Milliseconds()
+}
+
+// Windows splits [startMs, endMs) into windows of at most maxCandles candles, aligned
+// to the buckets of the interval, for candle snapshots of long ranges. A maxCandles
+// of 0 means MaxCandles. There are no windows for an invalid interval.
+func (i Interval) Windows(startMs, endMs int64, maxCandles int) []utils.TimeWindow {
+	if startMs >= endMs || !i.Valid() {
+		return nil
+	}
+	if maxCandles <= 0 {
+		maxCandles = MaxCandles
+	}
+	var windows []utils.TimeWindow
+	for start := i.BucketStart(startMs); start < endMs; {
+		end := start
+		for n := 0; n < maxCandles && end < endMs; n++ {
+			end = i.NextBucket(end)
+		}
+		windows = append(windows, utils.TimeWindow{Start: start, End: min(end, endMs)})
+		start = end
+	}
+	return windows
+}
+
+// floorMod returns a mod b in [0, b), also for negative a
+func floorMod(a, b int64) int64 {
+	return ((a % b) + b) % b
+}
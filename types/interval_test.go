@@ -0,0 +1,81 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+func utcMs(year int, month time.Month, day, hour, minute int) int64 {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC).UnixMilli()
+}
+
+func TestIntervalBuckets(t *testing.T) {
+	// A Wednesday
+	ms := time.Date(2025, time.January, 15, 13, 47, 12, 0, time.UTC).UnixMilli()
+	tests := []struct {
+		interval Interval
+		start    int64
+		next     int64
+	}{
+		{Interval1m, utcMs(2025, time.January, 15, 13, 47), utcMs(2025, time.January, 15, 13, 48)},
+		{Interval15m, utcMs(2025, time.January, 15, 13, 45), utcMs(2025, time.January, 15, 14, 0)},
+		{Interval4h, utcMs(2025, time.January, 15, 12, 0), utcMs(2025, time.January, 15, 16, 0)},
+		{Interval1d, utcMs(2025, time.January, 15, 0, 0), utcMs(2025, time.January, 16, 0, 0)},
+		{Interval1w, utcMs(2025, time.January, 13, 0, 0), utcMs(2025, time.January, 20, 0, 0)},
+		{Interval1M, utcMs(2025, time.January, 1, 0, 0), utcMs(2025, time.February, 1, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.interval), func(t *testing.T) {
+			if got := tt.interval.BucketStart(ms); got != tt.start {
+				t.Errorf("BucketStart() = %v, want %v", time.UnixMilli(got).UTC(), time.UnixMilli(tt.start).UTC())
+			}
+			if got := tt.interval.NextBucket(ms); got != tt.next {
+				t.Errorf("NextBucket() = %v, want %v", time.UnixMilli(got).UTC(), time.UnixMilli(tt.next).UTC())
+			}
+			if got := tt.interval.BucketStart(tt.start); got != tt.start {
+				t.Errorf("BucketStart() of a bucket start = %v", time.UnixMilli(got).UTC())
+			}
+		})
+	}
+	if got := Interval1h.BucketStart(-1); got != -time.Hour.Milliseconds() {
+		t.Errorf("BucketStart(-1) = %d", got)
+	}
+}
+
+func TestIntervalWindows(t *testing.T) {
+	start := utcMs(2025, time.January, 1, 0, 30)
+	end := utcMs(2025, time.January, 1, 10, 0)
+	got := Interval1h.Windows(start, end, 4)
+	want := []utils.TimeWindow{
+		{Start: utcMs(2025, time.January, 1, 0, 0), End: utcMs(2025, time.January, 1, 4, 0)},
+		{Start: utcMs(2025, time.January, 1, 4, 0), End: utcMs(2025, time.January, 1, 8, 0)},
+		{Start: utcMs(2025, time.January, 1, 8, 0), End: end},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Windows() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("window %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	months := Interval1M.Windows(utcMs(2024, time.November, 10, 0, 0), utcMs(2025, time.March, 1, 0, 0), 2)
+	if len(months) != 2 || months[1].Start != utcMs(2025, time.January, 1, 0, 0) {
+		t.Errorf("Windows() of months = %v", months)
+	}
+	if windows := Interval1d.Windows(end, start, 0); len(windows) != 0 {
+		t.Errorf("Windows() of an empty range = %v", windows)
+	}
+}
+
+func TestInvalidIntervalBuckets(t *testing.T) {
+	if got := Interval("6h").BucketStart(12345); got != 12345 {
+		t.Errorf("BucketStart() = %d, want 12345", got)
+	}
+	if windows := Interval("6h").Windows(0, 1000, 0); windows != nil {
+		t.Errorf("Windows() = %v, want none", windows)
+	}
+}
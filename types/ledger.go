@@ -0,0 +1,252 @@
+package types
+
+import "encoding/json"
+
+// NonFundingLedgerUpdate represents a ledger update (withdrawal, deposit, transfer, or liquidation)
+type NonFundingLedgerUpdate struct {
+	Time  int64       `json:"time"`
+	Hash  string      `json:"hash"`
+	Delta LedgerDelta `json:"delta"`
+}
+
+// LedgerDeltaType is the kind of a ledger update
+type LedgerDeltaType string
+
+// Ledger delta types, as sent in the "type" field of a delta
+const (
+	LedgerDeposit               LedgerDeltaType = "deposit"
+	LedgerWithdraw              LedgerDeltaType = "withdraw"
+	LedgerInternalTransfer      LedgerDeltaType = "internalTransfer"
+	LedgerSubAccountTransfer    LedgerDeltaType = "subAccountTransfer"
+	LedgerAccountClassTransfer  LedgerDeltaType = "accountClassTransfer"
+	LedgerSpotTransfer          LedgerDeltaType = "spotTransfer"
+	LedgerSend                  LedgerDeltaType = "send"
+	LedgerLiquidation           LedgerDeltaType = "liquidation"
+	LedgerVaultCreate           LedgerDeltaType = "vaultCreate"
+	LedgerVaultDeposit          LedgerDeltaType = "vaultDeposit"
+	LedgerVaultWithdraw         LedgerDeltaType = "vaultWithdraw"
+	LedgerVaultDistribution     LedgerDeltaType = "vaultDistribution"
+	LedgerVaultLeaderCommission LedgerDeltaType = "vaultLeaderCommission"
+	LedgerRewardsClaim          LedgerDeltaType = "rewardsClaim"
+	LedgerCStakingTransfer      LedgerDeltaType = "cStakingTransfer"
+	LedgerSpotGenesis           LedgerDeltaType = "spotGenesis"
+	LedgerDeployGasAuction      LedgerDeltaType = "deployGasAuction"
+)
+
+// LedgerDelta is the change described by a ledger update.
+// Type selects the variant; only the fields of that variant are set:
+//
+//	deposit:               Usdc
+//	withdraw:              Usdc, Nonce, Fee
+//	internalTransfer:      Usdc, User, Destination, Fee
+//	subAccountTransfer:    Usdc, User, Destination
+//	accountClassTransfer:  Usdc, ToPerp
+//	spotTransfer:          Token, Amount, UsdcValue, User, Destination, Fee, NativeTokenFee, Nonce
+//	send:                  Token, Amount, UsdcValue, User, Destination, SourceDex, DestinationDex, Fee, NativeTokenFee, Nonce
+//	liquidation:           LiquidatedNtlPos, AccountValue, LeverageType, LiquidatedPositions
+//	vaultCreate:           Vault, Usdc, Fee
+//	vaultDeposit:          Vault, Usdc
+//	vaultWithdraw:         Vault, User, RequestedUsd, Commission, ClosingCost, Basis, NetWithdrawnUsd
+//	vaultDistribution:     Vault, Usdc
+//	vaultLeaderCommission: User, Usdc
+//	rewardsClaim:          Token, Amount
+//	cStakingTransfer:      Token, Amount, IsDeposit
+//	spotGenesis:           Token, Amount
+//	deployGasAuction:      Token, Amount
+type LedgerDelta struct {
+	Type LedgerDeltaType `json:"type"`
+
+	Usdc        string `json:"usdc,omitempty"`
+	User        string `json:"user,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Fee         string `json:"fee,omitempty"`
+	Nonce       int64  `json:"nonce,omitempty"`
+	ToPerp      *bool  `json:"toPerp,omitempty"`
+	IsDeposit   *bool  `json:"isDeposit,omitempty"`
+	Vault       string `json:"vault,omitempty"`
+
+	// Token transfers
+	Token          string `json:"token,omitempty"`
+	Amount         string `json:"amount,omitempty"`
+	UsdcValue      string `json:"usdcValue,omitempty"`
+	NativeTokenFee string `json:"nativeTokenFee,omitempty"`
+	SourceDex      string `json:"sourceDex,omitempty"`
+	DestinationDex string `json:"destinationDex,omitempty"`
+
+	// Liquidations
+	LiquidatedNtlPos    string               `json:"liquidatedNtlPos,omitempty"`
+	AccountValue        string               `json:"accountValue,omitempty"`
+	LeverageType        string               `json:"leverageType,omitempty"`
+	LiquidatedPositions []LiquidatedPosition `json:"liquidatedPositions,omitempty"`
+
+	// Vault withdrawals
+	RequestedUsd    string `json:"requestedUsd,omitempty"`
+	Commission      string `json:"commission,omitempty"`
+	ClosingCost     string `json:"closingCost,omitempty"`
+	Basis           string `json:"basis,omitempty"`
+	NetWithdrawnUsd string `json:"netWithdrawnUsd,omitempty"`
+}
+
+// LiquidatedPosition is a position closed by a liquidation ledger update
+type LiquidatedPosition struct {
+	Coin string `json:"coin"`
+	Szi  string `json:"szi"`
+}
+
+// LedgerVariant is the typed form of a LedgerDelta, holding only the fields of its
+// type. It is one of DepositDelta, WithdrawDelta, TransferDelta, VaultDelta,
+// LiquidationDelta or TokenDelta, or the LedgerDelta itself for unknown types:
+//
+//	switch delta := update.Delta.Variant().(type) {
+//	case types.DepositDelta:
+//	    log.Println("deposit of", delta.Usdc)
+//	case types.TransferDelta:
+//	    ...
+//	}
+type LedgerVariant interface {
+	LedgerType() LedgerDeltaType
+}
+
+// DepositDelta is a USDC deposit from the bridge
+type DepositDelta struct {
+	Usdc string
+}
+
+// WithdrawDelta is a USDC withdrawal to the bridge
+type WithdrawDelta struct {
+	Usdc  string
+	Nonce int64
+	Fee   string
+}
+
+// TransferDelta is a transfer between accounts, sub-accounts, dexs or the spot and perp
+// balances: internalTransfer, subAccountTransfer, accountClassTransfer, spotTransfer
+// or send. Token and Amount are only set for the token transfers spotTransfer and send,
+// Usdc for the others.
+type TransferDelta struct {
+	Type           LedgerDeltaType
+	Usdc           string
+	Token          string
+	Amount         string
+	UsdcValue      string
+	User           string
+	Destination    string
+	SourceDex      string
+	DestinationDex string
+	ToPerp         *bool
+	Fee            string
+	NativeTokenFee string
+	Nonce          int64
+}
+
+// VaultDelta is a change of a vault position: vaultCreate, vaultDeposit, vaultWithdraw,
+// vaultDistribution or vaultLeaderCommission
+type VaultDelta struct {
+	Type            LedgerDeltaType
+	Vault           string
+	User            string
+	Usdc            string
+	Fee             string
+	RequestedUsd    string
+	Commission      string
+	ClosingCost     string
+	Basis           string
+	NetWithdrawnUsd string
+}
+
+// LiquidationDelta is a liquidation of the account
+type LiquidationDelta struct {
+	LiquidatedNtlPos    string
+	AccountValue        string
+	LeverageType        string
+	LiquidatedPositions []LiquidatedPosition
+}
+
+// TokenDelta is a change of a token balance outside of trading: rewardsClaim,
+// cStakingTransfer, spotGenesis or deployGasAuction
+type TokenDelta struct {
+	Type      LedgerDeltaType
+	Token     string
+	Amount    string
+	IsDeposit *bool // only for cStakingTransfer
+}
+
+// LedgerType returns LedgerDeposit
+func (DepositDelta) LedgerType() LedgerDeltaType { return LedgerDeposit }
+
+// LedgerType returns LedgerWithdraw
+func (WithdrawDelta) LedgerType() LedgerDeltaType { return LedgerWithdraw }
+
+// LedgerType returns the type of the transfer
+func (d TransferDelta) LedgerType() LedgerDeltaType { return d.Type }
+
+// LedgerType returns the type of the vault change
+func (d VaultDelta) LedgerType() LedgerDeltaType { return d.Type }
+
+// LedgerType returns LedgerLiquidation
+func (LiquidationDelta) LedgerType() LedgerDeltaType { return LedgerLiquidation }
+
+// LedgerType returns the type of the token change
+func (d TokenDelta) LedgerType() LedgerDeltaType { return d.Type }
+
+// LedgerType returns the type of the delta
+func (d LedgerDelta) LedgerType() LedgerDeltaType { return d.Type }
+
+// Variant returns the typed form of the delta, selected by its type
+func (d LedgerDelta) Variant() LedgerVariant {
+	switch d.Type {
+	case LedgerDeposit:
+		return DepositDelta{Usdc: d.Usdc}
+	case LedgerWithdraw:
+		return WithdrawDelta{Usdc: d.Usdc, Nonce: d.Nonce, Fee: d.Fee}
+	case LedgerInternalTransfer, LedgerSubAccountTransfer, LedgerAccountClassTransfer, LedgerSpotTransfer, LedgerSend:
+		return TransferDelta{
+			Type:           d.Type,
+			Usdc:           d.Usdc,
+			Token:          d.Token,
+			Amount:         d.Amount,
+			UsdcValue:      d.UsdcValue,
+			User:           d.User,
+			Destination:    d.Destination,
+			SourceDex:      d.SourceDex,
+			DestinationDex: d.DestinationDex,
+			ToPerp:         d.ToPerp,
+			Fee:            d.Fee,
+			NativeTokenFee: d.NativeTokenFee,
+			Nonce:          d.Nonce,
+		}
+	case LedgerVaultCreate, LedgerVaultDeposit, LedgerVaultWithdraw, LedgerVaultDistribution, LedgerVaultLeaderCommission:
+		return VaultDelta{
+			Type:            d.Type,
+			Vault:           d.Vault,
+			User:            d.User,
+			Usdc:            d.Usdc,
+			Fee:             d.Fee,
+			RequestedUsd:    d.RequestedUsd,
+			Commission:      d.Commission,
+			ClosingCost:     d.ClosingCost,
+			Basis:           d.Basis,
+			NetWithdrawnUsd: d.NetWithdrawnUsd,
+		}
+	case LedgerLiquidation:
+		return LiquidationDelta{
+			LiquidatedNtlPos:    d.LiquidatedNtlPos,
+			AccountValue:        d.AccountValue,
+			LeverageType:        d.LeverageType,
+			LiquidatedPositions: d.LiquidatedPositions,
+		}
+	case LedgerRewardsClaim, LedgerCStakingTransfer, LedgerSpotGenesis, LedgerDeployGasAuction:
+		return TokenDelta{Type: d.Type, Token: d.Token, Amount: d.Amount, IsDeposit: d.IsDeposit}
+	default:
+		return d
+	}
+}
+
+// UnmarshalLedgerDelta decodes a ledger delta into its typed form
+func UnmarshalLedgerDelta(data []byte) (LedgerVariant, error) {
+	var delta LedgerDelta
+	if err := json.Unmarshal(data, &delta); err != nil {
+		return nil, err
+	}
+	return delta.Variant(), nil
+}
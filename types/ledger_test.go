@@ -0,0 +1,56 @@
+package types
+
+import "testing"
+
+func TestLedgerDeltaVariant(t *testing.T) {
+	tests := []struct {
+		data  string
+		check func(v LedgerVariant) bool
+	}{
+		{`{"type":"deposit","usdc":"100.0"}`, func(v LedgerVariant) bool {
+			d, ok := v.(DepositDelta)
+			return ok && d.Usdc == "100.0"
+		}},
+		{`{"type":"withdraw","usdc":"50.0","nonce":7,"fee":"1.0"}`, func(v LedgerVariant) bool {
+			d, ok := v.(WithdrawDelta)
+			return ok && d.Nonce == 7 && d.Fee == "1.0"
+		}},
+		{`{"type":"send","token":"HYPE","amount":"2","usdcValue":"60","user":"0xa","destination":"0xb","sourceDex":"","destinationDex":"spot"}`, func(v LedgerVariant) bool {
+			d, ok := v.(TransferDelta)
+			return ok && d.Type == LedgerSend && d.Token == "HYPE" && d.DestinationDex == "spot"
+		}},
+		{`{"type":"accountClassTransfer","usdc":"5.0","toPerp":true}`, func(v LedgerVariant) bool {
+			d, ok := v.(TransferDelta)
+			return ok && d.ToPerp != nil && *d.ToPerp
+		}},
+		{`{"type":"vaultWithdraw","vault":"0xv","user":"0xa","requestedUsd":"10","netWithdrawnUsd":"9.5"}`, func(v LedgerVariant) bool {
+			d, ok := v.(VaultDelta)
+			return ok && d.Type == LedgerVaultWithdraw && d.NetWithdrawnUsd == "9.5"
+		}},
+		{`{"type":"liquidation","liquidatedNtlPos":"10.0","accountValue":"1.0","leverageType":"Cross","liquidatedPositions":[{"coin":"ETH","szi":"-0.1"}]}`, func(v LedgerVariant) bool {
+			d, ok := v.(LiquidationDelta)
+			return ok && len(d.LiquidatedPositions) == 1 && d.LiquidatedPositions[0].Coin == "ETH"
+		}},
+		{`{"type":"cStakingTransfer","token":"HYPE","amount":"1","isDeposit":true}`, func(v LedgerVariant) bool {
+			d, ok := v.(TokenDelta)
+			return ok && d.Type == LedgerCStakingTransfer && d.IsDeposit != nil && *d.IsDeposit
+		}},
+		{`{"type":"borrowLend","token":"USDC"}`, func(v LedgerVariant) bool {
+			d, ok := v.(LedgerDelta)
+			return ok && d.Type == "borrowLend" && d.Token == "USDC"
+		}},
+	}
+	for _, tt := range tests {
+		variant, err := UnmarshalLedgerDelta([]byte(tt.data))
+		if err != nil {
+			t.Fatalf("UnmarshalLedgerDelta(%s) error = %v", tt.data, err)
+		}
+		if !tt.check(variant) {
+			t.Errorf("UnmarshalLedgerDelta(%s) = %#v", tt.data, variant)
+		}
+	}
+
+	if _, err := UnmarshalLedgerDelta([]byte(`{"type":1}`)); err == nil {
+		t.Error("UnmarshalLedgerDelta() of an invalid delta should fail")
+	}
+}
@@ -0,0 +1,154 @@
+package types
+
+import (
+	"fmt"
+	"math"
+)
+
+// MarginTable returns the margin table of asset. Assets without a table in
+// MarginTables have a single tier at their max leverage.
+func (m *Meta) MarginTable(asset AssetInfo) MarginTableData {
+	for _, table := range m.MarginTables {
+		if table.Index == asset.MarginTableID {
+			return table.Data
+		}
+	}
+	return MarginTableData{MarginTiers: []MarginTier{{LowerBound: "0", MaxLeverage: asset.MaxLeverage}}}
+}
+
+// MaxLeverage returns the max leverage of a position of notional USD, that of the
+// highest tier whose lower bound is at most notional
+func (d MarginTableData) MaxLeverage(notional float64) (int, error) {
+	if len(d.MarginTiers) == 0 {
+		return 0, fmt.Errorf("margin table %q has no tiers", d.Description)
+	}
+	leverage := d.MarginTiers[0].MaxLeverage
+	for _, tier := range d.MarginTiers[1:] {
+		lower, err := parseFloat(tier.LowerBound)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse margin tier lower bound: %w", err)
+		}
+		if lower > math.Abs(notional) {
+			break
+		}
+		leverage = tier.MaxLeverage
+	}
+	return leverage, nil
+}
+
+// RequiredMargin returns the initial margin of a position of sz at px with leverage,
+// lowered to the max leverage of its notional. A leverage of 0 uses the max leverage.
+func (d MarginTableData) RequiredMargin(sz, px float64, leverage int) (float64, error) {
+	notional := math.Abs(sz) * px
+	maxLeverage, err := d.MaxLeverage(notional)
+	if err != nil {
+		return 0, err
+	}
+	if leverage <= 0 || leverage > maxLeverage {
+		leverage = maxLeverage
+	}
+	if leverage <= 0 {
+		return 0, fmt.Errorf("invalid max leverage %d", leverage)
+	}
+	return notional / float64(leverage), nil
+}
+
+// MaintenanceMargin returns the maintenance margin of a position of notional USD. The
+// maintenance margin rate of each tier is half its initial margin rate at max
+// leverage, with a deduction keeping the margin continuous across tiers.
+func (d MarginTableData) MaintenanceMargin(notional float64) (float64, error) {
+	notional = math.Abs(notional)
+	var rate, deduction float64
+	for i, tier := range d.MarginTiers {
+		lower, err := parseFloat(tier.LowerBound)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse margin tier lower bound: %w", err)
+		}
+		if i > 0 && lower > notional {
+			break
+		}
+		if tier.MaxLeverage <= 0 {
+			return 0, fmt.Errorf("invalid max leverage %d", tier.MaxLeverage)
+		}
+		next := 1 / (2 * float64(tier.MaxLeverage))
+		if i > 0 {
+			deduction += lower * (next - rate)
+		}
+		rate = next
+	}
+	if len(d.MarginTiers) == 0 {
+		return 0, fmt.Errorf("margin table %q has no tiers", d.Description)
+	}
+	return notional*rate - deduction, nil
+}
+
+// MarginCheck is the margin an order requires against the margin available
+type MarginCheck struct {
+	Coin string
+	// Notional is the notional in USD of the position after the order
+	Notional float64
+	// Leverage is the leverage of the position after the order
+	Leverage int
+	// RequiredMargin is the margin the order adds to the position, 0 if it reduces it
+	RequiredMargin float64
+	// Available is the withdrawable margin of the account
+	Available float64
+}
+
+// OK reports whether the account has the margin the order requires
+func (c MarginCheck) OK() bool {
+	return c.RequiredMargin <= c.Available
+}
+
+// CheckMargin checks the margin of an order of sz coin at px, sz being negative for
+// sells, against the perp state of the account. The position keeps its leverage; a new
+// position uses the max leverage of the asset, lowered by its margin table.
+func CheckMargin(meta *Meta, state *UserState, coin string, sz, px float64) (MarginCheck, error) {
+	asset, _, ok := meta.FindAsset(coin)
+	if !ok {
+		return MarginCheck{}, fmt.Errorf("unknown coin: %s", coin)
+	}
+	table := meta.MarginTable(asset)
+
+	leverage := asset.MaxLeverage
+	var szi float64
+	for _, p := range state.AssetPositions {
+		if p.Position.Coin != coin {
+			continue
+		}
+		var err error
+		if szi, err = p.Position.SziFloat(); err != nil {
+			return MarginCheck{}, fmt.Errorf("failed to parse position size: %w", err)
+		}
+		if p.Position.Leverage.Value > 0 {
+			leverage = p.Position.Leverage.Value
+		}
+		break
+	}
+
+	current, err := table.RequiredMargin(szi, px, leverage)
+	if err != nil {
+		return MarginCheck{}, err
+	}
+	after, err := table.RequiredMargin(szi+sz, px, leverage)
+	if err != nil {
+		return MarginCheck{}, err
+	}
+	available, err := state.WithdrawableFloat()
+	if err != nil {
+		return MarginCheck{}, fmt.Errorf("failed to parse withdrawable: %w", err)
+	}
+
+	notional := math.Abs(szi+sz) * px
+	maxLeverage, err := table.MaxLeverage(notional)
+	if err != nil {
+		return MarginCheck{}, err
+	}
+	return MarginCheck{
+		Coin:           coin,
+		Notional:       notional,
+		Leverage:       min(leverage, maxLeverage),
+		RequiredMargin: max(after-current, 0),
+		Available:      available,
+	}, nil
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func testMarginMeta() *Meta {
+	return &Meta{
+		Universe: []AssetInfo{
+			{Name: "BTC", SzDecimals: 5, MaxLeverage: 40, MarginTableID: 56},
+			{Name: "ETH", SzDecimals: 4, MaxLeverage: 25, MarginTableID: 25},
+		},
+		MarginTables: []MarginTablePair{{
+			Index: 56,
+			Data: MarginTableData{Description: "tiered 40x", MarginTiers: []MarginTier{
+				{LowerBound: "0.0", MaxLeverage: 40},
+				{LowerBound: "150000000.0", MaxLeverage: 20},
+			}},
+		}},
+	}
+}
+
+func TestMarginTable(t *testing.T) {
+	meta := testMarginMeta()
+	btc := meta.MarginTable(meta.Universe[0])
+	tests := []struct {
+		notional float64
+		leverage int
+	}{
+		{0, 40},
+		{149_999_999, 40},
+		{150_000_000, 20},
+		{-200_000_000, 20},
+	}
+	for _, tt := range tests {
+		if got, err := btc.MaxLeverage(tt.notional); err != nil || got != tt.leverage {
+			t.Errorf("MaxLeverage(%v) = %d, %v, want %d", tt.notional, got, err, tt.leverage)
+		}
+	}
+
+	if got, _ := btc.RequiredMargin(-1, 100_000, 10); got != 10_000 {
+		t.Errorf("RequiredMargin() at 10x = %v, want 10000", got)
+	}
+	if got, _ := btc.RequiredMargin(2000, 100_000, 0); got != 10_000_000 {
+		t.Errorf("RequiredMargin() at max leverage = %v, want 10000000", got)
+	}
+
+	// Continuous at the tier bound: 150m / 80 on both sides
+	below, _ := btc.MaintenanceMargin(150_000_000 - 1e-3)
+	at, _ := btc.MaintenanceMargin(150_000_000)
+	if math.Abs(below-at) > 1e-3 || math.Abs(at-1_875_000) > 1e-6 {
+		t.Errorf("MaintenanceMargin() = %v below and %v at the bound", below, at)
+	}
+	if got, _ := btc.MaintenanceMargin(250_000_000); math.Abs(got-(250_000_000.0/40-1_875_000)) > 1e-6 {
+		t.Errorf("MaintenanceMargin(250m) = %v", got)
+	}
+
+	// No table: a single tier at the max leverage of the asset
+	eth := meta.MarginTable(meta.Universe[1])
+	if got, err := eth.MaxLeverage(1e12); err != nil || got != 25 {
+		t.Errorf("MaxLeverage() without a table = %d, %v", got, err)
+	}
+	if _, err := (MarginTableData{}).MaxLeverage(1); err == nil {
+		t.Error("MaxLeverage() without tiers should fail")
+	}
+}
+
+func TestCheckMargin(t *testing.T) {
+	meta := testMarginMeta()
+	state := &UserState{
+		AssetPositions: []AssetPosition{{Position: Position{Coin: "ETH", Szi: "2", Leverage: Leverage{Type: "cross", Value: 10}}}},
+		Withdrawable:   "500",
+	}
+
+	tests := []struct {
+		name     string
+		coin     string
+		sz       float64
+		leverage int
+		required float64
+		ok       bool
+	}{
+		{"increase", "ETH", 1, 10, 300, true},
+		{"too large", "ETH", 2, 10, 600, false},
+		{"reduce", "ETH", -1, 10, 0, true},
+		{"new position", "BTC", 0.01, 40, 25, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			px := 3000.0
+			if tt.coin == "BTC" {
+				px = 100_000
+			}
+			check, err := CheckMargin(meta, state, tt.coin, tt.sz, px)
+			if err != nil {
+				t.Fatalf("CheckMargin() error = %v", err)
+			}
+			if math.Abs(check.RequiredMargin-tt.required) > 1e-9 || check.Leverage != tt.leverage || check.OK() != tt.ok {
+				t.Errorf("CheckMargin() = %+v", check)
+			}
+		})
+	}
+
+	if _, err := CheckMargin(meta, state, "DOGE", 1, 1); err == nil {
+		t.Error("CheckMargin() of an unknown coin should fail")
+	}
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"strings"
+	"sync"
+)
+
+// metaIndexMu guards the indexes that the lookups of Meta and SpotMeta build on first use
+var metaIndexMu sync.Mutex
+
+// FindAsset returns the asset named name and its index in the universe, which is the
+// asset id for the default dex. Meta is indexed on the first lookup and must not be
+// modified afterwards.
+func (m *Meta) FindAsset(name string) (AssetInfo, int, bool) {
+	metaIndexMu.Lock()
+	if m.assetIndex == nil {
+		m.assetIndex = make(map[string]int, len(m.Universe))
+		for i, asset := range m.Universe {
+			m.assetIndex[asset.Name] = i
+		}
+	}
+	i, ok := m.assetIndex[name]
+	metaIndexMu.Unlock()
+
+	if !ok {
+		return AssetInfo{}, 0, false
+	}
+	return m.Universe[i], i, true
+}
+
+type spotMetaIndex struct {
+	tokens map[string]int // by name and by token id
+	pairs  map[[2]int]int // by base and quote token index
+	names  map[string]int // by pair name, e.g. "@107" or "PURR/USDC"
+}
+
+func (m *SpotMeta) lookup() *spotMetaIndex {
+	metaIndexMu.Lock()
+	defer metaIndexMu.Unlock()
+	if m.index != nil {
+		return m.index
+	}
+
+	index := &spotMetaIndex{
+		tokens: make(map[string]int, 2*len(m.Tokens)),
+		pairs:  make(map[[2]int]int, len(m.Universe)),
+		names:  make(map[string]int, len(m.Universe)),
+	}
+	for i, token := range m.Tokens {
+		index.tokens[token.Name] = i
+		if token.TokenID != "" {
+			index.tokens[strings.ToLower(token.TokenID)] = i
+		}
+	}
+	for i, pair := range m.Universe {
+		index.pairs[pair.Tokens] = i
+		index.names[pair.Name] = i
+	}
+	m.index = index
+	return index
+}
+
+// FindToken returns the token named nameOrID, or with token id nameOrID, e.g.
+// "0xc1fb593aeffbeb02f85e0308e9956a90". SpotMeta is indexed on the first lookup and must
+// not be modified afterwards.
+func (m *SpotMeta) FindToken(nameOrID string) (SpotTokenInfo, bool) {
+	index := m.lookup()
+	i, ok := index.tokens[nameOrID]
+	if !ok {
+		i, ok = index.tokens[strings.ToLower(nameOrID)]
+	}
+	if !ok {
+		return SpotTokenInfo{}, false
+	}
+	return m.Tokens[i], true
+}
+
+// FindPair returns the pair trading the token base against the token quote, both
+// names or token ids
+func (m *SpotMeta) FindPair(base, quote string) (SpotAssetInfo, bool) {
+	baseToken, ok := m.FindToken(base)
+	if !ok {
+		return SpotAssetInfo{}, false
+	}
+	quoteToken, ok := m.FindToken(quote)
+	if !ok {
+		return SpotAssetInfo{}, false
+	}
+	i, ok := m.lookup().pairs[[2]int{baseToken.Index, quoteToken.Index}]
+	if !ok {
+		return SpotAssetInfo{}, false
+	}
+	return m.Universe[i], true
+}
+
+// FindPairByName returns the pair named name, e.g. "@107", or "BASE/QUOTE" as accepted
+// by the exchange client, e.g. "PURR/USDC"
+func (m *SpotMeta) FindPairByName(name string) (SpotAssetInfo, bool) {
+	if i, ok := m.lookup().names[name]; ok {
+		return m.Universe[i], true
+	}
+	if base, quote, ok := strings.Cut(name, "/"); ok {
+		return m.FindPair(base, quote)
+	}
+	return SpotAssetInfo{}, false
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestMetaFindAsset(t *testing.T) {
+	meta := &Meta{Universe: []AssetInfo{{Name: "BTC", SzDecimals: 5}, {Name: "ETH", SzDecimals: 4}}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if asset, index, ok := meta.FindAsset("ETH"); !ok || index != 1 || asset.SzDecimals != 4 {
+				t.Errorf("FindAsset(ETH) = %+v, %d, %v", asset, index, ok)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, _, ok := meta.FindAsset("SOL"); ok {
+		t.Error("FindAsset(SOL) should not find an asset")
+	}
+}
+
+func TestSpotMetaFind(t *testing.T) {
+	var meta SpotMeta
+	err := json.Unmarshal([]byte(`{
+		"universe":[{"name":"PURR/USDC","tokens":[1,0],"index":0,"isCanonical":true},{"name":"@1","tokens":[2,0],"index":1}],
+		"tokens":[
+			{"name":"USDC","szDecimals":8,"weiDecimals":8,"index":0,"tokenId":"0x6d1e7cde53ba9467b783cb7c530ce054"},
+			{"name":"PURR","szDecimals":0,"weiDecimals":5,"index":1,"tokenId":"0xc1fb593aeffbeb02f85e0308e9956a90"},
+			{"name":"HFUN","szDecimals":2,"weiDecimals":8,"index":2,"tokenId":"0xbaf265ef389da684513d98d68edf4eae"}
+		]}`), &meta)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if token, ok := meta.FindToken("HFUN"); !ok || token.Index != 2 {
+		t.Errorf("FindToken(HFUN) = %+v, %v", token, ok)
+	}
+	if token, ok := meta.FindToken("0xC1FB593AEFFBEB02F85E0308E9956A90"); !ok || token.Name != "PURR" {
+		t.Errorf("FindToken(token id) = %+v, %v", token, ok)
+	}
+	if pair, ok := meta.FindPair("HFUN", "USDC"); !ok || pair.Name != "@1" {
+		t.Errorf("FindPair(HFUN, USDC) = %+v, %v", pair, ok)
+	}
+	if _, ok := meta.FindPair("USDC", "HFUN"); ok {
+		t.Error("FindPair(USDC, HFUN) should not find a pair")
+	}
+	for _, name := range []string{"@1", "HFUN/USDC"} {
+		if pair, ok := meta.FindPairByName(name); !ok || pair.Index != 1 {
+			t.Errorf("FindPairByName(%s) = %+v, %v", name, pair, ok)
+		}
+	}
+	if _, ok := meta.FindToken("DOGE"); ok {
+		t.Error("FindToken(DOGE) should not find a token")
+	}
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OrderError is the machine-readable form of the error of an order status, so retry
+// and resize logic can branch on the reason instead of the message:
+//
+//	for _, status := range result.Response.Data.Statuses {
+//	    if err, ok := status.ParseError(); ok && err.Status == types.OrderStatusMinTradeNtlRejected {
+//	        // resize to err.MinValue
+//	    }
+//	}
+type OrderError struct {
+	// Status is the reason of the rejection, OrderStatusRejected if the message is not known
+	Status OrderStatusType
+	// Message is the error as sent by the exchange
+	Message string
+	// Asset is the asset id of the order, from the "asset=" suffix of most messages
+	Asset *int
+	// MinValue is the minimum notional in USD, for OrderStatusMinTradeNtlRejected
+	MinValue float64
+	// BboBid and BboAsk are the best bid and ask the order would have matched, for
+	// OrderStatusBadAloPxRejected
+	BboBid float64
+	BboAsk float64
+}
+
+// Error returns the message of the exchange
+func (e *OrderError) Error() string {
+	return e.Message
+}
+
+// orderErrorPatterns maps known error messages to their status, matched in order
+var orderErrorPatterns = []struct {
+	status  OrderStatusType
+	pattern *regexp.Regexp
+}{
+	{OrderStatusMinTradeNtlRejected, regexp.MustCompile(`(?i)minimum value of \$?([0-9.]+)`)},
+	{OrderStatusBadAloPxRejected, regexp.MustCompile(`(?i)post only order would have immediately matched(?:, bbo was ([0-9.]+)@([0-9.]+))?`)},
+	{OrderStatusIocCancelRejected, regexp.MustCompile(`(?i)could not immediately match`)},
+	{OrderStatusInsufficientSpotBalanceRejected, regexp.MustCompile(`(?i)insufficient spot balance`)},
+	{OrderStatusPerpMarginRejected, regexp.MustCompile(`(?i)insufficient margin`)},
+	{OrderStatusReduceOnlyRejected, regexp.MustCompile(`(?i)reduce only order would increase position`)},
+	{OrderStatusBadTriggerPxRejected, regexp.MustCompile(`(?i)invalid TP/SL price`)},
+	{OrderStatusTickRejected, regexp.MustCompile(`(?i)invalid price|divisible by tick size`)},
+	{OrderStatusMarketOrderNoLiquidityRejected, regexp.MustCompile(`(?i)no liquidity available for market order`)},
+	{OrderStatusPositionIncreaseAtOpenInterestCapRejected, regexp.MustCompile(`(?i)cannot increase position when open interest is at cap`)},
+	{OrderStatusPositionFlipAtOpenInterestCapRejected, regexp.MustCompile(`(?i)cannot flip position when open interest is at cap`)},
+	{OrderStatusTooAggressiveAtOpenInterestCapRejected, regexp.MustCompile(`(?i)too aggressive .*open interest (is )?at cap`)},
+	{OrderStatusOpenInterestIncreaseRejected, regexp.MustCompile(`(?i)open interest`)},
+	{OrderStatusOracleRejected, regexp.MustCompile(`(?i)away from the (reference|oracle) price`)},
+	{OrderStatusPerpMaxPositionRejected, regexp.MustCompile(`(?i)(maximum|max) position`)},
+}
+
+var orderErrorAsset = regexp.MustCompile(`asset=(\d+)`)
+
+// ParseOrderError parses the error message of an order status
+func ParseOrderError(message string) *OrderError {
+	parsed := &OrderError{Status: OrderStatusRejected, Message: message}
+	if match := orderErrorAsset.FindStringSubmatch(message); match != nil {
+		if asset, err := strconv.Atoi(match[1]); err == nil {
+			parsed.Asset = &asset
+		}
+	}
+
+	for _, p := range orderErrorPatterns {
+		match := p.pattern.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		parsed.Status = p.status
+		switch p.status {
+		case OrderStatusMinTradeNtlRejected:
+			parsed.MinValue = parseErrorNumber(match[1])
+		case OrderStatusBadAloPxRejected:
+			parsed.BboBid = parseErrorNumber(match[1])
+			parsed.BboAsk = parseErrorNumber(match[2])
+		}
+		break
+	}
+	return parsed
+}
+
+// ParseError returns the parsed error of the status, false if the order did not fail
+func (s OrderStatus) ParseError() (*OrderError, bool) {
+	if s.Error == "" {
+		return nil, false
+	}
+	return ParseOrderError(s.Error), true
+}
+
+// parseErrorNumber parses a number of a message, ignoring a trailing period
+func parseErrorNumber(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSuffix(s, "."), 64)
+	return f
+}
@@ -0,0 +1,44 @@
+package types
+
+import "testing"
+
+func TestParseOrderError(t *testing.T) {
+	tests := []struct {
+		message string
+		status  OrderStatusType
+		asset   int // -1 for none
+		check   func(e *OrderError) bool
+	}{
+		{"Order must have minimum value of $10. asset=0", OrderStatusMinTradeNtlRejected, 0,
+			func(e *OrderError) bool { return e.MinValue == 10 }},
+		{"Post only order would have immediately matched, bbo was 97000.5@97001. asset=3", OrderStatusBadAloPxRejected, 3,
+			func(e *OrderError) bool { return e.BboBid == 97000.5 && e.BboAsk == 97001 }},
+		{"Insufficient margin to place order. asset=1", OrderStatusPerpMarginRejected, 1, nil},
+		{"Insufficient spot balance asset=10107", OrderStatusInsufficientSpotBalanceRejected, 10107, nil},
+		{"Order could not immediately match against any resting orders. asset=0", OrderStatusIocCancelRejected, 0, nil},
+		{"Reduce only order would increase position. asset=0", OrderStatusReduceOnlyRejected, 0, nil},
+		{"Order has invalid price.", OrderStatusTickRejected, -1, nil},
+		{"Invalid TP/SL price. asset=0", OrderStatusBadTriggerPxRejected, 0, nil},
+		{"Cannot increase position when open interest is at cap. asset=5", OrderStatusPositionIncreaseAtOpenInterestCapRejected, 5, nil},
+		{"Order price cannot be more than 80% away from the reference price", OrderStatusOracleRejected, -1, nil},
+		{"Something new", OrderStatusRejected, -1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			err := ParseOrderError(tt.message)
+			if err.Status != tt.status || err.Error() != tt.message {
+				t.Errorf("ParseOrderError() = %+v, want status %s", err, tt.status)
+			}
+			if tt.asset < 0 && err.Asset != nil || tt.asset >= 0 && (err.Asset == nil || *err.Asset != tt.asset) {
+				t.Errorf("Asset = %v, want %d", err.Asset, tt.asset)
+			}
+			if tt.check != nil && !tt.check(err) {
+				t.Errorf("ParseOrderError() = %+v", err)
+			}
+		})
+	}
+
+	if _, ok := (OrderStatus{Resting: &RestingOrder{Oid: 1}}).ParseError(); ok {
+		t.Error("ParseError() of a resting order should report no error")
+	}
+}
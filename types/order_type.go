@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidOrderType is returned for an order type without exactly one of Limit and Trigger
+var ErrInvalidOrderType = errors.New("order type must have exactly one of limit and trigger")
+
+// NewLimit returns a limit order type with the time in force tif
+func NewLimit(tif Tif) OrderType {
+	return OrderType{Limit: &LimitOrderType{Tif: tif}}
+}
+
+// NewTrigger returns a trigger order type, executed at market once the price reaches
+// triggerPx if isMarket, else as a limit order at the limit price of the order
+func NewTrigger(triggerPx float64, isMarket bool, tpsl Tpsl) OrderType {
+	return OrderType{Trigger: &TriggerOrderType{TriggerPx: triggerPx, IsMarket: isMarket, Tpsl: tpsl}}
+}
+
+// NewTakeProfit returns a take profit trigger order type, see NewTrigger
+func NewTakeProfit(triggerPx float64, isMarket bool) OrderType {
+	return NewTrigger(triggerPx, isMarket, TpslTp)
+}
+
+// NewStopLoss returns a stop loss trigger order type, see NewTrigger
+func NewStopLoss(triggerPx float64, isMarket bool) OrderType {
+	return NewTrigger(triggerPx, isMarket, TpslSl)
+}
+
+// NewTakeProfitOrder returns a reduce-only take profit order closing sz of a position
+// on coin, so it never opens a position in the other direction. isBuy is the side of
+// the order, true to close a short. limitPx is the limit price once triggered, or the
+// worst price accepted for a market trigger.
+func NewTakeProfitOrder(coin string, isBuy bool, sz, triggerPx, limitPx float64, isMarket bool) OrderRequest {
+	return OrderRequest{
+		Coin:       coin,
+		IsBuy:      isBuy,
+		Sz:         sz,
+		LimitPx:    limitPx,
+		OrderType:  NewTakeProfit(triggerPx, isMarket),
+		ReduceOnly: true,
+	}
+}
+
+// NewStopLossOrder returns a reduce-only stop loss order, see NewTakeProfitOrder
+func NewStopLossOrder(coin string, isBuy bool, sz, triggerPx, limitPx float64, isMarket bool) OrderRequest {
+	return OrderRequest{
+		Coin:       coin,
+		IsBuy:      isBuy,
+		Sz:         sz,
+		LimitPx:    limitPx,
+		OrderType:  NewStopLoss(triggerPx, isMarket),
+		ReduceOnly: true,
+	}
+}
+
+// Check returns ErrInvalidOrderType unless exactly one of Limit and Trigger is set
+func (t OrderType) Check() error {
+	if (t.Limit == nil) == (t.Trigger == nil) {
+		return ErrInvalidOrderType
+	}
+	return nil
+}
+
+// MarshalJSON encodes the order type, failing unless exactly one variant is set
+func (t OrderType) MarshalJSON() ([]byte, error) {
+	if err := t.Check(); err != nil {
+		return nil, err
+	}
+	type orderType OrderType
+	return json.Marshal(orderType(t))
+}
+
+// UnmarshalJSON decodes the order type, failing unless exactly one variant is set
+func (t *OrderType) UnmarshalJSON(data []byte) error {
+	type orderType OrderType
+	var decoded orderType
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if err := OrderType(decoded).Check(); err != nil {
+		return err
+	}
+	*t = OrderType(decoded)
+	return nil
+}
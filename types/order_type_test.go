@@ -0,0 +1,56 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestOrderTypeJSON(t *testing.T) {
+	for _, orderType := range []OrderType{NewLimit(TifAlo), NewTrigger(95000, true, TpslSl)} {
+		data, err := json.Marshal(orderType)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		var decoded OrderType
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", data, err)
+		}
+		if again, _ := json.Marshal(decoded); string(again) != string(data) {
+			t.Errorf("round trip of %s = %s", data, again)
+		}
+	}
+
+	invalid := []OrderType{{}, {Limit: &LimitOrderType{Tif: TifGtc}, Trigger: &TriggerOrderType{TriggerPx: 1, Tpsl: TpslTp}}}
+	for _, orderType := range invalid {
+		if _, err := json.Marshal(orderType); !errors.Is(err, ErrInvalidOrderType) {
+			t.Errorf("Marshal(%+v) error = %v, want ErrInvalidOrderType", orderType, err)
+		}
+	}
+	for _, data := range []string{`{}`, `{"limit":{"tif":"Gtc"},"trigger":{"triggerPx":1,"isMarket":true,"tpsl":"tp"}}`} {
+		var orderType OrderType
+		if err := json.Unmarshal([]byte(data), &orderType); !errors.Is(err, ErrInvalidOrderType) {
+			t.Errorf("Unmarshal(%s) error = %v, want ErrInvalidOrderType", data, err)
+		}
+	}
+}
+
+func TestTpslConstructors(t *testing.T) {
+	if tp := NewTakeProfit(2100, false); tp.Trigger == nil || tp.Trigger.Tpsl != TpslTp || tp.Trigger.IsMarket || tp.Limit != nil {
+		t.Errorf("NewTakeProfit() = %+v", tp)
+	}
+	if sl := NewStopLoss(1900, true); sl.Trigger == nil || sl.Trigger.Tpsl != TpslSl || !sl.Trigger.IsMarket {
+		t.Errorf("NewStopLoss() = %+v", sl)
+	}
+
+	order := NewStopLossOrder("ETH", false, 0.5, 1900, 1850, true)
+	if !order.ReduceOnly || order.IsBuy || order.LimitPx != 1850 || order.OrderType.Trigger.TriggerPx != 1900 {
+		t.Errorf("NewStopLossOrder() = %+v", order)
+	}
+	if err := order.Validate(AssetInfo{Name: "ETH", SzDecimals: 4}); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	if order := NewTakeProfitOrder("ETH", true, 0.5, 1800, 1800, false); !order.ReduceOnly || order.OrderType.Trigger.Tpsl != TpslTp {
+		t.Errorf("NewTakeProfitOrder() = %+v", order)
+	}
+}
@@ -0,0 +1,162 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// PerpMaxDecimals is the maximum number of decimals of perp prices, minus szDecimals
+	PerpMaxDecimals = 6
+	// SpotMaxDecimals is the maximum number of decimals of spot prices, minus szDecimals
+	SpotMaxDecimals = 8
+	// MaxPriceSigFigs is the maximum number of significant figures of non-integer prices
+	MaxPriceSigFigs = 5
+)
+
+// OrderValidationError describes a field of an order the exchange would reject
+type OrderValidationError struct {
+	Coin   string
+	Field  string // "coin", "sz", "limitPx", "triggerPx" or "orderType"
+	Reason string
+	// Err is the error the problem is an instance of, e.g. ErrInvalidOrderType, or nil
+	Err error
+}
+
+func (e *OrderValidationError) Error() string {
+	return fmt.Sprintf("invalid %s for %s order: %s", e.Field, e.Coin, e.Reason)
+}
+
+func (e *OrderValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the order against the rules of the exchange for a perp asset: positive
+// size and prices, size within the szDecimals of the asset, prices within 5 significant
+// figures and 6 - szDecimals decimals, and a consistent order type. All problems are
+// returned joined, each as an *OrderValidationError.
+func (o OrderRequest) Validate(asset AssetInfo) error {
+	return o.validate(asset.SzDecimals, PerpMaxDecimals)
+}
+
+// ValidateSpot is like Validate for a spot asset, whose prices may have 8 - szDecimals
+// decimals, szDecimals being those of the base token
+func (o OrderRequest) ValidateSpot(szDecimals int) error {
+	return o.validate(szDecimals, SpotMaxDecimals)
+}
+
+func (o OrderRequest) validate(szDecimals, maxDecimals int) error {
+	var errs []error
+	invalid := func(field, format string, args ...any) {
+		errs = append(errs, &OrderValidationError{Coin: o.Coin, Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if o.Coin == "" {
+		invalid("coin", "empty coin")
+	}
+
+	if reason := checkSize(o.ExactSz, o.Sz, szDecimals); reason != "" {
+		invalid("sz", "%s", reason)
+	}
+	if reason := checkPrice(o.ExactLimitPx, o.LimitPx, maxDecimals-szDecimals); reason != "" {
+		invalid("limitPx", "%s", reason)
+	}
+
+	invalidType := func(reason string) {
+		errs = append(errs, &OrderValidationError{Coin: o.Coin, Field: "orderType", Reason: reason, Err: ErrInvalidOrderType})
+	}
+	switch limit, trigger := o.OrderType.Limit, o.OrderType.Trigger; {
+	case limit != nil && trigger != nil:
+		if limit.Tif == TifAlo {
+			invalidType("ALO cannot be combined with a trigger")
+		} else {
+			invalidType("both limit and trigger are set")
+		}
+	case limit != nil:
+		switch limit.Tif {
+		case TifAlo, TifIoc, TifGtc:
+		default:
+			invalid("orderType", "unknown tif %q", limit.Tif)
+		}
+	case trigger != nil:
+		if trigger.Tpsl != TpslTp && trigger.Tpsl != TpslSl {
+			invalid("orderType", "unknown tpsl %q", trigger.Tpsl)
+		}
+		if reason := checkPrice(trigger.ExactTriggerPx, trigger.TriggerPx, maxDecimals-szDecimals); reason != "" {
+			invalid("triggerPx", "%s", reason)
+		}
+	default:
+		invalidType("either limit or trigger must be set")
+	}
+
+	return errors.Join(errs...)
+}
+
+// orderNumber returns exact if set, else f, false if f is NaN or infinite
+func orderNumber(exact *Decimal, f float64) (Decimal, bool) {
+	if exact != nil {
+		return *exact, true
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, false
+	}
+	return DecimalFromFloat(f), true
+}
+
+// checkSize returns why the size, exact if set else sz, is not valid with szDecimals
+// decimals, or ""
+func checkSize(exact *Decimal, sz float64, szDecimals int) string {
+	d, ok := orderNumber(exact, sz)
+	if !ok || d.Sign() <= 0 {
+		return fmt.Sprintf("%v is not positive", orderNumberString(exact, sz))
+	}
+	if places := decimalPlaces(d); places > szDecimals {
+		return fmt.Sprintf("%s has %d decimals, more than the %d of the asset", d, places, szDecimals)
+	}
+	return ""
+}
+
+// checkPrice returns why the price, exact if set else px, is not valid with
+// maxDecimals decimals, or ""
+func checkPrice(exact *Decimal, px float64, maxDecimals int) string {
+	d, ok := orderNumber(exact, px)
+	if !ok || d.Sign() <= 0 {
+		return fmt.Sprintf("%v is not positive", orderNumberString(exact, px))
+	}
+	places := decimalPlaces(d)
+	if places > max(maxDecimals, 0) {
+		return fmt.Sprintf("%s has %d decimals, more than the %d allowed", d, places, max(maxDecimals, 0))
+	}
+	// Integer prices are always allowed, whatever their significant figures
+	if places > 0 {
+		if figs := sigFigs(d); figs > MaxPriceSigFigs {
+			return fmt.Sprintf("%s has %d significant figures, more than %d", d, figs, MaxPriceSigFigs)
+		}
+	}
+	return ""
+}
+
+// orderNumberString formats exact if set, else f, for error messages
+func orderNumberString(exact *Decimal, f float64) string {
+	if exact != nil {
+		return exact.String()
+	}
+	return fmt.Sprint(f)
+}
+
+// decimalPlaces returns the number of decimals of d without trailing zeros
+func decimalPlaces(d Decimal) int {
+	s := d.String()
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// sigFigs returns the number of significant figures of d without trailing zeros
+func sigFigs(d Decimal) int {
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(d.String()), "0")
+	return len(digits)
+}
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestOrderRequestValidate(t *testing.T) {
+	btc := AssetInfo{Name: "BTC", SzDecimals: 5, MaxLeverage: 40}
+	gtc := OrderType{Limit: &LimitOrderType{Tif: TifGtc}}
+	valid := OrderRequest{Coin: "BTC", IsBuy: true, Sz: 0.00123, LimitPx: 97123, OrderType: gtc}
+
+	tests := []struct {
+		name   string
+		modify func(o *OrderRequest)
+		fields []string
+	}{
+		{"valid", func(o *OrderRequest) {}, nil},
+		{"integer price beyond 5 significant figures", func(o *OrderRequest) { o.LimitPx = 123456 }, nil},
+		{"zero size", func(o *OrderRequest) { o.Sz = 0 }, []string{"sz"}},
+		{"NaN size", func(o *OrderRequest) { o.Sz = math.NaN() }, []string{"sz"}},
+		{"too precise size", func(o *OrderRequest) { o.Sz = 0.000001 }, []string{"sz"}},
+		{"negative price", func(o *OrderRequest) { o.LimitPx = -1 }, []string{"limitPx"}},
+		{"too many significant figures", func(o *OrderRequest) { o.LimitPx = 97123.5 }, []string{"limitPx"}},
+		{"too many decimals", func(o *OrderRequest) { o.LimitPx = 0.15 }, []string{"limitPx"}},
+		{"missing order type", func(o *OrderRequest) { o.OrderType = OrderType{} }, []string{"orderType"}},
+		{"unknown tif", func(o *OrderRequest) { o.OrderType = OrderType{Limit: &LimitOrderType{Tif: "Fok"}} }, []string{"orderType"}},
+		{"ALO with trigger", func(o *OrderRequest) {
+			o.OrderType = OrderType{
+				Limit:   &LimitOrderType{Tif: TifAlo},
+				Trigger: &TriggerOrderType{TriggerPx: 95000, Tpsl: TpslSl},
+			}
+		}, []string{"orderType"}},
+		{"invalid trigger", func(o *OrderRequest) {
+			o.OrderType = OrderType{Trigger: &TriggerOrderType{TriggerPx: 95000.25, Tpsl: "stop"}}
+		}, []string{"orderType", "triggerPx"}},
+		{"several problems", func(o *OrderRequest) { o.Coin, o.Sz = "", -1 }, []string{"coin", "sz"}},
+		{"exact size", func(o *OrderRequest) { o.Sz, o.ExactSz = 0, exact("0.00001") }, nil},
+		{"too precise exact size", func(o *OrderRequest) { o.ExactSz = exact("0.000001") }, []string{"sz"}},
+		{"too many significant figures of exact price", func(o *OrderRequest) { o.ExactLimitPx = exact("97123.5") }, []string{"limitPx"}},
+		{"negative exact trigger price", func(o *OrderRequest) {
+			o.OrderType = OrderType{Trigger: &TriggerOrderType{TriggerPx: 95000, ExactTriggerPx: exact("-1"), Tpsl: TpslSl}}
+		}, []string{"triggerPx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := valid
+			tt.modify(&order)
+			err := order.Validate(btc)
+			fields := validationFields(err)
+			if len(fields) != len(tt.fields) {
+				t.Fatalf("Validate() = %v, want errors for %v", err, tt.fields)
+			}
+			for i := range fields {
+				if fields[i] != tt.fields[i] {
+					t.Errorf("Validate() = %v, want errors for %v", err, tt.fields)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderRequestValidateOrderType(t *testing.T) {
+	btc := AssetInfo{Name: "BTC", SzDecimals: 5, MaxLeverage: 40}
+	order := OrderRequest{Coin: "BTC", IsBuy: true, Sz: 0.001, LimitPx: 97000}
+	if err := order.Validate(btc); !errors.Is(err, ErrInvalidOrderType) {
+		t.Errorf("Validate() = %v, want ErrInvalidOrderType", err)
+	}
+	order.OrderType = OrderType{Limit: &LimitOrderType{Tif: "Fok"}}
+	if err := order.Validate(btc); err == nil || errors.Is(err, ErrInvalidOrderType) {
+		t.Errorf("Validate() = %v, want an error other than ErrInvalidOrderType", err)
+	}
+}
+
+func TestOrderRequestValidateSpot(t *testing.T) {
+	order := OrderRequest{Coin: "@107", Sz: 1.5, LimitPx: 0.0012345, OrderType: OrderType{Limit: &LimitOrderType{Tif: TifIoc}}}
+	if err := order.ValidateSpot(1); err != nil {
+		t.Errorf("ValidateSpot() = %v", err)
+	}
+	if err := order.Validate(AssetInfo{Name: "@107", SzDecimals: 1}); err == nil {
+		t.Error("Validate() should reject 7 decimals for a perp")
+	}
+}
+
+func validationFields(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var fields []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var validationErr *OrderValidationError
+		if errors.As(err, &validationErr) {
+			fields = append(fields, validationErr.Field)
+		}
+	}
+	return fields
+}
+
+func exact(s string) *Decimal {
+	d := MustParseDecimal(s)
+	return &d
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SideFromIsBuy returns SideBid for a buy and SideAsk for a sell
+func SideFromIsBuy(isBuy bool) Side {
+	if isBuy {
+		return SideBid
+	}
+	return SideAsk
+}
+
+// ParseSide parses a side as sent by the API, "A" or "B" as in the side fields of the
+// ws package, or as "buy", "sell", "bid" or "ask" in any case
+func ParseSide(s string) (Side, error) {
+	switch strings.ToLower(s) {
+	case "b", "buy", "bid":
+		return SideBid, nil
+	case "a", "sell", "ask":
+		return SideAsk, nil
+	}
+	return "", fmt.Errorf("invalid side: %q", s)
+}
+
+// IsBuy reports whether the side is SideBid
+func (s Side) IsBuy() bool {
+	return s == SideBid
+}
+
+// Opposite returns the other side, e.g. the side of an order closing a position
+func (s Side) Opposite() Side {
+	switch s {
+	case SideBid:
+		return SideAsk
+	case SideAsk:
+		return SideBid
+	}
+	return s
+}
+
+// Sign returns 1 for SideBid and -1 for SideAsk, the sign of the position change of a
+// fill, 0 for an invalid side
+func (s Side) Sign() int {
+	switch s {
+	case SideBid:
+		return 1
+	case SideAsk:
+		return -1
+	}
+	return 0
+}
+
+// Valid reports whether the side is SideBid or SideAsk
+func (s Side) Valid() bool {
+	return s == SideBid || s == SideAsk
+}
+
+// Direction is the effect of a fill on the position, as sent in the Dir field of fills
+type Direction string
+
+// Directions of perp fills
+const (
+	DirOpenLong    Direction = "Open Long"
+	DirOpenShort   Direction = "Open Short"
+	DirCloseLong   Direction = "Close Long"
+	DirCloseShort  Direction = "Close Short"
+	DirLongToShort Direction = "Long > Short"
+	DirShortToLong Direction = "Short > Long"
+	// DirBuy and DirSell are the directions of spot fills
+	DirBuy  Direction = "Buy"
+	DirSell Direction = "Sell"
+)
+
+// IsOpen reports whether the fill opened or increased a position
+func (d Direction) IsOpen() bool {
+	return d == DirOpenLong || d == DirOpenShort
+}
+
+// IsClose reports whether the fill reduced or closed a position
+func (d Direction) IsClose() bool {
+	return d == DirCloseLong || d == DirCloseShort
+}
+
+// IsFlip reports whether the fill closed a position and opened one on the other side
+func (d Direction) IsFlip() bool {
+	return d == DirLongToShort || d == DirShortToLong
+}
+
+// IsSpot reports whether the direction is of a spot fill
+func (d Direction) IsSpot() bool {
+	return d == DirBuy || d == DirSell
+}
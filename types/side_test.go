@@ -0,0 +1,47 @@
+package types
+
+import "testing"
+
+func TestSide(t *testing.T) {
+	for _, s := range []string{"B", "b", "buy", "BID"} {
+		if side, err := ParseSide(s); err != nil || side != SideBid {
+			t.Errorf("ParseSide(%q) = %q, %v", s, side, err)
+		}
+	}
+	for _, s := range []string{"A", "Sell", "ask"} {
+		if side, err := ParseSide(s); err != nil || side != SideAsk {
+			t.Errorf("ParseSide(%q) = %q, %v", s, side, err)
+		}
+	}
+	if _, err := ParseSide("long"); err == nil {
+		t.Error("ParseSide(long) should fail")
+	}
+
+	if SideFromIsBuy(true) != SideBid || SideFromIsBuy(false) != SideAsk {
+		t.Error("SideFromIsBuy() mismatch")
+	}
+	if !SideBid.IsBuy() || SideAsk.IsBuy() || SideBid.Opposite() != SideAsk || SideAsk.Opposite() != SideBid {
+		t.Error("IsBuy() or Opposite() mismatch")
+	}
+	if SideBid.Sign() != 1 || SideAsk.Sign() != -1 || Side("X").Sign() != 0 || Side("X").Valid() {
+		t.Error("Sign() or Valid() mismatch")
+	}
+}
+
+func TestDirection(t *testing.T) {
+	tests := []struct {
+		dir                           Direction
+		isOpen, isClose, isFlip, spot bool
+	}{
+		{DirOpenLong, true, false, false, false},
+		{DirCloseShort, false, true, false, false},
+		{DirLongToShort, false, false, true, false},
+		{DirSell, false, false, false, true},
+		{"Liquidated Cross Long", false, false, false, false},
+	}
+	for _, tt := range tests {
+		if tt.dir.IsOpen() != tt.isOpen || tt.dir.IsClose() != tt.isClose || tt.dir.IsFlip() != tt.isFlip || tt.dir.IsSpot() != tt.spot {
+			t.Errorf("flags of %q mismatch", tt.dir)
+		}
+	}
+}
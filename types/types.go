@@ -73,6 +73,8 @@ type TriggerOrderType struct {
 	TriggerPx float64 `json:"triggerPx"`
 	IsMarket  bool    `json:"isMarket"`
 	Tpsl      Tpsl    `json:"tpsl"`
+	// ExactTriggerPx, if set, is sent instead of TriggerPx
+	ExactTriggerPx *Decimal `json:"exactTriggerPx,omitempty"`
 }
 
 // TriggerOrderTypeWire is the wire format for trigger orders
@@ -103,6 +105,10 @@ type OrderRequest struct {
 	OrderType  OrderType `json:"order_type"`
 	ReduceOnly bool      `json:"reduce_only"`
 	Cloid      *Cloid    `json:"cloid,omitempty"`
+	// ExactSz and ExactLimitPx, if set, are sent instead of Sz and LimitPx, so sizes
+	// and prices held as decimals are not rounded through float64
+	ExactSz      *Decimal `json:"exact_sz,omitempty"`
+	ExactLimitPx *Decimal `json:"exact_limit_px,omitempty"`
 }
 
 // OrderWire is the wire format for orders sent to the API
@@ -142,15 +148,18 @@ type CancelByCloidRequest struct {
 
 // AssetInfo represents information about a trading asset
 type AssetInfo struct {
-	Name        string `json:"name"`
-	SzDecimals  int    `json:"szDecimals"`
-	MaxLeverage int    `json:"maxLeverage"`
+	Name          string `json:"name"`
+	SzDecimals    int    `json:"szDecimals"`
+	MaxLeverage   int    `json:"maxLeverage"`
+	MarginTableID int    `json:"marginTableId,omitempty"`
 }
 
 // Meta represents exchange metadata
 type Meta struct {
 	Universe     []AssetInfo       `json:"universe"`
 	MarginTables []MarginTablePair `json:"marginTables,omitempty"`
+
+	assetIndex map[string]int // built by FindAsset
 }
 
 // MarginTier represents a single tier in a margin table
@@ -229,6 +238,8 @@ type SpotTokenInfo struct {
 type SpotMeta struct {
 	Universe []SpotAssetInfo `json:"universe"`
 	Tokens   []SpotTokenInfo `json:"tokens"`
+
+	index *spotMetaIndex // built by the Find methods
 }
 
 // SpotAssetCtx represents spot asset context
@@ -247,6 +258,16 @@ type SpotMetaAndAssetCtxs struct {
 	AssetCtxs []SpotAssetCtx `json:"assetCtxs"`
 }
 
+// UnmarshalJSON supports both the array form [meta, assetCtxs] sent by the API and
+// an object form
+func (m *SpotMetaAndAssetCtxs) UnmarshalJSON(b []byte) error {
+	if ok, err := unmarshalPair(b, &m.Meta, &m.AssetCtxs); ok {
+		return err
+	}
+	type spotMetaAndAssetCtxs SpotMetaAndAssetCtxs
+	return json.Unmarshal(b, (*spotMetaAndAssetCtxs)(m))
+}
+
 // BuilderInfo represents builder fee information
 type BuilderInfo struct {
 	B string `json:"b"` // builder address
@@ -300,6 +321,9 @@ type UserState struct {
 	CrossMarginSummary MarginSummary   `json:"crossMarginSummary"`
 	MarginSummary      MarginSummary   `json:"marginSummary"`
 	Withdrawable       string          `json:"withdrawable"`
+	// CrossMaintenanceMarginUsed is the maintenance margin of the cross positions,
+	// under which the cross account value gets them liquidated
+	CrossMaintenanceMarginUsed string `json:"crossMaintenanceMarginUsed"`
 }
 
 // OpenOrder represents an open order
@@ -312,22 +336,53 @@ type OpenOrder struct {
 	Timestamp int64  `json:"timestamp"`
 }
 
-// Fill represents a trade fill
+// Fill represents a trade fill. The REST and WebSocket APIs send the same fills, so
+// ws.WsFill is an alias of Fill.
 type Fill struct {
-	Coin          string `json:"coin"`
-	Px            string `json:"px"`
-	Sz            string `json:"sz"`
-	Side          Side   `json:"side"`
-	Time          int64  `json:"time"`
-	StartPosition string `json:"startPosition"`
-	Dir           string `json:"dir"`
-	ClosedPnl     string `json:"closedPnl"`
-	Hash          string `json:"hash"`
-	Oid           int    `json:"oid"`
-	Crossed       bool   `json:"crossed"`
-	Fee           string `json:"fee"`
-	Tid           int    `json:"tid"`
-	FeeToken      string `json:"feeToken"`
+	Coin          string           `json:"coin"`
+	Px            string           `json:"px"` // price
+	Sz            string           `json:"sz"` // size
+	Side          Side             `json:"side"`
+	Time          int64            `json:"time"`
+	StartPosition string           `json:"startPosition"`
+	Dir           Direction        `json:"dir"` // used for frontend display
+	ClosedPnl     string           `json:"closedPnl"`
+	Hash          string           `json:"hash"`    // L1 transaction hash
+	Oid           int64            `json:"oid"`     // order id
+	Crossed       bool             `json:"crossed"` // whether order crossed the spread (was taker)
+	Fee           string           `json:"fee"`     // negative means rebate
+	Tid           int64            `json:"tid"`     // unique trade id
+	Liquidation   *FillLiquidation `json:"liquidation,omitempty"`
+	FeeToken      string           `json:"feeToken"`             // the token the fee was paid in
+	BuilderFee    *string          `json:"builderFee,omitempty"` // amount paid to builder
+}
+
+// FillLiquidation represents liquidation details in a fill
+type FillLiquidation struct {
+	LiquidatedUser *string `json:"liquidatedUser,omitempty"`
+	MarkPx         float64 `json:"markPx"`
+	Method         string  `json:"method"` // "market" or "backstop"
+}
+
+// UnmarshalJSON accepts the mark price as a number or, as sent by some endpoints, a string
+func (l *FillLiquidation) UnmarshalJSON(data []byte) error {
+	type fillLiquidation FillLiquidation
+	var raw struct {
+		fillLiquidation
+		MarkPx json.Number `json:"markPx"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*l = FillLiquidation(raw.fillLiquidation)
+	if raw.MarkPx != "" {
+		markPx, err := raw.MarkPx.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid liquidation mark price: %w", err)
+		}
+		l.MarkPx = markPx
+	}
+	return nil
 }
 
 // L2Level represents a level in the L2 order book
@@ -402,34 +457,35 @@ func (c *Cloid) UnmarshalJSON(data []byte) error {
 
 // Exchange Response Types
 
-// ApiResponse is the common response structure for all exchange API calls
-type ApiResponse struct {
+// ApiResponse is the common response structure for all exchange API calls, whose
+// response data is a T when the status is "ok", e.g. ApiResponse[OrderResponse]
+type ApiResponse[T any] struct {
 	Status   string          `json:"status"` // "ok" or "err"
 	Response json.RawMessage `json:"response,omitempty"`
 }
 
 // DecodeResponse decodes the response field based on status
-// If status is not "ok", it decodes response as error string
-// If status is "ok", it decodes response into the provided struct
-func (r *ApiResponse) DecodeResponse(v interface{}) error {
+// If status is not "ok", it decodes response as error string and returns it as error
+// If status is "ok", it decodes response into a T
+func (r *ApiResponse[T]) DecodeResponse() (T, error) {
+	var result T
 	if r.Status != "ok" {
-		// Response is an error string
-		var errMsg string
-		if err := json.Unmarshal(r.Response, &errMsg); err != nil {
-			return fmt.Errorf("failed to decode error message: %w", err)
+		errMsg, err := r.GetError()
+		if err != nil {
+			return result, err
 		}
-		return fmt.Errorf("%s", errMsg)
+		return result, fmt.Errorf("%s", errMsg)
 	}
 
-	// Response is the actual data, decode into v
-	if err := json.Unmarshal(r.Response, v); err != nil {
-		return fmt.Errorf("failed to decode response data: %w", err)
+	// Response is the actual data, decode into result
+	if err := json.Unmarshal(r.Response, &result); err != nil {
+		return result, fmt.Errorf("failed to decode response data: %w", err)
 	}
-	return nil
+	return result, nil
 }
 
 // GetError returns the error message if status is not "ok"
-func (r *ApiResponse) GetError() (string, error) {
+func (r *ApiResponse[T]) GetError() (string, error) {
 	if r.Status == "ok" {
 		return "", nil
 	}
@@ -640,7 +696,8 @@ type SpotUserState struct {
 	Balances []SpotBalance `json:"balances"`
 }
 
-// Candle represents a single candle entry in candle snapshot
+// Candle represents a single candle entry in candle snapshot, as sent by the API.
+// OHLCV returns it with numeric prices and readable names.
 type Candle struct {
 	T  int64  `json:"T"` // end time in ms
 	C  string `json:"c"`
@@ -661,6 +718,24 @@ type FundingRecord struct {
 	Rate string `json:"rate"`
 }
 
+// UserFundingUpdate is a funding payment of the userFunding info request
+type UserFundingUpdate struct {
+	Time  int64        `json:"time"`
+	Hash  string       `json:"hash"`
+	Delta FundingDelta `json:"delta"`
+}
+
+// FundingDelta is the payment of a UserFundingUpdate. Usdc is received, negative when
+// paid, on a position of Szi.
+type FundingDelta struct {
+	Type        string `json:"type"`
+	Coin        string `json:"coin"`
+	Usdc        string `json:"usdc"`
+	Szi         string `json:"szi"`
+	FundingRate string `json:"fundingRate"`
+	NSamples    *int   `json:"nSamples"`
+}
+
 // UserFees represents the user fees summary and schedule
 type UserFees struct {
 	DailyUserVlm                []RawJSON   `json:"dailyUserVlm"`
@@ -778,6 +853,29 @@ type MetaAndAssetCtxs struct {
 	AssetCtxs []PerpAssetCtx `json:"assetCtxs"`
 }
 
+// UnmarshalJSON supports both the array form [meta, assetCtxs] sent by the API and
+// an object form
+func (m *MetaAndAssetCtxs) UnmarshalJSON(b []byte) error {
+	if ok, err := unmarshalPair(b, &m.Meta, &m.AssetCtxs); ok {
+		return err
+	}
+	type metaAndAssetCtxs MetaAndAssetCtxs
+	return json.Unmarshal(b, (*metaAndAssetCtxs)(m))
+}
+
+// unmarshalPair decodes the array form [first, second], returning false if b is not
+// an array of two elements
+func unmarshalPair(b []byte, first, second any) (bool, error) {
+	var arr []json.RawMessage
+	if err := json.Unmarshal(b, &arr); err != nil || len(arr) != 2 {
+		return false, nil
+	}
+	if err := json.Unmarshal(arr[0], first); err != nil {
+		return true, err
+	}
+	return true, json.Unmarshal(arr[1], second)
+}
+
 // PerpDex represents a perpetual DEX entry (shape can vary)
 // PerpDex represents a perpetual DEX entry with known fields where available.
 type PerpDex struct {
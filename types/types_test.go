@@ -0,0 +1,38 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFillUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"mark price number", `{"coin":"ETH","px":"2000.5","sz":"1","side":"A","oid":9007199254740993,"tid":123,"liquidation":{"markPx":1999.5,"method":"market"},"builderFee":"0.01"}`},
+		{"mark price string", `{"coin":"ETH","px":"2000.5","sz":"1","side":"A","oid":9007199254740993,"tid":123,"liquidation":{"markPx":"1999.5","method":"market"},"builderFee":"0.01"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fill Fill
+			if err := json.Unmarshal([]byte(tt.data), &fill); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if fill.Side != SideAsk || fill.Oid != 9007199254740993 || fill.Tid != 123 {
+				t.Errorf("fill = %+v", fill)
+			}
+			if fill.Liquidation == nil || fill.Liquidation.MarkPx != 1999.5 || fill.Liquidation.Method != "market" {
+				t.Errorf("liquidation = %+v", fill.Liquidation)
+			}
+			if fill.BuilderFee == nil || *fill.BuilderFee != "0.01" {
+				t.Errorf("builderFee = %v", fill.BuilderFee)
+			}
+		})
+	}
+
+	var liquidation FillLiquidation
+	if err := json.Unmarshal([]byte(`{"markPx":"abc"}`), &liquidation); err == nil {
+		t.Error("Unmarshal() of an invalid mark price should fail")
+	}
+}
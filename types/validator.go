@@ -0,0 +1,70 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ValidatorSummary is a validator of the validatorSummaries info, with its stake in
+// HYPE wei and its uptime over the periods of Stats
+type ValidatorSummary struct {
+	Validator     string `json:"validator"`
+	Signer        string `json:"signer"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	NRecentBlocks int    `json:"nRecentBlocks"`
+	Stake         int64  `json:"stake"`
+	IsJailed      bool   `json:"isJailed"`
+	// UnjailableAfter is the time in milliseconds after which a jailed validator may
+	// unjail itself, nil if not jailed
+	UnjailableAfter *int64 `json:"unjailableAfter"`
+	IsActive        bool   `json:"isActive"`
+	// Commission is the fraction of the rewards kept by the validator, e.g. "0.04"
+	Commission string `json:"commission"`
+	// Stats are sent as [period, stats] pairs, the periods being "day", "week" and
+	// "month"
+	Stats []ValidatorPeriodStats `json:"stats"`
+}
+
+// ValidatorPeriodStats are the stats of a validator over a period
+type ValidatorPeriodStats struct {
+	Period string
+	ValidatorStats
+}
+
+// ValidatorStats are the uptime and predicted APR of a validator
+type ValidatorStats struct {
+	UptimeFraction string `json:"uptimeFraction"`
+	PredictedApr   string `json:"predictedApr"`
+	NSamples       int    `json:"nSamples"`
+}
+
+// UnmarshalJSON decodes the [period, stats] pair of the API
+func (s *ValidatorPeriodStats) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("invalid validator stats: %s", b)
+	}
+	if err := json.Unmarshal(pair[0], &s.Period); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &s.ValidatorStats)
+}
+
+// MarshalJSON encodes the stats as the [period, stats] pair of the API
+func (s ValidatorPeriodStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{s.Period, s.ValidatorStats})
+}
+
+// StatsFor returns the stats of the validator over period, e.g. "day"
+func (s *ValidatorSummary) StatsFor(period string) (ValidatorStats, bool) {
+	for _, stats := range s.Stats {
+		if stats.Period == period {
+			return stats.ValidatorStats, true
+		}
+	}
+	return ValidatorStats{}, false
+}
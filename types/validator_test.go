@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const validatorSummariesJSON = `[{
+	"validator": "0x5ac99df645f3414876c816caa18b2d234024b487",
+	"signer": "0x6b6bc1ff0a4b5c0e7e2d5c8b0a4c1e2f3a4b5c6d",
+	"name": "Hypurr",
+	"description": "",
+	"nRecentBlocks": 1200,
+	"stake": 2500000000000,
+	"isJailed": true,
+	"unjailableAfter": 1736000000000,
+	"isActive": true,
+	"commission": "0.04",
+	"stats": [
+		["day", {"uptimeFraction": "0.95", "predictedApr": "0.021", "nSamples": 1440}],
+		["week", {"uptimeFraction": "0.99", "predictedApr": "0.022", "nSamples": 10080}]
+	]
+}]`
+
+func TestValidatorSummary(t *testing.T) {
+	var summaries []ValidatorSummary
+	if err := json.Unmarshal([]byte(validatorSummariesJSON), &summaries); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(summaries))
+	}
+	s := summaries[0]
+	if s.Name != "Hypurr" || s.Stake != 2_500_000_000_000 || !s.IsJailed || s.Commission != "0.04" {
+		t.Errorf("summary = %+v", s)
+	}
+	if s.UnjailableAfter == nil || *s.UnjailableAfter != 1_736_000_000_000 {
+		t.Errorf("UnjailableAfter = %v", s.UnjailableAfter)
+	}
+
+	day, ok := s.StatsFor("day")
+	if !ok || day.UptimeFraction != "0.95" || day.NSamples != 1440 {
+		t.Errorf("StatsFor(day) = %+v, %v", day, ok)
+	}
+	if _, ok := s.StatsFor("month"); ok {
+		t.Error("StatsFor(month) found stats not sent")
+	}
+
+	// Round trip through the pair form
+	data, err := json.Marshal(s.Stats)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var stats []ValidatorPeriodStats
+	if err := json.Unmarshal(data, &stats); err != nil || len(stats) != 2 || stats[1].Period != "week" || stats[1].PredictedApr != "0.022" {
+		t.Errorf("round trip = %+v, %v", stats, err)
+	}
+
+	var bad ValidatorPeriodStats
+	if err := json.Unmarshal([]byte(`["day"]`), &bad); err == nil {
+		t.Error("Unmarshal() of a single element accepted")
+	}
+}
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// ToWire converts the order type to the form signed and sent to the exchange, the
+// trigger price as a normalized decimal string
+func (o OrderType) ToWire() (OrderTypeWire, error) {
+	wire := OrderTypeWire{}
+	if err := o.Check(); err != nil {
+		return wire, err
+	}
+
+	if o.Limit != nil {
+		wire.Limit = o.Limit
+		return wire, nil
+	}
+	triggerPx, err := numberToWire(o.Trigger.ExactTriggerPx, o.Trigger.TriggerPx)
+	if err != nil {
+		return wire, fmt.Errorf("invalid trigger price: %w", err)
+	}
+	wire.Trigger = &TriggerOrderTypeWire{
+		TriggerPx: triggerPx,
+		IsMarket:  o.Trigger.IsMarket,
+		Tpsl:      o.Trigger.Tpsl,
+	}
+	return wire, nil
+}
+
+// Size returns the size sent for the order, ExactSz if set, else Sz
+func (o OrderRequest) Size() float64 {
+	if o.ExactSz != nil {
+		return o.ExactSz.Float64()
+	}
+	return o.Sz
+}
+
+// Price returns the limit price sent for the order, ExactLimitPx if set, else LimitPx
+func (o OrderRequest) Price() float64 {
+	if o.ExactLimitPx != nil {
+		return o.ExactLimitPx.Float64()
+	}
+	return o.LimitPx
+}
+
+// Price returns the trigger price sent for the order type, ExactTriggerPx if set, else
+// TriggerPx
+func (t TriggerOrderType) Price() float64 {
+	if t.ExactTriggerPx != nil {
+		return t.ExactTriggerPx.Float64()
+	}
+	return t.TriggerPx
+}
+
+// ToWire converts the order to the form signed and sent to the exchange, for the
+// asset id of its coin. ExactSz and ExactLimitPx are used instead of Sz and LimitPx
+// if set.
+func (o OrderRequest) ToWire(asset int) (OrderWire, error) {
+	limitPx, err := numberToWire(o.ExactLimitPx, o.LimitPx)
+	if err != nil {
+		return OrderWire{}, fmt.Errorf("invalid limit price: %w", err)
+	}
+
+	sz, err := numberToWire(o.ExactSz, o.Sz)
+	if err != nil {
+		return OrderWire{}, fmt.Errorf("invalid size: %w", err)
+	}
+
+	orderType, err := o.OrderType.ToWire()
+	if err != nil {
+		return OrderWire{}, err
+	}
+
+	wire := OrderWire{
+		Asset:      asset,
+		IsBuy:      o.IsBuy,
+		LimitPx:    limitPx,
+		Sz:         sz,
+		ReduceOnly: o.ReduceOnly,
+		OrderType:  orderType,
+	}
+	if o.Cloid != nil {
+		raw := o.Cloid.ToRaw()
+		wire.Cloid = &raw
+	}
+	return wire, nil
+}
+
+// ToWire converts the modify to the form signed and sent to the exchange, for the
+// asset id of the coin of its order. A *Cloid oid is sent as its raw hex string.
+func (m ModifyRequest) ToWire(asset int) (ModifyWire, error) {
+	order, err := m.Order.ToWire(asset)
+	if err != nil {
+		return ModifyWire{}, err
+	}
+
+	oid := m.Oid
+	if cloid, ok := m.Oid.(*Cloid); ok {
+		oid = cloid.ToRaw()
+	}
+	return ModifyWire{Oid: oid, Order: order}, nil
+}
+
+// DecimalToWire converts a decimal to the string representation expected by the API,
+// failing if it has more than 8 decimals
+func DecimalToWire(d Decimal) (string, error) {
+	return utils.StringToWire(d.String())
+}
+
+// numberToWire converts exact if set, else f
+func numberToWire(exact *Decimal, f float64) (string, error) {
+	if exact != nil {
+		return DecimalToWire(*exact)
+	}
+	return utils.FloatToWire(f)
+}
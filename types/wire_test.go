@@ -0,0 +1,157 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestOrderRequestToWire(t *testing.T) {
+	cloid := NewCloidFromInt(12345)
+	tests := []struct {
+		name    string
+		order   OrderRequest
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "limit",
+			order: OrderRequest{Coin: "ETH", IsBuy: true, Sz: 0.1, LimitPx: 2000, OrderType: NewLimit(TifGtc)},
+			want:  `{"a":4,"b":true,"p":"2000","s":"0.1","r":false,"t":{"limit":{"tif":"Gtc"}}}`,
+		},
+		{
+			name:  "trailing zeros and cloid",
+			order: OrderRequest{Coin: "ETH", Sz: 1.5, LimitPx: 0.000123, OrderType: NewLimit(TifAlo), ReduceOnly: true, Cloid: cloid},
+			want:  `{"a":4,"b":false,"p":"0.000123","s":"1.5","r":true,"t":{"limit":{"tif":"Alo"}},"c":"0x00000000000000000000000000003039"}`,
+		},
+		{
+			name:  "trigger",
+			order: NewStopLossOrder("ETH", false, 0.5, 1800.5, 1790, true),
+			want:  `{"a":4,"b":false,"p":"1790","s":"0.5","r":true,"t":{"trigger":{"triggerPx":"1800.5","isMarket":true,"tpsl":"sl"}}}`,
+		},
+		{
+			name: "exact size and prices",
+			order: OrderRequest{
+				Coin: "ETH", Sz: 1, LimitPx: 1, ExactSz: exact("0.30"), ExactLimitPx: exact("0.12345678"),
+				OrderType: OrderType{Trigger: &TriggerOrderType{TriggerPx: 1, ExactTriggerPx: exact("2000.10"), Tpsl: TpslTp}},
+			},
+			want: `{"a":4,"b":false,"p":"0.12345678","s":"0.3","r":false,"t":{"trigger":{"triggerPx":"2000.1","isMarket":false,"tpsl":"tp"}}}`,
+		},
+		{
+			name:    "too precise exact size",
+			order:   OrderRequest{Coin: "ETH", ExactSz: exact("0.000000001"), LimitPx: 1, OrderType: NewLimit(TifGtc)},
+			wantErr: true,
+		},
+		{
+			name:    "rounded limit price",
+			order:   OrderRequest{Coin: "ETH", Sz: 1, LimitPx: 0.123456789, OrderType: NewLimit(TifGtc)},
+			wantErr: true,
+		},
+		{
+			name:    "rounded size",
+			order:   OrderRequest{Coin: "ETH", Sz: 1e-9, LimitPx: 1, OrderType: NewLimit(TifGtc)},
+			wantErr: true,
+		},
+		{
+			name:    "rounded trigger price",
+			order:   OrderRequest{Coin: "ETH", Sz: 1, LimitPx: 1, OrderType: NewTrigger(1.000000001, true, TpslTp)},
+			wantErr: true,
+		},
+		{
+			name:    "no order type",
+			order:   OrderRequest{Coin: "ETH", Sz: 1, LimitPx: 1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wire, err := tt.order.ToWire(4)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToWire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			data, err := json.Marshal(wire)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("ToWire() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderRequestSizeAndPrice(t *testing.T) {
+	sz, px := MustParseDecimal("0.3"), MustParseDecimal("2000.5")
+	tests := []struct {
+		name    string
+		order   OrderRequest
+		sz, px  float64
+		trigger float64
+	}{
+		{"floats", OrderRequest{Sz: 1, LimitPx: 2000, OrderType: NewStopLoss(1900, true)}, 1, 2000, 1900},
+		{"exact", OrderRequest{Sz: 1, LimitPx: 2000, ExactSz: &sz, ExactLimitPx: &px, OrderType: OrderType{Trigger: &TriggerOrderType{TriggerPx: 1900, ExactTriggerPx: &px}}}, 0.3, 2000.5, 2000.5},
+		{"exact only", OrderRequest{ExactSz: &sz, ExactLimitPx: &px, OrderType: NewLimit(TifGtc)}, 0.3, 2000.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.order.Size(); got != tt.sz {
+				t.Errorf("Size() = %v, want %v", got, tt.sz)
+			}
+			if got := tt.order.Price(); got != tt.px {
+				t.Errorf("Price() = %v, want %v", got, tt.px)
+			}
+			if trigger := tt.order.OrderType.Trigger; trigger != nil && trigger.Price() != tt.trigger {
+				t.Errorf("Trigger.Price() = %v, want %v", trigger.Price(), tt.trigger)
+			}
+		})
+	}
+}
+
+func TestOrderTypeToWire(t *testing.T) {
+	if _, err := (OrderType{}).ToWire(); !errors.Is(err, ErrInvalidOrderType) {
+		t.Errorf("ToWire() of an empty order type error = %v", err)
+	}
+	limit := NewLimit(TifIoc)
+	wire, err := limit.ToWire()
+	if err != nil || wire.Limit != limit.Limit || wire.Trigger != nil {
+		t.Errorf("ToWire() = %+v, %v", wire, err)
+	}
+}
+
+func TestModifyRequestToWire(t *testing.T) {
+	cloid := NewCloidFromInt(1)
+	order := OrderRequest{Coin: "BTC", IsBuy: true, Sz: 0.01, LimitPx: 97000, OrderType: NewLimit(TifGtc)}
+	tests := []struct {
+		oid  any
+		want string
+	}{
+		{int64(123), `{"oid":123,"order":{"a":0,"b":true,"p":"97000","s":"0.01","r":false,"t":{"limit":{"tif":"Gtc"}}}}`},
+		{cloid, `{"oid":"0x00000000000000000000000000000001","order":{"a":0,"b":true,"p":"97000","s":"0.01","r":false,"t":{"limit":{"tif":"Gtc"}}}}`},
+	}
+	for _, tt := range tests {
+		wire, err := ModifyRequest{Oid: tt.oid, Order: order}.ToWire(0)
+		if err != nil {
+			t.Fatalf("ToWire() error = %v", err)
+		}
+		data, _ := json.Marshal(wire)
+		if string(data) != tt.want {
+			t.Errorf("ToWire() = %s, want %s", data, tt.want)
+		}
+	}
+
+	if _, err := (ModifyRequest{Oid: 1, Order: OrderRequest{Sz: 1, LimitPx: 1}}).ToWire(0); err == nil {
+		t.Error("ToWire() of an invalid order should fail")
+	}
+}
+
+func TestDecimalToWire(t *testing.T) {
+	if got, err := DecimalToWire(MustParseDecimal("1.50e3")); err != nil || got != "1500" {
+		t.Errorf("DecimalToWire(1.50e3) = %q, %v", got, err)
+	}
+	if _, err := DecimalToWire(MustParseDecimal("1e-9")); err == nil {
+		t.Error("DecimalToWire(1e-9) should fail")
+	}
+}
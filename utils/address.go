@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ValidateAddress checks that address is a 0x-prefixed 20-byte hex address. Mixed-case
+// addresses must carry a valid EIP-55 checksum; all-lowercase and all-uppercase
+// addresses are accepted as is.
+func ValidateAddress(address string) error {
+	if !strings.HasPrefix(address, "0x") {
+		return fmt.Errorf("address %q must start with 0x", address)
+	}
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("address %q is not 20 hex bytes", address)
+	}
+	digits := address[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) {
+		if checksummed := common.HexToAddress(address).Hex(); checksummed != address {
+			return fmt.Errorf("address %q has an invalid checksum, expected %s", address, checksummed)
+		}
+	}
+	return nil
+}
+
+// NormalizeAddress validates address and returns it in lowercase, as the API sends
+// addresses
+func NormalizeAddress(address string) (string, error) {
+	if err := ValidateAddress(address); err != nil {
+		return "", err
+	}
+	return strings.ToLower(address), nil
+}
+
+// ChecksumAddress validates address and returns it with its EIP-55 checksum, e.g. for
+// display
+func ChecksumAddress(address string) (string, error) {
+	if err := ValidateAddress(address); err != nil {
+		return "", err
+	}
+	return common.HexToAddress(address).Hex(), nil
+}
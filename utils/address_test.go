@@ -0,0 +1,36 @@
+package utils
+
+import "testing"
+
+func TestValidateAddress(t *testing.T) {
+	const checksummed = "0x8c967E73E7B15087c42A10D344cFf4c96D877f1D"
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{checksummed, false},
+		{"0x8c967e73e7b15087c42a10d344cff4c96d877f1d", false},
+		{"0x8C967E73E7B15087C42A10D344CFF4C96D877F1D", false},
+		{"0x8C967E73E7B15087c42A10D344cFf4c96D877f1D", true}, // bad checksum
+		{"8c967e73e7b15087c42a10d344cff4c96d877f1d", true},
+		{"0x8c967e73e7b15087c42a10d344cff4c96d877f", true},
+		{"0x8c967e73e7b15087c42a10d344cff4c96d877f1d00", true},
+		{"0xzz967e73e7b15087c42a10d344cff4c96d877f1d", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateAddress(tt.address); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+		}
+	}
+
+	if got, err := NormalizeAddress(checksummed); err != nil || got != "0x8c967e73e7b15087c42a10d344cff4c96d877f1d" {
+		t.Errorf("NormalizeAddress() = %q, %v", got, err)
+	}
+	if got, err := ChecksumAddress("0x8c967e73e7b15087c42a10d344cff4c96d877f1d"); err != nil || got != checksummed {
+		t.Errorf("ChecksumAddress() = %q, %v", got, err)
+	}
+	if _, err := NormalizeAddress("0x1"); err == nil {
+		t.Error("NormalizeAddress(0x1) should fail")
+	}
+}
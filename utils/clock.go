@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time used for nonces and expirations, so code depending on it can be
+// tested deterministically
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the real clock
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// TimestampMs returns the time of clock in milliseconds, the real time if clock is nil
+func TimestampMs(clock Clock) int64 {
+	if clock == nil {
+		return GetTimestampMs()
+	}
+	return clock.Now().UnixMilli()
+}
+
+// FakeClock is a clock that only moves when told, for tests. Safe for concurrent use.
+type FakeClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock creates a clock stopped at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the clock, then moves it by the step if one is set
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Set moves the clock to t
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SetStep makes each call to Now move the clock forward by step, e.g. a millisecond so
+// consecutive nonces differ. A step of 0 stops the clock.
+func (c *FakeClock) SetStep(step time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.step = step
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.UnixMilli(1700000000000)
+	clock := NewFakeClock(start)
+	if !clock.Now().Equal(start) || !clock.Now().Equal(start) {
+		t.Error("stopped clock moved")
+	}
+
+	clock.Advance(time.Second)
+	if got := TimestampMs(clock); got != 1700000001000 {
+		t.Errorf("TimestampMs() after Advance = %d", got)
+	}
+
+	clock.SetStep(time.Millisecond)
+	first, second := TimestampMs(clock), TimestampMs(clock)
+	if first != 1700000001000 || second != first+1 {
+		t.Errorf("TimestampMs() with step = %d, %d", first, second)
+	}
+
+	clock.Set(start)
+	if got := TimestampMs(clock); got != start.UnixMilli() {
+		t.Errorf("TimestampMs() after Set = %d", got)
+	}
+	if got := TimestampMs(nil); got < start.UnixMilli() {
+		t.Errorf("TimestampMs(nil) = %d, want the real time", got)
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatNumber formats x with at most places decimals, without trailing zeros and with
+// thousands separators, e.g. 1670.10 with 2 places as "1,670.1"
+func FormatNumber(x float64, places int) string {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(x, 'f', max(places, 0), 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return groupThousands(s)
+}
+
+// FormatSize formats a size with the szDecimals of its asset, e.g. "0.0147"
+func FormatSize(sz float64, szDecimals int) string {
+	return FormatNumber(sz, szDecimals)
+}
+
+// FormatPrice formats a price with the precision the exchange accepts for an asset with
+// szDecimals, see RoundToTick
+func FormatPrice(px float64, szDecimals int, isSpot bool) string {
+	maxDecimals := perpMaxDecimals
+	if isSpot {
+		maxDecimals = spotMaxDecimals
+	}
+	return FormatNumber(RoundToTick(px, szDecimals, isSpot), maxDecimals-szDecimals)
+}
+
+// FormatUSD formats an amount of USD with cents, e.g. "$24,551.47" or "-$3.10"
+func FormatUSD(x float64) string {
+	s := groupThousands(strconv.FormatFloat(math.Abs(x), 'f', 2, 64))
+	if x < 0 && s != "0.00" {
+		return "-$" + s
+	}
+	return "$" + s
+}
+
+// groupThousands inserts commas between the groups of three digits of the integer part
+// of a formatted number
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if hasFrac {
+		b.WriteByte('.')
+		b.WriteString(fracPart)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		x      float64
+		places int
+		want   string
+	}{
+		{1670.1, 2, "1,670.1"},
+		{1234567.891, 2, "1,234,567.89"},
+		{0.0147, 4, "0.0147"},
+		{100, 4, "100"},
+		{999.9999, 2, "1,000"},
+		{-1234.5, 1, "-1,234.5"},
+		{-0.0001, 2, "0"},
+		{12.5, 0, "12"},
+	}
+	for _, tt := range tests {
+		if got := FormatNumber(tt.x, tt.places); got != tt.want {
+			t.Errorf("FormatNumber(%v, %d) = %q, want %q", tt.x, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSizePriceUSD(t *testing.T) {
+	if got := FormatSize(0.014719, 4); got != "0.0147" {
+		t.Errorf("FormatSize() = %q", got)
+	}
+	if got := FormatPrice(1670.1234, 4, false); got != "1,670.1" {
+		t.Errorf("FormatPrice() = %q", got)
+	}
+	if got := FormatPrice(97123.456, 5, false); got != "97,123" {
+		t.Errorf("FormatPrice() = %q", got)
+	}
+	if got := FormatPrice(0.000123456, 0, true); got != "0.00012346" {
+		t.Errorf("FormatPrice() of spot = %q", got)
+	}
+	tests := map[float64]string{24551.4667: "$24,551.47", -3.1: "-$3.10", 0: "$0.00", -0.001: "$0.00"}
+	for x, want := range tests {
+		if got := FormatUSD(x); got != want {
+			t.Errorf("FormatUSD(%v) = %q, want %q", x, got, want)
+		}
+	}
+}
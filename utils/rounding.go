@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+)
+
+// Precision rules of the exchange, also exposed as types.PerpMaxDecimals,
+// types.SpotMaxDecimals and types.MaxPriceSigFigs
+const (
+	perpMaxDecimals = 6
+	spotMaxDecimals = 8
+	maxPriceSigFigs = 5
+)
+
+// RoundToTick rounds a price to the nearest price the exchange accepts for an asset
+// with szDecimals: at most 5 significant figures and 6 (perp) or 8 (spot) minus
+// szDecimals decimals. Integer prices are always accepted, so prices of 100000 and
+// above are rounded to integers rather than to 5 significant figures.
+func RoundToTick(px float64, szDecimals int, isSpot bool) float64 {
+	if px == 0 || math.IsNaN(px) || math.IsInf(px, 0) {
+		return px
+	}
+
+	maxDecimals := perpMaxDecimals
+	if isSpot {
+		maxDecimals = spotMaxDecimals
+	}
+	magnitude := int(math.Floor(math.Log10(math.Abs(px))))
+	places := min(maxDecimals-szDecimals, maxPriceSigFigs-1-magnitude)
+	return roundPlaces(px, max(places, 0))
+}
+
+// RoundToLot rounds a size to the nearest size the exchange accepts for an asset with
+// szDecimals, i.e. to szDecimals decimals
+func RoundToLot(sz float64, szDecimals int) float64 {
+	if math.IsNaN(sz) || math.IsInf(sz, 0) {
+		return sz
+	}
+	return roundPlaces(sz, max(szDecimals, 0))
+}
+
+// roundPlaces rounds x to places decimals through its decimal representation, so the
+// result has no binary artifacts such as 0.30000000000000004. Ties round to even.
+func roundPlaces(x float64, places int) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(x, 'f', places, 64), 64)
+	return rounded
+}
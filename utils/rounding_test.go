@@ -0,0 +1,47 @@
+package utils
+
+import "testing"
+
+func TestRoundToTick(t *testing.T) {
+	tests := []struct {
+		px         float64
+		szDecimals int
+		isSpot     bool
+		want       float64
+	}{
+		{97123.456, 5, false, 97123},
+		{123456.78, 0, false, 123457},
+		{3012.3456, 4, false, 3012.3},
+		{1.234567, 0, false, 1.2346},
+		{0.000123456, 0, false, 0.000123},
+		{0.000123456, 0, true, 0.00012346},
+		{0.000123456, 2, true, 0.000123},
+		{25.123456, 7, false, 25},
+		{-1.234567, 0, false, -1.2346},
+		{0, 3, false, 0},
+	}
+	for _, tt := range tests {
+		if got := RoundToTick(tt.px, tt.szDecimals, tt.isSpot); got != tt.want {
+			t.Errorf("RoundToTick(%v, %d, %v) = %v, want %v", tt.px, tt.szDecimals, tt.isSpot, got, tt.want)
+		}
+	}
+}
+
+func TestRoundToLot(t *testing.T) {
+	tests := []struct {
+		sz         float64
+		szDecimals int
+		want       float64
+	}{
+		{0.123456, 4, 0.1235},
+		{0.1 + 0.2, 2, 0.3},
+		{12.5, 0, 12},
+		{13.5, 0, 14},
+		{1.00001, 5, 1.00001},
+	}
+	for _, tt := range tests {
+		if got := RoundToLot(tt.sz, tt.szDecimals); got != tt.want {
+			t.Errorf("RoundToLot(%v, %d) = %v, want %v", tt.sz, tt.szDecimals, got, tt.want)
+		}
+	}
+}
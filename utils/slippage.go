@@ -0,0 +1,35 @@
+package utils
+
+// BpsPerUnit is the number of basis points in 1, i.e. 100%
+const BpsPerUnit = 10000
+
+// BpsToFraction converts basis points to a fraction, e.g. 50 bps to 0.005
+func BpsToFraction(bps float64) float64 {
+	return bps / BpsPerUnit
+}
+
+// FractionToBps converts a fraction to basis points, e.g. 0.005 to 50 bps
+func FractionToBps(fraction float64) float64 {
+	return fraction * BpsPerUnit
+}
+
+// SlippagePx returns the worst price accepted for an order around px, slippageBps
+// above px for a buy and below for a sell
+func SlippagePx(px, slippageBps float64, isBuy bool) float64 {
+	if isBuy {
+		return px * (1 + BpsToFraction(slippageBps))
+	}
+	return px * (1 - BpsToFraction(slippageBps))
+}
+
+// SlippageBps returns the slippage in basis points of a fill at px against refPx,
+// positive when the fill is worse than refPx: above it for a buy, below it for a sell
+func SlippageBps(refPx, px float64, isBuy bool) float64 {
+	if refPx == 0 {
+		return 0
+	}
+	if isBuy {
+		return FractionToBps((px - refPx) / refPx)
+	}
+	return FractionToBps((refPx - px) / refPx)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSlippagePx(t *testing.T) {
+	tests := []struct {
+		px    float64
+		bps   float64
+		isBuy bool
+		want  float64
+	}{
+		{100, 50, true, 100.5},
+		{100, 50, false, 99.5},
+		{2000, 0, true, 2000},
+		{2000, 500, false, 1900},
+	}
+	for _, tt := range tests {
+		got := SlippagePx(tt.px, tt.bps, tt.isBuy)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("SlippagePx(%v, %v, %v) = %v, want %v", tt.px, tt.bps, tt.isBuy, got, tt.want)
+		}
+		if bps := SlippageBps(tt.px, got, tt.isBuy); math.Abs(bps-tt.bps) > 1e-6 {
+			t.Errorf("SlippageBps(%v, %v, %v) = %v, want %v", tt.px, got, tt.isBuy, bps, tt.bps)
+		}
+	}
+	// A fill better than the reference is negative slippage
+	if bps := SlippageBps(100, 99, true); math.Abs(bps+100) > 1e-9 {
+		t.Errorf("SlippageBps(100, 99, true) = %v, want -100", bps)
+	}
+	if BpsToFraction(25) != 0.0025 || FractionToBps(0.05) != 500 {
+		t.Errorf("BpsToFraction(25) = %v, FractionToBps(0.05) = %v", BpsToFraction(25), FractionToBps(0.05))
+	}
+}
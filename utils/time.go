@@ -0,0 +1,30 @@
+package utils
+
+import "time"
+
+// TimeWindow is a range of time [Start, End) in milliseconds
+type TimeWindow struct {
+	Start int64
+	End   int64
+}
+
+// Duration returns the length of the window
+func (w TimeWindow) Duration() time.Duration {
+	return time.Duration(w.End-w.Start) * time.Millisecond
+}
+
+// SplitTimeRange splits [startMs, endMs) into consecutive windows of at most window,
+// for requests whose responses are capped such as fills or funding history. The last
+// window ends at endMs.
+func SplitTimeRange(startMs, endMs int64, window time.Duration) []TimeWindow {
+	step := window.Milliseconds()
+	if step <= 0 {
+		step = endMs - startMs
+	}
+
+	var windows []TimeWindow
+	for start := startMs; start < endMs; start += step {
+		windows = append(windows, TimeWindow{Start: start, End: min(start+step, endMs)})
+	}
+	return windows
+}
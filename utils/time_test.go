@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitTimeRange(t *testing.T) {
+	got := SplitTimeRange(0, 250_000, 100*time.Second)
+	want := []TimeWindow{{0, 100_000}, {100_000, 200_000}, {200_000, 250_000}}
+	if len(got) != len(want) {
+		t.Fatalf("SplitTimeRange() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("window %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if got[2].Duration() != 50*time.Second {
+		t.Errorf("Duration() = %v", got[2].Duration())
+	}
+
+	if got := SplitTimeRange(10, 20, 0); len(got) != 1 || got[0] != (TimeWindow{10, 20}) {
+		t.Errorf("SplitTimeRange() without window = %v", got)
+	}
+	if got := SplitTimeRange(20, 10, time.Second); len(got) != 0 {
+		t.Errorf("SplitTimeRange() of an empty range = %v", got)
+	}
+}
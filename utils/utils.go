@@ -37,6 +37,40 @@ func FloatToWire(x float64) (string, error) {
 	return normalized, nil
 }
 
+// StringToWire normalizes a decimal string such as "0.100" or "+12.5" to the
+// representation expected by the API, without a round trip through float64. It fails
+// on malformed numbers, exponents, and more than 8 decimals, which FloatToWire would
+// round.
+func StringToWire(s string) (string, error) {
+	mantissa := strings.TrimPrefix(s, "+")
+	negative := strings.HasPrefix(mantissa, "-")
+	mantissa = strings.TrimPrefix(mantissa, "-")
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if intPart+fracPart == "" || strings.Trim(intPart+fracPart, "0123456789") != "" {
+		return "", fmt.Errorf("invalid decimal string: %q", s)
+	}
+
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > 8 {
+		return "", fmt.Errorf("string_to_wire causes rounding: %s", s)
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	normalized := intPart
+	if fracPart != "" {
+		normalized += "." + fracPart
+	}
+	// Handle -0 case
+	if negative && normalized != "0" {
+		normalized = "-" + normalized
+	}
+	return normalized, nil
+}
+
 // FloatToIntForHashing converts a float to an integer for hashing (8 decimals)
 func FloatToIntForHashing(x float64) (int64, error) {
 	return FloatToInt(x, 8)
@@ -0,0 +1,50 @@
+package utils
+
+import "testing"
+
+func TestStringToWire(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"0.100", "0.1", false},
+		{"+12.50", "12.5", false},
+		{"-0.00", "0", false},
+		{"007", "7", false},
+		{".5", "0.5", false},
+		{"5.", "5", false},
+		{"97000", "97000", false},
+		{"0.12345678", "0.12345678", false},
+		{"0.123456780000", "0.12345678", false},
+		{"123456789.123456789", "", true},
+		{"0.000000001", "", true},
+		{"1e-8", "", true},
+		{"", "", true},
+		{".", "", true},
+		{"-", "", true},
+		{"1.2.3", "", true},
+		{"--1", "", true},
+		{" 1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := StringToWire(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StringToWire(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StringToWire(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	// Same output as FloatToWire for the values it represents exactly
+	for _, x := range []float64{0.1, 1.5, 97000, 0.00012345} {
+		fromFloat, _ := FloatToWire(x)
+		fromString, _ := StringToWire(FormatFloat(x))
+		if fromFloat != fromString {
+			t.Errorf("FloatToWire(%v) = %q, StringToWire = %q", x, fromFloat, fromString)
+		}
+	}
+}
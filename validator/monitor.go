@@ -0,0 +1,422 @@
+// Package validator watches a validator for its operators. A Monitor polls the
+// validator summaries, reports jailing, changes of stake, commission and activity and
+// low uptime, and unjails the validator with its signer once it may, backing off
+// while the unjail does not take:
+//
+//	monitor := validator.NewMonitor(info, signerExchange, validator.Config{
+//	    Validator:  "0x...",
+//	    AutoUnjail: true,
+//	    MinUptime:  0.95,
+//	})
+//	monitor.OnEvent(func(e validator.Event) { log.Println(e) })
+//	monitor.SetSummaryHook(m.ObserveValidator) // a metrics.Metrics
+//	go monitor.Run(ctx)
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Defaults of a Monitor
+const (
+	DefaultPollInterval     = 30 * time.Second
+	DefaultUnjailBackoff    = time.Minute
+	DefaultMaxUnjailBackoff = 30 * time.Minute
+	// UptimePeriod is the period of the stats whose uptime is checked
+	UptimePeriod = "day"
+)
+
+// hypeWei is the wei of a HYPE of the stakes of validators
+const hypeWei = 1e8
+
+// EventKind is a kind of event raised by a Monitor
+type EventKind int
+
+const (
+	// Jailed and Unjailed are the validator being jailed and unjailed
+	Jailed EventKind = iota
+	Unjailed
+
+	// UnjailSent is an unjail sent by the signer, UnjailFailed one the exchange refused
+	UnjailSent
+	UnjailFailed
+
+	// Activated and Deactivated are the validator entering and leaving the active set
+	Activated
+	Deactivated
+
+	// StakeChanged is a change of the stake delegated to the validator, Change in HYPE
+	StakeChanged
+
+	// CommissionChanged is a change of the commission of the validator
+	CommissionChanged
+
+	// LowUptime is the uptime of the day going under Config.MinUptime, and
+	// UptimeRecovered going back above it
+	LowUptime
+	UptimeRecovered
+
+	// NotFound is the validator missing from the summaries
+	NotFound
+
+	// PollFailed is a poll of Run that failed
+	PollFailed
+)
+
+// String returns the name of the kind
+func (k EventKind) String() string {
+	switch k {
+	case Jailed:
+		return "jailed"
+	case Unjailed:
+		return "unjailed"
+	case UnjailSent:
+		return "unjail-sent"
+	case UnjailFailed:
+		return "unjail-failed"
+	case Activated:
+		return "activated"
+	case Deactivated:
+		return "deactivated"
+	case StakeChanged:
+		return "stake-changed"
+	case CommissionChanged:
+		return "commission-changed"
+	case LowUptime:
+		return "low-uptime"
+	case UptimeRecovered:
+		return "uptime-recovered"
+	case NotFound:
+		return "not-found"
+	case PollFailed:
+		return "poll-failed"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+// Event is a change of the validator seen by a poll
+type Event struct {
+	Kind   EventKind
+	Time   time.Time
+	Detail string
+	// Change is the change of stake in HYPE of StakeChanged, or of commission of
+	// CommissionChanged
+	Change float64
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Detail)
+}
+
+// Config configures a Monitor
+type Config struct {
+	// Validator is the address of the validator
+	Validator string
+	// PollInterval is how often Run polls, DefaultPollInterval if zero
+	PollInterval time.Duration
+	// AutoUnjail is whether a jailed validator is unjailed with the signer once it
+	// may. Unjails are retried after UnjailBackoff, doubling up to MaxUnjailBackoff,
+	// while the validator stays jailed.
+	AutoUnjail       bool
+	UnjailBackoff    time.Duration
+	MaxUnjailBackoff time.Duration
+	// MinUptime is the uptime fraction of the day under which LowUptime is raised,
+	// none if zero
+	MinUptime float64
+	// MinStakeChange is the smallest change of stake in HYPE reported
+	MinStakeChange float64
+}
+
+// Health is the state of the validator at the last poll
+type Health struct {
+	Time  time.Time
+	Found bool
+	// Summary is the summary of the validator of the last poll it was found in
+	Summary    types.ValidatorSummary
+	Stake      float64
+	Commission float64
+	// Uptime is the uptime fraction of the day
+	Uptime float64
+	// UnjailAttempts are the unjails sent since the validator was jailed, and
+	// NextUnjail the time of the next, zero if none is due
+	UnjailAttempts int
+	NextUnjail     time.Time
+}
+
+// Monitor polls a validator and unjails it. Safe for concurrent use.
+type Monitor struct {
+	info    client.Infoer
+	signer  client.Exchanger
+	config  Config
+	onEvent func(Event)
+	onPoll  func(types.ValidatorSummary)
+
+	mu         sync.Mutex
+	clock      utils.Clock
+	autoUnjail bool
+	polled     bool
+	health     Health
+	lowUptime  bool
+	// reported is the stake of the last StakeChanged, or of the first poll
+	reported float64
+}
+
+// NewMonitor creates a monitor of the validator of config reading the summaries with
+// info. signer is the exchange of the signer of the validator, which sends unjails;
+// with a nil signer the validator is not unjailed.
+func NewMonitor(info client.Infoer, signer client.Exchanger, config Config) *Monitor {
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.UnjailBackoff <= 0 {
+		config.UnjailBackoff = DefaultUnjailBackoff
+	}
+	if config.MaxUnjailBackoff <= 0 {
+		config.MaxUnjailBackoff = DefaultMaxUnjailBackoff
+	}
+	return &Monitor{
+		info:       info,
+		signer:     signer,
+		config:     config,
+		clock:      utils.SystemClock,
+		autoUnjail: config.AutoUnjail && signer != nil,
+	}
+}
+
+// SetClock sets the clock of the events and unjails, e.g. a utils.FakeClock in tests
+func (m *Monitor) SetClock(clock utils.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
+
+// OnEvent sets a callback invoked for every event. Must be called before Run.
+func (m *Monitor) OnEvent(fn func(Event)) {
+	m.onEvent = fn
+}
+
+// SetSummaryHook sets a callback invoked with the summary of the validator at every
+// poll it is found in, e.g. metrics.Metrics.ObserveValidator. Must be called before
+// Run.
+func (m *Monitor) SetSummaryHook(fn func(types.ValidatorSummary)) {
+	m.onPoll = fn
+}
+
+// SetAutoUnjail turns automatic unjails on or off, e.g. off before jailing the
+// validator for maintenance. It has no effect without a signer.
+func (m *Monitor) SetAutoUnjail(enable bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.autoUnjail = enable && m.signer != nil
+	m.health.NextUnjail = time.Time{}
+}
+
+// Health returns the state of the validator at the last poll
+func (m *Monitor) Health() Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.health
+}
+
+// Run polls the validator every interval until ctx is done. Failed polls are reported
+// as PollFailed events.
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Poll(); err != nil {
+			m.emit(Event{Kind: PollFailed, Time: m.now(), Detail: err.Error()})
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads the summary of the validator and unjails it when due. It returns the
+// events of the poll, also passed to the OnEvent callback.
+func (m *Monitor) Poll() ([]Event, error) {
+	summaries, err := m.info.ValidatorSummaries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validator summaries: %w", err)
+	}
+	var summary *types.ValidatorSummary
+	for i := range summaries {
+		if strings.EqualFold(summaries[i].Validator, m.config.Validator) {
+			summary = &summaries[i]
+			break
+		}
+	}
+
+	m.mu.Lock()
+	events, err := m.observe(summary)
+	unjail := m.unjailDue()
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if summary != nil && m.onPoll != nil {
+		m.onPoll(*summary)
+	}
+	if unjail {
+		events = append(events, m.unjail())
+	}
+	for _, event := range events {
+		m.emit(event)
+	}
+	return events, nil
+}
+
+// observe compares summary with the last poll. Must be called with the lock held.
+func (m *Monitor) observe(summary *types.ValidatorSummary) ([]Event, error) {
+	now := m.clock.Now()
+	var events []Event
+	event := func(kind EventKind, change float64, format string, args ...any) {
+		events = append(events, Event{Kind: kind, Time: now, Change: change, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	prev := m.health
+	m.health.Time = now
+	if summary == nil {
+		if !m.polled || prev.Found {
+			event(NotFound, 0, "validator %s not in summaries", m.config.Validator)
+		}
+		m.health.Found = false
+		m.polled = true
+		return events, nil
+	}
+
+	commission, err := strconv.ParseFloat(summary.Commission, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commission: %w", err)
+	}
+	var uptime float64
+	hasUptime := false
+	if stats, ok := summary.StatsFor(UptimePeriod); ok {
+		if uptime, err = strconv.ParseFloat(stats.UptimeFraction, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse uptime: %w", err)
+		}
+		hasUptime = true
+	}
+	stake := float64(summary.Stake) / hypeWei
+
+	m.health.Found = true
+	m.health.Summary = *summary
+	m.health.Stake = stake
+	m.health.Commission = commission
+	m.health.Uptime = uptime
+
+	if !summary.IsJailed {
+		m.health.UnjailAttempts = 0
+		m.health.NextUnjail = time.Time{}
+	}
+	if prev.Found {
+		was := prev.Summary
+		switch {
+		case summary.IsJailed && !was.IsJailed:
+			event(Jailed, 0, "%s jailed", summary.Name)
+		case !summary.IsJailed && was.IsJailed:
+			event(Unjailed, 0, "%s unjailed", summary.Name)
+		}
+		switch {
+		case summary.IsActive && !was.IsActive:
+			event(Activated, 0, "%s active", summary.Name)
+		case !summary.IsActive && was.IsActive:
+			event(Deactivated, 0, "%s inactive", summary.Name)
+		}
+		if change := stake - m.reported; change != 0 && abs(change) >= m.config.MinStakeChange {
+			event(StakeChanged, change, "stake %v -> %v HYPE", m.reported, stake)
+			m.reported = stake
+		}
+		if change := commission - prev.Commission; change != 0 {
+			event(CommissionChanged, change, "commission %s -> %s", was.Commission, summary.Commission)
+		}
+	} else {
+		m.reported = stake
+		if summary.IsJailed {
+			// Jailed before the monitor started
+			event(Jailed, 0, "%s jailed", summary.Name)
+		}
+	}
+	if m.config.MinUptime > 0 && hasUptime {
+		switch {
+		case !m.lowUptime && uptime < m.config.MinUptime:
+			m.lowUptime = true
+			event(LowUptime, 0, "uptime %.4f < %.4f", uptime, m.config.MinUptime)
+		case m.lowUptime && uptime >= m.config.MinUptime:
+			m.lowUptime = false
+			event(UptimeRecovered, 0, "uptime %.4f >= %.4f", uptime, m.config.MinUptime)
+		}
+	}
+	m.polled = true
+	return events, nil
+}
+
+// unjailDue returns whether an unjail should be sent now. Must be called with the
+// lock held.
+func (m *Monitor) unjailDue() bool {
+	h := &m.health
+	if !m.autoUnjail || !h.Found || !h.Summary.IsJailed {
+		return false
+	}
+	now := m.clock.Now()
+	if after := h.Summary.UnjailableAfter; after != nil {
+		if unjailable := time.UnixMilli(*after); now.Before(unjailable) {
+			h.NextUnjail = unjailable
+			return false
+		}
+	}
+	return !now.Before(h.NextUnjail)
+}
+
+// unjail sends an unjail and schedules the next attempt
+func (m *Monitor) unjail() Event {
+	_, err := m.signer.CSignerUnjailSelf()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	m.health.UnjailAttempts++
+	backoff := m.config.UnjailBackoff << (m.health.UnjailAttempts - 1)
+	if backoff > m.config.MaxUnjailBackoff || backoff <= 0 {
+		backoff = m.config.MaxUnjailBackoff
+	}
+	m.health.NextUnjail = now.Add(backoff)
+	if err != nil {
+		return Event{Kind: UnjailFailed, Time: now,
+			Detail: fmt.Sprintf("attempt %d failed, next in %v: %v", m.health.UnjailAttempts, backoff, err)}
+	}
+	return Event{Kind: UnjailSent, Time: now,
+		Detail: fmt.Sprintf("attempt %d sent, next in %v if still jailed", m.health.UnjailAttempts, backoff)}
+}
+
+func (m *Monitor) emit(event Event) {
+	if m.onEvent != nil {
+		m.onEvent(event)
+	}
+}
+
+func (m *Monitor) now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clock.Now()
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,400 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+const validatorAddress = "0x00000000000000000000000000000000000000aa"
+
+// fakeValidator serves the summary of a validator and records the actions of its
+// signer and of the validator
+type fakeValidator struct {
+	mu       sync.Mutex
+	summary  types.ValidatorSummary
+	found    bool
+	refuse   bool
+	signer   []string
+	profiles []map[string]any
+}
+
+func (v *fakeValidator) update(fn func(s *types.ValidatorSummary)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	fn(&v.summary)
+}
+
+func newValidator(t *testing.T, config Config) (*Monitor, *Operator, *fakeValidator, *utils.FakeClock) {
+	t.Helper()
+	srv := hltest.NewTestServer(t)
+	// The server only tries the wire key order of the 7 fields of changeProfile
+	srv.SetVerifySignatures(false)
+	info := srv.NewTestInfo(t)
+
+	v := &fakeValidator{found: true, summary: types.ValidatorSummary{
+		Validator:  "0x00000000000000000000000000000000000000AA",
+		Name:       "node",
+		Stake:      1000 * 1e8,
+		IsActive:   true,
+		Commission: "0.05",
+		Stats: []types.ValidatorPeriodStats{
+			{Period: "day", ValidatorStats: types.ValidatorStats{UptimeFraction: "1.0"}},
+		},
+	}}
+	srv.HandleInfo("validatorSummaries", func(map[string]any) (any, error) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		other := types.ValidatorSummary{Validator: "0x00000000000000000000000000000000000000bb", Commission: "0.1"}
+		if !v.found {
+			return []types.ValidatorSummary{other}, nil
+		}
+		return []types.ValidatorSummary{other, v.summary}, nil
+	})
+	srv.HandleExchange("CSignerAction", func(req *hltest.ExchangeRequest) (any, error) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		for variant := range req.Action {
+			if variant != "type" {
+				v.signer = append(v.signer, variant)
+			}
+		}
+		if v.refuse {
+			return nil, errors.New("validator cannot unjail yet")
+		}
+		return map[string]any{"type": "default"}, nil
+	})
+	srv.HandleExchange("CValidatorAction", func(req *hltest.ExchangeRequest) (any, error) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.profiles = append(v.profiles, req.Action["changeProfile"].(map[string]any))
+		return map[string]any{"type": "default"}, nil
+	})
+
+	signer := srv.NewTestExchange(t)
+	config.Validator = validatorAddress
+	monitor := NewMonitor(info, signer, config)
+	clock := hltest.NewFakeClock()
+	monitor.SetClock(clock)
+	return monitor, NewOperator(srv.NewTestExchange(t), signer), v, clock
+}
+
+func kinds(events []Event) []EventKind {
+	var k []EventKind
+	for _, e := range events {
+		k = append(k, e.Kind)
+	}
+	return k
+}
+
+func TestMonitorChanges(t *testing.T) {
+	monitor, _, v, _ := newValidator(t, Config{MinUptime: 0.9, MinStakeChange: 10})
+	var hooked []types.ValidatorSummary
+	monitor.SetSummaryHook(func(s types.ValidatorSummary) { hooked = append(hooked, s) })
+
+	events, err := monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	// The first poll is the baseline
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+	health := monitor.Health()
+	if !health.Found {
+		t.Error("health.Found = false")
+	}
+	if health.Stake != 1000.0 {
+		t.Errorf("health.Stake = %v, want 1000.0", health.Stake)
+	}
+	if health.Commission != 0.05 {
+		t.Errorf("health.Commission = %v, want 0.05", health.Commission)
+	}
+
+	// A stake change under MinStakeChange is not reported, but counts towards the next
+	v.update(func(s *types.ValidatorSummary) { s.Stake += 5 * 1e8 })
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+
+	v.update(func(s *types.ValidatorSummary) {
+		s.Stake -= 105 * 1e8
+		s.Commission = "0.04"
+		s.IsActive = false
+		s.Stats[0].UptimeFraction = "0.85"
+	})
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{Deactivated, StakeChanged, CommissionChanged, LowUptime}; !slices.Equal(got, want) {
+		t.Fatalf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if math.Abs(events[1].Change-(-100)) > 1e-9 {
+		t.Errorf("events[1].Change = %v, want %v", events[1].Change, -100)
+	}
+	if math.Abs(events[2].Change-(-0.01)) > 1e-9 {
+		t.Errorf("events[2].Change = %v, want %v", events[2].Change, -0.01)
+	}
+
+	v.update(func(s *types.ValidatorSummary) {
+		s.IsActive = true
+		s.Stats[0].UptimeFraction = "0.92"
+	})
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{Activated, UptimeRecovered}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+
+	v.mu.Lock()
+	v.found = false
+	v.mu.Unlock()
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{NotFound}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if monitor.Health().Found {
+		t.Error("monitor.Health().Found = true")
+	}
+	// The hook sees the polls the validator is found in
+	if len(hooked) != 4 {
+		t.Errorf("len(hooked) = %d, want 4", len(hooked))
+	}
+}
+
+func TestMonitorUnjail(t *testing.T) {
+	monitor, _, v, clock := newValidator(t, Config{AutoUnjail: true, UnjailBackoff: time.Minute, MaxUnjailBackoff: 3 * time.Minute})
+	var seen []Event
+	monitor.OnEvent(func(e Event) { seen = append(seen, e) })
+
+	_, err := monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+
+	// Jailed, unjailable in 10 minutes
+	unjailable := clock.Now().Add(10 * time.Minute).UnixMilli()
+	v.update(func(s *types.ValidatorSummary) {
+		s.IsJailed = true
+		s.UnjailableAfter = &unjailable
+	})
+	events, err := monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{Jailed}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if got, want := monitor.Health().NextUnjail, time.UnixMilli(unjailable); !reflect.DeepEqual(got, want) {
+		t.Errorf("monitor.Health().NextUnjail = %+v, want %+v", got, want)
+	}
+	if len(v.signer) != 0 {
+		t.Errorf("v.signer = %+v, want empty", v.signer)
+	}
+
+	clock.Advance(10 * time.Minute)
+	v.refuse = true
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{UnjailFailed}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if got, want := monitor.Health().NextUnjail, clock.Now().Add(time.Minute); !reflect.DeepEqual(got, want) {
+		t.Errorf("monitor.Health().NextUnjail = %+v, want %+v", got, want)
+	}
+
+	// Not retried before the backoff, which doubles up to the max
+	clock.Advance(30 * time.Second)
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+
+	clock.Advance(30 * time.Second)
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{UnjailFailed}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if got, want := monitor.Health().NextUnjail, clock.Now().Add(2*time.Minute); !reflect.DeepEqual(got, want) {
+		t.Errorf("monitor.Health().NextUnjail = %+v, want %+v", got, want)
+	}
+
+	clock.Advance(2 * time.Minute)
+	v.refuse = false
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{UnjailSent}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if got := monitor.Health().UnjailAttempts; got != 3 {
+		t.Errorf("monitor.Health().UnjailAttempts = %v, want 3", got)
+	}
+	if got, want := monitor.Health().NextUnjail, clock.Now().Add(3*time.Minute); !reflect.DeepEqual(got, want) {
+		t.Errorf("monitor.Health().NextUnjail = %+v, want %+v", got, want)
+	}
+
+	v.update(func(s *types.ValidatorSummary) {
+		s.IsJailed = false
+		s.UnjailableAfter = nil
+	})
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{Unjailed}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if got := monitor.Health().UnjailAttempts; got != 0 {
+		t.Errorf("monitor.Health().UnjailAttempts = %v, want 0", got)
+	}
+	if !monitor.Health().NextUnjail.IsZero() {
+		t.Error("IsZero() = false")
+	}
+	if want := []string{"unjailSelf", "unjailSelf", "unjailSelf"}; !slices.Equal(v.signer, want) {
+		t.Errorf("v.signer = %+v, want %+v", v.signer, want)
+	}
+	if len(seen) != 5 {
+		t.Errorf("len(seen) = %d, want 5", len(seen))
+	}
+}
+
+func TestMonitorMaintenance(t *testing.T) {
+	monitor, operator, v, _ := newValidator(t, Config{AutoUnjail: true})
+	_, err := monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+
+	monitor.SetAutoUnjail(false)
+	if err := operator.Jail(); err != nil {
+		t.Fatalf("Jail() error = %v", err)
+	}
+	v.update(func(s *types.ValidatorSummary) { s.IsJailed = true })
+	events, err := monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	// No unjail while paused
+	if got, want := kinds(events), []EventKind{Jailed}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+
+	monitor.SetAutoUnjail(true)
+	events, err = monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{UnjailSent}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if want := []string{"jailSelf", "unjailSelf"}; !slices.Equal(v.signer, want) {
+		t.Errorf("v.signer = %+v, want %+v", v.signer, want)
+	}
+}
+
+func TestMonitorWithoutSigner(t *testing.T) {
+	srv := hltest.NewTestServer(t)
+	srv.SetInfo("validatorSummaries", []types.ValidatorSummary{{Validator: validatorAddress, IsJailed: true, Commission: "0"}})
+	info := srv.NewTestInfo(t)
+
+	monitor := NewMonitor(info, nil, Config{Validator: validatorAddress, AutoUnjail: true})
+	events, err := monitor.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{Jailed}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if err := NewOperator(nil, nil).Unjail(); err == nil {
+		t.Error("Unjail() error = nil, want error")
+	}
+}
+
+func TestMonitorRun(t *testing.T) {
+	monitor, _, v, _ := newValidator(t, Config{PollInterval: time.Millisecond})
+	events := make(chan Event, 16)
+	monitor.OnEvent(func(e Event) { events <- e })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- monitor.Run(ctx) }()
+
+	for deadline := time.Now().Add(time.Second); !monitor.Health().Found; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Health().Found = false")
+		}
+	}
+	v.update(func(s *types.ValidatorSummary) { s.Commission = "bad" })
+	select {
+	case e := <-events:
+		if e.Kind != PollFailed {
+			t.Errorf("e.Kind = %v, want PollFailed", e.Kind)
+		}
+		if !strings.Contains(e.Detail, "failed to parse commission") {
+			t.Errorf("e.Detail = %q, want containing %q", e.Detail, "failed to parse commission")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestOperator(t *testing.T) {
+	_, operator, v, _ := newValidator(t, Config{})
+	if err := operator.SetCommission(250); err != nil {
+		t.Fatalf("SetCommission(250) error = %v", err)
+	}
+	if err := operator.SetDelegations(false); err != nil {
+		t.Fatalf("SetDelegations(false) error = %v", err)
+	}
+	if err := operator.SetCommission(20000); err == nil {
+		t.Error("SetCommission(20000) error = nil, want error")
+	}
+
+	if len(v.profiles) != 2 {
+		t.Fatalf("len(v.profiles) = %d, want 2", len(v.profiles))
+	}
+	if got := v.profiles[0]["commission_bps"].(interface{ String() string }).String(); got != "250" {
+		t.Errorf("String() = %q, want %q", got, "250")
+	}
+	if v.profiles[1]["disable_delegations"] != true {
+		t.Errorf("v.profiles[1][disable_delegations] = %v, want true", v.profiles[1]["disable_delegations"])
+	}
+	if v.profiles[1]["commission_bps"] != nil {
+		t.Errorf("v.profiles[1][commission_bps] = %v, want nil", v.profiles[1]["commission_bps"])
+	}
+}
@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/dwdwow/hl-go/client"
+)
+
+// Operator sends the profile actions of a validator and the jail actions of its
+// signer
+type Operator struct {
+	validator client.Exchanger
+	signer    client.Exchanger
+}
+
+// NewOperator creates an operator sending the CValidatorAction of validator, the
+// exchange of the validator account, and the CSignerAction of signer, the exchange of
+// its signer. Either may be nil when its actions are not used.
+func NewOperator(validator, signer client.Exchanger) *Operator {
+	return &Operator{validator: validator, signer: signer}
+}
+
+// SetCommission sets the commission of the validator in basis points
+func (o *Operator) SetCommission(bps int) error {
+	if bps < 0 || bps > 10000 {
+		return fmt.Errorf("invalid commission: %d bps", bps)
+	}
+	return o.changeProfile("commission", func(e client.Exchanger) error {
+		_, err := e.CValidatorChangeProfile(nil, nil, nil, false, nil, &bps, nil)
+		return err
+	})
+}
+
+// SetDelegations enables or disables new delegations to the validator
+func (o *Operator) SetDelegations(enabled bool) error {
+	disable := !enabled
+	return o.changeProfile("delegations", func(e client.Exchanger) error {
+		_, err := e.CValidatorChangeProfile(nil, nil, nil, false, &disable, nil, nil)
+		return err
+	})
+}
+
+// SetProfile sets the name and description of the validator, unchanged if nil
+func (o *Operator) SetProfile(name, description *string) error {
+	return o.changeProfile("profile", func(e client.Exchanger) error {
+		_, err := e.CValidatorChangeProfile(nil, name, description, false, nil, nil, nil)
+		return err
+	})
+}
+
+// SetNodeIP sets the IP of the node of the validator
+func (o *Operator) SetNodeIP(ip string) error {
+	return o.changeProfile("node IP", func(e client.Exchanger) error {
+		_, err := e.CValidatorChangeProfile(&ip, nil, nil, false, nil, nil, nil)
+		return err
+	})
+}
+
+// RotateSigner makes signer the signer of the validator. The Operator keeps sending
+// the jail actions with its old signer.
+func (o *Operator) RotateSigner(signer string) error {
+	return o.changeProfile("signer", func(e client.Exchanger) error {
+		_, err := e.CValidatorChangeProfile(nil, nil, nil, false, nil, nil, &signer)
+		return err
+	})
+}
+
+// Jail jails the validator through its signer, e.g. before maintenance of the node.
+// Turn off the auto-unjail of a Monitor first.
+func (o *Operator) Jail() error {
+	if o.signer == nil {
+		return fmt.Errorf("failed to jail validator: no signer")
+	}
+	if _, err := o.signer.CSignerJailSelf(); err != nil {
+		return fmt.Errorf("failed to jail validator: %w", err)
+	}
+	return nil
+}
+
+// Unjail unjails the validator through its signer
+func (o *Operator) Unjail() error {
+	if o.signer == nil {
+		return fmt.Errorf("failed to unjail validator: no signer")
+	}
+	if _, err := o.signer.CSignerUnjailSelf(); err != nil {
+		return fmt.Errorf("failed to unjail validator: %w", err)
+	}
+	return nil
+}
+
+func (o *Operator) changeProfile(what string, change func(client.Exchanger) error) error {
+	if o.validator == nil {
+		return fmt.Errorf("failed to set %s: no validator exchange", what)
+	}
+	if err := change(o.validator); err != nil {
+		return fmt.Errorf("failed to set %s: %w", what, err)
+	}
+	return nil
+}
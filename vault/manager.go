@@ -0,0 +1,452 @@
+// Package vault runs a vault for its leader. A Manager polls the details and the
+// account of the vault, reports the deposits and withdrawals of its followers, closes
+// deposits at an equity cap and opens them again below it, distributes on a schedule
+// and alerts when the performance of the vault draws down:
+//
+//	manager := vault.NewManager(exchange, info, vault.Config{
+//	    Vault:           "0x...",
+//	    MaxEquity:       1_000_000,
+//	    MaxDrawdown:     0.1,
+//	    DistributeEvery: 7 * 24 * time.Hour,
+//	})
+//	manager.OnEvent(func(e vault.Event) { log.Println(e) })
+//	go manager.Run(ctx)
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/client"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+// Defaults of a Manager
+const (
+	DefaultPollInterval = time.Minute
+	// DefaultMinFlow is the smallest change of the equity of a follower, in USD,
+	// reported as a flow when Config.MinFlow is zero
+	DefaultMinFlow = 0.01
+	// DefaultReopenFraction is the fraction of MaxEquity below which deposits closed
+	// by the cap are opened again when Config.ReopenEquity is zero
+	DefaultReopenFraction = 0.9
+)
+
+// EventKind is a kind of event raised by a Manager
+type EventKind int
+
+const (
+	// FollowerJoined is a new follower, Amount its deposit
+	FollowerJoined EventKind = iota
+
+	// FollowerLeft is a follower gone, Amount the opposite of its last equity
+	FollowerLeft
+
+	// Inflow and Outflow are deposits and withdrawals of a follower already in the
+	// vault, Amount positive and negative
+	Inflow
+	Outflow
+
+	// DepositsClosed is the cap closing deposits, and DepositsOpened deposits opened
+	// again below it
+	DepositsClosed
+	DepositsOpened
+
+	// Distributed is a distribution to the followers, Amount the USD distributed
+	Distributed
+
+	// DrawdownAlert is the drawdown of the vault reaching Config.MaxDrawdown, and
+	// DrawdownRecovered the drawdown going back under it
+	DrawdownAlert
+	DrawdownRecovered
+
+	// PollFailed is a poll of Run or an action of the manager that failed
+	PollFailed
+)
+
+// String returns the name of the kind
+func (k EventKind) String() string {
+	switch k {
+	case FollowerJoined:
+		return "follower-joined"
+	case FollowerLeft:
+		return "follower-left"
+	case Inflow:
+		return "inflow"
+	case Outflow:
+		return "outflow"
+	case DepositsClosed:
+		return "deposits-closed"
+	case DepositsOpened:
+		return "deposits-opened"
+	case Distributed:
+		return "distributed"
+	case DrawdownAlert:
+		return "drawdown"
+	case DrawdownRecovered:
+		return "drawdown-recovered"
+	case PollFailed:
+		return "poll-failed"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+// Event is something that happened to the vault, seen by a poll
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	// User is the follower of the follower events
+	User string
+	// Amount is the USD of the flows and distributions
+	Amount float64
+	// Equity is the equity of the vault at the poll, Drawdown its drawdown
+	Equity   float64
+	Drawdown float64
+	Detail   string
+}
+
+func (e Event) String() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%s %s %v", e.Kind, e.User, e.Amount)
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Detail)
+}
+
+// Config configures a Manager. Zero limits are none.
+type Config struct {
+	// Vault is the address of the vault, led by the account of the exchange
+	Vault string
+	// PollInterval is how often Run polls, DefaultPollInterval if zero
+	PollInterval time.Duration
+	// MinFlow is the smallest flow reported, in USD, DefaultMinFlow if zero
+	MinFlow float64
+
+	// MaxEquity is the equity of the vault at which deposits are closed. Deposits
+	// closed by the cap are opened again below ReopenEquity, DefaultReopenFraction of
+	// MaxEquity if zero; deposits closed by the leader are left closed.
+	MaxEquity    float64
+	ReopenEquity float64
+
+	// MaxDrawdown is the drop of the performance of the vault from its peak, as a
+	// fraction, that raises a DrawdownAlert
+	MaxDrawdown float64
+
+	// DistributeEvery is the period of distributions, starting one period after the
+	// first poll. Each distributes DistributeAmount USD, all the distributable if
+	// zero, capped by the distributable amount of the vault.
+	DistributeEvery  time.Duration
+	DistributeAmount float64
+}
+
+// Status is the state of the vault at the last poll
+type Status struct {
+	Time      time.Time
+	Equity    float64
+	Followers int
+	// AllowDeposits is whether the vault accepts deposits
+	AllowDeposits    bool
+	MaxDistributable float64
+	// Performance is the growth of the equity since the first poll net of flows,
+	// starting at 1, and Drawdown its drop from its peak
+	Performance float64
+	Drawdown    float64
+	// NextDistribution is the time of the next distribution, zero without schedule
+	NextDistribution time.Time
+}
+
+type follower struct {
+	equity     float64
+	allTimePnl float64
+}
+
+// Manager polls a vault and acts on it for its leader. Safe for concurrent use.
+type Manager struct {
+	exchange client.Exchanger
+	info     client.Infoer
+	config   Config
+	onEvent  func(Event)
+
+	mu        sync.Mutex
+	clock     utils.Clock
+	polled    bool
+	followers map[string]follower
+	status    Status
+	peak      float64
+	// capped is whether the cap closed the deposits
+	capped     bool
+	inDrawdown bool
+}
+
+// NewManager creates a manager of the vault of config, acting with exchange and
+// reading the vault with info
+func NewManager(exchange client.Exchanger, info client.Infoer, config Config) *Manager {
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.MinFlow <= 0 {
+		config.MinFlow = DefaultMinFlow
+	}
+	if config.ReopenEquity <= 0 {
+		config.ReopenEquity = config.MaxEquity * DefaultReopenFraction
+	}
+	return &Manager{
+		exchange:  exchange,
+		info:      info,
+		config:    config,
+		clock:     utils.SystemClock,
+		followers: make(map[string]follower),
+		status:    Status{Performance: 1},
+		peak:      1,
+	}
+}
+
+// SetClock sets the clock of the events and distributions, e.g. a utils.FakeClock in
+// tests
+func (m *Manager) SetClock(clock utils.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
+
+// OnEvent sets a callback invoked for every event. Must be called before Run.
+func (m *Manager) OnEvent(fn func(Event)) {
+	m.onEvent = fn
+}
+
+// Status returns the state of the vault at the last poll
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// Run polls the vault every interval until ctx is done. Failed polls are reported as
+// PollFailed events.
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Poll(); err != nil {
+			m.emit(Event{Kind: PollFailed, Time: m.now(), Detail: err.Error()})
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads the vault, then closes or opens deposits and distributes as configured.
+// It returns the events of the poll, also passed to the OnEvent callback. Events are
+// returned with the error of a failed action.
+func (m *Manager) Poll() ([]Event, error) {
+	details, err := m.info.VaultDetails(m.config.Vault, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault details: %w", err)
+	}
+	state, err := m.info.UserState(m.config.Vault, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault state: %w", err)
+	}
+	equity, err := strconv.ParseFloat(state.MarginSummary.AccountValue, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vault equity: %w", err)
+	}
+
+	m.mu.Lock()
+	events, err := m.observe(details, equity)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, action := range []func(*types.VaultDetails) (*Event, error){m.applyCap, m.distribute} {
+		event, err := action(details)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if event != nil {
+			events = append(events, *event)
+		}
+	}
+	for _, event := range events {
+		m.emit(event)
+	}
+	return events, errors.Join(errs...)
+}
+
+// observe updates the followers and the performance with a poll. Must be called with
+// the lock held.
+func (m *Manager) observe(details *types.VaultDetails, equity float64) ([]Event, error) {
+	now := m.clock.Now()
+	followers := make(map[string]follower, len(details.Followers))
+	for _, f := range details.Followers {
+		vaultEquity, err := strconv.ParseFloat(f.VaultEquity, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse equity of %s: %w", f.User, err)
+		}
+		allTimePnl, err := strconv.ParseFloat(f.AllTimePnl, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pnl of %s: %w", f.User, err)
+		}
+		followers[f.User] = follower{equity: vaultEquity, allTimePnl: allTimePnl}
+	}
+
+	var events []Event
+	var netFlow float64
+	if m.polled {
+		flow := func(kind EventKind, user string, amount float64) {
+			netFlow += amount
+			events = append(events, Event{Kind: kind, Time: now, User: user, Amount: amount, Equity: equity})
+		}
+		for user, f := range followers {
+			prev, ok := m.followers[user]
+			switch {
+			case !ok:
+				flow(FollowerJoined, user, f.equity-f.allTimePnl)
+			default:
+				// The change of equity that is not pnl
+				amount := (f.equity - prev.equity) - (f.allTimePnl - prev.allTimePnl)
+				if amount >= m.config.MinFlow {
+					flow(Inflow, user, amount)
+				} else if amount <= -m.config.MinFlow {
+					flow(Outflow, user, amount)
+				}
+			}
+		}
+		for user, prev := range m.followers {
+			if _, ok := followers[user]; !ok {
+				flow(FollowerLeft, user, -prev.equity)
+			}
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			if events[i].Kind != events[j].Kind {
+				return events[i].Kind < events[j].Kind
+			}
+			return events[i].User < events[j].User
+		})
+
+		if m.status.Equity > 0 {
+			pnl := equity - m.status.Equity - netFlow
+			m.status.Performance *= 1 + pnl/m.status.Equity
+		}
+	}
+	m.peak = math.Max(m.peak, m.status.Performance)
+	drawdown := 1 - m.status.Performance/m.peak
+
+	m.followers = followers
+	m.status.Time = now
+	m.status.Equity = equity
+	m.status.Followers = len(followers)
+	m.status.AllowDeposits = details.AllowDeposits
+	m.status.MaxDistributable = details.MaxDistributable
+	m.status.Drawdown = drawdown
+	if !m.polled && m.config.DistributeEvery > 0 {
+		m.status.NextDistribution = now.Add(m.config.DistributeEvery)
+	}
+	m.polled = true
+
+	if m.config.MaxDrawdown > 0 {
+		switch {
+		case !m.inDrawdown && drawdown >= m.config.MaxDrawdown:
+			m.inDrawdown = true
+			events = append(events, Event{Kind: DrawdownAlert, Time: now, Equity: equity, Drawdown: drawdown,
+				Detail: fmt.Sprintf("drawdown %.2f%% >= %.2f%%", drawdown*100, m.config.MaxDrawdown*100)})
+		case m.inDrawdown && drawdown < m.config.MaxDrawdown:
+			m.inDrawdown = false
+			events = append(events, Event{Kind: DrawdownRecovered, Time: now, Equity: equity, Drawdown: drawdown,
+				Detail: fmt.Sprintf("drawdown %.2f%% < %.2f%%", drawdown*100, m.config.MaxDrawdown*100)})
+		}
+	}
+	return events, nil
+}
+
+// applyCap closes deposits at the cap and opens the deposits it closed below it
+func (m *Manager) applyCap(details *types.VaultDetails) (*Event, error) {
+	if m.config.MaxEquity <= 0 {
+		return nil, nil
+	}
+	m.mu.Lock()
+	status, capped := m.status, m.capped
+	m.mu.Unlock()
+
+	var allow bool
+	switch {
+	case details.AllowDeposits && status.Equity >= m.config.MaxEquity:
+		allow = false
+	case !details.AllowDeposits && capped && status.Equity < m.config.ReopenEquity:
+		allow = true
+	default:
+		return nil, nil
+	}
+	if _, err := m.exchange.VaultModify(m.config.Vault, &allow, nil); err != nil {
+		return nil, fmt.Errorf("failed to set deposits of vault: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.capped = !allow
+	m.status.AllowDeposits = allow
+	event := &Event{Kind: DepositsClosed, Time: status.Time, Equity: status.Equity, Drawdown: status.Drawdown,
+		Detail: fmt.Sprintf("equity %v >= cap %v", status.Equity, m.config.MaxEquity)}
+	if allow {
+		event.Kind = DepositsOpened
+		event.Detail = fmt.Sprintf("equity %v < %v", status.Equity, m.config.ReopenEquity)
+	}
+	return event, nil
+}
+
+// distribute distributes when the next distribution is due
+func (m *Manager) distribute(details *types.VaultDetails) (*Event, error) {
+	m.mu.Lock()
+	status := m.status
+	m.mu.Unlock()
+	if status.NextDistribution.IsZero() || status.Time.Before(status.NextDistribution) {
+		return nil, nil
+	}
+
+	amount := details.MaxDistributable
+	if m.config.DistributeAmount > 0 {
+		amount = math.Min(m.config.DistributeAmount, amount)
+	}
+	// VaultDistribute takes millionths of USD
+	usd := int(math.Floor(amount * 1e6))
+	if usd > 0 {
+		if _, err := m.exchange.VaultDistribute(m.config.Vault, usd); err != nil {
+			return nil, fmt.Errorf("failed to distribute %v USD: %w", amount, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for !m.status.NextDistribution.After(status.Time) {
+		m.status.NextDistribution = m.status.NextDistribution.Add(m.config.DistributeEvery)
+	}
+	if usd <= 0 {
+		return nil, nil
+	}
+	return &Event{Kind: Distributed, Time: status.Time, Amount: float64(usd) / 1e6, Equity: status.Equity,
+		Drawdown: status.Drawdown, Detail: fmt.Sprintf("distributed %v USD", float64(usd)/1e6)}, nil
+}
+
+func (m *Manager) emit(event Event) {
+	if m.onEvent != nil {
+		m.onEvent(event)
+	}
+}
+
+func (m *Manager) now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clock.Now()
+}
@@ -0,0 +1,347 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/hltest"
+	"github.com/dwdwow/hl-go/types"
+	"github.com/dwdwow/hl-go/utils"
+)
+
+const vaultAddress = "0x00000000000000000000000000000000000000aa"
+
+// fakeVault serves the details and the account of a vault, and records the actions of
+// its leader
+type fakeVault struct {
+	mu         sync.Mutex
+	details    types.VaultDetails
+	equity     float64
+	modifies   []bool
+	distribute []string
+}
+
+func (v *fakeVault) set(equity float64, followers ...types.VaultFollower) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.equity = equity
+	v.details.Followers = followers
+}
+
+func newVault(t *testing.T, config Config) (*Manager, *fakeVault, *utils.FakeClock) {
+	t.Helper()
+	srv := hltest.NewTestServer(t)
+	exchange, info := srv.NewTestExchange(t), srv.NewTestInfo(t)
+
+	v := &fakeVault{details: types.VaultDetails{VaultAddress: vaultAddress, AllowDeposits: true}}
+	srv.HandleInfo("vaultDetails", func(req map[string]any) (any, error) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if req["vaultAddress"] != vaultAddress {
+			return nil, errors.New("unknown vault")
+		}
+		return v.details, nil
+	})
+	srv.HandleInfo("clearinghouseState", func(map[string]any) (any, error) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		return types.UserState{MarginSummary: types.MarginSummary{AccountValue: fmt.Sprint(v.equity)}}, nil
+	})
+	srv.HandleExchange("vaultModify", func(req *hltest.ExchangeRequest) (any, error) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		allow := req.Action["allowDeposits"].(bool)
+		v.modifies = append(v.modifies, allow)
+		v.details.AllowDeposits = allow
+		return map[string]any{"type": "default"}, nil
+	})
+	srv.HandleExchange("vaultDistribute", func(req *hltest.ExchangeRequest) (any, error) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.distribute = append(v.distribute, fmt.Sprint(req.Action["usd"]))
+		return map[string]any{"type": "default"}, nil
+	})
+
+	config.Vault = vaultAddress
+	manager := NewManager(exchange, info, config)
+	clock := hltest.NewFakeClock()
+	manager.SetClock(clock)
+	return manager, v, clock
+}
+
+func member(user string, equity, allTimePnl string) types.VaultFollower {
+	return types.VaultFollower{User: user, VaultEquity: equity, AllTimePnl: allTimePnl}
+}
+
+func kinds(events []Event) []EventKind {
+	var k []EventKind
+	for _, e := range events {
+		k = append(k, e.Kind)
+	}
+	return k
+}
+
+func TestManagerFlows(t *testing.T) {
+	manager, v, clock := newVault(t, Config{MaxDrawdown: 0.03})
+	var seen []Event
+	manager.OnEvent(func(e Event) { seen = append(seen, e) })
+
+	v.set(1500, member("Leader", "1000", "0"), member("0xa", "500", "0"))
+	events, err := manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	// The first poll is the baseline
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+
+	// 0xa and the leader earn, 0xb joins with 200
+	clock.Advance(time.Hour)
+	v.set(1850, member("Leader", "1100", "100"), member("0xa", "550", "50"), member("0xb", "200", "0"))
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{FollowerJoined}; !slices.Equal(got, want) {
+		t.Fatalf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if events[0].User != "0xb" {
+		t.Errorf("events[0].User = %q, want %q", events[0].User, "0xb")
+	}
+	if math.Abs(events[0].Amount-200) > 1e-9 {
+		t.Errorf("events[0].Amount = %v, want %v", events[0].Amount, 200)
+	}
+	if got := manager.Status().Performance; math.Abs(got-1.1) > 1e-9 {
+		t.Errorf("manager.Status().Performance = %v, want %v", got, 1.1)
+	}
+
+	// 0xb leaves, 0xa withdraws 230 and the vault loses 60
+	clock.Advance(time.Hour)
+	v.set(1360, member("Leader", "1060", "60"), member("0xa", "300", "30"))
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{FollowerLeft, Outflow, DrawdownAlert}; !slices.Equal(got, want) {
+		t.Fatalf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if events[0].User != "0xb" {
+		t.Errorf("events[0].User = %q, want %q", events[0].User, "0xb")
+	}
+	if math.Abs(events[0].Amount-(-200)) > 1e-9 {
+		t.Errorf("events[0].Amount = %v, want %v", events[0].Amount, -200)
+	}
+	if events[1].User != "0xa" {
+		t.Errorf("events[1].User = %q, want %q", events[1].User, "0xa")
+	}
+	if math.Abs(events[1].Amount-(-230)) > 1e-9 {
+		t.Errorf("events[1].Amount = %v, want %v", events[1].Amount, -230)
+	}
+	if math.Abs(events[2].Drawdown-(60.0/1850)) > 1e-9 {
+		t.Errorf("events[2].Drawdown = %v, want %v", events[2].Drawdown, 60.0/1850)
+	}
+
+	status := manager.Status()
+	if status.Equity != 1360.0 {
+		t.Errorf("status.Equity = %v, want 1360.0", status.Equity)
+	}
+	if status.Followers != 2 {
+		t.Errorf("status.Followers = %v, want 2", status.Followers)
+	}
+	if math.Abs(status.Performance-(1.1*(1-60.0/1850))) > 1e-9 {
+		t.Errorf("status.Performance = %v, want %v", status.Performance, 1.1*(1-60.0/1850))
+	}
+
+	// Recovering the loss ends the alert
+	clock.Advance(time.Hour)
+	v.set(1420, member("Leader", "1100", "100"), member("0xa", "320", "50"))
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{DrawdownRecovered}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	// Events are passed to the callback
+	if len(seen) != 5 {
+		t.Errorf("len(seen) = %d, want 5", len(seen))
+	}
+}
+
+func TestManagerDepositCap(t *testing.T) {
+	manager, v, _ := newVault(t, Config{MaxEquity: 1000})
+
+	v.set(900)
+	events, err := manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+
+	v.set(1000)
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{DepositsClosed}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if manager.Status().AllowDeposits {
+		t.Error("manager.Status().AllowDeposits = true")
+	}
+
+	// Closed until the equity is below 90% of the cap
+	v.set(950)
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+
+	v.set(899)
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{DepositsOpened}; !slices.Equal(got, want) {
+		t.Errorf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if want := []bool{false, true}; !slices.Equal(v.modifies, want) {
+		t.Errorf("v.modifies = %+v, want %+v", v.modifies, want)
+	}
+
+	// Deposits closed by the leader stay closed
+	v.mu.Lock()
+	v.details.AllowDeposits = false
+	v.mu.Unlock()
+	v.set(100)
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+	if len(v.modifies) != 2 {
+		t.Errorf("len(v.modifies) = %d, want 2", len(v.modifies))
+	}
+}
+
+func TestManagerDistributions(t *testing.T) {
+	manager, v, clock := newVault(t, Config{DistributeEvery: 24 * time.Hour, DistributeAmount: 40})
+	v.mu.Lock()
+	v.details.MaxDistributable = 100
+	v.mu.Unlock()
+	v.set(1000)
+
+	_, err := manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	start := clock.Now()
+	if got, want := manager.Status().NextDistribution, start.Add(24*time.Hour); !reflect.DeepEqual(got, want) {
+		t.Errorf("manager.Status().NextDistribution = %+v, want %+v", got, want)
+	}
+
+	clock.Advance(23 * time.Hour)
+	events, err := manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want empty", events)
+	}
+
+	clock.Advance(time.Hour)
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{Distributed}; !slices.Equal(got, want) {
+		t.Fatalf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if events[0].Amount != 40.0 {
+		t.Errorf("events[0].Amount = %v, want 40.0", events[0].Amount)
+	}
+	if got, want := manager.Status().NextDistribution, start.Add(48*time.Hour); !reflect.DeepEqual(got, want) {
+		t.Errorf("manager.Status().NextDistribution = %+v, want %+v", got, want)
+	}
+
+	// Capped by what the vault can distribute, and skipped periods are not made up
+	v.mu.Lock()
+	v.details.MaxDistributable = 12.5
+	v.mu.Unlock()
+	clock.Advance(50 * time.Hour)
+	events, err = manager.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got, want := kinds(events), []EventKind{Distributed}; !slices.Equal(got, want) {
+		t.Fatalf("kinds(events) = %+v, want %+v", got, want)
+	}
+	if events[0].Amount != 12.5 {
+		t.Errorf("events[0].Amount = %v, want 12.5", events[0].Amount)
+	}
+	if got, want := manager.Status().NextDistribution, start.Add(96*time.Hour); !reflect.DeepEqual(got, want) {
+		t.Errorf("manager.Status().NextDistribution = %+v, want %+v", got, want)
+	}
+	if want := []string{"40000000", "12500000"}; !slices.Equal(v.distribute, want) {
+		t.Errorf("v.distribute = %+v, want %+v", v.distribute, want)
+	}
+}
+
+func TestManagerRun(t *testing.T) {
+	manager, v, _ := newVault(t, Config{PollInterval: time.Millisecond})
+	events := make(chan Event, 16)
+	manager.OnEvent(func(e Event) { events <- e })
+	v.set(1000, member("Leader", "1000", "0"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- manager.Run(ctx) }()
+
+	for deadline := time.Now().Add(time.Second); manager.Status().Equity != 1000; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Status().Equity = %v, want 1000", manager.Status().Equity)
+		}
+	}
+	v.set(1100, member("Leader", "1000", "0"), member("0xa", "100", "0"))
+	select {
+	case e := <-events:
+		if e.Kind != FollowerJoined {
+			t.Errorf("e.Kind = %v, want FollowerJoined", e.Kind)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestManagerPollFails(t *testing.T) {
+	manager, _, _ := newVault(t, Config{})
+	manager.config.Vault = "0x00000000000000000000000000000000000000bb"
+	_, err := manager.Poll()
+	if err == nil || !strings.Contains(err.Error(), "failed to get vault details") {
+		t.Errorf("Poll() error = %v, want %q", err, "failed to get vault details")
+	}
+	if got := PollFailed.String(); got != "poll-failed" {
+		t.Errorf("String() = %q, want %q", got, "poll-failed")
+	}
+}
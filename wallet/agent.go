@@ -0,0 +1,226 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+// agentMetadataSuffix is the file suffix of agent metadata stored next to the key file
+const agentMetadataSuffix = ".agent.json"
+
+// AgentMetadata describes an agent (API) wallet approved by a master account
+type AgentMetadata struct {
+	Name          string     `json:"name"`
+	AgentAddress  string     `json:"agentAddress"`
+	MasterAddress string     `json:"masterAddress"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ValidUntil    *time.Time `json:"validUntil,omitempty"`
+	ApprovedAt    *time.Time `json:"approvedAt,omitempty"`
+}
+
+// IsExpired returns true if the agent has an expiry that is not after now
+func (m AgentMetadata) IsExpired(now time.Time) bool {
+	return m.ValidUntil != nil && !m.ValidUntil.After(now)
+}
+
+// ApprovalName returns the agent name to approve on the exchange. An expiring agent
+// gets the "valid_until <ms>" suffix, with which the exchange rejects its actions
+// after ValidUntil; an unnamed expiring agent is approved under the suffix alone.
+func (m AgentMetadata) ApprovalName() string {
+	if m.ValidUntil == nil {
+		return m.Name
+	}
+	suffix := fmt.Sprintf("valid_until %d", m.ValidUntil.UnixMilli())
+	if m.Name == "" {
+		return suffix
+	}
+	return m.Name + " " + suffix
+}
+
+// Agent is an agent wallet with its metadata
+type Agent struct {
+	AgentMetadata
+	Key *PrivateKey
+}
+
+// AgentStore keeps agent keys encrypted in a keystore directory, together with
+// their metadata. Key files are standard go-ethereum keystore files, so the
+// directory can also be used with LoadFromKeystore.
+type AgentStore struct {
+	dir     string
+	scryptN int
+	scryptP int
+}
+
+// NewAgentStore creates a store in dir using the standard scrypt parameters
+func NewAgentStore(dir string) *AgentStore {
+	return &AgentStore{
+		dir:     dir,
+		scryptN: keystore.StandardScryptN,
+		scryptP: keystore.StandardScryptP,
+	}
+}
+
+// SetScryptParams sets the key derivation cost of newly encrypted keys
+func (s *AgentStore) SetScryptParams(n, p int) {
+	s.scryptN = n
+	s.scryptP = p
+}
+
+// Generate creates a new agent key for masterAddress, encrypts it with passphrase
+// and records its metadata. The agent still has to be approved with Exchange.ApproveAgent.
+func (s *AgentStore) Generate(name, masterAddress string, validUntil *time.Time, passphrase string) (*Agent, error) {
+	if !common.IsHexAddress(masterAddress) {
+		return nil, fmt.Errorf("invalid master address: %s", masterAddress)
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create agent directory: %w", err)
+	}
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate agent key: %w", err)
+	}
+	key := &keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}
+
+	keyJSON, err := keystore.EncryptKey(key, passphrase, s.scryptN, s.scryptP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt agent key: %w", err)
+	}
+	if err := os.WriteFile(s.keyPath(key.Address.Hex()), keyJSON, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write agent key: %w", err)
+	}
+
+	metadata := AgentMetadata{
+		Name:          name,
+		AgentAddress:  key.Address.Hex(),
+		MasterAddress: common.HexToAddress(masterAddress).Hex(),
+		CreatedAt:     time.Now().UTC(),
+		ValidUntil:    validUntil,
+	}
+	if err := s.writeMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	return &Agent{AgentMetadata: metadata, Key: NewPrivateKey(privateKey)}, nil
+}
+
+// MarkApproved records that the agent was approved at the given time
+func (s *AgentStore) MarkApproved(agentAddress string, at time.Time) error {
+	metadata, err := s.Metadata(agentAddress)
+	if err != nil {
+		return err
+	}
+	at = at.UTC()
+	metadata.ApprovedAt = &at
+	return s.writeMetadata(*metadata)
+}
+
+// Metadata returns the metadata of an agent
+func (s *AgentStore) Metadata(agentAddress string) (*AgentMetadata, error) {
+	if !common.IsHexAddress(agentAddress) {
+		return nil, fmt.Errorf("invalid agent address: %s", agentAddress)
+	}
+	data, err := os.ReadFile(s.metadataPath(common.HexToAddress(agentAddress).Hex()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent metadata: %w", err)
+	}
+
+	var metadata AgentMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse agent metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// List returns the metadata of all agents in the store, oldest first
+func (s *AgentStore) List() ([]AgentMetadata, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent directory: %w", err)
+	}
+
+	var agents []AgentMetadata
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, agentMetadataSuffix) {
+			continue
+		}
+		metadata, err := s.Metadata(strings.TrimSuffix(name, agentMetadataSuffix))
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, *metadata)
+	}
+
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].CreatedAt.Before(agents[j].CreatedAt)
+	})
+	return agents, nil
+}
+
+// Load decrypts an agent key. Expired agents are still returned; check IsExpired.
+func (s *AgentStore) Load(agentAddress string, passphrase PassphraseFunc) (*Agent, error) {
+	metadata, err := s.Metadata(agentAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := passphrase(metadata.AgentAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passphrase: %w", err)
+	}
+	privateKey, err := LoadKeystoreFile(s.keyPath(metadata.AgentAddress), auth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Agent{AgentMetadata: *metadata, Key: NewPrivateKey(privateKey)}, nil
+}
+
+// Remove deletes an agent's key and metadata, e.g. after it has expired or been replaced
+func (s *AgentStore) Remove(agentAddress string) error {
+	if !common.IsHexAddress(agentAddress) {
+		return fmt.Errorf("invalid agent address: %s", agentAddress)
+	}
+	address := common.HexToAddress(agentAddress).Hex()
+	for _, path := range []string{s.keyPath(address), s.metadataPath(address)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+func (s *AgentStore) keyPath(address string) string {
+	return filepath.Join(s.dir, address+".key.json")
+}
+
+func (s *AgentStore) metadataPath(address string) string {
+	return filepath.Join(s.dir, address+agentMetadataSuffix)
+}
+
+func (s *AgentStore) writeMetadata(metadata AgentMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal agent metadata: %w", err)
+	}
+	if err := os.WriteFile(s.metadataPath(metadata.AgentAddress), data, 0600); err != nil {
+		return fmt.Errorf("failed to write agent metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestAgentStoreLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	store := NewAgentStore(dir)
+	store.SetScryptParams(keystore.LightScryptN, keystore.LightScryptP)
+
+	master := "0x5e9ee1089755c3435139848e47e6635505d5a13a"
+	validUntil := time.Now().Add(time.Hour).UTC()
+	agent, err := store.Generate("bot", master, &validUntil, "secret")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if agent.MasterAddress != common.HexToAddress(master).Hex() || agent.Key.Address() != agent.AgentAddress {
+		t.Errorf("unexpected agent metadata: %+v", agent.AgentMetadata)
+	}
+
+	if err := store.MarkApproved(agent.AgentAddress, time.Now()); err != nil {
+		t.Fatalf("MarkApproved() error = %v", err)
+	}
+
+	agents, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(agents) != 1 || agents[0].ApprovedAt == nil || agents[0].Name != "bot" {
+		t.Fatalf("List() = %+v", agents)
+	}
+	if agents[0].IsExpired(time.Now()) || !agents[0].IsExpired(validUntil) {
+		t.Error("IsExpired() does not follow ValidUntil")
+	}
+
+	// Key files are regular keystore files
+	accounts, err := ListKeystoreAccounts(dir)
+	if err != nil || len(accounts) != 1 || accounts[0].Address != agent.AgentAddress {
+		t.Fatalf("ListKeystoreAccounts() = %+v, %v", accounts, err)
+	}
+
+	loaded, err := store.Load(agent.AgentAddress, StaticPassphrase("secret"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := loaded.Key.Use(func(k *ecdsa.PrivateKey) error {
+		if crypto.PubkeyToAddress(k.PublicKey).Hex() != agent.AgentAddress {
+			t.Error("loaded key does not match agent address")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Remove(agent.AgentAddress); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if agents, _ := store.List(); len(agents) != 0 {
+		t.Errorf("List() after Remove() = %+v", agents)
+	}
+}
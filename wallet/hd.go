@@ -0,0 +1,191 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultDerivationPath is the BIP-44 path prefix for Ethereum accounts.
+// The account index N is appended as the last component: m/44'/60'/0'/0/N.
+const DefaultDerivationPath = "m/44'/60'/0'/0"
+
+// hardenedOffset marks a hardened BIP-32 child index
+const hardenedOffset uint32 = 0x80000000
+
+// Signer is a private key together with the address it signs for
+type Signer struct {
+	PrivateKey *ecdsa.PrivateKey
+	Address    string
+	// Path is the derivation path the key was derived from, empty if not derived
+	Path string
+}
+
+// NewSigner creates a signer from a private key
+func NewSigner(privateKey *ecdsa.PrivateKey) *Signer {
+	return &Signer{
+		PrivateKey: privateKey,
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+	}
+}
+
+// NewMnemonic generates a new BIP-39 mnemonic with the given entropy size in bits (128-256, multiple of 32)
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mnemonic: %w", err)
+	}
+	return mnemonic, nil
+}
+
+// HDWallet derives signers from a BIP-39 seed
+type HDWallet struct {
+	masterKey []byte
+	chainCode []byte
+}
+
+// NewHDWalletFromMnemonic creates an HD wallet from a BIP-39 mnemonic and optional passphrase
+func NewHDWalletFromMnemonic(mnemonic, passphrase string) (*HDWallet, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	return NewHDWalletFromSeed(seed)
+}
+
+// NewHDWalletFromSeed creates an HD wallet from a BIP-32 seed
+func NewHDWalletFromSeed(seed []byte) (*HDWallet, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed must be between 16 and 64 bytes, got %d", len(seed))
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid master key derived from seed")
+	}
+
+	return &HDWallet{masterKey: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// Derive returns the signer at a derivation path such as "m/44'/60'/0'/0/0"
+func (w *HDWallet) Derive(path string) (*Signer, error) {
+	indices, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, chainCode := w.masterKey, w.chainCode
+	for _, index := range indices {
+		key, chainCode, err = deriveChild(key, chainCode, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %w", path, err)
+		}
+	}
+
+	privateKey, err := crypto.ToECDSA(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert derived key: %w", err)
+	}
+
+	signer := NewSigner(privateKey)
+	signer.Path = path
+	return signer, nil
+}
+
+// Account returns the signer at m/44'/60'/0'/0/index
+func (w *HDWallet) Account(index uint32) (*Signer, error) {
+	return w.Derive(fmt.Sprintf("%s/%d", DefaultDerivationPath, index))
+}
+
+// Accounts returns count consecutive signers starting at m/44'/60'/0'/0/start
+func (w *HDWallet) Accounts(start, count uint32) ([]*Signer, error) {
+	signers := make([]*Signer, 0, count)
+	for i := uint32(0); i < count; i++ {
+		signer, err := w.Account(start + i)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// ParseDerivationPath parses a BIP-32 path like "m/44'/60'/0'/0/0" into child indices.
+// Hardened components may be marked with ' or h.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path must start with m: %s", path)
+	}
+
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+
+		value, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(value) >= hardenedOffset {
+			return nil, fmt.Errorf("invalid derivation path component %q in %s", part, path)
+		}
+
+		index := uint32(value)
+		if hardened {
+			index += hardenedOffset
+		}
+		indices = append(indices, index)
+	}
+
+	return indices, nil
+}
+
+// deriveChild implements BIP-32 private parent key to private child key derivation
+func deriveChild(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	data := make([]byte, 0, 37)
+	if index >= hardenedOffset {
+		data = append(data, 0)
+		data = append(data, key...)
+	} else {
+		privateKey, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, crypto.CompressPubkey(&privateKey.PublicKey)...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+
+	child := tweak.Add(tweak, new(big.Int).SetBytes(key))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+
+	return child.FillBytes(make([]byte, 32)), sum[32:], nil
+}
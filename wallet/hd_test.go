@@ -0,0 +1,47 @@
+package wallet
+
+import "testing"
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestHDWalletDerivesStandardAddresses(t *testing.T) {
+	w, err := NewHDWalletFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("NewHDWalletFromMnemonic() error = %v", err)
+	}
+
+	// Well-known addresses for the BIP-39 test mnemonic, as produced by MetaMask and Ledger
+	want := []string{
+		"0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+	}
+
+	signers, err := w.Accounts(0, uint32(len(want)))
+	if err != nil {
+		t.Fatalf("Accounts() error = %v", err)
+	}
+	for i, signer := range signers {
+		if signer.Address != want[i] {
+			t.Errorf("account %d address = %s, want %s", i, signer.Address, want[i])
+		}
+	}
+	if signers[1].Path != "m/44'/60'/0'/0/1" {
+		t.Errorf("account 1 path = %s", signers[1].Path)
+	}
+}
+
+func TestHDWalletRejectsInvalidInput(t *testing.T) {
+	if _, err := NewHDWalletFromMnemonic("abandon abandon abandon", ""); err == nil {
+		t.Error("NewHDWalletFromMnemonic() with invalid mnemonic expected error")
+	}
+
+	w, err := NewHDWalletFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("NewHDWalletFromMnemonic() error = %v", err)
+	}
+	for _, path := range []string{"", "44'/60'", "m/x", "m/2147483648"} {
+		if _, err := w.Derive(path); err == nil {
+			t.Errorf("Derive(%q) expected error", path)
+		}
+	}
+}
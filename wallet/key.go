@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrKeyZeroed is returned when a key is used after Zero
+var ErrKeyZeroed = errors.New("private key has been zeroed")
+
+// redacted is printed instead of key material
+const redacted = "[REDACTED]"
+
+// PrivateKey guards an ECDSA private key.
+// It never prints the key through fmt, log or encoding/json, supports wiping the
+// key with Zero on shutdown, and can lock the key's memory to keep it out of swap.
+type PrivateKey struct {
+	mu      sync.RWMutex
+	key     *ecdsa.PrivateKey
+	address string
+	locked  bool
+}
+
+// NewPrivateKey wraps key. The wrapper takes ownership: Zero wipes key in place.
+func NewPrivateKey(key *ecdsa.PrivateKey) *PrivateKey {
+	return &PrivateKey{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey).Hex(),
+	}
+}
+
+// Address returns the address of the key. It remains available after Zero.
+func (k *PrivateKey) Address() string {
+	return k.address
+}
+
+// Use calls fn with the raw key. The key must not be retained after fn returns.
+func (k *PrivateKey) Use(fn func(key *ecdsa.PrivateKey) error) error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.key == nil {
+		return ErrKeyZeroed
+	}
+	return fn(k.key)
+}
+
+// Zero overwrites the key material and releases any memory lock.
+// Every later Use returns ErrKeyZeroed, and signing with previously obtained raw
+// references fails because the scalar is zero.
+func (k *PrivateKey) Zero() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.key == nil {
+		return
+	}
+	if k.locked {
+		_ = munlockKey(k.key)
+		k.locked = false
+	}
+	clear(k.key.D.Bits())
+	k.key.D.SetInt64(0)
+	k.key = nil
+}
+
+// IsZeroed reports whether Zero has been called
+func (k *PrivateKey) IsZeroed() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.key == nil
+}
+
+// Lock locks the memory holding the key scalar so it is not swapped to disk.
+// It returns an error on platforms without mlock or when the process lacks the
+// required resource limits.
+func (k *PrivateKey) Lock() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.key == nil {
+		return ErrKeyZeroed
+	}
+	if k.locked {
+		return nil
+	}
+	if err := mlockKey(k.key); err != nil {
+		return fmt.Errorf("failed to lock key memory: %w", err)
+	}
+	k.locked = true
+	return nil
+}
+
+// String returns the address with the key redacted
+func (k *PrivateKey) String() string {
+	return fmt.Sprintf("PrivateKey(%s, %s)", k.address, redacted)
+}
+
+// GoString returns the same as String, so %#v does not expose the key
+func (k *PrivateKey) GoString() string {
+	return k.String()
+}
+
+// Format prints the redacted form for every verb
+func (k *PrivateKey) Format(f fmt.State, verb rune) {
+	_, _ = f.Write([]byte(k.String()))
+}
+
+// MarshalJSON encodes the key as a redacted placeholder
+func (k *PrivateKey) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// MarshalText encodes the key as a redacted placeholder
+func (k *PrivateKey) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"errors"
+)
+
+func mlockKey(*ecdsa.PrivateKey) error {
+	return errors.New("memory locking is not supported on this platform")
+}
+
+func munlockKey(*ecdsa.PrivateKey) error {
+	return nil
+}
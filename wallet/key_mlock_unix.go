@@ -0,0 +1,34 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"math/bits"
+	"syscall"
+	"unsafe"
+)
+
+func keyBytes(key *ecdsa.PrivateKey) []byte {
+	words := key.D.Bits()
+	if len(words) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), len(words)*bits.UintSize/8)
+}
+
+func mlockKey(key *ecdsa.PrivateKey) error {
+	b := keyBytes(key)
+	if b == nil {
+		return nil
+	}
+	return syscall.Mlock(b)
+}
+
+func munlockKey(key *ecdsa.PrivateKey) error {
+	b := keyBytes(key)
+	if b == nil {
+		return nil
+	}
+	return syscall.Munlock(b)
+}
@@ -0,0 +1,63 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPrivateKeyIsNeverPrinted(t *testing.T) {
+	raw, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := fmt.Sprintf("%x", crypto.FromECDSA(raw))
+	key := NewPrivateKey(raw)
+
+	jsonData, err := json.Marshal(struct{ Key *PrivateKey }{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, out := range []string{
+		fmt.Sprint(key), fmt.Sprintf("%v %+v %#v %s %x", key, key, key, key, key), string(jsonData),
+	} {
+		if strings.Contains(out, secret) || !strings.Contains(out, redacted) {
+			t.Errorf("unexpected formatted key: %s", out)
+		}
+	}
+}
+
+func TestPrivateKeyZero(t *testing.T) {
+	raw, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := NewPrivateKey(raw)
+	address := key.Address()
+
+	if err := key.Use(func(k *ecdsa.PrivateKey) error {
+		_, err := crypto.Sign(make([]byte, 32), k)
+		return err
+	}); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	key.Zero()
+	if !key.IsZeroed() || key.Address() != address {
+		t.Errorf("after Zero: IsZeroed() = %v, Address() = %s", key.IsZeroed(), key.Address())
+	}
+	if raw.D.Sign() != 0 {
+		t.Error("Zero() did not wipe the underlying key")
+	}
+	if _, err := crypto.Sign(make([]byte, 32), raw); err == nil {
+		t.Error("signing with a zeroed raw key expected error")
+	}
+	if err := key.Use(func(*ecdsa.PrivateKey) error { return nil }); !errors.Is(err, ErrKeyZeroed) {
+		t.Errorf("Use() after Zero error = %v, want ErrKeyZeroed", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Package wallet provides helpers for loading and managing the private keys used
+// to sign Hyperliquid actions.
+//
+// Keys can be loaded from a standard go-ethereum keystore directory (encrypted
+// JSON key files, as written by geth or clef), so deployments can reuse existing
+// key management instead of handling raw hex keys.
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dwdwow/evmutil-go"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PassphraseFunc returns the passphrase used to decrypt the key of the given address
+type PassphraseFunc func(address string) (string, error)
+
+// StaticPassphrase returns a PassphraseFunc that always returns passphrase
+func StaticPassphrase(passphrase string) PassphraseFunc {
+	return func(string) (string, error) {
+		return passphrase, nil
+	}
+}
+
+// EnvPassphrase returns a PassphraseFunc that reads the passphrase from an environment variable
+func EnvPassphrase(name string) PassphraseFunc {
+	return func(string) (string, error) {
+		passphrase, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return passphrase, nil
+	}
+}
+
+// TerminalPassphrase returns a PassphraseFunc that prompts for the passphrase on the terminal
+func TerminalPassphrase() PassphraseFunc {
+	return func(address string) (string, error) {
+		return evmutil.ReadPasswordFromTerminal(fmt.Sprintf("Passphrase for %s: ", address), false)
+	}
+}
+
+// KeystoreAccount is an encrypted key file found in a keystore directory
+type KeystoreAccount struct {
+	Address string
+	Path    string
+}
+
+// ListKeystoreAccounts returns the accounts of all key files in a keystore directory.
+// Files that are not encrypted key files are skipped.
+func ListKeystoreAccounts(dir string) ([]KeystoreAccount, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore directory: %w", err)
+	}
+
+	var accounts []KeystoreAccount
+	for _, entry := range entries {
+		name := entry.Name()
+		// Skip directories, hidden files and editor backups like geth does
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file %s: %w", name, err)
+		}
+
+		var keyFile struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(data, &keyFile); err != nil || !common.IsHexAddress(keyFile.Address) {
+			continue
+		}
+
+		accounts = append(accounts, KeystoreAccount{
+			Address: common.HexToAddress(keyFile.Address).Hex(),
+			Path:    path,
+		})
+	}
+
+	return accounts, nil
+}
+
+// LoadFromKeystore decrypts the key for address from a keystore directory.
+// If address is empty, the directory must contain exactly one account.
+func LoadFromKeystore(dir string, address string, passphrase PassphraseFunc) (*ecdsa.PrivateKey, error) {
+	accounts, err := ListKeystoreAccounts(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := selectAccount(accounts, address)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := passphrase(account.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passphrase: %w", err)
+	}
+
+	return LoadKeystoreFile(account.Path, auth)
+}
+
+// LoadKeystoreFile decrypts a single encrypted key file
+func LoadKeystoreFile(path string, passphrase string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key file: %w", err)
+	}
+
+	return key.PrivateKey, nil
+}
+
+// selectAccount picks the account matching address, or the only account if address is empty
+func selectAccount(accounts []KeystoreAccount, address string) (KeystoreAccount, error) {
+	if address == "" {
+		switch len(accounts) {
+		case 0:
+			return KeystoreAccount{}, fmt.Errorf("no accounts found in keystore")
+		case 1:
+			return accounts[0], nil
+		default:
+			return KeystoreAccount{}, fmt.Errorf("keystore contains %d accounts, address must be specified", len(accounts))
+		}
+	}
+
+	if !common.IsHexAddress(address) {
+		return KeystoreAccount{}, fmt.Errorf("invalid address: %s", address)
+	}
+
+	want := common.HexToAddress(address).Hex()
+	for _, account := range accounts {
+		if account.Address == want {
+			return account, nil
+		}
+	}
+
+	return KeystoreAccount{}, fmt.Errorf("account %s not found in keystore", address)
+}
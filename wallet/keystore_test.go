@@ -0,0 +1,73 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+func writeTestKey(t *testing.T, dir string, passphrase string) string {
+	t.Helper()
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	key := &keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}
+
+	data, err := keystore.EncryptKey(key, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("EncryptKey() error = %v", err)
+	}
+
+	path := filepath.Join(dir, "UTC--"+key.Address.Hex())
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return key.Address.Hex()
+}
+
+func TestLoadFromKeystore(t *testing.T) {
+	dir := t.TempDir()
+	first := writeTestKey(t, dir, "first")
+	second := writeTestKey(t, dir, "second")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, err := ListKeystoreAccounts(dir)
+	if err != nil {
+		t.Fatalf("ListKeystoreAccounts() error = %v", err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("ListKeystoreAccounts() returned %d accounts, want 2", len(accounts))
+	}
+
+	key, err := LoadFromKeystore(dir, second, func(address string) (string, error) {
+		if address != second {
+			t.Errorf("passphrase requested for %s, want %s", address, second)
+		}
+		return "second", nil
+	})
+	if err != nil {
+		t.Fatalf("LoadFromKeystore() error = %v", err)
+	}
+	if got := crypto.PubkeyToAddress(key.PublicKey).Hex(); got != second {
+		t.Errorf("loaded key address = %s, want %s", got, second)
+	}
+
+	if _, err := LoadFromKeystore(dir, first, StaticPassphrase("wrong")); err == nil {
+		t.Error("LoadFromKeystore() with wrong passphrase expected error")
+	}
+	if _, err := LoadFromKeystore(dir, "", StaticPassphrase("first")); err == nil {
+		t.Error("LoadFromKeystore() without address on multi-account keystore expected error")
+	}
+}
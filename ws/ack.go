@@ -0,0 +1,146 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSubscriptionRejected is wrapped by the error returned when the server rejects a
+// subscription, e.g. for an unknown coin or an invalid user address
+var ErrSubscriptionRejected = errors.New("subscription rejected")
+
+// subscriptionAcks tracks the acknowledgments of the subscriptions sent on the current
+// connection. Safe for concurrent use.
+type subscriptionAcks struct {
+	mu      sync.Mutex
+	pending map[string]struct{}
+	err     error
+	// done is closed once every subscription was acknowledged, or with err set
+	done chan struct{}
+}
+
+func newSubscriptionAcks() *subscriptionAcks {
+	return &subscriptionAcks{done: make(chan struct{})}
+}
+
+// expect starts waiting for the acknowledgments of subs on a new connection
+func (a *subscriptionAcks) expect(subs []Subscription) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rearm()
+	a.pending = make(map[string]struct{}, len(subs))
+	for _, sub := range subs {
+		a.pending[ackKey(sub)] = struct{}{}
+	}
+	if len(a.pending) == 0 {
+		close(a.done)
+	}
+}
+
+// ack records the acknowledgment of sub
+func (a *subscriptionAcks) ack(sub Subscription) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.pending[ackKey(sub)]; !ok {
+		return
+	}
+	delete(a.pending, ackKey(sub))
+	if len(a.pending) == 0 && a.err == nil {
+		close(a.done)
+	}
+}
+
+// fail makes waiters return err, e.g. when a subscription was rejected or the client closed
+func (a *subscriptionAcks) fail(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return
+	}
+	a.rearm()
+	a.err = err
+	close(a.done)
+}
+
+// reconnecting makes waiters wait for the acknowledgments of the next connection
+func (a *subscriptionAcks) reconnecting() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rearm()
+}
+
+// rearm replaces done if it was closed. Must be called with mu held.
+func (a *subscriptionAcks) rearm() {
+	if a.isDone() {
+		a.done = make(chan struct{})
+	}
+	a.pending = nil
+	a.err = nil
+}
+
+func (a *subscriptionAcks) isDone() bool {
+	select {
+	case <-a.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until the subscriptions of the current connection are acknowledged
+func (a *subscriptionAcks) wait(ctx context.Context) error {
+	for {
+		a.mu.Lock()
+		done := a.done
+		a.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		a.mu.Lock()
+		current, err := a.done == done, a.err
+		a.mu.Unlock()
+		// Otherwise the client reconnected meanwhile, wait for the new connection
+		if current {
+			return err
+		}
+	}
+}
+
+// ackKey identifies a subscription in acknowledgments, which echo the subscription
+// with the server's normalization applied
+func ackKey(sub Subscription) string {
+	if key, err := subscriptionIdentifier(sub); err == nil {
+		return key
+	}
+	return string(sub.Type)
+}
+
+// parseSubscriptionAck returns the subscription acknowledged by the data of a
+// subscriptionResponse message, ok being false for unsubscribe responses
+func parseSubscriptionAck(data json.RawMessage) (sub Subscription, ok bool) {
+	var resp struct {
+		Method       string       `json:"method"`
+		Subscription Subscription `json:"subscription"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Method != "subscribe" {
+		return sub, false
+	}
+	return resp.Subscription, true
+}
+
+// rejectionError converts the data of an error message to an error wrapping
+// ErrSubscriptionRejected
+func rejectionError(data json.RawMessage) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		text = string(data)
+	}
+	return fmt.Errorf("%w: %s", ErrSubscriptionRejected, text)
+}
@@ -0,0 +1,109 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientWaitSubscribed(t *testing.T) {
+	release := make(chan struct{})
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		readSubscription(t, conn)
+		// Acknowledgments echo the subscription as normalized by the server
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"subscriptionResponse","data":{"method":"subscribe","subscription":{"type":"l2Book","coin":"BTC","nSigFigs":null,"mantissa":null}}}`))
+		<-release
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"subscriptionResponse","data":{"method":"subscribe","subscription":{"type":"l2Book","coin":"eth","nSigFigs":null,"mantissa":null}}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"l2Book","data":{"coin":"BTC","time":1,"levels":[[],[]]}}`))
+		conn.ReadMessage()
+	})
+
+	client := NewL2BookClient("BTC", "ETH")
+	client.url = server.URL()
+	defer client.Close()
+	books := make(chan WsBook, 1)
+	go func() {
+		book, err := client.Read()
+		if err != nil {
+			t.Errorf("Read() error = %v", err)
+		}
+		books <- book
+	}()
+
+	// ETH is not acknowledged yet
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := client.WaitSubscribed(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitSubscribed() before the second ack = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.WaitSubscribed(ctx); err != nil {
+		t.Fatalf("WaitSubscribed() error = %v", err)
+	}
+	// Acknowledgments are not returned as data
+	if book := <-books; book.Coin != "BTC" {
+		t.Errorf("Read() = %+v, want the BTC book", book)
+	}
+}
+
+func TestClientSubscriptionRejected(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"error","data":"Invalid subscription {\"type\":\"l2Book\",\"coin\":\"NOPE\"}"}`))
+		conn.ReadMessage()
+	})
+
+	client := NewL2BookClient("NOPE")
+	client.url = server.URL()
+	defer client.Close()
+
+	if _, err := client.Read(); !errors.Is(err, ErrSubscriptionRejected) {
+		t.Fatalf("Read() error = %v, want ErrSubscriptionRejected", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.WaitSubscribed(ctx); !errors.Is(err, ErrSubscriptionRejected) {
+		t.Errorf("WaitSubscribed() error = %v, want ErrSubscriptionRejected", err)
+	}
+	if got := client.State(); got != StateDisconnected {
+		t.Errorf("State() = %v, want %v", got, StateDisconnected)
+	}
+}
+
+func TestSubscriptionAcksWaitForNextConnection(t *testing.T) {
+	coin := "BTC"
+	sub := Subscription{Type: SubscriptionTrades, Coin: &coin}
+	acks := newSubscriptionAcks()
+	acks.expect([]Subscription{sub})
+	acks.ack(sub)
+	if err := acks.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+
+	acks.reconnecting()
+	result := make(chan error, 1)
+	go func() { result <- acks.wait(context.Background()) }()
+	select {
+	case err := <-result:
+		t.Fatalf("wait() returned %v while reconnecting", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	acks.expect([]Subscription{sub})
+	acks.ack(sub)
+	if err := <-result; err != nil {
+		t.Errorf("wait() after reconnection error = %v", err)
+	}
+
+	acks.fail(errors.New("client closed"))
+	if err := acks.wait(context.Background()); err == nil {
+		t.Error("wait() after fail = nil, want error")
+	}
+}
@@ -0,0 +1,86 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	testPerpCtxMsg = `{"channel":"activeAssetCtx","data":{"coin":"BTC","ctx":{"funding":"0.0000125","openInterest":"100.5","prevDayPx":"60000.0","dayNtlVlm":"1000000.0","premium":null,"oraclePx":"61000.0","markPx":"61001.0","midPx":"61000.5","impactPxs":["61000.0","61001.0"],"dayBaseVlm":"16.4"}}}`
+	testSpotCtxMsg = `{"channel":"activeSpotAssetCtx","data":{"coin":"@107","ctx":{"prevDayPx":"20.0","dayNtlVlm":"5000.0","markPx":"21.0","midPx":null,"circulatingSupply":"1000.0","totalSupply":"2000.0","dayBaseVlm":"250.0"}}}`
+)
+
+func newAssetCtxTestServer(t *testing.T) *testServer {
+	return newTestServer(t, func(conn *websocket.Conn, index int) {
+		for i := 0; i < 2; i++ {
+			readSubscription(t, conn)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"subscriptionResponse","data":{"method":"subscribe","subscription":{"type":"activeAssetCtx","coin":"BTC"}}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(testPerpCtxMsg))
+		conn.WriteMessage(websocket.TextMessage, []byte(testSpotCtxMsg))
+		conn.WriteMessage(websocket.TextMessage, []byte(testPerpCtxMsg))
+		conn.ReadMessage()
+	})
+}
+
+func TestActiveAssetCtxClientDetectsChannel(t *testing.T) {
+	server := newAssetCtxTestServer(t)
+	client := NewActiveAssetCtxClient("BTC", "@107")
+	client.url = server.URL()
+	defer client.Close()
+
+	perp, err := client.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if perp.Perp == nil || perp.Spot != nil || perp.Coin() != "BTC" {
+		t.Fatalf("perp update = %+v", perp)
+	}
+	if ctx := perp.Perp.Ctx; ctx.Funding != 0.0000125 || ctx.MarkPx != 61001 || ctx.MidPx == nil || *ctx.MidPx != 61000.5 || ctx.Premium != nil {
+		t.Errorf("perp ctx = %+v", ctx)
+	}
+
+	spot, err := client.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if spot.Spot == nil || spot.Perp != nil || spot.Coin() != "@107" {
+		t.Fatalf("spot update = %+v", spot)
+	}
+	if ctx := spot.Spot.Ctx; ctx.CirculatingSupply != 1000 || ctx.MidPx != nil {
+		t.Errorf("spot ctx = %+v", ctx)
+	}
+}
+
+func TestPerpAssetCtxClientSkipsSpot(t *testing.T) {
+	server := newAssetCtxTestServer(t)
+	client := NewPerpAssetCtxClient("BTC", "@107")
+	client.url = server.URL()
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		perp, err := client.Read()
+		if err != nil {
+			t.Fatalf("Read() %d error = %v", i, err)
+		}
+		if perp.Coin != "BTC" || perp.Ctx.OpenInterest != 100.5 {
+			t.Errorf("perp update %d = %+v", i, perp)
+		}
+	}
+}
+
+func TestSpotAssetCtxClientSkipsPerp(t *testing.T) {
+	server := newAssetCtxTestServer(t)
+	client := NewSpotAssetCtxClient("BTC", "@107")
+	client.url = server.URL()
+	defer client.Close()
+
+	spot, err := client.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if spot.Coin != "@107" || spot.Ctx.CirculatingSupply != 1000 {
+		t.Errorf("spot update = %+v", spot)
+	}
+}
@@ -0,0 +1,254 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+// Defaults of a BookChecker
+const (
+	DefaultBookCheckInterval = 30 * time.Second
+	DefaultBookMaxLag        = 5 * time.Second
+	DefaultBookCheckDepth    = 10
+)
+
+// BookIssue is a kind of inconsistency found by a BookChecker
+type BookIssue int
+
+const (
+	// BookCrossed is a local book whose best bid is not below its best ask
+	BookCrossed BookIssue = iota
+
+	// BookStale is a local book older than the snapshot by more than the maximum lag
+	BookStale
+
+	// BookDepthMismatch is a local book with fewer levels than the snapshot, or with
+	// other levels than a snapshot of the same time
+	BookDepthMismatch
+
+	// BookSnapshotFailed is a snapshot that could not be fetched
+	BookSnapshotFailed
+)
+
+// String returns the name of the issue
+func (i BookIssue) String() string {
+	switch i {
+	case BookCrossed:
+		return "crossed"
+	case BookStale:
+		return "stale"
+	case BookDepthMismatch:
+		return "depth-mismatch"
+	case BookSnapshotFailed:
+		return "snapshot-failed"
+	default:
+		return fmt.Sprintf("BookIssue(%d)", int(i))
+	}
+}
+
+// BookAlert reports an inconsistency between the local book of a coin and its snapshot
+type BookAlert struct {
+	Coin   string
+	Issue  BookIssue
+	Detail string
+	Local  WsBook
+	// Snapshot is nil for BookSnapshotFailed
+	Snapshot *types.L2BookData
+}
+
+// BookChecker periodically compares the local l2Book of each coin, as received from the
+// WebSocket feed, with a snapshot fetched over REST, to detect a feed that silently
+// stopped updating or went out of sync:
+//
+//	checker := ws.NewBookChecker(info.L2Snapshot)
+//	checker.OnAlert(func(a ws.BookAlert) { log.Printf("%s book %s: %s", a.Coin, a.Issue, a.Detail) })
+//	checker.OnResync(func(coin string) { books.Close() }) // the next Read dials again
+//	go checker.Run(ctx)
+//
+//	for {
+//	    book, err := books.Read()
+//	    if err != nil {
+//	        continue
+//	    }
+//	    checker.Update(book)
+//	    // Quote on book...
+//	}
+//
+// Safe for concurrent use.
+type BookChecker struct {
+	snapshot func(coin string) (*types.L2BookData, error)
+	interval time.Duration
+	maxLag   time.Duration
+	depth    int
+	onAlert  func(BookAlert)
+	onResync func(coin string)
+
+	mu    sync.Mutex
+	books map[string]WsBook
+}
+
+// NewBookChecker creates a checker fetching snapshots with snapshot, e.g.
+// (*client.Info).L2Snapshot
+func NewBookChecker(snapshot func(coin string) (*types.L2BookData, error)) *BookChecker {
+	return &BookChecker{
+		snapshot: snapshot,
+		interval: DefaultBookCheckInterval,
+		maxLag:   DefaultBookMaxLag,
+		depth:    DefaultBookCheckDepth,
+		books:    make(map[string]WsBook),
+	}
+}
+
+// SetInterval sets how often Run checks the books. Must be called before Run.
+func (c *BookChecker) SetInterval(interval time.Duration) {
+	c.interval = interval
+}
+
+// SetMaxLag sets how much older than the snapshot the local book may be before it is
+// reported as stale. Must be called before Run.
+func (c *BookChecker) SetMaxLag(lag time.Duration) {
+	c.maxLag = lag
+}
+
+// SetDepth sets the number of levels per side compared with the snapshot.
+// Must be called before Run.
+func (c *BookChecker) SetDepth(depth int) {
+	c.depth = depth
+}
+
+// OnAlert sets a callback invoked by Run for every alert. Must be called before Run.
+func (c *BookChecker) OnAlert(fn func(BookAlert)) {
+	c.onAlert = fn
+}
+
+// OnResync sets a callback invoked by Run once per check of a coin that raised alerts
+// other than BookSnapshotFailed, to resubscribe to its book. Must be called before Run.
+func (c *BookChecker) OnResync(fn func(coin string)) {
+	c.onResync = fn
+}
+
+// Update records book as the local book of its coin
+func (c *BookChecker) Update(book WsBook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.books[book.Coin] = book
+}
+
+// Forget stops checking the book of coin, e.g. after a resync until the first update
+func (c *BookChecker) Forget(coin string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.books, coin)
+}
+
+// Run checks the books of every coin updated so far every interval, until ctx is done
+func (c *BookChecker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			c.checkAll()
+		}
+	}
+}
+
+func (c *BookChecker) checkAll() {
+	c.mu.Lock()
+	coins := make([]string, 0, len(c.books))
+	for coin := range c.books {
+		coins = append(coins, coin)
+	}
+	c.mu.Unlock()
+	sort.Strings(coins)
+
+	for _, coin := range coins {
+		alerts := c.Check(coin)
+		resync := false
+		for _, alert := range alerts {
+			if c.onAlert != nil {
+				c.onAlert(alert)
+			}
+			resync = resync || alert.Issue != BookSnapshotFailed
+		}
+		if resync && c.onResync != nil {
+			c.onResync(coin)
+		}
+	}
+}
+
+// Check fetches a snapshot of coin and compares it with the local book, returning the
+// inconsistencies found. It returns nil if no local book of coin was recorded.
+func (c *BookChecker) Check(coin string) []BookAlert {
+	c.mu.Lock()
+	local, ok := c.books[coin]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	var alerts []BookAlert
+	alert := func(issue BookIssue, snapshot *types.L2BookData, format string, args ...any) {
+		alerts = append(alerts, BookAlert{
+			Coin:     coin,
+			Issue:    issue,
+			Detail:   fmt.Sprintf(format, args...),
+			Local:    local,
+			Snapshot: snapshot,
+		})
+	}
+
+	bids, asks := local.Levels[0], local.Levels[1]
+	if len(bids) > 0 && len(asks) > 0 && bids[0].Px >= asks[0].Px {
+		alert(BookCrossed, nil, "best bid %v >= best ask %v", bids[0].Px, asks[0].Px)
+	}
+
+	snapshot, err := c.snapshot(coin)
+	if err != nil {
+		alert(BookSnapshotFailed, nil, "failed to fetch snapshot: %v", err)
+		return alerts
+	}
+	if lag := time.Duration(snapshot.Time-local.Time) * time.Millisecond; lag > c.maxLag {
+		alert(BookStale, snapshot, "local book %v older than snapshot", lag)
+	}
+
+	for side, name := range []string{"bids", "asks"} {
+		want := snapshot.Levels[side]
+		got := local.Levels[side]
+		if c.depth > 0 {
+			want = want[:min(len(want), c.depth)]
+			got = got[:min(len(got), c.depth)]
+		}
+		if len(got) < len(want) {
+			alert(BookDepthMismatch, snapshot, "%d %s, snapshot has %d", len(got), name, len(want))
+			continue
+		}
+		// Levels are only comparable for the same state of the book
+		if snapshot.Time != local.Time {
+			continue
+		}
+		for i, level := range want {
+			if !sameLevel(got[i], level) {
+				alert(BookDepthMismatch, snapshot, "%s level %d is %v x %v, snapshot has %s x %s",
+					name, i, got[i].Px, got[i].Sz, level.Px, level.Sz)
+				break
+			}
+		}
+	}
+	return alerts
+}
+
+// sameLevel compares a level of the feed with a level of a snapshot
+func sameLevel(local WsLevel, snapshot types.L2Level) bool {
+	px, pxErr := strconv.ParseFloat(snapshot.Px, 64)
+	sz, szErr := strconv.ParseFloat(snapshot.Sz, 64)
+	return pxErr == nil && szErr == nil && local.Px == px && local.Sz == sz && local.N == snapshot.N
+}
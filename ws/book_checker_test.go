@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+func testSnapshot(time int64) *types.L2BookData {
+	return &types.L2BookData{
+		Coin: "BTC",
+		Time: time,
+		Levels: [2][]types.L2Level{
+			{{Px: "100", Sz: "1", N: 1}, {Px: "99", Sz: "2", N: 2}},
+			{{Px: "101", Sz: "1.5", N: 1}},
+		},
+	}
+}
+
+func testLocalBook(time int64) WsBook {
+	return WsBook{
+		Coin: "BTC",
+		Time: time,
+		Levels: [2][]WsLevel{
+			{{Px: 100, Sz: 1, N: 1}, {Px: 99, Sz: 2, N: 2}},
+			{{Px: 101, Sz: 1.5, N: 1}},
+		},
+	}
+}
+
+func issues(alerts []BookAlert) []BookIssue {
+	var out []BookIssue
+	for _, alert := range alerts {
+		out = append(out, alert.Issue)
+	}
+	return out
+}
+
+func TestBookCheckerCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		local    func() WsBook
+		snapshot *types.L2BookData
+		want     []BookIssue
+	}{
+		{"consistent", func() WsBook { return testLocalBook(1000) }, testSnapshot(1000), nil},
+		{"newer snapshot", func() WsBook { return testLocalBook(1000) }, testSnapshot(3000), nil},
+		{"stale", func() WsBook { return testLocalBook(1000) }, testSnapshot(10000), []BookIssue{BookStale}},
+		{"crossed", func() WsBook {
+			book := testLocalBook(1000)
+			book.Levels[1][0].Px = 100
+			return book
+		}, testSnapshot(3000), []BookIssue{BookCrossed}},
+		{"missing levels", func() WsBook {
+			book := testLocalBook(1000)
+			book.Levels[0] = book.Levels[0][:1]
+			return book
+		}, testSnapshot(3000), []BookIssue{BookDepthMismatch}},
+		{"different levels", func() WsBook {
+			book := testLocalBook(1000)
+			book.Levels[1][0].Sz = 2
+			return book
+		}, testSnapshot(1000), []BookIssue{BookDepthMismatch}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewBookChecker(func(coin string) (*types.L2BookData, error) { return tt.snapshot, nil })
+			checker.Update(tt.local())
+			got := issues(checker.Check("BTC"))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Check() issues = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Check() issues = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	checker := NewBookChecker(func(string) (*types.L2BookData, error) { return nil, errors.New("down") })
+	if alerts := checker.Check("BTC"); alerts != nil {
+		t.Errorf("Check() without a local book = %v", alerts)
+	}
+}
+
+func TestBookCheckerRun(t *testing.T) {
+	snapshots := 0
+	checker := NewBookChecker(func(coin string) (*types.L2BookData, error) {
+		snapshots++
+		if snapshots == 1 {
+			return nil, errors.New("rate limited")
+		}
+		return testSnapshot(10000), nil
+	})
+	checker.SetInterval(10 * time.Millisecond)
+	alerts := make(chan BookAlert, 10)
+	resyncs := make(chan string, 10)
+	checker.OnAlert(func(alert BookAlert) { alerts <- alert })
+	checker.OnResync(func(coin string) {
+		checker.Forget(coin)
+		resyncs <- coin
+	})
+	checker.Update(testLocalBook(1000))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- checker.Run(ctx) }()
+
+	// A failed snapshot is reported without resync
+	if alert := <-alerts; alert.Issue != BookSnapshotFailed || alert.Coin != "BTC" {
+		t.Errorf("first alert = %+v", alert)
+	}
+	if alert := <-alerts; alert.Issue != BookStale || alert.Snapshot == nil {
+		t.Errorf("second alert = %+v", alert)
+	}
+	select {
+	case coin := <-resyncs:
+		if coin != "BTC" {
+			t.Errorf("resync of %s", coin)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no resync")
+	}
+	if len(resyncs) != 0 {
+		t.Error("resync after the failed snapshot")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,234 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Limits the server enforces per IP address. Beyond them, new connections are refused
+// and new subscriptions are dropped without an error reaching the client.
+const (
+	MaxConnectionsPerIP   = 100
+	MaxSubscriptionsPerIP = 1000
+	// MaxUsersPerIP counts the unique users of user-specific subscriptions
+	MaxUsersPerIP = 10
+)
+
+// ErrBudgetExceeded is wrapped by the error returned when a connection or subscription
+// does not fit in its SubscriptionBudget
+var ErrBudgetExceeded = errors.New("subscription budget exceeded")
+
+// BudgetPolicy decides what happens to a connection or subscription that does not fit
+// in its SubscriptionBudget
+type BudgetPolicy int
+
+const (
+	// BudgetReject fails with ErrBudgetExceeded (the default)
+	BudgetReject BudgetPolicy = iota
+
+	// BudgetQueue waits until enough connections or subscriptions are released.
+	// Requests that could never fit are still rejected.
+	BudgetQueue
+)
+
+// BudgetUsage is the share of a SubscriptionBudget in use
+type BudgetUsage struct {
+	Connections      int
+	Subscriptions    int
+	Users            int
+	MaxConnections   int
+	MaxSubscriptions int
+	MaxUsers         int
+}
+
+// SubscriptionBudget counts the connections and subscriptions of the clients sharing it
+// and keeps them under the server's limits, so that subscriptions are not dropped
+// silently. Share one budget between all clients, managers and pools of the process:
+//
+//	budget := ws.NewSubscriptionBudget()
+//	trades := ws.NewTradesClient("BTC")
+//	trades.SetBudget(budget)
+//	pool := ws.NewConnectionPool()
+//	pool.SetConfigure(func(m *ws.Manager) { m.SetBudget(budget) })
+//
+// Connections and subscriptions are counted while established, and released when they
+// are closed or unsubscribed. Safe for concurrent use.
+type SubscriptionBudget struct {
+	mu               sync.Mutex
+	maxConnections   int
+	maxSubscriptions int
+	maxUsers         int
+	maxPerConnection int
+	policy           BudgetPolicy
+
+	connections   int
+	subscriptions int
+	users         map[string]int
+	// released is closed and replaced whenever capacity is released
+	released chan struct{}
+}
+
+// NewSubscriptionBudget creates a budget with the server's per-IP limits
+func NewSubscriptionBudget() *SubscriptionBudget {
+	return &SubscriptionBudget{
+		maxConnections:   MaxConnectionsPerIP,
+		maxSubscriptions: MaxSubscriptionsPerIP,
+		maxUsers:         MaxUsersPerIP,
+		users:            make(map[string]int),
+		released:         make(chan struct{}),
+	}
+}
+
+// SetLimits sets the maximum number of connections, subscriptions and unique users,
+// e.g. to keep a share of the IP's limits for another process. Zero means no limit.
+func (b *SubscriptionBudget) SetLimits(connections, subscriptions, users int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxConnections = connections
+	b.maxSubscriptions = subscriptions
+	b.maxUsers = users
+}
+
+// SetConnectionLimit sets the maximum number of subscriptions on one connection.
+// Zero means no limit (the default).
+func (b *SubscriptionBudget) SetConnectionLimit(subscriptions int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxPerConnection = subscriptions
+}
+
+// SetPolicy sets what happens when the budget is exhausted. Defaults to BudgetReject.
+func (b *SubscriptionBudget) SetPolicy(policy BudgetPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.policy = policy
+}
+
+// Usage returns the connections, subscriptions and users currently counted
+func (b *SubscriptionBudget) Usage() BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BudgetUsage{
+		Connections:      b.connections,
+		Subscriptions:    b.subscriptions,
+		Users:            len(b.users),
+		MaxConnections:   b.maxConnections,
+		MaxSubscriptions: b.maxSubscriptions,
+		MaxUsers:         b.maxUsers,
+	}
+}
+
+// acquire counts connections and subs. perConnection is the number of subscriptions
+// the connection receiving subs will have.
+func (b *SubscriptionBudget) acquire(ctx context.Context, connections int, subs []Subscription, perConnection int) error {
+	users := subscriptionUsers(subs)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		err := b.fits(connections, len(subs), users, perConnection)
+		if err == nil {
+			break
+		}
+		if b.policy != BudgetQueue || !b.couldFit(connections, len(subs), users, perConnection) {
+			return err
+		}
+
+		released := b.released
+		b.mu.Unlock()
+		select {
+		case <-released:
+			b.mu.Lock()
+		case <-ctx.Done():
+			b.mu.Lock()
+			return ctx.Err()
+		}
+	}
+
+	b.connections += connections
+	b.subscriptions += len(subs)
+	for _, user := range users {
+		b.users[user]++
+	}
+	return nil
+}
+
+// release stops counting connections and subs
+func (b *SubscriptionBudget) release(connections int, subs []Subscription) {
+	if connections == 0 && len(subs) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connections -= connections
+	b.subscriptions -= len(subs)
+	for _, user := range subscriptionUsers(subs) {
+		if b.users[user]--; b.users[user] <= 0 {
+			delete(b.users, user)
+		}
+	}
+	close(b.released)
+	b.released = make(chan struct{})
+}
+
+// fits checks the request against the current usage. Must be called with mu held.
+func (b *SubscriptionBudget) fits(connections, subs int, users []string, perConnection int) error {
+	if b.maxPerConnection > 0 && perConnection > b.maxPerConnection {
+		return fmt.Errorf("%w: %d subscriptions on one connection, limit %d", ErrBudgetExceeded, perConnection, b.maxPerConnection)
+	}
+	if b.maxConnections > 0 && b.connections+connections > b.maxConnections {
+		return fmt.Errorf("%w: %d connections in use, limit %d", ErrBudgetExceeded, b.connections, b.maxConnections)
+	}
+	if b.maxSubscriptions > 0 && b.subscriptions+subs > b.maxSubscriptions {
+		return fmt.Errorf("%w: %d subscriptions in use, limit %d", ErrBudgetExceeded, b.subscriptions, b.maxSubscriptions)
+	}
+	if b.maxUsers > 0 {
+		count := len(b.users)
+		for _, user := range distinct(users) {
+			if _, ok := b.users[user]; !ok {
+				count++
+			}
+		}
+		if count > b.maxUsers {
+			return fmt.Errorf("%w: %d users in use, limit %d", ErrBudgetExceeded, len(b.users), b.maxUsers)
+		}
+	}
+	return nil
+}
+
+// couldFit reports whether the request fits in an empty budget. Must be called with mu held.
+func (b *SubscriptionBudget) couldFit(connections, subs int, users []string, perConnection int) bool {
+	return (b.maxPerConnection <= 0 || perConnection <= b.maxPerConnection) &&
+		(b.maxConnections <= 0 || connections <= b.maxConnections) &&
+		(b.maxSubscriptions <= 0 || subs <= b.maxSubscriptions) &&
+		(b.maxUsers <= 0 || len(distinct(users)) <= b.maxUsers)
+}
+
+// subscriptionUsers returns the user of each user-specific subscription of subs, in
+// lower case. Users are counted once per subscription, so that subscriptions can be
+// released one by one.
+func subscriptionUsers(subs []Subscription) []string {
+	var users []string
+	for _, sub := range subs {
+		if sub.User != nil && *sub.User != "" {
+			users = append(users, strings.ToLower(*sub.User))
+		}
+	}
+	return users
+}
+
+func distinct(users []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, user := range users {
+		if !seen[user] {
+			seen[user] = true
+			out = append(out, user)
+		}
+	}
+	return out
+}
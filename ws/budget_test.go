@@ -0,0 +1,170 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func userSub(typ SubscriptionType, user string) Subscription {
+	return Subscription{Type: typ, User: &user}
+}
+
+func TestSubscriptionBudgetLimits(t *testing.T) {
+	budget := NewSubscriptionBudget()
+	budget.SetLimits(2, 3, 1)
+	ctx := context.Background()
+
+	held := []Subscription{userSub(SubscriptionUserFills, "0xAAA"), userSub(SubscriptionOrderUpdates, "0xaaa")}
+	if err := budget.acquire(ctx, 1, held, 2); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	if got := budget.Usage(); got.Connections != 1 || got.Subscriptions != 2 || got.Users != 1 || got.MaxSubscriptions != 3 {
+		t.Errorf("Usage() = %+v", got)
+	}
+
+	tests := []struct {
+		name string
+		subs []Subscription
+	}{
+		{"second user", []Subscription{userSub(SubscriptionUserFills, "0xbbb")}},
+		{"subscriptions", []Subscription{{Type: SubscriptionAllMids}, {Type: SubscriptionAllMids}}},
+	}
+	for _, tt := range tests {
+		if err := budget.acquire(ctx, 0, tt.subs, 1); !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("acquire(%s) error = %v, want ErrBudgetExceeded", tt.name, err)
+		}
+	}
+	// The same user can be subscribed again
+	if err := budget.acquire(ctx, 1, []Subscription{userSub(SubscriptionUserFundings, "0xaaa")}, 1); err != nil {
+		t.Errorf("acquire() for the same user error = %v", err)
+	}
+
+	budget.release(1, held[:1])
+	if got := budget.Usage(); got.Connections != 1 || got.Subscriptions != 2 || got.Users != 1 {
+		t.Errorf("Usage() after a partial release = %+v", got)
+	}
+	budget.release(1, append(held[1:], userSub(SubscriptionUserFundings, "0xaaa")))
+	if got := budget.Usage(); got.Connections != 0 || got.Subscriptions != 0 || got.Users != 0 {
+		t.Errorf("Usage() after release = %+v", got)
+	}
+
+	budget.SetConnectionLimit(1)
+	if err := budget.acquire(ctx, 0, []Subscription{{Type: SubscriptionAllMids}}, 2); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("acquire() beyond the connection limit error = %v, want ErrBudgetExceeded", err)
+	}
+}
+
+func TestSubscriptionBudgetQueue(t *testing.T) {
+	budget := NewSubscriptionBudget()
+	budget.SetLimits(1, 0, 0)
+	budget.SetPolicy(BudgetQueue)
+	ctx := context.Background()
+
+	if err := budget.acquire(ctx, 1, nil, 0); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	// A request that could never fit is not queued
+	if err := budget.acquire(ctx, 2, nil, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("acquire(2) error = %v, want ErrBudgetExceeded", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := budget.acquire(timeoutCtx, 1, nil, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("queued acquire() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- budget.acquire(ctx, 1, nil, 0) }()
+	select {
+	case err := <-acquired:
+		t.Fatalf("acquire() returned %v before the connection was released", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	budget.release(1, nil)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Errorf("queued acquire() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued acquire() not released")
+	}
+}
+
+func TestClientBudget(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		conn.ReadMessage()
+	})
+	budget := NewSubscriptionBudget()
+	budget.SetLimits(1, 0, 0)
+
+	first := newClient[AllMids](server.URL(), AllMidsSubscription())
+	first.SetBudget(budget)
+	defer first.Close()
+	if _, err := first.Read(); err != nil {
+		t.Fatalf("first Read() error = %v", err)
+	}
+
+	second := newClient[AllMids](server.URL(), AllMidsSubscription())
+	second.SetBudget(budget)
+	defer second.Close()
+	if _, err := second.Read(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("second Read() error = %v, want ErrBudgetExceeded", err)
+	}
+	if n := server.Connections(); n != 1 {
+		t.Errorf("server saw %d connections, want 1", n)
+	}
+
+	first.Close()
+	if got := budget.Usage(); got.Connections != 0 || got.Subscriptions != 0 {
+		t.Errorf("Usage() after Close = %+v", got)
+	}
+	if _, err := second.Read(); err != nil {
+		t.Errorf("second Read() after Close error = %v", err)
+	}
+}
+
+func TestManagerBudget(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		conn.ReadMessage()
+	})
+	budget := NewSubscriptionBudget()
+	budget.SetConnectionLimit(1)
+
+	m := NewManagerWithURL(server.URL())
+	m.SetBudget(budget)
+	btc, eth := "BTC", "ETH"
+	id, err := m.Subscribe(Subscription{Type: SubscriptionTrades, Coin: &btc}, func(Message) {})
+	if err != nil {
+		t.Fatalf("Subscribe(BTC) error = %v", err)
+	}
+	// Callbacks sharing a subscription are counted once
+	if _, err := m.Subscribe(Subscription{Type: SubscriptionTrades, Coin: &btc}, func(Message) {}); err != nil {
+		t.Fatalf("second Subscribe(BTC) error = %v", err)
+	}
+	if _, err := m.Subscribe(Subscription{Type: SubscriptionTrades, Coin: &eth}, func(Message) {}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Subscribe(ETH) error = %v, want ErrBudgetExceeded", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if got := budget.Usage(); got.Connections != 1 || got.Subscriptions != 1 {
+		t.Errorf("Usage() = %+v", got)
+	}
+
+	m.Unsubscribe(Subscription{Type: SubscriptionTrades, Coin: &btc}, id)
+	if got := budget.Usage(); got.Subscriptions != 1 {
+		t.Errorf("Usage() with a callback left = %+v", got)
+	}
+	m.Stop()
+	if got := budget.Usage(); got.Connections != 0 || got.Subscriptions != 0 {
+		t.Errorf("Usage() after Stop = %+v", got)
+	}
+}
@@ -0,0 +1,192 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// OverflowPolicy decides what a buffered client does when its queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stops reading from the connection until the consumer catches up.
+	// Nothing is lost, but the server may disconnect a consumer that stays behind. The
+	// pong timeout is paused meanwhile, as pongs cannot be read.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropOldest discards the oldest queued message to make room
+	OverflowDropOldest
+
+	// OverflowDropNewest discards the incoming message
+	OverflowDropNewest
+)
+
+// String returns the name of the policy
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// frameQueue is a bounded queue of raw frames filled by a background reader,
+// so the connection keeps being drained while the consumer is busy
+type frameQueue struct {
+	mu      sync.Mutex
+	frames  [][]byte
+	size    int
+	policy  OverflowPolicy
+	err     error
+	closed  bool
+	dropped *atomic.Uint64
+
+	// readable and writable are signalled when a frame is pushed or popped
+	readable chan struct{}
+	writable chan struct{}
+	// done is closed when fill returns
+	done chan struct{}
+}
+
+func newFrameQueue(size int, policy OverflowPolicy, dropped *atomic.Uint64) *frameQueue {
+	return &frameQueue{
+		size:     size,
+		policy:   policy,
+		dropped:  dropped,
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// fill reads frames from conn into the queue until the connection fails or the queue is closed.
+// A connection silent for longer than idle fails with ErrStaleConnection, one closed by
+// the heartbeat with ErrPongTimeout.
+func (q *frameQueue) fill(conn *websocket.Conn, idle time.Duration, hb *heartbeat, tap func([]byte)) {
+	defer close(q.done)
+	for {
+		if idle > 0 {
+			conn.SetReadDeadline(time.Now().Add(idle))
+		}
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if idle > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("%w (%v)", ErrStaleConnection, idle)
+			}
+			if hbErr := hb.err(); hbErr != nil {
+				err = hbErr
+			}
+			q.fail(err)
+			return
+		}
+		hb.metrics.record(frame)
+		if tap != nil {
+			tap(frame)
+		}
+		hb.watchdog.frame(frame)
+		if !q.push(frame, hb) {
+			return
+		}
+	}
+}
+
+// push adds a frame according to the overflow policy, returning false once the queue is closed.
+// While it waits for room, the pong timeout of hb, if not nil, is paused.
+func (q *frameQueue) push(frame []byte, hb *heartbeat) bool {
+	q.mu.Lock()
+	for len(q.frames) >= q.size && !q.closed {
+		switch q.policy {
+		case OverflowDropOldest:
+			q.frames[0] = nil
+			q.frames = q.frames[1:]
+			q.dropped.Add(1)
+		case OverflowDropNewest:
+			q.dropped.Add(1)
+			q.mu.Unlock()
+			return true
+		default:
+			q.mu.Unlock()
+			hb.stall()
+			<-q.writable
+			hb.unstall()
+			q.mu.Lock()
+		}
+	}
+	if q.closed {
+		q.mu.Unlock()
+		return false
+	}
+	q.frames = append(q.frames, frame)
+	q.mu.Unlock()
+
+	signal(q.readable)
+	return true
+}
+
+// pop returns the next frame, waiting until one is available, the reader failed or ctx is done
+func (q *frameQueue) pop(ctx context.Context) ([]byte, error) {
+	for {
+		q.mu.Lock()
+		if len(q.frames) > 0 {
+			frame := q.frames[0]
+			q.frames[0] = nil
+			q.frames = q.frames[1:]
+			q.mu.Unlock()
+			signal(q.writable)
+			return frame, nil
+		}
+		if q.err != nil {
+			err := q.err
+			q.mu.Unlock()
+			return nil, err
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.readable:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// fail records the reader's error once the queued frames are consumed
+func (q *frameQueue) fail(err error) {
+	q.mu.Lock()
+	q.err = err
+	q.mu.Unlock()
+	signal(q.readable)
+}
+
+// close releases a reader blocked on a full queue
+func (q *frameQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	if q.err == nil {
+		q.err = errors.New("connection closed")
+	}
+	q.mu.Unlock()
+	signal(q.writable)
+	signal(q.readable)
+}
+
+// signal wakes a waiter on ch without blocking
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
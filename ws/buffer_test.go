@@ -0,0 +1,70 @@
+package ws
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// readBuffered reads all messages of a burst of 10 through a client buffering 3
+func readBuffered(t *testing.T, policy OverflowPolicy) (received []int, dropped uint64) {
+	t.Helper()
+
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		for i := 1; i <= 10; i++ {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"n":"`+strconv.Itoa(i)+`"}}}`))
+		}
+		conn.ReadMessage()
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetBuffer(3, policy)
+	client.SetReadTimeout(200 * time.Millisecond)
+	defer client.Close()
+
+	read := func() int {
+		mids, err := client.Read()
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.Atoi(mids.Mids["n"])
+		return n
+	}
+
+	received = append(received, read())
+	// Let the background reader run ahead of the consumer
+	time.Sleep(100 * time.Millisecond)
+	for n := read(); n != 0; n = read() {
+		received = append(received, n)
+	}
+	return received, client.Dropped()
+}
+
+func TestClientBufferBlock(t *testing.T) {
+	received, dropped := readBuffered(t, OverflowBlock)
+	if len(received) != 10 || dropped != 0 {
+		t.Errorf("received = %v, dropped = %d", received, dropped)
+	}
+}
+
+func TestClientBufferDropOldest(t *testing.T) {
+	received, dropped := readBuffered(t, OverflowDropOldest)
+	if dropped == 0 || uint64(len(received))+dropped != 10 || received[len(received)-1] != 10 {
+		t.Errorf("received = %v, dropped = %d", received, dropped)
+	}
+	for i := 1; i < len(received); i++ {
+		if received[i] <= received[i-1] {
+			t.Errorf("received out of order: %v", received)
+		}
+	}
+}
+
+func TestClientBufferDropNewest(t *testing.T) {
+	received, dropped := readBuffered(t, OverflowDropNewest)
+	if dropped == 0 || uint64(len(received))+dropped != 10 || received[len(received)-1] == 10 {
+		t.Errorf("received = %v, dropped = %d", received, dropped)
+	}
+}
@@ -0,0 +1,238 @@
+package ws
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// CandleAggregator builds OHLCV candles of any interval from trades, including
+// sub-minute intervals the candle feed does not offer.
+//
+// Candles are aligned to wall-clock buckets (a 15s candle starts at :00, :15, ...)
+// and emitted once their bucket is over: when a later trade arrives or when Flush
+// is called with a later time. With gap filling, buckets without trades are
+// emitted as flat candles at the previous close with zero volume.
+//
+//	agg := ws.NewCandleAggregator(15 * time.Second)
+//	trades, errs := ws.NewTradesClient("BTC", "ETH").Stream(ctx)
+//	for candle := range agg.Stream(ctx, trades) {
+//	    // Process closed candle...
+//	}
+//
+// Not thread-safe, except for Stream which owns the aggregator while it runs.
+type CandleAggregator struct {
+	interval time.Duration
+	fillGaps bool
+	coins    map[string]*coinCandles
+}
+
+// coinCandles is the aggregation state of one coin
+type coinCandles struct {
+	// current is the open candle, nil if none
+	current *Candle
+	// lastStart is the start of the last emitted candle, 0 if none
+	lastStart int64
+	lastClose float64
+}
+
+// NewCandleAggregator creates an aggregator for candles of the given interval,
+// which must be at least a millisecond. Gap filling is enabled.
+func NewCandleAggregator(interval time.Duration) *CandleAggregator {
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	return &CandleAggregator{
+		interval: interval,
+		fillGaps: true,
+		coins:    make(map[string]*coinCandles),
+	}
+}
+
+// SetFillGaps sets whether buckets without trades are emitted as flat candles
+func (a *CandleAggregator) SetFillGaps(fillGaps bool) {
+	a.fillGaps = fillGaps
+}
+
+// Add applies trades and returns the candles they closed, in time order per coin.
+// Trades belonging to a bucket that was already emitted are ignored.
+func (a *CandleAggregator) Add(trades []WsTrade) []Candle {
+	var closed []Candle
+	for _, trade := range trades {
+		state := a.coins[trade.Coin]
+		if state == nil {
+			state = &coinCandles{}
+			a.coins[trade.Coin] = state
+		}
+
+		start := a.bucketStart(trade.Time)
+		if state.lastStart != 0 && start <= state.lastStart {
+			continue
+		}
+
+		if state.current != nil && start > state.current.T {
+			closed = append(closed, a.close(state)...)
+		}
+		if state.current == nil {
+			closed = append(closed, a.gaps(trade.Coin, state, start)...)
+			state.current = &Candle{
+				T:  start,
+				T2: start + a.interval.Milliseconds() - 1,
+				S:  trade.Coin,
+				I:  a.intervalString(),
+				O:  trade.Px,
+				H:  trade.Px,
+				L:  trade.Px,
+			}
+		}
+
+		c := state.current
+		c.H = max(c.H, trade.Px)
+		c.L = min(c.L, trade.Px)
+		c.C = trade.Px
+		c.V += trade.Sz
+		c.N++
+	}
+	return closed
+}
+
+// Flush returns the candles of all coins whose bucket ended at or before now,
+// including flat candles for buckets without trades if gap filling is enabled
+func (a *CandleAggregator) Flush(now time.Time) []Candle {
+	nowStart := a.bucketStart(now.UnixMilli())
+
+	coins := make([]string, 0, len(a.coins))
+	for coin := range a.coins {
+		coins = append(coins, coin)
+	}
+	sort.Strings(coins)
+
+	var closed []Candle
+	for _, coin := range coins {
+		state := a.coins[coin]
+		if state.current != nil && state.current.T < nowStart {
+			closed = append(closed, a.close(state)...)
+		}
+		if state.current == nil {
+			closed = append(closed, a.gaps(coin, state, nowStart)...)
+		}
+	}
+	return closed
+}
+
+// Current returns the open candle of coin, if any
+func (a *CandleAggregator) Current(coin string) (Candle, bool) {
+	if state := a.coins[coin]; state != nil && state.current != nil {
+		return *state.current, true
+	}
+	return Candle{}, false
+}
+
+// Stream aggregates trade batches, such as the output of Client.Stream, and sends
+// closed candles on the returned channel. Candles are also flushed on wall-clock
+// bucket boundaries, so quiet markets still produce candles on time. The channel
+// is closed when ctx is done or trades is closed.
+func (a *CandleAggregator) Stream(ctx context.Context, trades <-chan []WsTrade) <-chan Candle {
+	out := make(chan Candle)
+
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(a.untilNextBucket(time.Now()))
+		defer timer.Stop()
+
+		send := func(candles []Candle) bool {
+			for _, c := range candles {
+				select {
+				case out <- c:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case batch, ok := <-trades:
+				if !ok {
+					return
+				}
+				if !send(a.Add(batch)) {
+					return
+				}
+			case now := <-timer.C:
+				if !send(a.Flush(now)) {
+					return
+				}
+				timer.Reset(a.untilNextBucket(time.Now()))
+			}
+		}
+	}()
+
+	return out
+}
+
+// close emits the open candle of state
+func (a *CandleAggregator) close(state *coinCandles) []Candle {
+	c := *state.current
+	state.current = nil
+	state.lastStart = c.T
+	state.lastClose = c.C
+	return []Candle{c}
+}
+
+// gaps emits flat candles for the buckets between the last emitted candle and start
+func (a *CandleAggregator) gaps(coin string, state *coinCandles, start int64) []Candle {
+	if !a.fillGaps || state.lastStart == 0 {
+		return nil
+	}
+
+	step := a.interval.Milliseconds()
+	var candles []Candle
+	for t := state.lastStart + step; t < start; t += step {
+		candles = append(candles, Candle{
+			T:  t,
+			T2: t + step - 1,
+			S:  coin,
+			I:  a.intervalString(),
+			O:  state.lastClose,
+			H:  state.lastClose,
+			L:  state.lastClose,
+			C:  state.lastClose,
+		})
+		state.lastStart = t
+	}
+	return candles
+}
+
+// bucketStart returns the start (millis) of the bucket containing millis
+func (a *CandleAggregator) bucketStart(millis int64) int64 {
+	step := a.interval.Milliseconds()
+	return millis - millis%step
+}
+
+func (a *CandleAggregator) untilNextBucket(now time.Time) time.Duration {
+	next := a.bucketStart(now.UnixMilli()) + a.interval.Milliseconds()
+	return time.Until(time.UnixMilli(next))
+}
+
+// intervalString formats the interval like the API ("15s", "1m", "4h", "1d")
+func (a *CandleAggregator) intervalString() string {
+	d := a.interval
+	switch {
+	case d%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	default:
+		return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+}
@@ -0,0 +1,97 @@
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCandleAggregator(t *testing.T) {
+	agg := NewCandleAggregator(15 * time.Second)
+	base := int64(1699999995000) // aligned to 15s
+
+	closed := agg.Add([]WsTrade{
+		{Coin: "BTC", Px: 100, Sz: 1, Time: base + 1000},
+		{Coin: "BTC", Px: 105, Sz: 2, Time: base + 2000},
+		{Coin: "BTC", Px: 95, Sz: 1, Time: base + 3000},
+		{Coin: "BTC", Px: 101, Sz: 1, Time: base + 14999},
+	})
+	if len(closed) != 0 {
+		t.Fatalf("closed = %+v, want none", closed)
+	}
+
+	current, ok := agg.Current("BTC")
+	if !ok || current.O != 100 || current.H != 105 || current.L != 95 || current.C != 101 || current.V != 5 || current.N != 4 {
+		t.Errorf("current = %+v", current)
+	}
+	if current.T != base || current.T2 != base+14999 || current.I != "15s" || current.S != "BTC" {
+		t.Errorf("current bucket = %+v", current)
+	}
+
+	// A trade two buckets later closes the candle and fills the empty bucket
+	closed = agg.Add([]WsTrade{{Coin: "BTC", Px: 110, Sz: 1, Time: base + 31000}})
+	if len(closed) != 2 {
+		t.Fatalf("closed = %+v, want 2 candles", closed)
+	}
+	if closed[0].T != base || closed[0].C != 101 {
+		t.Errorf("closed[0] = %+v", closed[0])
+	}
+	if gap := closed[1]; gap.T != base+15000 || gap.O != 101 || gap.H != 101 || gap.L != 101 || gap.C != 101 || gap.V != 0 || gap.N != 0 {
+		t.Errorf("gap candle = %+v", gap)
+	}
+
+	// Late trades for emitted buckets are ignored
+	if closed := agg.Add([]WsTrade{{Coin: "BTC", Px: 1, Sz: 1, Time: base + 5000}}); len(closed) != 0 {
+		t.Errorf("late trade closed %+v", closed)
+	}
+
+	// Flushing on the wall clock closes the open candle and fills up to now
+	closed = agg.Flush(time.UnixMilli(base + 61000))
+	if len(closed) != 2 || closed[0].T != base+30000 || closed[0].O != 110 || closed[1].T != base+45000 || closed[1].C != 110 {
+		t.Errorf("flushed = %+v", closed)
+	}
+	if _, ok := agg.Current("BTC"); ok {
+		t.Error("Current() after flush should be empty")
+	}
+	if closed := agg.Flush(time.UnixMilli(base + 61000)); len(closed) != 0 {
+		t.Errorf("second flush = %+v", closed)
+	}
+}
+
+func TestCandleAggregatorWithoutGapFilling(t *testing.T) {
+	agg := NewCandleAggregator(time.Minute)
+	agg.SetFillGaps(false)
+	base := int64(1700000040000) // aligned to 1m
+
+	agg.Add([]WsTrade{{Coin: "ETH", Px: 10, Sz: 1, Time: base}})
+	closed := agg.Add([]WsTrade{{Coin: "ETH", Px: 11, Sz: 1, Time: base + 5*60000}})
+	if len(closed) != 1 || closed[0].I != "1m" {
+		t.Errorf("closed = %+v, want 1 candle", closed)
+	}
+}
+
+func TestCandleAggregatorStream(t *testing.T) {
+	agg := NewCandleAggregator(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trades := make(chan []WsTrade, 1)
+	candles := agg.Stream(ctx, trades)
+
+	now := time.Now().UnixMilli()
+	trades <- []WsTrade{{Coin: "BTC", Px: 100, Sz: 1, Time: now}}
+
+	// The candle is closed by the wall-clock flush without further trades
+	select {
+	case c := <-candles:
+		if c.S != "BTC" || c.O != 100 || c.N != 1 {
+			t.Errorf("candle = %+v", c)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for candle")
+	}
+
+	cancel()
+	for range candles {
+	}
+}
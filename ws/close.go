@@ -0,0 +1,53 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultCloseTimeout is how long Close waits for the server to answer the close frame
+const DefaultCloseTimeout = time.Second
+
+// closeGracefully performs the closing handshake on conn: it sends a close frame and
+// waits up to timeout for the server's close frame, then closes conn. reader is closed
+// when the goroutine reading conn has exited, nil if there is none; conn is then read
+// until the server's close frame. A zero timeout closes conn without the handshake.
+func closeGracefully(conn *websocket.Conn, timeout time.Duration, reader <-chan struct{}) error {
+	if timeout <= 0 {
+		return conn.Close()
+	}
+
+	deadline := time.Now().Add(timeout)
+	if sendClose(conn, deadline) == nil {
+		drain := true
+		if reader != nil {
+			// The reader exits once it receives the server's close frame
+			timer := time.NewTimer(timeout)
+			select {
+			case <-reader:
+			case <-timer.C:
+				drain = false
+			}
+			timer.Stop()
+		}
+		if drain {
+			// Discard the frames preceding the close frame. Reads fail at once after
+			// an earlier read error, so a broken connection does not wait.
+			conn.SetReadDeadline(deadline)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					break
+				}
+			}
+		}
+	}
+	return conn.Close()
+}
+
+// sendClose sends a normal closure frame. Unlike other writes, it is safe to call
+// concurrently with them.
+func sendClose(conn *websocket.Conn, deadline time.Time) error {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	return conn.WriteControl(websocket.CloseMessage, msg, deadline)
+}
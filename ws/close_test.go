@@ -0,0 +1,110 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// closingServer reports the close code of every connection the client closes
+func closingServer(t *testing.T, codes chan<- int) *testServer {
+	return newTestServer(t, func(conn *websocket.Conn, index int) {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				// The default close handler answers the client's close frame
+				code := -1
+				var closeErr *websocket.CloseError
+				if errors.As(err, &closeErr) {
+					code = closeErr.Code
+				}
+				codes <- code
+				return
+			}
+		}
+	})
+}
+
+func TestGracefulClose(t *testing.T) {
+	tests := []struct {
+		name  string
+		start func(t *testing.T, url string) (closeFn func() error)
+	}{
+		{"client", func(t *testing.T, url string) func() error {
+			client := newClient[AllMids](url, AllMidsSubscription())
+			if _, err := client.Read(); err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			return client.Close
+		}},
+		{"buffered client", func(t *testing.T, url string) func() error {
+			client := newClient[AllMids](url, AllMidsSubscription())
+			client.SetBuffer(10, OverflowBlock)
+			if _, err := client.Read(); err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			return client.Close
+		}},
+		{"manager", func(t *testing.T, url string) func() error {
+			m := NewManagerWithURL(url)
+			if err := m.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			return m.Stop
+		}},
+		{"post client", func(t *testing.T, url string) func() error {
+			client := NewPostOnlyClient()
+			client.SetURL(url)
+			if err := client.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			return client.Close
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := make(chan int, 1)
+			server := closingServer(t, codes)
+			closeFn := tt.start(t, server.URL())
+
+			start := time.Now()
+			closeFn()
+			// The server answered at once, Close did not wait for the timeout
+			if elapsed := time.Since(start); elapsed >= DefaultCloseTimeout {
+				t.Errorf("Close() took %v", elapsed)
+			}
+			select {
+			case code := <-codes:
+				if code != websocket.CloseNormalClosure {
+					t.Errorf("server saw close code %d, want %d", code, websocket.CloseNormalClosure)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("server connection not closed")
+			}
+		})
+	}
+}
+
+func TestGracefulCloseTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		// Never answer the close frame
+		<-release
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetCloseTimeout(100 * time.Millisecond)
+	if _, err := client.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	start := time.Now()
+	client.Close()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Close() took %v, want about the close timeout", elapsed)
+	}
+}
@@ -0,0 +1,132 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// countingConn counts the bytes received from the network
+type countingConn struct {
+	net.Conn
+	read *atomic.Int64
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+// countingDialer returns a dialer counting the bytes it receives into read
+func countingDialer(read *atomic.Int64) *websocket.Dialer {
+	dialer := NewDialer()
+	dialer.NetDialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return countingConn{Conn: conn, read: read}, nil
+	}
+	return dialer
+}
+
+// testBookFrame returns an l2Book frame with levels price levels per side
+func testBookFrame(levels int) []byte {
+	book := WsBook{Coin: "BTC", Time: 1700000000000}
+	for i := 0; i < levels; i++ {
+		book.Levels[0] = append(book.Levels[0], WsLevel{Px: 50000 - float64(i), Sz: 0.1 + float64(i%7)/10, N: 1 + i%3})
+		book.Levels[1] = append(book.Levels[1], WsLevel{Px: 50001 + float64(i), Sz: 0.2 + float64(i%5)/10, N: 1 + i%4})
+	}
+	data, _ := json.Marshal(book)
+	return []byte(`{"channel":"l2Book","data":` + string(data) + `}`)
+}
+
+// bookServer streams frame to each connection until it is closed
+func bookServer(tb testing.TB, frame []byte) *testServer {
+	return newTestServer(tb, func(conn *websocket.Conn, index int) {
+		readSubscription(tb, conn)
+		// Answer the client's close frame
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+		for {
+			if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func TestClientCompression(t *testing.T) {
+	frame := testBookFrame(20)
+	server := bookServer(t, frame)
+
+	for _, compression := range []bool{false, true} {
+		var read atomic.Int64
+		client := newClient[WsBook](server.URL(), L2BookSubscription("BTC"))
+		client.SetDialer(countingDialer(&read))
+		client.SetCompression(compression)
+
+		const messages = 50
+		for i := 0; i < messages; i++ {
+			book, err := client.Read()
+			if err != nil {
+				t.Fatalf("compression=%v: Read() error = %v", compression, err)
+			}
+			if len(book.Levels[0]) != 20 {
+				t.Fatalf("compression=%v: book = %+v", compression, book)
+			}
+		}
+		// Close drains the connection, count before
+		received := read.Load()
+		client.Close()
+
+		// The frames are highly repetitive, so compressed traffic is much smaller
+		payload := int64(messages * len(frame))
+		if compressed := received < payload/2; compressed != compression {
+			t.Errorf("compression=%v: received %d bytes for %d bytes of frames", compression, received, payload)
+		}
+	}
+}
+
+// BenchmarkClientCompression reads full-depth books with and without compression.
+// wire-B/op is the network traffic per message; ns/op includes inflating frames.
+func BenchmarkClientCompression(b *testing.B) {
+	for _, levels := range []int{20, 100} {
+		frame := testBookFrame(levels)
+		server := bookServer(b, frame)
+		for _, compression := range []bool{false, true} {
+			b.Run(fmt.Sprintf("levels=%d/compression=%v", levels, compression), func(b *testing.B) {
+				var read atomic.Int64
+				client := newClient[WsBook](server.URL(), L2BookSubscription("BTC"))
+				client.SetDialer(countingDialer(&read))
+				client.SetCompression(compression)
+				defer client.Close()
+				if _, err := client.Read(); err != nil {
+					b.Fatalf("Read() error = %v", err)
+				}
+
+				read.Store(0)
+				b.SetBytes(int64(len(frame)))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := client.Read(); err != nil {
+						b.Fatalf("Read() error = %v", err)
+					}
+				}
+				b.StopTimer()
+				b.ReportMetric(float64(read.Load())/float64(b.N), "wire-B/op")
+			})
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultReadTimeout is how long a connection may stay silent before it is considered dead.
+// The server answers every ping, so a healthy connection is never silent for longer
+// than the ping interval.
+const DefaultReadTimeout = 90 * time.Second
+
+// ErrStaleConnection is returned when nothing was received within the read timeout
+var ErrStaleConnection = errors.New("no message received within read timeout")
+
+// readDeadline manages the read deadline of one connection during a read.
+// The deadline is the earliest of the idle timeout and ctx's deadline, and it is
+// refreshed for every message. Once ctx is done the deadline stays in the past,
+// so a refresh cannot undo the interruption.
+type readDeadline struct {
+	mu          sync.Mutex
+	conn        *websocket.Conn
+	idle        time.Duration
+	deadline    time.Time
+	hasDeadline bool
+	interrupted bool
+	stop        func() bool
+}
+
+func newReadDeadline(ctx context.Context, conn *websocket.Conn, idle time.Duration) *readDeadline {
+	d := &readDeadline{conn: conn, idle: idle}
+	d.deadline, d.hasDeadline = ctx.Deadline()
+	if ctx.Done() != nil {
+		d.stop = context.AfterFunc(ctx, d.interrupt)
+	}
+	return d
+}
+
+// refresh sets the deadline for the next message
+func (d *readDeadline) refresh() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.interrupted {
+		return
+	}
+
+	var t time.Time
+	if d.idle > 0 {
+		t = time.Now().Add(d.idle)
+	}
+	if d.hasDeadline && (t.IsZero() || d.deadline.Before(t)) {
+		t = d.deadline
+	}
+	d.conn.SetReadDeadline(t)
+}
+
+// interrupt unblocks a pending read
+func (d *readDeadline) interrupt() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.interrupted = true
+	d.conn.SetReadDeadline(time.Now())
+}
+
+// release stops watching ctx and clears the deadline
+func (d *readDeadline) release() {
+	if d.stop != nil {
+		d.stop()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.interrupted {
+		d.conn.SetReadDeadline(time.Time{})
+	}
+}
+
+// classify translates a read error: ctx's error if ctx is done or its deadline passed,
+// ErrStaleConnection if the idle timeout expired, otherwise the error itself.
+// connLost reports whether the connection itself failed.
+func (d *readDeadline) classify(ctx context.Context, readErr error) (err error, connLost bool) {
+	if ctx.Err() != nil {
+		return ctx.Err(), false
+	}
+	// The connection deadline can fire just before ctx's own timer
+	if d.hasDeadline && !time.Now().Before(d.deadline) {
+		return context.DeadlineExceeded, false
+	}
+	var netErr net.Error
+	if d.idle > 0 && errors.As(readErr, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w (%v)", ErrStaleConnection, d.idle), true
+	}
+	return readErr, true
+}
@@ -0,0 +1,77 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientDetectsSilentConnection(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		if index == 0 {
+			// Go silent without closing the connection
+			conn.ReadMessage()
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		conn.ReadMessage()
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetReadTimeout(50 * time.Millisecond)
+	client.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 1, InitialBackoff: time.Millisecond})
+	var cause error
+	client.OnReconnect(func(e ReconnectEvent) { cause = e.Cause })
+	defer client.Close()
+
+	if !client.LastMessageTime().IsZero() {
+		t.Error("LastMessageTime() before any message is not zero")
+	}
+	if _, err := client.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !errors.Is(cause, ErrStaleConnection) {
+		t.Errorf("reconnect cause = %v, want ErrStaleConnection", cause)
+	}
+	if time.Since(client.LastMessageTime()) > time.Second {
+		t.Errorf("LastMessageTime() = %v", client.LastMessageTime())
+	}
+}
+
+func TestClientStaleConnectionWithoutReconnect(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.ReadMessage()
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetReadTimeout(50 * time.Millisecond)
+	if _, err := client.Read(); !errors.Is(err, ErrStaleConnection) {
+		t.Errorf("Read() error = %v, want ErrStaleConnection", err)
+	}
+}
+
+func TestManagerDetectsSilentConnection(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		conn.ReadMessage()
+	})
+
+	m := newManager(server.URL())
+	m.SetReadTimeout(50 * time.Millisecond)
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer m.Stop()
+
+	select {
+	case <-m.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("manager did not stop on a silent connection")
+	}
+	if !errors.Is(m.Err(), ErrStaleConnection) {
+		t.Errorf("Err() = %v, want ErrStaleConnection", m.Err())
+	}
+}
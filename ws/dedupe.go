@@ -0,0 +1,140 @@
+package ws
+
+import "math"
+
+// DefaultDedupeCapacity is the number of events a deduper remembers by default.
+// It comfortably exceeds the size of the userFills and userFundings snapshots.
+const DefaultDedupeCapacity = 10000
+
+// FillDeduper drops fills that were already seen, so that each fill is emitted exactly
+// once although the userFills feed resends a snapshot of recent fills after each
+// (re)connection. Fills are identified by trade id and order id, which also tells
+// apart both sides of a self-trade.
+//
+//	client := ws.NewUserFillsClient(user)
+//	client.SetReconnectPolicy(ws.DefaultReconnectPolicy())
+//	dedupe := ws.NewFillDeduper(0)
+//	for {
+//	    msg, err := client.Read()
+//	    if err != nil {
+//	        return err
+//	    }
+//	    for _, fill := range dedupe.Filter(msg) {
+//	        // Process each fill once...
+//	    }
+//	}
+//
+// Not safe for concurrent use.
+type FillDeduper struct {
+	seen *dedupeSet[fillKey]
+}
+
+type fillKey struct {
+	tid int64
+	oid int64
+}
+
+// NewFillDeduper creates a FillDeduper remembering up to capacity fills,
+// or DefaultDedupeCapacity if capacity is not positive
+func NewFillDeduper(capacity int) *FillDeduper {
+	return &FillDeduper{seen: newDedupeSet[fillKey](capacity)}
+}
+
+// Filter returns the fills of msg that were not seen before, in their original order
+func (d *FillDeduper) Filter(msg WsUserFills) []WsFill {
+	return d.FilterFills(msg.Fills)
+}
+
+// FilterFills returns the fills that were not seen before, in their original order
+func (d *FillDeduper) FilterFills(fills []WsFill) []WsFill {
+	var fresh []WsFill
+	for _, fill := range fills {
+		if d.seen.add(fillKey{tid: fill.Tid, oid: fill.Oid}, fill.Time) {
+			fresh = append(fresh, fill)
+		}
+	}
+	return fresh
+}
+
+// FundingDeduper drops funding payments that were already seen, like FillDeduper for
+// the userFundings feed. Payments are identified by time and coin.
+//
+// Not safe for concurrent use.
+type FundingDeduper struct {
+	seen *dedupeSet[fundingKey]
+}
+
+type fundingKey struct {
+	time int64
+	coin string
+}
+
+// NewFundingDeduper creates a FundingDeduper remembering up to capacity payments,
+// or DefaultDedupeCapacity if capacity is not positive
+func NewFundingDeduper(capacity int) *FundingDeduper {
+	return &FundingDeduper{seen: newDedupeSet[fundingKey](capacity)}
+}
+
+// Filter returns the payments of msg that were not seen before, in their original order
+func (d *FundingDeduper) Filter(msg WsUserFundings) []WsUserFunding {
+	var fresh []WsUserFunding
+	for _, funding := range msg.Fundings {
+		if d.seen.add(fundingKey{time: funding.Time, coin: funding.Coin}, funding.Time) {
+			fresh = append(fresh, funding)
+		}
+	}
+	return fresh
+}
+
+// dedupeSet remembers the keys of the last capacity events. Once events are forgotten,
+// events not newer than the newest forgotten one are considered seen, so that a snapshot
+// reaching further back than the capacity is not emitted again.
+type dedupeSet[K comparable] struct {
+	capacity int
+	seen     map[K]struct{}
+	// order is a ring of the remembered keys in insertion order
+	order []dedupeEntry[K]
+	next  int
+	// watermark is the newest time of the forgotten events
+	watermark int64
+}
+
+type dedupeEntry[K comparable] struct {
+	key  K
+	time int64
+}
+
+func newDedupeSet[K comparable](capacity int) *dedupeSet[K] {
+	if capacity <= 0 {
+		capacity = DefaultDedupeCapacity
+	}
+	return &dedupeSet[K]{
+		capacity: capacity,
+		seen:     make(map[K]struct{}, capacity),
+		// Nothing is forgotten yet
+		watermark: math.MinInt64,
+	}
+}
+
+// add records key, returning false if it was already seen
+func (s *dedupeSet[K]) add(key K, time int64) bool {
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	if time <= s.watermark {
+		return false
+	}
+
+	entry := dedupeEntry[K]{key: key, time: time}
+	if len(s.order) < s.capacity {
+		s.order = append(s.order, entry)
+	} else {
+		oldest := s.order[s.next]
+		delete(s.seen, oldest.key)
+		s.watermark = max(s.watermark, oldest.time)
+		s.order[s.next] = entry
+		s.next = (s.next + 1) % s.capacity
+	}
+	s.seen[key] = struct{}{}
+	return true
+}
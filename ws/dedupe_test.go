@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestFillDeduperAcrossReconnect(t *testing.T) {
+	fill := func(tid int64) string {
+		return fmt.Sprintf(`{"coin":"BTC","px":"1","sz":"1","side":"B","time":%d,"oid":7,"tid":%d}`, 1000+tid, tid)
+	}
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		if index == 0 {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"userFills","data":{"isSnapshot":true,"user":"0x1","fills":[`+fill(1)+`,`+fill(2)+`]}}`))
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"userFills","data":{"user":"0x1","fills":[`+fill(3)+`]}}`))
+			return
+		}
+		// The snapshot after reconnecting overlaps what was already streamed
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"userFills","data":{"isSnapshot":true,"user":"0x1","fills":[`+fill(1)+`,`+fill(2)+`,`+fill(3)+`,`+fill(4)+`]}}`))
+		conn.ReadMessage()
+	})
+
+	client := newClient[WsUserFills](server.URL(), UserFillsSubscription("0x1"))
+	client.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond})
+	defer client.Close()
+
+	dedupe := NewFillDeduper(0)
+	var tids []int64
+	for len(tids) < 4 {
+		msg, err := client.Read()
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		for _, f := range dedupe.Filter(msg) {
+			tids = append(tids, f.Tid)
+		}
+	}
+	if fmt.Sprint(tids) != "[1 2 3 4]" {
+		t.Errorf("fills = %v, want each once", tids)
+	}
+}
+
+func TestFillDeduperSelfTrade(t *testing.T) {
+	dedupe := NewFillDeduper(0)
+	fills := []WsFill{{Tid: 1, Oid: 10}, {Tid: 1, Oid: 11}, {Tid: 1, Oid: 10}}
+	if got := dedupe.FilterFills(fills); len(got) != 2 {
+		t.Errorf("FilterFills() = %+v, want both sides of the self-trade once", got)
+	}
+}
+
+func TestDedupeSetForgetsOldest(t *testing.T) {
+	set := newDedupeSet[int](2)
+	for i, want := range []bool{true, true, true, false} {
+		key := []int{1, 2, 3, 3}[i]
+		if got := set.add(key, int64(key)); got != want {
+			t.Errorf("add(%d) = %v, want %v", key, got, want)
+		}
+	}
+	// 1 was forgotten, but is older than the watermark
+	if set.add(1, 1) {
+		t.Error("add() re-emitted a forgotten event older than the watermark")
+	}
+	if !set.add(4, 4) {
+		t.Error("add() dropped a new event")
+	}
+}
+
+func TestFundingDeduper(t *testing.T) {
+	dedupe := NewFundingDeduper(0)
+	snapshot := WsUserFundings{Fundings: []WsUserFunding{{Time: 1, Coin: "BTC"}, {Time: 1, Coin: "ETH"}}}
+	if got := dedupe.Filter(snapshot); len(got) != 2 {
+		t.Errorf("Filter(snapshot) = %+v", got)
+	}
+	snapshot.Fundings = append(snapshot.Fundings, WsUserFunding{Time: 2, Coin: "BTC"})
+	if got := dedupe.Filter(snapshot); len(got) != 1 || got[0].Time != 2 {
+		t.Errorf("Filter(resent snapshot) = %+v, want only the new payment", got)
+	}
+}
@@ -0,0 +1,145 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// CoinMessage is a message of a multi-coin feed tagged with its coin
+type CoinMessage[T any] struct {
+	Coin string
+	Data T
+}
+
+// CoinOf returns the coin a feed message belongs to, for the feeds that can be
+// subscribed for several coins at once (trades, l2Book, bbo, candle, activeAssetCtx)
+func CoinOf(data any) (string, bool) {
+	switch v := data.(type) {
+	case WsBook:
+		return v.Coin, true
+	case WsBbo:
+		return v.Coin, true
+	case []WsTrade:
+		if len(v) > 0 {
+			return v[0].Coin, true
+		}
+	case []Candle:
+		if len(v) > 0 {
+			return v[0].S, true
+		}
+	case Candle:
+		return v.S, true
+	case WsAssetCtx:
+		return v.Coin(), v.Perp != nil || v.Spot != nil
+	case WsActiveAssetCtx:
+		return v.Coin, true
+	case WsActiveSpotAssetCtx:
+		return v.Coin, true
+	case WsActiveAssetData:
+		return v.Coin, true
+	}
+	return "", false
+}
+
+// StreamByCoin is like Stream but tags each message with its coin, so consumers of a
+// multi-coin client can tell the markets apart:
+//
+//	books, errs := ws.NewL2BookClient("BTC", "ETH").StreamByCoin(ctx)
+//	for msg := range books {
+//	    fmt.Println(msg.Coin, msg.Data.Levels)
+//	}
+//
+// Messages whose coin cannot be determined end the stream with an error.
+func (c *Client[T]) StreamByCoin(ctx context.Context) (<-chan CoinMessage[T], <-chan error) {
+	out := make(chan CoinMessage[T])
+	errs := make(chan error, 1)
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	data, streamErrs := c.Stream(streamCtx)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		defer cancel()
+
+		for d := range data {
+			coin, ok := CoinOf(d)
+			if !ok {
+				// Stop the underlying stream before reporting
+				cancel()
+				for range data {
+				}
+				<-streamErrs
+				errs <- fmt.Errorf("cannot determine coin of %T message", d)
+				return
+			}
+			select {
+			case out <- CoinMessage[T]{Coin: coin, Data: d}:
+			case <-ctx.Done():
+			}
+		}
+		if err, ok := <-streamErrs; ok {
+			errs <- err
+		}
+	}()
+
+	return out, errs
+}
+
+// Demux is like Stream but delivers the messages of each subscribed coin on its own
+// channel, buffered by buffer messages:
+//
+//	coins, errs := ws.NewTradesClient("BTC", "ETH").Demux(ctx, 100)
+//	go process(coins["BTC"])
+//	go process(coins["ETH"])
+//
+// A full channel blocks delivery to all coins, so size buffer for the slowest consumer.
+// Messages for coins that were not subscribed are dropped. All channels are closed
+// when the stream ends, after which the error channel receives the cause.
+func (c *Client[T]) Demux(ctx context.Context, buffer int) (map[string]<-chan T, <-chan error) {
+	channels := make(map[string]chan T)
+	result := make(map[string]<-chan T)
+	for _, coin := range c.subscribedCoins() {
+		ch := make(chan T, buffer)
+		channels[coin] = ch
+		result[coin] = ch
+	}
+
+	errs := make(chan error, 1)
+	tagged, taggedErrs := c.StreamByCoin(ctx)
+	go func() {
+		defer close(errs)
+		defer func() {
+			for _, ch := range channels {
+				close(ch)
+			}
+		}()
+
+		for msg := range tagged {
+			ch, ok := channels[msg.Coin]
+			if !ok {
+				continue
+			}
+			select {
+			case ch <- msg.Data:
+			case <-ctx.Done():
+			}
+		}
+		if err, ok := <-taggedErrs; ok {
+			errs <- err
+		}
+	}()
+
+	return result, errs
+}
+
+// subscribedCoins returns the coins of the subscription
+func (c *Client[T]) subscribedCoins() []string {
+	var coins []string
+	for _, sub := range c.subscriptions {
+		if sub.Coin != nil && !slices.Contains(coins, *sub.Coin) {
+			coins = append(coins, *sub.Coin)
+		}
+	}
+	return coins
+}
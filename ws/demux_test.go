@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func newTwoCoinBookServer(t *testing.T) *testServer {
+	return newTestServer(t, func(conn *websocket.Conn, index int) {
+		for i := 0; i < 2; i++ {
+			readSubscription(t, conn)
+		}
+		for _, coin := range []string{"BTC", "ETH", "SOL", "ETH"} {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"l2Book","data":{"coin":"`+coin+`","levels":[[],[]],"time":1}}`))
+		}
+		conn.ReadMessage()
+	})
+}
+
+func TestClientStreamByCoin(t *testing.T) {
+	server := newTwoCoinBookServer(t)
+	client := NewL2BookClient("BTC", "ETH")
+	client.SetURL(server.URL())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	books, _ := client.StreamByCoin(ctx)
+
+	for _, want := range []string{"BTC", "ETH", "SOL"} {
+		select {
+		case msg := <-books:
+			if msg.Coin != want || msg.Data.Coin != want {
+				t.Errorf("message coin = %s, want %s", msg.Coin, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for book")
+		}
+	}
+}
+
+func TestClientDemux(t *testing.T) {
+	server := newTwoCoinBookServer(t)
+	client := NewL2BookClient("BTC", "ETH")
+	client.SetURL(server.URL())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	coins, errs := client.Demux(ctx, 4)
+	if len(coins) != 2 {
+		t.Fatalf("Demux() channels = %d, want 2", len(coins))
+	}
+
+	for coin, want := range map[string]int{"BTC": 1, "ETH": 2} {
+		for i := 0; i < want; i++ {
+			select {
+			case book := <-coins[coin]:
+				if book.Coin != coin {
+					t.Errorf("%s channel got %s", coin, book.Coin)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timed out waiting for %s book", coin)
+			}
+		}
+	}
+
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("Demux() error = %v, want context.Canceled", err)
+	}
+	if _, ok := <-coins["BTC"]; ok {
+		t.Error("BTC channel still open")
+	}
+}
+
+func TestCoinOf(t *testing.T) {
+	if coin, ok := CoinOf([]WsTrade{{Coin: "BTC"}}); !ok || coin != "BTC" {
+		t.Errorf("CoinOf(trades) = %s, %v", coin, ok)
+	}
+	if _, ok := CoinOf([]WsTrade{}); ok {
+		t.Error("CoinOf(empty trades) should fail")
+	}
+	if _, ok := CoinOf(AllMids{}); ok {
+		t.Error("CoinOf(AllMids) should fail")
+	}
+}
@@ -0,0 +1,56 @@
+package ws
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultHandshakeTimeout is the handshake timeout of the default dialer
+const DefaultHandshakeTimeout = 10 * time.Second
+
+// NewDialer returns the dialer used when none is set: a 10s handshake timeout and
+// the proxy from the HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment variables.
+// Use it as a starting point for custom dialers:
+//
+//	dialer := ws.NewDialer()
+//	dialer.Proxy = http.ProxyURL(proxyURL)
+//	dialer.TLSClientConfig = &tls.Config{RootCAs: pool}
+//	client.SetDialer(dialer)
+func NewDialer() *websocket.Dialer {
+	return &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: DefaultHandshakeTimeout,
+	}
+}
+
+// dialOptions are the connection settings shared by all clients
+type dialOptions struct {
+	// dialer is the dialer to connect with, the default dialer if nil
+	dialer *websocket.Dialer
+	header http.Header
+	// compression enables permessage-deflate
+	compression bool
+	// handshakeTimeout overrides the handshake timeout of dialer if positive
+	handshakeTimeout time.Duration
+}
+
+// dial connects to url, applying opts to a copy of the dialer
+func dial(url string, opts dialOptions) (*websocket.Conn, error) {
+	dialer := opts.dialer
+	if dialer == nil {
+		dialer = NewDialer()
+	}
+	if (opts.compression && !dialer.EnableCompression) ||
+		(opts.handshakeTimeout > 0 && opts.handshakeTimeout != dialer.HandshakeTimeout) {
+		d := *dialer
+		d.EnableCompression = d.EnableCompression || opts.compression
+		if opts.handshakeTimeout > 0 {
+			d.HandshakeTimeout = opts.handshakeTimeout
+		}
+		dialer = &d
+	}
+	conn, _, err := dialer.Dial(url, opts.header)
+	return conn, err
+}
@@ -0,0 +1,53 @@
+package ws
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientUsesDialerAndHeader(t *testing.T) {
+	headers := make(chan string, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get("X-Test")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{}}}`))
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	var dials atomic.Int32
+	dialer := NewDialer()
+	dialer.NetDialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+
+	client := NewAllMidsClient()
+	client.SetURL("ws" + strings.TrimPrefix(server.URL, "http"))
+	client.SetDialer(dialer)
+	client.SetHeader(http.Header{"X-Test": []string{"yes"}})
+	defer client.Close()
+
+	if _, err := client.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if dials.Load() != 1 {
+		t.Errorf("custom dialer used %d times, want 1", dials.Load())
+	}
+	if got := <-headers; got != "yes" {
+		t.Errorf("X-Test header = %q, want yes", got)
+	}
+}
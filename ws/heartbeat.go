@@ -0,0 +1,143 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultPingInterval is how often clients ping the server by default. The server
+// closes connections that sent nothing for 60 seconds.
+const DefaultPingInterval = 40 * time.Second
+
+// ErrPongTimeout is returned when a ping was not answered within the pong timeout
+var ErrPongTimeout = errors.New("ping not answered within pong timeout")
+
+// heartbeat pings the server over one connection and, with a pong timeout, closes
+// the connection when a ping stays unanswered
+type heartbeat struct {
+	interval    time.Duration
+	pongTimeout time.Duration
+	metrics     *connMetrics
+
+	// continuous is set when the connection is read in the background, so an
+	// unanswered ping cannot be caused by a consumer that is busy elsewhere
+	continuous bool
+	// blockedSince is the UnixNano time the reader started waiting for a frame, 0 when
+	// it is not waiting
+	blockedSince atomic.Int64
+	// readTime is the total time the reader waited for frames, in nanoseconds
+	readTime atomic.Int64
+	// stalled is set while the background reader waits for room in a full queue
+	// (OverflowBlock), so pongs behind it cannot be read yet
+	stalled atomic.Bool
+
+	expired atomic.Bool
+	// watchdog closes the connection when a subscription goes quiet, nil if none
+	watchdog *watchdog
+}
+
+// run sends a ping with ping every interval until ctx is done or the connection fails
+func (h *heartbeat) run(ctx context.Context, conn *websocket.Conn, ping func() error) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	// check fires when the pending ping should have been answered
+	var timer *time.Timer
+	var check <-chan time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.metrics.pinged()
+			if err := ping(); err != nil {
+				// Failed to send ping - connection likely broken
+				return
+			}
+			if h.pongTimeout > 0 && check == nil {
+				timer = time.NewTimer(h.pongTimeout)
+				check = timer.C
+			}
+		case <-check:
+			sent := h.metrics.pingSent.Load()
+			if sent == 0 {
+				// Answered
+				check = nil
+				continue
+			}
+			wait := h.pongTimeout - time.Since(time.Unix(0, sent))
+			if wait <= 0 && (h.stalled.Load() || !h.continuous && h.blockedSince.Load() == 0) {
+				// The pong may be waiting to be read, check again once the consumer reads
+				// or the queue has room
+				wait = h.pongTimeout
+			}
+			if wait > 0 {
+				timer.Reset(wait)
+				continue
+			}
+			h.expired.Store(true)
+			conn.Close()
+			return
+		}
+	}
+}
+
+// startRead and endRead surround a blocking read of the consumer
+func (h *heartbeat) startRead() {
+	h.blockedSince.Store(time.Now().UnixNano())
+}
+
+func (h *heartbeat) endRead() {
+	if since := h.blockedSince.Swap(0); since != 0 {
+		h.readTime.Add(time.Now().UnixNano() - since)
+	}
+}
+
+// stall and unstall surround a wait of the background reader for room in the queue.
+// They do nothing on a nil heartbeat.
+func (h *heartbeat) stall() {
+	if h != nil {
+		h.stalled.Store(true)
+	}
+}
+
+func (h *heartbeat) unstall() {
+	if h != nil {
+		h.stalled.Store(false)
+	}
+}
+
+// readClock returns a clock that only advances while the reader waits for frames, so
+// it tells how long a feed was quiet excluding the time the consumer was busy. With
+// a background reader, it is the wall clock.
+func (h *heartbeat) readClock() time.Duration {
+	now := time.Now().UnixNano()
+	if h.continuous {
+		return time.Duration(now)
+	}
+	total := h.readTime.Load()
+	if since := h.blockedSince.Load(); since != 0 {
+		total += now - since
+	}
+	return time.Duration(total)
+}
+
+// err returns an error wrapping ErrPongTimeout if the connection was closed because
+// a ping stayed unanswered
+func (h *heartbeat) err() error {
+	if h.expired.Load() {
+		return fmt.Errorf("%w (%v)", ErrPongTimeout, h.pongTimeout)
+	}
+	return h.watchdog.err()
+}
@@ -0,0 +1,202 @@
+package ws
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientPongTimeout(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		// Never answer pings
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	for _, buffered := range []bool{false, true} {
+		client := newClient[AllMids](server.URL(), AllMidsSubscription())
+		client.SetPingInterval(20 * time.Millisecond)
+		client.SetPongTimeout(30 * time.Millisecond)
+		if buffered {
+			client.SetBuffer(8, OverflowBlock)
+		}
+
+		start := time.Now()
+		_, err := client.Read()
+		if !errors.Is(err, ErrPongTimeout) {
+			t.Errorf("buffered=%v: Read() error = %v, want ErrPongTimeout", buffered, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("buffered=%v: Read() took %v", buffered, elapsed)
+		}
+		client.Close()
+	}
+}
+
+func TestClientPongTimeoutIgnoresBusyConsumer(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg["method"] == "ping" {
+				conn.WriteMessage(websocket.TextMessage, []byte(pongFrame))
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"2"}}}`))
+			}
+		}
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetPingInterval(20 * time.Millisecond)
+	client.SetPongTimeout(30 * time.Millisecond)
+	defer client.Close()
+
+	if _, err := client.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	// Pongs queue up unread while the consumer is busy
+	time.Sleep(150 * time.Millisecond)
+	if _, err := client.Read(); err != nil {
+		t.Fatalf("Read() after a busy period error = %v", err)
+	}
+}
+
+func TestClientPongTimeoutPausedWhileQueueFull(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		for i := 0; i < 8; i++ {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		}
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg["method"] == "ping" {
+				conn.WriteMessage(websocket.TextMessage, []byte(pongFrame))
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"2"}}}`))
+			}
+		}
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetPingInterval(20 * time.Millisecond)
+	client.SetPongTimeout(30 * time.Millisecond)
+	client.SetBuffer(1, OverflowBlock)
+	defer client.Close()
+
+	if _, err := client.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	// The pongs wait behind the data while the queue is full
+	time.Sleep(150 * time.Millisecond)
+	// Read past the frames buffered before the stall, up to the data sent with the pongs
+	for i := 0; i < 12; i++ {
+		if _, err := client.Read(); err != nil {
+			t.Fatalf("Read() %d after a full queue error = %v", i, err)
+		}
+	}
+}
+
+func TestManagerPongTimeout(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	manager := NewManagerWithURL(server.URL())
+	manager.SetPingInterval(20 * time.Millisecond)
+	manager.SetPongTimeout(30 * time.Millisecond)
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer manager.Stop()
+
+	select {
+	case <-manager.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("manager did not stop after the pong timeout")
+	}
+	if err := manager.Err(); !errors.Is(err, ErrPongTimeout) {
+		t.Errorf("Err() = %v, want ErrPongTimeout", err)
+	}
+}
+
+func TestSetPingIntervalNotPositive(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		conn.ReadMessage()
+	})
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		client := newClient[AllMids](server.URL(), AllMidsSubscription())
+		client.SetPingInterval(interval)
+		if client.pingInterval != DefaultPingInterval {
+			t.Errorf("SetPingInterval(%v): client interval = %v, want %v", interval, client.pingInterval, DefaultPingInterval)
+		}
+		// The heartbeat starts without panicking
+		if _, err := client.Read(); err != nil {
+			t.Errorf("SetPingInterval(%v): Read() error = %v", interval, err)
+		}
+		client.Close()
+
+		manager := NewManagerWithURL(server.URL())
+		manager.SetPingInterval(interval)
+		if manager.pingInterval != DefaultPingInterval {
+			t.Errorf("SetPingInterval(%v): manager interval = %v, want %v", interval, manager.pingInterval, DefaultPingInterval)
+		}
+		post := NewPostOnlyClient()
+		post.SetPingInterval(interval)
+		if post.pingInterval != DefaultPingInterval {
+			t.Errorf("SetPingInterval(%v): post-only interval = %v, want %v", interval, post.pingInterval, DefaultPingInterval)
+		}
+	}
+}
+
+func TestClientHandshakeTimeout(t *testing.T) {
+	// Accept TCP connections but never answer the handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	dialer := NewDialer()
+	client := newClient[AllMids]("ws://"+listener.Addr().String(), AllMidsSubscription())
+	client.SetDialer(dialer)
+	client.SetHandshakeTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := client.Read(); err == nil {
+		t.Fatal("Read() expected error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Read() took %v, want the handshake timeout", elapsed)
+	}
+	if dialer.HandshakeTimeout != DefaultHandshakeTimeout {
+		t.Errorf("custom dialer modified: HandshakeTimeout = %v", dialer.HandshakeTimeout)
+	}
+}
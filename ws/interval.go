@@ -0,0 +1,50 @@
+package ws
+
+import "github.com/dwdwow/hl-go/types"
+
+// Interval is the interval of the candles of a candle subscription
+type Interval = types.Interval
+
+// Intervals offered by the candle feed
+const (
+	Interval1m  = types.Interval1m
+	Interval3m  = types.Interval3m
+	Interval5m  = types.Interval5m
+	Interval15m = types.Interval15m
+	Interval30m = types.Interval30m
+	Interval1h  = types.Interval1h
+	Interval2h  = types.Interval2h
+	Interval4h  = types.Interval4h
+	Interval8h  = types.Interval8h
+	Interval12h = types.Interval12h
+	Interval1d  = types.Interval1d
+	Interval3d  = types.Interval3d
+	Interval1w  = types.Interval1w
+	Interval1M  = types.Interval1M
+)
+
+// ParseInterval returns the interval named s, e.g. "15m", see types.ParseInterval
+func ParseInterval(s string) (Interval, error) {
+	return types.ParseInterval(s)
+}
+
+// Interval returns the interval of the candle
+func (c Candle) Interval() Interval {
+	return Interval(c.I)
+}
+
+// OHLCV returns the candle in the canonical form shared with candle snapshots
+func (c Candle) OHLCV() types.OHLCV {
+	return types.OHLCV{
+		Coin:     c.S,
+		Interval: c.I,
+		Start:    c.T,
+		End:      c.T2,
+		Open:     c.O,
+		High:     c.H,
+		Low:      c.L,
+		Close:    c.C,
+		Volume:   c.V,
+		Trades:   c.N,
+	}
+}
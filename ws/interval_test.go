@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		wantErr  bool
+	}{
+		{"1m", time.Minute, false},
+		{"4h", 4 * time.Hour, false},
+		{"1w", 7 * 24 * time.Hour, false},
+		{"1M", 0, false},
+		{"6h", 0, true},
+		{"1H", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval, err := ParseInterval(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseInterval(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if interval.Duration() != tt.duration {
+				t.Errorf("Duration() = %v, want %v", interval.Duration(), tt.duration)
+			}
+		})
+	}
+}
+
+func TestCandleOHLCV(t *testing.T) {
+	snapshot := types.Candle{T0: 1000, T: 1999, S: "ETH", I: "1s", O: "1.5", H: "2", L: "1", C: "1.75", V: "10", N: 3}
+	feed := Candle{T: 1000, T2: 1999, S: "ETH", I: "1s", O: 1.5, H: 2, L: 1, C: 1.75, V: 10, N: 3}
+	want, err := snapshot.OHLCV()
+	if err != nil {
+		t.Fatalf("OHLCV() error = %v", err)
+	}
+	if got := feed.OHLCV(); got != want {
+		t.Errorf("OHLCV() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCandleIntervalsClient(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for i := 0; i < 2; i++ {
+			sub := readSubscription(t, conn)
+			interval, _ := sub["interval"].(string)
+			conn.WriteMessage(websocket.TextMessage,
+				[]byte(`{"channel":"candle","data":[{"s":"BTC","i":"`+interval+`"}]}`))
+		}
+		conn.ReadMessage()
+	})
+
+	client := NewCandleIntervalsClient("BTC", Interval1m, Interval1h)
+	client.SetURL(server.URL())
+	defer client.Close()
+
+	got := make(map[Interval]bool)
+	for i := 0; i < 2; i++ {
+		candles, err := client.Read()
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		for _, candle := range candles {
+			got[candle.Interval()] = true
+		}
+	}
+	if len(got) != 2 || !got[Interval1m] || !got[Interval1h] {
+		t.Errorf("intervals = %v, want 1m and 1h", got)
+	}
+}
+
+func TestCandleClientInvalidInterval(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		conn.ReadMessage()
+	})
+	client := NewCandleClient("6h", "BTC")
+	client.SetURL(server.URL())
+	if _, err := client.Read(); err == nil || !strings.Contains(err.Error(), "invalid candle interval") {
+		t.Errorf("Read() error = %v, want an invalid interval", err)
+	}
+	if n := server.Connections(); n != 0 {
+		t.Errorf("connections = %d, want none", n)
+	}
+}
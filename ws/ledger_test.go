@@ -0,0 +1,49 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestUserNonFundingLedgerUpdatesClient(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		sub := readSubscription(t, conn)
+		if sub["type"] != "userNonFundingLedgerUpdates" || sub["user"] != "0xabc" {
+			t.Errorf("subscription = %v", sub)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"userNonFundingLedgerUpdates","data":{
+			"isSnapshot":true,"user":"0xabc","nonFundingLedgerUpdates":[
+				{"time":1,"hash":"0x01","delta":{"type":"deposit","usdc":"100.0"}},
+				{"time":2,"hash":"0x02","delta":{"type":"accountClassTransfer","usdc":"5.0","toPerp":false}},
+				{"time":3,"hash":"0x03","delta":{"type":"liquidation","liquidatedNtlPos":"10.0","accountValue":"1.0","leverageType":"Cross","liquidatedPositions":[{"coin":"ETH","szi":"-0.1"}]}}
+			]}}`))
+		conn.ReadMessage()
+	})
+
+	client := NewUserNonFundingLedgerUpdatesClient("0xabc")
+	client.url = server.URL()
+	defer client.Close()
+
+	updates, err := client.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if updates.IsSnapshot == nil || !*updates.IsSnapshot || len(updates.Updates) != 3 {
+		t.Fatalf("updates = %+v", updates)
+	}
+
+	deposit := updates.Updates[0]
+	if deposit.Hash != "0x01" || deposit.Delta.Type != LedgerDeposit || deposit.Delta.Usdc != "100.0" {
+		t.Errorf("deposit = %+v", deposit)
+	}
+	transfer := updates.Updates[1].Delta
+	if transfer.Type != LedgerAccountClassTransfer || transfer.ToPerp == nil || *transfer.ToPerp {
+		t.Errorf("accountClassTransfer = %+v", transfer)
+	}
+	liquidation := updates.Updates[2].Delta
+	if liquidation.Type != LedgerLiquidation || len(liquidation.LiquidatedPositions) != 1 ||
+		liquidation.LiquidatedPositions[0].Coin != "ETH" || liquidation.LiquidatedPositions[0].Szi != "-0.1" {
+		t.Errorf("liquidation = %+v", liquidation)
+	}
+}
@@ -0,0 +1,610 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dwdwow/hl-go/constants"
+)
+
+// ErrManagerStopped is returned when a stopped Manager is used
+var ErrManagerStopped = errors.New("manager stopped")
+
+// Message is a raw message received on a subscription
+type Message struct {
+	Channel string
+	Data    json.RawMessage
+}
+
+// Manager multiplexes many subscriptions over a single WebSocket connection and
+// dispatches each message to the callbacks of the subscription it belongs to.
+//
+//	m := ws.NewManager()
+//	if err := m.Start(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer m.Stop()
+//
+//	coin := "BTC"
+//	id, err := m.Subscribe(ws.Subscription{Type: ws.SubscriptionL2Book, Coin: &coin}, func(msg ws.Message) {
+//	    var book ws.WsBook
+//	    json.Unmarshal(msg.Data, &book)
+//	})
+//
+// Callbacks run on the manager's read goroutine, one at a time, and should return quickly.
+// Unlike Client, Manager is safe for concurrent use.
+type Manager struct {
+	url         string
+	dialer      *websocket.Dialer
+	header      http.Header
+	compression bool
+	// handshakeTimeout overrides the handshake timeout of the dialer if positive
+	handshakeTimeout time.Duration
+	pingInterval     time.Duration
+	pongTimeout      time.Duration
+	readTimeout      time.Duration
+	closeTimeout     time.Duration
+	heartbeat        *heartbeat
+
+	metrics         connMetrics
+	metricsInterval time.Duration
+	metricsHook     func(Stats)
+	rawTap          func(frame []byte)
+	budget          *SubscriptionBudget
+
+	writeMu sync.Mutex
+	conn    *websocket.Conn
+
+	mu            sync.Mutex
+	subscriptions map[string][]*managedSubscription
+	nextID        int
+	started       bool
+	stopped       bool
+	err           error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// managedSubscription is a single callback registered for a subscription
+type managedSubscription struct {
+	id           int
+	subscription Subscription
+	callback     func(Message)
+}
+
+// NewManager creates a subscription manager for mainnet
+func NewManager() *Manager {
+	return newManager(MainnetWsURL)
+}
+
+// NewManagerForNetwork creates a subscription manager connecting to the WebSocket URL of network
+func NewManagerForNetwork(network constants.Network) *Manager {
+	return newManager(network.WsURL)
+}
+
+// NewManagerWithURL creates a subscription manager connecting to url, e.g. a local node
+func NewManagerWithURL(url string) *Manager {
+	return newManager(url)
+}
+
+func newManager(url string) *Manager {
+	return &Manager{
+		url:           url,
+		pingInterval:  DefaultPingInterval,
+		readTimeout:   DefaultReadTimeout,
+		closeTimeout:  DefaultCloseTimeout,
+		subscriptions: make(map[string][]*managedSubscription),
+		done:          make(chan struct{}),
+	}
+}
+
+// SetDialer sets the dialer used to connect, e.g. to go through a proxy or to pin
+// TLS certificates. See NewDialer for the default. Must be called before Start.
+func (m *Manager) SetDialer(dialer *websocket.Dialer) {
+	m.dialer = dialer
+}
+
+// SetHeader sets extra HTTP headers sent with the handshake. Must be called before Start.
+func (m *Manager) SetHeader(header http.Header) {
+	m.header = header
+}
+
+// SetCompression negotiates permessage-deflate compression with the server, which cuts
+// bandwidth several times for large feeds such as l2Book at the cost of CPU time to
+// inflate frames. Compression is used only if the server accepts it. Also enabled by a
+// dialer with EnableCompression set. Must be called before Start.
+func (m *Manager) SetCompression(enabled bool) {
+	m.compression = enabled
+}
+
+// SetPingInterval sets how often the manager pings the server. The server closes
+// connections that sent nothing for 60 seconds, so keep it well below that.
+// Defaults to DefaultPingInterval, also used for zero or negative intervals. Must be
+// called before Start.
+func (m *Manager) SetPingInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPingInterval
+	}
+	m.pingInterval = interval
+}
+
+// SetPongTimeout sets how long the server may take to answer a ping before the
+// connection is considered dead. The manager then stops with an error wrapping ErrPongTimeout. Zero disables the check (the default).
+// Must be called before Start.
+func (m *Manager) SetPongTimeout(timeout time.Duration) {
+	m.pongTimeout = timeout
+}
+
+// SetHandshakeTimeout overrides the handshake timeout of the dialer, which defaults to
+// DefaultHandshakeTimeout. Must be called before Start.
+func (m *Manager) SetHandshakeTimeout(timeout time.Duration) {
+	m.handshakeTimeout = timeout
+}
+
+// SetReadTimeout sets how long the connection may stay silent before the manager
+// stops with an error wrapping ErrStaleConnection. Zero disables the check.
+// Defaults to DefaultReadTimeout. Must be called before Start.
+func (m *Manager) SetReadTimeout(timeout time.Duration) {
+	m.readTimeout = timeout
+}
+
+// SetCloseTimeout sets how long Stop waits for the server to answer the close frame.
+// Zero closes the connection without the closing handshake. Defaults to DefaultCloseTimeout.
+func (m *Manager) SetCloseTimeout(timeout time.Duration) {
+	m.closeTimeout = timeout
+}
+
+// Stats returns traffic statistics of the connection. Safe to call from any goroutine.
+func (m *Manager) Stats() Stats {
+	return m.metrics.stats()
+}
+
+// SetRawTap sets a callback receiving every inbound frame before decoding. fn runs on the
+// read goroutine; it must not block or modify frame. Must be called before Start.
+func (m *Manager) SetRawTap(fn func(frame []byte)) {
+	m.rawTap = fn
+}
+
+// SetMetricsHook makes the manager call fn with its Stats every interval while running.
+// fn runs on a background goroutine and must not block. Must be called before Start.
+func (m *Manager) SetMetricsHook(interval time.Duration, fn func(Stats)) {
+	m.metricsInterval = interval
+	m.metricsHook = fn
+}
+
+// SetBudget counts the connection and subscriptions of the manager in budget, which
+// rejects or delays them beyond its limits, see SubscriptionBudget. Subscriptions are
+// counted from Subscribe until Unsubscribe or Stop. Must be called before Subscribe.
+func (m *Manager) SetBudget(budget *SubscriptionBudget) {
+	m.budget = budget
+}
+
+// Start connects and subscribes to everything registered before the call
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return ErrManagerStopped
+	}
+	if m.started {
+		return fmt.Errorf("manager already started")
+	}
+
+	if m.budget != nil {
+		// The subscriptions were counted by Subscribe
+		if err := m.budget.acquire(context.Background(), 1, nil, len(m.subscriptions)); err != nil {
+			return err
+		}
+	}
+	conn, err := dial(m.url, dialOptions{
+		dialer:           m.dialer,
+		header:           m.header,
+		compression:      m.compression,
+		handshakeTimeout: m.handshakeTimeout,
+	})
+	if err != nil {
+		if m.budget != nil {
+			m.budget.release(1, nil)
+		}
+		return fmt.Errorf("failed to connect to websocket: %w", err)
+	}
+	m.conn = conn
+	m.metrics.reset()
+
+	for _, subs := range m.subscriptions {
+		if err := m.write(subscribeMessage("subscribe", subs[0].subscription)); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to send subscription: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.started = true
+
+	m.heartbeat = &heartbeat{
+		interval:    m.pingInterval,
+		pongTimeout: m.pongTimeout,
+		metrics:     &m.metrics,
+		continuous:  true,
+	}
+	go m.heartbeat.run(ctx, conn, func() error {
+		return m.write(map[string]string{"method": "ping"})
+	})
+	go m.readRoutine()
+	if m.metricsHook != nil && m.metricsInterval > 0 {
+		go reportMetrics(ctx, m.metricsInterval, m.metricsHook, m.Stats)
+	}
+
+	return nil
+}
+
+// Stop closes the connection with a close frame, waiting up to the close timeout for
+// the server's (see SetCloseTimeout), and waits for the read goroutine to exit.
+// A stopped manager cannot be restarted.
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	started := m.started
+	m.releaseBudget()
+	m.mu.Unlock()
+
+	if !started {
+		close(m.done)
+		return nil
+	}
+
+	m.cancel()
+	err := closeGracefully(m.conn, m.closeTimeout, m.done)
+	<-m.done
+	return err
+}
+
+// Done returns a channel that is closed when the manager stops, either through
+// Stop or because the connection failed
+func (m *Manager) Done() <-chan struct{} {
+	return m.done
+}
+
+// Err returns the error that stopped the manager, or nil
+func (m *Manager) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// Subscribe registers callback for a subscription and returns an id for Unsubscribe.
+// Several callbacks can share a subscription; it is sent to the server only once.
+// Subscribing before Start is allowed: subscriptions are sent when the manager starts.
+func (m *Manager) Subscribe(subscription Subscription, callback func(Message)) (int, error) {
+	if callback == nil {
+		return 0, fmt.Errorf("callback is required")
+	}
+	identifier, err := subscriptionIdentifier(subscription)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return 0, ErrManagerStopped
+	}
+
+	existing := m.subscriptions[identifier]
+	if len(existing) == 0 && m.budget != nil {
+		if err := m.reserve(identifier, subscription); err != nil {
+			return 0, err
+		}
+		existing = m.subscriptions[identifier]
+	}
+	if len(existing) > 0 && !sameSubscription(existing[0].subscription, subscription) {
+		// userEvents, orderUpdates and notification messages carry no user, so they cannot be told apart
+		return 0, fmt.Errorf("cannot subscribe to %s for multiple users on one connection", subscription.Type)
+	}
+
+	if len(existing) == 0 && m.started {
+		if err := m.write(subscribeMessage("subscribe", subscription)); err != nil {
+			if m.budget != nil {
+				m.budget.release(0, []Subscription{subscription})
+			}
+			return 0, fmt.Errorf("failed to send subscription: %w", err)
+		}
+	}
+
+	m.nextID++
+	m.subscriptions[identifier] = append(existing, &managedSubscription{
+		id:           m.nextID,
+		subscription: subscription,
+		callback:     callback,
+	})
+
+	return m.nextID, nil
+}
+
+// Unsubscribe removes a callback. When the last callback of a subscription is
+// removed, the subscription is cancelled on the server.
+func (m *Manager) Unsubscribe(subscription Subscription, id int) error {
+	identifier, err := subscriptionIdentifier(subscription)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subs := m.subscriptions[identifier]
+	for i, sub := range subs {
+		if sub.id != id {
+			continue
+		}
+
+		subs = append(subs[:i:i], subs[i+1:]...)
+		if len(subs) > 0 {
+			m.subscriptions[identifier] = subs
+			return nil
+		}
+
+		delete(m.subscriptions, identifier)
+		if m.budget != nil && !m.stopped {
+			m.budget.release(0, []Subscription{sub.subscription})
+		}
+		if m.started && !m.stopped {
+			if err := m.write(subscribeMessage("unsubscribe", sub.subscription)); err != nil {
+				return fmt.Errorf("failed to send unsubscribe: %w", err)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("subscription %d not found", id)
+}
+
+// reserve counts a new subscription in the budget, releasing mu while the budget
+// queues it. Must be called with mu held. If the subscription was registered meanwhile,
+// it is released again, as it does not need to be sent.
+func (m *Manager) reserve(identifier string, subscription Subscription) error {
+	subs := []Subscription{subscription}
+	perConnection := len(m.subscriptions) + 1
+	m.mu.Unlock()
+	err := m.budget.acquire(context.Background(), 0, subs, perConnection)
+	m.mu.Lock()
+	if err != nil {
+		return err
+	}
+	if m.stopped {
+		m.budget.release(0, subs)
+		return ErrManagerStopped
+	}
+	if len(m.subscriptions[identifier]) > 0 {
+		m.budget.release(0, subs)
+	}
+	return nil
+}
+
+// releaseBudget stops counting the connection and subscriptions of the manager.
+// Must be called with mu held, when the manager stops.
+func (m *Manager) releaseBudget() {
+	if m.budget == nil {
+		return
+	}
+	subs := make([]Subscription, 0, len(m.subscriptions))
+	for _, registered := range m.subscriptions {
+		subs = append(subs, registered[0].subscription)
+	}
+	connections := 0
+	if m.started {
+		connections = 1
+	}
+	m.budget.release(connections, subs)
+}
+
+// write sends a message on the connection
+func (m *Manager) write(msg any) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	return m.conn.WriteJSON(msg)
+}
+
+// readRoutine reads messages until the connection fails or the manager stops
+func (m *Manager) readRoutine() {
+	defer close(m.done)
+
+	ctx := context.Background()
+	deadline := newReadDeadline(ctx, m.conn, m.readTimeout)
+	for {
+		deadline.refresh()
+		_, rawMsg, err := m.conn.ReadMessage()
+		if err != nil {
+			err, _ = deadline.classify(ctx, err)
+			if hbErr := m.heartbeat.err(); hbErr != nil {
+				err = hbErr
+			}
+			m.mu.Lock()
+			if !m.stopped {
+				m.err = err
+				m.stopped = true
+				m.releaseBudget()
+				m.cancel()
+				m.conn.Close()
+			}
+			m.mu.Unlock()
+			return
+		}
+		m.metrics.record(rawMsg)
+		if m.rawTap != nil {
+			m.rawTap(rawMsg)
+		}
+
+		if len(rawMsg) == 0 || rawMsg[0] != '{' {
+			continue
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(rawMsg, &msg); err != nil {
+			continue
+		}
+
+		identifier, ok := messageIdentifier(msg.Channel, msg.Data)
+		if !ok {
+			continue
+		}
+
+		m.mu.Lock()
+		subs := append([]*managedSubscription(nil), m.subscriptions[identifier]...)
+		m.mu.Unlock()
+
+		message := Message{Channel: msg.Channel, Data: msg.Data}
+		for _, sub := range subs {
+			sub.callback(message)
+		}
+	}
+}
+
+func subscribeMessage(method string, subscription Subscription) map[string]any {
+	return map[string]any{
+		"method":       method,
+		"subscription": subscription,
+	}
+}
+
+func sameSubscription(a, b Subscription) bool {
+	eq := func(x, y *string) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return strings.EqualFold(*x, *y)
+	}
+	return a.Type == b.Type && eq(a.Coin, b.Coin) && eq(a.User, b.User) && eq(a.Interval, b.Interval) &&
+		eq(a.Dex, b.Dex)
+}
+
+// subscriptionIdentifier returns the key that incoming messages of a subscription map to
+func subscriptionIdentifier(sub Subscription) (string, error) {
+	field := func(name string, value *string) (string, error) {
+		if value == nil || *value == "" {
+			return "", fmt.Errorf("%s subscription requires %s", sub.Type, name)
+		}
+		return strings.ToLower(*value), nil
+	}
+
+	switch sub.Type {
+	case SubscriptionAllMids:
+		if sub.Dex != nil && *sub.Dex != "" {
+			return "allMids:" + strings.ToLower(*sub.Dex), nil
+		}
+		return string(sub.Type), nil
+	case SubscriptionUserEvents, SubscriptionOrderUpdates, SubscriptionNotification:
+		return string(sub.Type), nil
+	case SubscriptionL2Book, SubscriptionTrades, SubscriptionBBO, SubscriptionActiveAssetCtx:
+		coin, err := field("coin", sub.Coin)
+		if err != nil {
+			return "", err
+		}
+		return string(sub.Type) + ":" + coin, nil
+	case SubscriptionCandle:
+		coin, err := field("coin", sub.Coin)
+		if err != nil {
+			return "", err
+		}
+		if _, err := field("interval", sub.Interval); err != nil {
+			return "", err
+		}
+		if _, err := ParseInterval(*sub.Interval); err != nil {
+			return "", err
+		}
+		return "candle:" + coin + "," + *sub.Interval, nil
+	case SubscriptionUserFills, SubscriptionUserFundings, SubscriptionUserNonFundingLedgerUpdates, SubscriptionWebData2,
+		SubscriptionUserTwapSliceFills, SubscriptionUserTwapHistory:
+		user, err := field("user", sub.User)
+		if err != nil {
+			return "", err
+		}
+		return string(sub.Type) + ":" + user, nil
+	case SubscriptionActiveAssetData:
+		coin, err := field("coin", sub.Coin)
+		if err != nil {
+			return "", err
+		}
+		user, err := field("user", sub.User)
+		if err != nil {
+			return "", err
+		}
+		return "activeAssetData:" + coin + "," + user, nil
+	default:
+		return "", fmt.Errorf("unsupported subscription type: %s", sub.Type)
+	}
+}
+
+// messageIdentifier returns the subscription key of an incoming message
+func messageIdentifier(channel string, data json.RawMessage) (string, bool) {
+	var fields struct {
+		Coin string `json:"coin"`
+		User string `json:"user"`
+		S    string `json:"s"`
+		I    string `json:"i"`
+	}
+
+	switch channel {
+	case "allMids":
+		var mids DexMids
+		if err := json.Unmarshal(data, &mids); err != nil {
+			return "", false
+		}
+		if mids.Dex != "" {
+			return "allMids:" + strings.ToLower(mids.Dex), true
+		}
+		return channel, true
+	case "orderUpdates", "notification":
+		return channel, true
+	case "user":
+		return string(SubscriptionUserEvents), true
+	case "trades":
+		var trades []WsTrade
+		if err := json.Unmarshal(data, &trades); err != nil || len(trades) == 0 {
+			return "", false
+		}
+		return "trades:" + strings.ToLower(trades[0].Coin), true
+	case "l2Book", "bbo", "activeAssetCtx", "activeSpotAssetCtx":
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return "", false
+		}
+		if channel == "activeSpotAssetCtx" {
+			channel = "activeAssetCtx"
+		}
+		return channel + ":" + strings.ToLower(fields.Coin), true
+	case "candle":
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return "", false
+		}
+		return "candle:" + strings.ToLower(fields.S) + "," + fields.I, true
+	case "userFills", "userFundings", "userNonFundingLedgerUpdates", "webData2", "userTwapSliceFills",
+		"userTwapHistory":
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return "", false
+		}
+		return channel + ":" + strings.ToLower(fields.User), true
+	case "activeAssetData":
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return "", false
+		}
+		return "activeAssetData:" + strings.ToLower(fields.Coin) + "," + strings.ToLower(fields.User), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,189 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestManagerDispatchesBySubscription(t *testing.T) {
+	requests := make(chan map[string]any, 10)
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for i := 0; i < 2; i++ {
+			requests <- readSubscription(t, conn)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"subscriptionResponse","data":{}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"l2Book","data":{"coin":"ETH","levels":[[],[]],"time":1}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"l2Book","data":{"coin":"BTC","levels":[[],[]],"time":2}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"trades","data":[{"coin":"BTC","px":"1","sz":"1"}]}`))
+
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg["method"] == "unsubscribe" {
+				requests <- msg
+			}
+		}
+	})
+
+	m := newManager(server.URL())
+	btc, eth := "BTC", "ETH"
+	bookSub := Subscription{Type: SubscriptionL2Book, Coin: &btc}
+	tradeSub := Subscription{Type: SubscriptionTrades, Coin: &btc}
+
+	books := make(chan Message, 10)
+	trades := make(chan Message, 10)
+	bookID, err := m.Subscribe(bookSub, func(msg Message) { books <- msg })
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer m.Stop()
+	if _, err := m.Subscribe(tradeSub, func(msg Message) { trades <- msg }); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	// A second callback on the same subscription is not sent to the server again
+	secondID, err := m.Subscribe(Subscription{Type: SubscriptionL2Book, Coin: &btc}, func(Message) {})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	for _, want := range []string{"l2Book", "trades"} {
+		if sub := <-requests; sub["type"] != want {
+			t.Errorf("subscription = %v, want type %s", sub, want)
+		}
+	}
+
+	select {
+	case msg := <-books:
+		if msg.Channel != "l2Book" || string(msg.Data) != `{"coin":"BTC","levels":[[],[]],"time":2}` {
+			t.Errorf("book message = %s %s", msg.Channel, msg.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for book message")
+	}
+	select {
+	case <-trades:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for trades message")
+	}
+	if len(books) != 0 {
+		t.Errorf("unexpected extra book messages: %d", len(books))
+	}
+
+	if _, err := m.Subscribe(Subscription{Type: SubscriptionL2Book, Coin: &eth}, nil); err == nil {
+		t.Error("Subscribe() with nil callback expected error")
+	}
+	if err := m.Unsubscribe(bookSub, bookID+100); err == nil {
+		t.Error("Unsubscribe() unknown id expected error")
+	}
+	if err := m.Unsubscribe(bookSub, bookID); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	// The other callback still holds the subscription
+	select {
+	case msg := <-requests:
+		t.Fatalf("unexpected request %v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := m.Unsubscribe(bookSub, secondID); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	select {
+	case msg := <-requests:
+		sub, _ := msg["subscription"].(map[string]any)
+		if sub["type"] != "l2Book" || sub["coin"] != "BTC" {
+			t.Errorf("unsubscribe = %v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for unsubscribe")
+	}
+
+	if err := m.Stop(); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	if _, err := m.Subscribe(tradeSub, func(Message) {}); err != ErrManagerStopped {
+		t.Errorf("Subscribe() after Stop error = %v, want ErrManagerStopped", err)
+	}
+}
+
+func TestManagerRejectsSecondUserEvents(t *testing.T) {
+	m := newManager("ws://unused")
+	alice, bob := "0xaaaa", "0xbbbb"
+	if _, err := m.Subscribe(Subscription{Type: SubscriptionUserEvents, User: &alice}, func(Message) {}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if _, err := m.Subscribe(Subscription{Type: SubscriptionUserEvents, User: &bob}, func(Message) {}); err == nil {
+		t.Error("Subscribe() userEvents for second user expected error")
+	}
+	if _, err := m.Subscribe(Subscription{Type: SubscriptionL2Book}, func(Message) {}); err == nil {
+		t.Error("Subscribe() l2Book without coin expected error")
+	}
+}
+
+func TestMessageIdentifier(t *testing.T) {
+	coin, user, interval, dex := "BTC", "0xAbC", "1m", "xyz"
+	tests := []struct {
+		sub     Subscription
+		channel string
+		data    string
+	}{
+		{Subscription{Type: SubscriptionAllMids}, "allMids", `{"mids":{}}`},
+		{Subscription{Type: SubscriptionAllMids, Dex: &dex}, "allMids", `{"mids":{"xyz:XYZ100":"1"}}`},
+		{Subscription{Type: SubscriptionAllMids, Dex: &dex}, "allMids", `{"dex":"xyz","mids":{}}`},
+		{Subscription{Type: SubscriptionUserEvents, User: &user}, "user", `{}`},
+		{Subscription{Type: SubscriptionNotification, User: &user}, "notification", `{"notification":"hi"}`},
+		{Subscription{Type: SubscriptionBBO, Coin: &coin}, "bbo", `{"coin":"BTC"}`},
+		{Subscription{Type: SubscriptionCandle, Coin: &coin, Interval: &interval}, "candle", `{"s":"BTC","i":"1m"}`},
+		{Subscription{Type: SubscriptionUserFills, User: &user}, "userFills", `{"user":"0xabc"}`},
+		{Subscription{Type: SubscriptionActiveAssetCtx, Coin: &coin}, "activeSpotAssetCtx", `{"coin":"BTC"}`},
+		{Subscription{Type: SubscriptionActiveAssetData, Coin: &coin, User: &user}, "activeAssetData", `{"coin":"BTC","user":"0xabc"}`},
+	}
+
+	for _, tt := range tests {
+		want, err := subscriptionIdentifier(tt.sub)
+		if err != nil {
+			t.Fatalf("subscriptionIdentifier(%s) error = %v", tt.sub.Type, err)
+		}
+		got, ok := messageIdentifier(tt.channel, []byte(tt.data))
+		if !ok || got != want {
+			t.Errorf("messageIdentifier(%s) = %q, %v, want %q", tt.channel, got, ok, want)
+		}
+	}
+}
+
+func TestDexAllMidsClient(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for i := 0; i < 2; i++ {
+			sub := readSubscription(t, conn)
+			dex, _ := sub["dex"].(string)
+			conn.WriteMessage(websocket.TextMessage,
+				[]byte(`{"channel":"allMids","data":{"mids":{"`+dex+`:COIN":"1"}}}`))
+		}
+		conn.ReadMessage()
+	})
+
+	client := NewDexAllMidsClient("xyz", "abc")
+	client.SetURL(server.URL())
+	defer client.Close()
+
+	got := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		mids, err := client.Read()
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if mids.Mids[mids.Dex+":COIN"] != "1" {
+			t.Errorf("mids of %s = %v", mids.Dex, mids.Mids)
+		}
+		got[mids.Dex] = true
+	}
+	if len(got) != 2 || !got["xyz"] || !got["abc"] {
+		t.Errorf("dexs = %v, want xyz and abc", got)
+	}
+}
@@ -0,0 +1,264 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/dwdwow/hl-go/constants"
+)
+
+// MarketView is the state of the market of a coin combined from its bbo, trades and
+// l2Book feeds
+type MarketView struct {
+	Coin string
+
+	// Bid and Ask are the best levels, from the latest of the bbo and l2Book messages.
+	// A side without orders has a zero level.
+	Bid WsLevel
+	Ask WsLevel
+	// Mid is the middle of the best levels, 0 until both sides have orders
+	Mid float64
+
+	// LastTrade is the latest trade, with a zero Time until the first trade
+	LastTrade WsTrade
+
+	// Book is the latest book, with a zero Time until the first book
+	Book WsBook
+
+	// Time is the time in milliseconds of the latest update
+	Time int64
+
+	bboTime int64
+}
+
+// Spread returns the difference between the best ask and the best bid, 0 until both
+// sides have orders
+func (v MarketView) Spread() float64 {
+	if v.Mid == 0 {
+		return 0
+	}
+	return v.Ask.Px - v.Bid.Px
+}
+
+// MarketData subscribes to the bbo, trades and l2Book feeds of a coin over one
+// connection and maintains a combined MarketView, read with the polling accessors or
+// received on Updates:
+//
+//	btc := ws.NewMarketData("BTC")
+//	go btc.Run(ctx)
+//	for view := range btc.Updates() {
+//	    fmt.Println(view.Bid.Px, view.Ask.Px, view.LastTrade.Px)
+//	}
+//
+// Several coins can share a connection with SetManager. Safe for concurrent use.
+type MarketData struct {
+	coin      string
+	url       string
+	manager   *Manager
+	configure func(*Manager)
+
+	mu   sync.RWMutex
+	view MarketView
+
+	updates chan MarketView
+}
+
+// NewMarketData creates the market data of coin on mainnet
+func NewMarketData(coin string) *MarketData {
+	return &MarketData{
+		coin:    coin,
+		url:     MainnetWsURL,
+		view:    MarketView{Coin: coin},
+		updates: make(chan MarketView, 1),
+	}
+}
+
+// SetURL sets the WebSocket URL to connect to, e.g. a local node. Must be called before Run.
+func (d *MarketData) SetURL(url string) {
+	d.url = url
+}
+
+// SetNetwork connects to the WebSocket URL of network. Must be called before Run.
+func (d *MarketData) SetNetwork(network constants.Network) {
+	d.url = network.WsURL
+}
+
+// SetConfigure sets a function applied to the Manager of the connection before it
+// starts, e.g. to set a dialer or a read timeout. Ignored with SetManager.
+// Must be called before Run.
+func (d *MarketData) SetConfigure(fn func(m *Manager)) {
+	d.configure = fn
+}
+
+// SetManager subscribes over manager instead of a connection of its own, e.g. to share
+// one connection between the market data of several coins. The caller starts and stops
+// manager; Run then only adds and removes the subscriptions of the coin.
+// Must be called before Run.
+func (d *MarketData) SetManager(manager *Manager) {
+	d.manager = manager
+}
+
+// Run subscribes to the feeds of the coin and keeps the view up to date until ctx is
+// done or the connection fails, returning ctx.Err() or the error of the connection
+func (d *MarketData) Run(ctx context.Context) error {
+	m := d.manager
+	if m == nil {
+		m = newManager(d.url)
+		if d.configure != nil {
+			d.configure(m)
+		}
+		defer m.Stop()
+	}
+
+	coin := d.coin
+	for _, subType := range []SubscriptionType{SubscriptionBBO, SubscriptionTrades, SubscriptionL2Book} {
+		sub := Subscription{Type: subType, Coin: &coin}
+		id, err := m.Subscribe(sub, d.handle)
+		if err != nil {
+			return err
+		}
+		if d.manager != nil {
+			defer m.Unsubscribe(sub, id)
+		}
+	}
+	if d.manager == nil {
+		if err := m.Start(); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.Done():
+		if err := m.Err(); err != nil {
+			return err
+		}
+		return ErrManagerStopped
+	}
+}
+
+// View returns the current view of the market
+func (d *MarketData) View() MarketView {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.view
+}
+
+// BBO returns the best bid and ask, zero levels for sides without orders
+func (d *MarketData) BBO() (bid, ask WsLevel) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.view.Bid, d.view.Ask
+}
+
+// Mid returns the middle of the best bid and ask, 0 until both sides have orders
+func (d *MarketData) Mid() float64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.view.Mid
+}
+
+// LastTrade returns the latest trade, with a zero Time before the first trade
+func (d *MarketData) LastTrade() WsTrade {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.view.LastTrade
+}
+
+// Book returns the best depth levels of each side of the latest book, all of them if
+// depth is 0
+func (d *MarketData) Book(depth int) WsBook {
+	d.mu.RLock()
+	book := d.view.Book
+	d.mu.RUnlock()
+	if depth > 0 {
+		book = BookDepth(depth)(book)
+	}
+	return book
+}
+
+// Updates returns a channel receiving the view after every update. It holds only the
+// latest view, so a slow receiver skips intermediate views instead of stalling the feeds.
+// It is never closed.
+func (d *MarketData) Updates() <-chan MarketView {
+	return d.updates
+}
+
+// handle applies a message of one of the feeds to the view
+func (d *MarketData) handle(msg Message) {
+	d.mu.Lock()
+	view := &d.view
+	switch msg.Channel {
+	case "bbo":
+		var bbo WsBbo
+		if json.Unmarshal(msg.Data, &bbo) != nil || bbo.Time < view.Book.Time {
+			d.mu.Unlock()
+			return
+		}
+		view.setBest(bbo.Bbo[0], bbo.Bbo[1])
+		view.bboTime = bbo.Time
+		view.Time = max(view.Time, bbo.Time)
+	case "trades":
+		var trades []WsTrade
+		if json.Unmarshal(msg.Data, &trades) != nil || len(trades) == 0 {
+			d.mu.Unlock()
+			return
+		}
+		for _, trade := range trades {
+			if trade.Time >= view.LastTrade.Time {
+				view.LastTrade = trade
+			}
+		}
+		view.Time = max(view.Time, view.LastTrade.Time)
+	case "l2Book":
+		var book WsBook
+		if json.Unmarshal(msg.Data, &book) != nil {
+			d.mu.Unlock()
+			return
+		}
+		view.Book = book
+		if book.Time >= view.bboTime {
+			view.setBest(firstLevel(book.Levels[0]), firstLevel(book.Levels[1]))
+		}
+		view.Time = max(view.Time, book.Time)
+	default:
+		d.mu.Unlock()
+		return
+	}
+	latest := d.view
+	d.mu.Unlock()
+
+	// Replace the view the receiver has not taken yet. Callbacks run one at a time,
+	// so nothing else sends meanwhile.
+	select {
+	case d.updates <- latest:
+	default:
+		select {
+		case <-d.updates:
+		default:
+		}
+		d.updates <- latest
+	}
+}
+
+func (v *MarketView) setBest(bid, ask *WsLevel) {
+	v.Bid, v.Ask, v.Mid = WsLevel{}, WsLevel{}, 0
+	if bid != nil {
+		v.Bid = *bid
+	}
+	if ask != nil {
+		v.Ask = *ask
+	}
+	if bid != nil && ask != nil {
+		v.Mid = (bid.Px + ask.Px) / 2
+	}
+}
+
+func firstLevel(levels []WsLevel) *WsLevel {
+	if len(levels) == 0 {
+		return nil
+	}
+	return &levels[0]
+}
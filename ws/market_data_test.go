@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMarketData(t *testing.T) {
+	frames := []string{
+		`{"channel":"l2Book","data":{"coin":"BTC","time":1000,"levels":[[{"px":"99","sz":"1","n":1},{"px":"98","sz":"2","n":1}],[{"px":"101","sz":"1","n":1}]]}}`,
+		`{"channel":"trades","data":[{"coin":"BTC","side":"B","px":"100","sz":"0.5","time":1001},{"coin":"BTC","side":"A","px":"99.5","sz":"1","time":1002}]}`,
+		`{"channel":"bbo","data":{"coin":"BTC","time":1003,"bbo":[{"px":"99.5","sz":"1","n":1},null]}}`,
+		// An older bbo does not override the book
+		`{"channel":"bbo","data":{"coin":"BTC","time":10,"bbo":[{"px":"1","sz":"1","n":1},{"px":"2","sz":"1","n":1}]}}`,
+		`{"channel":"bbo","data":{"coin":"BTC","time":1004,"bbo":[{"px":"99.5","sz":"1","n":1},{"px":"100.5","sz":"1","n":1}]}}`,
+	}
+	subscribed := make(chan SubscriptionType, 3)
+	send := make(chan struct{})
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for i := 0; i < 3; i++ {
+			sub := readSubscription(t, conn)
+			subType, _ := sub["type"].(string)
+			subscribed <- SubscriptionType(subType)
+		}
+		for _, frame := range frames {
+			<-send
+			conn.WriteMessage(websocket.TextMessage, []byte(frame))
+		}
+		conn.ReadMessage()
+	})
+
+	btc := NewMarketData("BTC")
+	btc.SetURL(server.URL())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- btc.Run(ctx) }()
+
+	subTypes := make(map[SubscriptionType]bool)
+	for i := 0; i < 3; i++ {
+		subTypes[<-subscribed] = true
+	}
+	if !subTypes[SubscriptionBBO] || !subTypes[SubscriptionTrades] || !subTypes[SubscriptionL2Book] {
+		t.Errorf("subscriptions = %v", subTypes)
+	}
+
+	var views []MarketView
+	for i := range frames {
+		send <- struct{}{}
+		if i == 3 {
+			// No update for the older bbo
+			continue
+		}
+		select {
+		case view := <-btc.Updates():
+			views = append(views, view)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no update after %d frames", len(views))
+		}
+	}
+	if len(views) != 4 {
+		t.Fatalf("%d updates, want 4", len(views))
+	}
+
+	if v := views[0]; v.Bid.Px != 99 || v.Ask.Px != 101 || v.Mid != 100 || v.Spread() != 2 {
+		t.Errorf("view of the book = %+v", v)
+	}
+	if v := views[1]; v.LastTrade.Px != 99.5 || v.LastTrade.Time != 1002 || v.Time != 1002 {
+		t.Errorf("view of the trades = %+v", v)
+	}
+	if v := views[2]; v.Bid.Px != 99.5 || v.Ask != (WsLevel{}) || v.Mid != 0 || v.Spread() != 0 {
+		t.Errorf("view of a one-sided bbo = %+v", v)
+	}
+	if mid := btc.Mid(); mid != 100 {
+		t.Errorf("Mid() = %v, want 100", mid)
+	}
+	if bid, ask := btc.BBO(); bid.Px != 99.5 || ask.Px != 100.5 {
+		t.Errorf("BBO() = %v, %v", bid, ask)
+	}
+	if book := btc.Book(1); len(book.Levels[0]) != 1 || book.Time != 1000 {
+		t.Errorf("Book(1) = %+v", book)
+	}
+	if trade := btc.LastTrade(); trade.Side != "A" {
+		t.Errorf("LastTrade() = %+v", trade)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,168 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateWindow is the period over which Stats averages message and byte rates
+const RateWindow = 10 * time.Second
+
+// pongFrame is the server's answer to {"method":"ping"}
+const pongFrame = `{"channel":"pong"}`
+
+// Stats describes the traffic of the current connection of a client
+type Stats struct {
+	// ConnectedAt is when the current connection was established; counters start from it
+	ConnectedAt time.Time
+
+	// Messages and Bytes count the frames received on the current connection, pongs included
+	Messages uint64
+	Bytes    uint64
+
+	// MessagesPerSec and BytesPerSec are averaged over the last RateWindow, or since
+	// ConnectedAt for younger connections
+	MessagesPerSec float64
+	BytesPerSec    float64
+
+	// LastMessage is when the last frame was received, zero if none was received yet
+	LastMessage time.Time
+
+	// SinceLastMessage is the time elapsed since LastMessage, or since ConnectedAt
+	// if nothing was received on the current connection yet
+	SinceLastMessage time.Duration
+
+	// PingRTT is the round-trip time of the last answered ping, zero before the first pong
+	PingRTT time.Duration
+
+	// Dropped is the number of messages discarded by the overflow policy, see Client.SetBuffer
+	Dropped uint64
+}
+
+// connMetrics records the traffic of a connection. Safe for concurrent use.
+type connMetrics struct {
+	connectedAt atomic.Int64
+	lastMessage atomic.Int64
+	messages    atomic.Uint64
+	bytes       atomic.Uint64
+	pingSent    atomic.Int64
+	pingRTT     atomic.Int64
+
+	// buckets holds per-second counts over RateWindow, indexed by Unix second
+	mu      sync.Mutex
+	buckets [int(RateWindow / time.Second)]rateBucket
+}
+
+type rateBucket struct {
+	second   int64
+	messages uint64
+	bytes    uint64
+}
+
+// reset starts counting for a new connection. LastMessage is kept, so that it
+// reports the last frame received by the client across reconnections.
+func (m *connMetrics) reset() {
+	m.connectedAt.Store(time.Now().UnixNano())
+	m.messages.Store(0)
+	m.bytes.Store(0)
+	m.pingSent.Store(0)
+	m.pingRTT.Store(0)
+	m.mu.Lock()
+	m.buckets = [len(m.buckets)]rateBucket{}
+	m.mu.Unlock()
+}
+
+// record accounts for a received frame, measuring ping RTT when frame is a pong
+func (m *connMetrics) record(frame []byte) {
+	now := time.Now()
+	m.lastMessage.Store(now.UnixNano())
+	m.messages.Add(1)
+	m.bytes.Add(uint64(len(frame)))
+
+	if string(frame) == pongFrame {
+		if sent := m.pingSent.Swap(0); sent != 0 {
+			m.pingRTT.Store(now.UnixNano() - sent)
+		}
+	}
+
+	second := now.Unix()
+	m.mu.Lock()
+	bucket := &m.buckets[second%int64(len(m.buckets))]
+	if bucket.second != second {
+		*bucket = rateBucket{second: second}
+	}
+	bucket.messages++
+	bucket.bytes += uint64(len(frame))
+	m.mu.Unlock()
+}
+
+// pinged records that a ping was sent. Only the first ping awaiting a pong is timed.
+func (m *connMetrics) pinged() {
+	m.pingSent.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// lastMessageTime returns when the last frame was received, or the zero time
+func (m *connMetrics) lastMessageTime() time.Time {
+	if nanos := m.lastMessage.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+func (m *connMetrics) stats() Stats {
+	now := time.Now()
+	s := Stats{
+		Messages:    m.messages.Load(),
+		Bytes:       m.bytes.Load(),
+		LastMessage: m.lastMessageTime(),
+		PingRTT:     time.Duration(m.pingRTT.Load()),
+	}
+	if nanos := m.connectedAt.Load(); nanos != 0 {
+		s.ConnectedAt = time.Unix(0, nanos)
+	}
+
+	switch {
+	case s.LastMessage.After(s.ConnectedAt):
+		s.SinceLastMessage = now.Sub(s.LastMessage)
+	case !s.ConnectedAt.IsZero():
+		s.SinceLastMessage = now.Sub(s.ConnectedAt)
+	}
+
+	// Average over the buckets of the window, the current partial second included
+	window := RateWindow
+	if age := now.Sub(s.ConnectedAt); !s.ConnectedAt.IsZero() && age < window {
+		window = age
+	}
+	if window <= 0 {
+		return s
+	}
+	oldest := now.Add(-RateWindow).Unix()
+	var messages, bytes uint64
+	m.mu.Lock()
+	for _, bucket := range m.buckets {
+		if bucket.second > oldest {
+			messages += bucket.messages
+			bytes += bucket.bytes
+		}
+	}
+	m.mu.Unlock()
+	s.MessagesPerSec = float64(messages) / window.Seconds()
+	s.BytesPerSec = float64(bytes) / window.Seconds()
+	return s
+}
+
+// reportMetrics calls fn with stats() every interval until ctx is done
+func reportMetrics(ctx context.Context, interval time.Duration, fn func(Stats), stats func() Stats) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(stats())
+		}
+	}
+}
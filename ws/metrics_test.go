@@ -0,0 +1,109 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientStats(t *testing.T) {
+	const mids = `{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(mids))
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg["method"] == "ping" {
+				time.Sleep(5 * time.Millisecond)
+				conn.WriteMessage(websocket.TextMessage, []byte(pongFrame))
+				conn.WriteMessage(websocket.TextMessage, []byte(mids))
+			}
+		}
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.pingInterval = 20 * time.Millisecond
+	reports := make(chan Stats, 16)
+	client.SetMetricsHook(10*time.Millisecond, func(s Stats) {
+		select {
+		case reports <- s:
+		default:
+		}
+	})
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Read(); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+	}
+
+	s := client.Stats()
+	if s.Messages != 3 {
+		t.Errorf("Messages = %d, want 3 (two data messages and a pong)", s.Messages)
+	}
+	if want := uint64(2*len(mids) + len(pongFrame)); s.Bytes != want {
+		t.Errorf("Bytes = %d, want %d", s.Bytes, want)
+	}
+	if s.PingRTT < 5*time.Millisecond {
+		t.Errorf("PingRTT = %v, want at least the server delay", s.PingRTT)
+	}
+	if s.MessagesPerSec <= 0 || s.BytesPerSec <= 0 {
+		t.Errorf("rates = %v msg/s, %v B/s, want positive", s.MessagesPerSec, s.BytesPerSec)
+	}
+	if s.ConnectedAt.IsZero() || s.LastMessage.Before(s.ConnectedAt) {
+		t.Errorf("ConnectedAt = %v, LastMessage = %v", s.ConnectedAt, s.LastMessage)
+	}
+
+	select {
+	case r := <-reports:
+		if r.ConnectedAt.IsZero() {
+			t.Errorf("hook Stats = %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("metrics hook not called")
+	}
+}
+
+func TestConnMetricsRates(t *testing.T) {
+	var m connMetrics
+	m.reset()
+	// Pretend the connection is older than the window
+	m.connectedAt.Store(time.Now().Add(-time.Minute).UnixNano())
+
+	frame, _ := json.Marshal(map[string]string{"channel": "trades"})
+	for i := 0; i < 20; i++ {
+		m.record(frame)
+	}
+	s := m.stats()
+	if want := 20 / RateWindow.Seconds(); s.MessagesPerSec != want {
+		t.Errorf("MessagesPerSec = %v, want %v", s.MessagesPerSec, want)
+	}
+	if want := float64(20*len(frame)) / RateWindow.Seconds(); s.BytesPerSec != want {
+		t.Errorf("BytesPerSec = %v, want %v", s.BytesPerSec, want)
+	}
+
+	// Pongs are timed against the first pending ping only
+	m.pinged()
+	m.pingSent.Store(time.Now().Add(-30 * time.Millisecond).UnixNano())
+	m.pinged()
+	m.record([]byte(pongFrame))
+	if rtt := m.stats().PingRTT; rtt < 30*time.Millisecond || rtt > time.Second {
+		t.Errorf("PingRTT = %v, want about 30ms", rtt)
+	}
+
+	// Rates decay once the buckets fall out of the window
+	m.mu.Lock()
+	for i := range m.buckets {
+		m.buckets[i].second -= int64(RateWindow / time.Second)
+	}
+	m.mu.Unlock()
+	if s := m.stats(); s.MessagesPerSec != 0 {
+		t.Errorf("MessagesPerSec after window = %v, want 0", s.MessagesPerSec)
+	}
+}
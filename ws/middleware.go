@@ -0,0 +1,111 @@
+package ws
+
+import (
+	"context"
+	"slices"
+)
+
+// Reader is a feed of messages of type T, implemented by Client and by the pipes
+// built on it with WithFilter and WithMap
+type Reader[T any] interface {
+	Read() (T, error)
+	ReadContext(ctx context.Context) (T, error)
+	Close() error
+}
+
+// Pipe is a feed derived from another Reader by WithFilter or WithMap. Reading a pipe
+// reads its source, and closing it closes the source.
+//
+//	big := ws.WithMap(ws.NewTradesClient("BTC"), ws.TradesMinSize(10)).
+//	    WithFilter(func(trades []ws.WsTrade) bool { return len(trades) > 0 })
+//	defer big.Close()
+//	for {
+//	    trades, err := big.Read()
+//	    // Process trades of at least 10 BTC...
+//	}
+//
+// Like Client, a Pipe is designed for single-threaded use.
+type Pipe[T any] struct {
+	read   func(ctx context.Context) (T, error)
+	source interface{ Close() error }
+}
+
+// WithFilter returns a feed of the messages of r for which keep returns true
+func WithFilter[T any](r Reader[T], keep func(T) bool) *Pipe[T] {
+	return &Pipe[T]{
+		read: func(ctx context.Context) (T, error) {
+			for {
+				data, err := r.ReadContext(ctx)
+				if err != nil || keep(data) {
+					return data, err
+				}
+			}
+		},
+		source: r,
+	}
+}
+
+// WithMap returns a feed of the messages of r transformed by fn
+func WithMap[T, U any](r Reader[T], fn func(T) U) *Pipe[U] {
+	return &Pipe[U]{
+		read: func(ctx context.Context) (U, error) {
+			data, err := r.ReadContext(ctx)
+			if err != nil {
+				var zero U
+				return zero, err
+			}
+			return fn(data), nil
+		},
+		source: r,
+	}
+}
+
+// WithFilter returns a feed of the messages of the client for which keep returns true
+func (c *Client[T]) WithFilter(keep func(T) bool) *Pipe[T] {
+	return WithFilter[T](c, keep)
+}
+
+// WithFilter returns a feed of the messages of the pipe for which keep returns true
+func (p *Pipe[T]) WithFilter(keep func(T) bool) *Pipe[T] {
+	return WithFilter[T](p, keep)
+}
+
+// Read blocks until a message passes the pipe, see Client.Read
+func (p *Pipe[T]) Read() (T, error) {
+	return p.ReadContext(context.Background())
+}
+
+// ReadContext is like Read but gives up when ctx is done, see Client.ReadContext
+func (p *Pipe[T]) ReadContext(ctx context.Context) (T, error) {
+	return p.read(ctx)
+}
+
+// Close closes the source of the pipe
+func (p *Pipe[T]) Close() error {
+	return p.source.Close()
+}
+
+// Stream delivers the messages of the pipe on a channel, see Client.Stream
+func (p *Pipe[T]) Stream(ctx context.Context) (<-chan T, <-chan error) {
+	return stream[T](ctx, p)
+}
+
+// BookDepth returns a WithMap function keeping the best n levels of each side of a book
+func BookDepth(n int) func(WsBook) WsBook {
+	return func(book WsBook) WsBook {
+		for side := range book.Levels {
+			if len(book.Levels[side]) > n {
+				book.Levels[side] = book.Levels[side][:n:n]
+			}
+		}
+		return book
+	}
+}
+
+// TradesMinSize returns a WithMap function keeping the trades of at least size.
+// Batches may become empty; filter them out with WithFilter.
+func TradesMinSize(size float64) func([]WsTrade) []WsTrade {
+	return func(trades []WsTrade) []WsTrade {
+		return slices.DeleteFunc(trades, func(trade WsTrade) bool { return trade.Sz < size })
+	}
+}
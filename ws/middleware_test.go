@@ -0,0 +1,108 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// sliceReader is a Reader returning msgs, then io.EOF
+type sliceReader[T any] struct {
+	msgs   []T
+	closed bool
+}
+
+func (r *sliceReader[T]) Read() (T, error) {
+	return r.ReadContext(context.Background())
+}
+
+func (r *sliceReader[T]) ReadContext(ctx context.Context) (data T, err error) {
+	if len(r.msgs) == 0 {
+		return data, io.EOF
+	}
+	data, r.msgs = r.msgs[0], r.msgs[1:]
+	return data, nil
+}
+
+func (r *sliceReader[T]) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestWithFilterAndMap(t *testing.T) {
+	source := &sliceReader[[]WsTrade]{msgs: [][]WsTrade{
+		{{Coin: "BTC", Sz: 0.5}, {Coin: "BTC", Sz: 12}},
+		{{Coin: "BTC", Sz: 1}},
+		{{Coin: "BTC", Sz: 10}},
+	}}
+	big := WithMap(source, TradesMinSize(10)).
+		WithFilter(func(trades []WsTrade) bool { return len(trades) > 0 })
+	counts := WithMap(big, func(trades []WsTrade) int { return len(trades) })
+
+	var sizes []float64
+	for {
+		trades, err := big.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		for _, trade := range trades {
+			sizes = append(sizes, trade.Sz)
+		}
+	}
+	if len(sizes) != 2 || sizes[0] != 12 || sizes[1] != 10 {
+		t.Errorf("sizes = %v, want [12 10]", sizes)
+	}
+
+	// Errors of the source pass through transforms
+	if _, err := counts.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() at the end = %v, want io.EOF", err)
+	}
+	counts.Close()
+	if !source.closed {
+		t.Error("Close() did not close the source")
+	}
+}
+
+func TestBookDepth(t *testing.T) {
+	book := WsBook{Coin: "BTC", Levels: [2][]WsLevel{
+		{{Px: 3}, {Px: 2}, {Px: 1}},
+		{{Px: 4}},
+	}}
+	top := BookDepth(2)(book)
+	if len(top.Levels[0]) != 2 || top.Levels[0][1].Px != 2 || len(top.Levels[1]) != 1 {
+		t.Errorf("BookDepth(2) = %+v", top.Levels)
+	}
+}
+
+func TestClientWithFilterStream(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		for _, coin := range []string{"BTC", "ETH", "BTC"} {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"l2Book","data":{"coin":"`+coin+`","time":1,"levels":[[],[]]}}`))
+		}
+		conn.ReadMessage()
+	})
+	client := newClient[WsBook](server.URL(), L2BookSubscription("BTC"), L2BookSubscription("ETH"))
+	btc := client.WithFilter(func(book WsBook) bool { return book.Coin == "BTC" })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	books, errs := btc.Stream(ctx)
+	for i := 0; i < 2; i++ {
+		if book := <-books; book.Coin != "BTC" {
+			t.Errorf("book %d coin = %s, want BTC", i, book.Coin)
+		}
+	}
+	cancel()
+	for range books {
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("stream error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,105 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// UserMessage is a message of a user feed tagged with its user
+type UserMessage[T any] struct {
+	User string
+	Data T
+}
+
+// MultiUserOrderUpdates merges the orderUpdates feeds of several users, e.g. a master
+// account and its sub-accounts, into one stream tagged with the user of each update:
+//
+//	updates := ws.NewMultiUserOrderUpdates(master, sub1, sub2)
+//	updates.SetConfigure(func(user string, c *ws.Client[[]ws.WsOrder]) {
+//	    c.SetReconnectPolicy(ws.DefaultReconnectPolicy())
+//	})
+//	msgs, errs := updates.Stream(ctx)
+//	for msg := range msgs {
+//	    fmt.Println(msg.User, msg.Data)
+//	}
+//
+// orderUpdates messages do not name their user, so each user needs a connection of its
+// own to be told apart. To stay under the connection limit, the configure function can
+// give each user a ConnectionPool shared with the other feeds of that user, such as
+// userFills, but never with the orderUpdates of another user.
+type MultiUserOrderUpdates struct {
+	users     []string
+	configure func(user string, c *Client[[]WsOrder])
+}
+
+// NewMultiUserOrderUpdates creates a merged orderUpdates stream for users on mainnet
+func NewMultiUserOrderUpdates(users ...string) *MultiUserOrderUpdates {
+	return &MultiUserOrderUpdates{users: users}
+}
+
+// SetConfigure sets a function applied to the client of each user before it connects,
+// e.g. to set the network, a reconnect policy or a pool. Must be called before Stream.
+func (m *MultiUserOrderUpdates) SetConfigure(fn func(user string, c *Client[[]WsOrder])) {
+	m.configure = fn
+}
+
+// Stream subscribes to the orderUpdates of every user and delivers the updates on the
+// returned channel, in the order they are received. The stream ends when ctx is done or
+// the feed of any user fails; the error channel then receives exactly one error, naming
+// the user whose feed failed, and both channels are closed.
+func (m *MultiUserOrderUpdates) Stream(ctx context.Context) (<-chan UserMessage[[]WsOrder], <-chan error) {
+	out := make(chan UserMessage[[]WsOrder])
+	errs := make(chan error, 1)
+	if len(m.users) == 0 {
+		errs <- fmt.Errorf("no users to stream order updates of")
+		close(errs)
+		close(out)
+		return out, errs
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	for _, user := range m.users {
+		client := NewOrderUpdatesClient(user)
+		if m.configure != nil {
+			m.configure(user, client)
+		}
+		data, streamErrs := client.Stream(streamCtx)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range data {
+				select {
+				case out <- UserMessage[[]WsOrder]{User: user, Data: d}:
+				case <-streamCtx.Done():
+				}
+			}
+			if err, ok := <-streamErrs; ok && streamCtx.Err() == nil {
+				fail(fmt.Errorf("order updates of %s: %w", user, err))
+			}
+		}()
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(out)
+
+		wg.Wait()
+		cancel()
+		once.Do(func() { firstErr = ctx.Err() })
+		errs <- firstErr
+	}()
+
+	return out, errs
+}
@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMultiUserOrderUpdatesTagsUsers(t *testing.T) {
+	oids := map[string]int{"0xaaa": 1, "0xbbb": 2}
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		sub := readSubscription(t, conn)
+		user, _ := sub["user"].(string)
+		update := fmt.Sprintf(`{"channel":"orderUpdates","data":[{"order":{"coin":"BTC","oid":%d},"status":"open","statusTimestamp":1}]}`, oids[user])
+		conn.WriteMessage(websocket.TextMessage, []byte(update))
+		conn.ReadMessage()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	updates := NewMultiUserOrderUpdates("0xaaa", "0xbbb")
+	updates.SetConfigure(func(user string, c *Client[[]WsOrder]) { c.SetURL(server.URL()) })
+	msgs, errs := updates.Stream(ctx)
+
+	got := make(map[string]int64)
+	for len(got) < 2 {
+		msg, ok := <-msgs
+		if !ok {
+			t.Fatalf("stream ended early: %v", <-errs)
+		}
+		if len(msg.Data) != 1 {
+			t.Fatalf("update of %s = %+v", msg.User, msg.Data)
+		}
+		got[msg.User] = msg.Data[0].Order.Oid
+	}
+	for user, oid := range oids {
+		if got[user] != int64(oid) {
+			t.Errorf("oid of %s = %d, want %d", user, got[user], oid)
+		}
+	}
+
+	cancel()
+	for range msgs {
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("error after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func TestMultiUserOrderUpdatesFailure(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		sub := readSubscription(t, conn)
+		if sub["user"] == "0xbad" {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"error","data":"Invalid subscription"}`))
+		}
+		conn.ReadMessage()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	updates := NewMultiUserOrderUpdates("0xaaa", "0xbad")
+	updates.SetConfigure(func(user string, c *Client[[]WsOrder]) { c.SetURL(server.URL()) })
+	msgs, errs := updates.Stream(ctx)
+	for range msgs {
+	}
+	err := <-errs
+	if !errors.Is(err, ErrSubscriptionRejected) {
+		t.Fatalf("error = %v, want ErrSubscriptionRejected", err)
+	}
+	if !strings.HasPrefix(err.Error(), "order updates of 0xbad: ") {
+		t.Errorf("error = %q, want it to name the user", err)
+	}
+}
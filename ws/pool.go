@@ -0,0 +1,197 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dwdwow/hl-go/constants"
+)
+
+// DefaultPoolBuffer is the queue size of a pooled client without SetBuffer
+const DefaultPoolBuffer = 1024
+
+// ErrPoolClosed is returned by pooled clients once their ConnectionPool is closed
+var ErrPoolClosed = errors.New("connection pool closed")
+
+// ConnectionPool shares one WebSocket connection between typed clients, to stay under
+// the server's limit on connections per IP. Attach clients with Client.SetPool; they keep
+// their Read API while their subscriptions are routed over the shared connection by a
+// Manager:
+//
+//	pool := ws.NewConnectionPool()
+//	defer pool.Close()
+//
+//	trades := ws.NewTradesClient("BTC")
+//	trades.SetPool(pool)
+//	book := ws.NewL2BookClient("BTC")
+//	book.SetPool(pool)
+//
+// The connection is opened by the first pooled Read. If it fails, every pooled client
+// gets the error, and a pooled client with a reconnect policy opens a new shared
+// connection on its next attempt.
+//
+// Clients sharing a subscription receive the same messages, but only the first one gets
+// the snapshot the server sends when subscribing, e.g. the isSnapshot batch of userFills.
+// Safe for concurrent use.
+type ConnectionPool struct {
+	url       string
+	configure func(*Manager)
+
+	mu      sync.Mutex
+	manager *Manager
+	closed  bool
+}
+
+// NewConnectionPool creates a connection pool for mainnet
+func NewConnectionPool() *ConnectionPool {
+	return &ConnectionPool{url: MainnetWsURL}
+}
+
+// NewConnectionPoolForNetwork creates a connection pool connecting to the WebSocket URL of network
+func NewConnectionPoolForNetwork(network constants.Network) *ConnectionPool {
+	return &ConnectionPool{url: network.WsURL}
+}
+
+// NewConnectionPoolWithURL creates a connection pool connecting to url, e.g. a local node
+func NewConnectionPoolWithURL(url string) *ConnectionPool {
+	return &ConnectionPool{url: url}
+}
+
+// SetConfigure sets a function applied to each Manager of the pool before it starts,
+// e.g. to set a dialer or a read timeout. Must be called before the first pooled Read.
+func (p *ConnectionPool) SetConfigure(fn func(m *Manager)) {
+	p.configure = fn
+}
+
+// Manager returns the Manager of the shared connection, connecting if needed, e.g. to
+// register untyped subscriptions next to the pooled clients
+func (p *ConnectionPool) Manager() (*Manager, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	if p.manager != nil {
+		select {
+		case <-p.manager.Done():
+			// The connection failed, replace it
+		default:
+			return p.manager, nil
+		}
+	}
+
+	m := newManager(p.url)
+	if p.configure != nil {
+		p.configure(m)
+	}
+	if err := m.Start(); err != nil {
+		return nil, err
+	}
+	p.manager = m
+	return m, nil
+}
+
+// Close closes the shared connection. Pooled clients then fail with ErrPoolClosed.
+func (p *ConnectionPool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	m := p.manager
+	p.manager = nil
+	p.mu.Unlock()
+
+	if m == nil {
+		return nil
+	}
+	return m.Stop()
+}
+
+// isClosed reports whether Close was called
+func (p *ConnectionPool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// pooledSubscription is a subscription of a pooled client on a Manager
+type pooledSubscription struct {
+	manager      *Manager
+	subscription Subscription
+	id           int
+}
+
+// poolFrame rebuilds the frame of a message routed by a Manager
+func poolFrame(msg Message) []byte {
+	channel, _ := json.Marshal(msg.Channel)
+	frame := make([]byte, 0, len(channel)+len(msg.Data)+22)
+	frame = append(frame, `{"channel":`...)
+	frame = append(frame, channel...)
+	frame = append(frame, `,"data":`...)
+	frame = append(frame, msg.Data...)
+	return append(frame, '}')
+}
+
+// startPooled subscribes the client on the shared connection of its pool
+func (c *Client[T]) startPooled() error {
+	subs := c.subscriptions
+	if err := validateSubscriptions(subs); err != nil {
+		return err
+	}
+	m, err := c.pool.Manager()
+	if err != nil {
+		return fmt.Errorf("failed to connect to websocket: %w", err)
+	}
+
+	size := c.bufferSize
+	if size <= 0 {
+		size = DefaultPoolBuffer
+	}
+	queue := newFrameQueue(size, c.overflowPolicy, &c.dropped)
+	c.metrics.reset()
+	tap := c.rawTap
+	for _, sub := range subs {
+		id, err := m.Subscribe(sub, func(msg Message) {
+			frame := poolFrame(msg)
+			c.metrics.record(frame)
+			if tap != nil {
+				tap(frame)
+			}
+			queue.push(frame, nil)
+		})
+		if err != nil {
+			c.stopPooled()
+			queue.close()
+			return fmt.Errorf("failed to send subscription: %w", err)
+		}
+		c.poolSubs = append(c.poolSubs, pooledSubscription{manager: m, subscription: sub, id: id})
+	}
+	c.queue = queue
+	c.isConnected = true
+	// The Manager does not route acknowledgments
+	c.acks.expect(nil)
+
+	// Fail reads when the shared connection stops
+	ctx, pool := c.ctx, c.pool
+	go func() {
+		select {
+		case <-m.Done():
+			err := ErrPoolClosed
+			if mErr := m.Err(); mErr != nil && !pool.isClosed() {
+				err = fmt.Errorf("shared connection failed: %w", mErr)
+			}
+			queue.fail(err)
+		case <-ctx.Done():
+		}
+	}()
+	return nil
+}
+
+// stopPooled removes the subscriptions of the client from the shared connection
+func (c *Client[T]) stopPooled() {
+	for _, sub := range c.poolSubs {
+		sub.manager.Unsubscribe(sub.subscription, sub.id)
+	}
+	c.poolSubs = nil
+}
@@ -0,0 +1,115 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// poolTestServer answers the trades and allMids subscriptions of each connection,
+// then runs after, which returns when the connection should end
+func poolTestServer(t *testing.T, after func(conn *websocket.Conn, index int)) *testServer {
+	return newTestServer(t, func(conn *websocket.Conn, index int) {
+		for i := 0; i < 2; i++ {
+			readSubscription(t, conn)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"trades","data":[{"coin":"BTC","side":"B","px":"100","sz":"1","time":1,"hash":"0x","tid":1}]}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"100"}}}`))
+		after(conn, index)
+	})
+}
+
+func TestConnectionPoolSharesConnection(t *testing.T) {
+	server := poolTestServer(t, func(conn *websocket.Conn, index int) {
+		conn.ReadMessage()
+	})
+
+	pool := NewConnectionPoolWithURL(server.URL())
+	trades := NewTradesClient("BTC")
+	trades.SetPool(pool)
+	mids := NewAllMidsClient()
+	mids.SetPool(pool)
+
+	// Both subscriptions are needed before the server sends anything
+	midsRead := make(chan AllMids, 1)
+	go func() {
+		m, err := mids.Read()
+		if err != nil {
+			t.Errorf("allMids Read() error = %v", err)
+		}
+		midsRead <- m
+	}()
+	got, err := trades.Read()
+	if err != nil {
+		t.Fatalf("trades Read() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Coin != "BTC" || got[0].Px != 100 {
+		t.Errorf("trades = %+v", got)
+	}
+	if m := <-midsRead; m.Mids["BTC"] != "100" {
+		t.Errorf("mids = %+v", m)
+	}
+	if server.Connections() != 1 {
+		t.Errorf("server saw %d connections, want 1", server.Connections())
+	}
+
+	mids.Close()
+	pool.Close()
+	if _, err := trades.Read(); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Read() after pool Close error = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestConnectionPoolReconnects(t *testing.T) {
+	server := poolTestServer(t, func(conn *websocket.Conn, index int) {
+		if index == 0 {
+			// Give both clients time to read before dropping the connection
+			time.Sleep(50 * time.Millisecond)
+			return
+		}
+		conn.ReadMessage()
+	})
+
+	pool := NewConnectionPoolWithURL(server.URL())
+	defer pool.Close()
+	trades := NewTradesClient("BTC")
+	trades.SetPool(pool)
+	mids := NewAllMidsClient()
+	mids.SetPool(pool)
+	for _, c := range []interface{ SetReconnectPolicy(*ReconnectPolicy) }{trades, mids} {
+		c.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond})
+	}
+	defer trades.Close()
+	defer mids.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 2; i++ {
+			if _, err := mids.Read(); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i := 0; i < 2; i++ {
+		if _, err := trades.Read(); err != nil {
+			t.Fatalf("trades Read() %d error = %v", i, err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("allMids Read() error = %v", err)
+	}
+	if server.Connections() != 2 {
+		t.Errorf("server saw %d connections, want 2", server.Connections())
+	}
+}
+
+func TestPoolFrame(t *testing.T) {
+	frame := poolFrame(Message{Channel: "allMids", Data: []byte(`{"mids":{}}`)})
+	if string(frame) != `{"channel":"allMids","data":{"mids":{}}}` {
+		t.Errorf("poolFrame() = %s", frame)
+	}
+}
@@ -0,0 +1,76 @@
+package ws
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// ReconnectPolicy controls how a Client re-dials after the connection is lost.
+//
+// Delays grow exponentially from InitialBackoff by Multiplier up to MaxBackoff,
+// with up to Jitter (a fraction of the delay) added or removed at random so that
+// many clients do not reconnect in lockstep.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of consecutive attempts before giving up, 0 for no limit
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first attempt
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+
+	// Multiplier is the growth factor of the delay between attempts
+	Multiplier float64
+
+	// Jitter is the maximum random fraction added to or removed from each delay (0-1)
+	Jitter float64
+}
+
+// DefaultReconnectPolicy returns a policy with 10 attempts, starting at 500ms and capped at 30s
+func DefaultReconnectPolicy() *ReconnectPolicy {
+	return &ReconnectPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Backoff returns the delay before the given attempt (starting at 1)
+func (p *ReconnectPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	return time.Duration(delay)
+}
+
+// ReconnectEvent reports a reconnection attempt
+type ReconnectEvent struct {
+	// Attempt is the number of the attempt since the connection was lost, starting at 1
+	Attempt int
+
+	// Cause is the error that closed the previous connection
+	Cause error
+
+	// Delay is the backoff waited before this attempt
+	Delay time.Duration
+
+	// Err is nil if the attempt reconnected and resubscribed, otherwise why it failed
+	Err error
+}
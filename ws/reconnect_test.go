@@ -0,0 +1,85 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientReconnectsAndResubscribes(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		sub := readSubscription(t, conn)
+		if sub["type"] != "allMids" {
+			t.Errorf("connection %d: subscription = %v", index, sub)
+		}
+		mids := `{"channel":"allMids","data":{"mids":{"BTC":"` + []string{"1", "2"}[index%2] + `"}}}`
+		conn.WriteMessage(websocket.TextMessage, []byte(mids))
+		if index == 0 {
+			// Drop the first connection right after the first message
+			return
+		}
+		// Keep later connections open until the client closes
+		conn.ReadMessage()
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond})
+	var events []ReconnectEvent
+	client.OnReconnect(func(e ReconnectEvent) {
+		events = append(events, e)
+	})
+	defer client.Close()
+
+	for _, want := range []string{"1", "2"} {
+		mids, err := client.Read()
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if mids.Mids["BTC"] != want {
+			t.Errorf("Read() BTC mid = %s, want %s", mids.Mids["BTC"], want)
+		}
+	}
+
+	if server.Connections() != 2 {
+		t.Errorf("server saw %d connections, want 2", server.Connections())
+	}
+	if len(events) != 1 || events[0].Attempt != 1 || events[0].Err != nil || events[0].Cause == nil {
+		t.Errorf("reconnect events = %+v", events)
+	}
+}
+
+func TestClientGivesUpAfterMaxAttempts(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	attempts := 0
+	client.OnReconnect(func(ReconnectEvent) { attempts++ })
+
+	// Every connection is dropped right after subscribing
+	if _, err := client.Read(); err == nil {
+		t.Fatal("Read() expected error")
+	}
+	if attempts != 2 {
+		t.Errorf("reconnect attempts = %d, want 2", attempts)
+	}
+}
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	policy := &ReconnectPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := policy.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("Backoff(1) with jitter = %v", got)
+		}
+	}
+}
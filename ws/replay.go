@@ -0,0 +1,339 @@
+package ws
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultReplayStartDelay is how long a ReplayServer waits after the first subscription
+// of a connection before replaying, so the other subscriptions of the client are in
+const DefaultReplayStartDelay = 100 * time.Millisecond
+
+// RecordedFrame is a raw frame with the time it was received
+type RecordedFrame struct {
+	Time  time.Time
+	Frame []byte
+}
+
+// recordedLine is the JSON line of a recorded frame. Frames are stored as strings since
+// some, such as the greeting of the server, are not JSON.
+type recordedLine struct {
+	Time  time.Time `json:"time"`
+	Frame string    `json:"frame"`
+}
+
+// Recorder writes raw frames with their time to an io.Writer, one JSON object per line,
+// for use as a raw tap. ReadRecording reads them back for a ReplayServer:
+//
+//	f, _ := os.Create("session.jsonl")
+//	rec := ws.NewRecorder(f)
+//	trades.SetRawTap(rec.Tap)
+//	book.SetRawTap(rec.Tap)
+//
+// Safe for concurrent use, so several clients can share one Recorder.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder creates a Recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Tap writes frame with the current time. After a write error, frames are discarded
+// and the error is reported by Err.
+func (r *Recorder) Tap(frame []byte) {
+	line := recordedLine{Time: time.Now(), Frame: string(frame)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err := r.enc.Encode(line); err != nil {
+		r.err = fmt.Errorf("failed to write frame: %w", err)
+	}
+}
+
+// Err returns the first write error, if any
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReadRecording reads the frames written by a Recorder
+func ReadRecording(r io.Reader) ([]RecordedFrame, error) {
+	var frames []RecordedFrame
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var line recordedLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("failed to parse recorded frame %d: %w", n, err)
+		}
+		frames = append(frames, RecordedFrame{Time: line.Time, Frame: []byte(line.Frame)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return frames, nil
+}
+
+// ReplayServer serves recorded frames over WebSocket to the same typed clients that
+// received them, pointed at it with SetURL, for deterministic backtests and regression
+// tests of strategy code:
+//
+//	frames, _ := ws.ReadRecording(f)
+//	replay := ws.NewReplayServer(frames)
+//	replay.SetSpeed(10)
+//	if err := replay.Start(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer replay.Close()
+//
+//	trades := ws.NewTradesClient("BTC")
+//	trades.SetURL(replay.URL())
+//
+// Each connection gets the whole recording from the start, filtered by its
+// subscriptions, and is closed with a normal closure once the recording ends. Pings and
+// subscriptions are answered, while the pongs and acknowledgments of the recording are
+// not replayed. Feeds replayed over separate connections are not in step; share a
+// ConnectionPool between the clients to keep the recorded order across feeds.
+type ReplayServer struct {
+	frames     []RecordedFrame
+	speed      float64
+	startDelay time.Duration
+
+	listener net.Listener
+	server   *http.Server
+
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewReplayServer creates a server replaying frames at their original speed
+func NewReplayServer(frames []RecordedFrame) *ReplayServer {
+	return &ReplayServer{
+		frames:     frames,
+		speed:      1,
+		startDelay: DefaultReplayStartDelay,
+		done:       make(chan struct{}),
+	}
+}
+
+// SetSpeed sets how many times faster than recorded the frames are replayed, e.g. 10.
+// Zero replays them as fast as possible. Must be called before Start.
+func (s *ReplayServer) SetSpeed(speed float64) {
+	s.speed = speed
+}
+
+// SetStartDelay sets how long the server waits after the first subscription of a
+// connection before replaying. Defaults to DefaultReplayStartDelay. Must be called before Start.
+func (s *ReplayServer) SetStartDelay(delay time.Duration) {
+	s.startDelay = delay
+}
+
+// Start listens on a local port, see URL
+func (s *ReplayServer) Start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	s.listener = listener
+	s.server = &http.Server{Handler: http.HandlerFunc(s.serve)}
+	go s.server.Serve(listener)
+	return nil
+}
+
+// URL returns the WebSocket URL of the server once started
+func (s *ReplayServer) URL() string {
+	return "ws://" + s.listener.Addr().String()
+}
+
+// Close stops the server, closing the connections being replayed
+func (s *ReplayServer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.mu.Unlock()
+
+	err := s.server.Close()
+	s.wg.Wait()
+	return err
+}
+
+// replayConn is a connection being replayed
+type replayConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	subscriptions map[string]bool
+	subscribed    chan struct{}
+	closed        chan struct{}
+}
+
+func (s *ReplayServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rc := &replayConn{
+		conn:          conn,
+		subscriptions: make(map[string]bool),
+		subscribed:    make(chan struct{}),
+		closed:        make(chan struct{}),
+	}
+	go rc.readRequests()
+
+	select {
+	case <-rc.subscribed:
+	case <-rc.closed:
+		return
+	case <-s.done:
+		return
+	}
+	if !s.wait(rc, time.Now().Add(s.startDelay)) {
+		return
+	}
+
+	start := time.Now()
+	for _, frame := range s.frames {
+		if s.speed > 0 {
+			offset := float64(frame.Time.Sub(s.frames[0].Time)) / s.speed
+			if !s.wait(rc, start.Add(time.Duration(offset))) {
+				return
+			}
+		}
+		if rc.routes(frame.Frame) {
+			if err := rc.write(websocket.TextMessage, frame.Frame); err != nil {
+				return
+			}
+		}
+	}
+
+	rc.writeMu.Lock()
+	rc.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "end of replay"), time.Now().Add(time.Second))
+	rc.writeMu.Unlock()
+	select {
+	case <-rc.closed:
+	case <-time.After(time.Second):
+	}
+}
+
+// wait waits until at, returning false if the connection or the server closed first
+func (s *ReplayServer) wait(rc *replayConn, at time.Time) bool {
+	delay := time.Until(at)
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-rc.closed:
+		return false
+	case <-s.done:
+		return false
+	}
+}
+
+// readRequests answers the pings and records the subscriptions of the client
+func (rc *replayConn) readRequests() {
+	defer close(rc.closed)
+	var once sync.Once
+	for {
+		_, data, err := rc.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req struct {
+			Method       string       `json:"method"`
+			Subscription Subscription `json:"subscription"`
+		}
+		if json.Unmarshal(data, &req) != nil {
+			continue
+		}
+		switch req.Method {
+		case "ping":
+			rc.write(websocket.TextMessage, []byte(pongFrame))
+		case "subscribe", "unsubscribe":
+			identifier, err := subscriptionIdentifier(req.Subscription)
+			if err != nil {
+				rc.write(websocket.TextMessage, []byte(fmt.Sprintf(`{"channel":"error","data":%q}`, err.Error())))
+				continue
+			}
+			rc.mu.Lock()
+			if req.Method == "subscribe" {
+				rc.subscriptions[identifier] = true
+			} else {
+				delete(rc.subscriptions, identifier)
+			}
+			rc.mu.Unlock()
+
+			ack, _ := json.Marshal(map[string]any{
+				"channel": "subscriptionResponse",
+				"data":    map[string]any{"method": req.Method, "subscription": req.Subscription},
+			})
+			rc.write(websocket.TextMessage, ack)
+			if req.Method == "subscribe" {
+				once.Do(func() { close(rc.subscribed) })
+			}
+		}
+	}
+}
+
+// routes reports whether frame belongs to a subscription of the connection
+func (rc *replayConn) routes(frame []byte) bool {
+	var msg wsMessage
+	if len(frame) == 0 || frame[0] != '{' || json.Unmarshal(frame, &msg) != nil {
+		return false
+	}
+	identifier, ok := messageIdentifier(msg.Channel, msg.Data)
+	if !ok {
+		return false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.subscriptions[identifier]
+}
+
+func (rc *replayConn) write(messageType int, data []byte) error {
+	rc.writeMu.Lock()
+	defer rc.writeMu.Unlock()
+	return rc.conn.WriteMessage(messageType, data)
+}
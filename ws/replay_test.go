@@ -0,0 +1,89 @@
+package ws
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRecorderRoundTrip(t *testing.T) {
+	frames := []string{
+		"Websocket connection established.",
+		`{"channel":"trades","data":[{"coin":"BTC","px":"100","sz":"1","time":1}]}`,
+	}
+	var out bytes.Buffer
+	rec := NewRecorder(&out)
+	for _, frame := range frames {
+		rec.Tap([]byte(frame))
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	got, err := ReadRecording(&out)
+	if err != nil {
+		t.Fatalf("ReadRecording() error = %v", err)
+	}
+	if len(got) != len(frames) {
+		t.Fatalf("ReadRecording() = %d frames, want %d", len(got), len(frames))
+	}
+	for i, frame := range got {
+		if string(frame.Frame) != frames[i] || frame.Time.IsZero() {
+			t.Errorf("frame %d = %q at %v", i, frame.Frame, frame.Time)
+		}
+	}
+	if i := len(got) - 1; got[i].Time.Before(got[0].Time) {
+		t.Error("frames out of order")
+	}
+}
+
+func TestReplayServer(t *testing.T) {
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	frames := []RecordedFrame{
+		{at(0), []byte("Websocket connection established.")},
+		{at(0), []byte(`{"channel":"subscriptionResponse","data":{"method":"subscribe","subscription":{"type":"trades","coin":"BTC"}}}`)},
+		{at(100), []byte(`{"channel":"trades","data":[{"coin":"BTC","px":"100","sz":"1","time":1}]}`)},
+		{at(200), []byte(`{"channel":"trades","data":[{"coin":"ETH","px":"10","sz":"1","time":2}]}`)},
+		{at(300), []byte(pongFrame)},
+		{at(1000), []byte(`{"channel":"trades","data":[{"coin":"BTC","px":"101","sz":"2","time":3}]}`)},
+	}
+	replay := NewReplayServer(frames)
+	replay.SetSpeed(10)
+	replay.SetStartDelay(0)
+	if err := replay.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer replay.Close()
+
+	for run := 0; run < 2; run++ {
+		trades := NewTradesClient("BTC")
+		trades.SetURL(replay.URL())
+
+		began := time.Now()
+		var prices []float64
+		for len(prices) < 2 {
+			batch, err := trades.Read()
+			if err != nil {
+				t.Fatalf("run %d: Read() error = %v", run, err)
+			}
+			for _, trade := range batch {
+				prices = append(prices, trade.Px)
+			}
+		}
+		// The last trade comes 1s into the recording, 100ms at 10 times the speed
+		if elapsed := time.Since(began); elapsed < 80*time.Millisecond || elapsed > time.Second {
+			t.Errorf("run %d: replay took %v, want about 100ms", run, elapsed)
+		}
+		if prices[0] != 100 || prices[1] != 101 {
+			t.Errorf("run %d: prices = %v, want [100 101]", run, prices)
+		}
+
+		_, err := trades.Read()
+		if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			t.Errorf("run %d: Read() at the end = %v, want a normal closure", run, err)
+		}
+	}
+}
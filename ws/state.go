@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ConnState is the connection state of a client
+type ConnState int32
+
+const (
+	// StateDisconnected means the client is not connected, either not started yet or
+	// after an error; the next Read or Start dials again
+	StateDisconnected ConnState = iota
+
+	// StateConnecting means the client is dialing and subscribing
+	StateConnecting
+
+	// StateConnected means the client is connected and subscribed
+	StateConnected
+
+	// StateReconnecting means the connection was lost and the reconnect policy is retrying
+	StateReconnecting
+
+	// StateClosed means Close was called
+	StateClosed
+)
+
+// String returns the name of the state
+func (s ConnState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("ConnState(%d)", int32(s))
+	}
+}
+
+// lifecycle tracks the connection state of a client and runs its lifecycle hooks
+type lifecycle struct {
+	state atomic.Int32
+
+	hooksMu      sync.Mutex
+	onConnect    func()
+	onDisconnect func(error)
+}
+
+func (l *lifecycle) current() ConnState {
+	return ConnState(l.state.Load())
+}
+
+func (l *lifecycle) set(state ConnState) {
+	l.state.Store(int32(state))
+}
+
+func (l *lifecycle) setHooks(onConnect func(), onDisconnect func(error)) {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+	if onConnect != nil {
+		l.onConnect = onConnect
+	}
+	if onDisconnect != nil {
+		l.onDisconnect = onDisconnect
+	}
+}
+
+// connected moves to StateConnected and runs the OnConnect hook
+func (l *lifecycle) connected() {
+	l.set(StateConnected)
+	l.hooksMu.Lock()
+	fn := l.onConnect
+	l.hooksMu.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// disconnected moves to state and runs the OnDisconnect hook if the client was connected
+func (l *lifecycle) disconnected(state ConnState, cause error) {
+	prev := ConnState(l.state.Swap(int32(state)))
+	if prev != StateConnected {
+		return
+	}
+	l.hooksMu.Lock()
+	fn := l.onDisconnect
+	l.hooksMu.Unlock()
+	if fn != nil {
+		fn(cause)
+	}
+}
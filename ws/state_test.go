@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientLifecycleHooks(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		if index == 0 {
+			// Drop the first connection right after the first message
+			return
+		}
+		conn.ReadMessage()
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond})
+	var (
+		mu          sync.Mutex
+		connects    int
+		disconnects []error
+	)
+	client.OnConnect(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		connects++
+	})
+	client.OnDisconnect(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		disconnects = append(disconnects, err)
+	})
+
+	if got := client.State(); got != StateDisconnected {
+		t.Errorf("State() before Read = %v, want %v", got, StateDisconnected)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Read(); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if got := client.State(); got != StateConnected {
+			t.Errorf("State() after Read = %v, want %v", got, StateConnected)
+		}
+	}
+	client.Close()
+	if got := client.State(); got != StateClosed {
+		t.Errorf("State() after Close = %v, want %v", got, StateClosed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if connects != 2 {
+		t.Errorf("OnConnect calls = %d, want 2", connects)
+	}
+	if len(disconnects) != 2 || disconnects[0] == nil || disconnects[1] != nil {
+		t.Errorf("OnDisconnect errors = %v, want [<drop error> <nil>]", disconnects)
+	}
+}
+
+func TestPostOnlyClientLifecycleHooks(t *testing.T) {
+	drop := make(chan struct{})
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		<-drop
+	})
+
+	client := NewPostOnlyClient()
+	client.url = server.URL()
+	connected := make(chan struct{}, 1)
+	disconnected := make(chan error, 1)
+	client.OnConnect(func() { connected <- struct{}{} })
+	client.OnDisconnect(func(err error) { disconnected <- err })
+
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Close()
+	select {
+	case <-connected:
+	default:
+		t.Error("OnConnect not called by Start")
+	}
+	if got := client.State(); got != StateConnected {
+		t.Errorf("State() after Start = %v, want %v", got, StateConnected)
+	}
+
+	close(drop)
+	select {
+	case err := <-disconnected:
+		if err == nil {
+			t.Error("OnDisconnect error = nil, want the read error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnDisconnect not called after the server dropped the connection")
+	}
+	if got := client.State(); got != StateDisconnected {
+		t.Errorf("State() after drop = %v, want %v", got, StateDisconnected)
+	}
+	if _, err := client.Request(PostRequestTypeInfo, map[string]any{"type": "allMids"}); err == nil {
+		t.Error("Request() after drop expected error")
+	}
+}
+
+func TestConnStateString(t *testing.T) {
+	if got := StateReconnecting.String(); got != "reconnecting" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := ConnState(42).String(); got != "ConnState(42)" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package ws
+
+import "context"
+
+// Stream reads the feed in a background goroutine and delivers each message on
+// the returned data channel, so several feeds can be consumed from one select:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//
+//	trades, tradeErrs := ws.NewTradesClient("BTC").Stream(ctx)
+//	books, bookErrs := ws.NewL2BookClient("BTC").Stream(ctx)
+//	for {
+//	    select {
+//	    case t := <-trades:
+//	        // Process trades...
+//	    case b := <-books:
+//	        // Process order book...
+//	    case err := <-tradeErrs:
+//	        log.Fatal(err)
+//	    case err := <-bookErrs:
+//	        log.Fatal(err)
+//	    }
+//	}
+//
+// The stream ends when ctx is done or a read fails (after any reconnection allowed
+// by the reconnect policy). The error channel then receives exactly one error,
+// ctx.Err() on cancellation, the client is closed and both channels are closed.
+//
+// The client must not be used by other goroutines while the stream is running.
+func (c *Client[T]) Stream(ctx context.Context) (<-chan T, <-chan error) {
+	return stream[T](ctx, c)
+}
+
+// stream implements Stream for any Reader
+func stream[T any](ctx context.Context, r Reader[T]) (<-chan T, <-chan error) {
+	out := make(chan T)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(out)
+
+		for {
+			data, err := r.ReadContext(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case out <- data:
+			case <-ctx.Done():
+				r.Close()
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return out, errs
+}
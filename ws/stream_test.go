@@ -0,0 +1,105 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientStream(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"2"}}}`))
+		// Stay idle until the client goes away
+		conn.ReadMessage()
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data, errs := client.Stream(ctx)
+	for _, want := range []string{"1", "2"} {
+		select {
+		case mids := <-data:
+			if mids.Mids["BTC"] != want {
+				t.Errorf("BTC mid = %s, want %s", mids.Mids["BTC"], want)
+			}
+		case err := <-errs:
+			t.Fatalf("stream error = %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for data")
+		}
+	}
+
+	// Cancelling interrupts the idle read
+	cancel()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("stream error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for stream to stop")
+	}
+	if _, ok := <-data; ok {
+		t.Error("data channel still open after stream stopped")
+	}
+}
+
+func TestClientStreamReportsReadError(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	_, errs := client.Stream(context.Background())
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("stream error = nil, want connection error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for stream error")
+	}
+}
+
+func TestClientReadContextDeadline(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`))
+		conn.ReadMessage()
+	})
+
+	client := newClient[AllMids](server.URL(), AllMidsSubscription())
+	client.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.ReadContext(ctx); err != nil {
+		t.Fatalf("ReadContext() error = %v", err)
+	}
+
+	// The feed is idle, so the next read runs into the deadline instead of reconnecting
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.ReadContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ReadContext() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ReadContext() returned after %v", elapsed)
+	}
+	if server.Connections() != 1 {
+		t.Errorf("server saw %d connections, want 1", server.Connections())
+	}
+
+	if _, err := client.ReadContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadContext() with expired ctx error = %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package ws
+
+// Builders of the subscriptions of each feed, for NewClient and Manager.Subscribe
+
+// AllMidsSubscription returns the subscription to the mid prices of the default dex
+func AllMidsSubscription() Subscription {
+	return Subscription{Type: SubscriptionAllMids}
+}
+
+// DexAllMidsSubscription returns the subscription to the mid prices of a
+// builder-deployed perp dex
+func DexAllMidsSubscription(dex string) Subscription {
+	return Subscription{Type: SubscriptionAllMids, Dex: &dex}
+}
+
+// L2BookSubscription returns the subscription to the order book of coin
+func L2BookSubscription(coin string) Subscription {
+	return Subscription{Type: SubscriptionL2Book, Coin: &coin}
+}
+
+// TradesSubscription returns the subscription to the trades of coin
+func TradesSubscription(coin string) Subscription {
+	return Subscription{Type: SubscriptionTrades, Coin: &coin}
+}
+
+// BBOSubscription returns the subscription to the best bid and offer of coin
+func BBOSubscription(coin string) Subscription {
+	return Subscription{Type: SubscriptionBBO, Coin: &coin}
+}
+
+// CandleSubscription returns the subscription to the candles of coin at interval
+func CandleSubscription(coin string, interval Interval) Subscription {
+	name := string(interval)
+	return Subscription{Type: SubscriptionCandle, Coin: &coin, Interval: &name}
+}
+
+// ActiveAssetCtxSubscription returns the subscription to the asset context of coin
+func ActiveAssetCtxSubscription(coin string) Subscription {
+	return Subscription{Type: SubscriptionActiveAssetCtx, Coin: &coin}
+}
+
+// ActiveAssetDataSubscription returns the subscription to the trading data of user on coin
+func ActiveAssetDataSubscription(user, coin string) Subscription {
+	return Subscription{Type: SubscriptionActiveAssetData, User: &user, Coin: &coin}
+}
+
+// UserEventsSubscription returns the subscription to the trading events of user
+func UserEventsSubscription(user string) Subscription {
+	return userSubscription(SubscriptionUserEvents, user)
+}
+
+// UserFillsSubscription returns the subscription to the fills of user
+func UserFillsSubscription(user string) Subscription {
+	return userSubscription(SubscriptionUserFills, user)
+}
+
+// OrderUpdatesSubscription returns the subscription to the order updates of user
+func OrderUpdatesSubscription(user string) Subscription {
+	return userSubscription(SubscriptionOrderUpdates, user)
+}
+
+// UserFundingsSubscription returns the subscription to the funding payments of user
+func UserFundingsSubscription(user string) Subscription {
+	return userSubscription(SubscriptionUserFundings, user)
+}
+
+// UserNonFundingLedgerUpdatesSubscription returns the subscription to the non-funding
+// ledger updates of user
+func UserNonFundingLedgerUpdatesSubscription(user string) Subscription {
+	return userSubscription(SubscriptionUserNonFundingLedgerUpdates, user)
+}
+
+// WebData2Subscription returns the subscription to the aggregate account data of user
+func WebData2Subscription(user string) Subscription {
+	return userSubscription(SubscriptionWebData2, user)
+}
+
+// UserTwapSliceFillsSubscription returns the subscription to the TWAP slice fills of user
+func UserTwapSliceFillsSubscription(user string) Subscription {
+	return userSubscription(SubscriptionUserTwapSliceFills, user)
+}
+
+// UserTwapHistorySubscription returns the subscription to the TWAP orders of user
+func UserTwapHistorySubscription(user string) Subscription {
+	return userSubscription(SubscriptionUserTwapHistory, user)
+}
+
+// NotificationSubscription returns the subscription to the notifications of user
+func NotificationSubscription(user string) Subscription {
+	return userSubscription(SubscriptionNotification, user)
+}
+
+// coinSubscriptions returns the subscription of build for each coin
+func coinSubscriptions(build func(coin string) Subscription, coins []string) []Subscription {
+	subs := make([]Subscription, len(coins))
+	for i, coin := range coins {
+		subs[i] = build(coin)
+	}
+	return subs
+}
+
+func userSubscription(subType SubscriptionType, user string) Subscription {
+	return Subscription{Type: subType, User: &user}
+}
+
+// dedupeSubscriptions removes the subscriptions identical to an earlier one, such as
+// a coin listed twice, keeping the order of the others
+func dedupeSubscriptions(subs []Subscription) []Subscription {
+	seen := make(map[string]bool, len(subs))
+	result := make([]Subscription, 0, len(subs))
+	for _, sub := range subs {
+		identifier, err := subscriptionIdentifier(sub)
+		if err != nil {
+			// Invalid subscriptions fail when the client starts
+			result = append(result, sub)
+			continue
+		}
+		if !seen[identifier] {
+			seen[identifier] = true
+			result = append(result, sub)
+		}
+	}
+	return result
+}
+
+// validateSubscriptions checks that the server can route the messages of subs, which
+// also rejects unknown candle intervals
+func validateSubscriptions(subs []Subscription) error {
+	for _, sub := range subs {
+		if _, err := subscriptionIdentifier(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscriptionBuilders(t *testing.T) {
+	tests := []struct {
+		sub  Subscription
+		want string
+	}{
+		{AllMidsSubscription(), `{"type":"allMids"}`},
+		{DexAllMidsSubscription("xyz"), `{"type":"allMids","dex":"xyz"}`},
+		{CandleSubscription("BTC", Interval15m), `{"type":"candle","coin":"BTC","interval":"15m"}`},
+		{ActiveAssetDataSubscription("0x1", "ETH"), `{"type":"activeAssetData","coin":"ETH","user":"0x1"}`},
+		{UserFillsSubscription("0x1"), `{"type":"userFills","user":"0x1"}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.sub)
+		if err != nil {
+			t.Fatalf("Marshal(%s) error = %v", tt.sub.Type, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Marshal(%s) = %s, want %s", tt.sub.Type, got, tt.want)
+		}
+	}
+}
+
+func TestClientDedupesSubscriptions(t *testing.T) {
+	if subs := NewTradesClient("BTC", "ETH", "btc").Subscriptions(); len(subs) != 2 {
+		t.Errorf("Subscriptions() = %d, want 2", len(subs))
+	}
+
+	received := make(chan map[string]any, 10)
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				close(received)
+				return
+			}
+			received <- msg
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"l2Book","data":{"coin":"BTC","time":1,"levels":[[],[]]}}`))
+		}
+	})
+	client := NewClient[WsBook](L2BookSubscription("BTC"), L2BookSubscription("BTC"), L2BookSubscription("ETH"))
+	client.SetURL(server.URL())
+	if _, err := client.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	client.Close()
+
+	var coins []string
+	for msg := range received {
+		if msg["method"] == "subscribe" {
+			sub, _ := msg["subscription"].(map[string]any)
+			coin, _ := sub["coin"].(string)
+			coins = append(coins, coin)
+		}
+	}
+	if len(coins) != 2 || coins[0] != "BTC" || coins[1] != "ETH" {
+		t.Errorf("subscribed to %v, want [BTC ETH]", coins)
+	}
+}
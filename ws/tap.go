@@ -0,0 +1,45 @@
+package ws
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// FrameWriter writes raw frames to an io.Writer, one per line, for use as a raw tap:
+//
+//	f, _ := os.Create("frames.jsonl")
+//	fw := ws.NewFrameWriter(f)
+//	client.SetRawTap(fw.Tap)
+//
+// Safe for concurrent use, so several clients can share one FrameWriter.
+type FrameWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewFrameWriter creates a FrameWriter writing to w
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{w: w}
+}
+
+// Tap writes frame followed by a newline. After a write error, frames are discarded
+// and the error is reported by Err.
+func (fw *FrameWriter) Tap(frame []byte) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.err != nil {
+		return
+	}
+	if _, err := fw.w.Write(append(frame[:len(frame):len(frame)], '\n')); err != nil {
+		fw.err = fmt.Errorf("failed to write frame: %w", err)
+	}
+}
+
+// Err returns the first write error, if any
+func (fw *FrameWriter) Err() error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.err
+}
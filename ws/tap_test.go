@@ -0,0 +1,66 @@
+package ws
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientRawTap(t *testing.T) {
+	frames := []string{
+		pongFrame,
+		`{"channel":"allMids","data":{"mids":{"BTC":"1"}}}`,
+	}
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		for _, frame := range frames {
+			conn.WriteMessage(websocket.TextMessage, []byte(frame))
+		}
+		conn.ReadMessage()
+	})
+
+	for _, buffered := range []bool{false, true} {
+		var out bytes.Buffer
+		fw := NewFrameWriter(&out)
+		client := newClient[AllMids](server.URL(), AllMidsSubscription())
+		if buffered {
+			client.SetBuffer(8, OverflowBlock)
+		}
+		client.SetRawTap(fw.Tap)
+
+		if _, err := client.Read(); err != nil {
+			t.Fatalf("buffered=%v: Read() error = %v", buffered, err)
+		}
+		client.Close()
+
+		if got, want := out.String(), strings.Join(frames, "\n")+"\n"; got != want {
+			t.Errorf("buffered=%v: captured\n%s\nwant\n%s", buffered, got, want)
+		}
+		if err := fw.Err(); err != nil {
+			t.Errorf("buffered=%v: Err() = %v", buffered, err)
+		}
+	}
+}
+
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("disk full")
+}
+
+func TestFrameWriterStopsAfterError(t *testing.T) {
+	w := &failingWriter{}
+	fw := NewFrameWriter(w)
+	fw.Tap([]byte(`{}`))
+	fw.Tap([]byte(`{}`))
+	if w.writes != 1 {
+		t.Errorf("writes = %d, want 1", w.writes)
+	}
+	if err := fw.Err(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Err() = %v", err)
+	}
+}
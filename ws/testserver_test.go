@@ -0,0 +1,72 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// testServer is a local WebSocket server standing in for the Hyperliquid API.
+// Each accepted connection is handed to the handler, which owns it until it returns.
+type testServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	connections int
+}
+
+func newTestServer(t testing.TB, handler func(conn *websocket.Conn, index int)) *testServer {
+	t.Helper()
+
+	s := &testServer{}
+	// Compression is used only by clients asking for it
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s.mu.Lock()
+		index := s.connections
+		s.connections++
+		s.mu.Unlock()
+
+		handler(conn, index)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// URL returns the ws:// URL of the server
+func (s *testServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.Server.URL, "http")
+}
+
+// Connections returns the number of connections accepted so far
+func (s *testServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+// readSubscription reads the next subscribe message from conn
+func readSubscription(t testing.TB, conn *websocket.Conn) map[string]any {
+	t.Helper()
+
+	for {
+		var msg map[string]any
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Errorf("failed to read subscription: %v", err)
+			return nil
+		}
+		if msg["method"] == "subscribe" {
+			sub, _ := msg["subscription"].(map[string]any)
+			return sub
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dwdwow/hl-go/types"
+)
+
+const twapSliceFillsFrame = `{"channel":"userTwapSliceFills","data":{
+	"isSnapshot":true,"user":"0xAbC","twapSliceFills":[
+		{"twapId":3,"fill":{"coin":"ETH","px":"2000.5","sz":"0.1","side":"B","time":1700000000000,"startPosition":"0.0",
+			"dir":"Open Long","closedPnl":"0.0","hash":"0x01","oid":11,"crossed":true,"fee":"0.08","tid":21,"feeToken":"USDC"}},
+		{"twapId":3,"fill":{"coin":"ETH","px":"2001.0","sz":"0.1","side":"B","time":1700000030000,"startPosition":"0.1",
+			"dir":"Open Long","closedPnl":"0.0","hash":"0x02","oid":12,"crossed":true,"fee":"0.08","tid":22,"feeToken":"USDC"}}
+	]}}`
+
+func TestUserTwapSliceFillsClient(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		sub := readSubscription(t, conn)
+		if sub["type"] != "userTwapSliceFills" || sub["user"] != "0xAbC" {
+			t.Errorf("subscription = %v", sub)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(twapSliceFillsFrame))
+		conn.ReadMessage()
+	})
+
+	client := NewUserTwapSliceFillsClient("0xAbC")
+	client.url = server.URL()
+	defer client.Close()
+
+	fills, err := client.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if fills.IsSnapshot == nil || !*fills.IsSnapshot || fills.User != "0xAbC" || len(fills.TwapSliceFills) != 2 {
+		t.Fatalf("fills = %+v", fills)
+	}
+	first := fills.TwapSliceFills[0]
+	if first.TwapId != 3 || first.Fill.Coin != "ETH" || first.Fill.Px != "2000.5" || first.Fill.Side != types.SideBid ||
+		first.Fill.Oid != 11 || first.Fill.Tid != 21 || !first.Fill.Crossed {
+		t.Errorf("first slice fill = %+v", first)
+	}
+	if second := fills.TwapSliceFills[1]; second.Fill.StartPosition != "0.1" || second.Fill.Time != 1700000030000 {
+		t.Errorf("second slice fill = %+v", second)
+	}
+}
+
+func TestUserTwapSliceFillsRouting(t *testing.T) {
+	// Frames are routed by user case-insensitively, and never to another user
+	want, err := subscriptionIdentifier(UserTwapSliceFillsSubscription("0xAbC"))
+	if err != nil {
+		t.Fatalf("subscriptionIdentifier() error = %v", err)
+	}
+	if want != "userTwapSliceFills:0xabc" {
+		t.Errorf("subscriptionIdentifier() = %q", want)
+	}
+	got, ok := messageIdentifier("userTwapSliceFills", []byte(`{"user":"0xAbC","twapSliceFills":[]}`))
+	if !ok || got != want {
+		t.Errorf("messageIdentifier() = %q, %v, want %q", got, ok, want)
+	}
+	other, err := subscriptionIdentifier(UserTwapSliceFillsSubscription("0xdef"))
+	if err != nil || other == got {
+		t.Errorf("subscriptionIdentifier(0xdef) = %q, %v", other, err)
+	}
+	if _, ok := messageIdentifier("userTwapSliceFills", []byte(`[]`)); ok {
+		t.Error("messageIdentifier() routed a frame without user")
+	}
+}
+
+func TestUserTwapHistoryClient(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		sub := readSubscription(t, conn)
+		if sub["type"] != "userTwapHistory" || sub["user"] != "0xabc" {
+			t.Errorf("subscription = %v", sub)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"userTwapHistory","data":{
+			"isSnapshot":true,"user":"0xabc","history":[
+				{"time":1700000000,"status":{"status":"activated","description":""},"state":{"coin":"ETH","user":"0xabc","side":"B",
+					"sz":"1.0","executedSz":"0.2","executedNtl":"400.1","minutes":30,"reduceOnly":false,"randomize":true,"timestamp":1700000000000}},
+				{"time":1700000600,"status":{"status":"terminated","description":"Terminated by user"},"state":{"coin":"BTC","user":"0xabc","side":"A",
+					"sz":"0.1","executedSz":"0.05","executedNtl":"3000.0","minutes":60,"reduceOnly":true,"randomize":false,"timestamp":1699999000000}},
+				{"time":1700001800,"status":{"status":"finished","description":""},"state":{"coin":"SOL","user":"0xabc","side":"B",
+					"sz":"10.0","executedSz":"10.0","executedNtl":"1500.0","minutes":5,"reduceOnly":false,"randomize":false,"timestamp":1700001500000}}
+			]}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"userTwapHistory","data":{"user":"0xabc","history":[
+			{"time":1700002000,"status":{"status":"error","description":"Insufficient margin"},"state":{"coin":"ETH","user":"0xabc","side":"A",
+				"sz":"2.0","executedSz":"0.0","executedNtl":"0.0","minutes":10,"reduceOnly":false,"randomize":false,"timestamp":1700002000000}}
+		]}}`))
+		conn.ReadMessage()
+	})
+
+	client := NewUserTwapHistoryClient("0xabc")
+	client.url = server.URL()
+	defer client.Close()
+
+	history, err := client.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if history.IsSnapshot == nil || !*history.IsSnapshot || len(history.History) != 3 {
+		t.Fatalf("history = %+v", history)
+	}
+	tests := []struct {
+		status, description, coin, executedSz string
+		minutes                               int
+		reduceOnly, randomize                 bool
+	}{
+		{TwapStatusActivated, "", "ETH", "0.2", 30, false, true},
+		{TwapStatusTerminated, "Terminated by user", "BTC", "0.05", 60, true, false},
+		{TwapStatusFinished, "", "SOL", "10.0", 5, false, false},
+	}
+	for i, tt := range tests {
+		entry := history.History[i]
+		if entry.Status.Status != tt.status || entry.Status.Description != tt.description {
+			t.Errorf("history[%d].Status = %+v, want %s", i, entry.Status, tt.status)
+		}
+		state := entry.State
+		if state.Coin != tt.coin || state.ExecutedSz != tt.executedSz || state.Minutes != tt.minutes ||
+			state.ReduceOnly != tt.reduceOnly || state.Randomize != tt.randomize || state.User != "0xabc" {
+			t.Errorf("history[%d].State = %+v", i, state)
+		}
+	}
+
+	update, err := client.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if update.IsSnapshot != nil || len(update.History) != 1 || update.History[0].Status.Status != TwapStatusError ||
+		update.History[0].Status.Description != "Insufficient margin" {
+		t.Errorf("update = %+v", update)
+	}
+}
@@ -1,7 +1,14 @@
 // Package ws types defines all WebSocket message structures for Hyperliquid.
 package ws
 
-import "github.com/dwdwow/hl-go/types"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dwdwow/hl-go/types"
+)
 
 // WebSocket data type definitions based on Hyperliquid API documentation.
 //
@@ -50,15 +57,26 @@ const (
 
 	// SubscriptionWebData2 subscribes to web data for a user
 	SubscriptionWebData2 SubscriptionType = "webData2"
+
+	// SubscriptionUserTwapSliceFills subscribes to fills of a user's TWAP slices
+	SubscriptionUserTwapSliceFills SubscriptionType = "userTwapSliceFills"
+
+	// SubscriptionUserTwapHistory subscribes to the lifecycle of a user's TWAP orders
+	SubscriptionUserTwapHistory SubscriptionType = "userTwapHistory"
+
+	// SubscriptionNotification subscribes to exchange notifications for a user
+	SubscriptionNotification SubscriptionType = "notification"
 )
 
 // Subscription represents a WebSocket subscription
-// type Subscription struct {
-// 	Type     SubscriptionType `json:"type"`
-// 	Coin     *string          `json:"coin,omitempty"`
-// 	User     *string          `json:"user,omitempty"`
-// 	Interval *string          `json:"interval,omitempty"`
-// }
+type Subscription struct {
+	Type     SubscriptionType `json:"type"`
+	Coin     *string          `json:"coin,omitempty"`
+	User     *string          `json:"user,omitempty"`
+	Interval *string          `json:"interval,omitempty"`
+	// Dex selects a builder-deployed perp dex for allMids, nil for the default dex
+	Dex *string `json:"dex,omitempty"`
+}
 
 // WsTrade represents a trade update
 type WsTrade struct {
@@ -98,12 +116,42 @@ type AllMids struct {
 	Mids map[string]string `json:"mids"`
 }
 
-// Notification represents a notification message
+// DexMids represents the mid prices of one perp dex
+type DexMids struct {
+	// Dex is the name of the dex, empty for the default dex
+	Dex  string            `json:"dex"`
+	Mids map[string]string `json:"mids"`
+}
+
+// UnmarshalJSON decodes the mids, taking the dex from the coins, which are named
+// "dex:COIN" on builder-deployed dexs, when the message does not name it
+func (m *DexMids) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Dex  string            `json:"dex"`
+		Mids map[string]string `json:"mids"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Dex, m.Mids = raw.Dex, raw.Mids
+	if m.Dex == "" {
+		for coin := range raw.Mids {
+			if dex, _, ok := strings.Cut(coin, ":"); ok {
+				m.Dex = dex
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// Notification represents a notification message, such as a liquidation warning or a filled order
 type Notification struct {
 	Notification string `json:"notification"`
 }
 
-// Candle represents candlestick data
+// Candle represents candlestick data of the candle feed. OHLCV returns it in the
+// canonical form shared with candle snapshots.
 type Candle struct {
 	T  int64   `json:"t"` // open millis
 	T2 int64   `json:"T"` // close millis
@@ -151,32 +199,11 @@ type WsUserFills struct {
 	Fills      []WsFill `json:"fills"`
 }
 
-// WsFill represents a fill
-type WsFill struct {
-	Coin          string           `json:"coin"`
-	Px            string           `json:"px"` // price
-	Sz            string           `json:"sz"` // size
-	Side          string           `json:"side"`
-	Time          int64            `json:"time"`
-	StartPosition string           `json:"startPosition"`
-	Dir           string           `json:"dir"` // used for frontend display
-	ClosedPnl     string           `json:"closedPnl"`
-	Hash          string           `json:"hash"`    // L1 transaction hash
-	Oid           int64            `json:"oid"`     // order id
-	Crossed       bool             `json:"crossed"` // whether order crossed the spread (was taker)
-	Fee           string           `json:"fee"`     // negative means rebate
-	Tid           int64            `json:"tid"`     // unique trade id
-	Liquidation   *FillLiquidation `json:"liquidation,omitempty"`
-	FeeToken      string           `json:"feeToken"`             // the token the fee was paid in
-	BuilderFee    *string          `json:"builderFee,omitempty"` // amount paid to builder
-}
+// WsFill represents a fill, the same as the fills of the REST API
+type WsFill = types.Fill
 
 // FillLiquidation represents liquidation details in a fill
-type FillLiquidation struct {
-	LiquidatedUser *string `json:"liquidatedUser,omitempty"`
-	MarkPx         float64 `json:"markPx"`
-	Method         string  `json:"method"` // "market" or "backstop"
-}
+type FillLiquidation = types.FillLiquidation
 
 // WsUserFunding represents a funding payment
 type WsUserFunding struct {
@@ -213,17 +240,41 @@ type WsNonUserCancel struct {
 type WsUserNonFundingLedgerUpdates struct {
 	IsSnapshot *bool                    `json:"isSnapshot,omitempty"`
 	User       string                   `json:"user"`
-	Updates    []NonFundingLedgerUpdate `json:"updates"`
+	Updates    []NonFundingLedgerUpdate `json:"nonFundingLedgerUpdates"`
 }
 
 // NonFundingLedgerUpdate represents a ledger update (withdrawal, deposit, transfer, or liquidation)
-type NonFundingLedgerUpdate struct {
-	// Define based on actual API response structure
-	// This is a placeholder - adjust according to actual data
-	Time int64                  `json:"time"`
-	Type string                 `json:"type"`
-	Data map[string]interface{} `json:"data"`
-}
+type NonFundingLedgerUpdate = types.NonFundingLedgerUpdate
+
+// LedgerDeltaType is the kind of a ledger update
+type LedgerDeltaType = types.LedgerDeltaType
+
+// Ledger delta types, as sent in the "type" field of a delta
+const (
+	LedgerDeposit               = types.LedgerDeposit
+	LedgerWithdraw              = types.LedgerWithdraw
+	LedgerInternalTransfer      = types.LedgerInternalTransfer
+	LedgerSubAccountTransfer    = types.LedgerSubAccountTransfer
+	LedgerAccountClassTransfer  = types.LedgerAccountClassTransfer
+	LedgerSpotTransfer          = types.LedgerSpotTransfer
+	LedgerSend                  = types.LedgerSend
+	LedgerLiquidation           = types.LedgerLiquidation
+	LedgerVaultCreate           = types.LedgerVaultCreate
+	LedgerVaultDeposit          = types.LedgerVaultDeposit
+	LedgerVaultWithdraw         = types.LedgerVaultWithdraw
+	LedgerVaultDistribution     = types.LedgerVaultDistribution
+	LedgerVaultLeaderCommission = types.LedgerVaultLeaderCommission
+	LedgerRewardsClaim          = types.LedgerRewardsClaim
+	LedgerCStakingTransfer      = types.LedgerCStakingTransfer
+	LedgerSpotGenesis           = types.LedgerSpotGenesis
+	LedgerDeployGasAuction      = types.LedgerDeployGasAuction
+)
+
+// LedgerDelta is the change described by a ledger update, see types.LedgerDelta
+type LedgerDelta = types.LedgerDelta
+
+// LiquidatedPosition is a position closed by a liquidation ledger update
+type LiquidatedPosition = types.LiquidatedPosition
 
 // WsActiveAssetCtx represents active asset context (perps)
 type WsActiveAssetCtx struct {
@@ -231,32 +282,98 @@ type WsActiveAssetCtx struct {
 	Ctx  PerpsAssetCtx `json:"ctx"`
 }
 
-// WsActiveSpotAssetCtx represents active asset context (spot)
+// WsActiveSpotAssetCtx represents active asset context (spot), sent on the activeSpotAssetCtx channel
 type WsActiveSpotAssetCtx struct {
 	Coin string       `json:"coin"`
 	Ctx  SpotAssetCtx `json:"ctx"`
 }
 
+// WsAssetCtx is an asset context update for either a perp or a spot asset.
+// The activeAssetCtx subscription answers on the activeSpotAssetCtx channel for
+// spot coins, so exactly one of Perp and Spot is set depending on the channel.
+type WsAssetCtx struct {
+	Perp *WsActiveAssetCtx
+	Spot *WsActiveSpotAssetCtx
+}
+
+// Coin returns the coin of the update
+func (c WsAssetCtx) Coin() string {
+	if c.Spot != nil {
+		return c.Spot.Coin
+	}
+	if c.Perp != nil {
+		return c.Perp.Coin
+	}
+	return ""
+}
+
 // SharedAssetCtx contains shared asset context fields
 type SharedAssetCtx struct {
-	DayNtlVlm float64  `json:"dayNtlVlm"`
-	PrevDayPx float64  `json:"prevDayPx"`
-	MarkPx    float64  `json:"markPx"`
-	MidPx     *float64 `json:"midPx,omitempty"`
+	DayNtlVlm  float64  `json:"dayNtlVlm,string"`
+	DayBaseVlm float64  `json:"dayBaseVlm,string"`
+	PrevDayPx  float64  `json:"prevDayPx,string"`
+	MarkPx     float64  `json:"markPx,string"`
+	MidPx      *float64 `json:"midPx,string,omitempty"`
 }
 
 // PerpsAssetCtx represents perpetual asset context
 type PerpsAssetCtx struct {
 	SharedAssetCtx
-	Funding      float64 `json:"funding"`
-	OpenInterest float64 `json:"openInterest"`
-	OraclePx     float64 `json:"oraclePx"`
+	Funding      float64  `json:"funding,string"`
+	OpenInterest float64  `json:"openInterest,string"`
+	OraclePx     float64  `json:"oraclePx,string"`
+	Premium      *float64 `json:"premium,string,omitempty"`
+	ImpactPxs    []string `json:"impactPxs,omitempty"`
 }
 
 // SpotAssetCtx represents spot asset context
 type SpotAssetCtx struct {
 	SharedAssetCtx
-	CirculatingSupply float64 `json:"circulatingSupply"`
+	CirculatingSupply float64 `json:"circulatingSupply,string"`
+	TotalSupply       float64 `json:"totalSupply,string"`
+}
+
+// channelDecoder is implemented by data types that depend on the channel a message arrived on
+type channelDecoder interface {
+	decodeChannel(channel string, data json.RawMessage) error
+}
+
+// errOtherAssetClass is returned by decodeChannel for the asset context of a coin of
+// the other asset class, e.g. a spot coin for a perp client, which is skipped
+var errOtherAssetClass = errors.New("asset context of the other asset class")
+
+func (c *WsActiveAssetCtx) decodeChannel(channel string, data json.RawMessage) error {
+	switch channel {
+	case "activeAssetCtx":
+		return json.Unmarshal(data, c)
+	case "activeSpotAssetCtx":
+		return errOtherAssetClass
+	}
+	return fmt.Errorf("unexpected channel %s for perp asset context", channel)
+}
+
+func (c *WsActiveSpotAssetCtx) decodeChannel(channel string, data json.RawMessage) error {
+	switch channel {
+	case "activeSpotAssetCtx":
+		return json.Unmarshal(data, c)
+	case "activeAssetCtx":
+		return errOtherAssetClass
+	}
+	return fmt.Errorf("unexpected channel %s for spot asset context", channel)
+}
+
+func (c *WsAssetCtx) decodeChannel(channel string, data json.RawMessage) error {
+	*c = WsAssetCtx{}
+	switch channel {
+	case "activeAssetCtx":
+		c.Perp = &WsActiveAssetCtx{}
+		return json.Unmarshal(data, c.Perp)
+	case "activeSpotAssetCtx":
+		c.Spot = &WsActiveSpotAssetCtx{}
+		return json.Unmarshal(data, c.Spot)
+	default:
+		return fmt.Errorf("unexpected channel %s for asset context", channel)
+	}
 }
 
 // WsActiveAssetData represents active asset data for a user
@@ -297,16 +414,16 @@ type WsTwapHistory struct {
 
 // TwapState represents TWAP state
 type TwapState struct {
-	Coin        string  `json:"coin"`
-	User        string  `json:"user"`
-	Side        string  `json:"side"`
-	Sz          float64 `json:"sz"`
-	ExecutedSz  float64 `json:"executedSz"`
-	ExecutedNtl float64 `json:"executedNtl"`
-	Minutes     int     `json:"minutes"`
-	ReduceOnly  bool    `json:"reduceOnly"`
-	Randomize   bool    `json:"randomize"`
-	Timestamp   int64   `json:"timestamp"`
+	Coin        string `json:"coin"`
+	User        string `json:"user"`
+	Side        string `json:"side"`
+	Sz          string `json:"sz"`
+	ExecutedSz  string `json:"executedSz"`
+	ExecutedNtl string `json:"executedNtl"`
+	Minutes     int    `json:"minutes"`
+	ReduceOnly  bool   `json:"reduceOnly"`
+	Randomize   bool   `json:"randomize"`
+	Timestamp   int64  `json:"timestamp"`
 }
 
 // TwapStatus represents TWAP status
@@ -315,10 +432,69 @@ type TwapStatus struct {
 	Description string `json:"description"`
 }
 
-// WebData2 represents aggregate information about a user
+// TWAP statuses reported in TwapStatus.Status
+const (
+	TwapStatusActivated  = "activated"
+	TwapStatusTerminated = "terminated"
+	TwapStatusFinished   = "finished"
+	TwapStatusError      = "error"
+)
+
+// WebData2 represents aggregate information about a user, as shown on the
+// Hyperliquid frontend: perp positions and margin, open orders, spot balances,
+// vaults, the approved agent and the market contexts of every perp and spot asset
 type WebData2 struct {
-	// Define based on actual API response structure
-	// This is a placeholder - adjust according to actual data
-	User string                 `json:"user"`
-	Data map[string]interface{} `json:"data"`
+	User               string                    `json:"user"`
+	ClearinghouseState types.UserState           `json:"clearinghouseState"`
+	SpotState          *types.SpotUserState      `json:"spotState,omitempty"`
+	OpenOrders         []types.FrontendOpenOrder `json:"openOrders"`
+	TwapStates         []WebData2TwapState       `json:"twapStates"`
+
+	// LeadingVaults are the vaults the user leads
+	LeadingVaults    []WebData2Vault `json:"leadingVaults"`
+	TotalVaultEquity string          `json:"totalVaultEquity"`
+	IsVault          bool            `json:"isVault"`
+
+	// AgentAddress is the approved API wallet, nil if there is none
+	AgentAddress    *string `json:"agentAddress"`
+	AgentValidUntil *int64  `json:"agentValidUntil"` // millis
+	CumLedger       string  `json:"cumLedger"`
+
+	Meta                   types.Meta           `json:"meta"`
+	AssetCtxs              []types.PerpAssetCtx `json:"assetCtxs"`
+	SpotAssetCtxs          []types.SpotAssetCtx `json:"spotAssetCtxs"`
+	PerpsAtOpenInterestCap []string             `json:"perpsAtOpenInterestCap,omitempty"`
+	ServerTime             int64                `json:"serverTime"`
+}
+
+// WebData2Vault is a vault led by the user
+type WebData2Vault struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+// WebData2TwapState is a running TWAP, sent as an [id, state] pair
+type WebData2TwapState struct {
+	TwapId int64
+	State  TwapState
+}
+
+// UnmarshalJSON decodes the [id, state] pair
+func (t *WebData2TwapState) UnmarshalJSON(data []byte) error {
+	var pair [2]json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return fmt.Errorf("failed to unmarshal twap state: %w", err)
+	}
+	if err := json.Unmarshal(pair[0], &t.TwapId); err != nil {
+		return fmt.Errorf("failed to unmarshal twap id: %w", err)
+	}
+	if err := json.Unmarshal(pair[1], &t.State); err != nil {
+		return fmt.Errorf("failed to unmarshal twap state: %w", err)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the state as an [id, state] pair
+func (t WebData2TwapState) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]any{t.TwapId, t.State})
 }
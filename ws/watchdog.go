@@ -0,0 +1,187 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrFeedStale is returned when the watchdog reconnected because a subscription
+// stopped receiving data although the connection was alive
+var ErrFeedStale = errors.New("feed went quiet")
+
+// WatchdogAction is what the watchdog does when a feed goes quiet
+type WatchdogAction int
+
+const (
+	// WatchdogResubscribe resubscribes to the quiet feed, and reconnects if it stays
+	// quiet for another timeout (the default)
+	WatchdogResubscribe WatchdogAction = iota
+
+	// WatchdogReconnect reconnects at once
+	WatchdogReconnect
+)
+
+// WatchdogPolicy configures the watchdog of a client, which tracks when each of its
+// subscriptions last received data. Pongs keep a connection alive but say nothing about
+// its subscriptions, which the server may stop serving silently.
+type WatchdogPolicy struct {
+	// Timeouts is how long a subscription of each type may stay quiet. How often feeds
+	// update differs widely, e.g. allMids every block but trades only when someone trades,
+	// so types without a timeout are not watched.
+	Timeouts map[SubscriptionType]time.Duration
+
+	// Action is what the watchdog does when a feed goes quiet
+	Action WatchdogAction
+}
+
+// DefaultWatchdogPolicy returns a policy watching the feeds that update continuously
+// regardless of trading activity, with timeouts sized for quiet markets
+func DefaultWatchdogPolicy() *WatchdogPolicy {
+	return &WatchdogPolicy{
+		Timeouts: map[SubscriptionType]time.Duration{
+			SubscriptionAllMids:        30 * time.Second,
+			SubscriptionL2Book:         60 * time.Second,
+			SubscriptionActiveAssetCtx: 60 * time.Second,
+			SubscriptionWebData2:       60 * time.Second,
+			SubscriptionBBO:            5 * time.Minute,
+		},
+	}
+}
+
+// watchdog watches the subscriptions of one connection
+type watchdog struct {
+	policy *WatchdogPolicy
+	// hb measures time while the consumer waits for frames, see heartbeat.readClock
+	hb    *heartbeat
+	write func(msg any) error
+
+	mu    sync.Mutex
+	feeds map[string]*watchedFeed
+
+	expired atomic.Pointer[string]
+}
+
+type watchedFeed struct {
+	subscription Subscription
+	timeout      time.Duration
+	// last is the read clock of the last message, see heartbeat.readClock
+	last         time.Duration
+	resubscribed bool
+}
+
+// newWatchdog returns a watchdog for the subscriptions of policy among subs, nil if
+// there are none
+func newWatchdog(policy *WatchdogPolicy, subs []Subscription, hb *heartbeat, write func(msg any) error) *watchdog {
+	if policy == nil {
+		return nil
+	}
+	w := &watchdog{policy: policy, hb: hb, write: write, feeds: make(map[string]*watchedFeed)}
+	now := hb.readClock()
+	for _, sub := range subs {
+		timeout := policy.Timeouts[sub.Type]
+		if timeout <= 0 {
+			continue
+		}
+		identifier, err := subscriptionIdentifier(sub)
+		if err != nil {
+			continue
+		}
+		w.feeds[identifier] = &watchedFeed{subscription: sub, timeout: timeout, last: now}
+	}
+	if len(w.feeds) == 0 {
+		return nil
+	}
+	return w
+}
+
+// frame records a frame received on the connection
+func (w *watchdog) frame(frame []byte) {
+	if w == nil {
+		return
+	}
+	var msg wsMessage
+	if len(frame) == 0 || frame[0] != '{' || json.Unmarshal(frame, &msg) != nil {
+		return
+	}
+	w.message(msg.Channel, msg.Data)
+}
+
+func (w *watchdog) message(channel string, data json.RawMessage) {
+	identifier, ok := messageIdentifier(channel, data)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if feed, ok := w.feeds[identifier]; ok {
+		feed.last = w.hb.readClock()
+		feed.resubscribed = false
+	}
+}
+
+// run checks the feeds until ctx is done, closing conn to reconnect
+func (w *watchdog) run(ctx context.Context, conn *websocket.Conn) {
+	var shortest time.Duration
+	for _, feed := range w.feeds {
+		if shortest == 0 || feed.timeout < shortest {
+			shortest = feed.timeout
+		}
+	}
+	ticker := time.NewTicker(max(shortest/4, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if stale := w.check(); stale != "" {
+				w.expired.Store(&stale)
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// check resubscribes to the quiet feeds, returning the identifier of a feed
+// requiring a reconnection
+func (w *watchdog) check() string {
+	now := w.hb.readClock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for identifier, feed := range w.feeds {
+		if now-feed.last < feed.timeout {
+			continue
+		}
+		if w.policy.Action == WatchdogReconnect || feed.resubscribed {
+			return identifier
+		}
+		feed.resubscribed = true
+		feed.last = now
+		// The connection fails soon if these cannot be sent
+		w.write(subscribeMessage("unsubscribe", feed.subscription))
+		w.write(subscribeMessage("subscribe", feed.subscription))
+	}
+	return ""
+}
+
+// err returns an error wrapping ErrFeedStale if the watchdog closed the connection
+// to reconnect
+func (w *watchdog) err() error {
+	if w == nil {
+		return nil
+	}
+	if identifier := w.expired.Load(); identifier != nil {
+		return fmt.Errorf("%w: %s", ErrFeedStale, *identifier)
+	}
+	return nil
+}
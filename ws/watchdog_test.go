@@ -0,0 +1,129 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const testMids = `{"channel":"allMids","data":{"mids":{"BTC":"100"}}}`
+
+func testWatchdogPolicy(action WatchdogAction) *WatchdogPolicy {
+	return &WatchdogPolicy{
+		Timeouts: map[SubscriptionType]time.Duration{SubscriptionAllMids: 50 * time.Millisecond},
+		Action:   action,
+	}
+}
+
+func TestWatchdogResubscribes(t *testing.T) {
+	methods := make(chan string, 10)
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(testMids))
+		// Go quiet until the client resubscribes
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			method, _ := msg["method"].(string)
+			methods <- method
+			if method == "subscribe" {
+				conn.WriteMessage(websocket.TextMessage, []byte(testMids))
+			}
+		}
+	})
+
+	for _, buffered := range []bool{false, true} {
+		client := NewAllMidsClient()
+		client.SetURL(server.URL())
+		client.SetWatchdog(testWatchdogPolicy(WatchdogResubscribe))
+		if buffered {
+			client.SetBuffer(10, OverflowBlock)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := client.Read(); err != nil {
+				t.Fatalf("buffered=%v: Read() %d error = %v", buffered, i, err)
+			}
+		}
+		if got := <-methods; got != "unsubscribe" {
+			t.Errorf("buffered=%v: first message after going quiet = %s, want unsubscribe", buffered, got)
+		}
+		if got := <-methods; got != "subscribe" {
+			t.Errorf("buffered=%v: second message after going quiet = %s, want subscribe", buffered, got)
+		}
+		client.Close()
+		for len(methods) > 0 {
+			<-methods
+		}
+	}
+	if n := server.Connections(); n != 2 {
+		t.Errorf("connections = %d, want 2", n)
+	}
+}
+
+func TestWatchdogReconnects(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		readSubscription(t, conn)
+		conn.WriteMessage(websocket.TextMessage, []byte(testMids))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	client := NewAllMidsClient()
+	client.SetURL(server.URL())
+	client.SetWatchdog(testWatchdogPolicy(WatchdogReconnect))
+	client.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	var events []ReconnectEvent
+	client.OnReconnect(func(event ReconnectEvent) { events = append(events, event) })
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Read(); err != nil {
+			t.Fatalf("Read() %d error = %v", i, err)
+		}
+	}
+	if n := server.Connections(); n != 2 {
+		t.Errorf("connections = %d, want 2", n)
+	}
+	if len(events) != 1 || !errors.Is(events[0].Cause, ErrFeedStale) {
+		t.Errorf("reconnect events = %+v, want one caused by ErrFeedStale", events)
+	}
+
+	// Without a reconnect policy, the error is returned
+	quiet := NewAllMidsClient()
+	quiet.SetURL(server.URL())
+	quiet.SetWatchdog(testWatchdogPolicy(WatchdogReconnect))
+	if _, err := quiet.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if _, err := quiet.Read(); !errors.Is(err, ErrFeedStale) {
+		t.Errorf("Read() of a quiet feed = %v, want ErrFeedStale", err)
+	}
+}
+
+func TestWatchdogIgnoresUnwatchedFeeds(t *testing.T) {
+	policy := DefaultWatchdogPolicy()
+	hb := &heartbeat{}
+	write := func(any) error { return nil }
+	coin := "BTC"
+	if w := newWatchdog(policy, []Subscription{{Type: SubscriptionTrades, Coin: &coin}}, hb, write); w != nil {
+		t.Error("newWatchdog() watches trades")
+	}
+	if w := newWatchdog(nil, []Subscription{{Type: SubscriptionAllMids}}, hb, write); w != nil {
+		t.Error("newWatchdog() without a policy is not nil")
+	}
+
+	w := newWatchdog(policy, []Subscription{{Type: SubscriptionTrades, Coin: &coin}, {Type: SubscriptionL2Book, Coin: &coin}}, hb, write)
+	if w == nil {
+		t.Fatal("newWatchdog() ignores the l2Book")
+	}
+	if len(w.feeds) != 1 || w.feeds["l2Book:btc"] == nil {
+		t.Errorf("newWatchdog() feeds = %v, want the l2Book of BTC", w.feeds)
+	}
+}
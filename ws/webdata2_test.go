@@ -0,0 +1,58 @@
+package ws
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestWebData2Unmarshal(t *testing.T) {
+	raw, err := os.ReadFile("testdata/webdata2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data WebData2
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if data.User != "0x3333333333333333333333333333333333333333" || data.ServerTime != 1700000000123 {
+		t.Errorf("user = %s, serverTime = %d", data.User, data.ServerTime)
+	}
+	if positions := data.ClearinghouseState.AssetPositions; len(positions) != 1 || positions[0].Position.Szi != "0.1" {
+		t.Errorf("asset positions = %+v", positions)
+	}
+	if len(data.OpenOrders) != 1 || data.OpenOrders[0].Oid != 42 {
+		t.Errorf("open orders = %+v", data.OpenOrders)
+	}
+	if data.SpotState == nil || len(data.SpotState.Balances) != 1 || data.SpotState.Balances[0].Total != "100.0" {
+		t.Errorf("spot state = %+v", data.SpotState)
+	}
+	if data.AgentAddress == nil || *data.AgentAddress != "0x2222222222222222222222222222222222222222" ||
+		data.AgentValidUntil == nil || *data.AgentValidUntil != 1800000000000 {
+		t.Errorf("agent = %v until %v", data.AgentAddress, data.AgentValidUntil)
+	}
+	if len(data.LeadingVaults) != 1 || data.LeadingVaults[0].Name != "My Vault" {
+		t.Errorf("leading vaults = %+v", data.LeadingVaults)
+	}
+	if len(data.Meta.Universe) != 1 || len(data.AssetCtxs) != 1 || data.AssetCtxs[0].MarkPx != "2500.5" {
+		t.Errorf("meta = %+v, asset ctxs = %+v", data.Meta, data.AssetCtxs)
+	}
+	if len(data.SpotAssetCtxs) != 1 || data.SpotAssetCtxs[0].MidPx != nil {
+		t.Errorf("spot asset ctxs = %+v", data.SpotAssetCtxs)
+	}
+	if len(data.TwapStates) != 1 || data.TwapStates[0].TwapId != 7 || data.TwapStates[0].State.ExecutedSz != "0.25" {
+		t.Errorf("twap states = %+v", data.TwapStates)
+	}
+
+	// TWAP states round-trip as [id, state] pairs
+	encoded, err := json.Marshal(data.TwapStates)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded []WebData2TwapState
+	if err := json.Unmarshal(encoded, &decoded); err != nil || len(decoded) != 1 || decoded[0] != data.TwapStates[0] {
+		t.Errorf("round trip = %+v, %v", decoded, err)
+	}
+}
@@ -18,8 +18,26 @@
 //
 // Features:
 //   - Automatic connection on first Read()
-//   - Automatic heartbeat (ping every 50s)
+//   - Automatic heartbeat (ping every 40s, SetPingInterval) and dead-connection detection
+//     (SetReadTimeout, SetPongTimeout)
 //   - Automatic cleanup on error
+//   - Optional automatic reconnection with resubscription (SetReconnectPolicy)
+//   - Resubscription or reconnection when a feed goes quiet (SetWatchdog)
+//   - Cancellation and deadlines for reads (ReadContext)
+//   - Channel-based consumption with cancellation (Stream)
+//   - Filters and transforms of feeds (WithFilter, WithMap)
+//   - Order book consistency checks against REST snapshots (BookChecker)
+//   - Combined bbo, trades and book view of a coin (MarketData)
+//   - Optional background buffering with overflow policies (SetBuffer)
+//   - Connection state and lifecycle hooks (State, OnConnect, OnDisconnect)
+//   - Traffic and ping latency statistics (Stats, SetMetricsHook)
+//   - Raw frame capture for debugging and archival (SetRawTap, FrameWriter)
+//   - Recording and replay of sessions for backtests (Recorder, ReplayServer)
+//   - Optional permessage-deflate compression (SetCompression)
+//   - Shared connections between clients (SetPool, ConnectionPool)
+//   - Subscription acknowledgments and rejections (WaitSubscribed)
+//   - Merged order updates of several accounts (MultiUserOrderUpdates)
+//   - Connection and subscription limits shared between clients (SetBudget, SubscriptionBudget)
 //   - Support for multiple subscriptions (e.g., multiple coins)
 //   - Type-safe data structures
 //
@@ -50,16 +68,22 @@ package ws
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/dwdwow/hl-go/constants"
 )
 
 const (
 	// MainnetWsURL is the default Hyperliquid WebSocket URL
-	MainnetWsURL = "wss://api.hyperliquid.xyz/ws"
+	MainnetWsURL = constants.MainnetWsURL
 )
 
 // wsMessage represents the raw WebSocket message structure
@@ -78,89 +102,278 @@ type wsMessage struct {
 //
 // Type parameter T specifies the data type returned by Read().
 type Client[T any] struct {
-	url          string
-	conn         *websocket.Conn
-	subscription map[string]any
-	isConnected  bool
-	writeMu      sync.Mutex
-	ctx          context.Context
-	cancel       context.CancelFunc
-	pingInterval time.Duration
-}
-
-// newClient creates a new WebSocket client for a specific data type
-// The subscription parameter should match the Hyperliquid subscription format:
-//
-//	Example for trades:
-//	  subscription := Subscription{
-//	    Type: SubscriptionTrades,
-//	    Coin: &"BTC",
-//	  }
-//
-//	Example for user fills:
-//	  subscription := Subscription{
-//	    Type: SubscriptionUserFills,
-//	    User: &"0x1234...",
-//	  }
-func newClient[T any](url string, subscription map[string]any) *Client[T] {
+	url         string
+	dialer      *websocket.Dialer
+	header      http.Header
+	compression bool
+	// handshakeTimeout overrides the handshake timeout of the dialer if positive
+	handshakeTimeout time.Duration
+	conn             *websocket.Conn
+	subscriptions    []Subscription
+	isConnected      bool
+	writeMu          sync.Mutex
+	ctx              context.Context
+	cancel           context.CancelFunc
+	pingInterval     time.Duration
+	pongTimeout      time.Duration
+	readTimeout      time.Duration
+	closeTimeout     time.Duration
+	heartbeat        *heartbeat
+	watchdogPolicy   *WatchdogPolicy
+	metrics          connMetrics
+
+	metricsInterval time.Duration
+	metricsHook     func(Stats)
+
+	rawTap func(frame []byte)
+
+	// queue buffers frames of the current connection when bufferSize > 0
+	bufferSize     int
+	overflowPolicy OverflowPolicy
+	queue          *frameQueue
+	dropped        atomic.Uint64
+
+	lifecycle lifecycle
+	acks      *subscriptionAcks
+
+	// pool carries the subscriptions over a shared connection instead of conn
+	pool     *ConnectionPool
+	poolSubs []pooledSubscription
+
+	// budget counts the connection and its subscriptions, budgetSubs while held
+	budget      *SubscriptionBudget
+	budgetConns int
+	budgetSubs  []Subscription
+
+	reconnectPolicy *ReconnectPolicy
+	onReconnect     func(ReconnectEvent)
+	// reconnectAttempts counts attempts since data was last received
+	reconnectAttempts int
+
+	// stop is closed by Close to interrupt reconnection backoff
+	stopMu  sync.Mutex
+	stop    chan struct{}
+	stopped bool
+}
+
+// NewClient creates a client for the feeds of subs on mainnet, built with the
+// subscription builders such as TradesSubscription. T must match the data of every
+// feed, e.g. WsBook for l2Book:
+//
+//	books := ws.NewClient[ws.WsBook](ws.L2BookSubscription("BTC"), ws.L2BookSubscription("ETH"))
+//
+// Identical subscriptions are sent once. To share feeds between clients instead of
+// paying for them twice, attach the clients to a ConnectionPool.
+func NewClient[T any](subs ...Subscription) *Client[T] {
+	return newClient[T](MainnetWsURL, subs...)
+}
+
+func newClient[T any](url string, subs ...Subscription) *Client[T] {
 	return &Client[T]{
-		url:          url,
-		subscription: subscription,
-		isConnected:  false,
-		pingInterval: 40 * time.Second, // Default ping interval
+		url:           url,
+		subscriptions: dedupeSubscriptions(subs),
+		isConnected:   false,
+		pingInterval:  DefaultPingInterval,
+		readTimeout:   DefaultReadTimeout,
+		closeTimeout:  DefaultCloseTimeout,
+		acks:          newSubscriptionAcks(),
+		stop:          make(chan struct{}),
 	}
 }
 
-// subscriptionHandler converts the subscription into a list of subscription messages
-// If any field contains a slice, it will expand into multiple subscriptions
-func (c *Client[T]) subscriptionHandler() []map[string]any {
-	if len(c.subscription) == 0 {
-		return []map[string]any{}
-	}
+// SetURL sets the WebSocket URL to connect to, e.g. a local node.
+// Must be called before the first Read; the New*Client helpers default to mainnet.
+func (c *Client[T]) SetURL(url string) {
+	c.url = url
+}
 
-	// Check if any field is a slice
-	var sliceField string
-	var sliceValues []string
+// SetNetwork connects the client to the WebSocket URL of network, e.g. constants.Testnet.
+// Must be called before the first Read.
+func (c *Client[T]) SetNetwork(network constants.Network) {
+	c.url = network.WsURL
+}
 
-	for key, value := range c.subscription {
-		// Check if value is a string slice
-		if strSlice, ok := value.([]string); ok {
-			sliceField = key
-			sliceValues = strSlice
-			break
-		}
-	}
+// SetDialer sets the dialer used to connect, e.g. to go through a proxy or to pin
+// TLS certificates. See NewDialer for the default. Must be called before the first Read.
+func (c *Client[T]) SetDialer(dialer *websocket.Dialer) {
+	c.dialer = dialer
+}
 
-	// If no slice field found, return single subscription
-	if sliceField == "" {
-		return []map[string]any{
-			{
-				"method":       "subscribe",
-				"subscription": c.subscription,
-			},
-		}
-	}
+// SetHeader sets extra HTTP headers sent with the handshake, e.g. proxy credentials.
+// Must be called before the first Read.
+func (c *Client[T]) SetHeader(header http.Header) {
+	c.header = header
+}
 
-	// Expand slice field into multiple subscriptions
-	var result []map[string]any
-	for _, value := range sliceValues {
-		// Create a copy of the subscription
-		sub := make(map[string]any)
-		for k, v := range c.subscription {
-			if k == sliceField {
-				sub[k] = value // Replace slice with single value
-			} else {
-				sub[k] = v
-			}
-		}
+// SetCompression negotiates permessage-deflate compression with the server, which cuts
+// bandwidth several times for large feeds such as l2Book at the cost of CPU time to
+// inflate frames. Compression is used only if the server accepts it. Also enabled by a
+// dialer with EnableCompression set. Must be called before the first Read.
+func (c *Client[T]) SetCompression(enabled bool) {
+	c.compression = enabled
+}
 
-		result = append(result, map[string]any{
-			"method":       "subscribe",
-			"subscription": sub,
-		})
+// SetPingInterval sets how often the client pings the server. The server closes
+// connections that sent nothing for 60 seconds, so keep it well below that.
+// Defaults to DefaultPingInterval, also used for zero or negative intervals. Must be
+// called before the first Read.
+func (c *Client[T]) SetPingInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPingInterval
 	}
+	c.pingInterval = interval
+}
+
+// SetPongTimeout sets how long the server may take to answer a ping before the
+// connection is considered dead, which detects a dead link sooner than the read timeout.
+// The connection is then handled like after the read timeout, with an error wrapping
+// ErrPongTimeout. Without SetBuffer, the time Read is not being called does not count, nor
+// with OverflowBlock the time the queue is full. Zero disables the check (the default).
+// Must be called before the first Read.
+func (c *Client[T]) SetPongTimeout(timeout time.Duration) {
+	c.pongTimeout = timeout
+}
+
+// SetHandshakeTimeout overrides the handshake timeout of the dialer, which defaults to
+// DefaultHandshakeTimeout. Must be called before the first Read.
+func (c *Client[T]) SetHandshakeTimeout(timeout time.Duration) {
+	c.handshakeTimeout = timeout
+}
+
+// SetReadTimeout sets how long the connection may stay silent, pongs included, before
+// it is considered dead. A dead connection is reconnected if a reconnect policy is set,
+// otherwise Read returns an error wrapping ErrStaleConnection. Zero disables the check.
+// Defaults to DefaultReadTimeout.
+func (c *Client[T]) SetReadTimeout(timeout time.Duration) {
+	c.readTimeout = timeout
+}
+
+// SetCloseTimeout sets how long closing the connection waits for the server to answer
+// the close frame. Zero closes the connection without the closing handshake.
+// Defaults to DefaultCloseTimeout.
+func (c *Client[T]) SetCloseTimeout(timeout time.Duration) {
+	c.closeTimeout = timeout
+}
+
+// LastMessageTime returns when the last message, pongs included, was received,
+// or the zero time if nothing was received yet. Safe to call from any goroutine.
+func (c *Client[T]) LastMessageTime() time.Time {
+	return c.metrics.lastMessageTime()
+}
+
+// Stats returns traffic statistics of the current connection, such as message rates
+// and ping round-trip time. Safe to call from any goroutine.
+func (c *Client[T]) Stats() Stats {
+	s := c.metrics.stats()
+	s.Dropped = c.dropped.Load()
+	return s
+}
+
+// SetRawTap sets a callback receiving every inbound frame before decoding, pongs and
+// subscription responses included, e.g. to capture traffic for a bug report with a
+// FrameWriter. fn runs on the goroutine reading the connection, which is a background
+// goroutine with SetBuffer; it must not block or modify frame.
+// Must be called before the first Read.
+func (c *Client[T]) SetRawTap(fn func(frame []byte)) {
+	c.rawTap = fn
+}
+
+// SetWatchdog makes the client track when each of its subscriptions last received
+// data, and resubscribe or reconnect according to policy when one goes quiet, e.g.
+// DefaultWatchdogPolicy(). A reconnection needs SetReconnectPolicy, otherwise Read
+// returns an error wrapping ErrFeedStale. Without SetBuffer, time spent by the consumer
+// between reads does not count. Ignored for pooled clients. Must be called before the
+// first Read.
+func (c *Client[T]) SetWatchdog(policy *WatchdogPolicy) {
+	c.watchdogPolicy = policy
+}
+
+// SetMetricsHook makes the client call fn with its Stats every interval while connected,
+// e.g. to export them to a monitoring system. fn runs on a background goroutine and
+// must not block. Must be called before the first Read.
+func (c *Client[T]) SetMetricsHook(interval time.Duration, fn func(Stats)) {
+	c.metricsInterval = interval
+	c.metricsHook = fn
+}
+
+// SetBuffer makes the client read the connection in the background into a queue of
+// up to size messages, so a slow consumer does not stall the connection. policy decides
+// what happens when the queue is full; see Dropped for the number of discarded messages.
+// Zero size disables buffering (the default). Must be called before the first Read.
+func (c *Client[T]) SetBuffer(size int, policy OverflowPolicy) {
+	c.bufferSize = size
+	c.overflowPolicy = policy
+}
+
+// Dropped returns the number of messages discarded by the overflow policy.
+// Safe to call from any goroutine.
+func (c *Client[T]) Dropped() uint64 {
+	return c.dropped.Load()
+}
+
+// State returns the connection state. Safe to call from any goroutine.
+func (c *Client[T]) State() ConnState {
+	return c.lifecycle.current()
+}
+
+// OnConnect sets a callback invoked every time the client has connected and subscribed,
+// including after a reconnection. It runs on the goroutine calling Read.
+func (c *Client[T]) OnConnect(fn func()) {
+	c.lifecycle.setHooks(fn, nil)
+}
+
+// OnDisconnect sets a callback invoked when an established connection ends, with the
+// error that ended it, or nil after Close. It runs on the goroutine calling Read or Close.
+func (c *Client[T]) OnDisconnect(fn func(err error)) {
+	c.lifecycle.setHooks(nil, fn)
+}
+
+// WaitSubscribed blocks until the server has acknowledged every subscription of the
+// client on the current connection, so that data will flow, or until ctx is done.
+// It returns an error wrapping ErrSubscriptionRejected if the server rejected one, e.g.
+// for an unknown coin, or the error that closed the client. Before the first Read and
+// while reconnecting, it waits for the next connection.
+//
+// Acknowledgments are processed by Read, so WaitSubscribed must be called from another
+// goroutine. Pooled clients are considered subscribed once their subscriptions are sent.
+// Safe to call from any goroutine.
+func (c *Client[T]) WaitSubscribed(ctx context.Context) error {
+	return c.acks.wait(ctx)
+}
+
+// SetPool routes the subscriptions of the client over the shared connection of pool
+// instead of a connection of its own. Connection settings such as SetURL, SetDialer or
+// SetPingInterval are then ignored in favor of those of the pool, and without SetBuffer
+// the client buffers up to DefaultPoolBuffer messages. With OverflowBlock, a client that
+// falls behind stalls the other clients of the pool. Must be called before the first Read.
+func (c *Client[T]) SetPool(pool *ConnectionPool) {
+	c.pool = pool
+}
+
+// SetBudget counts the connection and subscriptions of the client in budget, which
+// rejects or delays them beyond its limits, see SubscriptionBudget. Pooled clients are
+// counted by the budget of the pool's Manager instead. Must be called before the first Read.
+func (c *Client[T]) SetBudget(budget *SubscriptionBudget) {
+	c.budget = budget
+}
+
+// SetReconnectPolicy enables automatic reconnection when the connection is lost.
+// The client re-dials and re-sends its subscriptions transparently, so Read keeps
+// returning data. Pass nil to disable reconnection (the default).
+// Must be called before the first Read.
+func (c *Client[T]) SetReconnectPolicy(policy *ReconnectPolicy) {
+	c.reconnectPolicy = policy
+}
 
-	return result
+// OnReconnect sets a callback invoked after every reconnection attempt.
+// The callback runs on the goroutine calling Read and should return quickly.
+func (c *Client[T]) OnReconnect(fn func(ReconnectEvent)) {
+	c.onReconnect = fn
+}
+
+// Subscriptions returns the subscriptions of the client
+func (c *Client[T]) Subscriptions() []Subscription {
+	return slices.Clone(c.subscriptions)
 }
 
 func (c *Client[T]) Write(msg any) error {
@@ -175,7 +388,7 @@ func (c *Client[T]) Write(msg any) error {
 // start connects to the WebSocket and subscribes to the specified feed
 // It also starts a background goroutine to send ping messages periodically
 // Not thread-safe: should only be called from Read() once
-func (c *Client[T]) start() error {
+func (c *Client[T]) start(ctx context.Context) error {
 	if c.isConnected {
 		return fmt.Errorf("client already started")
 	}
@@ -183,37 +396,118 @@ func (c *Client[T]) start() error {
 	// Create context for controlling the ping goroutine
 	c.ctx, c.cancel = context.WithCancel(context.Background())
 
-	// Connect to WebSocket
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
+	if c.lifecycle.current() != StateReconnecting {
+		c.lifecycle.set(StateConnecting)
+	}
+
+	if c.pool != nil {
+		if err := c.startPooled(); err != nil {
+			c.cancel()
+			c.failStart()
+			return err
+		}
+		c.lifecycle.connected()
+		return nil
 	}
 
-	conn, _, err := dialer.Dial(c.url, nil)
+	subs := c.subscriptions
+	if err := validateSubscriptions(subs); err != nil {
+		c.cancel()
+		c.failStart()
+		return err
+	}
+	if c.budget != nil {
+		if err := c.budget.acquire(ctx, 1, subs, len(subs)); err != nil {
+			c.cancel()
+			c.failStart()
+			return err
+		}
+		c.budgetConns, c.budgetSubs = 1, subs
+	}
+
+	// Connect to WebSocket
+	conn, err := dial(c.url, dialOptions{
+		dialer:           c.dialer,
+		header:           c.header,
+		compression:      c.compression,
+		handshakeTimeout: c.handshakeTimeout,
+	})
 	if err != nil {
 		c.cancel()
+		c.releaseBudget()
+		c.failStart()
 		return fmt.Errorf("failed to connect to websocket: %w", err)
 	}
 
 	c.conn = conn
 	c.isConnected = true
+	c.metrics.reset()
 
 	// Send subscription messages
-	subs := c.subscriptionHandler()
+	c.acks.expect(subs)
 	for _, sub := range subs {
-		if err = c.Write(sub); err != nil {
+		if err = c.Write(subscribeMessage("subscribe", sub)); err != nil {
 			c.conn.Close()
 			c.isConnected = false
 			c.cancel()
+			c.releaseBudget()
+			c.failStart()
 			return fmt.Errorf("failed to send subscription: %w", err)
 		}
 	}
 
+	c.heartbeat = &heartbeat{
+		interval:    c.pingInterval,
+		pongTimeout: c.pongTimeout,
+		metrics:     &c.metrics,
+		continuous:  c.bufferSize > 0,
+	}
+	c.heartbeat.watchdog = newWatchdog(c.watchdogPolicy, subs, c.heartbeat, func(msg any) error {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		return conn.WriteJSON(msg)
+	})
+
+	// Start the background reader
+	if c.bufferSize > 0 {
+		c.queue = newFrameQueue(c.bufferSize, c.overflowPolicy, &c.dropped)
+		go c.queue.fill(conn, c.readTimeout, c.heartbeat, c.rawTap)
+	}
+
 	// Start ping goroutine
-	go c.pingRoutine()
+	go c.heartbeat.run(c.ctx, conn, func() error {
+		// Send ping with write lock (only lock needed for concurrent writes)
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		return conn.WriteJSON(map[string]string{"method": "ping"})
+	})
+	if c.heartbeat.watchdog != nil {
+		go c.heartbeat.watchdog.run(c.ctx, conn)
+	}
+	if c.metricsHook != nil && c.metricsInterval > 0 {
+		go reportMetrics(c.ctx, c.metricsInterval, c.metricsHook, c.Stats)
+	}
+
+	c.lifecycle.connected()
 
 	return nil
 }
 
+// releaseBudget stops counting the connection in the budget
+func (c *Client[T]) releaseBudget() {
+	if c.budget != nil {
+		c.budget.release(c.budgetConns, c.budgetSubs)
+	}
+	c.budgetConns, c.budgetSubs = 0, nil
+}
+
+// failStart leaves the connecting state after start failed
+func (c *Client[T]) failStart() {
+	if c.lifecycle.current() == StateConnecting {
+		c.lifecycle.set(StateDisconnected)
+	}
+}
+
 // Read blocks until data is received and returns the unmarshaled data.
 //
 // On first call, Read automatically establishes the WebSocket connection and
@@ -222,37 +516,108 @@ func (c *Client[T]) start() error {
 // Read filters out subscription responses and pong messages, returning only actual data.
 // Non-JSON messages (like "Websocket connection established.") are also skipped.
 //
-// If an error occurs, the connection is automatically closed before returning.
-// After an error, you must create a new client to reconnect.
+// If the connection is lost and a reconnect policy is set, Read reconnects and
+// resubscribes before reading on. Otherwise, or once the policy gives up, the
+// connection is closed before the error is returned; the next Read dials again.
 //
 // Not thread-safe: should only be called from a single goroutine.
 func (c *Client[T]) Read() (data T, err error) {
+	return c.ReadContext(context.Background())
+}
+
+// ReadContext is like Read but gives up when ctx is canceled or its deadline passes,
+// returning ctx.Err(). The deadline is applied to the connection with SetReadDeadline,
+// so an idle feed cannot block a shutdown.
+//
+// An interrupted read leaves the connection unusable, so it is closed like on any
+// other error; the next read dials again. Reconnection backoff is also cut short by ctx.
+//
+// Not thread-safe: should only be called from a single goroutine.
+func (c *Client[T]) ReadContext(ctx context.Context) (data T, err error) {
+	if err = ctx.Err(); err != nil {
+		return data, err
+	}
+
 	// Use defer to automatically close on error
 	defer func() {
 		if err != nil {
-			c.Close()
+			c.shutdown(err)
 		}
 	}()
 
 	// Auto-start if not connected
-	if !c.isConnected || c.conn == nil {
-		if err = c.start(); err != nil {
+	if !c.isConnected || (c.conn == nil && c.pool == nil) {
+		c.resetStop()
+		c.reconnectAttempts = 0
+		if err = c.start(ctx); err != nil {
 			return data, fmt.Errorf("failed to start client: %w", err)
 		}
 	}
 
-	conn := c.conn
-	if conn == nil {
-		err = fmt.Errorf("client not connected")
-		return
+	for {
+		var connLost bool
+		data, connLost, err = c.readData(ctx)
+		if err == nil {
+			c.reconnectAttempts = 0
+			return data, nil
+		}
+		if !connLost || c.reconnectPolicy == nil || c.isStopped() {
+			return data, err
+		}
+
+		if err = c.reconnect(ctx, err); err != nil {
+			return data, err
+		}
+	}
+}
+
+// readData reads from the current connection until a data message arrives.
+// connLost reports whether err came from the connection rather than the payload.
+func (c *Client[T]) readData(ctx context.Context) (data T, connLost bool, err error) {
+	conn, hb := c.conn, c.heartbeat
+
+	// With buffering or a pool, frames come from the background reader
+	queue := c.queue
+	if conn == nil && queue == nil {
+		return data, false, fmt.Errorf("client not connected")
+	}
+	var deadline *readDeadline
+	if queue == nil {
+		// Interrupt the blocking read when ctx is done or the feed goes silent
+		deadline = newReadDeadline(ctx, conn, c.readTimeout)
+		defer deadline.release()
 	}
 
 	for {
-		// Read raw message (blocking)
-		_, rawMsg, readErr := conn.ReadMessage()
-		if readErr != nil {
-			err = readErr
-			return
+		var rawMsg []byte
+		if queue != nil {
+			frame, popErr := queue.pop(ctx)
+			if popErr != nil {
+				if ctx.Err() != nil {
+					return data, false, ctx.Err()
+				}
+				return data, true, popErr
+			}
+			rawMsg = frame
+		} else {
+			// Read raw message (blocking)
+			deadline.refresh()
+			hb.startRead()
+			_, frame, readErr := conn.ReadMessage()
+			hb.endRead()
+			if readErr != nil {
+				err, connLost = deadline.classify(ctx, readErr)
+				if hbErr := hb.err(); hbErr != nil && connLost {
+					err = hbErr
+				}
+				return data, connLost, err
+			}
+			c.metrics.record(frame)
+			if c.rawTap != nil {
+				c.rawTap(frame)
+			}
+			hb.watchdog.frame(frame)
+			rawMsg = frame
 		}
 
 		// Handle text messages like "Websocket connection established."
@@ -268,6 +633,34 @@ func (c *Client[T]) Read() (data T, err error) {
 			continue
 		}
 
+		switch msg.Channel {
+		case "subscriptionResponse":
+			if sub, ok := parseSubscriptionAck(msg.Data); ok {
+				c.acks.ack(sub)
+			}
+			continue
+		case "error":
+			// The client sends nothing but subscriptions and pings
+			rejectErr := rejectionError(msg.Data)
+			c.acks.fail(rejectErr)
+			return data, false, rejectErr
+		}
+
+		// Types such as WsAssetCtx are decoded according to the channel
+		if decoder, ok := any(&data).(channelDecoder); ok {
+			if msg.Channel == "pong" {
+				continue
+			}
+			decodeErr := decoder.decodeChannel(msg.Channel, msg.Data)
+			if errors.Is(decodeErr, errOtherAssetClass) {
+				continue
+			}
+			if decodeErr != nil {
+				return data, false, fmt.Errorf("failed to unmarshal data: %w, %s", decodeErr, string(rawMsg))
+			}
+			return data, false, nil
+		}
+
 		// Unmarshal data to the specified type
 		if unmarshalErr := json.Unmarshal(msg.Data, &data); unmarshalErr != nil {
 			if string(rawMsg) == `{"channel":"pong"}` {
@@ -277,32 +670,108 @@ func (c *Client[T]) Read() (data T, err error) {
 				string(msg.Data[:20]) == `{"method":"subscribe` {
 				continue
 			}
-			err = fmt.Errorf("failed to unmarshal data: %w, %s", unmarshalErr, string(rawMsg))
-			return
+			return data, false, fmt.Errorf("failed to unmarshal data: %w, %s", unmarshalErr, string(rawMsg))
 		}
 
-		return data, nil
+		return data, false, nil
 	}
 }
 
-// Close closes the WebSocket connection and stops the ping goroutine.
-//
-// This method:
-//   - Cancels the background ping routine
-//   - Closes the WebSocket connection
-//   - Resets the connection state
-//
-// Safe to call multiple times. Subsequent calls after the first are no-ops.
-// Close is automatically called by Read() when an error occurs.
-func (c *Client[T]) Close() error {
-	// Cancel context to stop ping goroutine
+// reconnect re-dials with backoff after the connection failed with cause.
+// Attempts are counted until data is received again, so a server that accepts
+// connections and drops them immediately still exhausts the policy.
+func (c *Client[T]) reconnect(ctx context.Context, cause error) error {
+	c.disconnect(StateReconnecting, cause)
+
+	policy := c.reconnectPolicy
+	var lastErr error
+	for policy.MaxAttempts <= 0 || c.reconnectAttempts < policy.MaxAttempts {
+		c.reconnectAttempts++
+		attempt := c.reconnectAttempts
+		delay := policy.Backoff(attempt)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-c.stop:
+			timer.Stop()
+			return fmt.Errorf("client closed while reconnecting: %w", cause)
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		lastErr = c.start(ctx)
+		if c.onReconnect != nil {
+			c.onReconnect(ReconnectEvent{Attempt: attempt, Cause: cause, Delay: delay, Err: lastErr})
+		}
+		if lastErr == nil {
+			return nil
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = cause
+	}
+	return fmt.Errorf("failed to reconnect after %d attempts: %w", policy.MaxAttempts, lastErr)
+}
+
+// resetStop re-arms the stop channel after Close so the client can be started again
+func (c *Client[T]) resetStop() {
+	c.stopMu.Lock()
+	defer c.stopMu.Unlock()
+	if c.stopped {
+		c.stop = make(chan struct{})
+		c.stopped = false
+	}
+}
+
+func (c *Client[T]) isStopped() bool {
+	c.stopMu.Lock()
+	defer c.stopMu.Unlock()
+	return c.stopped
+}
+
+// disconnect closes the current connection and stops its ping routine, moving to state
+func (c *Client[T]) disconnect(state ConnState, cause error) error {
+	c.lifecycle.disconnected(state, cause)
+	if state == StateReconnecting {
+		c.acks.reconnecting()
+	} else if cause != nil {
+		c.acks.fail(cause)
+	} else {
+		c.acks.fail(fmt.Errorf("client closed"))
+	}
+
 	if c.cancel != nil {
 		c.cancel()
 	}
 
+	if c.pool != nil {
+		c.stopPooled()
+		c.isConnected = false
+	}
+
+	// reader is closed once the background reader, if any, stopped reading conn
+	var reader <-chan struct{}
+	if c.queue != nil {
+		if c.conn != nil {
+			reader = c.queue.done
+		}
+		c.queue.close()
+		c.queue = nil
+	}
+
+	c.releaseBudget()
+
 	if c.conn != nil {
-		err := c.conn.Close()
-		// c.conn = nil
+		timeout := c.closeTimeout
+		if reader == nil && c.heartbeat != nil && c.heartbeat.blockedSince.Load() != 0 {
+			// Read is blocked on another goroutine and receives the server's close
+			// frame itself, so the close frame is sent without waiting
+			sendClose(c.conn, time.Now().Add(timeout))
+			timeout = 0
+		}
+		err := closeGracefully(c.conn, timeout, reader)
 		c.isConnected = false
 		return err
 	}
@@ -310,34 +779,36 @@ func (c *Client[T]) Close() error {
 	return nil
 }
 
-// pingRoutine runs in a goroutine and sends periodic ping messages
-// It stops when the context is canceled
-func (c *Client[T]) pingRoutine() {
-	ticker := time.NewTicker(c.pingInterval)
-	defer ticker.Stop()
+// Close closes the WebSocket connection and stops the ping goroutine.
+//
+// This method:
+//   - Interrupts any reconnection in progress
+//   - Cancels the background ping routine
+//   - Closes the WebSocket connection with a close frame, waiting up to the close
+//     timeout for the server's (see SetCloseTimeout)
+//   - Resets the connection state
+//
+// Safe to call multiple times. Subsequent calls after the first are no-ops.
+// Close is automatically called by Read() when an error occurs.
+func (c *Client[T]) Close() error {
+	return c.shutdown(nil)
+}
 
-	for {
-		select {
-		case <-c.ctx.Done():
-			// Context canceled - stop ping routine
-			return
-		case <-ticker.C:
-			// Check connection status (no lock needed - single threaded use)
-			conn := c.conn
-			connected := c.isConnected
-
-			if conn != nil && connected {
-				// Send ping with write lock (only lock needed for concurrent writes)
-				pingMsg := map[string]string{"method": "ping"}
-				err := conn.WriteJSON(pingMsg)
-
-				if err != nil {
-					// Failed to send ping - connection likely broken
-					return
-				}
-			}
-		}
+// shutdown implements Close, cause being the error that ended the connection
+func (c *Client[T]) shutdown(cause error) error {
+	c.stopMu.Lock()
+	if !c.stopped {
+		close(c.stop)
+		c.stopped = true
+	}
+	c.stopMu.Unlock()
+
+	// Read can dial again after an error, but not after Close
+	state := StateClosed
+	if cause != nil {
+		state = StateDisconnected
 	}
+	return c.disconnect(state, cause)
 }
 
 // Helper functions for creating common subscriptions
@@ -348,15 +819,7 @@ func (c *Client[T]) pingRoutine() {
 //	NewTradesClient("BTC")           // single coin
 //	NewTradesClient("BTC", "ETH")    // multiple coins
 func NewTradesClient(coins ...string) *Client[[]WsTrade] {
-	sub := map[string]any{
-		"type": "trades",
-	}
-	if len(coins) == 1 {
-		sub["coin"] = coins[0]
-	} else {
-		sub["coin"] = coins
-	}
-	return newClient[[]WsTrade](MainnetWsURL, sub)
+	return newClient[[]WsTrade](MainnetWsURL, coinSubscriptions(TradesSubscription, coins)...)
 }
 
 // NewL2BookClient creates a client for subscribing to order book updates
@@ -365,64 +828,66 @@ func NewTradesClient(coins ...string) *Client[[]WsTrade] {
 //	NewL2BookClient("BTC")           // single coin
 //	NewL2BookClient("BTC", "ETH")    // multiple coins
 func NewL2BookClient(coins ...string) *Client[WsBook] {
-	sub := map[string]any{
-		"type": "l2Book",
-	}
-	if len(coins) == 1 {
-		sub["coin"] = coins[0]
-	} else {
-		sub["coin"] = coins
-	}
-	return newClient[WsBook](MainnetWsURL, sub)
+	return newClient[WsBook](MainnetWsURL, coinSubscriptions(L2BookSubscription, coins)...)
 }
 
 // NewUserFillsClient creates a client for subscribing to user fills
 func NewUserFillsClient(user string) *Client[WsUserFills] {
-	return newClient[WsUserFills](MainnetWsURL, map[string]any{
-		"type": "userFills",
-		"user": user,
-	})
+	return newClient[WsUserFills](MainnetWsURL, UserFillsSubscription(user))
 }
 
 // NewOrderUpdatesClient creates a client for subscribing to order updates
 func NewOrderUpdatesClient(user string) *Client[[]WsOrder] {
-	return newClient[[]WsOrder](MainnetWsURL, map[string]any{
-		"type": "orderUpdates",
-		"user": user,
-	})
+	return newClient[[]WsOrder](MainnetWsURL, OrderUpdatesSubscription(user))
 }
 
 // NewUserEventsClient creates a client for subscribing to user events
 func NewUserEventsClient(user string) *Client[WsUserEvent] {
-	return newClient[WsUserEvent](MainnetWsURL, map[string]any{
-		"type": "userEvents",
-		"user": user,
-	})
+	return newClient[WsUserEvent](MainnetWsURL, UserEventsSubscription(user))
 }
 
 // NewCandleClient creates a client for subscribing to candle updates
 // Can subscribe to single or multiple coins:
 //
-//	NewCandleClient("1m", "BTC")           // single coin
-//	NewCandleClient("1m", "BTC", "ETH")    // multiple coins
-func NewCandleClient(interval string, coins ...string) *Client[[]Candle] {
-	sub := map[string]any{
-		"type":     "candle",
-		"interval": interval,
+//	NewCandleClient(Interval1m, "BTC")           // single coin
+//	NewCandleClient(Interval1m, "BTC", "ETH")    // multiple coins
+//
+// An interval the feed does not offer makes the first Read fail.
+func NewCandleClient(interval Interval, coins ...string) *Client[[]Candle] {
+	subs := make([]Subscription, len(coins))
+	for i, coin := range coins {
+		subs[i] = CandleSubscription(coin, interval)
 	}
-	if len(coins) == 1 {
-		sub["coin"] = coins[0]
-	} else {
-		sub["coin"] = coins
+	return newClient[[]Candle](MainnetWsURL, subs...)
+}
+
+// NewCandleIntervalsClient creates a client for subscribing to candle updates of one
+// coin at several intervals, e.g. for multi-timeframe strategies. Candle.Interval tells
+// the interval of each candle:
+//
+//	NewCandleIntervalsClient("BTC", Interval1m, Interval1h)
+//
+// An interval the feed does not offer makes the first Read fail.
+func NewCandleIntervalsClient(coin string, intervals ...Interval) *Client[[]Candle] {
+	subs := make([]Subscription, len(intervals))
+	for i, interval := range intervals {
+		subs[i] = CandleSubscription(coin, interval)
 	}
-	return newClient[[]Candle](MainnetWsURL, sub)
+	return newClient[[]Candle](MainnetWsURL, subs...)
 }
 
 // NewAllMidsClient creates a client for subscribing to all mid prices
 func NewAllMidsClient() *Client[AllMids] {
-	return newClient[AllMids](MainnetWsURL, map[string]any{
-		"type": "allMids",
-	})
+	return newClient[AllMids](MainnetWsURL, AllMidsSubscription())
+}
+
+// NewDexAllMidsClient creates a client for subscribing to the mid prices of one or
+// more builder-deployed perp dexs, tagged with their dex:
+//
+//	NewDexAllMidsClient("xyz")            // single dex
+//	NewDexAllMidsClient("xyz", "abc")     // multiple dexs
+func NewDexAllMidsClient(dexs ...string) *Client[DexMids] {
+	return newClient[DexMids](MainnetWsURL, coinSubscriptions(DexAllMidsSubscription, dexs)...)
 }
 
 // NewBboClient creates a client for subscribing to best bid/offer updates
@@ -431,47 +896,64 @@ func NewAllMidsClient() *Client[AllMids] {
 //	NewBboClient("BTC")           // single coin
 //	NewBboClient("BTC", "ETH")    // multiple coins
 func NewBboClient(coins ...string) *Client[WsBbo] {
-	sub := map[string]any{
-		"type": "bbo",
-	}
-	if len(coins) == 1 {
-		sub["coin"] = coins[0]
-	} else {
-		sub["coin"] = coins
-	}
-	return newClient[WsBbo](MainnetWsURL, sub)
+	return newClient[WsBbo](MainnetWsURL, coinSubscriptions(BBOSubscription, coins)...)
 }
 
 // NewUserFundingsClient creates a client for subscribing to user funding payments
 func NewUserFundingsClient(user string) *Client[WsUserFundings] {
-	return newClient[WsUserFundings](MainnetWsURL, map[string]any{
-		"type": "userFundings",
-		"user": user,
-	})
+	return newClient[WsUserFundings](MainnetWsURL, UserFundingsSubscription(user))
+}
+
+// NewUserNonFundingLedgerUpdatesClient creates a client for subscribing to non-funding ledger updates
+// (deposits, withdrawals, transfers, liquidations, vault flows, ...)
+func NewUserNonFundingLedgerUpdatesClient(user string) *Client[WsUserNonFundingLedgerUpdates] {
+	return newClient[WsUserNonFundingLedgerUpdates](MainnetWsURL, UserNonFundingLedgerUpdatesSubscription(user))
+}
+
+// NewUserTwapSliceFillsClient creates a client for subscribing to fills of a user's TWAP slices
+func NewUserTwapSliceFillsClient(user string) *Client[WsUserTwapSliceFills] {
+	return newClient[WsUserTwapSliceFills](MainnetWsURL, UserTwapSliceFillsSubscription(user))
+}
+
+// NewUserTwapHistoryClient creates a client for subscribing to a user's TWAP history.
+// Each entry reports a TWAP being activated, terminated, finished or failing.
+func NewUserTwapHistoryClient(user string) *Client[WsUserTwapHistory] {
+	return newClient[WsUserTwapHistory](MainnetWsURL, UserTwapHistorySubscription(user))
+}
+
+// NewWebData2Client creates a client for subscribing to a user's aggregate account data
+// (positions, open orders, spot balances, agent and market contexts)
+func NewWebData2Client(user string) *Client[WebData2] {
+	return newClient[WebData2](MainnetWsURL, WebData2Subscription(user))
+}
+
+// NewNotificationClient creates a client for subscribing to exchange notifications for a user
+func NewNotificationClient(user string) *Client[Notification] {
+	return newClient[Notification](MainnetWsURL, NotificationSubscription(user))
 }
 
 // NewActiveAssetCtxClient creates a client for subscribing to active asset context
-// Can subscribe to single or multiple coins:
+// of perp or spot assets. Each update has either Perp or Spot set:
 //
 //	NewActiveAssetCtxClient("BTC")           // single coin
-//	NewActiveAssetCtxClient("BTC", "ETH")    // multiple coins
-func NewActiveAssetCtxClient(coins ...string) *Client[any] {
-	sub := map[string]any{
-		"type": "activeAssetCtx",
-	}
-	if len(coins) == 1 {
-		sub["coin"] = coins[0]
-	} else {
-		sub["coin"] = coins
-	}
-	return newClient[any](MainnetWsURL, sub)
+//	NewActiveAssetCtxClient("BTC", "@107")   // perp and spot coins
+func NewActiveAssetCtxClient(coins ...string) *Client[WsAssetCtx] {
+	return newClient[WsAssetCtx](MainnetWsURL, coinSubscriptions(ActiveAssetCtxSubscription, coins)...)
+}
+
+// NewPerpAssetCtxClient creates a client for subscribing to active asset context of perps
+// (funding, open interest, oracle price, ...). Updates for spot coins are skipped.
+func NewPerpAssetCtxClient(coins ...string) *Client[WsActiveAssetCtx] {
+	return newClient[WsActiveAssetCtx](MainnetWsURL, coinSubscriptions(ActiveAssetCtxSubscription, coins)...)
+}
+
+// NewSpotAssetCtxClient creates a client for subscribing to active asset context of spot assets
+// (circulating supply, ...). Updates for perp coins are skipped.
+func NewSpotAssetCtxClient(coins ...string) *Client[WsActiveSpotAssetCtx] {
+	return newClient[WsActiveSpotAssetCtx](MainnetWsURL, coinSubscriptions(ActiveAssetCtxSubscription, coins)...)
 }
 
 // NewActiveAssetDataClient creates a client for subscribing to active asset data
 func NewActiveAssetDataClient(user string, coin string) *Client[WsActiveAssetData] {
-	return newClient[WsActiveAssetData](MainnetWsURL, map[string]any{
-		"type": "activeAssetData",
-		"user": user,
-		"coin": coin,
-	})
+	return newClient[WsActiveAssetData](MainnetWsURL, ActiveAssetDataSubscription(user, coin))
 }
@@ -0,0 +1,244 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/types"
+)
+
+// infoResponsePayload is the payload of an info post response
+type infoResponsePayload struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// PostInfo sends an info request over c and decodes the response data into T.
+// The payload is the same as the body of an HTTP /info request:
+//
+//	book, err := ws.PostInfo[types.L2BookData](client, 5*time.Second, map[string]any{
+//	    "type": "l2Book",
+//	    "coin": "BTC",
+//	})
+func PostInfo[T any](c *PostOnlyClient, timeout time.Duration, payload any) (result T, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	waiter, err := c.RequestContext(ctx, PostRequestTypeInfo, payload)
+	if err != nil {
+		return result, fmt.Errorf("failed to request: %w", err)
+	}
+
+	resp := <-waiter.Chan()
+	if errors.Is(resp.Err, context.DeadlineExceeded) {
+		return result, fmt.Errorf("request timed out: %w", resp.Err)
+	}
+	if resp.Err != nil {
+		return result, fmt.Errorf("failed to get response: %w", resp.Err)
+	}
+
+	if resp.Data.Response.Type == PostResponseError {
+		return result, errors.New(string(resp.Data.Response.Payload))
+	}
+
+	var info infoResponsePayload
+	if err := json.Unmarshal(resp.Data.Response.Payload, &info); err != nil {
+		return result, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := json.Unmarshal(info.Data, &result); err != nil {
+		return result, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result, nil
+}
+
+// WsInfo provides typed info queries over the WebSocket post channel, for
+// deployments that only keep a WebSocket connection open.
+//
+//	client := ws.NewPostOnlyClient()
+//	if err := client.Start(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	info := ws.NewWsInfo(client)
+//	book, err := info.L2Book("BTC")
+//
+// Coins are passed as they appear on the wire ("BTC", "@107"), without the name
+// lookup of client.Info. WsInfo is safe for concurrent use.
+type WsInfo struct {
+	client  *PostOnlyClient
+	timeout time.Duration
+}
+
+// NewWsInfo creates typed info queries over a started client
+func NewWsInfo(client *PostOnlyClient) *WsInfo {
+	return &WsInfo{
+		client:  client,
+		timeout: constants.DefaultTimeout * time.Second,
+	}
+}
+
+// SetTimeout sets how long to wait for each response
+func (i *WsInfo) SetTimeout(timeout time.Duration) {
+	i.timeout = timeout
+}
+
+// UserState retrieves trading details about a user
+func (i *WsInfo) UserState(user string, dex string) (*types.UserState, error) {
+	result, err := PostInfo[types.UserState](i.client, i.timeout, map[string]any{
+		"type": "clearinghouseState",
+		"user": user,
+		"dex":  dex,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SpotUserState retrieves spot trading state for a user
+func (i *WsInfo) SpotUserState(user string) (*types.SpotUserState, error) {
+	result, err := PostInfo[types.SpotUserState](i.client, i.timeout, map[string]any{
+		"type": "spotClearinghouseState",
+		"user": user,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// OpenOrders retrieves a user's open orders
+func (i *WsInfo) OpenOrders(user string, dex string) ([]types.OpenOrder, error) {
+	return PostInfo[[]types.OpenOrder](i.client, i.timeout, map[string]any{
+		"type": "openOrders",
+		"user": user,
+		"dex":  dex,
+	})
+}
+
+// FrontendOpenOrders retrieves a user's open orders with additional frontend info
+func (i *WsInfo) FrontendOpenOrders(user string, dex string) ([]types.FrontendOpenOrder, error) {
+	return PostInfo[[]types.FrontendOpenOrder](i.client, i.timeout, map[string]any{
+		"type": "frontendOpenOrders",
+		"user": user,
+		"dex":  dex,
+	})
+}
+
+// AllMids retrieves all mid prices for actively traded coins
+func (i *WsInfo) AllMids(dex string) (map[string]string, error) {
+	return PostInfo[map[string]string](i.client, i.timeout, map[string]any{
+		"type": "allMids",
+		"dex":  dex,
+	})
+}
+
+// UserFills retrieves a given user's fills
+func (i *WsInfo) UserFills(user string) ([]types.Fill, error) {
+	return PostInfo[[]types.Fill](i.client, i.timeout, map[string]any{
+		"type": "userFills",
+		"user": user,
+	})
+}
+
+// Meta retrieves exchange perpetual metadata
+func (i *WsInfo) Meta(dex string) (*types.Meta, error) {
+	result, err := PostInfo[types.Meta](i.client, i.timeout, map[string]any{
+		"type": "meta",
+		"dex":  dex,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SpotMeta retrieves exchange spot metadata
+func (i *WsInfo) SpotMeta() (*types.SpotMeta, error) {
+	result, err := PostInfo[types.SpotMeta](i.client, i.timeout, map[string]any{
+		"type": "spotMeta",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// L2Book retrieves an L2 order book snapshot for a coin
+func (i *WsInfo) L2Book(coin string) (*types.L2BookData, error) {
+	result, err := PostInfo[types.L2BookData](i.client, i.timeout, map[string]any{
+		"type": "l2Book",
+		"coin": coin,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CandlesSnapshot retrieves candles for a coin between startTime and endTime (millis)
+func (i *WsInfo) CandlesSnapshot(coin string, interval string, startTime int64, endTime int64) ([]types.Candle, error) {
+	return PostInfo[[]types.Candle](i.client, i.timeout, map[string]any{
+		"type": "candleSnapshot",
+		"req": map[string]any{
+			"coin":      coin,
+			"interval":  interval,
+			"startTime": startTime,
+			"endTime":   endTime,
+		},
+	})
+}
+
+// FundingHistory retrieves funding history for a coin
+func (i *WsInfo) FundingHistory(coin string, startTime int64, endTime *int64) ([]types.FundingRecord, error) {
+	payload := map[string]any{
+		"type":      "fundingHistory",
+		"coin":      coin,
+		"startTime": startTime,
+	}
+	if endTime != nil {
+		payload["endTime"] = *endTime
+	}
+	return PostInfo[[]types.FundingRecord](i.client, i.timeout, payload)
+}
+
+// UserFees retrieves the volume of trading activity associated with a user
+func (i *WsInfo) UserFees(user string) (*types.UserFees, error) {
+	result, err := PostInfo[types.UserFees](i.client, i.timeout, map[string]any{
+		"type": "userFees",
+		"user": user,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// OrderStatus queries the status of an order by order ID
+func (i *WsInfo) OrderStatus(user string, oid int64) (*types.OrderQueryResponse, error) {
+	result, err := PostInfo[types.OrderQueryResponse](i.client, i.timeout, map[string]any{
+		"type": "orderStatus",
+		"user": user,
+		"oid":  oid,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ActiveAssetData retrieves a user's active asset data for a coin
+func (i *WsInfo) ActiveAssetData(user string, coin string) (*types.ActiveAssetData, error) {
+	result, err := PostInfo[types.ActiveAssetData](i.client, i.timeout, map[string]any{
+		"type": "activeAssetData",
+		"user": user,
+		"coin": coin,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,117 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type testPostRequest struct {
+	Method  string `json:"method"`
+	ID      int64  `json:"id"`
+	Request struct {
+		Type    string         `json:"type"`
+		Payload map[string]any `json:"payload"`
+	} `json:"request"`
+}
+
+// writeInfoResponse answers an info post request with data
+func writeInfoResponse(conn *websocket.Conn, req testPostRequest, data string) error {
+	return conn.WriteJSON(map[string]any{
+		"channel": "post",
+		"data": map[string]any{
+			"id": req.ID,
+			"response": map[string]any{
+				"type": "info",
+				"payload": map[string]any{
+					"type": req.Request.Payload["type"],
+					"data": json.RawMessage(data),
+				},
+			},
+		},
+	})
+}
+
+func TestWsInfoCorrelatesResponses(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		// Answer two requests in reverse order
+		var reqs []testPostRequest
+		for len(reqs) < 2 {
+			var req testPostRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method == "post" {
+				reqs = append(reqs, req)
+			}
+		}
+		for i := len(reqs) - 1; i >= 0; i-- {
+			switch reqs[i].Request.Payload["type"] {
+			case "l2Book":
+				writeInfoResponse(conn, reqs[i], `{"coin":"`+reqs[i].Request.Payload["coin"].(string)+`","levels":[[],[]],"time":1}`)
+			case "allMids":
+				writeInfoResponse(conn, reqs[i], `{"BTC":"100.5"}`)
+			}
+		}
+		conn.ReadMessage()
+	})
+
+	client := NewPostOnlyClient()
+	client.SetURL(server.URL())
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Close()
+
+	info := NewWsInfo(client)
+	info.SetTimeout(2 * time.Second)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		book, err := info.L2Book("ETH")
+		if err != nil || book.Coin != "ETH" {
+			t.Errorf("L2Book() = %+v, %v", book, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		mids, err := info.AllMids("")
+		if err != nil || mids["BTC"] != "100.5" {
+			t.Errorf("AllMids() = %v, %v", mids, err)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestPostInfoError(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		var req testPostRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		conn.WriteJSON(map[string]any{
+			"channel": "post",
+			"data": map[string]any{
+				"id":       req.ID,
+				"response": map[string]any{"type": "error", "payload": "unknown request"},
+			},
+		})
+		conn.ReadMessage()
+	})
+
+	client := NewPostOnlyClient()
+	client.SetURL(server.URL())
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Close()
+
+	if _, err := PostInfo[map[string]string](client, 2*time.Second, map[string]any{"type": "bogus"}); err == nil {
+		t.Error("PostInfo() expected error response")
+	}
+}
@@ -3,12 +3,18 @@ package ws
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/dwdwow/hl-go/constants"
+	"github.com/dwdwow/hl-go/tracing"
 	"github.com/dwdwow/hl-go/utils"
 	"github.com/gorilla/websocket"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PostRequestType string
@@ -42,45 +48,254 @@ type PostResponseContent struct {
 	Payload json.RawMessage  `json:"payload"`
 }
 
+// ErrConnectionLost is the error of requests still awaiting a response when the
+// connection is lost. An action may have been executed nonetheless.
+var ErrConnectionLost = errors.New("connection lost before the response")
+
+// PendingRequestPolicy decides what happens to the requests awaiting a response when
+// the connection of a PostOnlyClient is lost
+type PendingRequestPolicy int
+
+const (
+	// PendingFail fails pending requests with ErrConnectionLost (the default)
+	PendingFail PendingRequestPolicy = iota
+
+	// PendingRetryInfo sends pending info requests again once reconnected, within their
+	// timeout. Action requests still fail, since they may have been executed.
+	PendingRetryInfo
+)
+
 type PostOnlyRespWaiter struct {
 	ID int64
 	ch chan *PostResponse
+	// stop releases the context of the request once it is answered
+	stop func()
+	// span traces the request until its response
+	span trace.Span
+	// msg is the request as sent, for resending it after a reconnection
+	msg     any
+	reqType PostRequestType
 }
 
 func (w *PostOnlyRespWaiter) Chan() <-chan *PostResponse {
 	return w.ch
 }
 
+// finish releases the request once it is answered or failed with err
+func (w PostOnlyRespWaiter) finish(err error) {
+	w.stop()
+	tracing.End(w.span, err)
+}
+
 type PostOnlyClient struct {
-	url     string
-	conn    *websocket.Conn
-	writeMu sync.Mutex
+	url         string
+	dialer      *websocket.Dialer
+	header      http.Header
+	compression bool
+	// handshakeTimeout overrides the handshake timeout of the dialer if positive
+	handshakeTimeout time.Duration
+	writeMu          sync.Mutex
+
+	// connMu guards the current connection, which changes when reconnecting
+	connMu    sync.Mutex
+	conn      *websocket.Conn
+	cancel    context.CancelFunc
+	heartbeat *heartbeat
+
+	// startMu serializes Start and Close; stop is closed by Close to end the run loop,
+	// which closes runDone when it returns
+	startMu sync.Mutex
+	stop    chan struct{}
+	runDone chan struct{}
+
+	reconnectPolicy *ReconnectPolicy
+	onReconnect     func(ReconnectEvent)
+	pendingPolicy   PendingRequestPolicy
 
 	id            int64
 	respWaiters   map[int64]PostOnlyRespWaiter
 	respWaitersMu sync.Mutex
 
-	ctx          context.Context
-	cancel       context.CancelFunc
-	pingInterval time.Duration
+	pingInterval   time.Duration
+	pongTimeout    time.Duration
+	requestTimeout time.Duration
+	closeTimeout   time.Duration
+
+	lifecycle lifecycle
+
+	metrics         connMetrics
+	metricsInterval time.Duration
+	metricsHook     func(Stats)
+	rawTap          func(frame []byte)
+	tracer          trace.Tracer
 }
 
 func NewPostOnlyClient() *PostOnlyClient {
 	return &PostOnlyClient{
 		url:          MainnetWsURL,
-		pingInterval: 40 * time.Second,                   // Default ping interval
+		pingInterval: DefaultPingInterval,
+		closeTimeout: DefaultCloseTimeout,
 		respWaiters:  make(map[int64]PostOnlyRespWaiter), // Initialize respWaiters to avoid nil map panic
 	}
 }
 
+// SetURL sets the WebSocket URL to connect to. Must be called before Start.
+func (c *PostOnlyClient) SetURL(url string) {
+	c.url = url
+}
+
+// SetNetwork connects the client to the WebSocket URL of network. Must be called before Start.
+func (c *PostOnlyClient) SetNetwork(network constants.Network) {
+	c.url = network.WsURL
+}
+
+// SetDialer sets the dialer used to connect, e.g. to go through a proxy or to pin
+// TLS certificates. See NewDialer for the default. Must be called before Start.
+func (c *PostOnlyClient) SetDialer(dialer *websocket.Dialer) {
+	c.dialer = dialer
+}
+
+// SetHeader sets extra HTTP headers sent with the handshake. Must be called before Start.
+func (c *PostOnlyClient) SetHeader(header http.Header) {
+	c.header = header
+}
+
+// SetCompression negotiates permessage-deflate compression with the server, which cuts
+// bandwidth several times for large feeds such as l2Book at the cost of CPU time to
+// inflate frames. Compression is used only if the server accepts it. Also enabled by a
+// dialer with EnableCompression set. Must be called before Start.
+func (c *PostOnlyClient) SetCompression(enabled bool) {
+	c.compression = enabled
+}
+
+// SetPingInterval sets how often the client pings the server. The server closes
+// connections that sent nothing for 60 seconds, so keep it well below that.
+// Defaults to DefaultPingInterval, also used for zero or negative intervals. Must be
+// called before Start.
+func (c *PostOnlyClient) SetPingInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPingInterval
+	}
+	c.pingInterval = interval
+}
+
+// SetPongTimeout sets how long the server may take to answer a ping before the
+// connection is considered dead. The connection is then handled like any connection
+// loss, see SetReconnectPolicy. Zero disables the check (the default).
+// Must be called before Start.
+func (c *PostOnlyClient) SetPongTimeout(timeout time.Duration) {
+	c.pongTimeout = timeout
+}
+
+// SetHandshakeTimeout overrides the handshake timeout of the dialer, which defaults to
+// DefaultHandshakeTimeout. Must be called before Start.
+func (c *PostOnlyClient) SetHandshakeTimeout(timeout time.Duration) {
+	c.handshakeTimeout = timeout
+}
+
+// State returns the connection state. Safe to call from any goroutine.
+func (c *PostOnlyClient) State() ConnState {
+	return c.lifecycle.current()
+}
+
+// SetCloseTimeout sets how long Close waits for the server to answer the close frame.
+// Zero closes the connection without the closing handshake. Defaults to DefaultCloseTimeout.
+func (c *PostOnlyClient) SetCloseTimeout(timeout time.Duration) {
+	c.closeTimeout = timeout
+}
+
+// SetReconnectPolicy enables automatic reconnection when the connection is lost.
+// Requests pending at that point are handled according to SetPendingRequestPolicy,
+// and new requests fail until the client is connected again. Pass nil to disable
+// reconnection (the default). Must be called before Start.
+func (c *PostOnlyClient) SetReconnectPolicy(policy *ReconnectPolicy) {
+	c.reconnectPolicy = policy
+}
+
+// OnReconnect sets a callback invoked after every reconnection attempt. It runs on the
+// client's background reader. Must be called before Start.
+func (c *PostOnlyClient) OnReconnect(fn func(ReconnectEvent)) {
+	c.onReconnect = fn
+}
+
+// SetPendingRequestPolicy sets what happens to requests awaiting a response when the
+// connection is lost and a reconnect policy is set. Defaults to PendingFail.
+// Must be called before Start.
+func (c *PostOnlyClient) SetPendingRequestPolicy(policy PendingRequestPolicy) {
+	c.pendingPolicy = policy
+}
+
+// OnConnect sets a callback invoked when Start has connected, and after every reconnection.
+// The callbacks set with OnConnect and OnDisconnect must not call Start or Close.
+func (c *PostOnlyClient) OnConnect(fn func()) {
+	c.lifecycle.setHooks(fn, nil)
+}
+
+// OnDisconnect sets a callback invoked when the connection ends, with the error that
+// ended it, or nil after Close. It runs on the client's background reader or on Close.
+func (c *PostOnlyClient) OnDisconnect(fn func(err error)) {
+	c.lifecycle.setHooks(nil, fn)
+}
+
+// Stats returns traffic statistics of the connection. Safe to call from any goroutine.
+func (c *PostOnlyClient) Stats() Stats {
+	return c.metrics.stats()
+}
+
+// SetRawTap sets a callback receiving every inbound frame before decoding. fn runs on the
+// read goroutine; it must not block or modify frame. Must be called before Start.
+func (c *PostOnlyClient) SetRawTap(fn func(frame []byte)) {
+	c.rawTap = fn
+}
+
+// SetMetricsHook makes the client call fn with its Stats every interval while connected.
+// fn runs on a background goroutine and must not block. Must be called before Start.
+func (c *PostOnlyClient) SetMetricsHook(interval time.Duration, fn func(Stats)) {
+	c.metricsInterval = interval
+	c.metricsHook = fn
+}
+
+// SetTracerProvider sets the provider of the span traced for every request until its
+// response, with the request id. Without it the global provider of otel is used. Must be
+// called before Start.
+func (c *PostOnlyClient) SetTracerProvider(tp trace.TracerProvider) {
+	c.tracer = tracing.Tracer(tp)
+}
+
+// SetRequestTimeout sets how long requests wait for their response. An unanswered request
+// then gets a response whose Err wraps context.DeadlineExceeded. Zero disables the timeout
+// (the default), in which case requests wait until the connection closes.
+func (c *PostOnlyClient) SetRequestTimeout(timeout time.Duration) {
+	c.requestTimeout = timeout
+}
+
+// Request sends a post request. The response, or an error in its Err field, is delivered
+// once on the channel of waiter.
 func (c *PostOnlyClient) Request(magType PostRequestType, payload any) (waiter PostOnlyRespWaiter, err error) {
+	return c.RequestContext(context.Background(), magType, payload)
+}
+
+// RequestContext is like Request, but gives up waiting for the response once ctx is done:
+// the waiter then gets a response whose Err wraps ctx.Err() and is forgotten by the client.
+func (c *PostOnlyClient) RequestContext(ctx context.Context, magType PostRequestType, payload any) (waiter PostOnlyRespWaiter, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	cancel := func() {}
+	if c.requestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+	}
+
 	c.writeMu.Lock()
 	defer c.writeMu.Unlock()
-	if c.conn == nil {
+	conn := c.currentConn()
+	if conn == nil || c.lifecycle.current() != StateConnected {
+		cancel()
 		err = fmt.Errorf("client not connected")
 		return
 	}
 	c.id++
+	id := c.id
 	msg := utils.NewOrderedMap(
 		"method", "post",
 		"id", c.id,
@@ -89,107 +304,369 @@ func (c *PostOnlyClient) Request(magType PostRequestType, payload any) (waiter P
 			"payload", payload,
 		),
 	)
-	err = c.conn.WriteJSON(msg)
-	if err != nil {
-		return
+	tracer := c.tracer
+	if tracer == nil {
+		tracer = tracing.Tracer(nil)
 	}
+	_, span := tracer.Start(ctx, tracing.SpanWsPost, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		tracing.RPCSystem,
+		semconv.NetworkProtocolName("websocket"),
+		tracing.WsRequestIDKey.Int64(id),
+		tracing.WsRequestTypeKey.String(string(magType)),
+	))
+	// Register the waiter first: the response can arrive before WriteJSON returns
+	stopExpiry := context.AfterFunc(ctx, func() {
+		c.expire(id, ctx.Err())
+	})
 	waiter = PostOnlyRespWaiter{
-		ID: c.id,
+		ID: id,
 		ch: make(chan *PostResponse, 1),
+		stop: func() {
+			stopExpiry()
+			cancel()
+		},
+		span:    span,
+		msg:     msg,
+		reqType: magType,
 	}
 	c.respWaitersMu.Lock()
-	c.respWaiters[c.id] = waiter
+	c.respWaiters[id] = waiter
 	c.respWaitersMu.Unlock()
+	err = conn.WriteJSON(msg)
+	if err != nil {
+		c.respWaitersMu.Lock()
+		delete(c.respWaiters, id)
+		c.respWaitersMu.Unlock()
+		waiter.finish(err)
+		return
+	}
+	// ctx may have expired before the waiter was registered
+	if ctx.Err() != nil {
+		c.expire(id, ctx.Err())
+	}
 	return
 }
 
+// expire fails the pending request id with err, if it is still pending
+func (c *PostOnlyClient) expire(id int64, err error) {
+	c.respWaitersMu.Lock()
+	waiter, ok := c.respWaiters[id]
+	delete(c.respWaiters, id)
+	c.respWaitersMu.Unlock()
+	if !ok {
+		return
+	}
+	err = fmt.Errorf("post request %d: %w", id, err)
+	waiter.finish(err)
+	waiter.ch <- &PostResponse{Err: err}
+	close(waiter.ch)
+}
+
+// Start connects to the server. Calling Start on a connected client is a no-op, and
+// calling it after Close or after the connection was lost connects again.
 func (c *PostOnlyClient) Start() error {
-	// Create context for controlling the ping goroutine
-	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.startMu.Lock()
+	defer c.startMu.Unlock()
+
+	switch c.lifecycle.current() {
+	case StateConnected, StateReconnecting:
+		return nil
+	}
+
+	c.lifecycle.set(StateConnecting)
+	if err := c.connect(); err != nil {
+		c.lifecycle.set(StateDisconnected)
+		return err
+	}
+	c.stop = make(chan struct{})
+	c.lifecycle.connected()
 
-	// Connect to WebSocket
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
+	c.runDone = make(chan struct{})
+	go c.run(c.stop, c.runDone)
+
+	return nil
+}
+
+// Close fails the pending requests and closes the connection with a close frame,
+// waiting up to the close timeout for the server's (see SetCloseTimeout).
+// The client can be started again.
+func (c *PostOnlyClient) Close() error {
+	c.startMu.Lock()
+	c.lifecycle.disconnected(StateClosed, nil)
+	// Close stop first, so the run loop does not reconnect
+	reader := c.runDone
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	c.failPending(nil, fmt.Errorf("websocket closed"))
+	c.connMu.Lock()
+	conn, cancel := c.conn, c.cancel
+	c.connMu.Unlock()
+	// The run loop needs startMu to exit
+	c.startMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if conn == nil {
+		return nil
+	}
+	return closeGracefully(conn, c.closeTimeout, reader)
+}
+
+// connect dials and installs the new connection
+func (c *PostOnlyClient) connect() error {
+	conn, err := c.dialConn()
+	if err != nil {
+		return err
 	}
+	c.install(conn)
+	return nil
+}
 
-	conn, _, err := dialer.Dial(c.url, nil)
+func (c *PostOnlyClient) dialConn() (*websocket.Conn, error) {
+	conn, err := dial(c.url, dialOptions{
+		dialer:           c.dialer,
+		header:           c.header,
+		compression:      c.compression,
+		handshakeTimeout: c.handshakeTimeout,
+	})
 	if err != nil {
-		c.cancel()
-		return fmt.Errorf("failed to connect to websocket: %w", err)
+		return nil, fmt.Errorf("failed to connect to websocket: %w", err)
+	}
+	return conn, nil
+}
+
+// install makes conn the current connection and starts its ping routine
+func (c *PostOnlyClient) install(conn *websocket.Conn) {
+	// Create context for controlling the ping goroutine
+	ctx, cancel := context.WithCancel(context.Background())
+	hb := &heartbeat{
+		interval:    c.pingInterval,
+		pongTimeout: c.pongTimeout,
+		metrics:     &c.metrics,
+		continuous:  true,
 	}
+	c.metrics.reset()
 
-	c.conn = conn
+	c.writeMu.Lock()
+	c.connMu.Lock()
+	c.conn, c.cancel, c.heartbeat = conn, cancel, hb
+	c.connMu.Unlock()
+	c.writeMu.Unlock()
 
 	// Start ping goroutine
-	go c.pingRoutine()
-	go c.Read()
+	go c.pingRoutine(ctx, conn, hb)
+	if c.metricsHook != nil && c.metricsInterval > 0 {
+		go reportMetrics(ctx, c.metricsInterval, c.metricsHook, c.Stats)
+	}
+}
 
-	return nil
+func (c *PostOnlyClient) currentConn() *websocket.Conn {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.conn
 }
 
-func (c *PostOnlyClient) Close() error {
+// closeConn stops the ping routine and closes the connection
+func (c *PostOnlyClient) closeConn() error {
+	c.connMu.Lock()
+	conn, cancel := c.conn, c.cancel
+	c.connMu.Unlock()
+
 	// Cancel context to stop ping goroutine
-	if c.cancel != nil {
-		c.cancel()
+	if cancel != nil {
+		cancel()
 	}
 
-	if c.conn != nil {
-		err := c.conn.Close()
+	if conn != nil {
+		err := conn.Close()
 		return err
 	}
 
 	return nil
 }
 
-// pingRoutine runs in a goroutine and sends periodic ping messages
-// It stops when the context is canceled
-func (c *PostOnlyClient) pingRoutine() {
-	ticker := time.NewTicker(c.pingInterval)
-	defer ticker.Stop()
+// run reads responses until Close, reconnecting according to the reconnect policy.
+// State changes are made under startMu and only while stop is open, so that they
+// cannot override Close or a later Start.
+func (c *PostOnlyClient) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for {
+		c.connMu.Lock()
+		conn, hb := c.conn, c.heartbeat
+		c.connMu.Unlock()
+
+		cause := c.readLoop(conn, hb)
 
-	defer func() {
-		c.Close()
-		c.respWaitersMu.Lock()
-		for _, waiter := range c.respWaiters {
-			waiter.ch <- &PostResponse{Err: fmt.Errorf("websocket closed")}
-			close(waiter.ch)
+		c.startMu.Lock()
+		if isClosed(stop) {
+			// Close failed the pending requests
+			c.startMu.Unlock()
+			return
 		}
-		c.respWaiters = make(map[int64]PostOnlyRespWaiter)
-		c.respWaitersMu.Unlock()
-	}()
+		c.closeConn()
+		lost := fmt.Errorf("%w: %w", ErrConnectionLost, cause)
+		if c.reconnectPolicy == nil {
+			c.lifecycle.disconnected(StateDisconnected, cause)
+			c.failPending(nil, lost)
+			c.startMu.Unlock()
+			return
+		}
+		c.lifecycle.disconnected(StateReconnecting, cause)
+		var keep func(PostOnlyRespWaiter) bool
+		if c.pendingPolicy == PendingRetryInfo {
+			keep = func(w PostOnlyRespWaiter) bool { return w.reqType == PostRequestTypeInfo }
+		}
+		c.failPending(keep, lost)
+		c.startMu.Unlock()
+
+		if err := c.reconnect(stop, cause); err != nil {
+			c.startMu.Lock()
+			defer c.startMu.Unlock()
+			if !isClosed(stop) {
+				c.lifecycle.set(StateDisconnected)
+				c.failPending(nil, err)
+			}
+			return
+		}
+		c.resendPending()
+	}
+}
 
-	for {
+// reconnect re-dials with backoff after the connection failed with cause,
+// and installs the new connection unless Close was called meanwhile
+func (c *PostOnlyClient) reconnect(stop <-chan struct{}, cause error) error {
+	policy := c.reconnectPolicy
+	var lastErr error
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		delay := policy.Backoff(attempt)
+		timer := time.NewTimer(delay)
 		select {
-		case <-c.ctx.Done():
-			// Context canceled - stop ping routine
-			return
-		case <-ticker.C:
-			// Check connection status (no lock needed - single threaded use)
-			conn := c.conn
-
-			if conn != nil {
-				// Send ping with write lock (only lock needed for concurrent writes)
-				pingMsg := map[string]string{"method": "ping"}
-				err := conn.WriteJSON(pingMsg)
-
-				if err != nil {
-					// Failed to send ping - connection likely broken
-					return
-				}
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return fmt.Errorf("websocket closed")
+		}
+
+		var conn *websocket.Conn
+		conn, lastErr = c.dialConn()
+		if lastErr == nil {
+			c.startMu.Lock()
+			if isClosed(stop) {
+				c.startMu.Unlock()
+				conn.Close()
+				return fmt.Errorf("websocket closed")
 			}
+			c.install(conn)
+			c.lifecycle.connected()
+			c.startMu.Unlock()
+		}
+		if c.onReconnect != nil {
+			c.onReconnect(ReconnectEvent{Attempt: attempt, Cause: cause, Delay: delay, Err: lastErr})
+		}
+		if lastErr == nil {
+			return nil
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = cause
+	}
+	return fmt.Errorf("failed to reconnect after %d attempts: %w", policy.MaxAttempts, lastErr)
+}
+
+// failPending fails the pending requests with err, except those kept by keep
+func (c *PostOnlyClient) failPending(keep func(PostOnlyRespWaiter) bool, err error) {
+	c.respWaitersMu.Lock()
+	var failed []PostOnlyRespWaiter
+	for id, waiter := range c.respWaiters {
+		if keep != nil && keep(waiter) {
+			continue
 		}
+		delete(c.respWaiters, id)
+		failed = append(failed, waiter)
+	}
+	c.respWaitersMu.Unlock()
+
+	for _, waiter := range failed {
+		waiter.finish(err)
+		waiter.ch <- &PostResponse{Err: err}
+		close(waiter.ch)
 	}
 }
 
+// resendPending sends the requests kept by failPending again on the new connection
+func (c *PostOnlyClient) resendPending() {
+	c.respWaitersMu.Lock()
+	pending := make([]PostOnlyRespWaiter, 0, len(c.respWaiters))
+	for _, waiter := range c.respWaiters {
+		pending = append(pending, waiter)
+	}
+	c.respWaitersMu.Unlock()
+
+	for _, waiter := range pending {
+		c.writeMu.Lock()
+		err := c.currentConn().WriteJSON(waiter.msg)
+		c.writeMu.Unlock()
+		if err != nil {
+			c.expire(waiter.ID, fmt.Errorf("failed to resend request: %w", err))
+		}
+	}
+}
+
+// isClosed reports whether stop is closed
+func isClosed(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// pingRoutine runs in a goroutine and sends periodic ping messages on conn
+// It stops when ctx is canceled, closing conn if the heartbeat failed
+func (c *PostOnlyClient) pingRoutine(ctx context.Context, conn *websocket.Conn, hb *heartbeat) {
+	hb.run(ctx, conn, func() error {
+		// Send ping with write lock (only lock needed for concurrent writes)
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		return conn.WriteJSON(map[string]string{"method": "ping"})
+	})
+	if ctx.Err() == nil {
+		conn.Close()
+	}
+}
+
+// Read processes responses on the current connection until it fails.
+//
+// Deprecated: Start runs the read loop in the background; Read does not need to be called.
 func (c *PostOnlyClient) Read() {
+	c.connMu.Lock()
+	conn, hb := c.conn, c.heartbeat
+	c.connMu.Unlock()
+	if conn != nil {
+		c.readLoop(conn, hb)
+	}
+}
+
+// readLoop delivers the responses received on conn to their waiters until conn fails,
+// returning the error that ended it
+func (c *PostOnlyClient) readLoop(conn *websocket.Conn, hb *heartbeat) error {
 	for {
-		if c.ctx.Err() != nil {
-			return
-		}
 		// Read raw message (blocking)
-		_, rawMsg, readErr := c.conn.ReadMessage()
+		_, rawMsg, readErr := conn.ReadMessage()
 		if readErr != nil {
-			return
+			if hbErr := hb.err(); hbErr != nil {
+				return hbErr
+			}
+			return readErr
+		}
+		c.metrics.record(rawMsg)
+		if c.rawTap != nil {
+			c.rawTap(rawMsg)
 		}
 
 		// Handle text messages like "Websocket connection established."
@@ -224,6 +701,7 @@ func (c *PostOnlyClient) Read() {
 		if resp.Data.Response.Type == PostResponseError {
 			resp.Err = fmt.Errorf("%v", string(resp.Data.Response.Payload))
 		}
+		waiter.finish(resp.Err)
 
 		waiter.ch <- resp
 
@@ -0,0 +1,362 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dwdwow/hl-go/tracing"
+)
+
+// startPostClient starts a PostOnlyClient against a server that never answers requests.
+// Each request received is sent on requests.
+func startPostClient(t *testing.T, requests chan<- testPostRequest) *PostOnlyClient {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for {
+			var req testPostRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method == "post" && requests != nil {
+				requests <- req
+			}
+		}
+	})
+	client := NewPostOnlyClient()
+	client.SetURL(server.URL())
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func pendingRequests(c *PostOnlyClient) int {
+	c.respWaitersMu.Lock()
+	defer c.respWaitersMu.Unlock()
+	return len(c.respWaiters)
+}
+
+func TestPostRequestContextExpires(t *testing.T) {
+	client := startPostClient(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	waiter, err := client.RequestContext(ctx, PostRequestTypeInfo, map[string]any{"type": "allMids"})
+	if err != nil {
+		t.Fatalf("RequestContext() error = %v", err)
+	}
+
+	select {
+	case resp := <-waiter.Chan():
+		if !errors.Is(resp.Err, context.DeadlineExceeded) {
+			t.Errorf("response Err = %v, want context.DeadlineExceeded", resp.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter not released after the context expired")
+	}
+	if _, ok := <-waiter.Chan(); ok {
+		t.Error("waiter channel not closed")
+	}
+	if n := pendingRequests(client); n != 0 {
+		t.Errorf("%d requests still pending", n)
+	}
+}
+
+func TestPostRequestTimeout(t *testing.T) {
+	client := startPostClient(t, nil)
+	client.SetRequestTimeout(50 * time.Millisecond)
+
+	waiter, err := client.Request(PostRequestTypeInfo, map[string]any{"type": "allMids"})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	select {
+	case resp := <-waiter.Chan():
+		if !errors.Is(resp.Err, context.DeadlineExceeded) {
+			t.Errorf("response Err = %v, want context.DeadlineExceeded", resp.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request did not time out")
+	}
+
+	// PostInfo reports the timeout as well
+	if _, err := PostInfo[map[string]string](client, 20*time.Millisecond, map[string]any{"type": "allMids"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PostInfo() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPostRequestContextAlreadyDone(t *testing.T) {
+	requests := make(chan testPostRequest, 1)
+	client := startPostClient(t, requests)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.RequestContext(ctx, PostRequestTypeInfo, map[string]any{"type": "allMids"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("RequestContext() error = %v, want context.Canceled", err)
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("request %d sent despite the canceled context", req.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// fastReconnect reconnects without waiting
+var fastReconnect = &ReconnectPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, Multiplier: 1}
+
+// dropAfterPosts is a server handler dropping the first connection once it received n post
+// requests, and answering info requests on the following ones
+func dropAfterPosts(n int, received chan<- testPostRequest) func(conn *websocket.Conn, index int) {
+	return func(conn *websocket.Conn, index int) {
+		for count := 0; index > 0 || count < n; {
+			var req testPostRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method != "post" {
+				continue
+			}
+			count++
+			if received != nil {
+				received <- req
+			}
+			if index > 0 && req.Request.Type == "info" {
+				if err := writeInfoResponse(conn, req, `{"BTC":"1"}`); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func awaitResponse(t *testing.T, waiter PostOnlyRespWaiter) *PostResponse {
+	t.Helper()
+	select {
+	case resp := <-waiter.Chan():
+		return resp
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no response to request %d", waiter.ID)
+		return nil
+	}
+}
+
+func TestPostClientRetriesPendingInfo(t *testing.T) {
+	received := make(chan testPostRequest, 10)
+	server := newTestServer(t, dropAfterPosts(2, received))
+
+	client := NewPostOnlyClient()
+	client.SetURL(server.URL())
+	client.SetReconnectPolicy(fastReconnect)
+	client.SetPendingRequestPolicy(PendingRetryInfo)
+	reconnects := make(chan ReconnectEvent, 3)
+	client.OnReconnect(func(ev ReconnectEvent) { reconnects <- ev })
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Close()
+
+	info, err := client.Request(PostRequestTypeInfo, map[string]any{"type": "allMids"})
+	if err != nil {
+		t.Fatalf("Request(info) error = %v", err)
+	}
+	action, err := client.Request(PostRequestTypeAction, map[string]any{"action": map[string]any{"type": "noop"}})
+	if err != nil {
+		t.Fatalf("Request(action) error = %v", err)
+	}
+
+	// The action may have been executed, it is not resent
+	if resp := awaitResponse(t, action); !errors.Is(resp.Err, ErrConnectionLost) {
+		t.Errorf("action Err = %v, want ErrConnectionLost", resp.Err)
+	}
+	resp := awaitResponse(t, info)
+	if resp.Err != nil {
+		t.Fatalf("info Err = %v, want the response after reconnecting", resp.Err)
+	}
+	if resp.Data.ID != info.ID || resp.Data.Response.Type != PostResponseInfo {
+		t.Errorf("info response = %+v", resp.Data)
+	}
+
+	select {
+	case ev := <-reconnects:
+		if ev.Attempt != 1 || ev.Err != nil || ev.Cause == nil {
+			t.Errorf("ReconnectEvent = %+v", ev)
+		}
+	default:
+		t.Error("OnReconnect not called")
+	}
+	if got := client.State(); got != StateConnected {
+		t.Errorf("State() = %v, want %v", got, StateConnected)
+	}
+	if n := pendingRequests(client); n != 0 {
+		t.Errorf("%d requests still pending", n)
+	}
+
+	// The info request was sent again under the same id
+	var resent []testPostRequest
+	for len(received) > 0 {
+		if req := <-received; req.ID == info.ID {
+			resent = append(resent, req)
+		}
+	}
+	if len(resent) != 2 {
+		t.Errorf("info request sent %d times, want 2", len(resent))
+	}
+}
+
+func TestPostClientFailsPendingRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *ReconnectPolicy
+		state  ConnState
+	}{
+		{"no reconnect", nil, StateDisconnected},
+		{"reconnect", fastReconnect, StateConnected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, dropAfterPosts(1, nil))
+			client := NewPostOnlyClient()
+			client.SetURL(server.URL())
+			client.SetReconnectPolicy(tt.policy)
+			connected := make(chan struct{}, 2)
+			client.OnConnect(func() { connected <- struct{}{} })
+			if err := client.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			defer client.Close()
+
+			waiter, err := client.Request(PostRequestTypeInfo, map[string]any{"type": "allMids"})
+			if err != nil {
+				t.Fatalf("Request() error = %v", err)
+			}
+			if resp := awaitResponse(t, waiter); !errors.Is(resp.Err, ErrConnectionLost) {
+				t.Errorf("response Err = %v, want ErrConnectionLost", resp.Err)
+			}
+
+			if tt.policy != nil {
+				// OnConnect runs for Start and for the reconnection
+				for range 2 {
+					select {
+					case <-connected:
+					case <-time.After(2 * time.Second):
+						t.Fatal("client did not reconnect")
+					}
+				}
+			}
+			if got := client.State(); got != tt.state {
+				t.Errorf("State() = %v, want %v", got, tt.state)
+			}
+		})
+	}
+}
+
+func TestPostClientRestart(t *testing.T) {
+	server := newTestServer(t, dropAfterPosts(0, nil))
+	client := NewPostOnlyClient()
+	client.SetURL(server.URL())
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Close()
+
+	// Starting a connected client is a no-op
+	if err := client.Start(); err != nil {
+		t.Fatalf("second Start() error = %v", err)
+	}
+	if n := server.Connections(); n != 1 {
+		t.Errorf("%d connections after two Start calls, want 1", n)
+	}
+
+	client.Close()
+	if got := client.State(); got != StateClosed {
+		t.Errorf("State() after Close = %v, want %v", got, StateClosed)
+	}
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() after Close error = %v", err)
+	}
+	if got := client.State(); got != StateConnected {
+		t.Errorf("State() after restart = %v, want %v", got, StateConnected)
+	}
+
+	// The second connection answers info requests
+	resp, err := PostInfo[map[string]string](client, 2*time.Second, map[string]any{"type": "allMids"})
+	if err != nil {
+		t.Fatalf("PostInfo() after restart error = %v", err)
+	}
+	if resp["BTC"] != "1" {
+		t.Errorf("PostInfo() = %v", resp)
+	}
+}
+
+func TestPostClientTracesRequests(t *testing.T) {
+	server := newTestServer(t, func(conn *websocket.Conn, index int) {
+		for {
+			var req testPostRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method != "post" {
+				continue
+			}
+			responseType := "info"
+			if req.Request.Type == "action" {
+				responseType = "error"
+			}
+			conn.WriteJSON(map[string]any{
+				"channel": "post",
+				"data": map[string]any{
+					"id":       req.ID,
+					"response": map[string]any{"type": responseType, "payload": map[string]any{}},
+				},
+			})
+		}
+	})
+	recorder := tracetest.NewSpanRecorder()
+	client := NewPostOnlyClient()
+	client.SetURL(server.URL())
+	client.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Close()
+
+	for _, reqType := range []PostRequestType{PostRequestTypeInfo, PostRequestTypeAction} {
+		waiter, err := client.Request(reqType, map[string]any{"type": "allMids"})
+		if err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		awaitResponse(t, waiter)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	tests := []struct {
+		reqType string
+		status  codes.Code
+	}{
+		{"info", codes.Unset},
+		{"action", codes.Error},
+	}
+	for i, tt := range tests {
+		s := spans[i]
+		attrs := attribute.NewSet(s.Attributes()...)
+		id, _ := attrs.Value(tracing.WsRequestIDKey)
+		reqType, _ := attrs.Value(tracing.WsRequestTypeKey)
+		protocol, _ := attrs.Value("network.protocol.name")
+		if s.Name() != tracing.SpanWsPost || s.SpanKind() != trace.SpanKindClient || s.Status().Code != tt.status ||
+			id.AsInt64() != int64(i+1) || reqType.AsString() != tt.reqType || protocol.AsString() != "websocket" {
+			t.Errorf("span %d = %s %v %+v %v", i, s.Name(), s.SpanKind(), s.Status(), s.Attributes())
+		}
+	}
+}